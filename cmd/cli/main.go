@@ -17,18 +17,36 @@ package main
 
 import (
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/urfave/cli"
 
 	_cli "github.com/apiclarity/speculator/pkg/cli"
+	"github.com/apiclarity/speculator/pkg/spec"
 )
 
 func run(c *cli.Context) {
 	_cli.Run(c)
 }
 
+func runProxy(c *cli.Context) {
+	_cli.RunProxy(c)
+}
+
+func runBatch(c *cli.Context) {
+	_cli.RunBatch(c)
+}
+
+func runExport(c *cli.Context) {
+	_cli.RunExport(c)
+}
+
+func runPathTrie(c *cli.Context) {
+	_cli.RunPathTrie(c)
+}
+
 func main() {
 	viper.AutomaticEnv()
 
@@ -46,6 +64,26 @@ func main() {
 				Name:  "t",
 				Usage: "path to a telemetry json file (can be ran with multiple files, e.g. -t file1.json -t file2.json)",
 			},
+			cli.StringSliceFlag{
+				Name:  "har",
+				Usage: "path to a HAR file exported by browser devtools or a proxy (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "envoy-tap",
+				Usage: "path to an Envoy tap trace json file (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "envoy-access-log",
+				Usage: "path to an Envoy json access log file, see envoy.AccessLogEntry for the expected format (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "pcap",
+				Usage: "path to a pcap/pcapng packet capture of plain HTTP/1.x traffic (can be ran with multiple files)",
+			},
+			cli.StringFlag{
+				Name:  "config",
+				Usage: "path to a YAML/JSON speculator config file (overrides the env variables)",
+			},
 			cli.StringFlag{
 				Name:  "state",
 				Usage: "path to an encoded speculator state file",
@@ -58,8 +96,183 @@ func main() {
 	}
 	runCommand.UsageText = runCommand.Name
 
+	proxyCommand := cli.Command{
+		Name:   "proxy",
+		Usage:  "Reverse proxy that learns the OAS of the traffic forwarded to an upstream",
+		Action: runProxy,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "upstream",
+				Usage: "url of the upstream to forward the traffic to (e.g. http://localhost:8080)",
+			},
+			cli.StringFlag{
+				Name:  "listen",
+				Usage: "address to listen on",
+				Value: ":8000",
+			},
+			cli.StringFlag{
+				Name:  "management-listen",
+				Usage: "address to serve the management API (/healthz, /readyz) on, disabled when empty",
+			},
+			cli.BoolFlag{
+				Name:  "diff",
+				Usage: "diff the forwarded traffic against the approved spec",
+			},
+			cli.Int64Flag{
+				Name:  "max-body-size",
+				Usage: "maximum request/response body bytes to capture",
+				Value: 1 << 20,
+			},
+			cli.StringFlag{
+				Name:  "config",
+				Usage: "path to a YAML/JSON speculator config file (overrides the env variables)",
+			},
+			cli.StringFlag{
+				Name:  "state",
+				Usage: "path to an encoded speculator state file",
+			},
+			cli.StringFlag{
+				Name:  "save",
+				Usage: "save speculator state to a given path on exit",
+			},
+		},
+	}
+	proxyCommand.UsageText = proxyCommand.Name
+
+	batchCommand := cli.Command{
+		Name:   "batch",
+		Usage:  "Offline OAS generation from bulk telemetry files (newline delimited JSON telemetries, optionally gzip compressed)",
+		Action: runBatch,
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "f",
+				Usage: "path to a bulk telemetry file, gzip compressed if it has a .gz extension (can be ran with multiple files)",
+			},
+			cli.IntFlag{
+				Name:  "workers",
+				Usage: "amount of records decoded in parallel, defaults to the number of CPUs",
+			},
+			cli.DurationFlag{
+				Name:  "progress-interval",
+				Usage: "interval to report the processing progress at",
+				Value: 10 * time.Second,
+			},
+			cli.DurationFlag{
+				Name:  "checkpoint-interval",
+				Usage: "interval to save the state and a checkpoint (next to the save path) at, so an aborted job can be resumed. disabled when 0",
+			},
+			cli.BoolFlag{
+				Name:  "resume",
+				Usage: "resume an aborted job from the state and checkpoint of the save path, with the same files",
+			},
+			cli.StringFlag{
+				Name:  "config",
+				Usage: "path to a YAML/JSON speculator config file (overrides the env variables)",
+			},
+			cli.StringFlag{
+				Name:  "state",
+				Usage: "path to an encoded speculator state file",
+			},
+			cli.StringFlag{
+				Name:  "save",
+				Usage: "save speculator state to a given path after learning",
+			},
+		},
+	}
+	batchCommand.UsageText = batchCommand.Name
+
+	exportCommand := cli.Command{
+		Name:   "export",
+		Usage:  "Export telemetries with their sensitive values redacted into a bulk telemetry file, e.g. to share a dataset reproducing a learning issue",
+		Action: runExport,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "o",
+				Usage: "path of the bulk telemetry file to write, gzip compressed if it has a .gz extension",
+			},
+			cli.StringSliceFlag{
+				Name:  "t",
+				Usage: "path to a telemetry json file (can be ran with multiple files, e.g. -t file1.json -t file2.json)",
+			},
+			cli.StringSliceFlag{
+				Name:  "har",
+				Usage: "path to a HAR file exported by browser devtools or a proxy (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "envoy-tap",
+				Usage: "path to an Envoy tap trace json file (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "envoy-access-log",
+				Usage: "path to an Envoy json access log file, see envoy.AccessLogEntry for the expected format (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "pcap",
+				Usage: "path to a pcap/pcapng packet capture of plain HTTP/1.x traffic (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "f",
+				Usage: "path to a bulk telemetry file, gzip compressed if it has a .gz extension (can be ran with multiple files)",
+			},
+			cli.StringSliceFlag{
+				Name:  "redact",
+				Usage: "additional (case insensitive) name part of the headers, params and body fields to redact (can be ran with multiple names)",
+			},
+			cli.IntFlag{
+				Name:  "max-body-size",
+				Usage: "bodies are truncated to this size (in bytes), not truncated when 0",
+				Value: 4096,
+			},
+		},
+	}
+	exportCommand.UsageText = exportCommand.Name
+
+	pathTrieCommand := cli.Command{
+		Name:   "path-trie",
+		Usage:  "Export the path trie of a spec of an encoded state as Graphviz DOT or a JSON tree, to inspect how its paths were parameterized",
+		Action: runPathTrie,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "state",
+				Usage: "path to an encoded speculator state file",
+			},
+			cli.StringFlag{
+				Name:  "host",
+				Usage: "host of the spec",
+			},
+			cli.StringFlag{
+				Name:  "port",
+				Usage: "port of the spec",
+				Value: "80",
+			},
+			cli.StringFlag{
+				Name:  "kind",
+				Usage: "path trie to export: approved, provided or learning",
+				Value: string(spec.PathTrieKindLearning),
+			},
+			cli.StringFlag{
+				Name:  "format",
+				Usage: "output format: dot or json",
+				Value: string(spec.PathTrieFormatDOT),
+			},
+			cli.StringFlag{
+				Name:  "o",
+				Usage: "path of the file to write, stdout when empty",
+			},
+			cli.StringFlag{
+				Name:  "config",
+				Usage: "path to a YAML/JSON speculator config file (overrides the env variables)",
+			},
+		},
+	}
+	pathTrieCommand.UsageText = pathTrieCommand.Name
+
 	app.Commands = []cli.Command{
 		runCommand,
+		proxyCommand,
+		batchCommand,
+		exportCommand,
+		pathTrieCommand,
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -0,0 +1,144 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/apiclarity/speculator/pkg/speculator"
+	"github.com/apiclarity/speculator/pkg/telemetry/batch"
+)
+
+// the checkpoint of a batch job is saved next to its state
+const checkpointFileSuffix = ".checkpoint"
+
+func RunBatch(c *cli.Context) {
+	statePath := c.String("state")
+	savePath := c.String("save")
+	checkpointPath := savePath + checkpointFileSuffix
+	checkpointInterval := c.Duration("checkpoint-interval")
+	if (checkpointInterval > 0 || c.Bool("resume")) && savePath == "" {
+		log.Fatalf("Checkpointing requires a save path")
+	}
+
+	var resume *batch.Checkpoint
+	if c.Bool("resume") {
+		var err error
+		resume, err = batch.LoadCheckpoint(checkpointPath)
+		if err != nil {
+			log.Fatalf("Failed to load checkpoint: %v", err)
+		}
+		// the saved state is consistent with the checkpoint
+		statePath = savePath
+	}
+	var s *speculator.Speculator
+
+	speculatorConfig := createSpeculatorConfig(c)
+	if statePath != "" {
+		var err error
+		s, err = speculator.DecodeStateFile(statePath, speculatorConfig)
+		if err != nil {
+			log.Fatalf("Failed to decode stored state in path %v", statePath)
+		}
+	} else {
+		s = speculator.CreateSpeculator(speculatorConfig)
+	}
+
+	// stop processing on interrupt, keeping what was learned so far
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		log.Infof("Interrupted, stopping batch processing")
+		cancel()
+	}()
+
+	fileNames := c.StringSlice("f")
+	if resume != nil {
+		fileNames = getFilesToResume(fileNames, resume.File)
+		if fileNames == nil {
+			log.Fatalf("The checkpoint file %v is not one of the processed files", resume.File)
+		}
+	}
+
+	config := batch.Config{
+		Workers:            c.Int("workers"),
+		ProgressInterval:   c.Duration("progress-interval"),
+		CheckpointInterval: checkpointInterval,
+	}
+	if checkpointInterval > 0 {
+		config.OnCheckpoint = func(checkpoint batch.Checkpoint) error {
+			if err := s.EncodeStateFile(savePath); err != nil {
+				return fmt.Errorf("failed to encode speculator: %v", err)
+			}
+			return batch.SaveCheckpoint(checkpointPath, checkpoint)
+		}
+	}
+	failed := false
+	for i, fileName := range fileNames {
+		log.Infof("Processing bulk telemetry file %s", fileName)
+		config.OnProgress = func(progress batch.Progress) {
+			log.Infof("%s: processed %v records (%v learned, %v failed), %v bytes in %v",
+				fileName, progress.Records, progress.Learned, progress.Failed, progress.BytesRead, progress.Elapsed)
+		}
+		config.Resume = nil
+		if i == 0 {
+			config.Resume = resume
+		}
+		if _, err := batch.ProcessFile(ctx, fileName, s, config); err != nil {
+			log.Errorf("Failed to process bulk telemetry file %s: %v", fileName, err)
+			failed = true
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	log.Infof("Generating specs")
+	s.DumpSpecs()
+	if savePath != "" {
+		if err := s.EncodeStateFile(savePath); err != nil {
+			log.Fatalf("Failed to encode speculator: %v", err)
+		}
+	}
+	// a completed job can't be resumed
+	if config.OnCheckpoint != nil && !failed && ctx.Err() == nil {
+		if err := os.Remove(checkpointPath); err != nil && !os.IsNotExist(err) {
+			log.Errorf("Failed to remove checkpoint: %v", err)
+		}
+	} else if config.OnCheckpoint != nil {
+		log.Infof("Batch processing can be resumed from %v with the resume flag", checkpointPath)
+	}
+}
+
+// getFilesToResume returns the files from the checkpoint file onwards, or nil if it is not one of fileNames.
+func getFilesToResume(fileNames []string, checkpointFile string) []string {
+	for i, fileName := range fileNames {
+		if fileName == checkpointFile {
+			return fileNames[i:]
+		}
+	}
+	return nil
+}
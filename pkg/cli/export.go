@@ -0,0 +1,127 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/telemetry/batch"
+	"github.com/apiclarity/speculator/pkg/telemetry/envoy"
+	"github.com/apiclarity/speculator/pkg/telemetry/har"
+	"github.com/apiclarity/speculator/pkg/telemetry/pcap"
+)
+
+// exportLearner exports the telemetries of a bulk telemetry file processed by batch.ProcessFile.
+type exportLearner struct {
+	exporter *batch.Exporter
+}
+
+func (l *exportLearner) LearnTelemetry(telemetry *spec.Telemetry) error {
+	return l.exporter.Export(telemetry)
+}
+
+func RunExport(c *cli.Context) {
+	outputPath := c.String("o")
+	if outputPath == "" {
+		log.Fatalf("An output path is required")
+	}
+	policy := spec.DefaultRedactionPolicy()
+	policy.SensitiveNames = append(append([]string{}, policy.SensitiveNames...), c.StringSlice("redact")...)
+	policy.MaxBodySize = c.Int("max-body-size")
+
+	exporter, err := batch.CreateExporter(outputPath, policy)
+	if err != nil {
+		log.Fatalf("Failed to create exporter: %v", err)
+	}
+	exportCapture := func(telemetries []*spec.Telemetry, fileName string) {
+		for _, telemetry := range telemetries {
+			if err := exporter.Export(telemetry); err != nil {
+				log.Errorf("Failed to export telemetry from %s. %v", fileName, err)
+				return
+			}
+		}
+		log.Infof("Exported %v telemetries from %s", len(telemetries), fileName)
+	}
+
+	for _, fileName := range c.StringSlice("t") {
+		telemetryB, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			log.Errorf("Failed to read from file: %v. %v", fileName, err)
+			continue
+		}
+		telemetry, err := spec.DecodeTelemetry(telemetryB)
+		if err != nil {
+			log.Errorf("Failed to unmarshal telemetry. %v", err)
+			continue
+		}
+		exportCapture([]*spec.Telemetry{telemetry}, fileName)
+	}
+	for _, fileName := range c.StringSlice("har") {
+		telemetries, err := har.LoadFile(fileName)
+		if err != nil {
+			log.Errorf("Failed to load HAR file. %v", err)
+			continue
+		}
+		exportCapture(telemetries, fileName)
+	}
+	for _, fileName := range c.StringSlice("envoy-tap") {
+		traceB, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			log.Errorf("Failed to read from file: %v. %v", fileName, err)
+			continue
+		}
+		telemetry, err := envoy.DecodeTapTrace(traceB)
+		if err != nil {
+			log.Errorf("Failed to decode tap trace. %v", err)
+			continue
+		}
+		exportCapture([]*spec.Telemetry{telemetry}, fileName)
+	}
+	for _, fileName := range c.StringSlice("envoy-access-log") {
+		telemetries, err := loadEnvoyAccessLog(fileName)
+		if err != nil {
+			log.Errorf("Failed to load access log. %v", err)
+			continue
+		}
+		exportCapture(telemetries, fileName)
+	}
+	for _, fileName := range c.StringSlice("pcap") {
+		telemetries, err := pcap.LoadFile(fileName)
+		if err != nil {
+			log.Errorf("Failed to load packet capture. %v", err)
+			continue
+		}
+		exportCapture(telemetries, fileName)
+	}
+	for _, fileName := range c.StringSlice("f") {
+		progress, err := batch.ProcessFile(context.Background(), fileName, &exportLearner{exporter: exporter}, batch.Config{})
+		if err != nil {
+			log.Errorf("Failed to export bulk telemetry file %s: %v", fileName, err)
+			continue
+		}
+		log.Infof("Exported %v telemetries from %s (%v failed)", progress.Learned, fileName, progress.Failed)
+	}
+
+	if err := exporter.Close(); err != nil {
+		log.Fatalf("Failed to export telemetries: %v", err)
+	}
+	log.Infof("Exported %v redacted telemetries to %s", exporter.Count(), outputPath)
+}
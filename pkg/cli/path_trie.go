@@ -0,0 +1,57 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/speculator"
+)
+
+// RunPathTrie writes the path trie of a spec of an encoded state, to inspect how its paths were parameterized.
+func RunPathTrie(c *cli.Context) {
+	statePath := c.String("state")
+	if statePath == "" {
+		log.Fatalf("A state path is required")
+	}
+	host := c.String("host")
+	port := c.String("port")
+	if host == "" || port == "" {
+		log.Fatalf("A host and port are required")
+	}
+	s, err := speculator.DecodeStateFile(statePath, createSpeculatorConfig(c))
+	if err != nil {
+		log.Fatalf("Failed to decode stored state in path %v", statePath)
+	}
+
+	output := os.Stdout
+	if outputPath := c.String("o"); outputPath != "" {
+		output, err = os.Create(outputPath)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer output.Close()
+	}
+	kind := spec.PathTrieKind(c.String("kind"))
+	format := spec.PathTrieFormat(c.String("format"))
+	if err := s.ExportPathTrie(speculator.GetSpecKey(host, port), output, kind, format); err != nil {
+		log.Fatalf("Failed to export path trie: %v", err)
+	}
+}
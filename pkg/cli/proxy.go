@@ -0,0 +1,133 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/apiclarity/speculator/pkg/management"
+	"github.com/apiclarity/speculator/pkg/proxy"
+	"github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/speculator"
+)
+
+const shutdownTimeout = 30 * time.Second
+
+func RunProxy(c *cli.Context) {
+	upstream, err := url.Parse(c.String("upstream"))
+	if err != nil || upstream.Host == "" {
+		log.Fatalf("Invalid upstream url: %v", c.String("upstream"))
+	}
+
+	var s *speculator.Speculator
+	speculatorConfig := createSpeculatorConfig(c)
+	if savePath := c.String("save"); savePath != "" {
+		speculatorConfig.StateStore = speculator.NewFileStateStore(savePath)
+	}
+	if statePath := c.String("state"); statePath != "" {
+		s, err = speculator.DecodeStateFile(statePath, speculatorConfig)
+		if err != nil {
+			log.Fatalf("Failed to decode stored state in path %v", statePath)
+		}
+	} else {
+		s = speculator.CreateSpeculator(speculatorConfig)
+	}
+
+	p, err := proxy.New(s, proxy.Config{
+		Upstream:    upstream,
+		Learn:       true,
+		Diff:        c.Bool("diff"),
+		DiffSource:  spec.DiffSourceReconstructed,
+		MaxBodySize: c.Int64("max-body-size"),
+		OnDiff: func(diff *spec.APIDiff) {
+			if diff.Type != spec.DiffTypeNoDiff {
+				log.Infof("Found API diff. type=%v, path=%v", diff.Type, diff.Path)
+			}
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create proxy: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:    c.String("listen"),
+		Handler: p,
+	}
+
+	go func() {
+		log.Infof("Proxying %v to %v", server.Addr, upstream)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to serve proxy: %v", err)
+		}
+	}()
+
+	var managementServer *http.Server
+	if managementAddr := c.String("management-listen"); managementAddr != "" {
+		managementServer = &http.Server{
+			Addr:    managementAddr,
+			Handler: management.NewServer(s),
+		}
+		go func() {
+			log.Infof("Serving management API on %v", managementServer.Addr)
+			if err := managementServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to serve management API: %v", err)
+			}
+		}()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for received := range sig {
+		if received != syscall.SIGHUP {
+			break
+		}
+		if c.String("config") == "" {
+			log.Warnf("Ignoring SIGHUP, no config file to reload")
+			continue
+		}
+		config, err := speculator.LoadConfig(c.String("config"))
+		if err != nil {
+			log.Errorf("Failed to reload config, keeping the current config: %v", err)
+			continue
+		}
+		p.ReloadSpeculatorConfig(config)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Errorf("Failed to shutdown proxy: %v", err)
+	}
+
+	s.DumpSpecs()
+	if err := s.Shutdown(ctx); err != nil {
+		log.Fatalf("Failed to shutdown speculator: %v", err)
+	}
+	if managementServer != nil {
+		if err := managementServer.Shutdown(ctx); err != nil {
+			log.Errorf("Failed to shutdown management API: %v", err)
+		}
+	}
+}
@@ -16,8 +16,9 @@
 package cli
 
 import (
-	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"os"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -25,16 +26,19 @@ import (
 
 	"github.com/apiclarity/speculator/pkg/spec"
 	"github.com/apiclarity/speculator/pkg/speculator"
+	"github.com/apiclarity/speculator/pkg/telemetry/envoy"
+	"github.com/apiclarity/speculator/pkg/telemetry/har"
+	"github.com/apiclarity/speculator/pkg/telemetry/pcap"
 )
 
 func Run(c *cli.Context) {
 	statePath := c.String("state")
 	var s *speculator.Speculator
 
-	speculatorConfig := createSpeculatorConfig()
+	speculatorConfig := createSpeculatorConfig(c)
 	if statePath != "" {
 		var err error
-		s, err = speculator.DecodeState(statePath, speculatorConfig)
+		s, err = speculator.DecodeStateFile(statePath, speculatorConfig)
 		if err != nil {
 			log.Fatalf("Failed to decode stored state in path %v", statePath)
 		}
@@ -52,8 +56,7 @@ func Run(c *cli.Context) {
 			log.Errorf("Failed to read from file: %v. %v", fileName, err)
 			continue
 		}
-		telemetry := &spec.Telemetry{}
-		err = json.Unmarshal(telemetryB, telemetry)
+		telemetry, err := spec.DecodeTelemetry(telemetryB)
 		if err != nil {
 			log.Errorf("Failed to unmarshal telemetry. %v", err)
 			continue
@@ -66,20 +69,105 @@ func Run(c *cli.Context) {
 		}
 		log.Infof("Learned HTTP interaction for %v %v%v", telemetry.Request.Method, telemetry.Request.Host, telemetry.Request.Path)
 	}
+	for _, fileName := range c.StringSlice("har") {
+		log.Infof("Reading HAR from %s", fileName)
+		telemetries, err := har.LoadFile(fileName)
+		if err != nil {
+			log.Errorf("Failed to load HAR file. %v", err)
+			continue
+		}
+		learnCapture(s, telemetries, fileName)
+	}
+	for _, fileName := range c.StringSlice("envoy-tap") {
+		log.Infof("Reading Envoy tap trace from %s", fileName)
+		traceB, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			log.Errorf("Failed to read from file: %v. %v", fileName, err)
+			continue
+		}
+		telemetry, err := envoy.DecodeTapTrace(traceB)
+		if err != nil {
+			log.Errorf("Failed to decode tap trace. %v", err)
+			continue
+		}
+		envoy.Learn(s, []*spec.Telemetry{telemetry})
+	}
+	for _, fileName := range c.StringSlice("envoy-access-log") {
+		log.Infof("Reading Envoy access log from %s", fileName)
+		telemetries, err := loadEnvoyAccessLog(fileName)
+		if err != nil {
+			log.Errorf("Failed to load access log. %v", err)
+			continue
+		}
+		learnCapture(s, telemetries, fileName)
+	}
+	for _, fileName := range c.StringSlice("pcap") {
+		log.Infof("Reading packet capture from %s", fileName)
+		telemetries, err := pcap.LoadFile(fileName)
+		if err != nil {
+			log.Errorf("Failed to load packet capture. %v", err)
+			continue
+		}
+		learnCapture(s, telemetries, fileName)
+	}
 	log.Infof("Generating specs")
 	s.DumpSpecs()
 	if c.String("save") != "" {
-		if err := s.EncodeState(c.String("save")); err != nil {
+		if err := s.EncodeStateFile(c.String("save")); err != nil {
 			log.Fatalf("Failed to encode speculator: %v", err)
 		}
 	}
 }
 
-func createSpeculatorConfig() speculator.Config {
+// learnCapture learns the telemetries of a capture file into the spec of each of its hosts, and logs a summary per host.
+func learnCapture(s *speculator.Speculator, telemetries []*spec.Telemetry, fileName string) {
+	report, err := s.LearnTelemetries(telemetries)
+	if err != nil {
+		log.Errorf("Failed to learn telemetries. %v", err)
+		return
+	}
+	report.Log(fileName)
+}
+
+func loadEnvoyAccessLog(fileName string) ([]*spec.Telemetry, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v. %v", fileName, err)
+	}
+	defer file.Close()
+
+	return envoy.DecodeAccessLog(file)
+}
+
+// createSpeculatorConfig loads the config file given by the config flag, or creates the config from env variables.
+func createSpeculatorConfig(c *cli.Context) speculator.Config {
+	if configPath := c.String("config"); configPath != "" {
+		config, err := speculator.LoadConfig(configPath)
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		return config
+	}
+
+	var sourceClassifier speculator.SourceClassifier
+	internalCIDRs := viper.GetStringSlice("INTERNAL_CIDRS")
+	partnerCIDRs := viper.GetStringSlice("PARTNER_CIDRS")
+	if len(internalCIDRs) > 0 || len(partnerCIDRs) > 0 {
+		var err error
+		sourceClassifier, err = speculator.NewCIDRSourceClassifier(internalCIDRs, partnerCIDRs)
+		if err != nil {
+			log.Fatalf("Failed to create source classifier: %v", err)
+		}
+	}
+
 	return speculator.Config{
 		OperationGeneratorConfig: spec.OperationGeneratorConfig{
 			ResponseHeadersToIgnore: viper.GetStringSlice("RESPONSE_HEADERS_TO_IGNORE"),
 			RequestHeadersToIgnore:  viper.GetStringSlice("REQUEST_HEADERS_TO_IGNORE"),
 		},
+		MaxClockSkew:        viper.GetDuration("MAX_CLOCK_SKEW"),
+		DeduplicationWindow: viper.GetDuration("DEDUPLICATION_WINDOW"),
+		SourceClassifier:    sourceClassifier,
+		SplitSpecsBySource:  viper.GetBool("SPLIT_SPECS_BY_SOURCE"),
 	}
 }
@@ -0,0 +1,126 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpmiddleware
+
+import (
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	// DefaultQueueSize is the default maximum number of captured telemetries waiting to be learned, see LearnerConfig.
+	DefaultQueueSize = 1000
+	// DefaultWorkers is the default number of workers learning the queued telemetries, see LearnerConfig.
+	DefaultWorkers = 1
+)
+
+type LearnerConfig struct {
+	// QueueSize is the maximum number of captured telemetries waiting to be learned, defaults to DefaultQueueSize.
+	QueueSize int
+	// Workers is the number of workers learning the queued telemetries, defaults to DefaultWorkers.
+	// The telemetries of a spec are learned one at a time, more workers only help with the parsing of the bodies.
+	Workers int
+	// BlockWhenFull blocks the handler or client until there is room in the queue, instead of dropping the telemetry.
+	BlockWhenFull bool
+}
+
+// Learner learns the telemetries captured by Middleware and RoundTripper into a spec. The telemetries are queued
+// in a bounded queue that is served by a fixed number of workers, so the memory and goroutines used for learning
+// are bounded under load.
+type Learner struct {
+	spec  *_spec.Spec
+	block bool
+	// lock is held for reading while a telemetry is queued, and for writing by Close
+	lock    sync.RWMutex
+	closed  bool
+	queue   chan *_spec.Telemetry
+	workers sync.WaitGroup
+	dropped uint64
+}
+
+// NewLearner starts the workers that learn the queued telemetries into spec, until Close.
+func NewLearner(spec *_spec.Spec, config LearnerConfig) *Learner {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultWorkers
+	}
+	l := &Learner{
+		spec:  spec,
+		block: config.BlockWhenFull,
+		queue: make(chan *_spec.Telemetry, config.QueueSize),
+	}
+	l.workers.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go l.runWorker()
+	}
+
+	return l
+}
+
+func (l *Learner) runWorker() {
+	defer l.workers.Done()
+
+	for telemetry := range l.queue {
+		if err := l.spec.LearnTelemetry(telemetry); err != nil {
+			log.Errorf("Failed to learn telemetry: %v", err)
+		}
+	}
+}
+
+// learn queues telemetry, it is dropped when the queue is full unless LearnerConfig.BlockWhenFull is set, or
+// when the learner is closed.
+func (l *Learner) learn(telemetry *_spec.Telemetry) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.closed {
+		atomic.AddUint64(&l.dropped, 1)
+		return
+	}
+	if l.block {
+		l.queue <- telemetry
+		return
+	}
+	select {
+	case l.queue <- telemetry:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+		log.Debugf("Dropped telemetry %v, the learning queue is full", telemetry.RequestID)
+	}
+}
+
+// Dropped returns the number of telemetries dropped because the queue was full or the learner was closed.
+func (l *Learner) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+// Close stops queueing telemetries, and waits for the queued telemetries to be learned.
+func (l *Learner) Close() {
+	l.lock.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.queue)
+	}
+	l.lock.Unlock()
+
+	l.workers.Wait()
+}
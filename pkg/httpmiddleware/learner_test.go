@@ -0,0 +1,59 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpmiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestLearner_BlockWhenFull(t *testing.T) {
+	spec := _spec.CreateDefaultSpec("api.example.com", "80", testOperationGeneratorConfig)
+	learner := NewLearner(spec, LearnerConfig{QueueSize: 1, Workers: 2, BlockWhenFull: true})
+	handler := Middleware(learner)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for i := 0; i < 10; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://api.example.com/api/users", nil))
+	}
+
+	// the queued telemetries are learned by Close
+	learner.Close()
+	assert.Equal(t, learner.Dropped(), uint64(0))
+	stats := spec.Stats()
+	assert.Equal(t, stats.Operations["/api/users"][http.MethodGet].HitCount, 10)
+}
+
+func TestLearner_Dropped(t *testing.T) {
+	spec := _spec.CreateDefaultSpec("api.example.com", "80", testOperationGeneratorConfig)
+	// without workers the queue stays full
+	learner := &Learner{
+		spec:  spec,
+		queue: make(chan *_spec.Telemetry, 1),
+	}
+	learner.learn(&_spec.Telemetry{RequestID: "1"})
+	learner.learn(&_spec.Telemetry{RequestID: "2"})
+	assert.Equal(t, learner.Dropped(), uint64(1))
+
+	learner.Close()
+	learner.learn(&_spec.Telemetry{RequestID: "3"})
+	assert.Equal(t, learner.Dropped(), uint64(2))
+}
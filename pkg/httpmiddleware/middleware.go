@@ -0,0 +1,77 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpmiddleware implements a net/http middleware and round tripper that learn the traffic of the wrapped
+// handler/client into a spec, so Go services can learn their own API, or the APIs they call, in process.
+package httpmiddleware
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/internal/httpcapture"
+	_telemetry "github.com/apiclarity/speculator/pkg/telemetry"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+// the maximum request/response body bytes captured per interaction, larger bodies are marked as truncated and are not learned.
+const maxBodySize = 1 << 20 // 1 MB
+
+// Middleware returns a middleware that captures the requests and responses of the wrapped handler and queues them
+// to learner. Learning is done asynchronously after the response was written, so it doesn't delay the handler.
+// Only the part of the request body read by the handler is captured, a request body that was not read to the end
+// is not learned.
+func Middleware(learner *Learner) func(http.Handler) http.Handler {
+	spec := learner.spec
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			capturedAt := time.Now().UTC()
+			reqBody := httpcapture.NewBuffer(maxBodySize)
+			var teeBody *httpcapture.TeeReadCloser
+			if r.Body != nil && r.Body != http.NoBody {
+				teeBody = httpcapture.NewTeeReadCloser(r.Body, maxBodySize)
+				reqBody = teeBody.Buffer
+				r.Body = teeBody
+			}
+			// save the request before the handler modifies it
+			capturedReq := r.Clone(r.Context())
+
+			cw := httpcapture.NewResponseWriter(w, maxBodySize)
+			next.ServeHTTP(cw, r)
+
+			// the rest of the body was not read, so the captured body is partial
+			if teeBody != nil && !teeBody.EOF() {
+				reqBody.Truncated = true
+			}
+
+			capturedReq.Body = reqBody.ReadCloser()
+			telemetry, err := _telemetry.FromHTTP(capturedReq, cw.Response(capturedReq.Proto),
+				_telemetry.WithMaxBodySize(maxBodySize),
+				_telemetry.WithTruncatedBodies(reqBody.Truncated, cw.Buffer.Truncated),
+				_telemetry.WithDestinationAddress(net.JoinHostPort(spec.Host, spec.Port)),
+				_telemetry.WithRequestID(uuid.New().String()),
+				_telemetry.WithTimestamp(capturedAt))
+			if err != nil {
+				log.Errorf("Failed to create telemetry: %v", err)
+				return
+			}
+
+			learner.learn(telemetry)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpmiddleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+var testOperationGeneratorConfig = _spec.OperationGeneratorConfig{
+	ResponseHeadersToIgnore: []string{},
+	RequestHeadersToIgnore:  []string{},
+}
+
+// waitForOperation waits for the asynchronous learning of the operation of path and method, the learned spec
+// can be read once it returns.
+func waitForOperation(t *testing.T, spec *_spec.Spec, path, method string) *_spec.OperationStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		stats := spec.Stats()
+		if opStats, ok := stats.Operations[path][method]; ok {
+			return opStats
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("operation %v %v was not learned", method, path)
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	spec := _spec.CreateDefaultSpec("api.example.com", "80", testOperationGeneratorConfig)
+	learner := NewLearner(spec, LearnerConfig{})
+	defer learner.Close()
+	handler := Middleware(learner)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, string(body), `{"name":"foo"}`)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://api.example.com/api/users?foo=bar", strings.NewReader(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	// response is written as is
+	assert.Equal(t, w.Code, http.StatusCreated)
+	assert.Equal(t, w.Body.String(), `{"id":1}`)
+
+	waitForOperation(t, spec, "/api/users", http.MethodPost)
+	op := _spec.GetOperationFromPathItem(spec.LearningSpec.GetPathItem("/api/users"), http.MethodPost)
+	assert.Assert(t, op != nil)
+	assert.Assert(t, op.Responses.StatusCodeResponses[http.StatusCreated].Schema != nil)
+	hasBodyParam := false
+	for _, param := range op.Parameters {
+		if param.In == "body" {
+			hasBodyParam = true
+		}
+	}
+	assert.Assert(t, hasBodyParam)
+}
+
+func TestMiddleware_UnreadRequestBody(t *testing.T) {
+	spec := _spec.CreateDefaultSpec("api.example.com", "80", testOperationGeneratorConfig)
+	learner := NewLearner(spec, LearnerConfig{})
+	defer learner.Close()
+	handler := Middleware(learner)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "http://api.example.com/api/users", strings.NewReader(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the body the handler didn't read is not captured
+	waitForOperation(t, spec, "/api/users", http.MethodPut)
+	op := _spec.GetOperationFromPathItem(spec.LearningSpec.GetPathItem("/api/users"), http.MethodPut)
+	assert.Assert(t, op != nil)
+	for _, param := range op.Parameters {
+		assert.Assert(t, param.In != "body")
+	}
+}
+
+func TestMiddleware_TruncatedBody(t *testing.T) {
+	largeBody := `{"name":"` + strings.Repeat("a", maxBodySize) + `"}`
+	spec := _spec.CreateDefaultSpec("api.example.com", "80", testOperationGeneratorConfig)
+	learner := NewLearner(spec, LearnerConfig{})
+	defer learner.Close()
+	handler := Middleware(learner)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(largeBody))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://api.example.com/api/users", nil))
+
+	// the client gets the full body even though it was not captured
+	assert.Equal(t, w.Body.String(), largeBody)
+
+	waitForOperation(t, spec, "/api/users", http.MethodGet)
+	op := _spec.GetOperationFromPathItem(spec.LearningSpec.GetPathItem("/api/users"), http.MethodGet)
+	assert.Assert(t, op != nil)
+	assert.Assert(t, op.Responses.StatusCodeResponses[http.StatusOK].Schema == nil)
+}
@@ -0,0 +1,108 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpmiddleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/internal/httpcapture"
+	_telemetry "github.com/apiclarity/speculator/pkg/telemetry"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+type recordingRoundTripper struct {
+	learner *Learner
+	next    http.RoundTripper
+}
+
+// RoundTripper returns a round tripper that records the requests sent with next (http.DefaultTransport if nil)
+// and their responses, and queues them to learner. An interaction is queued once its response body is closed,
+// a response body that was not read to the end is not learned.
+func RoundTripper(learner *Learner, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingRoundTripper{
+		learner: learner,
+		next:    next,
+	}
+}
+
+func (rt *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	capturedAt := time.Now().UTC()
+	reqBody := httpcapture.NewBuffer(maxBodySize)
+	// a round tripper must not modify the request
+	if req.Body != nil && req.Body != http.NoBody {
+		teeBody := httpcapture.NewTeeReadCloser(req.Body, maxBodySize)
+		reqBody = teeBody.Buffer
+		req = req.Clone(req.Context())
+		req.Body = teeBody
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	requestID := uuid.New().String()
+	resp.Body = &recordingBody{
+		TeeReadCloser: httpcapture.NewTeeReadCloser(resp.Body, maxBodySize),
+		onClose: func(respBody *httpcapture.Buffer) {
+			// the transport is done with the request body once the response body is closed
+			capturedReq := req.Clone(req.Context())
+			capturedReq.Body = reqBody.ReadCloser()
+			capturedReq.GetBody = nil
+			capturedResp := *resp
+			capturedResp.Body = respBody.ReadCloser()
+			telemetry, err := _telemetry.FromHTTP(capturedReq, &capturedResp,
+				_telemetry.WithMaxBodySize(maxBodySize),
+				_telemetry.WithTruncatedBodies(reqBody.Truncated, respBody.Truncated),
+				_telemetry.WithDestinationAddress(httpcapture.AddressWithPort(req.URL.Host, req.URL.Scheme)),
+				_telemetry.WithRequestID(requestID),
+				_telemetry.WithTimestamp(capturedAt))
+			if err != nil {
+				log.Errorf("Failed to create telemetry: %v", err)
+				return
+			}
+			rt.learner.learn(telemetry)
+		},
+	}
+
+	return resp, nil
+}
+
+// recordingBody captures a response body while it is read and calls onClose with it once closed.
+type recordingBody struct {
+	*httpcapture.TeeReadCloser
+	once    sync.Once
+	onClose func(body *httpcapture.Buffer)
+}
+
+func (r *recordingBody) Close() error {
+	err := r.TeeReadCloser.Close()
+	r.once.Do(func() {
+		// the rest of the body was not read, so the captured body is partial
+		if !r.EOF() {
+			r.Buffer.Truncated = true
+		}
+		r.onClose(r.Buffer)
+	})
+	return err
+}
@@ -0,0 +1,100 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpmiddleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, string(body), `{"name":"foo"}`)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NilError(t, err)
+
+	spec := _spec.CreateDefaultSpec(serverURL.Hostname(), serverURL.Port(), testOperationGeneratorConfig)
+	learner := NewLearner(spec, LearnerConfig{})
+	defer learner.Close()
+	client := &http.Client{Transport: RoundTripper(learner, nil)}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/users?foo=bar", strings.NewReader(`{"name":"foo"}`))
+	assert.NilError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	reqBody := req.Body
+	resp, err := client.Do(req)
+	assert.NilError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.NilError(t, resp.Body.Close())
+
+	// the response is returned as is, and the request is not modified
+	assert.Equal(t, resp.StatusCode, http.StatusCreated)
+	assert.Equal(t, string(body), `{"id":1}`)
+	assert.Equal(t, req.Body, reqBody)
+
+	waitForOperation(t, spec, "/api/users", http.MethodPost)
+	op := _spec.GetOperationFromPathItem(spec.LearningSpec.GetPathItem("/api/users"), http.MethodPost)
+	assert.Assert(t, op != nil)
+	assert.Assert(t, op.Responses.StatusCodeResponses[http.StatusCreated].Schema != nil)
+	hasBodyParam := false
+	for _, param := range op.Parameters {
+		if param.In == "body" {
+			hasBodyParam = true
+		}
+	}
+	assert.Assert(t, hasBodyParam)
+}
+
+func TestRoundTripper_PartiallyReadBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"name":"foo"}`))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NilError(t, err)
+
+	spec := _spec.CreateDefaultSpec(serverURL.Hostname(), serverURL.Port(), testOperationGeneratorConfig)
+	learner := NewLearner(spec, LearnerConfig{})
+	defer learner.Close()
+	client := &http.Client{Transport: RoundTripper(learner, nil)}
+
+	resp, err := client.Get(server.URL + "/api/users")
+	assert.NilError(t, err)
+	_, err = resp.Body.Read(make([]byte, 5))
+	assert.NilError(t, err)
+	assert.NilError(t, resp.Body.Close())
+
+	waitForOperation(t, spec, "/api/users", http.MethodGet)
+	op := _spec.GetOperationFromPathItem(spec.LearningSpec.GetPathItem("/api/users"), http.MethodGet)
+	assert.Assert(t, op != nil)
+	assert.Assert(t, op.Responses.StatusCodeResponses[http.StatusOK].Schema == nil)
+}
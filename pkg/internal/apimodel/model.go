@@ -0,0 +1,150 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apimodel is an API description model independent of the OpenAPI version and library, with converters
+// to go-openapi (swagger 2.0) and kin-openapi (OpenAPI 3.0) documents. Spec.GenerateOAS3Json generates through it.
+package apimodel
+
+type ParameterLocation string
+
+const (
+	ParameterInPath   ParameterLocation = "path"
+	ParameterInQuery  ParameterLocation = "query"
+	ParameterInHeader ParameterLocation = "header"
+	ParameterInCookie ParameterLocation = "cookie"
+)
+
+const (
+	MediaTypeJSON           = "application/json"
+	MediaTypeFormURLEncoded = "application/x-www-form-urlencoded"
+	MediaTypeMultipartForm  = "multipart/form-data"
+)
+
+// Document is an API description.
+type Document struct {
+	Title   string
+	Version string
+	// Host and BasePath form the server of the API, Host may include a port
+	Host     string
+	BasePath string
+	Schemes  []string
+	// Paths are the path items by path, relative to BasePath
+	Paths map[string]*PathItem
+	// Schemas are the schemas referenced by Schema.Ref, by name
+	Schemas         map[string]*Schema
+	SecuritySchemes map[string]*SecurityScheme
+	// Extensions are the vendor extensions of the API info
+	Extensions map[string]interface{}
+}
+
+type PathItem struct {
+	// Parameters are common to all the path item operations
+	Parameters []*Parameter
+	// Operations are by upper case http method
+	Operations map[string]*Operation
+}
+
+type Operation struct {
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	Parameters  []*Parameter
+	RequestBody *RequestBody
+	// Responses are by status code, or "default"
+	Responses  map[string]*Response
+	Security   []map[string][]string
+	Extensions map[string]interface{}
+}
+
+type Parameter struct {
+	Name        string
+	In          ParameterLocation
+	Description string
+	Required    bool
+	// Style and Explode describe the serialization of array values, as in OpenAPI 3.x
+	Style   string
+	Explode bool
+	Schema  *Schema
+}
+
+type RequestBody struct {
+	Description string
+	Required    bool
+	// Content are the body schemas by media type
+	Content map[string]*Schema
+}
+
+type Response struct {
+	Description string
+	Headers     map[string]*Schema
+	// Content are the body schemas by media type, a response without a body has no content
+	Content map[string]*Schema
+}
+
+// Schema is a JSON schema. A schema with Ref set is a reference to the Document.Schemas schema named Ref, and has no
+// other fields.
+type Schema struct {
+	Ref                  string
+	Type                 string
+	Format               string
+	Description          string
+	Nullable             bool
+	Enum                 []interface{}
+	Pattern              string
+	Default              interface{}
+	Example              interface{}
+	Items                *Schema
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *Schema
+	AnyOf                []*Schema
+	Extensions           map[string]interface{}
+}
+
+type SecuritySchemeType string
+
+const (
+	SecuritySchemeAPIKey SecuritySchemeType = "apiKey"
+	SecuritySchemeHTTP   SecuritySchemeType = "http"
+	SecuritySchemeOAuth2 SecuritySchemeType = "oauth2"
+)
+
+// OAuth flow names, as in OpenAPI 3.x.
+const (
+	OAuthFlowImplicit          = "implicit"
+	OAuthFlowPassword          = "password"
+	OAuthFlowClientCredentials = "clientCredentials"
+	OAuthFlowAuthorizationCode = "authorizationCode"
+)
+
+type SecurityScheme struct {
+	Type        SecuritySchemeType
+	Description string
+	// Name and In are the api key parameter
+	Name string
+	In   ParameterLocation
+	// Scheme is the http authorization scheme, e.g. basic
+	Scheme string
+	// Flows are the oauth2 flows by name, e.g. OAuthFlowImplicit
+	Flows map[string]*OAuthFlow
+}
+
+type OAuthFlow struct {
+	AuthorizationURL string
+	TokenURL         string
+	Scopes           map[string]string
+}
@@ -0,0 +1,328 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apimodel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	oas3Version          = "3.0.3"
+	oas3SchemasRefPrefix = "#/components/schemas/"
+	oas3DefaultServerURL = "/"
+	oas3DefaultURLScheme = "http"
+)
+
+// component names must match ^[a-zA-Z0-9.\-_]+$
+var invalidComponentNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// MarshalOAS3 converts the document into an OpenAPI 3.0 document (json), validated by kin-openapi.
+func MarshalOAS3(ctx context.Context, doc *Document) ([]byte, error) {
+	ret, err := json.Marshal(ToOAS3(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAPI 3.0 document: %v", err)
+	}
+	// the refs are only resolved by the loader
+	loaded, err := openapi3.NewLoader().LoadFromData(ret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI 3.0 document: %v", err)
+	}
+	if err := loaded.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI 3.0 document: %w", err)
+	}
+	return ret, nil
+}
+
+// ToOAS3 converts the document into a kin-openapi OpenAPI 3.0 document. The schema names that are not valid
+// component names are converted into valid ones.
+func ToOAS3(doc *Document) *openapi3.T {
+	c := &oas3Converter{
+		schemaNames: createComponentSchemaNames(doc.Schemas),
+	}
+	ret := &openapi3.T{
+		OpenAPI: oas3Version,
+		Info: &openapi3.Info{
+			Extensions: copyExtensions(doc.Extensions),
+			Title:      doc.Title,
+			Version:    doc.Version,
+		},
+		Servers: toOAS3Servers(doc),
+		Paths:   make(openapi3.Paths, len(doc.Paths)),
+	}
+
+	for path, pathItem := range doc.Paths {
+		ret.Paths[path] = c.toOAS3PathItem(pathItem)
+	}
+
+	components := &openapi3.Components{}
+	if len(doc.Schemas) > 0 {
+		components.Schemas = make(openapi3.Schemas, len(doc.Schemas))
+		for name, schema := range doc.Schemas {
+			components.Schemas[c.schemaNames[name]] = c.toOAS3Schema(schema)
+		}
+	}
+	if len(doc.SecuritySchemes) > 0 {
+		components.SecuritySchemes = make(openapi3.SecuritySchemes, len(doc.SecuritySchemes))
+		for name, securityScheme := range doc.SecuritySchemes {
+			components.SecuritySchemes[name] = &openapi3.SecuritySchemeRef{Value: toOAS3SecurityScheme(securityScheme)}
+		}
+	}
+	if components.Schemas != nil || components.SecuritySchemes != nil {
+		ret.Components = components
+	}
+
+	return ret
+}
+
+type oas3Converter struct {
+	// schemaNames are the component names by Document.Schemas name
+	schemaNames map[string]string
+}
+
+// createComponentSchemaNames maps the schema names into valid and unique component names.
+func createComponentSchemaNames(schemas map[string]*Schema) map[string]string {
+	ret := make(map[string]string, len(schemas))
+	used := make(map[string]bool, len(schemas))
+	for _, name := range getSortedKeys(schemas) {
+		componentName := invalidComponentNameChars.ReplaceAllString(name, "_")
+		if used[componentName] {
+			counter := 0
+			for used[fmt.Sprintf("%s_%d", componentName, counter)] {
+				counter++
+			}
+			componentName = fmt.Sprintf("%s_%d", componentName, counter)
+		}
+		used[componentName] = true
+		ret[name] = componentName
+	}
+	return ret
+}
+
+func toOAS3Servers(doc *Document) openapi3.Servers {
+	basePath := doc.BasePath
+	if doc.Host == "" {
+		if basePath == "" {
+			basePath = oas3DefaultServerURL
+		}
+		return openapi3.Servers{{URL: basePath}}
+	}
+
+	schemes := doc.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{oas3DefaultURLScheme}
+	}
+	servers := make(openapi3.Servers, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, &openapi3.Server{URL: scheme + "://" + doc.Host + basePath})
+	}
+	return servers
+}
+
+func (c *oas3Converter) toOAS3PathItem(pathItem *PathItem) *openapi3.PathItem {
+	ret := &openapi3.PathItem{
+		Parameters: c.toOAS3Parameters(pathItem.Parameters),
+	}
+	for method, op := range pathItem.Operations {
+		ret.SetOperation(method, c.toOAS3Operation(op))
+	}
+	return ret
+}
+
+func (c *oas3Converter) toOAS3Operation(op *Operation) *openapi3.Operation {
+	ret := &openapi3.Operation{
+		Extensions:  copyExtensions(op.Extensions),
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.OperationID,
+		Parameters:  c.toOAS3Parameters(op.Parameters),
+		Responses:   make(openapi3.Responses, len(op.Responses)),
+		Deprecated:  op.Deprecated,
+	}
+	if len(op.Security) > 0 {
+		security := make(openapi3.SecurityRequirements, 0, len(op.Security))
+		for _, requirement := range op.Security {
+			security = append(security, requirement)
+		}
+		ret.Security = &security
+	}
+	if op.RequestBody != nil {
+		ret.RequestBody = &openapi3.RequestBodyRef{Value: &openapi3.RequestBody{
+			Description: op.RequestBody.Description,
+			Required:    op.RequestBody.Required,
+			Content:     c.toOAS3Content(op.RequestBody.Content),
+		}}
+	}
+	for code, response := range op.Responses {
+		ret.Responses[code] = &openapi3.ResponseRef{Value: c.toOAS3Response(response)}
+	}
+
+	return ret
+}
+
+func (c *oas3Converter) toOAS3Parameters(params []*Parameter) openapi3.Parameters {
+	if len(params) == 0 {
+		return nil
+	}
+	ret := make(openapi3.Parameters, 0, len(params))
+	for _, param := range params {
+		oas3Param := &openapi3.Parameter{
+			Name:        param.Name,
+			In:          string(param.In),
+			Description: param.Description,
+			// path params are always required
+			Required: param.Required || param.In == ParameterInPath,
+		}
+		if param.Style != "" {
+			explode := param.Explode
+			oas3Param.Style = param.Style
+			oas3Param.Explode = &explode
+		}
+		if param.Schema != nil {
+			oas3Param.Schema = c.toOAS3Schema(param.Schema)
+		}
+		ret = append(ret, &openapi3.ParameterRef{Value: oas3Param})
+	}
+	return ret
+}
+
+func (c *oas3Converter) toOAS3Response(response *Response) *openapi3.Response {
+	description := response.Description
+	ret := &openapi3.Response{
+		Description: &description,
+	}
+	if len(response.Headers) > 0 {
+		ret.Headers = make(openapi3.Headers, len(response.Headers))
+		for name, headerSchema := range response.Headers {
+			// the description is of the header
+			schema := *headerSchema
+			schema.Description = ""
+			ret.Headers[name] = &openapi3.HeaderRef{Value: &openapi3.Header{Parameter: openapi3.Parameter{
+				Description: headerSchema.Description,
+				Schema:      c.toOAS3Schema(&schema),
+			}}}
+		}
+	}
+	if len(response.Content) > 0 {
+		ret.Content = c.toOAS3Content(response.Content)
+	}
+	return ret
+}
+
+func (c *oas3Converter) toOAS3Content(content map[string]*Schema) openapi3.Content {
+	ret := make(openapi3.Content, len(content))
+	for mediaType, schema := range content {
+		mediaTypeObject := &openapi3.MediaType{}
+		if schema != nil {
+			mediaTypeObject.Schema = c.toOAS3Schema(schema)
+		}
+		ret[mediaType] = mediaTypeObject
+	}
+	return ret
+}
+
+func (c *oas3Converter) toOAS3Schema(schema *Schema) *openapi3.SchemaRef {
+	if schema.Ref != "" {
+		name, ok := c.schemaNames[schema.Ref]
+		if !ok {
+			name = schema.Ref
+		}
+		return openapi3.NewSchemaRef(oas3SchemasRefPrefix+name, nil)
+	}
+
+	ret := &openapi3.Schema{
+		Extensions:  copyExtensions(schema.Extensions),
+		Format:      schema.Format,
+		Description: schema.Description,
+		Pattern:     schema.Pattern,
+		Nullable:    schema.Nullable,
+		Enum:        schema.Enum,
+		Default:     schema.Default,
+		Example:     schema.Example,
+		Required:    schema.Required,
+	}
+	// OpenAPI 3.0 schemas have a single type
+	if types := strings.Split(schema.Type, ","); len(types) > 1 {
+		for _, tpe := range types {
+			ret.AnyOf = append(ret.AnyOf, openapi3.NewSchemaRef("", &openapi3.Schema{Type: tpe}))
+		}
+	} else {
+		ret.Type = schema.Type
+	}
+	if schema.Items != nil {
+		ret.Items = c.toOAS3Schema(schema.Items)
+	}
+	if len(schema.Properties) > 0 {
+		ret.Properties = make(openapi3.Schemas, len(schema.Properties))
+		for name, property := range schema.Properties {
+			ret.Properties[name] = c.toOAS3Schema(property)
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		ret.AdditionalProperties = openapi3.AdditionalProperties{Schema: c.toOAS3Schema(schema.AdditionalProperties)}
+	}
+	for _, variant := range schema.AnyOf {
+		ret.AnyOf = append(ret.AnyOf, c.toOAS3Schema(variant))
+	}
+
+	return openapi3.NewSchemaRef("", ret)
+}
+
+func toOAS3SecurityScheme(securityScheme *SecurityScheme) *openapi3.SecurityScheme {
+	ret := &openapi3.SecurityScheme{
+		Type:        string(securityScheme.Type),
+		Description: securityScheme.Description,
+	}
+
+	switch securityScheme.Type {
+	case SecuritySchemeAPIKey:
+		ret.Name = securityScheme.Name
+		ret.In = string(securityScheme.In)
+	case SecuritySchemeHTTP:
+		ret.Scheme = securityScheme.Scheme
+	case SecuritySchemeOAuth2:
+		ret.Flows = &openapi3.OAuthFlows{}
+		for name, flow := range securityScheme.Flows {
+			scopes := flow.Scopes
+			if scopes == nil {
+				scopes = map[string]string{}
+			}
+			oas3Flow := &openapi3.OAuthFlow{
+				AuthorizationURL: flow.AuthorizationURL,
+				TokenURL:         flow.TokenURL,
+				Scopes:           scopes,
+			}
+			switch name {
+			case OAuthFlowImplicit:
+				ret.Flows.Implicit = oas3Flow
+			case OAuthFlowPassword:
+				ret.Flows.Password = oas3Flow
+			case OAuthFlowClientCredentials:
+				ret.Flows.ClientCredentials = oas3Flow
+			case OAuthFlowAuthorizationCode:
+				ret.Flows.AuthorizationCode = oas3Flow
+			}
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,190 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apimodel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestMarshalOAS3(t *testing.T) {
+	doc := FromSwagger(loadTestSwagger(t, testSwaggerYaml))
+	oas3JSON, err := MarshalOAS3(context.Background(), doc)
+	assert.NilError(t, err)
+
+	var got map[string]interface{}
+	assert.NilError(t, json.Unmarshal(oas3JSON, &got))
+	var want map[string]interface{}
+	assert.NilError(t, json.Unmarshal([]byte(`{
+  "openapi": "3.0.3",
+  "info": {"title": "test", "version": "1"},
+  "servers": [{"url": "http://host:80/api"}],
+  "paths": {
+    "/avatars": {
+      "post": {
+        "requestBody": {
+          "required": true,
+          "content": {
+            "multipart/form-data": {
+              "schema": {
+                "type": "object",
+                "required": ["file"],
+                "properties": {"caption": {"type": "string"}, "file": {"type": "string", "format": "binary"}}
+              }
+            }
+          }
+        },
+        "responses": {"201": {"description": "created"}}
+      }
+    },
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "pattern": "^u-[0-9]+$"}}],
+      "get": {
+        "operationId": "getUser",
+        "tags": ["users"],
+        "security": [{"key": []}],
+        "x-speculator-hits": 3,
+        "parameters": [
+          {"name": "fields", "in": "query", "style": "form", "explode": true, "schema": {"type": "array", "items": {"type": "string"}}}
+        ],
+        "responses": {
+          "200": {
+            "description": "user",
+            "headers": {"X-Rate-Limit": {"description": "limit", "schema": {"type": "integer"}}},
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}
+          },
+          "default": {"description": "error"}
+        }
+      },
+      "put": {
+        "deprecated": true,
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+        "responses": {"204": {"description": "updated"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "User": {
+        "type": "object",
+        "required": ["id"],
+        "properties": {
+          "id": {"type": "integer", "format": "int64"},
+          "name": {"type": "string", "nullable": true},
+          "tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}}
+        }
+      }
+    },
+    "securitySchemes": {
+      "basic": {"type": "http", "scheme": "basic"},
+      "key": {"type": "apiKey", "name": "X-Key", "in": "header"},
+      "oauth": {
+        "type": "oauth2",
+        "flows": {"authorizationCode": {"authorizationUrl": "http://auth", "tokenUrl": "http://token", "scopes": {"read": "read"}}}
+      }
+    }
+  }
+}`), &want))
+	assert.DeepEqual(t, got, want)
+}
+
+func TestToOAS3_Servers(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *Document
+		want []string
+	}{
+		{
+			name: "no host",
+			doc:  &Document{},
+			want: []string{"/"},
+		},
+		{
+			name: "base path",
+			doc:  &Document{BasePath: "/api"},
+			want: []string{"/api"},
+		},
+		{
+			name: "schemes",
+			doc:  &Document{Host: "host", Schemes: []string{"http", "https"}},
+			want: []string{"http://host", "https://host"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var urls []string
+			for _, server := range ToOAS3(tt.doc).Servers {
+				urls = append(urls, server.URL)
+			}
+			assert.DeepEqual(t, urls, tt.want)
+		})
+	}
+}
+
+func TestToOAS3_MultipleTypes(t *testing.T) {
+	doc := &Document{
+		Paths: map[string]*PathItem{
+			"/a": {Operations: map[string]*Operation{
+				http.MethodGet: {Parameters: []*Parameter{{Name: "id", In: ParameterInPath, Schema: &Schema{Type: "integer,string"}}}},
+			}},
+		},
+	}
+	paramJSON, err := json.Marshal(ToOAS3(doc).Paths["/a"].Get.Parameters[0])
+	assert.NilError(t, err)
+	var param map[string]interface{}
+	assert.NilError(t, json.Unmarshal(paramJSON, &param))
+	assert.DeepEqual(t, param, map[string]interface{}{
+		"name":     "id",
+		"in":       "path",
+		"required": true,
+		"schema": map[string]interface{}{
+			"anyOf": []interface{}{map[string]interface{}{"type": "integer"}, map[string]interface{}{"type": "string"}},
+		},
+	})
+}
+
+func TestToOAS3_ComponentNames(t *testing.T) {
+	doc := &Document{
+		Title:   "test",
+		Version: "1",
+		Paths: map[string]*PathItem{
+			"/a": {Operations: map[string]*Operation{
+				http.MethodGet: {Responses: map[string]*Response{
+					"200": {Description: "ok", Content: map[string]*Schema{MediaTypeJSON: {Ref: "a b"}}},
+					"201": {Description: "ok", Content: map[string]*Schema{MediaTypeJSON: {Ref: "a_b"}}},
+				}},
+			}},
+		},
+		Schemas: map[string]*Schema{
+			"a b": {Type: "string"},
+			"a_b": {Type: "integer"},
+		},
+	}
+	_, err := MarshalOAS3(context.Background(), doc)
+	assert.NilError(t, err)
+
+	oas3 := ToOAS3(doc)
+	// the names are converted by sorted schema name
+	assert.Equal(t, oas3.Components.Schemas["a_b"].Value.Type, "string")
+	assert.Equal(t, oas3.Components.Schemas["a_b_0"].Value.Type, "integer")
+	responses := oas3.Paths["/a"].Get.Responses
+	assert.Equal(t, responses["200"].Value.Content[MediaTypeJSON].Schema.Ref, "#/components/schemas/a_b")
+	assert.Equal(t, responses["201"].Value.Content[MediaTypeJSON].Schema.Ref, "#/components/schemas/a_b_0")
+}
@@ -0,0 +1,748 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apimodel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+)
+
+const (
+	swaggerVersion = "2.0"
+
+	swaggerDefinitionsRefPrefix = "#/definitions/"
+	swaggerNullableExtension    = "x-nullable"
+
+	swaggerParameterInBody     = "body"
+	swaggerParameterInFormData = "formData"
+
+	swaggerCollectionFormatMulti = "multi"
+	swaggerCollectionFormatSSV   = "ssv"
+	swaggerCollectionFormatPipes = "pipes"
+
+	openAPIStyleForm           = "form"
+	openAPIStyleSpaceDelimited = "spaceDelimited"
+	openAPIStylePipeDelimited  = "pipeDelimited"
+
+	responseCodeDefault = "default"
+	schemaTypeObject    = "object"
+	schemaTypeArray     = "array"
+)
+
+// the swagger 2.0 oauth2 flow names by OpenAPI 3.x flow name
+var swaggerOAuthFlows = map[string]string{
+	OAuthFlowImplicit:          "implicit",
+	OAuthFlowPassword:          "password",
+	OAuthFlowClientCredentials: "application",
+	OAuthFlowAuthorizationCode: "accessCode",
+}
+
+// the swagger 2.0 path item operations by method
+var swaggerOperationMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodOptions, http.MethodHead, http.MethodPatch,
+}
+
+// FromSwagger converts a swagger 2.0 spec into a document. Body and form data params are converted into
+// request bodies of the consumed media types, and response schemas into contents of the produced media types.
+func FromSwagger(swagger *oapi_spec.Swagger) *Document {
+	doc := &Document{
+		Host:            swagger.Host,
+		BasePath:        swagger.BasePath,
+		Schemes:         swagger.Schemes,
+		Paths:           make(map[string]*PathItem),
+		Schemas:         make(map[string]*Schema),
+		SecuritySchemes: make(map[string]*SecurityScheme),
+	}
+	if swagger.Info != nil {
+		doc.Title = swagger.Info.Title
+		doc.Version = swagger.Info.Version
+		doc.Extensions = copyExtensions(swagger.Info.Extensions)
+	}
+	for name := range swagger.Definitions {
+		schema := swagger.Definitions[name]
+		doc.Schemas[name] = fromSwaggerSchema(&schema)
+	}
+	for name, securityScheme := range swagger.SecurityDefinitions {
+		doc.SecuritySchemes[name] = fromSwaggerSecurityScheme(securityScheme)
+	}
+	if swagger.Paths != nil {
+		for path := range swagger.Paths.Paths {
+			pathItem := swagger.Paths.Paths[path]
+			doc.Paths[path] = FromSwaggerPathItem(&pathItem, swagger.Consumes, swagger.Produces)
+		}
+	}
+
+	return doc
+}
+
+// FromSwaggerPathItem converts a swagger 2.0 path item, consumes and produces are the spec defaults of its operations.
+func FromSwaggerPathItem(pathItem *oapi_spec.PathItem, consumes, produces []string) *PathItem {
+	ret := &PathItem{
+		Operations: make(map[string]*Operation),
+	}
+
+	// body and form data params are part of the request body of each operation
+	var bodyParams []oapi_spec.Parameter
+	for i := range pathItem.Parameters {
+		param := &pathItem.Parameters[i]
+		if param.In == swaggerParameterInBody || param.In == swaggerParameterInFormData {
+			bodyParams = append(bodyParams, *param)
+			continue
+		}
+		ret.Parameters = append(ret.Parameters, fromSwaggerParameter(param))
+	}
+
+	for _, method := range swaggerOperationMethods {
+		op := getSwaggerOperation(pathItem, method)
+		if op == nil {
+			continue
+		}
+		if len(bodyParams) > 0 {
+			opWithBody := *op
+			opWithBody.Parameters = append(append([]oapi_spec.Parameter{}, bodyParams...), op.Parameters...)
+			op = &opWithBody
+		}
+		ret.Operations[method] = FromSwaggerOperation(op, consumes, produces)
+	}
+
+	return ret
+}
+
+// FromSwaggerOperation converts a swagger 2.0 operation, consumes and produces are used unless the operation
+// overrides them.
+func FromSwaggerOperation(op *oapi_spec.Operation, consumes, produces []string) *Operation {
+	ret := &Operation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+		Responses:   make(map[string]*Response),
+		Security:    op.Security,
+		Extensions:  copyExtensions(op.Extensions),
+	}
+	if len(op.Consumes) > 0 {
+		consumes = op.Consumes
+	}
+	if len(op.Produces) > 0 {
+		produces = op.Produces
+	}
+	if len(produces) == 0 {
+		produces = []string{MediaTypeJSON}
+	}
+
+	var formParams []*oapi_spec.Parameter
+	for i := range op.Parameters {
+		param := &op.Parameters[i]
+		switch param.In {
+		case swaggerParameterInBody:
+			ret.RequestBody = &RequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     make(map[string]*Schema),
+			}
+			bodyConsumes := consumes
+			if len(bodyConsumes) == 0 {
+				bodyConsumes = []string{MediaTypeJSON}
+			}
+			for _, mediaType := range bodyConsumes {
+				ret.RequestBody.Content[mediaType] = fromSwaggerSchema(param.Schema)
+			}
+		case swaggerParameterInFormData:
+			formParams = append(formParams, param)
+		default:
+			ret.Parameters = append(ret.Parameters, fromSwaggerParameter(param))
+		}
+	}
+	if len(formParams) > 0 && ret.RequestBody == nil {
+		ret.RequestBody = fromSwaggerFormParams(formParams, consumes)
+	}
+
+	if op.Responses != nil {
+		if op.Responses.Default != nil {
+			ret.Responses[responseCodeDefault] = fromSwaggerResponse(op.Responses.Default, produces)
+		}
+		for code := range op.Responses.StatusCodeResponses {
+			response := op.Responses.StatusCodeResponses[code]
+			ret.Responses[strconv.Itoa(code)] = fromSwaggerResponse(&response, produces)
+		}
+	}
+
+	return ret
+}
+
+func fromSwaggerFormParams(formParams []*oapi_spec.Parameter, consumes []string) *RequestBody {
+	formSchema := &Schema{
+		Type:       schemaTypeObject,
+		Properties: make(map[string]*Schema),
+	}
+	ret := &RequestBody{
+		Content: make(map[string]*Schema),
+	}
+	for _, param := range formParams {
+		propertySchema := fromSwaggerSimpleSchema(&param.SimpleSchema, &param.CommonValidations)
+		propertySchema.Description = param.Description
+		if propertySchema.Type == "file" {
+			propertySchema.Type = "string"
+			propertySchema.Format = "binary"
+		}
+		formSchema.Properties[param.Name] = propertySchema
+		if param.Required {
+			formSchema.Required = append(formSchema.Required, param.Name)
+			ret.Required = true
+		}
+	}
+
+	for _, mediaType := range consumes {
+		if mediaType == MediaTypeFormURLEncoded || mediaType == MediaTypeMultipartForm {
+			ret.Content[mediaType] = formSchema
+		}
+	}
+	if len(ret.Content) == 0 {
+		ret.Content[MediaTypeFormURLEncoded] = formSchema
+	}
+
+	return ret
+}
+
+func fromSwaggerParameter(param *oapi_spec.Parameter) *Parameter {
+	ret := &Parameter{
+		Name:        param.Name,
+		In:          ParameterLocation(param.In),
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      fromSwaggerSimpleSchema(&param.SimpleSchema, &param.CommonValidations),
+	}
+	if param.Type == schemaTypeArray {
+		ret.Style, ret.Explode = getParameterStyle(ret.In, param.CollectionFormat)
+	}
+
+	return ret
+}
+
+// getParameterStyle returns the OpenAPI 3.x style and explode of an array param in the swagger 2.0 collectionFormat.
+func getParameterStyle(in ParameterLocation, collectionFormat string) (string, bool) {
+	switch collectionFormat {
+	case swaggerCollectionFormatMulti:
+		return openAPIStyleForm, true
+	case swaggerCollectionFormatSSV:
+		return openAPIStyleSpaceDelimited, false
+	case swaggerCollectionFormatPipes:
+		return openAPIStylePipeDelimited, false
+	}
+	// csv, the swagger 2.0 default
+	if in == ParameterInQuery {
+		return openAPIStyleForm, false
+	}
+	return "", false
+}
+
+func fromSwaggerResponse(response *oapi_spec.Response, produces []string) *Response {
+	ret := &Response{
+		Description: response.Description,
+	}
+	for name := range response.Headers {
+		header := response.Headers[name]
+		if ret.Headers == nil {
+			ret.Headers = make(map[string]*Schema)
+		}
+		headerSchema := fromSwaggerSimpleSchema(&header.SimpleSchema, &header.CommonValidations)
+		headerSchema.Description = header.Description
+		ret.Headers[name] = headerSchema
+	}
+	if response.Schema != nil {
+		ret.Content = make(map[string]*Schema)
+		for _, mediaType := range produces {
+			ret.Content[mediaType] = fromSwaggerSchema(response.Schema)
+		}
+	}
+
+	return ret
+}
+
+func fromSwaggerSimpleSchema(simpleSchema *oapi_spec.SimpleSchema, validations *oapi_spec.CommonValidations) *Schema {
+	ret := &Schema{
+		Type:     simpleSchema.Type,
+		Format:   simpleSchema.Format,
+		Nullable: simpleSchema.Nullable,
+		Default:  simpleSchema.Default,
+		Example:  simpleSchema.Example,
+	}
+	if validations != nil {
+		ret.Enum = validations.Enum
+		ret.Pattern = validations.Pattern
+	}
+	if simpleSchema.Items != nil {
+		ret.Items = fromSwaggerSimpleSchema(&simpleSchema.Items.SimpleSchema, &simpleSchema.Items.CommonValidations)
+	}
+
+	return ret
+}
+
+func fromSwaggerSchema(schema *oapi_spec.Schema) *Schema {
+	if schema == nil {
+		return nil
+	}
+	if ref := schema.Ref.String(); ref != "" {
+		return &Schema{Ref: strings.TrimPrefix(ref, swaggerDefinitionsRefPrefix)}
+	}
+
+	ret := &Schema{
+		Type:        strings.Join(schema.Type, ","),
+		Format:      schema.Format,
+		Description: schema.Description,
+		Enum:        schema.Enum,
+		Pattern:     schema.Pattern,
+		Default:     schema.Default,
+		Example:     schema.Example,
+		Required:    schema.Required,
+	}
+	extensions := copyExtensions(schema.Extensions)
+	if nullable, ok := extensions[swaggerNullableExtension].(bool); ok {
+		ret.Nullable = nullable
+		delete(extensions, swaggerNullableExtension)
+	}
+	if len(extensions) > 0 {
+		ret.Extensions = extensions
+	}
+	if schema.Items != nil {
+		ret.Items = fromSwaggerSchema(schema.Items.Schema)
+	}
+	for name := range schema.Properties {
+		property := schema.Properties[name]
+		if ret.Properties == nil {
+			ret.Properties = make(map[string]*Schema)
+		}
+		ret.Properties[name] = fromSwaggerSchema(&property)
+	}
+	if schema.AdditionalProperties != nil {
+		ret.AdditionalProperties = fromSwaggerSchema(schema.AdditionalProperties.Schema)
+	}
+	for i := range schema.AnyOf {
+		ret.AnyOf = append(ret.AnyOf, fromSwaggerSchema(&schema.AnyOf[i]))
+	}
+
+	return ret
+}
+
+func fromSwaggerSecurityScheme(securityScheme *oapi_spec.SecurityScheme) *SecurityScheme {
+	ret := &SecurityScheme{
+		Type:        SecuritySchemeType(securityScheme.Type),
+		Description: securityScheme.Description,
+	}
+
+	switch securityScheme.Type {
+	case "basic":
+		ret.Type = SecuritySchemeHTTP
+		ret.Scheme = "basic"
+	case string(SecuritySchemeAPIKey):
+		ret.Name = securityScheme.Name
+		ret.In = ParameterLocation(securityScheme.In)
+	case string(SecuritySchemeOAuth2):
+		for flowName, swaggerFlowName := range swaggerOAuthFlows {
+			if securityScheme.Flow != swaggerFlowName {
+				continue
+			}
+			ret.Flows = map[string]*OAuthFlow{
+				flowName: {
+					AuthorizationURL: securityScheme.AuthorizationURL,
+					TokenURL:         securityScheme.TokenURL,
+					Scopes:           securityScheme.Scopes,
+				},
+			}
+		}
+	}
+
+	return ret
+}
+
+// ToSwagger converts the document into a swagger 2.0 spec. The parts of the document that can't be represented
+// (e.g. cookie params or several oauth2 flows) are dropped and returned as warnings.
+func ToSwagger(doc *Document) (*oapi_spec.Swagger, []string) {
+	c := &swaggerConverter{}
+	swagger := &oapi_spec.Swagger{
+		SwaggerProps: oapi_spec.SwaggerProps{
+			Swagger:  swaggerVersion,
+			Host:     doc.Host,
+			BasePath: doc.BasePath,
+			Schemes:  doc.Schemes,
+			Info: &oapi_spec.Info{
+				VendorExtensible: oapi_spec.VendorExtensible{
+					Extensions: copyExtensions(doc.Extensions),
+				},
+				InfoProps: oapi_spec.InfoProps{
+					Title:   doc.Title,
+					Version: doc.Version,
+				},
+			},
+			Paths: &oapi_spec.Paths{
+				Paths: make(map[string]oapi_spec.PathItem),
+			},
+		},
+	}
+
+	for name, schema := range doc.Schemas {
+		if swagger.Definitions == nil {
+			swagger.Definitions = make(oapi_spec.Definitions)
+		}
+		swagger.Definitions[name] = *c.toSwaggerSchema(schema)
+	}
+	for _, name := range getSortedKeys(doc.SecuritySchemes) {
+		if securityScheme := c.toSwaggerSecurityScheme(name, doc.SecuritySchemes[name]); securityScheme != nil {
+			if swagger.SecurityDefinitions == nil {
+				swagger.SecurityDefinitions = make(oapi_spec.SecurityDefinitions)
+			}
+			swagger.SecurityDefinitions[name] = securityScheme
+		}
+	}
+	for _, path := range getSortedKeys(doc.Paths) {
+		swagger.Paths.Paths[path] = *c.toSwaggerPathItem(path, doc.Paths[path])
+	}
+
+	return swagger, c.warnings
+}
+
+type swaggerConverter struct {
+	warnings []string
+}
+
+func (c *swaggerConverter) warnf(format string, args ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+func (c *swaggerConverter) toSwaggerPathItem(path string, pathItem *PathItem) *oapi_spec.PathItem {
+	ret := &oapi_spec.PathItem{}
+	ret.Parameters = c.toSwaggerParameters(path, pathItem.Parameters)
+	for _, method := range swaggerOperationMethods {
+		op, ok := pathItem.Operations[method]
+		if !ok {
+			continue
+		}
+		setSwaggerOperation(ret, method, c.toSwaggerOperation(path+" "+method, op))
+	}
+	for method := range pathItem.Operations {
+		if getSwaggerOperation(ret, method) == nil {
+			c.warnf("%v: method %v is not supported", path, method)
+		}
+	}
+
+	return ret
+}
+
+func (c *swaggerConverter) toSwaggerOperation(location string, op *Operation) *oapi_spec.Operation {
+	ret := &oapi_spec.Operation{
+		OperationProps: oapi_spec.OperationProps{
+			ID:          op.OperationID,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Tags:        op.Tags,
+			Deprecated:  op.Deprecated,
+			Security:    op.Security,
+			Parameters:  c.toSwaggerParameters(location, op.Parameters),
+			Responses: &oapi_spec.Responses{
+				ResponsesProps: oapi_spec.ResponsesProps{
+					StatusCodeResponses: make(map[int]oapi_spec.Response),
+				},
+			},
+		},
+		VendorExtensible: oapi_spec.VendorExtensible{
+			Extensions: copyExtensions(op.Extensions),
+		},
+	}
+
+	// the request body params are first, like in the learned specs
+	if op.RequestBody != nil && len(op.RequestBody.Content) > 0 {
+		consumes := getSortedKeys(op.RequestBody.Content)
+		ret.Consumes = consumes
+		bodySchema := op.RequestBody.Content[consumes[0]]
+		var bodyParams []oapi_spec.Parameter
+		if isFormMediaType(consumes[0]) && bodySchema != nil && bodySchema.Properties != nil {
+			bodyParams = c.toSwaggerFormParams(bodySchema)
+		} else {
+			bodyParam := oapi_spec.BodyParam("body", c.toSwaggerSchema(bodySchema))
+			bodyParam.Description = op.RequestBody.Description
+			bodyParam.Required = op.RequestBody.Required
+			bodyParams = []oapi_spec.Parameter{*bodyParam}
+		}
+		ret.Parameters = append(bodyParams, ret.Parameters...)
+		for _, mediaType := range consumes[1:] {
+			if !schemasEqual(op.RequestBody.Content[mediaType], bodySchema) {
+				c.warnf("%v: request body of %v is not the same as of %v", location, mediaType, consumes[0])
+			}
+		}
+	}
+
+	var produces []string
+	for _, code := range getSortedKeys(op.Responses) {
+		response := c.toSwaggerResponse(location+" "+code, op.Responses[code])
+		for mediaType := range op.Responses[code].Content {
+			produces = appendIfMissing(produces, mediaType)
+		}
+		if code == responseCodeDefault {
+			ret.Responses.Default = response
+			continue
+		}
+		statusCode, err := strconv.Atoi(code)
+		if err != nil {
+			c.warnf("%v: invalid response code %v", location, code)
+			continue
+		}
+		ret.Responses.StatusCodeResponses[statusCode] = *response
+	}
+	sort.Strings(produces)
+	ret.Produces = produces
+
+	return ret
+}
+
+func (c *swaggerConverter) toSwaggerParameters(location string, params []*Parameter) []oapi_spec.Parameter {
+	var ret []oapi_spec.Parameter
+	for _, param := range params {
+		if param.In == ParameterInCookie {
+			c.warnf("%v: cookie param %v is not supported", location, param.Name)
+			continue
+		}
+		swaggerParam := oapi_spec.Parameter{
+			ParamProps: oapi_spec.ParamProps{
+				Name:        param.Name,
+				In:          string(param.In),
+				Description: param.Description,
+				Required:    param.Required,
+			},
+		}
+		toSwaggerSimpleSchema(param.Schema, &swaggerParam.SimpleSchema, &swaggerParam.CommonValidations)
+		if swaggerParam.Type == schemaTypeArray {
+			swaggerParam.CollectionFormat = getCollectionFormat(param.Style, param.Explode)
+		}
+		ret = append(ret, swaggerParam)
+	}
+
+	return ret
+}
+
+// getCollectionFormat returns the swagger 2.0 collectionFormat of an array param in the OpenAPI 3.x style and explode.
+func getCollectionFormat(style string, explode bool) string {
+	switch style {
+	case openAPIStyleSpaceDelimited:
+		return swaggerCollectionFormatSSV
+	case openAPIStylePipeDelimited:
+		return swaggerCollectionFormatPipes
+	case openAPIStyleForm:
+		if explode {
+			return swaggerCollectionFormatMulti
+		}
+	}
+	// csv is the default
+	return ""
+}
+
+// toSwaggerFormParams returns the form data params of the properties of formSchema, sorted by name.
+func (c *swaggerConverter) toSwaggerFormParams(formSchema *Schema) []oapi_spec.Parameter {
+	required := make(map[string]bool, len(formSchema.Required))
+	for _, name := range formSchema.Required {
+		required[name] = true
+	}
+
+	var ret []oapi_spec.Parameter
+	for _, name := range getSortedKeys(formSchema.Properties) {
+		propertySchema := formSchema.Properties[name]
+		param := oapi_spec.FormDataParam(name)
+		param.Required = required[name]
+		param.Description = propertySchema.Description
+		toSwaggerSimpleSchema(propertySchema, &param.SimpleSchema, &param.CommonValidations)
+		if param.Type == "string" && param.Format == "binary" {
+			param.Type = "file"
+			param.Format = ""
+		}
+		ret = append(ret, *param)
+	}
+
+	return ret
+}
+
+func (c *swaggerConverter) toSwaggerResponse(location string, response *Response) *oapi_spec.Response {
+	ret := oapi_spec.NewResponse().WithDescription(response.Description)
+	for name, headerSchema := range response.Headers {
+		header := oapi_spec.ResponseHeader()
+		header.Description = headerSchema.Description
+		toSwaggerSimpleSchema(headerSchema, &header.SimpleSchema, &header.CommonValidations)
+		ret.AddHeader(name, header)
+	}
+
+	mediaTypes := getSortedKeys(response.Content)
+	if len(mediaTypes) > 0 {
+		ret.Schema = c.toSwaggerSchema(response.Content[mediaTypes[0]])
+		for _, mediaType := range mediaTypes[1:] {
+			if !schemasEqual(response.Content[mediaType], response.Content[mediaTypes[0]]) {
+				c.warnf("%v: response of %v is not the same as of %v", location, mediaType, mediaTypes[0])
+			}
+		}
+	}
+
+	return ret
+}
+
+func toSwaggerSimpleSchema(schema *Schema, simpleSchema *oapi_spec.SimpleSchema, validations *oapi_spec.CommonValidations) {
+	if schema == nil {
+		return
+	}
+	simpleSchema.Type = schema.Type
+	simpleSchema.Format = schema.Format
+	simpleSchema.Nullable = schema.Nullable
+	simpleSchema.Default = schema.Default
+	simpleSchema.Example = schema.Example
+	validations.Enum = schema.Enum
+	validations.Pattern = schema.Pattern
+	if schema.Items != nil {
+		items := oapi_spec.NewItems()
+		toSwaggerSimpleSchema(schema.Items, &items.SimpleSchema, &items.CommonValidations)
+		simpleSchema.Items = items
+	}
+}
+
+func (c *swaggerConverter) toSwaggerSchema(schema *Schema) *oapi_spec.Schema {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		return oapi_spec.RefSchema(swaggerDefinitionsRefPrefix + schema.Ref)
+	}
+
+	ret := &oapi_spec.Schema{
+		SchemaProps: oapi_spec.SchemaProps{
+			Format:      schema.Format,
+			Description: schema.Description,
+			Enum:        schema.Enum,
+			Pattern:     schema.Pattern,
+			Default:     schema.Default,
+			Required:    schema.Required,
+		},
+		SwaggerSchemaProps: oapi_spec.SwaggerSchemaProps{
+			Example: schema.Example,
+		},
+		VendorExtensible: oapi_spec.VendorExtensible{
+			Extensions: copyExtensions(schema.Extensions),
+		},
+	}
+	if schema.Type != "" {
+		ret.Type = strings.Split(schema.Type, ",")
+	}
+	if schema.Nullable {
+		ret.AddExtension(swaggerNullableExtension, true)
+	}
+	if schema.Items != nil {
+		ret.Items = &oapi_spec.SchemaOrArray{Schema: c.toSwaggerSchema(schema.Items)}
+	}
+	for name, property := range schema.Properties {
+		if ret.Properties == nil {
+			ret.Properties = make(oapi_spec.SchemaProperties)
+		}
+		ret.Properties[name] = *c.toSwaggerSchema(property)
+	}
+	if schema.AdditionalProperties != nil {
+		ret.AdditionalProperties = &oapi_spec.SchemaOrBool{Allows: true, Schema: c.toSwaggerSchema(schema.AdditionalProperties)}
+	}
+	for _, variant := range schema.AnyOf {
+		ret.AnyOf = append(ret.AnyOf, *c.toSwaggerSchema(variant))
+	}
+
+	return ret
+}
+
+func (c *swaggerConverter) toSwaggerSecurityScheme(name string, securityScheme *SecurityScheme) *oapi_spec.SecurityScheme {
+	switch securityScheme.Type {
+	case SecuritySchemeAPIKey:
+		ret := oapi_spec.APIKeyAuth(securityScheme.Name, string(securityScheme.In))
+		ret.Description = securityScheme.Description
+		return ret
+	case SecuritySchemeHTTP:
+		if !strings.EqualFold(securityScheme.Scheme, "basic") {
+			c.warnf("security scheme %v: http scheme %v is not supported", name, securityScheme.Scheme)
+			return nil
+		}
+		ret := oapi_spec.BasicAuth()
+		ret.Description = securityScheme.Description
+		return ret
+	case SecuritySchemeOAuth2:
+		flowNames := getSortedKeys(securityScheme.Flows)
+		if len(flowNames) == 0 {
+			c.warnf("security scheme %v: oauth2 has no flows", name)
+			return nil
+		}
+		if len(flowNames) > 1 {
+			c.warnf("security scheme %v: only the %v oauth2 flow is supported", name, flowNames[0])
+		}
+		flow := securityScheme.Flows[flowNames[0]]
+		ret := &oapi_spec.SecurityScheme{
+			SecuritySchemeProps: oapi_spec.SecuritySchemeProps{
+				Type:             string(SecuritySchemeOAuth2),
+				Description:      securityScheme.Description,
+				Flow:             swaggerOAuthFlows[flowNames[0]],
+				AuthorizationURL: flow.AuthorizationURL,
+				TokenURL:         flow.TokenURL,
+				Scopes:           flow.Scopes,
+			},
+		}
+		return ret
+	}
+
+	c.warnf("security scheme %v: type %v is not supported", name, securityScheme.Type)
+	return nil
+}
+
+func getSwaggerOperation(pathItem *oapi_spec.PathItem, method string) *oapi_spec.Operation {
+	switch method {
+	case http.MethodGet:
+		return pathItem.Get
+	case http.MethodPut:
+		return pathItem.Put
+	case http.MethodPost:
+		return pathItem.Post
+	case http.MethodDelete:
+		return pathItem.Delete
+	case http.MethodOptions:
+		return pathItem.Options
+	case http.MethodHead:
+		return pathItem.Head
+	case http.MethodPatch:
+		return pathItem.Patch
+	}
+	return nil
+}
+
+func setSwaggerOperation(pathItem *oapi_spec.PathItem, method string, op *oapi_spec.Operation) {
+	switch method {
+	case http.MethodGet:
+		pathItem.Get = op
+	case http.MethodPut:
+		pathItem.Put = op
+	case http.MethodPost:
+		pathItem.Post = op
+	case http.MethodDelete:
+		pathItem.Delete = op
+	case http.MethodOptions:
+		pathItem.Options = op
+	case http.MethodHead:
+		pathItem.Head = op
+	case http.MethodPatch:
+		pathItem.Patch = op
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apimodel
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+const testSwaggerYaml = `
+swagger: "2.0"
+info: {title: test, version: "1"}
+host: host:80
+basePath: /api
+consumes: [application/json]
+produces: [application/json]
+securityDefinitions:
+  basic: {type: basic}
+  key: {type: apiKey, name: X-Key, in: header}
+  oauth: {type: oauth2, flow: accessCode, authorizationUrl: "http://auth", tokenUrl: "http://token", scopes: {read: read}}
+definitions:
+  User:
+    type: object
+    required: [id]
+    properties:
+      id: {type: integer, format: int64}
+      name: {type: string, x-nullable: true}
+      tags: {type: array, items: {type: string, enum: [a, b]}}
+paths:
+  /users/{id}:
+    parameters:
+    - {name: id, in: path, required: true, type: string, pattern: "^u-[0-9]+$"}
+    get:
+      operationId: getUser
+      tags: [users]
+      security: [{key: []}]
+      x-speculator-hits: 3
+      parameters:
+      - {name: fields, in: query, type: array, items: {type: string}, collectionFormat: multi}
+      responses:
+        "200":
+          description: user
+          headers:
+            X-Rate-Limit: {type: integer, description: limit}
+          schema: {$ref: "#/definitions/User"}
+        default: {description: error}
+    put:
+      deprecated: true
+      parameters:
+      - {name: body, in: body, required: true, schema: {$ref: "#/definitions/User"}}
+      responses:
+        "204": {description: updated}
+  /avatars:
+    post:
+      consumes: [multipart/form-data]
+      parameters:
+      - {name: caption, in: formData, type: string}
+      - {name: file, in: formData, type: file, required: true}
+      responses:
+        "201": {description: created}
+`
+
+func TestFromSwagger_ToSwagger(t *testing.T) {
+	swagger := loadTestSwagger(t, testSwaggerYaml)
+
+	doc := FromSwagger(swagger)
+	user := doc.Paths["/users/{id}"]
+	assert.DeepEqual(t, user.Parameters[0].Schema, &Schema{Type: "string", Pattern: "^u-[0-9]+$"})
+	getUser := user.Operations[http.MethodGet]
+	assert.Equal(t, getUser.Parameters[0].Style, openAPIStyleForm)
+	assert.Equal(t, getUser.Parameters[0].Explode, true)
+	assert.DeepEqual(t, getUser.Responses["200"].Content, map[string]*Schema{MediaTypeJSON: {Ref: "User"}})
+	assert.DeepEqual(t, user.Operations[http.MethodPut].RequestBody, &RequestBody{Required: true, Content: map[string]*Schema{MediaTypeJSON: {Ref: "User"}}})
+	assert.DeepEqual(t, doc.Paths["/avatars"].Operations[http.MethodPost].RequestBody, &RequestBody{
+		Required: true,
+		Content: map[string]*Schema{
+			MediaTypeMultipartForm: {
+				Type: schemaTypeObject,
+				Properties: map[string]*Schema{
+					"file":    {Type: "string", Format: "binary"},
+					"caption": {Type: "string"},
+				},
+				Required: []string{"file"},
+			},
+		},
+	})
+	assert.Equal(t, doc.Schemas["User"].Properties["name"].Nullable, true)
+	assert.DeepEqual(t, doc.SecuritySchemes["basic"], &SecurityScheme{Type: SecuritySchemeHTTP, Scheme: "basic"})
+	assert.Equal(t, doc.SecuritySchemes["oauth"].Flows[OAuthFlowAuthorizationCode].TokenURL, "http://token")
+
+	converted, warnings := ToSwagger(doc)
+	assert.Equal(t, len(warnings), 0)
+	// the spec level consumes and produces are set per operation
+	want := loadTestSwagger(t, testSwaggerYaml)
+	want.Consumes, want.Produces = nil, nil
+	want.Paths.Paths["/users/{id}"].Get.Produces = []string{MediaTypeJSON}
+	want.Paths.Paths["/users/{id}"].Put.Consumes = []string{MediaTypeJSON}
+	assertEqualJSON(t, converted, want)
+}
+
+func TestToSwagger_Warnings(t *testing.T) {
+	doc := &Document{
+		Paths: map[string]*PathItem{
+			"/a": {
+				Operations: map[string]*Operation{
+					http.MethodGet: {
+						Parameters: []*Parameter{{Name: "session", In: ParameterInCookie, Schema: &Schema{Type: "string"}}},
+						Responses:  map[string]*Response{"200": {Description: "ok"}},
+					},
+					http.MethodTrace: {},
+				},
+			},
+		},
+		SecuritySchemes: map[string]*SecurityScheme{
+			"bearer": {Type: SecuritySchemeHTTP, Scheme: "bearer"},
+		},
+	}
+
+	swagger, warnings := ToSwagger(doc)
+	assert.DeepEqual(t, warnings, []string{
+		"security scheme bearer: http scheme bearer is not supported",
+		"/a GET: cookie param session is not supported",
+		"/a: method TRACE is not supported",
+	})
+	assert.Equal(t, len(swagger.Paths.Paths["/a"].Get.Parameters), 0)
+	assert.Equal(t, len(swagger.SecurityDefinitions), 0)
+}
+
+func loadTestSwagger(t *testing.T, swaggerYaml string) *oapi_spec.Swagger {
+	t.Helper()
+	swaggerJSON, err := yaml.YAMLToJSON([]byte(swaggerYaml))
+	assert.NilError(t, err)
+	swagger := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(swaggerJSON, swagger))
+	return swagger
+}
+
+func assertEqualJSON(t *testing.T, got, want interface{}) {
+	t.Helper()
+	gotB, err := json.Marshal(got)
+	assert.NilError(t, err)
+	wantB, err := json.Marshal(want)
+	assert.NilError(t, err)
+	assert.Equal(t, string(gotB), string(wantB))
+}
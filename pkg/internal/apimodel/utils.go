@@ -0,0 +1,60 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apimodel
+
+import (
+	"reflect"
+	"sort"
+)
+
+func isFormMediaType(mediaType string) bool {
+	return mediaType == MediaTypeFormURLEncoded || mediaType == MediaTypeMultipartForm
+}
+
+func schemasEqual(a, b *Schema) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func copyExtensions(extensions map[string]interface{}) map[string]interface{} {
+	if len(extensions) == 0 {
+		return nil
+	}
+	ret := make(map[string]interface{}, len(extensions))
+	for key, value := range extensions {
+		ret[key] = value
+	}
+	return ret
+}
+
+func appendIfMissing(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// getSortedKeys returns the sorted keys of m, a map with string keys.
+func getSortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	ret := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ret = append(ret, key.String())
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,152 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpcapture captures the bodies of the requests and responses of net/http, without affecting the
+// forwarded streams, for the proxy, the middleware and the round tripper.
+package httpcapture
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Buffer keeps up to limit bytes of the data written to it, and marks itself as truncated when data was dropped.
+// Writes never fail so it can be used with io.TeeReader without affecting the forwarded stream.
+type Buffer struct {
+	buf   bytes.Buffer
+	limit int64
+	// Truncated is set once data was dropped, or by the owner of a body that was not read to the end
+	Truncated bool
+}
+
+func NewBuffer(limit int64) *Buffer {
+	return &Buffer{
+		limit: limit,
+	}
+}
+
+func (c *Buffer) Write(p []byte) (int, error) {
+	remaining := c.limit - int64(c.buf.Len())
+	if int64(len(p)) > remaining {
+		c.Truncated = true
+		if remaining > 0 {
+			c.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+
+	c.buf.Write(p)
+	return len(p), nil
+}
+
+// Body returns the captured data, a truncated body is dropped since it can't be parsed into a schema.
+func (c *Buffer) Body() []byte {
+	if c.Truncated {
+		return nil
+	}
+	return c.buf.Bytes()
+}
+
+// ReadCloser returns a reader of the captured data, including the captured part of a truncated body.
+func (c *Buffer) ReadCloser() io.ReadCloser {
+	return ioutil.NopCloser(bytes.NewReader(c.buf.Bytes()))
+}
+
+// TeeReadCloser captures a body into Buffer while it is read.
+type TeeReadCloser struct {
+	io.Closer
+	reader io.Reader
+	Buffer *Buffer
+	eof    bool
+}
+
+// NewTeeReadCloser captures up to limit bytes of body while it is read.
+func NewTeeReadCloser(body io.ReadCloser, limit int64) *TeeReadCloser {
+	buffer := NewBuffer(limit)
+	return &TeeReadCloser{
+		Closer: body,
+		reader: io.TeeReader(body, buffer),
+		Buffer: buffer,
+	}
+}
+
+func (t *TeeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.reader.Read(p)
+	if err == io.EOF {
+		t.eof = true
+	}
+	return n, err
+}
+
+// EOF returns true once the body was read to the end.
+func (t *TeeReadCloser) EOF() bool {
+	return t.eof
+}
+
+// ResponseWriter captures the status code and the body written to the wrapped http.ResponseWriter.
+type ResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	Buffer     *Buffer
+}
+
+// NewResponseWriter captures up to limit bytes of the body written to w.
+func NewResponseWriter(w http.ResponseWriter, limit int64) *ResponseWriter {
+	return &ResponseWriter{
+		ResponseWriter: w,
+		Buffer:         NewBuffer(limit),
+	}
+}
+
+func (c *ResponseWriter) WriteHeader(statusCode int) {
+	if c.statusCode == 0 {
+		c.statusCode = statusCode
+	}
+	c.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (c *ResponseWriter) Write(b []byte) (int, error) {
+	if c.statusCode == 0 {
+		c.statusCode = http.StatusOK
+	}
+	_, _ = c.Buffer.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+func (c *ResponseWriter) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// StatusCode returns the written status code, http.StatusOK when none was written.
+func (c *ResponseWriter) StatusCode() int {
+	if c.statusCode == 0 {
+		return http.StatusOK
+	}
+	return c.statusCode
+}
+
+// Response returns the captured response, with a copy of the written headers and a reader of the captured body.
+func (c *ResponseWriter) Response(proto string) *http.Response {
+	return &http.Response{
+		StatusCode: c.StatusCode(),
+		Proto:      proto,
+		Header:     c.Header().Clone(),
+		Body:       c.Buffer.ReadCloser(),
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpcapture
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestBuffer_Write(t *testing.T) {
+	c := NewBuffer(4)
+	n, err := c.Write([]byte("ab"))
+	assert.NilError(t, err)
+	assert.Equal(t, n, 2)
+	assert.Equal(t, string(c.Body()), "ab")
+	assert.Equal(t, c.Truncated, false)
+
+	n, err = c.Write([]byte("cde"))
+	assert.NilError(t, err)
+	assert.Equal(t, n, 3)
+	assert.Equal(t, c.Truncated, true)
+	assert.Assert(t, c.Body() == nil)
+	assert.Equal(t, c.buf.String(), "abcd")
+}
+
+func TestTeeReadCloser(t *testing.T) {
+	body := NewTeeReadCloser(ioutil.NopCloser(strings.NewReader("abcdef")), 10)
+	_, err := body.Read(make([]byte, 3))
+	assert.NilError(t, err)
+	assert.Equal(t, body.EOF(), false)
+	assert.Equal(t, string(body.Buffer.Body()), "abc")
+
+	rest, err := ioutil.ReadAll(body)
+	assert.NilError(t, err)
+	assert.Equal(t, string(rest), "def")
+	assert.Equal(t, body.EOF(), true)
+	assert.Equal(t, string(body.Buffer.Body()), "abcdef")
+}
+
+func TestResponseWriter_Response(t *testing.T) {
+	cw := NewResponseWriter(httptest.NewRecorder(), 10)
+	cw.Header().Set("Content-Type", "text/plain")
+	cw.WriteHeader(http.StatusCreated)
+	_, err := cw.Write([]byte("created"))
+	assert.NilError(t, err)
+
+	resp := cw.Response("HTTP/1.1")
+	assert.Equal(t, resp.StatusCode, http.StatusCreated)
+	assert.Equal(t, resp.Proto, "HTTP/1.1")
+	assert.Equal(t, resp.Header.Get("Content-Type"), "text/plain")
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, string(body), "created")
+}
+
+func TestAddressWithPort(t *testing.T) {
+	assert.Equal(t, AddressWithPort("host:8080", "https"), "host:8080")
+	assert.Equal(t, AddressWithPort("host", "https"), "host:443")
+	assert.Equal(t, AddressWithPort("host", "http"), "host:80")
+	assert.Equal(t, AddressWithPort("host", ""), "host:80")
+}
+
+func TestConvertHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Add("X-B", "1")
+	header.Add("X-A", "2")
+	header.Add("X-A", "3")
+	assert.DeepEqual(t, ConvertHeaders(header), []*_spec.Header{
+		{Key: "X-A", Value: "2"},
+		{Key: "X-A", Value: "3"},
+		{Key: "X-B", Value: "1"},
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpcapture
+
+import (
+	"net"
+	"net/http"
+	"sort"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func HostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// AddressWithPort returns host as "host:port", with the default port of scheme when host has no port.
+func AddressWithPort(host, scheme string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	port := "80"
+	if scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// ConvertHeaders converts header into telemetry headers, sorted by key.
+func ConvertHeaders(header http.Header) []*_spec.Header {
+	var ret []*_spec.Header
+
+	for key, values := range header {
+		for _, value := range values {
+			ret = append(ret, &_spec.Header{
+				Key:   key,
+				Value: value,
+			})
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Key < ret[j].Key
+	})
+
+	return ret
+}
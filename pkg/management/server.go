@@ -0,0 +1,135 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package management implements the HTTP management API of a running speculator.
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/speculator"
+)
+
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+	// SamplesPath returns the retained samples of the spec of the host and port query params
+	SamplesPath = "/samples"
+	// PoisonedPath returns the telemetries that panicked while they were learned or diffed
+	PoisonedPath = "/poisoned"
+	// MetricsPath returns the learning pipeline metrics in the Prometheus text format
+	MetricsPath = "/metrics"
+)
+
+type Server struct {
+	speculator     *speculator.Speculator
+	mux            *http.ServeMux
+	metricsHandler http.Handler
+}
+
+func NewServer(s *speculator.Speculator) *Server {
+	server := &Server{
+		speculator: s,
+		mux:        http.NewServeMux(),
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(speculator.NewMetricsCollector(s))
+	server.metricsHandler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	server.mux.HandleFunc(HealthzPath, server.handleHealthz)
+	server.mux.HandleFunc(ReadyzPath, server.handleReadyz)
+	server.mux.HandleFunc(SamplesPath, server.handleSamples)
+	server.mux.HandleFunc(PoisonedPath, server.handlePoisoned)
+	server.mux.HandleFunc(MetricsPath, server.handleMetrics)
+
+	return server
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleHealthz returns the health snapshot, the speculator is alive as long as it answers.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.speculator.Health())
+}
+
+// handleReadyz returns the health snapshot, with http.StatusServiceUnavailable once the speculator is shutting down.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	health := s.speculator.Health()
+	statusCode := http.StatusOK
+	if !health.Ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+	writeJSON(w, statusCode, health)
+}
+
+// handleSamples returns the retained samples of a spec, see speculator.GetRetainedSamples.
+func (s *Server) handleSamples(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	host := r.URL.Query().Get("host")
+	port := r.URL.Query().Get("port")
+	if host == "" || port == "" {
+		http.Error(w, "host and port query params are required", http.StatusBadRequest)
+		return
+	}
+	samples, err := s.speculator.GetRetainedSamples(speculator.GetSpecKey(host, port))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, samples)
+}
+
+// handlePoisoned returns the poisoned telemetries, see speculator.GetPoisonedTelemetries.
+func (s *Server) handlePoisoned(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.speculator.GetPoisonedTelemetries())
+}
+
+// handleMetrics returns the learning pipeline metrics, see speculator.MetricsCollector.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.metricsHandler.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/speculator"
+)
+
+func createTelemetry() *_spec.Telemetry {
+	return &_spec.Telemetry{
+		DestinationAddress: "10.0.0.1:80",
+		RequestID:          "req-id",
+		Request: &_spec.Request{
+			Method: http.MethodGet,
+			Path:   "/api",
+			Host:   "host",
+			Common: &_spec.Common{},
+		},
+		Response: &_spec.Response{
+			StatusCode: "200",
+			Common:     &_spec.Common{},
+		},
+	}
+}
+
+func getHealth(t *testing.T, server *Server, path string, wantStatusCode int) *speculator.Health {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, w.Code, wantStatusCode)
+
+	health := &speculator.Health{}
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), health))
+	return health
+}
+
+func TestServer_Healthz(t *testing.T) {
+	s := speculator.CreateSpeculator(speculator.Config{})
+	server := NewServer(s)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry()))
+
+	health := getHealth(t, server, HealthzPath, http.StatusOK)
+	assert.Equal(t, health.Ready, true)
+	assert.Equal(t, health.QueueDepth, 0)
+	assert.Assert(t, !health.LastIngestion.IsZero())
+	getHealth(t, server, ReadyzPath, http.StatusOK)
+
+	assert.NilError(t, s.Shutdown(context.Background()))
+	health = getHealth(t, server, HealthzPath, http.StatusOK)
+	assert.Equal(t, health.Ready, false)
+	getHealth(t, server, ReadyzPath, http.StatusServiceUnavailable)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, HealthzPath, nil))
+	assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+}
+
+func TestServer_Samples(t *testing.T) {
+	s := speculator.CreateSpeculator(speculator.Config{
+		OperationGeneratorConfig: _spec.OperationGeneratorConfig{MaxRetainedSamples: 10},
+	})
+	server := NewServer(s)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry()))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SamplesPath+"?host=host&port=80", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	var samples []_spec.RetainedSample
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &samples))
+	assert.Equal(t, len(samples), 1)
+	assert.Equal(t, samples[0].Reason, _spec.SampleRetentionReasonNewPath)
+	assert.Equal(t, samples[0].Path, "/api")
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SamplesPath+"?host=other&port=80", nil))
+	assert.Equal(t, w.Code, http.StatusNotFound)
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SamplesPath, nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
+
+func TestServer_Poisoned(t *testing.T) {
+	panickingEnricher := func(telemetry *_spec.Telemetry) (map[string]string, error) {
+		panic("bad telemetry")
+	}
+	s := speculator.CreateSpeculator(speculator.Config{Enrichers: []speculator.Enricher{panickingEnricher}})
+	server := NewServer(s)
+	assert.ErrorContains(t, s.LearnTelemetry(createTelemetry()), "bad telemetry")
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PoisonedPath, nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	var poisoned []speculator.PoisonedTelemetry
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &poisoned))
+	assert.Equal(t, len(poisoned), 1)
+	assert.Equal(t, poisoned[0].Telemetry.RequestID, "req-id")
+	assert.Equal(t, getHealth(t, server, HealthzPath, http.StatusOK).RecoveredPanics, 1)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s := speculator.CreateSpeculator(speculator.Config{})
+	server := NewServer(s)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry()))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Assert(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	assert.Assert(t, strings.Contains(w.Body.String(), `speculator_telemetries_learned_total{spec="host:80"} 1`))
+	assert.Assert(t, strings.Contains(w.Body.String(), `speculator_learn_duration_seconds_count{spec="host:80"} 1`))
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, MetricsPath, nil))
+	assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+}
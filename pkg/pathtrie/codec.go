@@ -0,0 +1,175 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathtrie
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// valueCodec decodes the JSON values of a registered value type.
+type valueCodec struct {
+	name   string
+	decode func(data []byte) (interface{}, error)
+}
+
+var valueCodecs = struct {
+	lock   sync.RWMutex
+	byName map[string]*valueCodec
+	byType map[reflect.Type]*valueCodec
+}{
+	byName: make(map[string]*valueCodec),
+	byType: make(map[reflect.Type]*valueCodec),
+}
+
+func init() {
+	// the path IDs of the spec tries, which were untyped in the older encoded states
+	RegisterValueType[string]("string")
+}
+
+// RegisterValueType registers V as a trie value type under name, so that the values of type V of an untyped trie
+// keep their type through MarshalJSON and UnmarshalJSON. V is registered with gob as well, so that the untyped tries
+// holding V values can be gob encoded. Registering the same name or type twice panics.
+func RegisterValueType[V any](name string) {
+	var zero V
+	valueType := reflect.TypeOf(zero)
+	if valueType == nil {
+		panic("pathtrie: can't register an interface value type")
+	}
+
+	valueCodecs.lock.Lock()
+	defer valueCodecs.lock.Unlock()
+
+	if _, ok := valueCodecs.byName[name]; ok {
+		panic(fmt.Sprintf("pathtrie: value type name %v is already registered", name))
+	}
+	if codec, ok := valueCodecs.byType[valueType]; ok {
+		panic(fmt.Sprintf("pathtrie: value type %v is already registered as %v", valueType, codec.name))
+	}
+	codec := &valueCodec{
+		name: name,
+		decode: func(data []byte) (interface{}, error) {
+			var value V
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		},
+	}
+	valueCodecs.byName[name] = codec
+	valueCodecs.byType[valueType] = codec
+	gob.Register(zero)
+}
+
+func getValueCodecByName(name string) (*valueCodec, bool) {
+	valueCodecs.lock.RLock()
+	defer valueCodecs.lock.RUnlock()
+
+	codec, ok := valueCodecs.byName[name]
+	return codec, ok
+}
+
+func getValueCodecByType(valueType reflect.Type) (*valueCodec, bool) {
+	valueCodecs.lock.RLock()
+	defer valueCodecs.lock.RUnlock()
+
+	codec, ok := valueCodecs.byType[valueType]
+	return codec, ok
+}
+
+// jsonPathTrie is the JSON representation of a TypedPathTrie, the paths that have a value with the registered name
+// of their value type.
+type jsonPathTrie struct {
+	PathSeparator string          `json:"pathSeparator"`
+	Paths         []jsonPathValue `json:"paths,omitempty"`
+}
+
+type jsonPathValue struct {
+	Path  string          `json:"path"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MarshalJSON encodes the paths that have a value, sorted, with the values of the types registered by
+// RegisterValueType. The nodes without a value are not encoded.
+func (pt TypedPathTrie[T]) MarshalJSON() ([]byte, error) {
+	encoded := jsonPathTrie{PathSeparator: pt.PathSeparator}
+	var err error
+	pt.Walk(func(fullPath string, value T) bool {
+		var pathValue *jsonPathValue
+		if pathValue, err = createJSONPathValue(fullPath, value); err != nil {
+			return false
+		}
+		encoded.Paths = append(encoded.Paths, *pathValue)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encoded)
+}
+
+func createJSONPathValue(fullPath string, value interface{}) (*jsonPathValue, error) {
+	codec, ok := getValueCodecByType(reflect.TypeOf(value))
+	if !ok {
+		return nil, fmt.Errorf("value of %v is a %T, which is not a registered value type", fullPath, value)
+	}
+	valueB, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value of %v: %v", fullPath, err)
+	}
+
+	return &jsonPathValue{
+		Path:  fullPath,
+		Type:  codec.name,
+		Value: valueB,
+	}, nil
+}
+
+// UnmarshalJSON replaces the trie with the trie encoded by MarshalJSON.
+func (pt *TypedPathTrie[T]) UnmarshalJSON(data []byte) error {
+	encoded := jsonPathTrie{}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("failed to decode path trie: %v", err)
+	}
+	if encoded.PathSeparator == "" {
+		return fmt.Errorf("path trie is missing its path separator")
+	}
+
+	decoded := NewTypedWithPathSeparator[T](encoded.PathSeparator)
+	for _, pathValue := range encoded.Paths {
+		codec, ok := getValueCodecByName(pathValue.Type)
+		if !ok {
+			return fmt.Errorf("value of %v has an unregistered value type %v", pathValue.Path, pathValue.Type)
+		}
+		value, err := codec.decode(pathValue.Value)
+		if err != nil {
+			return fmt.Errorf("failed to decode value of %v: %v", pathValue.Path, err)
+		}
+		typedValue, ok := value.(T)
+		if !ok {
+			return fmt.Errorf("value of %v is a %T, not a %T", pathValue.Path, value, typedValue)
+		}
+		decoded.Insert(pathValue.Path, typedValue)
+	}
+	*pt = decoded
+
+	return nil
+}
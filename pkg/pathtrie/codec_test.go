@@ -0,0 +1,114 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathtrie
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type testCodecValue struct {
+	ID    string
+	Count int
+}
+
+type testUnregisteredValue struct{}
+
+func init() {
+	RegisterValueType[testCodecValue]("pathtrie.testCodecValue")
+}
+
+func TestPathTrie_JSON(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{id}", "1")
+	pt.Insert("/api/{id}/items", testCodecValue{ID: "2", Count: 3})
+	pt.Insert("/api", "3")
+	pt.Delete("/api")
+
+	b, err := json.Marshal(pt)
+	assert.NilError(t, err)
+	assert.Equal(t, string(b), `{"pathSeparator":"/","paths":[`+
+		`{"path":"/api/{id}","type":"string","value":"1"},`+
+		`{"path":"/api/{id}/items","type":"pathtrie.testCodecValue","value":{"ID":"2","Count":3}}]}`)
+
+	got := New()
+	assert.NilError(t, json.Unmarshal(b, &got))
+	assert.DeepEqual(t, got.Paths(), []string{"/api/{id}", "/api/{id}/items"})
+	_, value, found := got.GetPathAndValue("/api/1/items")
+	assert.Assert(t, found)
+	assert.Equal(t, value, interface{}(testCodecValue{ID: "2", Count: 3}))
+	assert.Equal(t, got.Trie[""].Children["api"].Children["{id}"].PathParamCounter, 1)
+}
+
+func TestTypedPathTrie_JSON(t *testing.T) {
+	pt := NewTypedWithPathSeparator[testCodecValue](".")
+	pt.Insert("a.b", testCodecValue{ID: "1"})
+
+	b, err := json.Marshal(pt)
+	assert.NilError(t, err)
+	got := NewTyped[testCodecValue]()
+	assert.NilError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, got.PathSeparator, ".")
+	assert.Equal(t, got.GetValue("a.b"), testCodecValue{ID: "1"})
+
+	// a string is not a testCodecValue
+	untyped := New()
+	untyped.Insert("/a", "1")
+	b, err = json.Marshal(untyped)
+	assert.NilError(t, err)
+	assert.ErrorContains(t, json.Unmarshal(b, &got), "value of /a is a string, not a pathtrie.testCodecValue")
+}
+
+func TestPathTrie_JSON_Errors(t *testing.T) {
+	pt := New()
+	pt.Insert("/a", testUnregisteredValue{})
+	_, err := json.Marshal(pt)
+	assert.ErrorContains(t, err, "not a registered value type")
+
+	got := New()
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"pathSeparator":"/","paths":[{"path":"/a","type":"unknown","value":1}]}`), &got),
+		"unregistered value type unknown")
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"paths":[]}`), &got), "missing its path separator")
+}
+
+func TestRegisterValueType_Duplicate(t *testing.T) {
+	assertPanics := func(register func()) {
+		defer func() {
+			assert.Assert(t, recover() != nil)
+		}()
+		register()
+	}
+	assertPanics(func() { RegisterValueType[int]("string") })
+	assertPanics(func() { RegisterValueType[testCodecValue]("other") })
+	assertPanics(func() { RegisterValueType[interface{}]("interface") })
+}
+
+func TestPathTrie_Gob(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{id}", testCodecValue{ID: "1"})
+	pt.Insert("/api", "2")
+
+	buf := &bytes.Buffer{}
+	assert.NilError(t, gob.NewEncoder(buf).Encode(pt))
+	got := New()
+	assert.NilError(t, gob.NewDecoder(buf).Decode(&got))
+	assert.Equal(t, got.GetValue("/api/1"), interface{}(testCodecValue{ID: "1"}))
+	assert.Equal(t, got.GetValue("/api"), interface{}("2"))
+}
@@ -0,0 +1,114 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathtrie
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// JSONTreeNode is a node of the nested JSON tree of a TypedPathTrie, see ToJSONTree.
+type JSONTreeNode struct {
+	Name     string `json:"name"`
+	FullPath string `json:"fullPath"`
+	// Value is set on the nodes of full paths
+	Value    interface{}     `json:"value,omitempty"`
+	Children []*JSONTreeNode `json:"children,omitempty"`
+}
+
+// ToJSONTree returns the root nodes of the trie as a nested tree, children are sorted by name.
+// The nameless root segment of paths starting with the separator is omitted.
+func (pt *TypedPathTrie[T]) ToJSONTree() []*JSONTreeNode {
+	return createJSONTreeNodes(pt.Trie)
+}
+
+func createJSONTreeNodes[T any](nodes TypedPathToTrieNode[T]) []*JSONTreeNode {
+	var ret []*JSONTreeNode
+	for _, node := range getSortedNodes(nodes) {
+		if node.FullPath == "" && !node.hasValue() {
+			ret = append(ret, createJSONTreeNodes(node.Children)...)
+			continue
+		}
+		jsonNode := &JSONTreeNode{
+			Name:     node.Name,
+			FullPath: node.FullPath,
+			Children: createJSONTreeNodes(node.Children),
+		}
+		if node.hasValue() {
+			jsonNode.Value = node.Value
+		}
+		ret = append(ret, jsonNode)
+	}
+	return ret
+}
+
+// WriteDOT writes the trie as a Graphviz DOT digraph named name. Path param segments are dashed and the nodes
+// of full paths are boxes labeled with their value.
+func (pt *TypedPathTrie[T]) WriteDOT(w io.Writer, name string) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %v {\n", strconv.Quote(name))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\troot [label=\"" + pt.PathSeparator + "\"];\n")
+	nextID := 0
+	writeDOTNodes(b, pt.Trie, "root", &nextID)
+	b.WriteString("}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write DOT: %v", err)
+	}
+	return nil
+}
+
+func writeDOTNodes[T any](b *strings.Builder, nodes TypedPathToTrieNode[T], parentID string, nextID *int) {
+	for _, node := range getSortedNodes(nodes) {
+		if node.FullPath == "" && !node.hasValue() {
+			writeDOTNodes(b, node.Children, parentID, nextID)
+			continue
+		}
+		id := "n" + strconv.Itoa(*nextID)
+		*nextID++
+
+		label := node.Name
+		var attributes []string
+		if node.hasValue() {
+			label += "\n" + fmt.Sprintf("%v", node.Value)
+			attributes = append(attributes, "shape=box")
+		}
+		if utils.IsPathParam(node.Name) {
+			attributes = append(attributes, "style=dashed")
+		}
+		attributes = append([]string{"label=" + strconv.Quote(label), "tooltip=" + strconv.Quote(node.FullPath)}, attributes...)
+		fmt.Fprintf(b, "\t%v [%v];\n", id, strings.Join(attributes, ", "))
+		fmt.Fprintf(b, "\t%v -> %v;\n", parentID, id)
+		writeDOTNodes(b, node.Children, id, nextID)
+	}
+}
+
+func getSortedNodes[T any](nodes TypedPathToTrieNode[T]) []*TypedTrieNode[T] {
+	ret := make([]*TypedTrieNode[T], 0, len(nodes))
+	for _, node := range nodes {
+		ret = append(ret, node)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
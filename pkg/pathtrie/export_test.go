@@ -0,0 +1,64 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathtrie
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPathTrie_ToJSONTree(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{param1}", 1)
+	pt.Insert("/api/items", 2)
+	pt.Insert("/health", 3)
+
+	assert.DeepEqual(t, pt.ToJSONTree(), []*JSONTreeNode{
+		{
+			Name:     "api",
+			FullPath: "/api",
+			Children: []*JSONTreeNode{
+				{Name: "items", FullPath: "/api/items", Value: 2},
+				{Name: "{param1}", FullPath: "/api/{param1}", Value: 1},
+			},
+		},
+		{Name: "health", FullPath: "/health", Value: 3},
+	})
+	empty := New()
+	assert.Assert(t, empty.ToJSONTree() == nil)
+}
+
+func TestPathTrie_WriteDOT(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{param1}", "id-1")
+	pt.Insert("/api/items", "id-2")
+
+	b := &strings.Builder{}
+	assert.NilError(t, pt.WriteDOT(b, "host:80 approved"))
+	assert.Equal(t, b.String(), `digraph "host:80 approved" {
+	rankdir=LR;
+	root [label="/"];
+	n0 [label="api", tooltip="/api"];
+	root -> n0;
+	n1 [label="items\nid-2", tooltip="/api/items", shape=box];
+	n0 -> n1;
+	n2 [label="{param1}\nid-1", tooltip="/api/{param1}", shape=box, style=dashed];
+	n0 -> n2;
+}
+`)
+}
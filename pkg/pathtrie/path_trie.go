@@ -17,13 +17,17 @@
 package pathtrie
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/apiclarity/speculator/pkg/utils"
 )
 
-type TrieNode struct {
-	Children PathToTrieNode
+// TypedTrieNode is a node of a TypedPathTrie.
+type TypedTrieNode[T any] struct {
+	Children TypedPathToTrieNode[T]
 
 	// Name of the path segment corresponding to this node.
 	// E.g. if this node represents /v1/foo/bar,
@@ -36,28 +40,106 @@ type TrieNode struct {
 	// PathParamCounter counts the amount of path params in the FullPath
 	PathParamCounter int
 
-	// Value of the full path
-	Value interface{}
+	// Value of the full path, the zero value of T (e.g. nil) means that the node has no value
+	Value T
 }
 
-type PathToTrieNode map[string]*TrieNode
+type TypedPathToTrieNode[T any] map[string]*TypedTrieNode[T]
 
-type PathTrie struct {
-	Trie          PathToTrieNode
+// TypedPathTrie is a trie of paths valued by T, whose path param segments (e.g. {id}) match any segment.
+type TypedPathTrie[T any] struct {
+	Trie          TypedPathToTrieNode[T]
 	PathSeparator string
 }
 
-type ValueMergeFunc func(existing, newV *interface{})
+type TypedValueMergeFunc[T any] func(existing, newV *T)
+
+// TrieNode, PathToTrieNode, PathTrie and ValueMergeFunc are the untyped trie, kept for existing users and for
+// decoding the states encoded with untyped tries, see ToTyped.
+type (
+	TrieNode       = TypedTrieNode[interface{}]
+	PathToTrieNode = TypedPathToTrieNode[interface{}]
+	PathTrie       = TypedPathTrie[interface{}]
+	ValueMergeFunc = TypedValueMergeFunc[interface{}]
+)
+
+// PathLookup is the path that matched a looked up path, see LookupPath.
+type PathLookup[T any] struct {
+	FullPath string
+	Value    T
+	Found    bool
+	// Ambiguous is true if other paths matched with as few path params, e.g. /api/{id}/items and /api/users/{name}
+	// for /api/users/items. FullPath is then the path with the longest literal prefix, then the lexically first.
+	Ambiguous bool
+}
+
+// GetPathAndValueAs is GetPathAndValue of an untyped trie whose values are of type V. The value is the zero value of V and an error is returned if the value of the
+// found path is not a V.
+func GetPathAndValueAs[V any](pt *PathTrie, path string) (string, V, bool, error) {
+	lookup, err := LookupPathAs[V](pt, path)
+	return lookup.FullPath, lookup.Value, lookup.Found, err
+}
+
+// LookupPathAs is LookupPath of an untyped trie whose values are of type V, see GetPathAndValueAs.
+func LookupPathAs[V any](pt *PathTrie, path string) (PathLookup[V], error) {
+	lookup := pt.LookupPath(path)
+	typedLookup := PathLookup[V]{
+		FullPath:  lookup.FullPath,
+		Found:     lookup.Found,
+		Ambiguous: lookup.Ambiguous,
+	}
+	if !lookup.Found {
+		return typedLookup, nil
+	}
+	typedValue, ok := lookup.Value.(V)
+	if !ok {
+		return typedLookup, fmt.Errorf("value of %v is a %T, not a %T", lookup.FullPath, lookup.Value, typedValue)
+	}
+	typedLookup.Value = typedValue
+
+	return typedLookup, nil
+}
+
+// ToTyped returns a typed copy of an untyped trie whose values are of type V, e.g. to migrate encoded untyped tries.
+// An error is returned if a value is not a V.
+func ToTyped[V any](pt *PathTrie) (TypedPathTrie[V], error) {
+	typed := NewTypedWithPathSeparator[V](pt.PathSeparator)
+	var err error
+	pt.Walk(func(fullPath string, value interface{}) bool {
+		typedValue, ok := value.(V)
+		if !ok {
+			err = fmt.Errorf("value of %v is a %T, not a %T", fullPath, value, typedValue)
+			return false
+		}
+		typed.Insert(fullPath, typedValue)
+		return true
+	})
+	if err != nil {
+		return TypedPathTrie[V]{}, err
+	}
+
+	return typed, nil
+}
 
 // Create a PathTrie with "/" as the path separator.
 func New() PathTrie {
-	return NewWithPathSeparator("/")
+	return NewTyped[interface{}]()
 }
 
 // Create a PathTrie with a user-supplied path separator.
 func NewWithPathSeparator(pathSeparator string) PathTrie {
-	return PathTrie{
-		Trie:          make(PathToTrieNode),
+	return NewTypedWithPathSeparator[interface{}](pathSeparator)
+}
+
+// Create a TypedPathTrie with "/" as the path separator.
+func NewTyped[T any]() TypedPathTrie[T] {
+	return NewTypedWithPathSeparator[T]("/")
+}
+
+// Create a TypedPathTrie with a user-supplied path separator.
+func NewTypedWithPathSeparator[T any](pathSeparator string) TypedPathTrie[T] {
+	return TypedPathTrie[T]{
+		Trie:          make(TypedPathToTrieNode[T]),
 		PathSeparator: pathSeparator,
 	}
 }
@@ -65,9 +147,8 @@ func NewWithPathSeparator(pathSeparator string) PathTrie {
 // Insert val at path, with path segments separated by PathSeparator.
 // Returns true if a new path was created, false if an existing path
 // was overwritten.
-//
-func (pt *PathTrie) Insert(path string, val interface{}) bool {
-	return pt.InsertMerge(path, val, func(existing, newV *interface{}) {
+func (pt *TypedPathTrie[T]) Insert(path string, val T) bool {
+	return pt.InsertMerge(path, val, func(existing, newV *T) {
 		*existing = *newV
 	})
 }
@@ -78,7 +159,7 @@ func (pt *PathTrie) Insert(path string, val interface{}) bool {
 //
 // The merge function is responsible for updating the existing value
 // with the new value.
-func (pt *PathTrie) InsertMerge(path string, val interface{}, merge ValueMergeFunc) (isNewPath bool) {
+func (pt *TypedPathTrie[T]) InsertMerge(path string, val T, merge TypedValueMergeFunc[T]) (isNewPath bool) {
 	trie := pt.Trie
 	isNewPath = true
 	// TODO: what about path that ends with pt.PathSeparator is it different ?
@@ -91,7 +172,7 @@ func (pt *PathTrie) InsertMerge(path string, val interface{}, merge ValueMergeFu
 			if isLastSegment {
 				// If this is the last path segment, then this is the node to update.
 				// If node value is not empty it means that an existing path is overwritten
-				isNewPath = utils.IsNil(node.Value)
+				isNewPath = !node.hasValue()
 				merge(&node.Value, &val)
 			} else {
 				// Otherwise, continue descending.
@@ -107,10 +188,146 @@ func (pt *PathTrie) InsertMerge(path string, val interface{}, merge ValueMergeFu
 	return isNewPath
 }
 
-func (pt *PathTrie) createPathTrieNode(segments []string, idx int, isLastSegment bool, val interface{}) *TrieNode {
+// Delete removes the value of path (the exact path, path params are not matched, see DeleteMatch), and the nodes left without
+// values or children. Returns true if path had a value.
+func (pt *TypedPathTrie[T]) Delete(path string) bool {
+	return pt.Trie.delete(strings.Split(path, pt.PathSeparator), 0)
+}
+
+func (trie TypedPathToTrieNode[T]) delete(segments []string, idx int) bool {
+	node, ok := trie[segments[idx]]
+	if !ok {
+		return false
+	}
+
+	var isDeleted bool
+	if idx == len(segments)-1 {
+		isDeleted = node.hasValue()
+		var noValue T
+		node.Value = noValue
+	} else {
+		isDeleted = node.Children.delete(segments, idx+1)
+	}
+	if !node.hasValue() && len(node.Children) == 0 {
+		delete(trie, segments[idx])
+	}
+
+	return isDeleted
+}
+
+// DeleteMatch removes the value of the path that path matches, like GetPathAndValue: a literal path deletes the
+// path param node it was merged into (e.g. /api/1 deletes /api/{param1}) unless it is in the trie itself.
+// The nodes left without values or children are removed. Returns the deleted full path, and false if path
+// has no match.
+func (pt *TypedPathTrie[T]) DeleteMatch(path string) (string, bool) {
+	node, _ := pt.getNode(path)
+	if node == nil {
+		return "", false
+	}
+	fullPath := node.FullPath
+
+	return fullPath, pt.Delete(fullPath)
+}
+
+// Prune removes the nodes that have no value and no descendant with a value, e.g. nodes whose value was
+// overwritten with the zero value of T. Returns the amount of removed nodes.
+func (pt *TypedPathTrie[T]) Prune() int {
+	return pt.Trie.prune()
+}
+
+func (trie TypedPathToTrieNode[T]) prune() int {
+	pruned := 0
+	for segment, node := range trie {
+		if node == nil {
+			delete(trie, segment)
+			pruned++
+			continue
+		}
+		pruned += node.Children.prune()
+		if !node.hasValue() && len(node.Children) == 0 {
+			delete(trie, segment)
+			pruned++
+		}
+	}
+
+	return pruned
+}
+
+// Walk calls fn with the full path and value of each path that has a value, until fn returns false. Sibling segments
+// are visited in lexical order and a path before its sub paths. fn must not modify the trie.
+func (pt *TypedPathTrie[T]) Walk(fn func(fullPath string, value T) bool) {
+	pt.Trie.walk(fn)
+}
+
+// walk returns false if fn stopped the walk.
+func (trie TypedPathToTrieNode[T]) walk(fn func(fullPath string, value T) bool) bool {
+	segments := make([]string, 0, len(trie))
+	for segment, node := range trie {
+		if node != nil {
+			segments = append(segments, segment)
+		}
+	}
+	sort.Strings(segments)
+
+	for _, segment := range segments {
+		node := trie[segment]
+		if node.hasValue() && !fn(node.FullPath, node.Value) {
+			return false
+		}
+		if !node.Children.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Paths returns the full paths that have a value, sorted.
+func (pt *TypedPathTrie[T]) Paths() []string {
+	var paths []string
+	pt.Walk(func(fullPath string, _ T) bool {
+		paths = append(paths, fullPath)
+		return true
+	})
+	sort.Strings(paths)
+
+	return paths
+}
+
+// Clone returns a copy of the trie whose nodes can be modified independently, the values are copied by assignment.
+func (pt *TypedPathTrie[T]) Clone() TypedPathTrie[T] {
+	return TypedPathTrie[T]{
+		Trie:          pt.Trie.clone(),
+		PathSeparator: pt.PathSeparator,
+	}
+}
+
+func (trie TypedPathToTrieNode[T]) clone() TypedPathToTrieNode[T] {
+	if trie == nil {
+		return nil
+	}
+
+	ret := make(TypedPathToTrieNode[T], len(trie))
+	for segment, node := range trie {
+		if node == nil {
+			ret[segment] = nil
+			continue
+		}
+		ret[segment] = &TypedTrieNode[T]{
+			Children:         node.Children.clone(),
+			Name:             node.Name,
+			FullPath:         node.FullPath,
+			PathParamCounter: node.PathParamCounter,
+			Value:            node.Value,
+		}
+	}
+
+	return ret
+}
+
+func (pt *TypedPathTrie[T]) createPathTrieNode(segments []string, idx int, isLastSegment bool, val T) *TypedTrieNode[T] {
 	fullPathSegments := segments[:idx+1]
-	node := &TrieNode{
-		Children: make(PathToTrieNode),
+	node := &TypedTrieNode[T]{
+		Children: make(TypedPathToTrieNode[T]),
 		Name:     segments[idx],
 		FullPath: strings.Join(fullPathSegments, pt.PathSeparator),
 	}
@@ -134,45 +351,58 @@ func countPathParam(segments []string) int {
 	return count
 }
 
-// GetValue returns the given node path value, nil if node is not found.
-func (pt *PathTrie) GetValue(path string) interface{} {
-	node := pt.getNode(path)
+// GetValue returns the given node path value, the zero value of T if node is not found.
+func (pt *TypedPathTrie[T]) GetValue(path string) T {
+	node, _ := pt.getNode(path)
 	if node == nil {
-		return nil
+		var noValue T
+		return noValue
 	}
 
 	return node.Value
 }
 
-// GetPathAndValue returns the given node full path and value, nil if node is not found.
-func (pt *PathTrie) GetPathAndValue(path string) (string, interface{}, bool) {
-	node := pt.getNode(path)
+// GetPathAndValue returns the given node full path and value, the zero value of T if node is not found.
+func (pt *TypedPathTrie[T]) GetPathAndValue(path string) (string, T, bool) {
+	lookup := pt.LookupPath(path)
+	return lookup.FullPath, lookup.Value, lookup.Found
+}
+
+// LookupPath returns the path that matches path, as GetPathAndValue, and whether the match was ambiguous.
+func (pt *TypedPathTrie[T]) LookupPath(path string) PathLookup[T] {
+	node, ambiguous := pt.getNode(path)
 	if node == nil {
-		return "", nil, false
+		return PathLookup[T]{}
 	}
 
-	return node.FullPath, node.Value, true
+	return PathLookup[T]{
+		FullPath:  node.FullPath,
+		Value:     node.Value,
+		Found:     true,
+		Ambiguous: ambiguous,
+	}
 }
 
-func (pt *PathTrie) getNode(path string) *TrieNode {
+// getNode returns the most accurate node that matches path, see getMostAccurateNode.
+func (pt *TypedPathTrie[T]) getNode(path string) (*TypedTrieNode[T], bool) {
 	segments := strings.Split(path, pt.PathSeparator)
 
 	nodes := pt.Trie.getMatchNodes(segments, 0)
 
 	if len(nodes) == 0 {
-		return nil
+		return nil, false
 	}
 
 	if len(nodes) == 1 {
-		return nodes[0]
+		return nodes[0], false
 	}
 
 	// if multiple nodes found, return the node with less path params segments
 	return getMostAccurateNode(nodes, path, len(segments))
 }
 
-func (trie PathToTrieNode) getMatchNodes(segments []string, idx int) []*TrieNode {
-	var nodes []*TrieNode
+func (trie TypedPathToTrieNode[T]) getMatchNodes(segments []string, idx int) []*TypedTrieNode[T] {
+	var nodes []*TypedTrieNode[T]
 
 	isLastSegment := idx == len(segments)-1
 
@@ -184,7 +414,7 @@ func (trie PathToTrieNode) getMatchNodes(segments []string, idx int) []*TrieNode
 
 		// If this is the last path segment, then return node if it holds a value.
 		if isLastSegment {
-			if node.Value != nil {
+			if !isZeroValue(node.Value) {
 				nodes = append(nodes, node)
 			}
 			continue
@@ -200,29 +430,55 @@ func (trie PathToTrieNode) getMatchNodes(segments []string, idx int) []*TrieNode
 	return nodes
 }
 
-// getMostAccurateNode returns the node with less path params segments.
-func getMostAccurateNode(nodes []*TrieNode, path string, segmentsLen int) *TrieNode {
-	var retNode *TrieNode
+// getMostAccurateNode returns the exact match of path, or the node with less path params segments. Of the nodes with
+// as few path params segments, the node with the longest literal prefix (e.g. /api/users/{name} before /api/{id}/items),
+// then the lexically first full path is returned, and the match is ambiguous.
+func getMostAccurateNode[T any](nodes []*TypedTrieNode[T], path string, segmentsLen int) (node *TypedTrieNode[T], ambiguous bool) {
+	var retNode *TypedTrieNode[T]
 	minPathParamSegmentsCount := segmentsLen + 1
 
 	for _, node := range nodes {
 		if node.isFullPathMatch(path) {
 			// return exact match
-			return node
+			return node, false
 		}
 
-		// TODO: if node.PathParamCounter == minPathParamSegmentsCount
-		if node.PathParamCounter < minPathParamSegmentsCount {
+		switch {
+		case node.PathParamCounter < minPathParamSegmentsCount:
 			// found more accurate node
 			minPathParamSegmentsCount = node.PathParamCounter
 			retNode = node
+			ambiguous = false
+		case node.PathParamCounter == minPathParamSegmentsCount:
+			ambiguous = true
+			if node.isMoreAccurateThan(retNode) {
+				retNode = node
+			}
 		}
 	}
 
-	return retNode
+	return retNode, ambiguous
+}
+
+// isMoreAccurateThan returns true if the full path of node has a longer literal prefix than the full path of other,
+// or as long and is lexically first.
+func (node *TypedTrieNode[T]) isMoreAccurateThan(other *TypedTrieNode[T]) bool {
+	prefixLen, otherPrefixLen := getLiteralPrefixLen(node.FullPath), getLiteralPrefixLen(other.FullPath)
+	if prefixLen != otherPrefixLen {
+		return prefixLen > otherPrefixLen
+	}
+	return node.FullPath < other.FullPath
 }
 
-func (node *TrieNode) isNameMatch(segment string) bool {
+// getLiteralPrefixLen returns the length of the full path before its first path param.
+func getLiteralPrefixLen(fullPath string) int {
+	if i := strings.Index(fullPath, utils.ParamPrefix); i >= 0 {
+		return i
+	}
+	return len(fullPath)
+}
+
+func (node *TypedTrieNode[T]) isNameMatch(segment string) bool {
 	if utils.IsPathParam(node.Name) {
 		return true
 	}
@@ -234,6 +490,16 @@ func (node *TrieNode) isNameMatch(segment string) bool {
 	return false
 }
 
-func (node *TrieNode) isFullPathMatch(path string) bool {
+func (node *TypedTrieNode[T]) isFullPathMatch(path string) bool {
 	return node.FullPath == path
 }
+
+// hasValue returns true if the node value is not nil (or a nil pointer) and not the zero value of T.
+func (node *TypedTrieNode[T]) hasValue() bool {
+	return !utils.IsNil(node.Value) && !isZeroValue(node.Value)
+}
+
+// isZeroValue returns true if value is the zero value of T, e.g. a nil interface{}.
+func isZeroValue[T any](value T) bool {
+	return reflect.ValueOf(&value).Elem().IsZero()
+}
@@ -136,7 +136,7 @@ func TestPathTrie_getNode(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := pt.getNode(tt.args.path); !reflect.DeepEqual(got, tt.want) {
+			if got, _ := pt.getNode(tt.args.path); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getNode() = %v, want %v", got, tt.want)
 			}
 		})
@@ -405,9 +405,10 @@ func Test_getMostAccurateNode(t *testing.T) {
 		segmentsLen int
 	}
 	tests := []struct {
-		name string
-		args args
-		want *TrieNode
+		name          string
+		args          args
+		want          *TrieNode
+		wantAmbiguous bool
 	}{
 		{
 			name: "exact prefix match",
@@ -444,12 +445,94 @@ func Test_getMostAccurateNode(t *testing.T) {
 			},
 			want: pt.createPathTrieNode([]string{"", "api", "{param1}", "test"}, 3, true, 1),
 		},
+		{
+			name: "as many path params - longest literal prefix",
+			args: args{
+				nodes: []*TrieNode{
+					pt.createPathTrieNode([]string{"", "api", "{id}", "items"}, 3, true, 1),
+					pt.createPathTrieNode([]string{"", "api", "users", "{name}"}, 3, true, 2),
+				},
+				path:        "/api/users/items",
+				segmentsLen: 4,
+			},
+			want:          pt.createPathTrieNode([]string{"", "api", "users", "{name}"}, 3, true, 2),
+			wantAmbiguous: true,
+		},
+		{
+			name: "as many path params - same literal prefix",
+			args: args{
+				nodes: []*TrieNode{
+					pt.createPathTrieNode([]string{"", "api", "{name}", "items"}, 3, true, 1),
+					pt.createPathTrieNode([]string{"", "api", "{id}", "items"}, 3, true, 2),
+				},
+				path:        "/api/users/items",
+				segmentsLen: 4,
+			},
+			want:          pt.createPathTrieNode([]string{"", "api", "{id}", "items"}, 3, true, 2),
+			wantAmbiguous: true,
+		},
+		{
+			name: "less path params match after a tie",
+			args: args{
+				nodes: []*TrieNode{
+					pt.createPathTrieNode([]string{"", "api", "{id}", "{kind}"}, 3, true, 1),
+					pt.createPathTrieNode([]string{"", "api", "{name}", "{type}"}, 3, true, 2),
+					pt.createPathTrieNode([]string{"", "api", "{id}", "items"}, 3, true, 3),
+				},
+				path:        "/api/users/items",
+				segmentsLen: 4,
+			},
+			want: pt.createPathTrieNode([]string{"", "api", "{id}", "items"}, 3, true, 3),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getMostAccurateNode(tt.args.nodes, tt.args.path, tt.args.segmentsLen); !reflect.DeepEqual(got, tt.want) {
+			got, gotAmbiguous := getMostAccurateNode(tt.args.nodes, tt.args.path, tt.args.segmentsLen)
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getMostAccurateNode() = %v, want %v", got, tt.want)
 			}
+			if gotAmbiguous != tt.wantAmbiguous {
+				t.Errorf("getMostAccurateNode() ambiguous = %v, want %v", gotAmbiguous, tt.wantAmbiguous)
+			}
+		})
+	}
+}
+
+func TestPathTrie_LookupPath(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{id}/items", 1)
+	pt.Insert("/api/users/{name}", 2)
+	pt.Insert("/api/users/{name}/{kind}", 3)
+
+	tests := []struct {
+		name string
+		path string
+		want PathLookup[any]
+	}{
+		{
+			name: "ambiguous",
+			path: "/api/users/items",
+			want: PathLookup[any]{FullPath: "/api/users/{name}", Value: 2, Found: true, Ambiguous: true},
+		},
+		{
+			name: "single match",
+			path: "/api/users/cats/dogs",
+			want: PathLookup[any]{FullPath: "/api/users/{name}/{kind}", Value: 3, Found: true},
+		},
+		{
+			name: "no match",
+			path: "/api/users",
+			want: PathLookup[any]{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// repeat to make sure the result doesn't depend on the map iteration order
+			for i := 0; i < 10; i++ {
+				if got := pt.LookupPath(tt.path); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("LookupPath() = %+v, want %+v", got, tt.want)
+				}
+			}
 		})
 	}
 }
@@ -981,7 +1064,286 @@ func TestPathTrie_InsertMerge(t *testing.T) {
 	}
 }
 
+func TestPathTrie_Delete(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/users", 1)
+	pt.Insert("/api/users/{id}", 2)
+	pt.Insert("/api/items/{id}", 3)
+
+	tests := []struct {
+		name        string
+		path        string
+		wantDeleted bool
+	}{
+		{
+			name:        "not found",
+			path:        "/api/orders",
+			wantDeleted: false,
+		},
+		{
+			name:        "path params are not matched",
+			path:        "/api/items/1",
+			wantDeleted: false,
+		},
+		{
+			name:        "path without value",
+			path:        "/api",
+			wantDeleted: false,
+		},
+		{
+			name:        "path with children",
+			path:        "/api/users",
+			wantDeleted: true,
+		},
+		{
+			name:        "leaf path",
+			path:        "/api/items/{id}",
+			wantDeleted: true,
+		},
+		{
+			name:        "deleted path",
+			path:        "/api/items/{id}",
+			wantDeleted: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, pt.Delete(tt.path), tt.wantDeleted)
+		})
+	}
+
+	_, _, found := pt.GetPathAndValue("/api/users")
+	assert.Assert(t, !found)
+	path, value, found := pt.GetPathAndValue("/api/users/1")
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/api/users/{id}")
+	assert.Equal(t, value, 2)
+	// the nodes left without values or children are removed
+	_, ok := pt.Trie[""].Children["api"].Children["items"]
+	assert.Assert(t, !ok)
+	assert.Equal(t, len(pt.Trie[""].Children["api"].Children), 1)
+}
+
+func TestPathTrie_DeleteMatch(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{param1}", 1)
+	pt.Insert("/api/items", 2)
+	pt.Insert("/api/{param1}/items/{param2}", 3)
+
+	tests := []struct {
+		name         string
+		path         string
+		wantPath     string
+		wantDeleted  bool
+		wantRemained []string
+	}{
+		{
+			name:         "not found",
+			path:         "/orders/1",
+			wantRemained: []string{"/api/{param1}", "/api/items", "/api/{param1}/items/{param2}"},
+		},
+		{
+			name:         "exact match is preferred",
+			path:         "/api/items",
+			wantPath:     "/api/items",
+			wantDeleted:  true,
+			wantRemained: []string{"/api/{param1}", "/api/{param1}/items/{param2}"},
+		},
+		{
+			name:         "literal path deletes its path param node",
+			path:         "/api/1/items/2",
+			wantPath:     "/api/{param1}/items/{param2}",
+			wantDeleted:  true,
+			wantRemained: []string{"/api/{param1}"},
+		},
+		{
+			name:        "matches the remaining path param node",
+			path:        "/api/items",
+			wantPath:    "/api/{param1}",
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, deleted := pt.DeleteMatch(tt.path)
+			assert.Equal(t, path, tt.wantPath)
+			assert.Equal(t, deleted, tt.wantDeleted)
+			for _, remained := range tt.wantRemained {
+				assert.Assert(t, pt.GetValue(remained) != nil, "%v was deleted", remained)
+			}
+		})
+	}
+	// all the nodes were left without values or children
+	assert.Equal(t, len(pt.Trie), 0)
+}
+
+func TestPathTrie_Prune(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/users/{id}", 1)
+	pt.Insert("/api/items/{id}", 2)
+	pt.Insert("/api/orders", 3)
+	assert.Equal(t, pt.Prune(), 0)
+
+	// overwriting with no value keeps the nodes
+	pt.Insert("/api/items/{id}", nil)
+	pt.Insert("/api/orders", nil)
+	pt.Trie[""].Children["empty"] = nil
+	assert.Equal(t, pt.Prune(), 4)
+
+	api := pt.Trie[""].Children["api"]
+	assert.Equal(t, len(api.Children), 1)
+	assert.Equal(t, pt.GetValue("/api/users/1"), 1)
+	_, ok := pt.Trie[""].Children["empty"]
+	assert.Assert(t, !ok)
+
+	pt.Insert("/api/users/{id}", nil)
+	assert.Equal(t, pt.Prune(), 4)
+	assert.Equal(t, len(pt.Trie), 0)
+}
+
+func TestGetPathAndValueAs(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{param1}", "id-1")
+	pt.Insert("/api/items", 2)
+
+	path, value, found, err := GetPathAndValueAs[string](&pt, "/api/1")
+	assert.NilError(t, err)
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/api/{param1}")
+	assert.Equal(t, value, "id-1")
+
+	path, value, found, err = GetPathAndValueAs[string](&pt, "/api/items")
+	assert.ErrorContains(t, err, "value of /api/items is a int, not a string")
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/api/items")
+	assert.Equal(t, value, "")
+
+	_, _, found, err = GetPathAndValueAs[string](&pt, "/users")
+	assert.NilError(t, err)
+	assert.Assert(t, !found)
+}
+
+func TestToTyped(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{param1}", "id-1")
+	pt.Insert("/api/{param1}/items", "id-2")
+
+	typed, err := ToTyped[string](&pt)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, typed.Paths(), []string{"/api/{param1}", "/api/{param1}/items"})
+	path, value, found := typed.GetPathAndValue("/api/1/items")
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/api/{param1}/items")
+	assert.Equal(t, value, "id-2")
+
+	pt.Insert("/api/users", 3)
+	_, err = ToTyped[string](&pt)
+	assert.ErrorContains(t, err, "value of /api/users is a int, not a string")
+}
+
+func TestPathTrie_Walk(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/users/{id}", 1)
+	pt.Insert("/api/users", 2)
+	pt.Insert("/api/items", 3)
+	pt.Insert("/api/items/{id}/tags", 4)
+	pt.Insert("/api/orders", nil)
+
+	var paths []string
+	var values []interface{}
+	pt.Walk(func(fullPath string, value interface{}) bool {
+		paths = append(paths, fullPath)
+		values = append(values, value)
+		return true
+	})
+	assert.DeepEqual(t, paths, []string{"/api/items", "/api/items/{id}/tags", "/api/users", "/api/users/{id}"})
+	assert.DeepEqual(t, values, []interface{}{3, 4, 2, 1})
+
+	// returning false stops the walk
+	paths = nil
+	pt.Walk(func(fullPath string, value interface{}) bool {
+		paths = append(paths, fullPath)
+		return len(paths) < 2
+	})
+	assert.DeepEqual(t, paths, []string{"/api/items", "/api/items/{id}/tags"})
+
+	empty := PathTrie{}
+	empty.Walk(func(fullPath string, value interface{}) bool {
+		t.Errorf("Walk() visited %v of an empty trie", fullPath)
+		return true
+	})
+}
+
+func TestPathTrie_Paths(t *testing.T) {
+	pt := NewTyped[string]()
+	pt.Insert("/api/a-b", "1")
+	pt.Insert("/api/a/b", "2")
+	pt.Insert("/api", "3")
+	pt.Insert("/api/a", "")
+
+	assert.DeepEqual(t, pt.Paths(), []string{"/api", "/api/a-b", "/api/a/b"})
+	empty := New()
+	assert.Assert(t, empty.Paths() == nil)
+}
+
 func marshal(obj interface{}) string {
 	objB, _ := json.Marshal(obj)
 	return string(objB)
 }
+
+func TestTypedPathTrie(t *testing.T) {
+	type value struct {
+		id string
+	}
+	pt := NewTyped[*value]()
+	assert.Equal(t, pt.Insert("/api/{param1}/items", &value{id: "1"}), true)
+	assert.Equal(t, pt.Insert("/api/items", &value{id: "2"}), true)
+	assert.Equal(t, pt.Insert("/api/items", &value{id: "3"}), false)
+
+	assert.Equal(t, pt.GetValue("/api/1/items").id, "1")
+	path, val, found := pt.GetPathAndValue("/api/items")
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/api/items")
+	assert.Equal(t, val.id, "3")
+	assert.Assert(t, pt.GetValue("/api/1") == nil)
+
+	assert.Equal(t, pt.Delete("/api/items"), true)
+	_, _, found = pt.GetPathAndValue("/api/items")
+	assert.Assert(t, !found)
+	assert.Equal(t, pt.Delete("/api/items"), false)
+}
+
+func TestTypedPathTrie_ZeroValue(t *testing.T) {
+	// the zero value is no value, like nil of the untyped trie
+	pt := NewTyped[string]()
+	assert.Equal(t, pt.Insert("/api", ""), true)
+	_, _, found := pt.GetPathAndValue("/api")
+	assert.Assert(t, !found)
+	assert.Equal(t, pt.Insert("/api", "a"), true)
+	assert.Equal(t, pt.Insert("/api", "b"), false)
+	assert.Equal(t, pt.GetValue("/api"), "b")
+
+	assert.Equal(t, pt.Delete("/api"), true)
+	assert.Equal(t, len(pt.Trie), 0)
+}
+
+func TestPathTrie_Clone(t *testing.T) {
+	pt := New()
+	pt.Insert("/api/{param1}/items", "1")
+	pt.Insert("/api/items", "2")
+
+	cloned := pt.Clone()
+	assert.DeepEqual(t, cloned, pt)
+
+	cloned.Insert("/api/items", "3")
+	cloned.Insert("/api/users", "4")
+	assert.Equal(t, cloned.Delete("/api/{param1}/items"), true)
+
+	assert.Equal(t, pt.GetValue("/api/items"), "2")
+	assert.Equal(t, pt.GetValue("/api/users"), nil)
+	assert.Equal(t, pt.GetValue("/api/1/items"), "1")
+	assert.Equal(t, cloned.GetValue("/api/items"), "3")
+
+	empty := PathTrie{}
+	assert.Assert(t, empty.Clone().Trie == nil)
+}
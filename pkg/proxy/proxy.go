@@ -0,0 +1,147 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proxy implements a reverse proxy that learns and/or diffs the traffic it forwards to an upstream.
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/internal/httpcapture"
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/speculator"
+	_telemetry "github.com/apiclarity/speculator/pkg/telemetry"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+const defaultMaxBodySize = 1 << 20 // 1 MB
+
+type Config struct {
+	// Upstream all requests are forwarded to
+	Upstream *url.URL
+	// Learn each forwarded interaction
+	Learn bool
+	// Diff each forwarded interaction against DiffSource, diffs are reported to OnDiff
+	Diff       bool
+	DiffSource _spec.DiffSource
+	OnDiff     func(diff *_spec.APIDiff)
+	// MaxBodySize is the maximum request/response body bytes captured per interaction (bodies are always forwarded in full).
+	// Bodies larger than MaxBodySize are marked as truncated and are not learned. Defaults to 1MB.
+	MaxBodySize int64
+}
+
+type Proxy struct {
+	config       Config
+	reverseProxy *httputil.ReverseProxy
+	// speculator is safe for concurrent use, the forwarded interactions are learned and diffed concurrently
+	speculator *speculator.Speculator
+}
+
+// responseVersionKey is the context key of the upstream response protocol version of a forwarded request.
+type responseVersionKey struct{}
+
+func New(s *speculator.Speculator, config Config) (*Proxy, error) {
+	if config.Upstream == nil {
+		return nil, fmt.Errorf("upstream is missing")
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxBodySize
+	}
+	if config.Diff && config.DiffSource == "" {
+		config.DiffSource = _spec.DiffSourceReconstructed
+	}
+
+	reverseProxy := httputil.NewSingleHostReverseProxy(config.Upstream)
+	reverseProxy.ModifyResponse = recordResponseVersion
+
+	return &Proxy{
+		config:       config,
+		reverseProxy: reverseProxy,
+		speculator:   s,
+	}, nil
+}
+
+// recordResponseVersion saves the protocol version of the upstream response, see responseVersionKey.
+func recordResponseVersion(resp *http.Response) error {
+	if respVersion, ok := resp.Request.Context().Value(responseVersionKey{}).(*string); ok {
+		*respVersion = resp.Proto
+	}
+	return nil
+}
+
+// ServeHTTP forwards the request to the upstream while capturing the request and response,
+// the captured interaction is learned/diffed once the response was written.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	capturedAt := time.Now().UTC()
+	reqBody := httpcapture.NewBuffer(p.config.MaxBodySize)
+	if r.Body != nil && r.Body != http.NoBody {
+		teeBody := httpcapture.NewTeeReadCloser(r.Body, p.config.MaxBodySize)
+		reqBody = teeBody.Buffer
+		r.Body = teeBody
+	}
+	// save the request before the reverse proxy modifies it
+	capturedReq := r.Clone(r.Context())
+	capturedReq.URL.Scheme = p.config.Upstream.Scheme
+	// not set if the upstream didn't respond
+	respVersion := new(string)
+
+	cw := httpcapture.NewResponseWriter(w, p.config.MaxBodySize)
+	p.reverseProxy.ServeHTTP(cw, r.WithContext(context.WithValue(r.Context(), responseVersionKey{}, respVersion)))
+
+	capturedReq.Body = reqBody.ReadCloser()
+	telemetry, err := _telemetry.FromHTTP(capturedReq, cw.Response(*respVersion),
+		_telemetry.WithMaxBodySize(p.config.MaxBodySize),
+		_telemetry.WithTruncatedBodies(reqBody.Truncated, cw.Buffer.Truncated),
+		_telemetry.WithDestinationAddress(httpcapture.AddressWithPort(p.config.Upstream.Host, p.config.Upstream.Scheme)),
+		_telemetry.WithRequestID(uuid.New().String()),
+		_telemetry.WithTimestamp(capturedAt),
+		_telemetry.WithLatency(time.Since(capturedAt)))
+	if err != nil {
+		log.Errorf("Failed to create telemetry: %v", err)
+		return
+	}
+
+	p.handleTelemetry(telemetry)
+}
+
+func (p *Proxy) handleTelemetry(telemetry *_spec.Telemetry) {
+	// diff before learning, so the interaction is compared with the state that preceded it
+	if p.config.Diff {
+		apiDiff, err := p.speculator.DiffTelemetry(telemetry, p.config.DiffSource)
+		if err != nil {
+			log.Debugf("Failed to diff telemetry: %v", err)
+		} else if apiDiff != nil && p.config.OnDiff != nil {
+			p.config.OnDiff(apiDiff)
+		}
+	}
+
+	if p.config.Learn {
+		if err := p.speculator.LearnTelemetry(telemetry); err != nil {
+			log.Errorf("Failed to learn telemetry: %v", err)
+		}
+	}
+}
+
+// ReloadSpeculatorConfig reloads the speculator config without interrupting the proxied traffic, see speculator.ReloadConfig.
+func (p *Proxy) ReloadSpeculatorConfig(config speculator.Config) {
+	p.speculator.ReloadConfig(config)
+}
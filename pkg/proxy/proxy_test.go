@@ -0,0 +1,151 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/speculator"
+)
+
+func TestProxy_ServeHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, string(body), `{"name":"foo"}`)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	assert.NilError(t, err)
+
+	var diffs []*_spec.APIDiff
+	s := speculator.CreateSpeculator(speculator.Config{})
+	p, err := New(s, Config{
+		Upstream: upstreamURL,
+		Learn:    true,
+		Diff:     true,
+		OnDiff: func(diff *_spec.APIDiff) {
+			diffs = append(diffs, diff)
+		},
+	})
+	assert.NilError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "http://api.example.com/api/users?foo=bar", strings.NewReader(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	// response is forwarded as is
+	assert.Equal(t, w.Code, http.StatusCreated)
+	assert.Equal(t, w.Body.String(), `{"id":1}`)
+
+	learnedSpec, ok := s.Specs[speculator.GetSpecKey("api.example.com", upstreamURL.Port())]
+	assert.Assert(t, ok)
+	pathItem := learnedSpec.LearningSpec.GetPathItem("/api/users")
+	assert.Assert(t, pathItem != nil)
+	assert.Assert(t, pathItem.Post != nil)
+	_, ok = pathItem.Post.Responses.StatusCodeResponses[http.StatusCreated]
+	assert.Assert(t, ok)
+
+	// there is no approved spec to diff with
+	assert.Equal(t, len(diffs), 0)
+}
+
+func TestProxy_ServeHTTP_TruncatedBody(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"name":"a long name"}`))
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	assert.NilError(t, err)
+
+	s := speculator.CreateSpeculator(speculator.Config{})
+	p, err := New(s, Config{
+		Upstream:    upstreamURL,
+		Learn:       true,
+		MaxBodySize: 5,
+	})
+	assert.NilError(t, err)
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://api.example.com/api/users", nil))
+
+	// the client gets the full body even though it was not captured
+	assert.Equal(t, w.Body.String(), `{"id":1,"name":"a long name"}`)
+
+	learnedSpec := s.Specs[speculator.GetSpecKey("api.example.com", upstreamURL.Port())]
+	pathItem := learnedSpec.LearningSpec.GetPathItem("/api/users")
+	assert.Assert(t, pathItem != nil)
+	assert.Assert(t, pathItem.Get.Responses.StatusCodeResponses[http.StatusOK].Schema == nil)
+}
+
+func TestProxy_ServeHTTP_Versions(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`ok`))
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	assert.NilError(t, err)
+
+	var versionsLock sync.Mutex
+	var reqVersions, respVersions []string
+	s := speculator.CreateSpeculator(speculator.Config{
+		Enrichers: []speculator.Enricher{func(telemetry *_spec.Telemetry) (map[string]string, error) {
+			versionsLock.Lock()
+			defer versionsLock.Unlock()
+			reqVersions = append(reqVersions, telemetry.Request.Common.Version)
+			respVersions = append(respVersions, telemetry.Response.Common.Version)
+			return nil, nil
+		}},
+	})
+	p, err := New(s, Config{
+		Upstream: upstreamURL,
+		Learn:    true,
+	})
+	assert.NilError(t, err)
+
+	// the interactions are learned concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/users", nil)
+			req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+			p.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, len(reqVersions), 10)
+	for i := range reqVersions {
+		assert.Equal(t, reqVersions[i], "HTTP/2.0")
+		// the response version is the upstream one
+		assert.Equal(t, respVersions[i], "HTTP/1.1")
+	}
+}
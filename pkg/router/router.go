@@ -0,0 +1,117 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package router exposes the spec path matching as an http.Handler, routing requests by their approved operation.
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+type contextKey struct{}
+
+// Validator is called for a matched request before it is routed to its handler.
+// When an error is returned, the request is rejected with http.StatusBadRequest.
+type Validator func(r *http.Request, match *_spec.PathMatch) error
+
+type Router struct {
+	spec *_spec.Spec
+	// map method and approved (parameterized) path into its handler
+	handlers        map[string]http.Handler
+	defaultHandler  http.Handler
+	notFoundHandler http.Handler
+	validators      []Validator
+}
+
+// New creates a Router that matches requests against the approved paths of s.
+// Unmatched requests are answered with http.StatusNotFound unless a not found handler is set.
+func New(s *_spec.Spec) *Router {
+	return &Router{
+		spec:            s,
+		handlers:        make(map[string]http.Handler),
+		notFoundHandler: http.NotFoundHandler(),
+	}
+}
+
+// Handle sets the handler for the approved (parameterized) path and method, e.g. GET /api/{param1}.
+func (r *Router) Handle(method, path string, handler http.Handler) {
+	r.handlers[getHandlerKey(method, path)] = handler
+}
+
+// HandleDefault sets the handler for matched requests that have no specific handler, e.g. an upstream proxy.
+func (r *Router) HandleDefault(handler http.Handler) {
+	r.defaultHandler = handler
+}
+
+// HandleNotFound sets the handler for requests that does not match an approved operation.
+func (r *Router) HandleNotFound(handler http.Handler) {
+	r.notFoundHandler = handler
+}
+
+// Validate adds a validator that will be run on each matched request.
+func (r *Router) Validate(validator Validator) {
+	r.validators = append(r.validators, validator)
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	match, found := r.spec.MatchPath(req.Method, req.URL.Path)
+	if !found || !match.Approved {
+		r.notFoundHandler.ServeHTTP(w, req)
+		return
+	}
+
+	for _, validator := range r.validators {
+		if err := validator(req, match); err != nil {
+			log.Debugf("Request validation failed. method=%v, path=%v: %v", req.Method, req.URL.Path, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	handler, ok := r.handlers[getHandlerKey(req.Method, match.Path)]
+	if !ok {
+		handler = r.defaultHandler
+	}
+	if handler == nil {
+		http.Error(w, fmt.Sprintf("no handler for %v %v", req.Method, match.Path), http.StatusNotImplemented)
+		return
+	}
+
+	handler.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKey{}, match)))
+}
+
+// MatchFromContext returns the path match of a request routed by a Router.
+func MatchFromContext(ctx context.Context) (*_spec.PathMatch, bool) {
+	match, ok := ctx.Value(contextKey{}).(*_spec.PathMatch)
+	return match, ok
+}
+
+// ValidatePathParams is a Validator that verifies the path param values match their approved types and formats.
+func ValidatePathParams(req *http.Request, match *_spec.PathMatch) error {
+	if _, err := _spec.ExtractPathParamValues(match.Path, req.URL.Path, match.PathItem.Parameters); err != nil {
+		return fmt.Errorf("invalid path params: %w", err)
+	}
+	return nil
+}
+
+func getHandlerKey(method, path string) string {
+	return method + " " + path
+}
@@ -0,0 +1,133 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func createTelemetry(method, path string) *_spec.Telemetry {
+	return &_spec.Telemetry{
+		RequestID: "req-id",
+		Request: &_spec.Request{
+			Method: method,
+			Path:   path,
+			Host:   "host",
+			Common: &_spec.Common{},
+		},
+		Response: &_spec.Response{
+			StatusCode: "200",
+			Common:     &_spec.Common{},
+		},
+	}
+}
+
+func createApprovedSpec(t *testing.T) *_spec.Spec {
+	t.Helper()
+	s := _spec.CreateDefaultSpec("host", "80", _spec.OperationGeneratorConfig{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry(http.MethodGet, "/api/1/items")))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry(http.MethodGet, "/api/2/items")))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry(http.MethodGet, "/api/learned")))
+
+	suggestedReview := s.CreateSuggestedReview()
+	approvedReview := &_spec.ApprovedSpecReview{
+		PathToPathItem: suggestedReview.PathToPathItem,
+	}
+	for _, item := range suggestedReview.PathItemsReview {
+		if item.ParameterizedPath == "/api/learned" {
+			continue
+		}
+		approvedReview.PathItemsReview = append(approvedReview.PathItemsReview, &_spec.ApprovedSpecReviewPathItem{
+			ReviewPathItem: item.ReviewPathItem,
+			PathUUID:       "1",
+		})
+	}
+	assert.NilError(t, s.ApplyApprovedReview(approvedReview))
+
+	return s
+}
+
+func TestRouter_ServeHTTP(t *testing.T) {
+	r := New(createApprovedSpec(t))
+	r.Validate(ValidatePathParams)
+	r.Handle(http.MethodGet, "/api/{param1}/items", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		match, ok := MatchFromContext(req.Context())
+		assert.Assert(t, ok)
+		assert.Equal(t, match.PathParams["param1"], "3")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		defaultHandler http.Handler
+		wantStatusCode int
+	}{
+		{
+			name:           "routed to handler",
+			method:         http.MethodGet,
+			path:           "/api/3/items",
+			wantStatusCode: http.StatusAccepted,
+		},
+		{
+			name:           "invalid path param",
+			method:         http.MethodGet,
+			path:           "/api/abc/items",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "method not approved",
+			method:         http.MethodPost,
+			path:           "/api/3/items",
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "learned path that was not approved",
+			method:         http.MethodGet,
+			path:           "/api/learned",
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, w.Code, tt.wantStatusCode)
+		})
+	}
+}
+
+func TestRouter_HandleDefault(t *testing.T) {
+	r := New(createApprovedSpec(t))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/3/items", nil))
+	assert.Equal(t, w.Code, http.StatusNotImplemented)
+
+	r.HandleDefault(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/3/items", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+}
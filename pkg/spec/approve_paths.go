@@ -0,0 +1,185 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+// ApproveOption configures the approval of learned paths, see Spec.ApprovePaths and Spec.ApplyApprovedReview.
+type ApproveOption func(*approveOptions)
+
+type approveOptions struct {
+	minOperationHits int
+}
+
+// WithMinOperationHits approves only the operations learned from at least minHits telemetries, the other operations
+// remain in the learning spec, so that rare one-off requests (often of scanners) are not documented.
+func WithMinOperationHits(minHits int) ApproveOption {
+	return func(o *approveOptions) {
+		o.minOperationHits = minHits
+	}
+}
+
+func createApproveOptions(opts []ApproveOption) *approveOptions {
+	options := &approveOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// ApprovePaths approves the learned paths (e.g. /users/1) only, the other learned paths are left for a later review.
+// The paths are approved under their parameterized path, grouped the same way as in the suggested review. A
+// parameterized path that is already approved is merged with the paths, keeping its path params.
+// Ignored operations are never approved.
+func (s *Spec) ApprovePaths(paths []string, opts ...ApproveOption) error {
+	defer s.observers.notify()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	options := createApproveOptions(opts)
+
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths to approve")
+	}
+	for _, path := range paths {
+		if _, ok := s.LearningSpec.PathItems[path]; !ok {
+			return fmt.Errorf("path %v was not learned", path)
+		}
+	}
+
+	// first update the approval into a copy of the state, in case the validation will fail
+	clonedSpec, err := s.SpecInfoClone()
+	if err != nil {
+		return fmt.Errorf("failed to clone spec. %v", err)
+	}
+	clonedSpec.OpGenerator = s.OpGenerator
+
+	parameterizedPathToPaths := make(map[string]map[string]bool)
+	for _, path := range paths {
+		parameterizedPath := clonedSpec.getLearningParameterizedPath(path)
+		if _, ok := parameterizedPathToPaths[parameterizedPath]; !ok {
+			parameterizedPathToPaths[parameterizedPath] = make(map[string]bool)
+		}
+		parameterizedPathToPaths[parameterizedPath][path] = true
+	}
+	parameterizedPaths := make([]string, 0, len(parameterizedPathToPaths))
+	for parameterizedPath := range parameterizedPathToPaths {
+		parameterizedPaths = append(parameterizedPaths, parameterizedPath)
+	}
+	sort.Strings(parameterizedPaths)
+	for _, parameterizedPath := range parameterizedPaths {
+		clonedSpec.approveLearnedPaths(parameterizedPath, parameterizedPathToPaths[parameterizedPath], options)
+	}
+
+	oasJSON, err := clonedSpec.generateApprovedOASJson()
+	if err != nil {
+		return fmt.Errorf("failed to generate Open API Spec. %w", err)
+	}
+	s.SpecInfo = clonedSpec.SpecInfo
+	s.oasCache.set(oasJSON)
+	s.queueSpecApprovedEvent(parameterizedPaths)
+
+	return nil
+}
+
+// approveLearnedPaths moves the learned path items of paths into the approved path item of parameterizedPath.
+func (s *Spec) approveLearnedPaths(parameterizedPath string, paths map[string]bool, options *approveOptions) {
+	mergedPathItem := &oapi_spec.PathItem{}
+	approvedPathItem, isApproved := s.ApprovedSpec.PathItems[parameterizedPath]
+	if isApproved {
+		mergedPathItem = MergePathItems(mergedPathItem, approvedPathItem)
+		mergedPathItem.Parameters = approvedPathItem.Parameters
+	}
+
+	approvedPaths := make(map[string]bool)
+	for path := range paths {
+		// ignored operations are never approved
+		pathItem := s.removeIgnoredOperations(path, s.LearningSpec.PathItems[path])
+		if pathItem == nil {
+			log.Warnf("Ignoring approval of path with only ignored operations. path=%v", path)
+			continue
+		}
+		pathItem = s.removeRareOperations(path, pathItem, options.minOperationHits)
+		if pathItem == nil {
+			log.Infof("Not approving path with only rare operations. path=%v", path)
+			continue
+		}
+		mergedPathItem = MergePathItems(mergedPathItem, pathItem)
+		approvedPaths[path] = true
+		s.removeApprovedLearnedOperations(path, options.minOperationHits)
+	}
+	if len(approvedPaths) == 0 {
+		return
+	}
+
+	if len(mergedPathItem.Parameters) == 0 {
+		addPathParamsToPathItem(mergedPathItem, parameterizedPath, approvedPaths, s.isLearningCompositePathParams())
+	}
+	s.ApprovedSpec.PathItems[parameterizedPath] = mergedPathItem
+	if !isApproved {
+		s.ApprovedPathTrie.Insert(parameterizedPath, uuid.New().String())
+	}
+	s.ApprovedSpec.SecurityDefinitions = updateSecurityDefinitionsFromPathItem(s.ApprovedSpec.SecurityDefinitions, mergedPathItem)
+}
+
+// removeRareOperations returns pathItem without the operations of the learned path that were learned from less than
+// minHits telemetries, or nil if all its operations are rare.
+func (s *Spec) removeRareOperations(path string, pathItem *oapi_spec.PathItem, minHits int) *oapi_spec.PathItem {
+	if minHits <= 0 {
+		return pathItem
+	}
+
+	for _, method := range supportedMethods {
+		if GetOperationFromPathItem(pathItem, method) != nil && s.getOperationHitCount(path, method) < minHits {
+			pathItem = CopyPathItemWithNewOperation(pathItem, method, nil)
+		}
+	}
+	if isEmptyPathItem(pathItem) {
+		return nil
+	}
+
+	return pathItem
+}
+
+// removeApprovedLearnedOperations removes the learned path from the learning spec once approved,
+// only its rare operations (see removeRareOperations) remain in learning.
+func (s *Spec) removeApprovedLearnedOperations(path string, minHits int) {
+	pathItem, ok := s.LearningSpec.PathItems[path]
+	if !ok || minHits <= 0 {
+		delete(s.LearningSpec.PathItems, path)
+		return
+	}
+
+	rarePathItem := pathItem
+	for _, method := range supportedMethods {
+		if GetOperationFromPathItem(rarePathItem, method) != nil && s.getOperationHitCount(path, method) >= minHits {
+			rarePathItem = CopyPathItemWithNewOperation(rarePathItem, method, nil)
+		}
+	}
+	if isEmptyPathItem(rarePathItem) {
+		delete(s.LearningSpec.PathItems, path)
+		return
+	}
+	s.LearningSpec.PathItems[path] = rarePathItem
+}
@@ -0,0 +1,114 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_ApprovePaths(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMergePathsTestPaths(t, s, "/users/1", "/users/2", "/health", "/debug")
+
+	assert.NilError(t, s.ApprovePaths([]string{"/users/1", "/health"}))
+
+	pathItem := s.ApprovedSpec.GetPathItem("/users/{param1}")
+	assert.Assert(t, pathItem != nil)
+	assert.Assert(t, pathItem.Get != nil)
+	assert.Equal(t, len(pathItem.Parameters), 1)
+	assert.Equal(t, pathItem.Parameters[0].Type, schemaTypeInteger)
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/health") != nil)
+	for _, path := range []string{"/users/3", "/health"} {
+		_, _, found := s.ApprovedPathTrie.GetPathAndValue(path)
+		assert.Assert(t, found, path)
+	}
+
+	// the paths that were not approved are still learned
+	assert.DeepEqual(t, getSuggestedReviewPaths(s), map[string]map[string]bool{
+		"/users/{param1}": {"/users/2": true},
+		"/debug":          {"/debug": true},
+	})
+	_, pathID, _ := s.ApprovedPathTrie.GetPathAndValue("/users/{param1}")
+
+	// approving into an approved parameterized path keeps its path params and id
+	assert.NilError(t, s.ApprovePaths([]string{"/users/2"}))
+	assert.Equal(t, len(s.ApprovedSpec.GetPathItem("/users/{param1}").Parameters), 1)
+	_, mergedPathID, _ := s.ApprovedPathTrie.GetPathAndValue("/users/{param1}")
+	assert.Equal(t, mergedPathID, pathID)
+	assert.DeepEqual(t, getSuggestedReviewPaths(s), map[string]map[string]bool{
+		"/debug": {"/debug": true},
+	})
+}
+
+func TestSpec_ApprovePaths_IgnoredOperations(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMergePathsTestPaths(t, s, "/debug")
+	assert.NilError(t, s.IgnoreOperation("/debug", http.MethodGet))
+
+	assert.NilError(t, s.ApprovePaths([]string{"/debug"}))
+	assert.Equal(t, len(s.ApprovedSpec.PathItems), 0)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/debug") != nil)
+}
+
+func TestSpec_ApprovePaths_Errors(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMergePathsTestPaths(t, s, "/users/1")
+
+	assert.ErrorContains(t, s.ApprovePaths(nil), "no paths to approve")
+	assert.ErrorContains(t, s.ApprovePaths([]string{"/users/1", "/users/2"}), "was not learned")
+	// nothing is approved on errors
+	assert.Equal(t, len(s.ApprovedSpec.PathItems), 0)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/users/1") != nil)
+}
+
+func learnMinOperationHitsTestPaths(t *testing.T, s *Spec) {
+	t.Helper()
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/users", "host", "200", "", Data.RespBody)))
+	}
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/users", "host", "200", "", Data.RespBody)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/scan", "host", "200", "", Data.RespBody)))
+}
+
+func TestSpec_ApprovePaths_WithMinOperationHits(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMinOperationHitsTestPaths(t, s)
+
+	assert.NilError(t, s.ApprovePaths([]string{"/users", "/scan"}, WithMinOperationHits(2)))
+
+	approvedPathItem := s.ApprovedSpec.GetPathItem("/users")
+	assert.Assert(t, approvedPathItem != nil)
+	assert.Assert(t, approvedPathItem.Get != nil)
+	assert.Assert(t, approvedPathItem.Post == nil)
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/scan") == nil)
+
+	// the rare operations remain in learning
+	learnedPathItem := s.LearningSpec.GetPathItem("/users")
+	assert.Assert(t, learnedPathItem != nil)
+	assert.Assert(t, learnedPathItem.Get == nil)
+	assert.Assert(t, learnedPathItem.Post != nil)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/scan") != nil)
+
+	// and are approved once frequent enough
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/users", "host", "200", "", Data.RespBody)))
+	assert.NilError(t, s.ApprovePaths([]string{"/users"}, WithMinOperationHits(2)))
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/users").Get != nil)
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/users").Post != nil)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/users") == nil)
+}
@@ -16,9 +16,6 @@
 package spec
 
 import (
-	"encoding/json"
-	"fmt"
-
 	oapi_spec "github.com/go-openapi/spec"
 )
 
@@ -34,17 +31,7 @@ func (a *ApprovedSpec) GetPathItem(path string) *oapi_spec.PathItem {
 	return nil
 }
 
+// Clone returns a deep copy of the approved spec, nil if a is nil.
 func (a *ApprovedSpec) Clone() (*ApprovedSpec, error) {
-	clonedApprovedSpec := new(ApprovedSpec)
-
-	approvedSpecB, err := json.Marshal(a)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal approved spec: %w", err)
-	}
-
-	if err := json.Unmarshal(approvedSpecB, &clonedApprovedSpec); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal approved spec: %w", err)
-	}
-
-	return clonedApprovedSpec, nil
+	return cloneApprovedSpec(a), nil
 }
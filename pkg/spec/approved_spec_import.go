@@ -0,0 +1,353 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+const (
+	parametersRefPrefix = "#/parameters/"
+	responsesRefPrefix  = "#/responses/"
+)
+
+// ApprovedSpecImportReport describes the changes of an imported approved spec, see Spec.ImportApprovedSpec.
+type ApprovedSpecImportReport struct {
+	AddedPaths   []string
+	UpdatedPaths []string
+	RemovedPaths []string
+	// Warnings describe the parts of the imported spec that can't be represented in the approved spec, which were dropped
+	Warnings []string
+}
+
+// ImportApprovedSpec replaces the approved spec with an exported (see Spec.GenerateOASYaml) and externally edited
+// one, json or yaml, swagger 2.0 or OpenAPI 3.x.
+// The refs of the imported spec are inlined, and the Cookie header params and x-variants schemas of exported specs
+// are converted back into cookie params and body variants. The base path is prepended to the paths. The parts of
+// the spec that can't be represented (e.g. top level security or remote refs) are dropped and reported as warnings.
+// Approved paths keep their path ID, also when only their path param names were edited.
+func (s *Spec) ImportApprovedSpec(rawSpec []byte) (*ApprovedSpecImportReport, error) {
+	jsonSpec, err := convertToSwaggerJSON(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRawJSONSpec(jsonSpec); err != nil {
+		return nil, fmt.Errorf("imported spec is not valid. %w", err)
+	}
+	swagger := &oapi_spec.Swagger{}
+	if err := json.Unmarshal(jsonSpec, swagger); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+
+	importer := &approvedSpecImporter{swagger: swagger}
+	pathItems := importer.importPathItems()
+
+	defer s.observers.notify()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// first update the import into a copy of the state, in case the validation will fail
+	clonedSpec, err := s.SpecInfoClone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone spec. %v", err)
+	}
+	report, err := clonedSpec.reconcileApprovedPathItems(pathItems)
+	if err != nil {
+		return nil, err
+	}
+	report.Warnings = importer.warnings
+	clonedSpec.ApprovedSpec.SecurityDefinitions = swagger.SecurityDefinitions
+	if clonedSpec.ApprovedSpec.SecurityDefinitions == nil {
+		clonedSpec.ApprovedSpec.SecurityDefinitions = oapi_spec.SecurityDefinitions{}
+	}
+
+	oasJSON, err := clonedSpec.generateApprovedOASJson()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Open API Spec. %w", err)
+	}
+	s.SpecInfo = clonedSpec.SpecInfo
+	s.oasCache.set(oasJSON)
+	importedPaths := make([]string, 0, len(pathItems))
+	for path := range pathItems {
+		importedPaths = append(importedPaths, path)
+	}
+	s.queueSpecApprovedEvent(importedPaths)
+
+	return report, nil
+}
+
+// reconcileApprovedPathItems replaces the approved path items with pathItems and updates the approved path trie.
+func (s *Spec) reconcileApprovedPathItems(pathItems map[string]*oapi_spec.PathItem) (*ApprovedSpecImportReport, error) {
+	report := &ApprovedSpecImportReport{}
+
+	// the path ids of the removed paths by their path shape, for paths whose param names were edited
+	removedPathIDs := make(map[string]string)
+	for _, path := range getSortedPaths(s.ApprovedSpec.PathItems, nil) {
+		if _, ok := pathItems[path]; ok {
+			continue
+		}
+		if approvedPath, pathID, found := s.ApprovedPathTrie.GetPathAndValue(path); found && approvedPath == path {
+			removedPathIDs[getPathShape(path)] = pathID
+		}
+		s.ApprovedPathTrie.Delete(path)
+		delete(s.ApprovedSpec.PathItems, path)
+		report.RemovedPaths = append(report.RemovedPaths, path)
+	}
+
+	for _, path := range getSortedPaths(pathItems, nil) {
+		pathItem := pathItems[path]
+		approvedPathItem, isApproved := s.ApprovedSpec.PathItems[path]
+		s.ApprovedSpec.PathItems[path] = pathItem
+		if isApproved {
+			hasDiff, err := compareObjects(approvedPathItem, pathItem)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare path %v: %v", path, err)
+			}
+			if hasDiff {
+				report.UpdatedPaths = append(report.UpdatedPaths, path)
+			}
+			continue
+		}
+
+		if pathID, ok := removedPathIDs[getPathShape(path)]; ok {
+			delete(removedPathIDs, getPathShape(path))
+			s.ApprovedPathTrie.Insert(path, pathID)
+		} else {
+			s.ApprovedPathTrie.Insert(path, uuid.New().String())
+		}
+		report.AddedPaths = append(report.AddedPaths, path)
+	}
+
+	return report, nil
+}
+
+// getPathShape returns path without its path param names, e.g. /users/{} for /users/{id}.
+func getPathShape(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if utils.IsPathParam(segment) {
+			segments[i] = utils.ParamPrefix + utils.ParamSuffix
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+type approvedSpecImporter struct {
+	swagger  *oapi_spec.Swagger
+	warnings []string
+}
+
+func (i *approvedSpecImporter) warnf(location *field.Path, format string, args ...interface{}) {
+	i.warnings = append(i.warnings, location.String()+": "+fmt.Sprintf(format, args...))
+}
+
+func (i *approvedSpecImporter) importPathItems() map[string]*oapi_spec.PathItem {
+	swagger := i.swagger
+	for name, isSet := range map[string]bool{
+		"consumes": len(swagger.Consumes) > 0,
+		"produces": len(swagger.Produces) > 0,
+		"schemes":  len(swagger.Schemes) > 0,
+		"security": len(swagger.Security) > 0,
+		"tags":     len(swagger.Tags) > 0,
+	} {
+		if isSet {
+			i.warnf(field.NewPath(name), "top level %v are not supported, set them on the operations instead", name)
+		}
+	}
+	sort.Strings(i.warnings)
+
+	pathItems := make(map[string]*oapi_spec.PathItem)
+	if swagger.Paths == nil {
+		return pathItems
+	}
+	basePath := strings.TrimSuffix(swagger.BasePath, "/")
+	paths := make([]string, 0, len(swagger.Paths.Paths))
+	for path := range swagger.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		pathItem := swagger.Paths.Paths[path]
+		location := field.NewPath("paths").Key(path)
+		if pathItem.Ref.String() != "" {
+			i.warnf(location, "path item refs are not supported, the path was dropped")
+			continue
+		}
+		pathItem.Parameters = i.importParameters(location.Child("parameters"), pathItem.Parameters)
+		for _, method := range supportedMethods {
+			operation := GetOperationFromPathItem(&pathItem, method)
+			if operation == nil {
+				continue
+			}
+			i.importOperation(location.Child(strings.ToLower(method)), operation)
+		}
+		pathItems[basePath+path] = &pathItem
+	}
+
+	return pathItems
+}
+
+func (i *approvedSpecImporter) importOperation(location *field.Path, operation *oapi_spec.Operation) {
+	operation.Parameters = i.importParameters(location.Child("parameters"), operation.Parameters)
+	if operation.Responses == nil {
+		return
+	}
+	responsesPath := location.Child("responses")
+	if operation.Responses.Default != nil {
+		operation.Responses.Default = i.importResponse(responsesPath.Child("default"), operation.Responses.Default)
+	}
+	for code := range operation.Responses.StatusCodeResponses {
+		response := operation.Responses.StatusCodeResponses[code]
+		if imported := i.importResponse(responsesPath.Key(fmt.Sprint(code)), &response); imported != nil {
+			operation.Responses.StatusCodeResponses[code] = *imported
+		} else {
+			delete(operation.Responses.StatusCodeResponses, code)
+		}
+	}
+}
+
+// importParameters resolves the parameter refs and inlines the body schema refs. Cookie header params are converted
+// back into cookie params.
+func (i *approvedSpecImporter) importParameters(location *field.Path, params []oapi_spec.Parameter) []oapi_spec.Parameter {
+	var ret []oapi_spec.Parameter
+	for idx := range params {
+		param := params[idx]
+		paramPath := location.Index(idx)
+		if ref := param.Ref.String(); ref != "" {
+			resolved, ok := i.swagger.Parameters[strings.TrimPrefix(ref, parametersRefPrefix)]
+			if !strings.HasPrefix(ref, parametersRefPrefix) || !ok {
+				i.warnf(paramPath, "unresolved ref %v, the parameter was dropped", ref)
+				continue
+			}
+			param = resolved
+		}
+		cookieParams, isCookieHeader, err := getCookieParams(&param)
+		if err != nil {
+			i.warnf(paramPath, "invalid cookie params, the Cookie header param is kept: %v", err)
+		}
+		if isCookieHeader && err == nil {
+			ret = append(ret, cookieParams...)
+			continue
+		}
+		param.Schema = i.importSchema(paramPath.Child("schema"), param.Schema, 0)
+		ret = append(ret, param)
+	}
+	return ret
+}
+
+func (i *approvedSpecImporter) importResponse(location *field.Path, response *oapi_spec.Response) *oapi_spec.Response {
+	if ref := response.Ref.String(); ref != "" {
+		resolved, ok := i.swagger.Responses[strings.TrimPrefix(ref, responsesRefPrefix)]
+		if !strings.HasPrefix(ref, responsesRefPrefix) || !ok {
+			i.warnf(location, "unresolved ref %v, the response was dropped", ref)
+			return nil
+		}
+		response = &resolved
+	}
+	response.Schema = i.importSchema(location.Child("schema"), response.Schema, 0)
+	return response
+}
+
+// importSchema returns a copy of schema with its definition refs inlined, up to refDepth nested refs. Refs that
+// can't be inlined are replaced with empty schemas. x-variants schemas are converted back into body variants.
+func (i *approvedSpecImporter) importSchema(location *field.Path, schema *oapi_spec.Schema, refDepth int) *oapi_spec.Schema {
+	if schema == nil {
+		return nil
+	}
+	if ref := schema.Ref.String(); ref != "" {
+		definition, ok := i.swagger.Definitions[strings.TrimPrefix(ref, definitionsRefPrefix)]
+		switch {
+		case !strings.HasPrefix(ref, definitionsRefPrefix) || !ok:
+			i.warnf(location, "unresolved ref %v, replaced with an empty schema", ref)
+			return &oapi_spec.Schema{}
+		case refDepth >= maxSchemaToRefDepth:
+			i.warnf(location, "ref %v is nested too deep (recursive), replaced with an empty schema", ref)
+			return &oapi_spec.Schema{}
+		}
+		schema = &definition
+		refDepth++
+	}
+
+	ret := *schema
+	if variants, ok := schema.Extensions[schemaVariantsExtensionName]; ok && len(schema.Type) == 0 {
+		if err := convertExtensionValue(variants, &ret.AnyOf); err != nil {
+			i.warnf(location, "invalid %v: %v", schemaVariantsExtensionName, err)
+		}
+		ret.Extensions = oapi_spec.Extensions{}
+		for key, value := range schema.Extensions {
+			if key != schemaVariantsExtensionName {
+				ret.Extensions[key] = value
+			}
+		}
+	}
+	if schema.Items != nil {
+		ret.Items = &oapi_spec.SchemaOrArray{Schema: i.importSchema(location.Child("items"), schema.Items.Schema, refDepth)}
+		for idx := range schema.Items.Schemas {
+			ret.Items.Schemas = append(ret.Items.Schemas, *i.importSchema(location.Child("items").Index(idx), &schema.Items.Schemas[idx], refDepth))
+		}
+	}
+	if schema.Properties != nil {
+		ret.Properties = make(oapi_spec.SchemaProperties, len(schema.Properties))
+		for name := range schema.Properties {
+			property := schema.Properties[name]
+			ret.Properties[name] = *i.importSchema(location.Child("properties").Key(name), &property, refDepth)
+		}
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		ret.AdditionalProperties = &oapi_spec.SchemaOrBool{
+			Allows: schema.AdditionalProperties.Allows,
+			Schema: i.importSchema(location.Child("additionalProperties"), schema.AdditionalProperties.Schema, refDepth),
+		}
+	}
+	ret.AllOf = i.importSchemas(location.Child("allOf"), schema.AllOf, refDepth)
+	if len(ret.AnyOf) > 0 {
+		ret.AnyOf = i.importSchemas(location.Child("anyOf"), ret.AnyOf, refDepth)
+	}
+	ret.OneOf = i.importSchemas(location.Child("oneOf"), schema.OneOf, refDepth)
+
+	return &ret
+}
+
+func (i *approvedSpecImporter) importSchemas(location *field.Path, schemas []oapi_spec.Schema, refDepth int) []oapi_spec.Schema {
+	if schemas == nil {
+		return nil
+	}
+	ret := make([]oapi_spec.Schema, 0, len(schemas))
+	for idx := range schemas {
+		ret = append(ret, *i.importSchema(location.Index(idx), &schemas[idx], refDepth))
+	}
+	return ret
+}
+
+func convertExtensionValue(value interface{}, out interface{}) error {
+	valueB, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %v", err)
+	}
+	if err := json.Unmarshal(valueB, out); err != nil {
+		return fmt.Errorf("failed to unmarshal: %v", err)
+	}
+	return nil
+}
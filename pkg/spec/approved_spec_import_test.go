@@ -0,0 +1,172 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_ImportApprovedSpec_RoundTrip(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.LearnBodyVariants = true
+	s := CreateDefaultSpec("host", "80", config)
+	telemetry := createTelemetry("req-id", http.MethodPost, "/api/1", "host", "200", interactionReqBody, interactionRespBody)
+	telemetry.Request.Common.Headers = append(telemetry.Request.Common.Headers, &Header{Key: cookieHeaderName, Value: "session=abc; theme=dark"})
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+	// the response body variants are exported as x-variants
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api/1", "host", "200", interactionReqBody, `[1, 2]`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api/2", "host", "200", interactionReqBody, interactionRespBody)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/health", "host", "200", "", "")))
+	assert.NilError(t, s.ApprovePaths([]string{"/api/1", "/api/2", "/health"}))
+	approvedBefore, err := s.ApprovedSpec.Clone()
+	assert.NilError(t, err)
+	assert.Assert(t, len(approvedBefore.PathItems["/api/{param1}"].Post.Responses.StatusCodeResponses[200].Schema.AnyOf) > 0)
+	_, pathIDBefore, _ := s.ApprovedPathTrie.GetPathAndValue("/api/{param1}")
+
+	exported, err := s.GenerateOASYaml()
+	assert.NilError(t, err)
+	report, err := s.ImportApprovedSpec(exported)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, report, &ApprovedSpecImportReport{})
+	assert.Equal(t, marshal(s.ApprovedSpec), marshal(approvedBefore))
+	_, pathID, _ := s.ApprovedPathTrie.GetPathAndValue("/api/{param1}")
+	assert.Equal(t, pathID, pathIDBefore)
+}
+
+func TestSpec_ImportApprovedSpec_Edits(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/users/1", "host", "200", "", `{"id": 1}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/health", "host", "200", "", "")))
+	assert.NilError(t, s.ApprovePaths([]string{"/users/1", "/health"}))
+	_, userPathID, _ := s.ApprovedPathTrie.GetPathAndValue("/users/{param1}")
+
+	report, err := s.ImportApprovedSpec([]byte(`
+swagger: "2.0"
+info: {title: edited, version: "1"}
+security:
+- token: []
+securityDefinitions:
+  token: {type: apiKey, in: header, name: X-Token}
+paths:
+  /users/{id}:
+    parameters:
+    - {name: id, in: path, required: true, type: integer}
+    get:
+      responses:
+        "200":
+          description: user
+          schema: {$ref: "#/definitions/User"}
+  /teams:
+    get:
+      parameters:
+      - {$ref: "#/parameters/Limit"}
+      responses:
+        "200": {$ref: "#/responses/Teams"}
+parameters:
+  Limit: {name: limit, in: query, type: integer}
+responses:
+  Teams:
+    description: teams
+    schema:
+      type: array
+      items: {$ref: "#/definitions/Team"}
+definitions:
+  User:
+    type: object
+    properties:
+      id: {type: string}
+      name: {type: string}
+  Team:
+    type: object
+    properties:
+      parent: {$ref: "#/definitions/Team"}
+`))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, report.AddedPaths, []string{"/teams", "/users/{id}"})
+	assert.DeepEqual(t, report.RemovedPaths, []string{"/health", "/users/{param1}"})
+	assert.Assert(t, report.UpdatedPaths == nil)
+	assert.Equal(t, len(report.Warnings), 2)
+	assert.Equal(t, report.Warnings[0], "security: top level security are not supported, set them on the operations instead")
+	assert.Assert(t, strings.Contains(report.Warnings[1], "is nested too deep"), report.Warnings[1])
+
+	// the refs are inlined
+	userPathItem := s.ApprovedSpec.GetPathItem("/users/{id}")
+	assert.Assert(t, userPathItem != nil)
+	userSchema := userPathItem.Get.Responses.StatusCodeResponses[200].Schema
+	assert.Equal(t, userSchema.Ref.String(), "")
+	assert.DeepEqual(t, []string(userSchema.Properties["id"].Type), []string{schemaTypeString})
+	teamsOp := s.ApprovedSpec.GetPathItem("/teams").Get
+	assert.Equal(t, teamsOp.Parameters[0].Name, "limit")
+	assert.Equal(t, teamsOp.Responses.StatusCodeResponses[200].Description, "teams")
+	assert.Assert(t, s.ApprovedSpec.SecurityDefinitions["token"] != nil)
+
+	// the path with renamed params keeps its path id
+	_, pathID, found := s.ApprovedPathTrie.GetPathAndValue("/users/1")
+	assert.Assert(t, found)
+	assert.Equal(t, pathID, userPathID)
+	_, _, found = s.ApprovedPathTrie.GetPathAndValue("/health")
+	assert.Assert(t, !found)
+	_, err = s.GenerateOASYaml()
+	assert.NilError(t, err)
+}
+
+func TestSpec_ImportApprovedSpec_BasePath(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+
+	report, err := s.ImportApprovedSpec([]byte(`
+swagger: "2.0"
+info: {title: edited, version: "1"}
+basePath: /v1/
+paths:
+  /health:
+    get:
+      responses:
+        "200": {description: ok}
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, report.AddedPaths, []string{"/v1/health"})
+	_, _, found := s.ApprovedPathTrie.GetPathAndValue("/v1/health")
+	assert.Assert(t, found)
+}
+
+func TestSpec_ImportApprovedSpec_Invalid(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/health", "host", "200", "", "")))
+	assert.NilError(t, s.ApprovePaths([]string{"/health"}))
+
+	_, err := s.ImportApprovedSpec([]byte(`{"not": [valid`))
+	assert.Assert(t, err != nil)
+	_, err = s.ImportApprovedSpec([]byte(`
+swagger: "2.0"
+info: {title: edited, version: "1"}
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200": {description: user}
+`))
+	assert.ErrorContains(t, err, "imported spec is not valid")
+
+	// the approved spec is left as is
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/health") != nil)
+	assert.Equal(t, len(s.ApprovedSpec.PathItems), 1)
+}
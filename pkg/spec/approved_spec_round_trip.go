@@ -0,0 +1,142 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+)
+
+// ApprovedSpecRoundTripReport describes the information of the approved spec that is lost by exporting it and
+// importing it back, see Spec.VerifyApprovedSpecRoundTrip.
+type ApprovedSpecRoundTripReport struct {
+	// Losses describe the parts of the approved spec that are changed by the round trip
+	Losses []string
+	// Warnings are the warnings of importing the exported spec, see ApprovedSpecImportReport.Warnings
+	Warnings []string
+}
+
+// IsLossless returns true if the round trip kept the approved spec as is.
+func (r *ApprovedSpecRoundTripReport) IsLossless() bool {
+	return len(r.Losses) == 0 && len(r.Warnings) == 0
+}
+
+// VerifyApprovedSpecRoundTrip exports the approved spec (see Spec.GenerateOASJson), imports it back like
+// Spec.ImportApprovedSpec does, without updating the spec, and reports any information that is lost on the way.
+// The spec is exported twice to verify that the exports (e.g. their definition names) are the same.
+func (s *Spec) VerifyApprovedSpecRoundTrip() (*ApprovedSpecRoundTripReport, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	report := &ApprovedSpecRoundTripReport{}
+
+	exportedSpec, err := s.generateApprovedOASJson()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Open API Spec. %w", err)
+	}
+	reexportedSpec, err := s.generateApprovedOASJson()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Open API Spec. %w", err)
+	}
+	if !bytes.Equal(exportedSpec, reexportedSpec) {
+		report.Losses = append(report.Losses, "the exported spec is not the same between exports")
+	}
+
+	swagger := &oapi_spec.Swagger{}
+	if err := json.Unmarshal(exportedSpec, swagger); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+	importer := &approvedSpecImporter{swagger: swagger}
+	importedPathItems := importer.importPathItems()
+	report.Warnings = importer.warnings
+
+	losses, err := getPathItemsRoundTripLosses(s.ApprovedSpec.PathItems, importedPathItems)
+	if err != nil {
+		return nil, err
+	}
+	report.Losses = append(report.Losses, losses...)
+
+	importedSecurityDefinitions := swagger.SecurityDefinitions
+	if importedSecurityDefinitions == nil {
+		importedSecurityDefinitions = oapi_spec.SecurityDefinitions{}
+	}
+	hasDiff, err := compareObjects(s.ApprovedSpec.SecurityDefinitions, importedSecurityDefinitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare security definitions: %v", err)
+	}
+	if hasDiff {
+		report.Losses = append(report.Losses, "securityDefinitions: changed")
+	}
+
+	return report, nil
+}
+
+// getPathItemsRoundTripLosses returns the paths, path params and operations of approvedPathItems that are missing
+// or changed in importedPathItems.
+func getPathItemsRoundTripLosses(approvedPathItems, importedPathItems map[string]*oapi_spec.PathItem) ([]string, error) {
+	var losses []string
+
+	pathsPath := field.NewPath("paths")
+	for _, path := range getSortedPaths(approvedPathItems, importedPathItems) {
+		pathPath := pathsPath.Key(path)
+		approvedPathItem, isApproved := approvedPathItems[path]
+		importedPathItem, isImported := importedPathItems[path]
+		if !isApproved {
+			losses = append(losses, pathPath.String()+": added")
+			continue
+		}
+		if !isImported {
+			losses = append(losses, pathPath.String()+": missing")
+			continue
+		}
+
+		hasDiff, err := compareObjects(approvedPathItem.Parameters, importedPathItem.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare path %v params: %v", path, err)
+		}
+		if hasDiff {
+			losses = append(losses, pathPath.Child("parameters").String()+": changed")
+		}
+
+		for _, method := range supportedMethods {
+			approvedOp := GetOperationFromPathItem(approvedPathItem, method)
+			importedOp := GetOperationFromPathItem(importedPathItem, method)
+			opPath := pathPath.Child(method)
+			switch {
+			case approvedOp == nil && importedOp == nil:
+				continue
+			case approvedOp == nil:
+				losses = append(losses, opPath.String()+": added")
+			case importedOp == nil:
+				losses = append(losses, opPath.String()+": missing")
+			default:
+				hasDiff, err := compareObjects(approvedOp, importedOp)
+				if err != nil {
+					return nil, fmt.Errorf("failed to compare operation %v %v: %v", method, path, err)
+				}
+				if hasDiff {
+					losses = append(losses, opPath.String()+": changed")
+				}
+			}
+		}
+	}
+
+	return losses, nil
+}
@@ -0,0 +1,97 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpec_VerifyApprovedSpecRoundTrip(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.LearnBodyVariants = true
+	s := CreateDefaultSpec("host", "80", config)
+	telemetry := createTelemetry("req-id", http.MethodPost, "/api/1", "host", "200", interactionReqBody, interactionRespBody)
+	telemetry.Request.Common.Headers = append(telemetry.Request.Common.Headers, &Header{Key: cookieHeaderName, Value: "session=abc"})
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api/1", "host", "200", interactionReqBody, `[1, 2]`)))
+	// different schemas with the same definition name hint
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/shops", "host", "200", "",
+		`{"owner": {"address": {"city": "a"}}, "shop": {"address": {"zip": 1}}}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/users", "host", "200", "",
+		`{"address": {"street": "a"}}`)))
+	assert.NilError(t, s.ApprovePaths([]string{"/api/1", "/shops", "/users"}))
+
+	for i := 0; i < 5; i++ {
+		report, err := s.VerifyApprovedSpecRoundTrip()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, report, &ApprovedSpecRoundTripReport{})
+		assert.Assert(t, report.IsLossless())
+	}
+}
+
+func Test_getPathItemsRoundTripLosses(t *testing.T) {
+	op := oapi_spec.NewOperation("").RespondsWith(200, oapi_spec.NewResponse().WithDescription("ok"))
+	changedOp := oapi_spec.NewOperation("").RespondsWith(201, oapi_spec.NewResponse().WithDescription("ok"))
+	tests := []struct {
+		name              string
+		approvedPathItems map[string]*oapi_spec.PathItem
+		importedPathItems map[string]*oapi_spec.PathItem
+		want              []string
+	}{
+		{
+			name:              "same",
+			approvedPathItems: map[string]*oapi_spec.PathItem{"/a": &NewTestPathItem().WithOperation(http.MethodGet, op).PathItem},
+			importedPathItems: map[string]*oapi_spec.PathItem{"/a": &NewTestPathItem().WithOperation(http.MethodGet, op).PathItem},
+			want:              nil,
+		},
+		{
+			name: "paths",
+			approvedPathItems: map[string]*oapi_spec.PathItem{
+				"/a": &NewTestPathItem().WithOperation(http.MethodGet, op).PathItem,
+				"/b": &NewTestPathItem().WithOperation(http.MethodGet, op).PathItem,
+			},
+			importedPathItems: map[string]*oapi_spec.PathItem{
+				"/a": &NewTestPathItem().WithOperation(http.MethodGet, op).PathItem,
+				"/c": &NewTestPathItem().WithOperation(http.MethodGet, op).PathItem,
+			},
+			want: []string{"paths[/b]: missing", "paths[/c]: added"},
+		},
+		{
+			name: "operations",
+			approvedPathItems: map[string]*oapi_spec.PathItem{
+				"/a": &NewTestPathItem().WithOperation(http.MethodGet, op).WithOperation(http.MethodPost, op).PathItem,
+			},
+			importedPathItems: map[string]*oapi_spec.PathItem{
+				"/a": &NewTestPathItem().WithOperation(http.MethodGet, changedOp).WithOperation(http.MethodPut, op).WithPathParams("id", schemaTypeString, "").PathItem,
+			},
+			want: []string{"paths[/a].parameters: changed", "paths[/a].GET: changed", "paths[/a].PUT: added", "paths[/a].POST: missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getPathItemsRoundTripLosses(tt.approvedPathItems, tt.importedPathItems)
+			assert.NilError(t, err)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getPathItemsRoundTripLosses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
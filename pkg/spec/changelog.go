@@ -0,0 +1,280 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+)
+
+type ChangeType string
+
+const (
+	ChangeTypeAdded   ChangeType = "ADDED"
+	ChangeTypeRemoved ChangeType = "REMOVED"
+	ChangeTypeChanged ChangeType = "CHANGED"
+)
+
+type OperationChange struct {
+	Type   ChangeType `json:"type"`
+	Path   string     `json:"path"`
+	Method string     `json:"method"`
+	// Fields holds the changed parts of the operation (e.g. parameters, responses), set only for ChangeTypeChanged
+	Fields []string `json:"fields,omitempty"`
+}
+
+type SchemaChange struct {
+	Type ChangeType `json:"type"`
+	Name string     `json:"name"`
+}
+
+type Changelog struct {
+	Operations []OperationChange `json:"operations,omitempty"`
+	Schemas    []SchemaChange    `json:"schemas,omitempty"`
+}
+
+// CreateChangelog describes the added, removed and changed operations and schemas between two approved revisions.
+func CreateChangelog(oldSpec, newSpec *ApprovedSpec) (*Changelog, error) {
+	clonedOld, err := oldSpec.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone old approved spec: %w", err)
+	}
+	clonedNew, err := newSpec.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone new approved spec: %w", err)
+	}
+
+	changelog := &Changelog{}
+
+	changelog.Operations, err = getOperationChanges(clonedOld.PathItems, clonedNew.PathItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation changes: %w", err)
+	}
+
+	// definitions are compared after the object refs reconstruction, the same way they will show in the generated spec
+	_, oldDefinitions := reconstructObjectRefs(clonedOld.PathItems)
+	_, newDefinitions := reconstructObjectRefs(clonedNew.PathItems)
+	changelog.Schemas, err = getSchemaChanges(oldDefinitions, newDefinitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema changes: %w", err)
+	}
+
+	return changelog, nil
+}
+
+func getOperationChanges(oldPathItems, newPathItems map[string]*oapi_spec.PathItem) ([]OperationChange, error) {
+	var changes []OperationChange
+
+	for _, path := range getSortedPaths(oldPathItems, newPathItems) {
+		oldPathItem := oldPathItems[path]
+		newPathItem := newPathItems[path]
+		for _, method := range supportedMethods {
+			var oldOp, newOp *oapi_spec.Operation
+			if oldPathItem != nil {
+				oldOp = GetOperationFromPathItem(oldPathItem, method)
+			}
+			if newPathItem != nil {
+				newOp = GetOperationFromPathItem(newPathItem, method)
+			}
+
+			switch {
+			case oldOp == nil && newOp == nil:
+				continue
+			case oldOp == nil:
+				changes = append(changes, OperationChange{Type: ChangeTypeAdded, Path: path, Method: method})
+			case newOp == nil:
+				changes = append(changes, OperationChange{Type: ChangeTypeRemoved, Path: path, Method: method})
+			default:
+				fields, err := getChangedOperationFields(oldOp, newOp)
+				if err != nil {
+					return nil, fmt.Errorf("failed to compare operation %v %v: %w", method, path, err)
+				}
+				if len(fields) > 0 {
+					changes = append(changes, OperationChange{Type: ChangeTypeChanged, Path: path, Method: method, Fields: fields})
+				}
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func getChangedOperationFields(oldOp, newOp *oapi_spec.Operation) ([]string, error) {
+	var fields []string
+
+	oldOp = sortParameters(oldOp)
+	newOp = sortParameters(newOp)
+
+	sort.Strings(oldOp.Consumes)
+	sort.Strings(newOp.Consumes)
+	sort.Strings(oldOp.Produces)
+	sort.Strings(newOp.Produces)
+
+	toCompare := []struct {
+		name           string
+		oldObj, newObj interface{}
+	}{
+		{name: "parameters", oldObj: oldOp.Parameters, newObj: newOp.Parameters},
+		{name: "responses", oldObj: oldOp.Responses, newObj: newOp.Responses},
+		{name: "consumes", oldObj: oldOp.Consumes, newObj: newOp.Consumes},
+		{name: "produces", oldObj: oldOp.Produces, newObj: newOp.Produces},
+		{name: "security", oldObj: oldOp.Security, newObj: newOp.Security},
+		{name: "deprecated", oldObj: oldOp.Deprecated, newObj: newOp.Deprecated},
+	}
+
+	for _, c := range toCompare {
+		hasDiff, err := compareObjects(c.oldObj, c.newObj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %v: %w", c.name, err)
+		}
+		if hasDiff {
+			fields = append(fields, c.name)
+		}
+	}
+
+	return fields, nil
+}
+
+func getSchemaChanges(oldDefinitions, newDefinitions map[string]oapi_spec.Schema) ([]SchemaChange, error) {
+	var changes []SchemaChange
+
+	for _, name := range getSortedKeys(oldDefinitions, newDefinitions) {
+		oldSchema, oldExist := oldDefinitions[name]
+		newSchema, newExist := newDefinitions[name]
+		switch {
+		case !oldExist:
+			changes = append(changes, SchemaChange{Type: ChangeTypeAdded, Name: name})
+		case !newExist:
+			changes = append(changes, SchemaChange{Type: ChangeTypeRemoved, Name: name})
+		default:
+			hasDiff, err := compareObjects(oldSchema, newSchema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare schema %v: %w", name, err)
+			}
+			if hasDiff {
+				changes = append(changes, SchemaChange{Type: ChangeTypeChanged, Name: name})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func getSortedPaths(pathItems, pathItems2 map[string]*oapi_spec.PathItem) []string {
+	pathsMap := make(map[string]struct{})
+	for path := range pathItems {
+		pathsMap[path] = struct{}{}
+	}
+	for path := range pathItems2 {
+		pathsMap[path] = struct{}{}
+	}
+
+	return getSortedStrings(pathsMap)
+}
+
+func getSortedKeys(definitions, definitions2 map[string]oapi_spec.Schema) []string {
+	namesMap := make(map[string]struct{})
+	for name := range definitions {
+		namesMap[name] = struct{}{}
+	}
+	for name := range definitions2 {
+		namesMap[name] = struct{}{}
+	}
+
+	return getSortedStrings(namesMap)
+}
+
+func getSortedStrings(m map[string]struct{}) []string {
+	ret := make([]string, 0, len(m))
+	for s := range m {
+		ret = append(ret, s)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func (c *Changelog) IsEmpty() bool {
+	return len(c.Operations) == 0 && len(c.Schemas) == 0
+}
+
+// Markdown renders the changelog in a format that can be attached to release notes.
+func (c *Changelog) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("## API Changes\n")
+
+	if c.IsEmpty() {
+		sb.WriteString("\nNo changes.\n")
+		return sb.String()
+	}
+
+	sections := []struct {
+		title      string
+		changeType ChangeType
+	}{
+		{title: "Added operations", changeType: ChangeTypeAdded},
+		{title: "Removed operations", changeType: ChangeTypeRemoved},
+		{title: "Changed operations", changeType: ChangeTypeChanged},
+	}
+	for _, section := range sections {
+		var lines []string
+		for _, change := range c.Operations {
+			if change.Type != section.changeType {
+				continue
+			}
+			line := fmt.Sprintf("- `%s %s`", change.Method, change.Path)
+			if len(change.Fields) > 0 {
+				line += ": " + strings.Join(change.Fields, ", ")
+			}
+			lines = append(lines, line)
+		}
+		writeMarkdownSection(&sb, section.title, lines)
+	}
+
+	schemaSections := []struct {
+		title      string
+		changeType ChangeType
+	}{
+		{title: "Added schemas", changeType: ChangeTypeAdded},
+		{title: "Removed schemas", changeType: ChangeTypeRemoved},
+		{title: "Changed schemas", changeType: ChangeTypeChanged},
+	}
+	for _, section := range schemaSections {
+		var lines []string
+		for _, change := range c.Schemas {
+			if change.Type == section.changeType {
+				lines = append(lines, fmt.Sprintf("- `%s`", change.Name))
+			}
+		}
+		writeMarkdownSection(&sb, section.title, lines)
+	}
+
+	return sb.String()
+}
+
+func writeMarkdownSection(sb *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	sb.WriteString("\n### " + title + "\n\n")
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+}
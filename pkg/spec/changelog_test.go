@@ -0,0 +1,153 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+)
+
+func TestCreateChangelog(t *testing.T) {
+	type args struct {
+		oldSpec *ApprovedSpec
+		newSpec *ApprovedSpec
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Changelog
+		wantErr bool
+	}{
+		{
+			name: "no changes",
+			args: args{
+				oldSpec: &ApprovedSpec{
+					PathItems: map[string]*oapi_spec.PathItem{
+						"/api": &NewTestPathItem().WithOperation(http.MethodGet, NewOperation(t, Data).Op).PathItem,
+					},
+				},
+				newSpec: &ApprovedSpec{
+					PathItems: map[string]*oapi_spec.PathItem{
+						"/api": &NewTestPathItem().WithOperation(http.MethodGet, NewOperation(t, Data).Op).PathItem,
+					},
+				},
+			},
+			want:    &Changelog{},
+			wantErr: false,
+		},
+		{
+			name: "added and removed operations",
+			args: args{
+				oldSpec: &ApprovedSpec{
+					PathItems: map[string]*oapi_spec.PathItem{
+						"/api": &NewTestPathItem().WithOperation(http.MethodGet, NewOperation(t, Data).Op).PathItem,
+					},
+				},
+				newSpec: &ApprovedSpec{
+					PathItems: map[string]*oapi_spec.PathItem{
+						"/api": &NewTestPathItem().WithOperation(http.MethodPost, NewOperation(t, Data).Op).PathItem,
+					},
+				},
+			},
+			want: &Changelog{
+				Operations: []OperationChange{
+					{Type: ChangeTypeRemoved, Path: "/api", Method: http.MethodGet},
+					{Type: ChangeTypeAdded, Path: "/api", Method: http.MethodPost},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "changed operation and schemas",
+			args: args{
+				oldSpec: &ApprovedSpec{
+					PathItems: map[string]*oapi_spec.PathItem{
+						"/api": &NewTestPathItem().WithOperation(http.MethodGet, NewOperation(t, Data).Op).PathItem,
+					},
+				},
+				newSpec: &ApprovedSpec{
+					PathItems: map[string]*oapi_spec.PathItem{
+						"/api": &NewTestPathItem().WithOperation(http.MethodGet, NewOperation(t, Data2).Op).PathItem,
+					},
+				},
+			},
+			want: &Changelog{
+				Operations: []OperationChange{
+					{Type: ChangeTypeChanged, Path: "/api", Method: http.MethodGet, Fields: []string{"parameters", "responses"}},
+				},
+				Schemas: []SchemaChange{
+					{Type: ChangeTypeRemoved, Name: "active_certificateVersion_controllerInstanceInfo_policyAndAppVersion_version"},
+					{Type: ChangeTypeAdded, Name: "active_statusCodes_version"},
+					{Type: ChangeTypeRemoved, Name: "controllerInstanceInfo"},
+					{Type: ChangeTypeChanged, Name: "cvs"},
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CreateChangelog(tt.args.oldSpec, tt.args.newSpec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateChangelog() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CreateChangelog() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangelog_Markdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		changelog *Changelog
+		want      string
+	}{
+		{
+			name:      "empty changelog",
+			changelog: &Changelog{},
+			want:      "## API Changes\n\nNo changes.\n",
+		},
+		{
+			name: "operations and schemas",
+			changelog: &Changelog{
+				Operations: []OperationChange{
+					{Type: ChangeTypeAdded, Path: "/api", Method: http.MethodPost},
+					{Type: ChangeTypeChanged, Path: "/api", Method: http.MethodGet, Fields: []string{"parameters", "responses"}},
+				},
+				Schemas: []SchemaChange{
+					{Type: ChangeTypeRemoved, Name: "user"},
+				},
+			},
+			want: "## API Changes\n" +
+				"\n### Added operations\n\n- `POST /api`\n" +
+				"\n### Changed operations\n\n- `GET /api`: parameters, responses\n" +
+				"\n### Removed schemas\n\n- `user`\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.changelog.Markdown(); got != tt.want {
+				t.Errorf("Markdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,529 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+)
+
+// The clone functions deep copy the spec state, the copy can be modified without affecting the original.
+// Maps and slices keep being nil or empty as in the original.
+
+func cloneSpecInfo(info *SpecInfo) SpecInfo {
+	return SpecInfo{
+		Host:              info.Host,
+		Port:              info.Port,
+		BasePath:          info.BasePath,
+		ID:                info.ID,
+		ProvidedSpec:      cloneProvidedSpec(info.ProvidedSpec),
+		ApprovedSpec:      cloneApprovedSpec(info.ApprovedSpec),
+		LearningSpec:      cloneLearningSpec(info.LearningSpec),
+		ApprovedPathTrie:  info.ApprovedPathTrie.Clone(),
+		ProvidedPathTrie:  info.ProvidedPathTrie.Clone(),
+		LearningStats:     cloneSpecStats(info.LearningStats),
+		IgnoredOperations: cloneNestedMap(info.IgnoredOperations),
+		FrozenOperations:  cloneNestedMap(info.FrozenOperations),
+		RejectedPaths:     cloneMap(info.RejectedPaths),
+		SchemaOutliers:    cloneSchemaOutliers(info.SchemaOutliers),
+		RetainedSamples:   cloneRetainedSamples(info.RetainedSamples),
+		SplitPaths:        cloneMap(info.SplitPaths),
+		PathTemplates:     cloneMap(info.PathTemplates),
+	}
+}
+
+func cloneApprovedSpec(approvedSpec *ApprovedSpec) *ApprovedSpec {
+	if approvedSpec == nil {
+		return nil
+	}
+	return &ApprovedSpec{
+		PathItems:           clonePathItems(approvedSpec.PathItems),
+		SecurityDefinitions: cloneSecurityDefinitions(approvedSpec.SecurityDefinitions),
+	}
+}
+
+func cloneLearningSpec(learningSpec *LearningSpec) *LearningSpec {
+	if learningSpec == nil {
+		return nil
+	}
+	return &LearningSpec{
+		PathItems:           clonePathItems(learningSpec.PathItems),
+		SecurityDefinitions: cloneSecurityDefinitions(learningSpec.SecurityDefinitions),
+	}
+}
+
+func cloneProvidedSpec(providedSpec *ProvidedSpec) *ProvidedSpec {
+	if providedSpec == nil {
+		return nil
+	}
+	return &ProvidedSpec{
+		Spec: cloneSwagger(providedSpec.Spec),
+	}
+}
+
+func cloneSpecStats(stats *SpecStats) *SpecStats {
+	if stats == nil {
+		return nil
+	}
+
+	ret := *stats
+	ret.Sources = cloneMap(stats.Sources)
+	if stats.Operations != nil {
+		ret.Operations = make(map[string]map[string]*OperationStats, len(stats.Operations))
+		for path, methods := range stats.Operations {
+			ret.Operations[path] = cloneMapFunc(methods, cloneOperationStats)
+		}
+	}
+	return &ret
+}
+
+func cloneOperationStats(stats *OperationStats) *OperationStats {
+	if stats == nil {
+		return nil
+	}
+
+	ret := *stats
+	ret.Sources = cloneMap(stats.Sources)
+	ret.Metadata = cloneNestedMap(stats.Metadata)
+	ret.SchemaTimeline = cloneSliceFunc(stats.SchemaTimeline, func(event SchemaChangeEvent) SchemaChangeEvent {
+		event.Added = cloneSlice(event.Added)
+		event.Removed = cloneSlice(event.Removed)
+		event.Changed = cloneSlice(event.Changed)
+		return event
+	})
+	ret.FieldValues = cloneMapFunc(stats.FieldValues, func(fieldStats *FieldValueStats) *FieldValueStats {
+		if fieldStats == nil {
+			return nil
+		}
+		ret := *fieldStats
+		ret.Values = cloneSlice(fieldStats.Values)
+		return &ret
+	})
+	ret.PropertyPresence = cloneMapFunc(stats.PropertyPresence, func(presenceStats *PropertyPresenceStats) *PropertyPresenceStats {
+		if presenceStats == nil {
+			return nil
+		}
+		ret := *presenceStats
+		ret.PropertyCounts = cloneMap(presenceStats.PropertyCounts)
+		return &ret
+	})
+	if stats.Latency != nil {
+		latency := *stats.Latency
+		latency.Buckets = cloneMap(stats.Latency.Buckets)
+		ret.Latency = &latency
+	}
+	return &ret
+}
+
+func cloneSchemaOutliers(outliers []*SchemaOutlier) []*SchemaOutlier {
+	return cloneSliceFunc(outliers, clonePtr[SchemaOutlier])
+}
+
+func cloneRetainedSamples(samples []*RetainedSample) []*RetainedSample {
+	return cloneSliceFunc(samples, func(sample *RetainedSample) *RetainedSample {
+		if sample == nil {
+			return nil
+		}
+		ret := *sample
+		ret.Fields = cloneSlice(sample.Fields)
+		ret.Telemetry = cloneTelemetry(sample.Telemetry)
+		return &ret
+	})
+}
+
+func cloneTelemetry(telemetry *Telemetry) *Telemetry {
+	if telemetry == nil {
+		return nil
+	}
+
+	ret := *telemetry
+	ret.Metadata = cloneMap(telemetry.Metadata)
+	if telemetry.Request != nil {
+		req := *telemetry.Request
+		req.Common = cloneCommon(telemetry.Request.Common)
+		ret.Request = &req
+	}
+	if telemetry.Response != nil {
+		resp := *telemetry.Response
+		resp.Common = cloneCommon(telemetry.Response.Common)
+		ret.Response = &resp
+	}
+	return &ret
+}
+
+func cloneCommon(common *Common) *Common {
+	if common == nil {
+		return nil
+	}
+
+	ret := *common
+	ret.Body = cloneSlice(common.Body)
+	ret.Headers = cloneSliceFunc(common.Headers, clonePtr[Header])
+	return &ret
+}
+
+func clonePathItems(pathItems map[string]*oapi_spec.PathItem) map[string]*oapi_spec.PathItem {
+	return cloneMapFunc(pathItems, clonePathItem)
+}
+
+func clonePathItem(pathItem *oapi_spec.PathItem) *oapi_spec.PathItem {
+	if pathItem == nil {
+		return nil
+	}
+
+	ret := *pathItem
+	ret.Extensions = cloneExtensions(pathItem.Extensions)
+	ret.Get = cloneOperation(pathItem.Get)
+	ret.Put = cloneOperation(pathItem.Put)
+	ret.Post = cloneOperation(pathItem.Post)
+	ret.Delete = cloneOperation(pathItem.Delete)
+	ret.Options = cloneOperation(pathItem.Options)
+	ret.Head = cloneOperation(pathItem.Head)
+	ret.Patch = cloneOperation(pathItem.Patch)
+	ret.Parameters = cloneSliceFunc(pathItem.Parameters, cloneParameter)
+	return &ret
+}
+
+func cloneOperation(operation *oapi_spec.Operation) *oapi_spec.Operation {
+	if operation == nil {
+		return nil
+	}
+
+	ret := *operation
+	ret.Extensions = cloneExtensions(operation.Extensions)
+	ret.Consumes = cloneSlice(operation.Consumes)
+	ret.Produces = cloneSlice(operation.Produces)
+	ret.Schemes = cloneSlice(operation.Schemes)
+	ret.Tags = cloneSlice(operation.Tags)
+	ret.ExternalDocs = clonePtr(operation.ExternalDocs)
+	ret.Security = cloneSecurityRequirements(operation.Security)
+	ret.Parameters = cloneSliceFunc(operation.Parameters, cloneParameter)
+	ret.Responses = cloneResponses(operation.Responses)
+	return &ret
+}
+
+func cloneSecurityRequirements(security []map[string][]string) []map[string][]string {
+	return cloneSliceFunc(security, func(requirement map[string][]string) map[string][]string {
+		return cloneMapFunc(requirement, cloneSlice[string])
+	})
+}
+
+func cloneSecurityDefinitions(securityDefinitions oapi_spec.SecurityDefinitions) oapi_spec.SecurityDefinitions {
+	return cloneMapFunc(securityDefinitions, func(scheme *oapi_spec.SecurityScheme) *oapi_spec.SecurityScheme {
+		if scheme == nil {
+			return nil
+		}
+		ret := *scheme
+		ret.Extensions = cloneExtensions(scheme.Extensions)
+		ret.Scopes = cloneMap(scheme.Scopes)
+		return &ret
+	})
+}
+
+func cloneParameter(param oapi_spec.Parameter) oapi_spec.Parameter {
+	ret := param
+	ret.CommonValidations = cloneCommonValidations(param.CommonValidations)
+	ret.SimpleSchema = cloneSimpleSchema(param.SimpleSchema)
+	ret.Extensions = cloneExtensions(param.Extensions)
+	ret.Schema = cloneSchema(param.Schema)
+	return ret
+}
+
+func cloneResponses(responses *oapi_spec.Responses) *oapi_spec.Responses {
+	if responses == nil {
+		return nil
+	}
+
+	ret := *responses
+	ret.Extensions = cloneExtensions(responses.Extensions)
+	if responses.Default != nil {
+		defaultResponse := cloneResponse(*responses.Default)
+		ret.Default = &defaultResponse
+	}
+	ret.StatusCodeResponses = cloneMapFunc(responses.StatusCodeResponses, cloneResponse)
+	return &ret
+}
+
+func cloneResponse(response oapi_spec.Response) oapi_spec.Response {
+	ret := response
+	ret.Schema = cloneSchema(response.Schema)
+	ret.Headers = cloneMapFunc(response.Headers, cloneHeader)
+	ret.Examples = cloneMapFunc(response.Examples, cloneValue)
+	ret.Extensions = cloneExtensions(response.Extensions)
+	return ret
+}
+
+func cloneHeader(header oapi_spec.Header) oapi_spec.Header {
+	ret := header
+	ret.CommonValidations = cloneCommonValidations(header.CommonValidations)
+	ret.SimpleSchema = cloneSimpleSchema(header.SimpleSchema)
+	ret.Extensions = cloneExtensions(header.Extensions)
+	return ret
+}
+
+func cloneItems(items *oapi_spec.Items) *oapi_spec.Items {
+	if items == nil {
+		return nil
+	}
+
+	ret := *items
+	ret.CommonValidations = cloneCommonValidations(items.CommonValidations)
+	ret.SimpleSchema = cloneSimpleSchema(items.SimpleSchema)
+	ret.Extensions = cloneExtensions(items.Extensions)
+	return &ret
+}
+
+func cloneSimpleSchema(simpleSchema oapi_spec.SimpleSchema) oapi_spec.SimpleSchema {
+	ret := simpleSchema
+	ret.Items = cloneItems(simpleSchema.Items)
+	ret.Default = cloneValue(simpleSchema.Default)
+	ret.Example = cloneValue(simpleSchema.Example)
+	return ret
+}
+
+func cloneCommonValidations(validations oapi_spec.CommonValidations) oapi_spec.CommonValidations {
+	ret := validations
+	ret.Maximum = clonePtr(validations.Maximum)
+	ret.Minimum = clonePtr(validations.Minimum)
+	ret.MaxLength = clonePtr(validations.MaxLength)
+	ret.MinLength = clonePtr(validations.MinLength)
+	ret.MaxItems = clonePtr(validations.MaxItems)
+	ret.MinItems = clonePtr(validations.MinItems)
+	ret.MultipleOf = clonePtr(validations.MultipleOf)
+	ret.Enum = cloneSliceFunc(validations.Enum, cloneValue)
+	return ret
+}
+
+func cloneSchema(schema *oapi_spec.Schema) *oapi_spec.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	ret := cloneSchemaValue(*schema)
+	return &ret
+}
+
+func cloneSchemaValue(schema oapi_spec.Schema) oapi_spec.Schema {
+	ret := schema
+	ret.Extensions = cloneExtensions(schema.Extensions)
+	ret.ExtraProps = cloneMapFunc(schema.ExtraProps, cloneValue)
+
+	ret.Type = cloneSlice(schema.Type)
+	ret.Default = cloneValue(schema.Default)
+	ret.Maximum = clonePtr(schema.Maximum)
+	ret.Minimum = clonePtr(schema.Minimum)
+	ret.MaxLength = clonePtr(schema.MaxLength)
+	ret.MinLength = clonePtr(schema.MinLength)
+	ret.MaxItems = clonePtr(schema.MaxItems)
+	ret.MinItems = clonePtr(schema.MinItems)
+	ret.MultipleOf = clonePtr(schema.MultipleOf)
+	ret.Enum = cloneSliceFunc(schema.Enum, cloneValue)
+	ret.MaxProperties = clonePtr(schema.MaxProperties)
+	ret.MinProperties = clonePtr(schema.MinProperties)
+	ret.Required = cloneSlice(schema.Required)
+	if schema.Items != nil {
+		ret.Items = &oapi_spec.SchemaOrArray{
+			Schema:  cloneSchema(schema.Items.Schema),
+			Schemas: cloneSliceFunc(schema.Items.Schemas, cloneSchemaValue),
+		}
+	}
+	ret.AllOf = cloneSliceFunc(schema.AllOf, cloneSchemaValue)
+	ret.OneOf = cloneSliceFunc(schema.OneOf, cloneSchemaValue)
+	ret.AnyOf = cloneSliceFunc(schema.AnyOf, cloneSchemaValue)
+	ret.Not = cloneSchema(schema.Not)
+	ret.Properties = cloneMapFunc(schema.Properties, cloneSchemaValue)
+	ret.AdditionalProperties = cloneSchemaOrBool(schema.AdditionalProperties)
+	ret.PatternProperties = cloneMapFunc(schema.PatternProperties, cloneSchemaValue)
+	ret.Dependencies = cloneMapFunc(schema.Dependencies, func(dependency oapi_spec.SchemaOrStringArray) oapi_spec.SchemaOrStringArray {
+		return oapi_spec.SchemaOrStringArray{
+			Schema:   cloneSchema(dependency.Schema),
+			Property: cloneSlice(dependency.Property),
+		}
+	})
+	ret.AdditionalItems = cloneSchemaOrBool(schema.AdditionalItems)
+	ret.Definitions = cloneMapFunc(schema.Definitions, cloneSchemaValue)
+
+	ret.XML = clonePtr(schema.XML)
+	ret.ExternalDocs = clonePtr(schema.ExternalDocs)
+	ret.Example = cloneValue(schema.Example)
+	return ret
+}
+
+func cloneSchemaOrBool(schemaOrBool *oapi_spec.SchemaOrBool) *oapi_spec.SchemaOrBool {
+	if schemaOrBool == nil {
+		return nil
+	}
+	return &oapi_spec.SchemaOrBool{
+		Allows: schemaOrBool.Allows,
+		Schema: cloneSchema(schemaOrBool.Schema),
+	}
+}
+
+func cloneSwagger(swagger *oapi_spec.Swagger) *oapi_spec.Swagger {
+	if swagger == nil {
+		return nil
+	}
+
+	ret := *swagger
+	ret.Extensions = cloneExtensions(swagger.Extensions)
+	ret.Consumes = cloneSlice(swagger.Consumes)
+	ret.Produces = cloneSlice(swagger.Produces)
+	ret.Schemes = cloneSlice(swagger.Schemes)
+	if swagger.Info != nil {
+		info := *swagger.Info
+		info.Extensions = cloneExtensions(swagger.Info.Extensions)
+		if swagger.Info.Contact != nil {
+			contact := *swagger.Info.Contact
+			contact.Extensions = cloneExtensions(swagger.Info.Contact.Extensions)
+			info.Contact = &contact
+		}
+		if swagger.Info.License != nil {
+			license := *swagger.Info.License
+			license.Extensions = cloneExtensions(swagger.Info.License.Extensions)
+			info.License = &license
+		}
+		ret.Info = &info
+	}
+	if swagger.Paths != nil {
+		ret.Paths = &oapi_spec.Paths{
+			VendorExtensible: oapi_spec.VendorExtensible{Extensions: cloneExtensions(swagger.Paths.Extensions)},
+			Paths: cloneMapFunc(swagger.Paths.Paths, func(pathItem oapi_spec.PathItem) oapi_spec.PathItem {
+				return *clonePathItem(&pathItem)
+			}),
+		}
+	}
+	ret.Definitions = cloneMapFunc(swagger.Definitions, cloneSchemaValue)
+	ret.Parameters = cloneMapFunc(swagger.Parameters, cloneParameter)
+	ret.Responses = cloneMapFunc(swagger.Responses, cloneResponse)
+	ret.SecurityDefinitions = cloneSecurityDefinitions(swagger.SecurityDefinitions)
+	ret.Security = cloneSecurityRequirements(swagger.Security)
+	ret.Tags = cloneSliceFunc(swagger.Tags, func(tag oapi_spec.Tag) oapi_spec.Tag {
+		tag.Extensions = cloneExtensions(tag.Extensions)
+		tag.ExternalDocs = clonePtr(tag.ExternalDocs)
+		return tag
+	})
+	ret.ExternalDocs = clonePtr(swagger.ExternalDocs)
+	return &ret
+}
+
+func cloneExtensions(extensions oapi_spec.Extensions) oapi_spec.Extensions {
+	return cloneMapFunc(extensions, cloneValue)
+}
+
+// cloneValue deep copies the values of extensions, defaults, examples and enums. The values decoded from JSON and
+// the extensions set while learning are copied as is, other values are copied as their decoded JSON.
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, float64, float32, int, int64, int32, json.Number:
+		return v
+	case map[string]interface{}:
+		return cloneMapFunc(v, cloneValue)
+	case []interface{}:
+		return cloneSliceFunc(v, cloneValue)
+	case []string:
+		return cloneSlice(v)
+	case []oapi_spec.Schema:
+		return cloneSliceFunc(v, cloneSchemaValue)
+	case []oapi_spec.Parameter:
+		return cloneSliceFunc(v, cloneParameter)
+	case *CompressionExtension:
+		if v == nil {
+			return v
+		}
+		return &CompressionExtension{
+			Encodings: cloneSlice(v.Encodings),
+			Chunked:   v.Chunked,
+		}
+	case *JWTSchema:
+		if v == nil {
+			return v
+		}
+		return &JWTSchema{
+			Header: cloneSchema(v.Header),
+			Claims: cloneSchema(v.Claims),
+		}
+	default:
+		return cloneJSONValue(v)
+	}
+}
+
+func cloneJSONValue(value interface{}) interface{} {
+	valueB, err := json.Marshal(value)
+	if err != nil {
+		log.Warnf("Failed to marshal value of type %T, the clone shares it: %v", value, err)
+		return value
+	}
+	var ret interface{}
+	if err := json.Unmarshal(valueB, &ret); err != nil {
+		log.Warnf("Failed to unmarshal value of type %T, the clone shares it: %v", value, err)
+		return value
+	}
+	return ret
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	ret := *p
+	return &ret
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+func cloneSliceFunc[T any](s []T, cloneFunc func(T) T) []T {
+	if s == nil {
+		return nil
+	}
+	ret := make([]T, len(s))
+	for i, v := range s {
+		ret[i] = cloneFunc(v)
+	}
+	return ret
+}
+
+func cloneMap[M ~map[K]V, K comparable, V any](m M) M {
+	if m == nil {
+		return nil
+	}
+	ret := make(M, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
+
+func cloneMapFunc[M ~map[K]V, K comparable, V any](m M, cloneFunc func(V) V) M {
+	if m == nil {
+		return nil
+	}
+	ret := make(M, len(m))
+	for k, v := range m {
+		ret[k] = cloneFunc(v)
+	}
+	return ret
+}
+
+func cloneNestedMap[K1, K2 comparable, V any](m map[K1]map[K2]V) map[K1]map[K2]V {
+	return cloneMapFunc(m, cloneMap[map[K2]V])
+}
@@ -0,0 +1,124 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sort"
+	"strings"
+)
+
+// SpecComparison is the structural comparison of two specs, e.g. of a service and its re-implementation.
+type SpecComparison struct {
+	// Similarity is from 0 to 1, see SpecSignature.Similarity
+	Similarity float64
+	// Operations maps the operations of the specs, sorted by path and method
+	Operations []OperationComparison
+}
+
+// OperationComparison maps an operation of a spec to the same operation of the other spec. Paths match regardless
+// of the names of their path params, e.g. /api/{id} matches /api/{param1}.
+type OperationComparison struct {
+	Method string
+	// Path is empty if only the other spec has the operation
+	Path string
+	// OtherPath is empty if only the spec has the operation
+	OtherPath string
+	// Similarity is the Jaccard index of the operation fields, or 0 if only one spec has the operation
+	Similarity float64
+	// MissingFields are the "field=type" of the operation that the other spec doesn't have
+	MissingFields []string
+	// AddedFields are the "field=type" of the other spec operation that the spec doesn't have
+	AddedFields []string
+}
+
+// CompareLearnedSpecs compares the approved and learned operations of a and b, see Spec.Signature. Unlike CompareSpecs,
+// paths match regardless of the names of their path params and the specs are scored structurally.
+func CompareLearnedSpecs(a, b *Spec) *SpecComparison {
+	return a.Signature().Compare(b.Signature())
+}
+
+// Compare returns the similarity of the signatures and the comparison of each of their operations.
+func (sig *SpecSignature) Compare(other *SpecSignature) *SpecComparison {
+	comparison := &SpecComparison{
+		Similarity: sig.Similarity(other),
+	}
+
+	keys := make(map[string]bool, len(sig.Operations))
+	for key := range sig.Operations {
+		keys[key] = true
+	}
+	for key := range other.Operations {
+		keys[key] = true
+	}
+	for key := range keys {
+		method, anonymousPath, _ := strings.Cut(key, " ")
+		fields, ok := sig.Operations[key]
+		otherFields, otherOk := other.Operations[key]
+		opComparison := OperationComparison{
+			Method:        method,
+			Path:          getFirstPath(sig.Paths[anonymousPath]),
+			OtherPath:     getFirstPath(other.Paths[anonymousPath]),
+			MissingFields: getMissingFields(fields, otherFields),
+			AddedFields:   getMissingFields(otherFields, fields),
+		}
+		if ok && otherOk {
+			opComparison.Similarity = getJaccardIndex(fields, otherFields)
+		}
+		if !ok {
+			opComparison.Path = ""
+		}
+		if !otherOk {
+			opComparison.OtherPath = ""
+		}
+		comparison.Operations = append(comparison.Operations, opComparison)
+	}
+	sort.Slice(comparison.Operations, func(i, j int) bool {
+		pathI := getComparisonPath(comparison.Operations[i])
+		pathJ := getComparisonPath(comparison.Operations[j])
+		if pathI != pathJ {
+			return pathI < pathJ
+		}
+		return comparison.Operations[i].Method < comparison.Operations[j].Method
+	})
+
+	return comparison
+}
+
+// getMissingFields returns the sorted fields that are not in other.
+func getMissingFields(fields, other map[string]bool) []string {
+	var missing []string
+	for typedField := range fields {
+		if !other[typedField] {
+			missing = append(missing, typedField)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func getFirstPath(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	return paths[0]
+}
+
+func getComparisonPath(opComparison OperationComparison) string {
+	if opComparison.Path != "" {
+		return opComparison.Path
+	}
+	return opComparison.OtherPath
+}
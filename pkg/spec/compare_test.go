@@ -0,0 +1,78 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCompareLearnedSpecs(t *testing.T) {
+	oldSpec := CreateDefaultSpec("old", "80", testOperationGeneratorConfig)
+	assert.NilError(t, oldSpec.LearnTelemetry(createTelemetry("1", http.MethodGet, "/api/users/1", "old", "200", "", `{"id": 1, "name": "a"}`)))
+	assert.NilError(t, oldSpec.LearnTelemetry(createTelemetry("2", http.MethodDelete, "/api/users", "old", "200", "", "")))
+	newSpec := CreateDefaultSpec("new", "80", testOperationGeneratorConfig)
+	assert.NilError(t, newSpec.LearnTelemetry(createTelemetry("3", http.MethodGet, "/api/users/2", "new", "200", "", `{"id": 2, "email": "a"}`)))
+	assert.NilError(t, newSpec.LearnTelemetry(createTelemetry("4", http.MethodPost, "/api/users", "new", "201", "", "")))
+
+	comparison := CompareLearnedSpecs(oldSpec, newSpec)
+	// (2/4 shared fields of GET) / 3 operations
+	assert.Equal(t, comparison.Similarity, 0.5/3)
+	assert.DeepEqual(t, comparison.Operations, []OperationComparison{
+		{
+			Method: http.MethodDelete,
+			Path:   "/api/users",
+		},
+		{
+			Method:    http.MethodPost,
+			OtherPath: "/api/users",
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/api/users/{param1}",
+			OtherPath:     "/api/users/{param1}",
+			Similarity:    0.5,
+			MissingFields: []string{"responses.200.schema.properties.name=string"},
+			AddedFields:   []string{"responses.200.schema.properties.email=string"},
+		},
+	})
+}
+
+func TestSpecSignature_Compare(t *testing.T) {
+	signature := &SpecSignature{
+		Operations: map[string]map[string]bool{"GET /api/{}": {"a=string": true}},
+		Paths:      map[string][]string{"/api/{}": {"/api/{id}"}},
+	}
+	other := &SpecSignature{
+		Operations: map[string]map[string]bool{"GET /api/{}": {"a=string": true}},
+		Paths:      map[string][]string{"/api/{}": {"/api/{param1}", "/api/{userId}"}},
+	}
+
+	assert.DeepEqual(t, signature.Compare(other), &SpecComparison{
+		Similarity: 1,
+		Operations: []OperationComparison{
+			{
+				Method:     http.MethodGet,
+				Path:       "/api/{id}",
+				OtherPath:  "/api/{param1}",
+				Similarity: 1,
+			},
+		},
+	})
+	assert.DeepEqual(t, (&SpecSignature{}).Compare(&SpecSignature{}), &SpecComparison{})
+}
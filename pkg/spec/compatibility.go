@@ -0,0 +1,440 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	oapi_spec "github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+)
+
+type CompatibilityChangeKind string
+
+const (
+	// CompatibilityChangeKindRemovedPath the path and all its operations were removed (breaking)
+	CompatibilityChangeKindRemovedPath CompatibilityChangeKind = "REMOVED_PATH"
+	// CompatibilityChangeKindRemovedOperation the method of an existing path was removed (breaking)
+	CompatibilityChangeKindRemovedOperation CompatibilityChangeKind = "REMOVED_OPERATION"
+	// CompatibilityChangeKindChangedType the type or format of a parameter or schema field changed (breaking)
+	CompatibilityChangeKindChangedType CompatibilityChangeKind = "CHANGED_TYPE"
+	// CompatibilityChangeKindNarrowedEnum values were removed from the enum of a field, or an enum was added (breaking)
+	CompatibilityChangeKindNarrowedEnum CompatibilityChangeKind = "NARROWED_ENUM"
+	// CompatibilityChangeKindNewRequiredField a request parameter or body property was added as required, or an
+	// existing one became required (breaking)
+	CompatibilityChangeKindNewRequiredField CompatibilityChangeKind = "NEW_REQUIRED_FIELD"
+	// CompatibilityChangeKindAddedPath a path was added
+	CompatibilityChangeKindAddedPath CompatibilityChangeKind = "ADDED_PATH"
+	// CompatibilityChangeKindAddedOperation a method was added to an existing path
+	CompatibilityChangeKindAddedOperation CompatibilityChangeKind = "ADDED_OPERATION"
+	// CompatibilityChangeKindAddedField an optional request field, or a response field, was added
+	CompatibilityChangeKindAddedField CompatibilityChangeKind = "ADDED_FIELD"
+	// CompatibilityChangeKindWidenedEnum values were added to the enum of a field, or its enum was removed
+	CompatibilityChangeKindWidenedEnum CompatibilityChangeKind = "WIDENED_ENUM"
+)
+
+var breakingCompatibilityChangeKinds = map[CompatibilityChangeKind]bool{
+	CompatibilityChangeKindRemovedPath:      true,
+	CompatibilityChangeKindRemovedOperation: true,
+	CompatibilityChangeKindChangedType:      true,
+	CompatibilityChangeKindNarrowedEnum:     true,
+	CompatibilityChangeKindNewRequiredField: true,
+}
+
+// CompatibilityChange is a change between two specs, classified as breaking or not.
+type CompatibilityChange struct {
+	Kind     CompatibilityChangeKind `json:"kind"`
+	Breaking bool                    `json:"breaking"`
+	Path     string                  `json:"path"`
+	// Method is empty for path changes
+	Method string `json:"method,omitempty"`
+	// Field is the path of the changed field in the operation (e.g. parameters.body.schema.properties.id,
+	// responses.200.schema.items), empty for path and operation changes
+	Field string `json:"field,omitempty"`
+	// Old and New are the types of CompatibilityChangeKindChangedType changes, or the enum values of enum changes
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// CompatibilityReport holds the changes between two specs, sorted by path and method.
+type CompatibilityReport struct {
+	Changes []CompatibilityChange `json:"changes"`
+}
+
+// IsBreaking returns true if any of the changes is breaking.
+func (r *CompatibilityReport) IsBreaking() bool {
+	return len(r.BreakingChanges()) > 0
+}
+
+// BreakingChanges returns the breaking changes of the report.
+func (r *CompatibilityReport) BreakingChanges() []CompatibilityChange {
+	var ret []CompatibilityChange
+	for _, change := range r.Changes {
+		if change.Breaking {
+			ret = append(ret, change)
+		}
+	}
+	return ret
+}
+
+// CompareSpecs compares two specs (json or yaml, swagger 2.0 or OpenAPI 3.x, e.g. two generated revisions) and
+// classifies their changes as breaking or non-breaking for the clients of oldSpec. Paths are matched as they are,
+// so renamed path params show as a removed and an added path.
+// Enum changes are classified the same way in requests and responses: narrowing is breaking, widening is not.
+func CompareSpecs(oldSpec, newSpec []byte) (*CompatibilityReport, error) {
+	oldSwagger, err := loadSwaggerSpec(oldSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load old spec: %v", err)
+	}
+	newSwagger, err := loadSwaggerSpec(newSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load new spec: %v", err)
+	}
+
+	c := &compatibilityComparer{
+		oldDefinitions: oldSwagger.Definitions,
+		newDefinitions: newSwagger.Definitions,
+		changes:        []CompatibilityChange{},
+	}
+	c.comparePaths(getSwaggerPathItems(oldSwagger), getSwaggerPathItems(newSwagger))
+
+	return &CompatibilityReport{Changes: c.changes}, nil
+}
+
+// loadSwaggerSpec loads a json or yaml spec, OpenAPI 3.x specs are converted into swagger 2.0.
+func loadSwaggerSpec(rawSpec []byte) (*oapi_spec.Swagger, error) {
+	jsonSpec, err := convertToSwaggerJSON(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	swagger := &oapi_spec.Swagger{}
+	if err := json.Unmarshal(jsonSpec, swagger); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+	return swagger, nil
+}
+
+// convertToSwaggerJSON converts a json or yaml spec into a swagger 2.0 json spec.
+func convertToSwaggerJSON(rawSpec []byte) ([]byte, error) {
+	jsonSpec, err := yaml.YAMLToJSON(rawSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert spec into json: %v", err)
+	}
+	isOAS3, err := isOAS3Spec(jsonSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect spec version: %v", err)
+	}
+	if isOAS3 {
+		if jsonSpec, err = convertOAS3ToSwagger(jsonSpec); err != nil {
+			return nil, fmt.Errorf("failed to convert OpenAPI 3.x spec: %v", err)
+		}
+	}
+	return jsonSpec, nil
+}
+
+func getSwaggerPathItems(swagger *oapi_spec.Swagger) map[string]*oapi_spec.PathItem {
+	pathItems := make(map[string]*oapi_spec.PathItem)
+	if swagger.Paths == nil {
+		return pathItems
+	}
+	for path := range swagger.Paths.Paths {
+		pathItem := swagger.Paths.Paths[path]
+		pathItems[path] = &pathItem
+	}
+	return pathItems
+}
+
+type compatibilityComparer struct {
+	oldDefinitions oapi_spec.Definitions
+	newDefinitions oapi_spec.Definitions
+	changes        []CompatibilityChange
+
+	// the path and method of the compared operation
+	path   string
+	method string
+}
+
+func (c *compatibilityComparer) addChange(kind CompatibilityChangeKind, fieldPath *field.Path, oldValue, newValue string) {
+	change := CompatibilityChange{
+		Kind:     kind,
+		Breaking: breakingCompatibilityChangeKinds[kind],
+		Path:     c.path,
+		Method:   c.method,
+		Old:      oldValue,
+		New:      newValue,
+	}
+	if fieldPath != nil {
+		change.Field = fieldPath.String()
+	}
+	c.changes = append(c.changes, change)
+}
+
+func (c *compatibilityComparer) comparePaths(oldPathItems, newPathItems map[string]*oapi_spec.PathItem) {
+	for _, path := range getSortedPaths(oldPathItems, newPathItems) {
+		oldPathItem, oldExist := oldPathItems[path]
+		newPathItem, newExist := newPathItems[path]
+		c.path, c.method = path, ""
+		switch {
+		case !newExist:
+			c.addChange(CompatibilityChangeKindRemovedPath, nil, "", "")
+			continue
+		case !oldExist:
+			c.addChange(CompatibilityChangeKindAddedPath, nil, "", "")
+			continue
+		}
+
+		for _, method := range supportedMethods {
+			oldOp := GetOperationFromPathItem(oldPathItem, method)
+			newOp := GetOperationFromPathItem(newPathItem, method)
+			c.method = method
+			switch {
+			case oldOp == nil && newOp == nil:
+				continue
+			case newOp == nil:
+				c.addChange(CompatibilityChangeKindRemovedOperation, nil, "", "")
+			case oldOp == nil:
+				c.addChange(CompatibilityChangeKindAddedOperation, nil, "", "")
+			default:
+				c.compareParameters(append(append([]oapi_spec.Parameter{}, oldPathItem.Parameters...), oldOp.Parameters...),
+					append(append([]oapi_spec.Parameter{}, newPathItem.Parameters...), newOp.Parameters...))
+				c.compareResponses(oldOp.Responses, newOp.Responses)
+			}
+		}
+	}
+}
+
+// compareParameters compares the parameters of an operation, including the parameters of its path item.
+func (c *compatibilityComparer) compareParameters(oldParams, newParams []oapi_spec.Parameter) {
+	oldParamsByKey := getParametersByKey(oldParams)
+	newParamsByKey := getParametersByKey(newParams)
+	keys := make([]string, 0, len(newParamsByKey))
+	for key := range newParamsByKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parametersPath := field.NewPath("parameters")
+	for _, key := range keys {
+		newParam := newParamsByKey[key]
+		paramPath := parametersPath.Child(newParam.Name)
+		if newParam.In == parametersInBody {
+			paramPath = paramPath.Child("schema")
+		}
+		oldParam, ok := oldParamsByKey[key]
+		if !ok {
+			if newParam.Required {
+				c.addChange(CompatibilityChangeKindNewRequiredField, paramPath, "", "")
+			} else {
+				c.addChange(CompatibilityChangeKindAddedField, paramPath, "", "")
+			}
+			continue
+		}
+		if newParam.Required && !oldParam.Required {
+			c.addChange(CompatibilityChangeKindNewRequiredField, paramPath, "", "")
+		}
+		if newParam.In == parametersInBody {
+			c.compareSchemas(paramPath, oldParam.Schema, newParam.Schema, true, 0)
+			continue
+		}
+		c.compareSimpleSchemas(paramPath, &oldParam.SimpleSchema, &oldParam.CommonValidations,
+			&newParam.SimpleSchema, &newParam.CommonValidations)
+	}
+}
+
+// getParametersByKey returns the parameters by location and name, header names are case insensitive.
+// Later parameters override earlier ones, the same way operation parameters override path item parameters.
+func getParametersByKey(params []oapi_spec.Parameter) map[string]oapi_spec.Parameter {
+	ret := make(map[string]oapi_spec.Parameter, len(params))
+	for _, param := range params {
+		name := param.Name
+		if param.In == parametersInHeader {
+			name = strings.ToLower(name)
+		}
+		// a spec has a single body parameter, regardless of its name
+		if param.In == parametersInBody {
+			name = ""
+		}
+		ret[param.In+"/"+name] = param
+	}
+	return ret
+}
+
+func (c *compatibilityComparer) compareResponses(oldResponses, newResponses *oapi_spec.Responses) {
+	if oldResponses == nil || newResponses == nil {
+		return
+	}
+	codes := make([]int, 0, len(newResponses.StatusCodeResponses))
+	for code := range newResponses.StatusCodeResponses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	responsesPath := field.NewPath("responses")
+	for _, code := range codes {
+		oldResponse, ok := oldResponses.StatusCodeResponses[code]
+		if !ok {
+			continue
+		}
+		newResponse := newResponses.StatusCodeResponses[code]
+		c.compareSchemas(responsesPath.Child(strconv.Itoa(code), "schema"), oldResponse.Schema, newResponse.Schema, false, 0)
+	}
+}
+
+func (c *compatibilityComparer) compareSimpleSchemas(fieldPath *field.Path, oldSchema *oapi_spec.SimpleSchema, oldValidations *oapi_spec.CommonValidations,
+	newSchema *oapi_spec.SimpleSchema, newValidations *oapi_spec.CommonValidations) {
+	if c.compareTypes(fieldPath, oldSchema.Type, oldSchema.Format, newSchema.Type, newSchema.Format) {
+		return
+	}
+	c.compareEnums(fieldPath, oldValidations.Enum, newValidations.Enum)
+	if oldSchema.Items != nil && newSchema.Items != nil {
+		c.compareSimpleSchemas(fieldPath.Child("items"), &oldSchema.Items.SimpleSchema, &oldSchema.Items.CommonValidations,
+			&newSchema.Items.SimpleSchema, &newSchema.Items.CommonValidations)
+	}
+}
+
+// compareSchemas compares the schemas of a request (isRequest) or response field. New required properties are only
+// breaking in requests, a response may always return more properties.
+func (c *compatibilityComparer) compareSchemas(fieldPath *field.Path, oldSchema, newSchema *oapi_spec.Schema, isRequest bool, depth int) {
+	oldSchema = resolveDefinitionRef(oldSchema, c.oldDefinitions)
+	newSchema = resolveDefinitionRef(newSchema, c.newDefinitions)
+	if oldSchema == nil || newSchema == nil || depth >= maxSchemaToRefDepth {
+		return
+	}
+	if c.compareTypes(fieldPath, getCompatibilitySchemaType(oldSchema), oldSchema.Format, getCompatibilitySchemaType(newSchema), newSchema.Format) {
+		return
+	}
+	c.compareEnums(fieldPath, oldSchema.Enum, newSchema.Enum)
+	if oldSchema.Items != nil && newSchema.Items != nil {
+		c.compareSchemas(fieldPath.Child("items"), oldSchema.Items.Schema, newSchema.Items.Schema, isRequest, depth+1)
+	}
+
+	oldRequired := make(map[string]bool, len(oldSchema.Required))
+	for _, name := range oldSchema.Required {
+		oldRequired[name] = true
+	}
+	newRequired := make(map[string]bool, len(newSchema.Required))
+	for _, name := range newSchema.Required {
+		newRequired[name] = true
+	}
+
+	names := make([]string, 0, len(newSchema.Properties))
+	for name := range newSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := fieldPath.Child("properties", name)
+		newProperty := newSchema.Properties[name]
+		oldProperty, ok := oldSchema.Properties[name]
+		isNewRequired := isRequest && newRequired[name] && (!ok || !oldRequired[name])
+		switch {
+		case isNewRequired:
+			c.addChange(CompatibilityChangeKindNewRequiredField, propertyPath, "", "")
+		case !ok:
+			c.addChange(CompatibilityChangeKindAddedField, propertyPath, "", "")
+		}
+		if ok {
+			c.compareSchemas(propertyPath, &oldProperty, &newProperty, isRequest, depth+1)
+		}
+	}
+}
+
+// compareTypes adds a CompatibilityChangeKindChangedType change and returns true if the types differ. Formats are only
+// compared when both are set, since learned formats are dropped when they conflict.
+func (c *compatibilityComparer) compareTypes(fieldPath *field.Path, oldType, oldFormat, newType, newFormat string) bool {
+	if oldType == "" || newType == "" {
+		return false
+	}
+	if oldType == newType && (oldFormat == "" || newFormat == "" || oldFormat == newFormat) {
+		return false
+	}
+	c.addChange(CompatibilityChangeKindChangedType, fieldPath, getCompatibilityTypeName(oldType, oldFormat),
+		getCompatibilityTypeName(newType, newFormat))
+	return true
+}
+
+func (c *compatibilityComparer) compareEnums(fieldPath *field.Path, oldEnum, newEnum []interface{}) {
+	oldValues := getEnumValues(oldEnum)
+	newValues := getEnumValues(newEnum)
+	isNarrowed := len(newValues) > 0 && len(oldValues) == 0
+	isWidened := len(oldValues) > 0 && len(newValues) == 0
+	for value := range oldValues {
+		if _, ok := newValues[value]; !ok && len(newValues) > 0 {
+			isNarrowed = true
+		}
+	}
+	for value := range newValues {
+		if _, ok := oldValues[value]; !ok && len(oldValues) > 0 {
+			isWidened = true
+		}
+	}
+
+	oldEnumStr := strings.Join(getSortedStrings(oldValues), ",")
+	newEnumStr := strings.Join(getSortedStrings(newValues), ",")
+	switch {
+	case isNarrowed:
+		c.addChange(CompatibilityChangeKindNarrowedEnum, fieldPath, oldEnumStr, newEnumStr)
+	case isWidened:
+		c.addChange(CompatibilityChangeKindWidenedEnum, fieldPath, oldEnumStr, newEnumStr)
+	}
+}
+
+func getEnumValues(enum []interface{}) map[string]struct{} {
+	values := make(map[string]struct{}, len(enum))
+	for _, value := range enum {
+		values[fmt.Sprint(value)] = struct{}{}
+	}
+	return values
+}
+
+// resolveDefinitionRef returns the definition a schema refers to, or the schema itself if it is not a ref.
+func resolveDefinitionRef(schema *oapi_spec.Schema, definitions oapi_spec.Definitions) *oapi_spec.Schema {
+	if schema == nil {
+		return nil
+	}
+	ref := schema.Ref.String()
+	if !strings.HasPrefix(ref, definitionsRefPrefix) {
+		return schema
+	}
+	definition, ok := definitions[strings.TrimPrefix(ref, definitionsRefPrefix)]
+	if !ok {
+		return schema
+	}
+	return &definition
+}
+
+func getCompatibilitySchemaType(schema *oapi_spec.Schema) string {
+	if len(schema.Type) > 0 {
+		return schema.Type[0]
+	}
+	if len(schema.Properties) > 0 {
+		return schemaTypeObject
+	}
+	return ""
+}
+
+func getCompatibilityTypeName(tpe, format string) string {
+	if format != "" {
+		return tpe + "(" + format + ")"
+	}
+	return tpe
+}
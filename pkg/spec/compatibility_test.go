@@ -0,0 +1,231 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const compatibilityTestOldSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/users/{param1}": {
+      "parameters": [{"name": "param1", "in": "path", "required": true, "type": "integer"}],
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "type": "integer"},
+          {"name": "sort", "in": "query", "type": "string", "enum": ["asc", "desc"]}
+        ],
+        "responses": {"200": {"description": "user", "schema": {"$ref": "#/definitions/User"}}}
+      },
+      "put": {
+        "parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}],
+        "responses": {"204": {"description": "updated"}}
+      },
+      "delete": {"responses": {"204": {"description": "deleted"}}}
+    },
+    "/health": {"get": {"responses": {"200": {"description": "ok"}}}}
+  },
+  "definitions": {
+    "User": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "integer"},
+        "name": {"type": "string"},
+        "role": {"type": "string", "enum": ["admin", "user"]}
+      }
+    }
+  }
+}`
+
+func TestCompareSpecs(t *testing.T) {
+	tests := []struct {
+		name         string
+		newSpec      string
+		wantChanges  []CompatibilityChange
+		wantBreaking bool
+	}{
+		{
+			name:         "same spec",
+			newSpec:      compatibilityTestOldSpec,
+			wantChanges:  []CompatibilityChange{},
+			wantBreaking: false,
+		},
+		{
+			name: "removed path and operation, added path and operation",
+			newSpec: `{
+  "swagger": "2.0",
+  "paths": {
+    "/users/{param1}": {
+      "parameters": [{"name": "param1", "in": "path", "required": true, "type": "integer"}],
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "type": "integer"},
+          {"name": "sort", "in": "query", "type": "string", "enum": ["asc", "desc"]}
+        ],
+        "responses": {"200": {"description": "user", "schema": {"$ref": "#/definitions/User"}}}
+      },
+      "put": {
+        "parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}],
+        "responses": {"204": {"description": "updated"}}
+      },
+      "patch": {"responses": {"204": {"description": "updated"}}}
+    },
+    "/ready": {"get": {"responses": {"200": {"description": "ok"}}}}
+  },
+  "definitions": {
+    "User": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "integer"},
+        "name": {"type": "string"},
+        "role": {"type": "string", "enum": ["admin", "user"]}
+      }
+    }
+  }
+}`,
+			wantChanges: []CompatibilityChange{
+				{Kind: CompatibilityChangeKindRemovedPath, Breaking: true, Path: "/health"},
+				{Kind: CompatibilityChangeKindAddedPath, Path: "/ready"},
+				{Kind: CompatibilityChangeKindRemovedOperation, Breaking: true, Path: "/users/{param1}", Method: "DELETE"},
+				{Kind: CompatibilityChangeKindAddedOperation, Path: "/users/{param1}", Method: "PATCH"},
+			},
+			wantBreaking: true,
+		},
+		{
+			name: "changed types, enums and required fields",
+			newSpec: `
+swagger: "2.0"
+paths:
+  /users/{param1}:
+    parameters:
+    - {name: param1, in: path, required: true, type: string, format: uuid}
+    get:
+      parameters:
+      - {name: limit, in: query, type: integer, required: true}
+      - {name: sort, in: query, type: string, enum: [asc]}
+      - {name: X-Trace, in: header, type: string}
+      responses:
+        "200":
+          description: user
+          schema:
+            type: object
+            required: [email]
+            properties:
+              id: {type: string}
+              name: {type: string}
+              role: {type: string, enum: [admin, user, guest]}
+              email: {type: string}
+    put:
+      parameters:
+      - name: body
+        in: body
+        schema:
+          type: object
+          required: [name, email]
+          properties:
+            id: {type: integer}
+            name: {type: string}
+            role: {type: string, enum: [admin, user]}
+            email: {type: string}
+      responses:
+        "204": {description: updated}
+    delete:
+      responses:
+        "204": {description: deleted}
+  /health:
+    get:
+      responses:
+        "200": {description: ok}
+`,
+			wantChanges: []CompatibilityChange{
+				{Kind: CompatibilityChangeKindAddedField, Path: "/users/{param1}", Method: "GET", Field: "parameters.X-Trace"},
+				{Kind: CompatibilityChangeKindChangedType, Breaking: true, Path: "/users/{param1}", Method: "GET", Field: "parameters.param1", Old: "integer", New: "string(uuid)"},
+				{Kind: CompatibilityChangeKindNewRequiredField, Breaking: true, Path: "/users/{param1}", Method: "GET", Field: "parameters.limit"},
+				{Kind: CompatibilityChangeKindNarrowedEnum, Breaking: true, Path: "/users/{param1}", Method: "GET", Field: "parameters.sort", Old: "asc,desc", New: "asc"},
+				{Kind: CompatibilityChangeKindAddedField, Path: "/users/{param1}", Method: "GET", Field: "responses.200.schema.properties.email"},
+				{Kind: CompatibilityChangeKindChangedType, Breaking: true, Path: "/users/{param1}", Method: "GET", Field: "responses.200.schema.properties.id", Old: "integer", New: "string"},
+				{Kind: CompatibilityChangeKindWidenedEnum, Path: "/users/{param1}", Method: "GET", Field: "responses.200.schema.properties.role", Old: "admin,user", New: "admin,guest,user"},
+				{Kind: CompatibilityChangeKindNewRequiredField, Breaking: true, Path: "/users/{param1}", Method: "PUT", Field: "parameters.body.schema.properties.email"},
+				{Kind: CompatibilityChangeKindNewRequiredField, Breaking: true, Path: "/users/{param1}", Method: "PUT", Field: "parameters.body.schema.properties.name"},
+				{Kind: CompatibilityChangeKindChangedType, Breaking: true, Path: "/users/{param1}", Method: "PUT", Field: "parameters.param1", Old: "integer", New: "string(uuid)"},
+				{Kind: CompatibilityChangeKindChangedType, Breaking: true, Path: "/users/{param1}", Method: "DELETE", Field: "parameters.param1", Old: "integer", New: "string(uuid)"},
+			},
+			wantBreaking: true,
+		},
+		{
+			name: "non breaking OpenAPI 3.0 spec",
+			newSpec: `{
+  "openapi": "3.0.3",
+  "info": {"title": "users", "version": "1"},
+  "paths": {
+    "/users/{param1}": {
+      "parameters": [{"name": "param1", "in": "path", "required": true, "schema": {"type": "integer"}}],
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+          {"name": "sort", "in": "query", "schema": {"type": "string", "enum": ["asc", "desc", "random"]}}
+        ],
+        "responses": {"200": {"description": "user", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}}
+      },
+      "put": {
+        "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+        "responses": {"204": {"description": "updated"}}
+      },
+      "delete": {"responses": {"204": {"description": "deleted"}}}
+    },
+    "/health": {"get": {"responses": {"200": {"description": "ok"}}}}
+  },
+  "components": {
+    "schemas": {
+      "User": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "name": {"type": "string"},
+          "role": {"type": "string"},
+          "nickname": {"type": "string"}
+        }
+      }
+    }
+  }
+}`,
+			wantChanges: []CompatibilityChange{
+				{Kind: CompatibilityChangeKindWidenedEnum, Path: "/users/{param1}", Method: "GET", Field: "parameters.sort", Old: "asc,desc", New: "asc,desc,random"},
+				{Kind: CompatibilityChangeKindAddedField, Path: "/users/{param1}", Method: "GET", Field: "responses.200.schema.properties.nickname"},
+				{Kind: CompatibilityChangeKindWidenedEnum, Path: "/users/{param1}", Method: "GET", Field: "responses.200.schema.properties.role", Old: "admin,user"},
+				{Kind: CompatibilityChangeKindAddedField, Path: "/users/{param1}", Method: "PUT", Field: "parameters.body.schema.properties.nickname"},
+				{Kind: CompatibilityChangeKindWidenedEnum, Path: "/users/{param1}", Method: "PUT", Field: "parameters.body.schema.properties.role", Old: "admin,user"},
+			},
+			wantBreaking: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CompareSpecs([]byte(compatibilityTestOldSpec), []byte(tt.newSpec))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, report.Changes, tt.wantChanges)
+			assert.Equal(t, report.IsBreaking(), tt.wantBreaking)
+		})
+	}
+}
+
+func TestCompareSpecs_InvalidSpec(t *testing.T) {
+	_, err := CompareSpecs([]byte(compatibilityTestOldSpec), []byte("not: [valid"))
+	assert.ErrorContains(t, err, "failed to load new spec")
+}
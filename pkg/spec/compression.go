@@ -0,0 +1,113 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+)
+
+// compressionExtensionName documents how the responses of an operation were compressed and transferred.
+const compressionExtensionName = "x-compression"
+
+// CompressionExtension is the x-compression extension of an operation, learned from the response headers.
+type CompressionExtension struct {
+	// Encodings are the sorted content encodings (e.g. br, gzip) of the responses
+	Encodings []string `json:"encodings,omitempty"`
+	// Chunked is set once a response was sent with chunked transfer encoding
+	Chunked bool `json:"chunked,omitempty"`
+}
+
+// addCompressionExtension adds the x-compression extension to operation if the response of respHeaders was compressed
+// or chunked.
+func addCompressionExtension(operation *spec.Operation, respHeaders map[string]string) {
+	compression := &CompressionExtension{}
+	for _, encoding := range parseEncodings(respHeaders[contentEncodingHeaderName]) {
+		compression.addEncoding(encoding)
+	}
+	for _, encoding := range parseEncodings(respHeaders[transferEncodingHeaderName]) {
+		if encoding == transferEncodingChunked {
+			compression.Chunked = true
+		}
+	}
+	if len(compression.Encodings) == 0 && !compression.Chunked {
+		return
+	}
+
+	operation.AddExtension(compressionExtensionName, compression)
+}
+
+func (c *CompressionExtension) addEncoding(encoding string) {
+	// x-gzip is an alias of gzip
+	if encoding == contentEncodingXGzip {
+		encoding = contentEncodingGzip
+	}
+	i := sort.SearchStrings(c.Encodings, encoding)
+	if i < len(c.Encodings) && c.Encodings[i] == encoding {
+		return
+	}
+	c.Encodings = append(c.Encodings, "")
+	copy(c.Encodings[i+1:], c.Encodings[i:])
+	c.Encodings[i] = encoding
+}
+
+// getCompressionExtension returns the x-compression extension of extensions, or nil if it has none.
+func getCompressionExtension(extensions spec.Extensions) *CompressionExtension {
+	value, ok := extensions[compressionExtensionName]
+	if !ok {
+		return nil
+	}
+	if compression, ok := value.(*CompressionExtension); ok {
+		return compression
+	}
+
+	// the extension is decoded as a map once the spec was decoded or imported
+	valueB, err := json.Marshal(value)
+	if err != nil {
+		log.Warnf("Failed to marshal %v extension: %v", compressionExtensionName, err)
+		return nil
+	}
+	compression := &CompressionExtension{}
+	if err := json.Unmarshal(valueB, compression); err != nil {
+		log.Warnf("Failed to unmarshal %v extension: %v", compressionExtensionName, err)
+		return nil
+	}
+	return compression
+}
+
+// mergeCompressionExtensions returns the union of the x-compression extensions, or nil if both have none.
+func mergeCompressionExtensions(extensions, extensions2 spec.Extensions) *CompressionExtension {
+	compression := getCompressionExtension(extensions)
+	compression2 := getCompressionExtension(extensions2)
+	if compression == nil && compression2 == nil {
+		return nil
+	}
+
+	ret := &CompressionExtension{}
+	for _, c := range []*CompressionExtension{compression, compression2} {
+		if c == nil {
+			continue
+		}
+		for _, encoding := range c.Encodings {
+			ret.addEncoding(encoding)
+		}
+		ret.Chunked = ret.Chunked || c.Chunked
+	}
+	return ret
+}
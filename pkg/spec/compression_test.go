@@ -0,0 +1,114 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func createCompressedTelemetry(t *testing.T, path, contentEncoding, transferEncoding string) *Telemetry {
+	t.Helper()
+
+	telemetry := createTelemetry("req-id", http.MethodGet, path, "host", "200", "", `{"id": 1}`)
+	if contentEncoding != "" {
+		telemetry.Response.Common.Body = encodeBody(t, telemetry.Response.Common.Body, contentEncoding)
+		telemetry.Response.Common.Headers = append(telemetry.Response.Common.Headers, &Header{Key: "Content-Encoding", Value: contentEncoding})
+	}
+	if transferEncoding != "" {
+		telemetry.Response.Common.Headers = append(telemetry.Response.Common.Headers, &Header{Key: "Transfer-Encoding", Value: transferEncoding})
+	}
+	return telemetry
+}
+
+func Test_addCompressionExtension(t *testing.T) {
+	tests := []struct {
+		name        string
+		respHeaders map[string]string
+		want        *CompressionExtension
+	}{
+		{
+			name:        "not compressed",
+			respHeaders: map[string]string{contentTypeHeaderName: mediaTypeApplicationJSON},
+			want:        nil,
+		},
+		{
+			name:        "identity",
+			respHeaders: map[string]string{contentEncodingHeaderName: contentEncodingIdentity},
+			want:        nil,
+		},
+		{
+			name:        "encodings",
+			respHeaders: map[string]string{contentEncodingHeaderName: "x-gzip, BR, gzip"},
+			want:        &CompressionExtension{Encodings: []string{"br", "gzip"}},
+		},
+		{
+			name:        "chunked",
+			respHeaders: map[string]string{transferEncodingHeaderName: "Chunked"},
+			want:        &CompressionExtension{Chunked: true},
+		},
+		{
+			name:        "compressed and chunked",
+			respHeaders: map[string]string{contentEncodingHeaderName: "br", transferEncodingHeaderName: "chunked"},
+			want:        &CompressionExtension{Encodings: []string{"br"}, Chunked: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation := oapi_spec.NewOperation("")
+			addCompressionExtension(operation, tt.respHeaders)
+			assert.DeepEqual(t, getCompressionExtension(operation.Extensions), tt.want)
+		})
+	}
+}
+
+func Test_mergeCompressionExtensions(t *testing.T) {
+	// the extension of a decoded spec is a map
+	decoded := oapi_spec.Extensions{}
+	assert.NilError(t, json.Unmarshal([]byte(`{"x-compression": {"encodings": ["gzip"]}}`), &decoded))
+	chunked := oapi_spec.Extensions{compressionExtensionName: &CompressionExtension{Encodings: []string{"br", "gzip"}, Chunked: true}}
+
+	assert.Assert(t, mergeCompressionExtensions(nil, oapi_spec.Extensions{largePayloadExtensionName: true}) == nil)
+	assert.DeepEqual(t, mergeCompressionExtensions(decoded, nil), &CompressionExtension{Encodings: []string{"gzip"}})
+	assert.DeepEqual(t, mergeCompressionExtensions(decoded, chunked), &CompressionExtension{Encodings: []string{"br", "gzip"}, Chunked: true})
+}
+
+func TestSpec_LearnTelemetry_Compression(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createCompressedTelemetry(t, "/api", contentEncodingGzip, "")))
+	assert.NilError(t, s.LearnTelemetry(createCompressedTelemetry(t, "/api", contentEncodingBrotli, transferEncodingChunked)))
+	assert.NilError(t, s.LearnTelemetry(createCompressedTelemetry(t, "/api", "", "")))
+
+	op := s.LearningSpec.GetPathItem("/api").Get
+	assert.DeepEqual(t, getCompressionExtension(op.Extensions), &CompressionExtension{Encodings: []string{"br", "gzip"}, Chunked: true})
+	// the compressed bodies are learned
+	assert.DeepEqual(t, op.Responses.StatusCodeResponses[200].Schema.Properties["id"].Type, oapi_spec.StringOrArray{schemaTypeInteger})
+}
+
+func TestSpec_DiffTelemetry_Compression(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createCompressedTelemetry(t, "/api", contentEncodingGzip, "")))
+	assert.NilError(t, s.ApprovePaths([]string{"/api"}))
+
+	// a response compressed differently is not a diff
+	diff, err := s.DiffTelemetry(createCompressedTelemetry(t, "/api", contentEncodingBrotli, ""), DiffSourceReconstructed)
+	assert.NilError(t, err)
+	assert.Equal(t, diff.Type, DiffTypeNoDiff)
+}
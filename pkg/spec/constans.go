@@ -21,10 +21,25 @@ const (
 	parametersInQuery  = "query"
 	parametersInForm   = "formData"
 	parametersInPath   = "path"
+	// cookie params are not supported by swagger 2.0, see convertCookieParamsToHeader
+	parametersInCookie = "cookie"
 )
 
 const (
-	formatUUID = "uuid"
+	formatUUID     = "uuid"
+	formatBinary   = "binary"
+	formatByte     = "byte"
+	formatHostname = "hostname"
+	formatURI      = "uri"
+	formatDate     = "date"
+	formatDateTime = "date-time"
+	// formatUnixTime is an integer of seconds or milliseconds since the epoch
+	formatUnixTime = "unix-time"
+	// formatHex is a hex encoded hash, e.g. a sha256 digest
+	formatHex  = "hex"
+	formatULID = "ulid"
+	// formatJWT is a vendor format of a JSON web token (JWS compact serialization)
+	formatJWT = "jwt"
 )
 
 const (
@@ -36,6 +51,9 @@ const (
 	schemaTypeString  = "string"
 )
 
+// parameterTypeFile is the type of formData file params
+const parameterTypeFile = "file"
+
 const inBodyParameterName = "body"
 
 const (
@@ -48,8 +66,12 @@ const (
 
 const (
 	contentTypeHeaderName       = "content-type"
+	contentLengthHeaderName     = "content-length"
+	contentEncodingHeaderName   = "content-encoding"
+	transferEncodingHeaderName  = "transfer-encoding"
 	acceptTypeHeaderName        = "accept"
 	authorizationTypeHeaderName = "authorization"
+	cookieHeaderName            = "cookie"
 )
 
 const (
@@ -57,4 +79,6 @@ const (
 	mediaTypeApplicationHalJSON = "application/hal+json"
 	mediaTypeApplicationForm    = "application/x-www-form-urlencoded"
 	mediaTypeMultipartFormData  = "multipart/form-data"
+	mediaTypeTextPlain          = "text/plain"
+	mediaTypeOctetStream        = "application/octet-stream"
 )
@@ -0,0 +1,153 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	contentEncodingGzip     = "gzip"
+	contentEncodingXGzip    = "x-gzip"
+	contentEncodingDeflate  = "deflate"
+	contentEncodingBrotli   = "br"
+	contentEncodingIdentity = "identity"
+
+	transferEncodingChunked = "chunked"
+)
+
+// chunk size lines longer than this are not considered as chunked framing
+const maxChunkSizeLineLength = 1024
+
+// decoded bodies larger than this size (in bytes) are not learned
+const maxDecodedBodySize = 10 << 20 // 10 MB
+
+// decodeBody decompresses body by its Content-Encoding header value, e.g. "gzip" or "deflate, br".
+// The encodings are listed in the order they were applied, so they are decoded in reverse order.
+func decodeBody(body []byte, contentEncoding string) ([]byte, error) {
+	encodings := parseEncodings(contentEncoding)
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		if body, err = decodeBodyEncoding(body, encodings[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode %v body: %v", encodings[i], err)
+		}
+	}
+
+	return body, nil
+}
+
+// decodeTransferEncoding decodes body by its Transfer-Encoding header value, e.g. "chunked" or "gzip, chunked".
+// Most taps capture bodies without their chunked framing, so a body that is not framed in chunks is kept as is.
+func decodeTransferEncoding(body []byte, transferEncoding string) ([]byte, error) {
+	encodings := parseEncodings(transferEncoding)
+	if len(encodings) > 0 && encodings[len(encodings)-1] == transferEncodingChunked {
+		encodings = encodings[:len(encodings)-1]
+		if hasChunkedFraming(body) {
+			decoded, err := ioutil.ReadAll(httputil.NewChunkedReader(bytes.NewReader(body)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode chunked body: %v", err)
+			}
+			body = decoded
+		}
+	}
+
+	return decodeBody(body, strings.Join(encodings, ","))
+}
+
+// hasChunkedFraming returns true if body starts with a chunk size line, e.g. "1a\r\n" or "1a;name=value\r\n".
+func hasChunkedFraming(body []byte) bool {
+	lineEnd := bytes.Index(body, []byte("\r\n"))
+	if lineEnd <= 0 || lineEnd > maxChunkSizeLineLength {
+		return false
+	}
+	size := body[:lineEnd]
+	if i := bytes.IndexByte(size, ';'); i >= 0 {
+		size = size[:i]
+	}
+	size = bytes.TrimRight(size, " \t")
+	if len(size) == 0 {
+		return false
+	}
+	for _, c := range size {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseEncodings returns the lower case encodings of a Content-Encoding or Transfer-Encoding header value,
+// in the order they were applied and without the identity encoding.
+func parseEncodings(value string) []string {
+	var encodings []string
+	for _, encoding := range strings.Split(value, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding == "" || encoding == contentEncodingIdentity {
+			continue
+		}
+		encodings = append(encodings, encoding)
+	}
+	return encodings
+}
+
+func decodeBodyEncoding(body []byte, encoding string) ([]byte, error) {
+	var reader io.Reader
+	switch encoding {
+	case contentEncodingGzip, contentEncodingXGzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case contentEncodingDeflate:
+		// deflate is zlib wrapped, though some servers send raw deflate
+		zlibReader, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			flateReader := flate.NewReader(bytes.NewReader(body))
+			defer flateReader.Close()
+			reader = flateReader
+		} else {
+			defer zlibReader.Close()
+			reader = zlibReader
+		}
+	case contentEncodingBrotli:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return nil, fmt.Errorf("unsupported content encoding")
+	}
+
+	decoded, err := ioutil.ReadAll(io.LimitReader(reader, maxDecodedBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) > maxDecodedBodySize {
+		return nil, fmt.Errorf("decoded body is larger than %v bytes", maxDecodedBodySize)
+	}
+
+	return decoded, nil
+}
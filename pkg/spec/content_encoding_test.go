@@ -0,0 +1,245 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func encodeBody(t *testing.T, body []byte, encoding string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case contentEncodingGzip:
+		writer = gzip.NewWriter(&buf)
+	case contentEncodingDeflate:
+		writer = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		flateWriter, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatalf("failed to create flate writer: %v", err)
+		}
+		writer = flateWriter
+	case contentEncodingBrotli:
+		writer = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unexpected encoding %v", encoding)
+	}
+	if _, err := writer.Write(body); err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func Test_decodeBody(t *testing.T) {
+	body := []byte(`{"name":"test","count":1}`)
+	tests := []struct {
+		name            string
+		body            []byte
+		contentEncoding string
+		want            []byte
+		wantErr         bool
+	}{
+		{
+			name:            "gzip",
+			body:            encodeBody(t, body, contentEncodingGzip),
+			contentEncoding: "gzip",
+			want:            body,
+		},
+		{
+			name:            "x-gzip",
+			body:            encodeBody(t, body, contentEncodingGzip),
+			contentEncoding: "x-gzip",
+			want:            body,
+		},
+		{
+			name:            "deflate",
+			body:            encodeBody(t, body, contentEncodingDeflate),
+			contentEncoding: "deflate",
+			want:            body,
+		},
+		{
+			name:            "raw deflate",
+			body:            encodeBody(t, body, "raw-deflate"),
+			contentEncoding: "deflate",
+			want:            body,
+		},
+		{
+			name:            "br",
+			body:            encodeBody(t, body, contentEncodingBrotli),
+			contentEncoding: "br",
+			want:            body,
+		},
+		{
+			name:            "identity",
+			body:            body,
+			contentEncoding: "identity",
+			want:            body,
+		},
+		{
+			name:            "multiple encodings are decoded in reverse order",
+			body:            encodeBody(t, encodeBody(t, body, contentEncodingGzip), contentEncodingBrotli),
+			contentEncoding: "GZIP, br",
+			want:            body,
+		},
+		{
+			name:            "unsupported encoding",
+			body:            body,
+			contentEncoding: "compress",
+			wantErr:         true,
+		},
+		{
+			name:            "invalid gzip",
+			body:            body,
+			contentEncoding: "gzip",
+			wantErr:         true,
+		},
+		{
+			name:            "decoded body over size limit",
+			body:            encodeBody(t, make([]byte, maxDecodedBodySize+1), contentEncodingGzip),
+			contentEncoding: "gzip",
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeBody(tt.body, tt.contentEncoding)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeBody() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeBody() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_LearnTelemetry_EncodedBody(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	telemetry := createTelemetry("req-id", "POST", "/api", "host", "200", Data.ReqBody, Data.RespBody)
+	telemetry.Request.Common.Body = encodeBody(t, telemetry.Request.Common.Body, contentEncodingBrotli)
+	telemetry.Request.Common.Headers = append(telemetry.Request.Common.Headers, &Header{Key: "Content-Encoding", Value: "br"})
+	telemetry.Response.Common.Body = encodeBody(t, telemetry.Response.Common.Body, contentEncodingGzip)
+	telemetry.Response.Common.Headers = append(telemetry.Response.Common.Headers, &Header{Key: "Content-Encoding", Value: "gzip"})
+
+	if err := s.LearnTelemetry(telemetry); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+
+	// the content-encoding headers are learned as well, and the response encoding is documented by x-compression
+	want := createTelemetry("req-id", "POST", "/api", "host", "200", Data.ReqBody, Data.RespBody)
+	want.Request.Common.Headers = append(want.Request.Common.Headers, &Header{Key: "Content-Encoding", Value: "identity"})
+	want.Response.Common.Headers = append(want.Response.Common.Headers, &Header{Key: "Content-Encoding", Value: "identity"})
+	wantSpec := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	if err := wantSpec.LearnTelemetry(want); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+	wantOp := wantSpec.LearningSpec.GetPathItem("/api").Post
+	wantOp.AddExtension(compressionExtensionName, &CompressionExtension{Encodings: []string{contentEncodingGzip}})
+	assertEqualOperationJSON(t, s.LearningSpec.GetPathItem("/api").Post, wantOp)
+}
+
+func Test_decodeTransferEncoding(t *testing.T) {
+	body := []byte(`{"name":"test","count":1}`)
+	chunked := []byte("a;name=value\r\n{\"name\":\"t\r\nf\r\nest\",\"count\":1}\r\n0\r\n\r\n")
+	tests := []struct {
+		name             string
+		body             []byte
+		transferEncoding string
+		want             []byte
+		wantErr          bool
+	}{
+		{
+			name:             "chunked",
+			body:             chunked,
+			transferEncoding: "chunked",
+			want:             body,
+		},
+		{
+			name:             "already unchunked",
+			body:             body,
+			transferEncoding: "Chunked",
+			want:             body,
+		},
+		{
+			name:             "gzip chunked",
+			body:             []byte(fmt.Sprintf("%x\r\n%s\r\n0\r\n\r\n", len(encodeBody(t, body, contentEncodingGzip)), encodeBody(t, body, contentEncodingGzip))),
+			transferEncoding: "gzip, chunked",
+			want:             body,
+		},
+		{
+			name:             "missing chunks",
+			body:             chunked[:20],
+			transferEncoding: "chunked",
+			wantErr:          true,
+		},
+		{
+			name:             "unsupported encoding",
+			body:             body,
+			transferEncoding: "compress, chunked",
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeTransferEncoding(tt.body, tt.transferEncoding)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeTransferEncoding() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeTransferEncoding() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hasChunkedFraming(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "chunk", body: "1a\r\n", want: true},
+		{name: "chunk extension", body: "1A;name=value\r\n", want: true},
+		{name: "last chunk", body: "0\r\n\r\n", want: true},
+		{name: "json", body: `{"a":1}`, want: false},
+		{name: "json lines", body: "{\"a\":1}\r\n{\"a\":2}\r\n", want: false},
+		{name: "empty line", body: "\r\n", want: false},
+		{name: "not hex", body: "hello\r\n", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasChunkedFraming([]byte(tt.body)); got != tt.want {
+				t.Errorf("hasChunkedFraming() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
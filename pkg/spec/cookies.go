@@ -0,0 +1,135 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// cookiesExtensionName holds the cookie params of the swagger 2.0 Cookie header param
+const cookiesExtensionName = "x-cookies"
+
+func createCookieNames(names []string) map[string]struct{} {
+	if len(names) == 0 {
+		return nil
+	}
+	ret := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		ret[name] = struct{}{}
+	}
+	return ret
+}
+
+func (o *OperationGenerator) shouldLearnCookie(name string) bool {
+	if _, ok := o.CookiesToIgnore[name]; ok {
+		return false
+	}
+	if o.CookiesToLearn == nil {
+		return true
+	}
+	_, ok := o.CookiesToLearn[name]
+	return ok
+}
+
+// addCookieParams adds a cookie param for each cookie of the Cookie request header.
+func (o *OperationGenerator) addCookieParams(operation *spec.Operation, cookieHeader string) *spec.Operation {
+	if shouldIgnoreHeader(o.RequestHeadersToIgnore, cookieHeaderName) {
+		return operation
+	}
+
+	header := http.Header{}
+	header.Set(cookieHeaderName, cookieHeader)
+	for _, cookie := range (&http.Request{Header: header}).Cookies() {
+		if !o.shouldLearnCookie(cookie.Name) {
+			continue
+		}
+		cookieParam := &spec.Parameter{ParamProps: spec.ParamProps{Name: cookie.Name, In: parametersInCookie}}
+		if cookie.Value == "" {
+			cookieParam.Typed(schemaTypeString, "")
+		} else {
+			cookieParam = populateParam(cookieParam, []string{cookie.Value}, false)
+		}
+		operation.AddParam(cookieParam)
+	}
+
+	return operation
+}
+
+// convertCookieParamsToHeader replaces the cookie params of each operation, that are not supported by swagger 2.0,
+// with a Cookie header param documenting them. The cookie params are kept in the header param x-cookies extension,
+// see getCookieParams.
+func convertCookieParamsToHeader(pathItems map[string]*spec.PathItem) {
+	for _, pathItem := range pathItems {
+		for _, method := range supportedMethods {
+			operation := GetOperationFromPathItem(pathItem, method)
+			if operation == nil {
+				continue
+			}
+
+			var cookieParams, params []spec.Parameter
+			for _, param := range operation.Parameters {
+				if param.In == parametersInCookie {
+					cookieParams = append(cookieParams, param)
+				} else {
+					params = append(params, param)
+				}
+			}
+			if len(cookieParams) == 0 {
+				continue
+			}
+			sort.Slice(cookieParams, func(i, j int) bool {
+				return cookieParams[i].Name < cookieParams[j].Name
+			})
+			names := make([]string, 0, len(cookieParams))
+			for _, param := range cookieParams {
+				names = append(names, param.Name)
+			}
+
+			headerParam := spec.HeaderParam(http.CanonicalHeaderKey(cookieHeaderName)).
+				Typed(schemaTypeString, "").
+				WithDescription("Cookies: " + strings.Join(names, ", "))
+			headerParam.AddExtension(cookiesExtensionName, cookieParams)
+			operation.Parameters = append(params, *headerParam)
+		}
+	}
+}
+
+// getCookieParams returns the cookie params documented by a Cookie header param, see convertCookieParamsToHeader.
+func getCookieParams(param *spec.Parameter) ([]spec.Parameter, bool, error) {
+	if param.In != parametersInHeader || !strings.EqualFold(param.Name, cookieHeaderName) {
+		return nil, false, nil
+	}
+	extension, ok := param.Extensions[cookiesExtensionName]
+	if !ok {
+		return nil, false, nil
+	}
+
+	extensionB, err := json.Marshal(extension)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal %v: %v", cookiesExtensionName, err)
+	}
+	var cookieParams []spec.Parameter
+	if err := json.Unmarshal(extensionB, &cookieParams); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal %v: %v", cookiesExtensionName, err)
+	}
+	return cookieParams, true, nil
+}
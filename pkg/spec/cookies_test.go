@@ -0,0 +1,132 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestOperationGenerator_addCookieParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		config OperationGeneratorConfig
+		cookie string
+		want   []spec.Parameter
+	}{
+		{
+			name:   "all cookies",
+			cookie: "session_id=3fa85f64-5717-4562-b3fc-2c963f66afa6; count=5; empty=",
+			want: []spec.Parameter{
+				createCookieParam("session_id", schemaTypeString, formatUUID),
+				createCookieParam("count", schemaTypeInteger, ""),
+				createCookieParam("empty", schemaTypeString, ""),
+			},
+		},
+		{
+			name:   "cookies to learn",
+			config: OperationGeneratorConfig{CookiesToLearn: []string{"count", "theme"}},
+			cookie: "session_id=abc; count=5",
+			want: []spec.Parameter{
+				createCookieParam("count", schemaTypeInteger, ""),
+			},
+		},
+		{
+			name:   "cookies to ignore",
+			config: OperationGeneratorConfig{CookiesToLearn: []string{"count", "session_id"}, CookiesToIgnore: []string{"session_id"}},
+			cookie: "session_id=abc; count=5",
+			want: []spec.Parameter{
+				createCookieParam("count", schemaTypeInteger, ""),
+			},
+		},
+		{
+			name:   "not a collection",
+			cookie: "ids=1,2,3",
+			want: []spec.Parameter{
+				createCookieParam("ids", schemaTypeString, ""),
+			},
+		},
+		{
+			name:   "ignored cookie header",
+			config: OperationGeneratorConfig{RequestHeadersToIgnore: []string{"Cookie"}},
+			cookie: "count=5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewOperationGenerator(tt.config).addCookieParams(spec.NewOperation(""), tt.cookie)
+			if !reflect.DeepEqual(got.Parameters, tt.want) {
+				t.Errorf("addCookieParams() = %v, want %v", marshal(got.Parameters), marshal(tt.want))
+			}
+		})
+	}
+}
+
+func TestSpec_GenerateOASJson_CookieParams(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	telemetry := createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", "")
+	telemetry.Request.Common.Headers = append(telemetry.Request.Common.Headers, &Header{Key: "Cookie", Value: "theme=dark; count=5"})
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+	telemetry.Request.Common.Headers[len(telemetry.Request.Common.Headers)-1].Value = "theme=light"
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+
+	oasJSON, err := s.GenerateLearningOAS()
+	assert.NilError(t, err)
+	swagger := &spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, swagger))
+	params := swagger.Paths.Paths["/api"].Get.Parameters
+	assert.Equal(t, len(params), 1)
+	assert.Equal(t, params[0].In, parametersInHeader)
+	assert.Equal(t, params[0].Name, "Cookie")
+	assert.Equal(t, params[0].Description, "Cookies: count, theme")
+	cookieParams, ok, err := getCookieParams(&params[0])
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	wantCookieParams := []spec.Parameter{
+		createCookieParam("count", schemaTypeInteger, ""),
+		createCookieParam("theme", schemaTypeString, ""),
+	}
+	if !reflect.DeepEqual(cookieParams, wantCookieParams) {
+		t.Errorf("getCookieParams() = %v, want %v", marshal(cookieParams), marshal(wantCookieParams))
+	}
+
+	oas31JSON, err := ConvertToOAS31(oasJSON)
+	assert.NilError(t, err)
+	var oas31 struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name string
+				In   string
+			}
+		}
+	}
+	assert.NilError(t, json.Unmarshal(oas31JSON, &oas31))
+	oas31Params := oas31.Paths["/api"]["get"].Parameters
+	assert.Equal(t, len(oas31Params), 2)
+	assert.Equal(t, oas31Params[0].Name, "count")
+	assert.Equal(t, oas31Params[0].In, parametersInCookie)
+	assert.Equal(t, oas31Params[1].Name, "theme")
+	assert.Equal(t, oas31Params[1].In, parametersInCookie)
+}
+
+func createCookieParam(name, tpe, format string) spec.Parameter {
+	return *(&spec.Parameter{ParamProps: spec.ParamProps{Name: name, In: parametersInCookie}}).Typed(tpe, format)
+}
@@ -0,0 +1,186 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+)
+
+const debugIndent = "  "
+
+// DebugDump writes a human-readable dump of the spec internal state: the approved and provided path tries, the learned
+// paths and their parameterization, the schema outliers and the learning stats. Meant to be attached to bug reports.
+func (s *Spec) DebugDump(w io.Writer) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Spec %v:%v (%v)\n", s.Host, s.Port, s.ID)
+
+	b.WriteString("Approved path trie:\n")
+	writeDebugPathTrie(b, s.ApprovedPathTrie)
+	b.WriteString("Provided path trie:\n")
+	writeDebugPathTrie(b, s.ProvidedPathTrie)
+
+	b.WriteString("Learning paths:\n")
+	s.writeDebugLearningPaths(b)
+
+	b.WriteString("Schema outliers:\n")
+	s.writeDebugSchemaOutliers(b)
+
+	b.WriteString("Ignored operations:\n")
+	s.writeDebugIgnoredOperations(b)
+
+	b.WriteString("Stats:\n")
+	s.writeDebugStats(b)
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write debug dump: %v", err)
+	}
+	return nil
+}
+
+// writeDebugPathTrie writes the nodes of trie, indented by depth, with the value of the nodes of full paths.
+func writeDebugPathTrie(b *strings.Builder, trie pathtrie.TypedPathTrie[string]) {
+	if len(trie.Trie) == 0 {
+		b.WriteString(debugIndent + "(empty)\n")
+		return
+	}
+	writeDebugTrieNodes(b, trie.Trie, 1)
+}
+
+func writeDebugTrieNodes(b *strings.Builder, nodes pathtrie.TypedPathToTrieNode[string], depth int) {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		node := nodes[name]
+		// the root node of paths starting with the separator has no name
+		if node.FullPath == "" {
+			writeDebugTrieNodes(b, node.Children, depth)
+			continue
+		}
+		b.WriteString(strings.Repeat(debugIndent, depth) + node.FullPath)
+		if node.Value != "" {
+			fmt.Fprintf(b, " = %v", node.Value)
+		}
+		b.WriteString("\n")
+		writeDebugTrieNodes(b, node.Children, depth+1)
+	}
+}
+
+// writeDebugLearningPaths writes the learned paths grouped by the parameterized path suggested for review, with the
+// hit count of each method and the approved path they match.
+func (s *Spec) writeDebugLearningPaths(b *strings.Builder) {
+	if s.LearningSpec == nil || len(s.LearningSpec.PathItems) == 0 {
+		b.WriteString(debugIndent + "(empty)\n")
+		return
+	}
+	parameterizedPaths := s.createLearningParametrizedPaths().Paths
+	sortedParameterizedPaths := make([]string, 0, len(parameterizedPaths))
+	for parameterizedPath := range parameterizedPaths {
+		sortedParameterizedPaths = append(sortedParameterizedPaths, parameterizedPath)
+	}
+	sort.Strings(sortedParameterizedPaths)
+	for _, parameterizedPath := range sortedParameterizedPaths {
+		b.WriteString(debugIndent + parameterizedPath + "\n")
+		for _, path := range getDebugSortedKeys(parameterizedPaths[parameterizedPath]) {
+			var methods []string
+			pathItem := s.LearningSpec.GetPathItem(path)
+			for _, method := range supportedMethods {
+				if GetOperationFromPathItem(pathItem, method) == nil {
+					continue
+				}
+				methods = append(methods, fmt.Sprintf("%v hits=%v", method, s.getOperationHitCount(path, method)))
+			}
+			fmt.Fprintf(b, "%v%v: %v", strings.Repeat(debugIndent, 2), path, strings.Join(methods, ", "))
+			if approvedPath, _, found := s.ApprovedPathTrie.GetPathAndValue(path); found {
+				fmt.Fprintf(b, " (approved as %v)", approvedPath)
+			}
+			b.WriteString("\n")
+		}
+	}
+}
+
+func (s *Spec) writeDebugSchemaOutliers(b *strings.Builder) {
+	if len(s.SchemaOutliers) == 0 {
+		b.WriteString(debugIndent + "(empty)\n")
+		return
+	}
+	for _, outlier := range s.SchemaOutliers {
+		fmt.Fprintf(b, "%v%v %v %v: count=%v first=%v last=%v: %v\n", debugIndent, outlier.Method, outlier.Path,
+			outlier.Field, outlier.Count, formatDebugTime(outlier.FirstSeen), formatDebugTime(outlier.LastSeen), outlier.Message)
+	}
+}
+
+func (s *Spec) writeDebugIgnoredOperations(b *strings.Builder) {
+	if len(s.IgnoredOperations) == 0 {
+		b.WriteString(debugIndent + "(empty)\n")
+		return
+	}
+	paths := make([]string, 0, len(s.IgnoredOperations))
+	for path := range s.IgnoredOperations {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, method := range getDebugSortedKeys(s.IgnoredOperations[path]) {
+			fmt.Fprintf(b, "%v%v %v\n", debugIndent, method, path)
+		}
+	}
+}
+
+func (s *Spec) writeDebugStats(b *strings.Builder) {
+	if s.LearningStats == nil {
+		b.WriteString(debugIndent + "(empty)\n")
+		return
+	}
+	stats := s.LearningStats
+	fmt.Fprintf(b, "%vtelemetries=%v first=%v last=%v\n", debugIndent, stats.TelemetryCount,
+		formatDebugTime(stats.FirstSeen), formatDebugTime(stats.LastSeen))
+	var sources []string
+	for source, count := range stats.Sources {
+		sources = append(sources, fmt.Sprintf("%v=%v", source, count))
+	}
+	sort.Strings(sources)
+	if len(sources) > 0 {
+		fmt.Fprintf(b, "%vsources: %v\n", debugIndent, strings.Join(sources, ", "))
+	}
+}
+
+func getDebugSortedKeys(m map[string]bool) []string {
+	ret := make([]string, 0, len(m))
+	for key := range m {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func formatDebugTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
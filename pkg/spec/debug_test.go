@@ -0,0 +1,76 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_DebugDump(t *testing.T) {
+	seen := time.Date(2021, 8, 23, 6, 52, 48, 0, time.UTC)
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for _, path := range []string{"/api/1", "/api/2", "/health"} {
+		telemetry := createTelemetry("", http.MethodGet, path, "host", "200", "", `{"a":1}`)
+		telemetry.Timestamp = seen
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	s.ApprovedPathTrie.Insert("/api/{id}", "path-id")
+	assert.NilError(t, s.IgnoreOperation("/health", http.MethodGet))
+	s.SchemaOutliers = append(s.SchemaOutliers, &SchemaOutlier{
+		Path:      "/api/1",
+		Method:    http.MethodGet,
+		Field:     "responses.200.schema.properties.a",
+		Message:   "type mismatch",
+		Count:     2,
+		FirstSeen: seen,
+		LastSeen:  seen,
+	})
+
+	b := &strings.Builder{}
+	assert.NilError(t, s.DebugDump(b))
+	assert.Equal(t, b.String(), `Spec host:80 (`+s.ID.String()+`)
+Approved path trie:
+  /api
+    /api/{id} = path-id
+Provided path trie:
+  (empty)
+Learning paths:
+  /api/{param1}
+    /api/1: GET hits=1 (approved as /api/{id})
+    /api/2: GET hits=1 (approved as /api/{id})
+  /health
+    /health: GET hits=1
+Schema outliers:
+  GET /api/1 responses.200.schema.properties.a: count=2 first=2021-08-23T06:52:48Z last=2021-08-23T06:52:48Z: type mismatch
+Ignored operations:
+  GET /health
+Stats:
+  telemetries=3 first=2021-08-23T06:52:48Z last=2021-08-23T06:52:48Z
+  sources: unknown=3
+`)
+}
+
+func TestSpec_DebugDump_Empty(t *testing.T) {
+	s := &Spec{}
+	b := &strings.Builder{}
+	assert.NilError(t, s.DebugDump(b))
+	assert.Equal(t, strings.Count(b.String(), "(empty)"), 6)
+}
@@ -34,8 +34,8 @@ func CreateDefaultSpec(host string, port string, config OperationGeneratorConfig
 				PathItems:           map[string]*spec.PathItem{},
 				SecurityDefinitions: map[string]*spec.SecurityScheme{},
 			},
-			ApprovedPathTrie: pathtrie.New(),
-			ProvidedPathTrie: pathtrie.New(),
+			ApprovedPathTrie: pathtrie.NewTyped[string](),
+			ProvidedPathTrie: pathtrie.NewTyped[string](),
 		},
 		OpGenerator: NewOperationGenerator(config),
 	}
@@ -38,9 +38,15 @@ func updateDefinitions(definitions map[string]spec.Schema, op *spec.Operation) (
 	}
 
 	if op.Responses != nil {
-		for i, response := range op.Responses.StatusCodeResponses {
+		codes := make([]int, 0, len(op.Responses.StatusCodeResponses))
+		for code := range op.Responses.StatusCodeResponses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			response := op.Responses.StatusCodeResponses[code]
 			definitions, response.Schema = schemaToRef(definitions, response.Schema, "", 0)
-			op.Responses.StatusCodeResponses[i] = response
+			op.Responses.StatusCodeResponses[code] = response
 		}
 	}
 
@@ -62,6 +68,15 @@ func schemaToRef(definitions map[string]spec.Schema, schema *spec.Schema, defNam
 		return definitions, schema
 	}
 
+	if isVariantsSchema(schema) {
+		for i := range schema.AnyOf {
+			var variant *spec.Schema
+			definitions, variant = schemaToRef(definitions, &schema.AnyOf[i], defNameHint, depth+1)
+			schema.AnyOf[i] = *variant
+		}
+		return definitions, schema
+	}
+
 	if schema.Type.Contains(schemaTypeArray) {
 		if schema.Items == nil {
 			// no need to create definition for an empty array
@@ -81,14 +96,18 @@ func schemaToRef(definitions map[string]spec.Schema, schema *spec.Schema, defNam
 		return definitions, schema
 	}
 
-	// go over all properties in the object and convert each one to ref if needed
-	var propNames []string
+	// go over all properties in the object and convert each one to ref if needed, in order so that
+	// properties with conflicting definition names get the same names between exports
+	propNames := make([]string, 0, len(schema.Properties))
 	for propName := range schema.Properties {
+		propNames = append(propNames, propName)
+	}
+	sort.Strings(propNames)
+	for _, propName := range propNames {
 		var newSchema *spec.Schema
 		propSchema := schema.Properties[propName]
 		definitions, newSchema = schemaToRef(definitions, &propSchema, propName, depth+1)
 		schema.Properties[propName] = *newSchema
-		propNames = append(propNames, propName)
 	}
 
 	// look for definition with identical schema
@@ -17,6 +17,7 @@ package spec
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -24,8 +25,10 @@ import (
 	"strings"
 
 	oapi_spec "github.com/go-openapi/spec"
-	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
 )
 
 type DiffType string
@@ -52,6 +55,9 @@ type APIDiff struct {
 	ModifiedPathItem *oapi_spec.PathItem
 	InteractionID    uuid.UUID
 	SpecID           uuid.UUID
+	// Source and Metadata of the caller of the diffed interaction
+	Source   SourceLabel
+	Metadata map[string]string
 }
 
 type operationDiff struct {
@@ -71,26 +77,32 @@ type DiffParams struct {
 func (s *Spec) createDiffParamsFromTelemetry(telemetry *Telemetry) (*DiffParams, error) {
 	securityDefinitions := oapi_spec.SecurityDefinitions{}
 
+	method, err := NormalizeMethod(telemetry.Request.Method)
+	if err != nil {
+		return nil, fmt.Errorf("invalid telemetry: %w", err)
+	}
 	path, _ := GetPathAndQuery(telemetry.Request.Path)
-	telemetryOp, err := s.telemetryToOperation(telemetry, securityDefinitions)
+	telemetryOp, _, err := s.telemetryToOperation(context.Background(), telemetry, securityDefinitions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert telemetry to operation: %w", err)
 	}
 	return &DiffParams{
 		operation: telemetryOp,
-		method:    telemetry.Request.Method,
+		method:    method,
 		path:      path,
 		requestID: telemetry.RequestID,
 		response:  telemetry.Response,
 	}, nil
 }
 
-func (s *Spec) DiffTelemetry(telemetry *Telemetry, diffSource DiffSource) (*APIDiff, error) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+func (s *Spec) DiffTelemetry(telemetry *Telemetry, diffSource DiffSource) (apiDiff *APIDiff, err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	defer utils.RecoverPanic(&err)
 
-	var apiDiff *APIDiff
-	var err error
+	if err := telemetry.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid telemetry: %w", err)
+	}
 	diffParams, err := s.createDiffParamsFromTelemetry(telemetry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create diff params from telemetry. %w", err)
@@ -98,7 +110,7 @@ func (s *Spec) DiffTelemetry(telemetry *Telemetry, diffSource DiffSource) (*APID
 
 	switch diffSource {
 	case DiffSourceProvided:
-		if !s.HasProvidedSpec() {
+		if !s.hasProvidedSpec() {
 			log.Infof("No provided spec to diff")
 			return nil, nil
 		}
@@ -107,7 +119,7 @@ func (s *Spec) DiffTelemetry(telemetry *Telemetry, diffSource DiffSource) (*APID
 			return nil, fmt.Errorf("failed to diff provided spec. %w", err)
 		}
 	case DiffSourceReconstructed:
-		if !s.HasApprovedSpec() {
+		if !s.hasApprovedSpec() {
 			log.Infof("No approved spec to diff")
 			return nil, nil
 		}
@@ -118,21 +130,20 @@ func (s *Spec) DiffTelemetry(telemetry *Telemetry, diffSource DiffSource) (*APID
 	default:
 		return nil, fmt.Errorf("diff source: %v is not valid", diffSource)
 	}
+	if apiDiff != nil {
+		apiDiff.Source = telemetry.Source
+		apiDiff.Metadata = telemetry.Metadata
+	}
 
 	return apiDiff, nil
 }
 
 func (s *Spec) diffApprovedSpec(diffParams *DiffParams) (*APIDiff, error) {
 	var pathItem *oapi_spec.PathItem
-	pathFromTrie, value, found := s.ApprovedPathTrie.GetPathAndValue(diffParams.path)
-	if found {
+	if pathFromTrie, pathID, found := lookupPathID(&s.ApprovedPathTrie, diffParams.path); found {
 		diffParams.path = pathFromTrie // The diff will show the parametrized path if matched and not the telemetry path
 		pathItem = s.ApprovedSpec.GetPathItem(pathFromTrie)
-		if pathID, ok := value.(string); !ok {
-			log.Warnf("value is not a string. %v", value)
-		} else {
-			diffParams.pathID = pathID
-		}
+		diffParams.pathID = pathID
 	}
 	return s.diffPathItem(pathItem, diffParams)
 }
@@ -142,16 +153,11 @@ func (s *Spec) diffProvidedSpec(diffParams *DiffParams) (*APIDiff, error) {
 
 	pathNoBase := trimBasePathIfNeeded(s.ProvidedSpec.Spec.BasePath, diffParams.path)
 
-	pathFromTrie, value, found := s.ProvidedPathTrie.GetPathAndValue(pathNoBase)
-	if found {
+	if pathFromTrie, pathID, found := lookupPathID(&s.ProvidedPathTrie, pathNoBase); found {
 		// The diff will show the parametrized path if matched and not the telemetry path
 		diffParams.path = addBasePathIfNeeded(s.ProvidedSpec.Spec.BasePath, pathFromTrie)
 		pathItem = s.ProvidedSpec.GetPathItem(pathFromTrie)
-		if pathID, ok := value.(string); !ok {
-			log.Warnf("value is not a string. %v", value)
-		} else {
-			diffParams.pathID = pathID
-		}
+		diffParams.pathID = pathID
 	}
 
 	return s.diffPathItem(pathItem, diffParams)
@@ -186,7 +192,7 @@ func (s *Spec) diffPathItem(pathItem *oapi_spec.PathItem, diffParams *DiffParams
 	path := diffParams.path
 	requestID := diffParams.requestID
 	pathID := diffParams.pathID
-	reqUUID := uuid.NewV5(uuid.Nil, requestID)
+	reqUUID := uuid.NewFromName(requestID)
 
 	if pathItem == nil {
 		apiDiff = s.createAPIDiffEvent(DiffTypeShadowDiff, nil, createPathItemFromOperation(method, telemetryOp),
@@ -249,6 +255,10 @@ func calculateOperationDiff(specOp, telemetryOp *oapi_spec.Operation, telemetryR
 		return nil, fmt.Errorf("failed to clone spec operation: %w", err)
 	}
 
+	// a response compressed differently is not an API change
+	delete(clonedTelemetryOp.Extensions, compressionExtensionName)
+	delete(clonedSpecOp.Extensions, compressionExtensionName)
+
 	clonedTelemetryOp = sortParameters(clonedTelemetryOp)
 	clonedSpecOp = sortParameters(clonedSpecOp)
 
@@ -21,9 +21,9 @@ import (
 	"testing"
 
 	"github.com/go-openapi/spec"
-	uuid "github.com/satori/go.uuid"
 
 	"github.com/apiclarity/speculator/pkg/pathtrie"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
 )
 
 var Data = &HTTPInteractionData{
@@ -123,13 +123,13 @@ func createTelemetryWithSecurity(reqID, method, path, host, statusCode string, r
 
 func TestSpec_DiffTelemetry_Reconstructed(t *testing.T) {
 	reqID := "req-id"
-	reqUUID := uuid.NewV5(uuid.Nil, reqID)
-	specUUID := uuid.NewV5(uuid.Nil, "spec-id")
+	reqUUID := uuid.NewFromName(reqID)
+	specUUID := uuid.NewFromName("spec-id")
 	type fields struct {
 		ID               uuid.UUID
 		ApprovedSpec     *ApprovedSpec
 		LearningSpec     *LearningSpec
-		ApprovedPathTrie pathtrie.PathTrie
+		ApprovedPathTrie pathtrie.TypedPathTrie[string]
 	}
 	type args struct {
 		telemetry *Telemetry
@@ -357,12 +357,12 @@ func TestSpec_DiffTelemetry_Reconstructed(t *testing.T) {
 
 func TestSpec_DiffTelemetry_Provided(t *testing.T) {
 	reqID := "req-id"
-	reqUUID := uuid.NewV5(uuid.Nil, reqID)
-	specUUID := uuid.NewV5(uuid.Nil, "spec-id")
+	reqUUID := uuid.NewFromName(reqID)
+	specUUID := uuid.NewFromName("spec-id")
 	type fields struct {
 		ID               uuid.UUID
 		ProvidedSpec     *ProvidedSpec
-		ProvidedPathTrie pathtrie.PathTrie
+		ProvidedPathTrie pathtrie.TypedPathTrie[string]
 	}
 	type args struct {
 		telemetry *Telemetry
@@ -771,8 +771,8 @@ func clearProduces(op *spec.Operation) *spec.Operation {
 	return op
 }
 
-func createPathTrie(pathToValue map[string]string) pathtrie.PathTrie {
-	pt := pathtrie.New()
+func createPathTrie(pathToValue map[string]string) pathtrie.TypedPathTrie[string] {
+	pt := pathtrie.NewTyped[string]()
 	for path, value := range pathToValue {
 		pt.Insert(path, value)
 	}
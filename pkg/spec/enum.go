@@ -0,0 +1,314 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/utils/field"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// FieldValueStats are the distinct values seen for a plain string or integer field of an operation,
+// see OperationGeneratorConfig.EnumMaxValues.
+type FieldValueStats struct {
+	// SampleCount is the amount of telemetries the field was seen in
+	SampleCount int
+	// Values are the sorted distinct values of the field, nil once Exceeded
+	Values []string
+	// Exceeded is set once the field had more than EnumMaxValues distinct values, it is never inferred as an enum again
+	Exceeded bool
+}
+
+func (f *FieldValueStats) addValues(values []string, maxValues int) {
+	f.SampleCount++
+	if f.Exceeded {
+		return
+	}
+	f.Values = addDistinctValues(f.Values, values)
+	if len(f.Values) > maxValues {
+		f.Exceeded = true
+		f.Values = nil
+	}
+}
+
+func (f *FieldValueStats) merge(other *FieldValueStats) {
+	f.SampleCount += other.SampleCount
+	f.Exceeded = f.Exceeded || other.Exceeded
+	if f.Exceeded {
+		f.Values = nil
+		return
+	}
+	f.Values = addDistinctValues(f.Values, other.Values)
+}
+
+func addDistinctValues(sorted, values []string) []string {
+	for _, value := range values {
+		i := sort.SearchStrings(sorted, value)
+		if i < len(sorted) && sorted[i] == value {
+			continue
+		}
+		sorted = append(sorted, "")
+		copy(sorted[i+1:], sorted[i:])
+		sorted[i] = value
+	}
+	return sorted
+}
+
+func (o *OperationStats) addFieldValues(fieldValues map[string][]string, maxValues int) {
+	if len(fieldValues) == 0 {
+		return
+	}
+	if o.FieldValues == nil {
+		o.FieldValues = make(map[string]*FieldValueStats)
+	}
+	for fieldPath, values := range fieldValues {
+		fieldStats, ok := o.FieldValues[fieldPath]
+		if !ok {
+			fieldStats = &FieldValueStats{}
+			o.FieldValues[fieldPath] = fieldStats
+		}
+		fieldStats.addValues(values, maxValues)
+	}
+}
+
+// recordFieldValues records the values of the enum candidate fields of telemetry (see getTelemetryFieldValues).
+// Must be called after the telemetry stats were recorded.
+func (s *Spec) recordFieldValues(path, method string, fieldValues map[string][]string) {
+	opStats, ok := s.LearningStats.Operations[path][method]
+	if !ok {
+		return
+	}
+	opStats.addFieldValues(fieldValues, s.OpGenerator.EnumMaxValues)
+}
+
+// getTelemetryFieldValues returns the values of the telemetry fields by the field paths of telemetryOp
+// (see forEachOperationField). Only the plain string and integer fields of telemetryOp, the operation learned from
+// telemetry, are enum candidates.
+func (o *OperationGenerator) getTelemetryFieldValues(telemetry *Telemetry, telemetryOp *oapi_spec.Operation) map[string][]string {
+	candidates := make(map[string][]string)
+	parametersPath := field.NewPath("parameters")
+
+	reqHeaders := ConvertHeadersToMap(telemetry.Request.Common.Headers)
+	reqBody := string(telemetry.Request.Common.getLearningBody())
+	switch mediaType, _, _ := mime.ParseMediaType(reqHeaders[contentTypeHeaderName]); {
+	case reqBody == "" || o.isLargePayload(mediaType, reqBody):
+	case utils.IsApplicationJSONMediaType(mediaType):
+		addJSONFieldValues(candidates, reqBody, parametersPath.Child(inBodyParameterName, "schema"))
+	case mediaType == mediaTypeApplicationForm:
+		if values, err := url.ParseQuery(reqBody); err == nil {
+			addParamFieldValues(candidates, values, parametersPath)
+		}
+	}
+	if queryParams, err := extractQueryParams(telemetry.Request.Path); err == nil {
+		addParamFieldValues(candidates, queryParams, parametersPath)
+	}
+	for key, value := range reqHeaders {
+		if !isSensitiveEnumField(key) {
+			headerPath := parametersPath.Child(key).String()
+			candidates[headerPath] = append(candidates[headerPath], value)
+		}
+	}
+
+	if statusCode, err := strconv.Atoi(telemetry.Response.StatusCode); err == nil {
+		codePath := field.NewPath("responses").Child(strconv.Itoa(statusCode))
+		respHeaders := ConvertHeadersToMap(telemetry.Response.Common.Headers)
+		respBody := string(telemetry.Response.Common.getLearningBody())
+		mediaType, _, _ := mime.ParseMediaType(respHeaders[contentTypeHeaderName])
+		if respBody != "" && !o.isLargePayload(mediaType, respBody) && utils.IsApplicationJSONMediaType(mediaType) {
+			addJSONFieldValues(candidates, respBody, codePath.Child("schema"))
+		}
+		for key, value := range respHeaders {
+			if !isSensitiveEnumField(key) {
+				headerPath := codePath.Child("headers", key).String()
+				candidates[headerPath] = append(candidates[headerPath], value)
+			}
+		}
+	}
+
+	ret := make(map[string][]string)
+	forEachOperationField(telemetryOp, func(path *field.Path, fieldType, format string) {
+		if !isEnumCandidate(fieldType, format) {
+			return
+		}
+		if values, ok := candidates[path.String()]; ok {
+			ret[path.String()] = values
+		}
+	})
+	return ret
+}
+
+func isEnumCandidate(fieldType, format string) bool {
+	return fieldType == schemaTypeInteger || (fieldType == schemaTypeString && format == "")
+}
+
+// isSensitiveEnumField returns true for the fields whose values must not be published as an enum, e.g. an API key
+// header that always has the same value.
+func isSensitiveEnumField(name string) bool {
+	return DefaultRedactionPolicy().isSensitiveName(name)
+}
+
+func addParamFieldValues(candidates map[string][]string, values url.Values, path *field.Path) {
+	for key, paramValues := range values {
+		// repeated params are learned as arrays
+		if len(paramValues) == 1 && !isSensitiveEnumField(key) {
+			paramPath := path.Child(key).String()
+			candidates[paramPath] = append(candidates[paramPath], paramValues[0])
+		}
+	}
+}
+
+func addJSONFieldValues(candidates map[string][]string, body string, path *field.Path) {
+	value, err := gojsonschema.NewStringLoader(body).LoadJSON()
+	if err != nil {
+		return
+	}
+	addJSONValues(candidates, value, path, 0)
+}
+
+func addJSONValues(candidates map[string][]string, value interface{}, path *field.Path, depth int) {
+	if depth >= maxSchemaToRefDepth {
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		candidates[path.String()] = append(candidates[path.String()], v)
+	case json.Number:
+		candidates[path.String()] = append(candidates[path.String()], v.String())
+	case map[string]interface{}:
+		for key, fieldValue := range v {
+			if isSensitiveEnumField(key) {
+				continue
+			}
+			addJSONValues(candidates, fieldValue, path.Child("properties", escapeString(key)), depth+1)
+		}
+	case []interface{}:
+		for _, item := range v {
+			addJSONValues(candidates, item, path.Child("items"), depth+1)
+		}
+	}
+}
+
+// addInferredEnums sets an enum on the operation fields of pathItems that were seen in at least EnumMinSamples
+// telemetries and had at most EnumMaxValues distinct values. opStats are the operation stats by the paths of pathItems.
+func (s *Spec) addInferredEnums(pathItems map[string]*oapi_spec.PathItem, opStats map[string]map[string]*OperationStats) {
+	maxValues := s.OpGenerator.EnumMaxValues
+	if maxValues <= 0 {
+		return
+	}
+
+	for path, pathItem := range pathItems {
+		for _, method := range supportedMethods {
+			operation := GetOperationFromPathItem(pathItem, method)
+			stats, ok := opStats[path][method]
+			if operation == nil || !ok || len(stats.FieldValues) == 0 {
+				continue
+			}
+			forEachOperationEnum(operation, func(path *field.Path, fieldType string, enum *[]interface{}) {
+				fieldStats, ok := stats.FieldValues[path.String()]
+				if !ok || fieldStats.Exceeded || len(fieldStats.Values) == 0 || len(fieldStats.Values) > maxValues ||
+					fieldStats.SampleCount < s.OpGenerator.EnumMinSamples {
+					return
+				}
+				*enum = createEnum(fieldType, fieldStats.Values)
+			})
+		}
+	}
+}
+
+// createEnum returns the values as enum values of fieldType, or nil if a value is not of fieldType.
+func createEnum(fieldType string, values []string) []interface{} {
+	ret := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if fieldType != schemaTypeInteger {
+			ret = append(ret, value)
+			continue
+		}
+		intValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil
+		}
+		ret = append(ret, intValue)
+	}
+	if fieldType == schemaTypeInteger {
+		sort.Slice(ret, func(i, j int) bool {
+			return ret[i].(int64) < ret[j].(int64)
+		})
+	}
+	return ret
+}
+
+// forEachOperationEnum calls fn with the type and enum of each plain string and integer field of op, with the field
+// paths of forEachOperationField.
+func forEachOperationEnum(op *oapi_spec.Operation, fn func(path *field.Path, fieldType string, enum *[]interface{})) {
+	parametersPath := field.NewPath("parameters")
+	for i := range op.Parameters {
+		param := &op.Parameters[i]
+		if param.In == parametersInBody || param.Type == "" {
+			forEachSchemaEnum(param.Schema, parametersPath.Child(param.Name, "schema"), fn, 0)
+			continue
+		}
+		paramPath := parametersPath.Child(param.Name)
+		if isEnumCandidate(param.Type, param.Format) {
+			fn(paramPath, param.Type, &param.Enum)
+		}
+		for items := param.Items; items != nil; items = items.Items {
+			paramPath = paramPath.Child("items")
+			if isEnumCandidate(items.Type, items.Format) {
+				fn(paramPath, items.Type, &items.Enum)
+			}
+		}
+	}
+
+	if op.Responses == nil {
+		return
+	}
+	responsesPath := field.NewPath("responses")
+	for code, response := range op.Responses.StatusCodeResponses {
+		codePath := responsesPath.Child(strconv.Itoa(code))
+		forEachSchemaEnum(response.Schema, codePath.Child("schema"), fn, 0)
+		for name, header := range response.Headers {
+			if isEnumCandidate(header.Type, header.Format) {
+				fn(codePath.Child("headers", name), header.Type, &header.Enum)
+				response.Headers[name] = header
+			}
+		}
+	}
+}
+
+func forEachSchemaEnum(schema *oapi_spec.Schema, path *field.Path, fn func(path *field.Path, fieldType string, enum *[]interface{}), depth int) {
+	if schema == nil || depth >= maxSchemaToRefDepth {
+		return
+	}
+	if len(schema.Type) == 1 && isEnumCandidate(schema.Type[0], schema.Format) {
+		fn(path, schema.Type[0], &schema.Enum)
+	}
+	if schema.Items != nil {
+		forEachSchemaEnum(schema.Items.Schema, path.Child("items"), fn, depth+1)
+	}
+	for name := range schema.Properties {
+		property := schema.Properties[name]
+		forEachSchemaEnum(&property, path.Child("properties", name), fn, depth+1)
+		schema.Properties[name] = property
+	}
+}
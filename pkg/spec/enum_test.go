@@ -0,0 +1,119 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func createEnumTelemetry(i int, status string) *Telemetry {
+	telemetry := createTelemetry(fmt.Sprintf("req-%v", i), http.MethodGet, "/api?sort=asc", "host", "200", "",
+		fmt.Sprintf(`{"status":"%v","count":%v,"id":"id-%v","created":"2021-08-23T06:52:48Z","tags":["a"]}`, status, i%2, i))
+	telemetry.Request.Common.Headers = append(telemetry.Request.Common.Headers, &Header{Key: "X-Api-Key", Value: "secret"})
+	return telemetry
+}
+
+// generateLearningOperation returns the learned GET /api operation and its 200 response schema properties.
+func generateLearningOperation(t *testing.T, s *Spec) (*oapi_spec.Operation, oapi_spec.SchemaProperties) {
+	t.Helper()
+	oasJSON, err := s.GenerateLearningOAS()
+	assert.NilError(t, err)
+	swagger := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, swagger))
+
+	op := swagger.Paths.Paths["/api"].Get
+	return op, resolveDefinition(swagger, op.Responses.StatusCodeResponses[200].Schema).Properties
+}
+
+func TestSpec_GenerateLearningOAS_Enums(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.EnumMaxValues = 3
+	config.EnumMinSamples = 2
+	s := CreateDefaultSpec("host", "80", config)
+
+	assert.NilError(t, s.LearnTelemetry(createEnumTelemetry(0, "active")))
+	// not enough samples
+	_, properties := generateLearningOperation(t, s)
+	assert.Assert(t, properties["status"].Enum == nil)
+
+	for i, status := range []string{"inactive", "active", "inactive"} {
+		assert.NilError(t, s.LearnTelemetry(createEnumTelemetry(i+1, status)))
+	}
+	op, properties := generateLearningOperation(t, s)
+	assert.DeepEqual(t, properties["status"].Enum, []interface{}{"active", "inactive"})
+	assert.DeepEqual(t, properties["count"].Enum, []interface{}{float64(0), float64(1)})
+	assert.DeepEqual(t, properties["tags"].Items.Schema.Enum, []interface{}{"a"})
+	// too many distinct values
+	assert.Assert(t, properties["id"].Enum == nil)
+	// formatted strings are not enums
+	assert.Assert(t, properties["created"].Enum == nil)
+	params := make(map[string]oapi_spec.Parameter)
+	for _, param := range op.Parameters {
+		params[param.Name] = param
+	}
+	assert.DeepEqual(t, params["sort"].Enum, []interface{}{"asc"})
+	// sensitive values are never published
+	assert.Assert(t, params["x-api-key"].Enum == nil)
+
+	// an enum is dropped for good once it exceeds the max values
+	for i, status := range []string{"pending", "deleted", "active"} {
+		assert.NilError(t, s.LearnTelemetry(createEnumTelemetry(i+4, status)))
+	}
+	_, properties = generateLearningOperation(t, s)
+	assert.Assert(t, properties["status"].Enum == nil)
+	assert.DeepEqual(t, properties["count"].Enum, []interface{}{float64(0), float64(1)})
+}
+
+func TestSpec_LearnTelemetry_EnumsDisabled(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, s.LearnTelemetry(createEnumTelemetry(i, "active")))
+	}
+
+	assert.Assert(t, s.LearningStats.Operations["/api"][http.MethodGet].FieldValues == nil)
+	_, properties := generateLearningOperation(t, s)
+	assert.Assert(t, properties["status"].Enum == nil)
+}
+
+func TestFieldValueStats(t *testing.T) {
+	stats := &FieldValueStats{}
+	stats.addValues([]string{"b", "a"}, 3)
+	stats.addValues([]string{"a"}, 3)
+	assert.DeepEqual(t, stats, &FieldValueStats{SampleCount: 2, Values: []string{"a", "b"}})
+
+	other := &FieldValueStats{SampleCount: 1, Values: []string{"c"}}
+	other.merge(stats)
+	assert.DeepEqual(t, other, &FieldValueStats{SampleCount: 3, Values: []string{"a", "b", "c"}})
+
+	stats.addValues([]string{"c", "d"}, 3)
+	assert.DeepEqual(t, stats, &FieldValueStats{SampleCount: 3, Exceeded: true})
+	stats.addValues([]string{"a"}, 3)
+	assert.DeepEqual(t, stats, &FieldValueStats{SampleCount: 4, Exceeded: true})
+	other.merge(stats)
+	assert.DeepEqual(t, other, &FieldValueStats{SampleCount: 7, Exceeded: true})
+}
+
+func Test_createEnum(t *testing.T) {
+	assert.DeepEqual(t, createEnum(schemaTypeInteger, []string{"10", "2"}), []interface{}{int64(2), int64(10)})
+	assert.DeepEqual(t, createEnum(schemaTypeString, []string{"10", "2"}), []interface{}{"10", "2"})
+	assert.Assert(t, createEnum(schemaTypeInteger, []string{"1", "a"}) == nil)
+}
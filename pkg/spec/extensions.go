@@ -0,0 +1,140 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+)
+
+const vendorExtensionPrefix = "x-"
+
+// OperationExtensionInjector returns vendor extensions (x-*) to add to the operation of method in the approved path.
+// stats aggregates the learned stats of all the paths matching the approved path, it is nil if nothing was learned for it.
+type OperationExtensionInjector func(path, method string, operation *oapi_spec.Operation, stats *OperationStats) map[string]interface{}
+
+// WithOperationExtensions adds the extensions returned by the injectors to each generated operation.
+// Injectors are called in order, so a later injector overrides the extensions of an earlier one.
+func WithOperationExtensions(injectors ...OperationExtensionInjector) GenerateOASOption {
+	return func(o *generateOASOptions) {
+		o.operationExtensionInjectors = append(o.operationExtensionInjectors, injectors...)
+	}
+}
+
+// injectOperationExtensions adds the injectors extensions to the operations of pathItems,
+// operationStats maps the paths of pathItems into method and its aggregated stats.
+func injectOperationExtensions(pathItems map[string]*oapi_spec.PathItem, operationStats map[string]map[string]*OperationStats,
+	injectors []OperationExtensionInjector) {
+	for path, pathItem := range pathItems {
+		for _, method := range supportedMethods {
+			operation := GetOperationFromPathItem(pathItem, method)
+			if operation == nil {
+				continue
+			}
+			for _, injector := range injectors {
+				for key, value := range injector(path, method, operation, operationStats[path][method]) {
+					if !strings.HasPrefix(strings.ToLower(key), vendorExtensionPrefix) {
+						log.Warnf("Ignoring operation extension %q of %v %v, extensions must start with %q", key, method, path, vendorExtensionPrefix)
+						continue
+					}
+					operation.AddExtension(key, value)
+				}
+			}
+		}
+	}
+}
+
+// getApprovedOperationStats aggregates the learned operation stats by the approved path they match.
+func (s *Spec) getApprovedOperationStats() map[string]map[string]*OperationStats {
+	ret := make(map[string]map[string]*OperationStats)
+	if s.LearningStats == nil {
+		return ret
+	}
+
+	for path, methods := range s.LearningStats.Operations {
+		approvedPath, _, found := s.ApprovedPathTrie.GetPathAndValue(path)
+		if !found {
+			continue
+		}
+		for method, opStats := range methods {
+			if _, ok := ret[approvedPath]; !ok {
+				ret[approvedPath] = make(map[string]*OperationStats)
+			}
+			aggregated, ok := ret[approvedPath][method]
+			if !ok {
+				aggregated = &OperationStats{}
+				ret[approvedPath][method] = aggregated
+			}
+			aggregated.merge(opStats)
+		}
+	}
+
+	return ret
+}
+
+func (o *OperationStats) merge(other *OperationStats) {
+	o.HitCount += other.HitCount
+	o.PartialLearnings += other.PartialLearnings
+	if o.FirstSeen.IsZero() || (!other.FirstSeen.IsZero() && other.FirstSeen.Before(o.FirstSeen)) {
+		o.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(o.LastSeen) {
+		o.LastSeen = other.LastSeen
+	}
+	for source, count := range other.Sources {
+		if o.Sources == nil {
+			o.Sources = make(map[SourceLabel]int)
+		}
+		o.Sources[source] += count
+	}
+	for key, values := range other.Metadata {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]map[string]int)
+		}
+		if _, ok := o.Metadata[key]; !ok {
+			o.Metadata[key] = make(map[string]int)
+		}
+		for value, count := range values {
+			o.Metadata[key][value] += count
+		}
+	}
+	for fieldPath, fieldStats := range other.FieldValues {
+		if o.FieldValues == nil {
+			o.FieldValues = make(map[string]*FieldValueStats)
+		}
+		if _, ok := o.FieldValues[fieldPath]; !ok {
+			o.FieldValues[fieldPath] = &FieldValueStats{}
+		}
+		o.FieldValues[fieldPath].merge(fieldStats)
+	}
+	for fieldPath, presence := range other.PropertyPresence {
+		if o.PropertyPresence == nil {
+			o.PropertyPresence = make(map[string]*PropertyPresenceStats)
+		}
+		if _, ok := o.PropertyPresence[fieldPath]; !ok {
+			o.PropertyPresence[fieldPath] = &PropertyPresenceStats{}
+		}
+		o.PropertyPresence[fieldPath].merge(presence)
+	}
+	if other.Latency != nil {
+		if o.Latency == nil {
+			o.Latency = &LatencyStats{}
+		}
+		o.Latency.merge(other.Latency)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpec_GenerateOASJson_WithOperationExtensions(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for _, path := range []string{"/api/1", "/api/2", "/api/2"} {
+		telemetry := createTelemetry("req-id", http.MethodGet, path, "host", "200", Data.ReqBody, Data.RespBody)
+		telemetry.Source = SourceExternal
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	s.ApprovedSpec.PathItems["/api/{param1}"] = &NewTestPathItem().
+		WithPathParams("param1", schemaTypeInteger, "").
+		WithOperation(http.MethodGet, NewOperation(t, Data).Op).
+		WithOperation(http.MethodPost, NewOperation(t, Data).Op).PathItem
+	s.ApprovedPathTrie.Insert("/api/{param1}", "1")
+
+	ownerInjector := func(path, method string, operation *oapi_spec.Operation, stats *OperationStats) map[string]interface{} {
+		return map[string]interface{}{
+			"x-owner":   "team-a",
+			"x-slo":     "https://slo/" + method,
+			"not-valid": "ignored",
+		}
+	}
+	statsInjector := func(path, method string, operation *oapi_spec.Operation, stats *OperationStats) map[string]interface{} {
+		if stats == nil {
+			return map[string]interface{}{"x-owner": "unknown"}
+		}
+		return map[string]interface{}{
+			"x-hits":     stats.HitCount,
+			"x-external": stats.Sources[SourceExternal] > 0,
+		}
+	}
+
+	oasJSON, err := s.GenerateOASJson(WithOperationExtensions(ownerInjector, statsInjector))
+	assert.NilError(t, err)
+	generated := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, generated))
+
+	pathItem := generated.Paths.Paths["/api/{param1}"]
+	assert.DeepEqual(t, pathItem.Get.Extensions, oapi_spec.Extensions{
+		"x-owner":    "team-a",
+		"x-slo":      "https://slo/GET",
+		"x-hits":     float64(3),
+		"x-external": true,
+	})
+	// nothing was learned for POST, the later injector overrides x-owner
+	assert.DeepEqual(t, pathItem.Post.Extensions, oapi_spec.Extensions{
+		"x-owner": "unknown",
+		"x-slo":   "https://slo/POST",
+	})
+
+	// the approved spec is not modified
+	assert.Assert(t, s.ApprovedSpec.PathItems["/api/{param1}"].Get.Extensions == nil)
+}
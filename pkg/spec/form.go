@@ -17,6 +17,9 @@ package spec
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"mime/multipart"
 	"net/url"
 	"strings"
@@ -30,6 +33,9 @@ const (
 	defaultMaxMemory = 32 << 20 // 32 MB
 )
 
+// formDataContentTypeExtensionName is the Content-Type of the multipart parts of a formData param.
+const formDataContentTypeExtensionName = "x-content-type"
+
 func addApplicationFormParams(operation *spec.Operation, sd spec.SecurityDefinitions, body string) (*spec.Operation, spec.SecurityDefinitions) {
 	values, err := url.ParseQuery(body)
 	if err != nil {
@@ -49,29 +55,90 @@ func addApplicationFormParams(operation *spec.Operation, sd spec.SecurityDefinit
 	return operation, sd
 }
 
+type multipartFormField struct {
+	name        string
+	values      []string
+	isFile      bool
+	contentType string
+}
+
+// addMultipartFormDataParams adds a formData param per part name: file parts are file params, other parts are typed
+// by their values. The Content-Type of a part (other than the text/plain default of value parts) is kept in the
+// x-content-type extension of its param.
 func addMultipartFormDataParams(operation *spec.Operation, body string, mediaTypeParams map[string]string) (*spec.Operation, error) {
 	boundary, ok := mediaTypeParams["boundary"]
 	if !ok {
 		return operation, fmt.Errorf("no multipart boundary param in Content-Type")
 	}
 
-	form, err := multipart.NewReader(strings.NewReader(body), boundary).ReadForm(defaultMaxMemory)
+	fields, err := readMultipartFormFields(body, boundary)
 	if err != nil {
 		return operation, fmt.Errorf("failed to read form: %w", err)
 	}
 
-	// add file formData
-	for key := range form.File {
-		operation.AddParam(spec.FileParam(key))
+	for _, f := range fields {
+		var param *spec.Parameter
+		if f.isFile {
+			// add file formData
+			param = spec.FileParam(f.name)
+		} else {
+			// add values formData
+			// when using populateParam strings with comma it will be translated to array and it might be wrong
+			// also when the array collection format is ssv the spaces will be URL encoded
+			// for now we will ignore collection
+			param = populateParam(spec.FormDataParam(f.name), f.values, false)
+		}
+		if f.contentType != "" {
+			param.AddExtension(formDataContentTypeExtensionName, f.contentType)
+		}
+		operation.AddParam(param)
 	}
 
-	// add values formData
-	for key, values := range form.Value {
-		// when using populateParam strings with comma it will be translated to array and it might be wrong
-		// also when the array collection format is ssv the spaces will be URL encoded
-		// for now we will ignore collection
-		operation.AddParam(populateParam(spec.FormDataParam(key), values, false))
+	return operation, nil
+}
+
+// readMultipartFormFields returns the fields of a multipart body by part name, in the order they first appear.
+func readMultipartFormFields(body string, boundary string) ([]*multipartFormField, error) {
+	var fields []*multipartFormField
+	nameToField := make(map[string]*multipartFormField)
+
+	reader := multipart.NewReader(strings.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+
+		f, ok := nameToField[name]
+		if !ok {
+			f = &multipartFormField{name: name}
+			nameToField[name] = f
+			fields = append(fields, f)
+		}
+		contentType := part.Header.Get("Content-Type")
+		if part.FileName() != "" {
+			f.isFile = true
+		} else {
+			value, err := ioutil.ReadAll(io.LimitReader(part, defaultMaxMemory))
+			if err != nil {
+				return nil, err
+			}
+			f.values = append(f.values, string(value))
+			if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == mediaTypeTextPlain {
+				contentType = ""
+			}
+		}
+		if f.contentType == "" {
+			f.contentType = contentType
+		}
 	}
 
-	return operation, nil
+	return fields, nil
 }
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/go-openapi/spec"
@@ -135,6 +136,32 @@ var formDataBody = "--cdce6441022a3dcf\r\n" +
 	"false\r\n" +
 	"--cdce6441022a3dcf--\r\n"
 
+var formDataBodyContentTypes = "--cdce6441022a3dcf\r\n" +
+	"Content-Disposition: form-data; name=\"image\"; filename=\"a.png\"\r\n" +
+	"Content-Type: image/png\r\n\r\n" +
+	"\x89PNG\r\n" +
+	"--cdce6441022a3dcf\r\n" +
+	"Content-Disposition: form-data; name=\"image\"; filename=\"b.jpg\"\r\n" +
+	"Content-Type: image/jpeg\r\n\r\n" +
+	"\xff\xd8\r\n" +
+	"--cdce6441022a3dcf\r\n" +
+	"Content-Disposition: form-data; name=\"attachment\"; filename=\"a.bin\"\r\n\r\n" +
+	"bin\r\n" +
+	"--cdce6441022a3dcf\r\n" +
+	"Content-Disposition: form-data; name=\"metadata\"\r\n" +
+	"Content-Type: application/json\r\n\r\n" +
+	"{\"name\":\"a\"}\r\n" +
+	"--cdce6441022a3dcf\r\n" +
+	"Content-Disposition: form-data; name=\"comment\"\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+	"nice\r\n" +
+	"--cdce6441022a3dcf--\r\n"
+
+func withFormDataContentType(param *spec.Parameter, contentType string) *spec.Parameter {
+	param.AddExtension(formDataContentTypeExtensionName, contentType)
+	return param
+}
+
 func Test_addMultipartFormDataParams(t *testing.T) {
 	type args struct {
 		operation *spec.Operation
@@ -155,7 +182,7 @@ func Test_addMultipartFormDataParams(t *testing.T) {
 				params:    map[string]string{"boundary": "cdce6441022a3dcf"},
 			},
 			want: spec.NewOperation("").
-				AddParam(spec.FileParam("upfile")).
+				AddParam(withFormDataContentType(spec.FileParam("upfile"), "text/plain")).
 				AddParam(spec.FormDataParam("integer").Typed(schemaTypeInteger, "")).
 				AddParam(spec.FormDataParam("boolean").Typed(schemaTypeBoolean, "")).
 				AddParam(spec.FormDataParam("string").Typed(schemaTypeString, "")).
@@ -174,6 +201,30 @@ func Test_addMultipartFormDataParams(t *testing.T) {
 				AddParam(spec.FormDataParam("integer").CollectionOf(spec.NewItems().Typed(schemaTypeInteger, ""), collectionFormatMulti)),
 			wantErr: false,
 		},
+		{
+			name: "part content types",
+			args: args{
+				operation: spec.NewOperation(""),
+				body:      formDataBodyContentTypes,
+				params:    map[string]string{"boundary": "cdce6441022a3dcf"},
+			},
+			want: spec.NewOperation("").
+				AddParam(withFormDataContentType(spec.FileParam("image"), "image/png")).
+				AddParam(spec.FileParam("attachment")).
+				AddParam(withFormDataContentType(spec.FormDataParam("metadata").Typed(schemaTypeString, ""), "application/json")).
+				AddParam(spec.FormDataParam("comment").Typed(schemaTypeString, "")),
+			wantErr: false,
+		},
+		{
+			name: "invalid body",
+			args: args{
+				operation: spec.NewOperation(""),
+				body:      "--cdce6441022a3dcf\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\nno closing boundary",
+				params:    map[string]string{"boundary": "cdce6441022a3dcf"},
+			},
+			want:    spec.NewOperation(""),
+			wantErr: true,
+		},
 		{
 			name: "missing boundary param",
 			args: args{
@@ -208,3 +259,35 @@ func Test_addMultipartFormDataParams(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSpecOperation_MultipartFormData(t *testing.T) {
+	generateOperation := func(boundary string) *spec.Operation {
+		body := strings.ReplaceAll(formDataBodyContentTypes, "cdce6441022a3dcf", boundary)
+		op, err := CreateTestNewOperationGenerator().GenerateSpecOperation(&HTTPInteractionData{
+			ReqBody:    body,
+			ReqHeaders: map[string]string{contentTypeHeaderName: mediaTypeMultipartFormData + "; boundary=" + boundary},
+			statusCode: 200,
+		}, nil)
+		if err != nil {
+			t.Fatalf("GenerateSpecOperation() error = %v", err)
+		}
+		return op
+	}
+
+	op := generateOperation("cdce6441022a3dcf")
+	// the boundary is not kept
+	if !reflect.DeepEqual(op.Consumes, []string{mediaTypeMultipartFormData}) {
+		t.Errorf("GenerateSpecOperation() consumes = %v", op.Consumes)
+	}
+
+	merged, conflicts := mergeOperation(op, generateOperation("a1b2c3"))
+	if len(conflicts) > 0 {
+		t.Errorf("mergeOperation() conflicts = %v", conflicts)
+	}
+	if !reflect.DeepEqual(merged.Consumes, []string{mediaTypeMultipartFormData}) {
+		t.Errorf("mergeOperation() consumes = %v", merged.Consumes)
+	}
+	if len(merged.Parameters) != 4 {
+		t.Errorf("mergeOperation() parameters = %v", marshal(merged.Parameters))
+	}
+}
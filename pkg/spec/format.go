@@ -16,11 +16,20 @@
 package spec
 
 import (
+	"encoding/base64"
+	"net/url"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/xeipuuv/gojsonschema"
 )
 
+// base64 strings shorter than this are not detected as byte, too many plain strings (e.g. "test") are valid base64
+const minByteFormatLength = 16
+
+// formats are checked in order, the first matching format is set on the schema.
+// formats with a formatCheckers entry are checked with it, the others with the gojsonschema format checkers.
 var formats = []string{
 	"date",
 	"time",
@@ -30,14 +39,24 @@ var formats = []string{
 	"ipv6",
 	"uuid",
 	"json-pointer",
+	formatJWT,
+	formatURI,
+	formatHostname,
+	formatByte,
 	// "relative-json-pointer", // matched with "1.147.1"
-	// "hostname",
 	// "regex",
-	// "uri",           // can be also iri
 	// "uri-reference", // can be also iri-reference
 	// "uri-template",
 }
 
+// formatCheckers are stricter than the gojsonschema ones, which match most plain strings for these formats.
+var formatCheckers = map[string]func(string) bool{
+	formatJWT:      isJWTFormat,
+	formatURI:      isURIFormat,
+	formatHostname: isHostnameFormat,
+	formatByte:     isByteFormat,
+}
+
 func getStringFormat(value interface{}) string {
 	str, ok := value.(string)
 	if !ok || str == "" {
@@ -45,6 +64,12 @@ func getStringFormat(value interface{}) string {
 	}
 
 	for _, format := range formats {
+		if checker, ok := formatCheckers[format]; ok {
+			if checker(str) {
+				return format
+			}
+			continue
+		}
 		if gojsonschema.FormatCheckers.IsFormat(format, value) {
 			return format
 		}
@@ -53,6 +78,96 @@ func getStringFormat(value interface{}) string {
 	return ""
 }
 
+// isURIFormat checks if input is an absolute URI with a host, e.g. https://example.com/a.
+func isURIFormat(input string) bool {
+	if strings.ContainsAny(input, " \t\n") {
+		return false
+	}
+	u, err := url.Parse(input)
+	if err != nil {
+		return false
+	}
+	return u.Scheme != "" && u.Host != ""
+}
+
+// the top level domains of hostnames that are more likely file names, e.g. report.pdf or node.js
+var fileExtensionTopLevelDomains = map[string]bool{
+	"bin": true, "css": true, "csv": true, "doc": true, "exe": true, "gif": true, "gz": true, "htm": true,
+	"html": true, "jpeg": true, "jpg": true, "js": true, "json": true, "log": true, "md": true, "mp3": true,
+	"mp4": true, "pdf": true, "php": true, "png": true, "py": true, "sh": true, "svg": true, "tar": true,
+	"txt": true, "xls": true, "xlsx": true, "xml": true, "yaml": true, "yml": true, "zip": true,
+}
+
+// the top level domains that start reverse domain names (e.g. com.example.app), which are not hostnames
+var reverseDomainTopLevelDomains = map[string]bool{
+	"com": true, "io": true, "net": true, "org": true,
+}
+
+// isHostnameFormat checks if input is a fully qualified lowercase hostname (RFC 1123) of at least three labels with
+// an alphabetic top level domain, e.g. api.example.com. Fewer labels are not detected as hostnames, as any word is
+// a valid label and a bare word.ext is more likely a file or a person name (e.g. report.pdf, john.smith), as well as
+// top level domains that are file extensions and reverse domain names (e.g. com.example.app).
+func isHostnameFormat(input string) bool {
+	if len(input) > 253 {
+		return false
+	}
+	labels := strings.Split(strings.TrimSuffix(input, "."), ".")
+	if len(labels) < 3 || reverseDomainTopLevelDomains[labels[0]] {
+		return false
+	}
+	for _, label := range labels {
+		if !isHostnameLabel(label) {
+			return false
+		}
+	}
+	tld := labels[len(labels)-1]
+	if len(tld) < 2 || fileExtensionTopLevelDomains[tld] {
+		return false
+	}
+	for _, r := range tld {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHostnameLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// isByteFormat checks if input is standard (padded) base64 encoded data. To avoid matching plain words, input must be
+// at least minByteFormatLength long and mix upper case, lower case and digit or symbol characters.
+func isByteFormat(input string) bool {
+	if len(input) < minByteFormatLength || len(input)%4 != 0 {
+		return false
+	}
+	if _, err := base64.StdEncoding.DecodeString(input); err != nil {
+		return false
+	}
+
+	var hasUpper, hasLower, hasOther bool
+	for _, r := range strings.TrimRight(input, "=") {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		default:
+			hasOther = true
+		}
+	}
+	return hasUpper && hasLower && hasOther
+}
+
 // isDateFormat checks if input is a correctly formatted date with spaces (excluding RFC3339 = "2006-01-02T15:04:05Z07:00")
 // This is useful to identify date string instead of an collection.
 func isDateFormat(input interface{}) bool {
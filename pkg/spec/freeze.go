@@ -0,0 +1,141 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/utils/field"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// FreezePath stops the schema learning of an operation, e.g. once it was verified by a human before the spec is
+// approved. Telemetries of a frozen operation are still counted in the stats, and the fields that drifted from its
+// schema (conflicting types, dropped formats and new fields) are recorded as schema outliers, see Spec.GetSchemaOutliers.
+// path can be a learned path (/users/1) or a parameterized path (/users/{param1}) matching learned paths.
+func (s *Spec) FreezePath(path, method string) error {
+	method, err := NormalizeMethod(method)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.hasLearnedOperation(path, method) {
+		return fmt.Errorf("operation %v %v was not learned", method, path)
+	}
+	if s.FrozenOperations == nil {
+		s.FrozenOperations = make(map[string]map[string]bool)
+	}
+	if _, ok := s.FrozenOperations[path]; !ok {
+		s.FrozenOperations[path] = make(map[string]bool)
+	}
+	s.FrozenOperations[path][method] = true
+
+	return nil
+}
+
+// UnfreezePath removes an operation frozen by FreezePath, so its schema is learned again.
+func (s *Spec) UnfreezePath(path, method string) {
+	method = strings.ToUpper(strings.TrimSpace(method))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.FrozenOperations[path], method)
+	if len(s.FrozenOperations[path]) == 0 {
+		delete(s.FrozenOperations, path)
+	}
+}
+
+// GetFrozenOperations returns the sorted methods of each frozen path.
+func (s *Spec) GetFrozenOperations() map[string][]string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := make(map[string][]string)
+	for path, methods := range s.FrozenOperations {
+		for method := range methods {
+			ret[path] = append(ret[path], method)
+		}
+		sort.Strings(ret[path])
+	}
+
+	return ret
+}
+
+func (s *Spec) isOperationFrozen(path, method string) bool {
+	return hasOperation(s.FrozenOperations, path, method)
+}
+
+// hasLearnedOperation returns true if the method of path, or of a learned path matching the parameterized path, was learned.
+func (s *Spec) hasLearnedOperation(path, method string) bool {
+	for learnedPath, pathItem := range s.LearningSpec.PathItems {
+		if learnedPath != path {
+			if _, ok := utils.GetPathParamValues(path, learnedPath); !ok {
+				continue
+			}
+		}
+		if GetOperationFromPathItem(pathItem, method) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFrozenOperation returns the frozen operation as is, the fields of telemetryOp that drifted from it are
+// returned and recorded as outliers.
+func (s *Spec) mergeFrozenOperation(path, method string, frozenOp, telemetryOp *oapi_spec.Operation, seen time.Time) (*oapi_spec.Operation, []conflict) {
+	// merging may update the merged operation
+	clonedOp, err := CloneOperation(frozenOp)
+	if err != nil {
+		log.Errorf("Failed to clone frozen operation, drift is not recorded: %v", err)
+		return frozenOp, nil
+	}
+	mergedOp, conflicts := mergeOperation(clonedOp, telemetryOp)
+	outliers := append(getWideningOutliers(frozenOp, mergedOp, conflicts), getNewFieldOutliers(frozenOp, mergedOp)...)
+	for _, outlier := range outliers {
+		s.recordSchemaOutlier(path, method, outlier, seen)
+	}
+
+	return frozenOp, outliers
+}
+
+// getNewFieldOutliers returns the fields of mergedOp that op does not have, sorted by field path.
+func getNewFieldOutliers(op, mergedOp *oapi_spec.Operation) []conflict {
+	fieldTypes := getOperationFieldTypes(op)
+	var outliers []conflict
+	forEachOperationField(mergedOp, func(path *field.Path, _, _ string) {
+		if _, ok := fieldTypes[path.String()]; !ok {
+			outliers = append(outliers, conflict{
+				path: path,
+				msg:  fmt.Sprintf("%s: new field", path),
+			})
+		}
+	})
+	sort.Slice(outliers, func(i, j int) bool {
+		return outliers[i].path.String() < outliers[j].path.String()
+	})
+
+	return outliers
+}
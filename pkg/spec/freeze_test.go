@@ -0,0 +1,59 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_FreezePath(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learn := func(path, respBody string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", "", respBody)))
+	}
+	learn("/users/1", `{"id": 1}`)
+	learn("/users/2", `{"id": 2}`)
+
+	assert.Assert(t, s.FreezePath("/users/1", "FOO") != nil)
+	assert.ErrorContains(t, s.FreezePath("/users/1", http.MethodPost), "was not learned")
+	assert.NilError(t, s.FreezePath("/users/{param1}", "get"))
+	assert.DeepEqual(t, s.GetFrozenOperations(), map[string][]string{"/users/{param1}": {http.MethodGet}})
+	frozenOp, err := CloneOperation(s.LearningSpec.GetPathItem("/users/1").Get)
+	assert.NilError(t, err)
+
+	learn("/users/1", `{"id": "one", "name": "alice"}`)
+
+	// the schema is not learned, the telemetry is counted and its drift is recorded
+	assertEqualOperationJSON(t, s.LearningSpec.GetPathItem("/users/1").Get, frozenOp)
+	assert.Equal(t, s.LearningStats.Operations["/users/1"][http.MethodGet].HitCount, 2)
+	outliers := s.GetSchemaOutliers()
+	assert.Equal(t, len(outliers), 2)
+	assert.Equal(t, outliers[0].Field, "responses.200.schema.properties.id")
+	assert.Equal(t, outliers[1].Field, "responses.200.schema.properties.name")
+	assert.Equal(t, outliers[1].Message, "responses.200.schema.properties.name: new field")
+
+	// telemetries without drift are not outliers
+	learn("/users/2", `{"id": 3}`)
+	assert.Equal(t, len(s.GetSchemaOutliers()), 2)
+
+	s.UnfreezePath("/users/{param1}", http.MethodGet)
+	assert.DeepEqual(t, s.GetFrozenOperations(), map[string][]string{})
+	learn("/users/1", `{"id": 1, "name": "alice"}`)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/users/1").Get.Responses.StatusCodeResponses[200].Schema.Properties["name"].Type != nil)
+}
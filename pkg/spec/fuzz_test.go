@@ -0,0 +1,64 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+// The fuzz targets check that no input makes learning panic, run them with e.g.
+// go test ./pkg/spec -run '^$' -fuzz FuzzSpec_LearnTelemetry
+
+func FuzzDecodeTelemetry(f *testing.F) {
+	f.Add([]byte(`{"request":{"method":"GET","path":"/api","host":"host"},"response":{"statusCode":"200"}}`))
+	f.Add([]byte(`{"schemaVersion":"1","request":{"common":{"body":"e30="}},"response":{}}`))
+	f.Add([]byte(`{"scnt_request":{"method":"GET","path":"/api"},"scnt_response":{"status_code":"200"}}`))
+	f.Add([]byte(`{"request":null}`))
+	f.Add([]byte(`[]`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		telemetry, err := DecodeTelemetry(data)
+		if err != nil {
+			return
+		}
+		s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+		if err := s.LearnTelemetry(telemetry); errors.Is(err, _errors.ErrRecoveredPanic) {
+			t.Fatalf("LearnTelemetry() panicked: %v", err)
+		}
+	})
+}
+
+func FuzzSpec_LearnTelemetry(f *testing.F) {
+	f.Add(http.MethodPost, "/api/1?id=2", "200", mediaTypeApplicationJSON, `{"a":[1,{"b":null}]}`, mediaTypeApplicationJSON, `[[]]`)
+	f.Add(http.MethodPut, "/api/users/abc", "201", mediaTypeApplicationForm, "a=1&a=2&b", "text/plain", "ok")
+	f.Add(http.MethodPatch, "/", "404", mediaTypeMultipartFormData+"; boundary=x", "--x\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n--x--", mediaTypeApplicationJSON, "{")
+	f.Add(http.MethodGet, "/a//b?%zz", "abc", "application/json; charset", "", "", "")
+	f.Fuzz(func(t *testing.T, method, path, statusCode, reqContentType, reqBody, respContentType, respBody string) {
+		telemetry := createTelemetry("req-id", method, path, "host", statusCode, reqBody, respBody)
+		telemetry.Request.Common.Headers[0].Value = reqContentType
+		telemetry.Response.Common.Headers[0].Value = respContentType
+
+		s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+		for i := 0; i < 2; i++ {
+			if err := s.LearnTelemetry(telemetry); errors.Is(err, _errors.ErrRecoveredPanic) {
+				t.Fatalf("LearnTelemetry() panicked: %v", err)
+			}
+		}
+	})
+}
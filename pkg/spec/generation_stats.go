@@ -0,0 +1,103 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sync"
+	"time"
+)
+
+// GenerationDurationBuckets are the upper bounds of the GenerationStats histogram buckets.
+var GenerationDurationBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 5 * time.Second, 10 * time.Second,
+}
+
+// GenerationStats is a histogram of the durations of the OAS generated by GenerateOASJson and GenerateLearningOAS.
+type GenerationStats struct {
+	Count int
+	Sum   time.Duration
+	// BucketCounts are the cumulative counts of the generations by GenerationDurationBuckets upper bound
+	BucketCounts []int
+}
+
+// generationStats is updated by concurrent generations, so it has its own lock.
+type generationStats struct {
+	lock  sync.Mutex
+	stats GenerationStats
+}
+
+func (g *generationStats) record(duration time.Duration) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.stats.Observe(duration)
+}
+
+// Observe adds a duration to the histogram.
+func (g *GenerationStats) Observe(duration time.Duration) {
+	if g.BucketCounts == nil {
+		g.BucketCounts = make([]int, len(GenerationDurationBuckets))
+	}
+	g.Count++
+	g.Sum += duration
+	for i, bound := range GenerationDurationBuckets {
+		if duration <= bound {
+			g.BucketCounts[i]++
+		}
+	}
+}
+
+func (s *Spec) recordGenerationDuration(start time.Time) {
+	s.generationStats.record(time.Since(start))
+}
+
+// GetGenerationStats returns a copy of the generation durations histogram of the spec.
+func (s *Spec) GetGenerationStats() GenerationStats {
+	s.generationStats.lock.Lock()
+	defer s.generationStats.lock.Unlock()
+
+	ret := s.generationStats.stats
+	ret.BucketCounts = make([]int, len(GenerationDurationBuckets))
+	copy(ret.BucketCounts, s.generationStats.stats.BucketCounts)
+	return ret
+}
+
+// Merge adds the generations of other into g.
+func (g *GenerationStats) Merge(other GenerationStats) {
+	if g.BucketCounts == nil {
+		g.BucketCounts = make([]int, len(GenerationDurationBuckets))
+	}
+	g.Count += other.Count
+	g.Sum += other.Sum
+	for i := range other.BucketCounts {
+		g.BucketCounts[i] += other.BucketCounts[i]
+	}
+}
+
+// GetPathCounts returns the amount of learning (not approved) and approved paths of the spec.
+func (s *Spec) GetPathCounts() (learningPaths, approvedPaths int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.LearningSpec != nil {
+		learningPaths = len(s.LearningSpec.PathItems)
+	}
+	if s.ApprovedSpec != nil {
+		approvedPaths = len(s.ApprovedSpec.PathItems)
+	}
+	return learningPaths, approvedPaths
+}
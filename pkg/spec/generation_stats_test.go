@@ -0,0 +1,54 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func Test_generationStats_record(t *testing.T) {
+	g := &generationStats{}
+	g.record(2 * time.Millisecond)
+	g.record(time.Minute)
+
+	assert.Equal(t, g.stats.Count, 2)
+	assert.Equal(t, g.stats.Sum, time.Minute+2*time.Millisecond)
+	assert.DeepEqual(t, g.stats.BucketCounts, []int{0, 1, 1, 1, 1, 1, 1, 1, 1})
+
+	merged := GenerationStats{}
+	merged.Merge(g.stats)
+	merged.Merge(g.stats)
+	assert.Equal(t, merged.Count, 4)
+	assert.DeepEqual(t, merged.BucketCounts, []int{0, 2, 2, 2, 2, 2, 2, 2, 2})
+}
+
+func TestSpec_GetGenerationStats(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "GET", "/api/users", "host", "200", "", "")))
+
+	_, err := s.GenerateLearningOAS()
+	assert.NilError(t, err)
+	stats := s.GetGenerationStats()
+	assert.Equal(t, stats.Count, 1)
+	assert.Equal(t, len(stats.BucketCounts), len(GenerationDurationBuckets))
+
+	learningPaths, approvedPaths := s.GetPathCounts()
+	assert.Equal(t, learningPaths, 1)
+	assert.Equal(t, approvedPaths, 0)
+}
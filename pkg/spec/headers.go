@@ -27,6 +27,17 @@ var defaultIgnoredHeaders = []string{
 	authorizationTypeHeaderName,
 }
 
+// response headers with structured values (e.g. Link: <https://example.com?page=2>; rel="next") that must not be
+// learned as collections.
+var stringResponseHeaders = map[string]struct{}{
+	"link":                {},
+	"location":            {},
+	"content-location":    {},
+	"content-disposition": {},
+	"www-authenticate":    {},
+	"etag":                {},
+}
+
 func createHeadersToIgnore(headers []string) map[string]struct{} {
 	ret := make(map[string]struct{})
 
@@ -49,7 +60,9 @@ func (o *OperationGenerator) addResponseHeader(response *spec.Response, headerKe
 
 	responseHeader := spec.ResponseHeader()
 
-	if isDateFormat(headerValue) {
+	if _, ok := stringResponseHeaders[strings.ToLower(headerKey)]; ok {
+		responseHeader.Typed(schemaTypeString, "")
+	} else if isDateFormat(headerValue) {
 		responseHeader.Typed(schemaTypeString, "")
 	} else {
 		items, collectionFormat := getCollection(headerValue, supportedCollectionFormat)
@@ -16,10 +16,12 @@
 package spec
 
 import (
+	"net/http"
 	"reflect"
 	"testing"
 
 	"github.com/go-openapi/spec"
+	"gotest.tools/assert"
 )
 
 func Test_shouldIgnoreHeader(t *testing.T) {
@@ -117,6 +119,36 @@ func Test_addResponseHeader(t *testing.T) {
 			want: spec.NewResponse().
 				AddHeader("date", spec.ResponseHeader().Typed("string", "")),
 		},
+		{
+			name: "pagination link",
+			args: args{
+				response:    spec.NewResponse(),
+				headerKey:   "link",
+				headerValue: `<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=5>; rel="last"`,
+			},
+			want: spec.NewResponse().
+				AddHeader("link", spec.ResponseHeader().Typed("string", "")),
+		},
+		{
+			name: "location",
+			args: args{
+				response:    spec.NewResponse(),
+				headerKey:   "Location",
+				headerValue: "/api/items/1,2",
+			},
+			want: spec.NewResponse().
+				AddHeader("Location", spec.ResponseHeader().Typed("string", "")),
+		},
+		{
+			name: "rate limit",
+			args: args{
+				response:    spec.NewResponse(),
+				headerKey:   "x-ratelimit-remaining",
+				headerValue: "99",
+			},
+			want: spec.NewResponse().
+				AddHeader("x-ratelimit-remaining", spec.ResponseHeader().Typed("integer", "")),
+		},
 		{
 			name: "ignore header",
 			args: args{
@@ -246,3 +278,19 @@ func Test_createHeadersToIgnore(t *testing.T) {
 		})
 	}
 }
+
+func TestSpec_LearnTelemetry_ResponseHeaders(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	ok := createTelemetry("req-id", http.MethodPost, "/api/items", "host", "200", "", "")
+	ok.Response.Common.Headers = append(ok.Response.Common.Headers, &Header{Key: "X-RateLimit-Remaining", Value: "99"})
+	created := createTelemetry("req-id", http.MethodPost, "/api/items", "host", "201", "", "")
+	created.Response.Common.Headers = append(created.Response.Common.Headers, &Header{Key: "Location", Value: "/api/items/1"})
+	assert.NilError(t, s.LearnTelemetry(ok))
+	assert.NilError(t, s.LearnTelemetry(created))
+
+	responses := s.LearningSpec.GetPathItem("/api/items").Post.Responses.StatusCodeResponses
+	assert.Equal(t, len(responses[http.StatusOK].Headers), 1)
+	assert.Equal(t, responses[http.StatusOK].Headers["x-ratelimit-remaining"].Type, schemaTypeInteger)
+	assert.Equal(t, len(responses[http.StatusCreated].Headers), 1)
+	assert.Equal(t, responses[http.StatusCreated].Headers["location"].Type, schemaTypeString)
+}
@@ -0,0 +1,117 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sort"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// IgnoreOperation marks the learned operation as ignored: it is still learned and counted in the stats,
+// but it is never suggested for review and never approved.
+// path can be a learned path (/debug/vars) or a parameterized path (/debug/{param1}) matching learned paths.
+func (s *Spec) IgnoreOperation(path, method string) error {
+	method, err := NormalizeMethod(method)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.IgnoredOperations == nil {
+		s.IgnoredOperations = make(map[string]map[string]bool)
+	}
+	if _, ok := s.IgnoredOperations[path]; !ok {
+		s.IgnoredOperations[path] = make(map[string]bool)
+	}
+	s.IgnoredOperations[path][method] = true
+
+	return nil
+}
+
+// UnignoreOperation removes an operation marked by IgnoreOperation, so it is suggested for review again.
+func (s *Spec) UnignoreOperation(path, method string) {
+	method = strings.ToUpper(strings.TrimSpace(method))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.IgnoredOperations[path], method)
+	if len(s.IgnoredOperations[path]) == 0 {
+		delete(s.IgnoredOperations, path)
+	}
+}
+
+// GetIgnoredOperations returns the sorted methods of each ignored path.
+func (s *Spec) GetIgnoredOperations() map[string][]string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := make(map[string][]string)
+	for path, methods := range s.IgnoredOperations {
+		for method := range methods {
+			ret[path] = append(ret[path], method)
+		}
+		sort.Strings(ret[path])
+	}
+
+	return ret
+}
+
+func (s *Spec) isOperationIgnored(path, method string) bool {
+	return hasOperation(s.IgnoredOperations, path, method)
+}
+
+// hasOperation returns true if operations (path -> method) has the method of path, or of a parameterized path
+// matching path.
+func hasOperation(operations map[string]map[string]bool, path, method string) bool {
+	for operationPath, methods := range operations {
+		if !methods[method] {
+			continue
+		}
+		if operationPath == path {
+			return true
+		}
+		if _, ok := utils.GetPathParamValues(operationPath, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// removeIgnoredOperations returns a copy of the path item of path without its ignored operations,
+// or nil if all its operations are ignored.
+func (s *Spec) removeIgnoredOperations(path string, pathItem *oapi_spec.PathItem) *oapi_spec.PathItem {
+	if len(s.IgnoredOperations) == 0 {
+		return pathItem
+	}
+
+	for _, method := range supportedMethods {
+		if GetOperationFromPathItem(pathItem, method) != nil && s.isOperationIgnored(path, method) {
+			pathItem = CopyPathItemWithNewOperation(pathItem, method, nil)
+		}
+	}
+	if isEmptyPathItem(pathItem) {
+		return nil
+	}
+
+	return pathItem
+}
@@ -0,0 +1,106 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_IgnoreOperation(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learn := func(method, path string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", method, path, "host", "200", Data.ReqBody, Data.RespBody)))
+	}
+	learn(http.MethodGet, "/debug/vars")
+	learn(http.MethodGet, "/api")
+	learn(http.MethodPost, "/api")
+
+	assert.Assert(t, s.IgnoreOperation("/debug/vars", "FOO") != nil)
+	assert.NilError(t, s.IgnoreOperation("/debug/vars", "get"))
+	assert.NilError(t, s.IgnoreOperation("/api", http.MethodPost))
+	assert.DeepEqual(t, s.GetIgnoredOperations(), map[string][]string{
+		"/debug/vars": {http.MethodGet},
+		"/api":        {http.MethodPost},
+	})
+
+	review := s.CreateSuggestedReview()
+	assert.Equal(t, len(review.PathItemsReview), 1)
+	assert.Equal(t, review.PathItemsReview[0].ParameterizedPath, "/api")
+	assert.Assert(t, review.PathToPathItem["/api"].Get != nil)
+	assert.Assert(t, review.PathToPathItem["/api"].Post == nil)
+	assert.Assert(t, review.PathToPathItem["/debug/vars"] == nil)
+	// the learning spec keeps the ignored operations
+	assert.Assert(t, s.LearningSpec.GetPathItem("/api").Post != nil)
+
+	// a review including an ignored operation does not approve it
+	approvedReview := &ApprovedSpecReview{
+		PathToPathItem: s.LearningSpec.PathItems,
+		PathItemsReview: []*ApprovedSpecReviewPathItem{
+			{
+				ReviewPathItem: ReviewPathItem{ParameterizedPath: "/api", Paths: map[string]bool{"/api": true}},
+				PathUUID:       "1",
+			},
+			{
+				ReviewPathItem: ReviewPathItem{ParameterizedPath: "/debug/vars", Paths: map[string]bool{"/debug/vars": true}},
+				PathUUID:       "2",
+			},
+		},
+	}
+	assert.NilError(t, s.ApplyApprovedReview(approvedReview))
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/api").Get != nil)
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/api").Post == nil)
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/debug/vars") == nil)
+
+	// ignored operations are still counted in the stats
+	assert.Equal(t, s.LearningStats.Operations["/debug/vars"][http.MethodGet].HitCount, 1)
+
+	s.UnignoreOperation("/debug/vars", http.MethodGet)
+	review = s.CreateSuggestedReview()
+	assert.Equal(t, len(review.PathItemsReview), 1)
+	assert.Equal(t, review.PathItemsReview[0].ParameterizedPath, "/debug/vars")
+}
+
+func TestSpec_isOperationIgnored(t *testing.T) {
+	s := &Spec{
+		SpecInfo: SpecInfo{
+			IgnoredOperations: map[string]map[string]bool{
+				"/debug/{param1}": {http.MethodGet: true},
+				"/health":         {http.MethodGet: true},
+			},
+		},
+	}
+	tests := []struct {
+		path   string
+		method string
+		want   bool
+	}{
+		{path: "/health", method: http.MethodGet, want: true},
+		{path: "/health", method: http.MethodPost, want: false},
+		{path: "/debug/vars", method: http.MethodGet, want: true},
+		{path: "/debug/vars/1", method: http.MethodGet, want: false},
+		{path: "/api", method: http.MethodGet, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			if got := s.isOperationIgnored(tt.path, tt.method); got != tt.want {
+				t.Errorf("isOperationIgnored() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/utils/field"
+)
+
+// jwtExtensionName holds the schemas of the header and claims of a jwt string, see OperationGeneratorConfig.LearnJWTClaims
+const jwtExtensionName = "x-jwt"
+
+// JWTSchema is the x-jwt extension of a jwt string schema. The tokens are decoded without verifying their signature.
+type JWTSchema struct {
+	Header *spec.Schema `json:"header,omitempty"`
+	Claims *spec.Schema `json:"claims,omitempty"`
+}
+
+// isJWTFormat checks if input is a JWS in compact serialization: a base64url JSON header with an alg,
+// a base64url JSON object payload and a (possibly empty for unsecured tokens) signature.
+func isJWTFormat(input string) bool {
+	_, _, ok := decodeJWT(input)
+	return ok
+}
+
+// decodeJWT returns the header and claims of token, without verifying its signature.
+func decodeJWT(token string) (header, claims map[string]interface{}, ok bool) {
+	parts := strings.Split(token, ".")
+	const jwsParts = 3
+	if len(parts) != jwsParts || parts[0] == "" || parts[1] == "" {
+		return nil, nil, false
+	}
+	header, ok = decodeJWTPart(parts[0])
+	if !ok {
+		return nil, nil, false
+	}
+	if alg, isString := header["alg"].(string); !isString || alg == "" {
+		return nil, nil, false
+	}
+	claims, ok = decodeJWTPart(parts[1])
+	if !ok {
+		return nil, nil, false
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "=")); err != nil {
+		return nil, nil, false
+	}
+
+	return header, claims, true
+}
+
+// decodeJWTPart decodes a base64url JSON object, numbers are kept as json.Number like the learned bodies.
+func decodeJWTPart(part string) (map[string]interface{}, bool) {
+	partB, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(partB))
+	decoder.UseNumber()
+	obj := make(map[string]interface{})
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+// getJSONBodySchema returns the schema of a JSON body, with the x-jwt schemas of its jwt fields
+// when OperationGeneratorConfig.LearnJWTClaims is set.
+func (o *OperationGenerator) getJSONBodySchema(value interface{}) (*spec.Schema, error) {
+	schema, err := getSchema(value)
+	if err != nil {
+		return nil, err
+	}
+	if o.LearnJWTClaims {
+		addJWTSchemas(schema, value, 0)
+	}
+	return schema, nil
+}
+
+// addJWTSchemas sets the x-jwt extension of the jwt strings of schema, the schema of value (see getSchema).
+func addJWTSchemas(schema *spec.Schema, value interface{}, depth int) {
+	if schema == nil || depth >= maxSchemaToRefDepth {
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		if schema.Format != formatJWT || getJWTSchema(schema) != nil {
+			return
+		}
+		if jwtSchema := createJWTSchema(v); jwtSchema != nil {
+			setJWTSchema(schema, jwtSchema)
+		}
+	case map[string]interface{}:
+		for key, val := range v {
+			name := escapeString(key)
+			property, ok := schema.Properties[name]
+			if !ok {
+				continue
+			}
+			addJWTSchemas(&property, val, depth+1)
+			schema.Properties[name] = property
+		}
+	case []interface{}:
+		if schema.Items == nil {
+			return
+		}
+		for _, item := range v {
+			addJWTSchemas(schema.Items.Schema, item, depth+1)
+		}
+	}
+}
+
+func createJWTSchema(token string) *JWTSchema {
+	header, claims, ok := decodeJWT(token)
+	if !ok {
+		return nil
+	}
+	headerSchema, err := getSchema(header)
+	if err != nil {
+		log.Debugf("Failed to get schema of jwt header: %v", err)
+		return nil
+	}
+	claimsSchema, err := getSchema(claims)
+	if err != nil {
+		log.Debugf("Failed to get schema of jwt claims: %v", err)
+		return nil
+	}
+
+	return &JWTSchema{
+		Header: headerSchema,
+		Claims: claimsSchema,
+	}
+}
+
+// getJWTSchema returns the x-jwt extension of schema, or nil if it has none.
+func getJWTSchema(schema *spec.Schema) *JWTSchema {
+	value, ok := schema.Extensions[jwtExtensionName]
+	if !ok {
+		return nil
+	}
+	if jwtSchema, ok := value.(*JWTSchema); ok {
+		return jwtSchema
+	}
+
+	// the extension is decoded as a map once the schema was cloned
+	valueB, err := json.Marshal(value)
+	if err != nil {
+		log.Warnf("Failed to marshal %v extension: %v", jwtExtensionName, err)
+		return nil
+	}
+	jwtSchema := &JWTSchema{}
+	if err := json.Unmarshal(valueB, jwtSchema); err != nil {
+		log.Warnf("Failed to unmarshal %v extension: %v", jwtExtensionName, err)
+		return nil
+	}
+	return jwtSchema
+}
+
+func setJWTSchema(schema *spec.Schema, jwtSchema *JWTSchema) {
+	schema.AddExtension(jwtExtensionName, jwtSchema)
+}
+
+// mergeJWTSchemas merges the x-jwt extension of schema2 into schema, the string schemas were merged already.
+// The extension is dropped once a merged value is not a jwt. Conflicting claims are not reported, the claims
+// are documentation only and keep the first learned type.
+func mergeJWTSchemas(schema, schema2 *spec.Schema, path *field.Path) {
+	if schema.Format != formatJWT {
+		delete(schema.Extensions, jwtExtensionName)
+		return
+	}
+	jwtSchema2 := getJWTSchema(schema2)
+	if jwtSchema2 == nil {
+		return
+	}
+	jwtSchema := getJWTSchema(schema)
+	if jwtSchema == nil {
+		setJWTSchema(schema, jwtSchema2)
+		return
+	}
+
+	jwtPath := path.Child(jwtExtensionName)
+	jwtSchema.Header, _ = mergeSchema(jwtSchema.Header, jwtSchema2.Header, jwtPath.Child("header"))
+	jwtSchema.Claims, _ = mergeSchema(jwtSchema.Claims, jwtSchema2.Claims, jwtPath.Child("claims"))
+	setJWTSchema(schema, jwtSchema)
+}
@@ -0,0 +1,65 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+)
+
+// largePayloadExtensionName flags an operation that had bodies too large to learn their schema.
+const largePayloadExtensionName = "x-large-payload"
+
+func createMaxBodySizeByMediaType(maxBodySizeByMediaType map[string]int) map[string]int {
+	ret := make(map[string]int, len(maxBodySizeByMediaType))
+	for mediaType, size := range maxBodySizeByMediaType {
+		ret[strings.ToLower(mediaType)] = size
+	}
+	return ret
+}
+
+// isLargePayload returns true if the schema of body (of mediaType) should not be inferred because of its size.
+func (o *OperationGenerator) isLargePayload(mediaType string, body string) bool {
+	maxSize, ok := o.MaxBodySizeToLearnByMediaType[mediaType]
+	if !ok {
+		maxSize = o.MaxBodySizeToLearn
+	}
+	if maxSize <= 0 {
+		return false
+	}
+
+	if len(body) > maxSize {
+		log.Debugf("Skipping schema inference of a large %v body. size=%v, max size=%v", mediaType, len(body), maxSize)
+		return true
+	}
+	return false
+}
+
+// getLargePayloadSchema returns the schema of a body that is too large to learn, without parsing it:
+// a binary body is a binary string, other bodies have no schema.
+func getLargePayloadSchema(body string) *spec.Schema {
+	if utf8.ValidString(body) {
+		return nil
+	}
+	return spec.StrFmtProperty(formatBinary)
+}
+
+func addLargePayloadExtension(operation *spec.Operation) {
+	operation.AddExtension(largePayloadExtensionName, true)
+}
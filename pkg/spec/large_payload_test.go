@@ -0,0 +1,99 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestOperationGenerator_GenerateSpecOperation_LargePayload(t *testing.T) {
+	generator := NewOperationGenerator(OperationGeneratorConfig{
+		MaxBodySizeToLearn:            10,
+		MaxBodySizeToLearnByMediaType: map[string]int{"Application/JSON": 100},
+	})
+	largeJSON := `{"items":["0123456789"]}`
+	binary := string([]byte{0xff, 0xfe, 0xfd, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07})
+
+	tests := []struct {
+		name             string
+		data             *HTTPInteractionData
+		wantLargePayload bool
+		check            func(t *testing.T, op *oapi_spec.Operation)
+	}{
+		{
+			name: "json under the media type limit is learned",
+			data: &HTTPInteractionData{
+				ReqBody:    largeJSON,
+				ReqHeaders: map[string]string{contentTypeHeaderName: mediaTypeApplicationJSON},
+				statusCode: 200,
+			},
+			check: func(t *testing.T, op *oapi_spec.Operation) {
+				assert.Equal(t, len(op.Parameters), 1)
+				assert.Assert(t, op.Parameters[0].Schema.Type.Contains(schemaTypeObject))
+			},
+		},
+		{
+			name: "large binary request",
+			data: &HTTPInteractionData{
+				ReqBody:    binary,
+				ReqHeaders: map[string]string{contentTypeHeaderName: "application/octet-stream"},
+				statusCode: 200,
+			},
+			wantLargePayload: true,
+			check: func(t *testing.T, op *oapi_spec.Operation) {
+				assert.DeepEqual(t, op.Consumes, []string{"application/octet-stream"})
+				assert.Assert(t, op.Parameters[0].Schema.Type.Contains(schemaTypeString))
+				assert.Equal(t, op.Parameters[0].Schema.Format, formatBinary)
+			},
+		},
+		{
+			name: "large text response",
+			data: &HTTPInteractionData{
+				RespBody:    "a,b,c\n1,2,3\n",
+				RespHeaders: map[string]string{contentTypeHeaderName: "text/csv"},
+				statusCode:  200,
+			},
+			wantLargePayload: true,
+			check: func(t *testing.T, op *oapi_spec.Operation) {
+				assert.DeepEqual(t, op.Produces, []string{"text/csv"})
+				assert.Assert(t, op.Responses.StatusCodeResponses[200].Schema == nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, err := generator.GenerateSpecOperation(tt.data, oapi_spec.SecurityDefinitions{})
+			assert.NilError(t, err)
+			_, hasExtension := op.Extensions[largePayloadExtensionName]
+			assert.Equal(t, hasExtension, tt.wantLargePayload)
+			tt.check(t, op)
+		})
+	}
+}
+
+func TestMergeOperation_KeepsExtensions(t *testing.T) {
+	op := oapi_spec.NewOperation("")
+	op2 := oapi_spec.NewOperation("")
+	addLargePayloadExtension(op2)
+
+	merged, _ := mergeOperation(op, op2)
+	assert.Equal(t, merged.Extensions[largePayloadExtensionName], true)
+	merged, _ = mergeOperation(merged, oapi_spec.NewOperation(""))
+	assert.Equal(t, merged.Extensions[largePayloadExtensionName], true)
+}
@@ -0,0 +1,111 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"context"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// LearnTelemetries learns telemetries into the learning spec, like LearnTelemetry for each of them, while taking the
+// lock once. The operations learned from the telemetries of the same path and method are merged together, and then
+// merged once into the learning spec, e.g. a single schema change is recorded for them.
+// Telemetries of frozen operations, or learned with OperationGeneratorConfig.SchemaMergeMinEstablishedHits or
+// OperationGeneratorConfig.LearnBodyVariants, are merged one by one as these depend on each learned telemetry.
+// The returned errors are the errors of the telemetries at the same index, nil for the learned telemetries.
+func (s *Spec) LearnTelemetries(telemetries []*Telemetry) []error {
+	// the observers are notified after the lock is released
+	defer s.observers.notify()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	errs := make([]error, len(telemetries))
+	var groups []*telemetryLearningGroup
+	groupByOperation := make(map[string]*telemetryLearningGroup)
+	for i, telemetry := range telemetries {
+		learning, err := s.prepareTelemetryLearningSafe(telemetry)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if learning == nil {
+			continue
+		}
+		key := learning.method + " " + learning.path
+		group, ok := groupByOperation[key]
+		if !ok {
+			group = &telemetryLearningGroup{path: learning.path, method: learning.method}
+			groupByOperation[key] = group
+			groups = append(groups, group)
+		}
+		group.indexes = append(group.indexes, i)
+		group.learnings = append(group.learnings, learning)
+	}
+
+	for _, group := range groups {
+		if err := s.learnTelemetryLearningGroup(group); err != nil {
+			for _, i := range group.indexes {
+				errs[i] = err
+			}
+		}
+	}
+
+	return errs
+}
+
+// telemetryLearningGroup are the telemetries of the same path and method, by index in the learned telemetries.
+type telemetryLearningGroup struct {
+	path      string
+	method    string
+	indexes   []int
+	learnings []*telemetryLearning
+}
+
+func (s *Spec) prepareTelemetryLearningSafe(telemetry *Telemetry) (learning *telemetryLearning, err error) {
+	defer utils.RecoverPanic(&err)
+
+	return s.prepareTelemetryLearning(context.Background(), telemetry)
+}
+
+func (s *Spec) learnTelemetryLearningGroup(group *telemetryLearningGroup) (err error) {
+	defer utils.RecoverPanic(&err)
+
+	if s.isOperationFrozen(group.path, group.method) || s.OpGenerator.SchemaMergeMinEstablishedHits > 0 || s.OpGenerator.LearnBodyVariants {
+		for _, learning := range group.learnings {
+			s.learnOperation(group.path, group.method, learning.operation, []*telemetryLearning{learning})
+		}
+		return nil
+	}
+
+	groupOp := group.learnings[0].operation
+	var conflictSamples []*RetainedSample
+	for _, learning := range group.learnings[1:] {
+		var conflicts []conflict
+		groupOp, conflicts = mergeOperation(groupOp, learning.operation)
+		if len(conflicts) > 0 {
+			conflictSamples = append(conflictSamples, &RetainedSample{Fields: getConflictFields(conflicts), Telemetry: learning.telemetry})
+		}
+	}
+	s.learnOperation(group.path, group.method, groupOp, group.learnings)
+	// retained after the sample of a new path, like when learning the telemetries one by one
+	for _, sample := range conflictSamples {
+		s.retainSample(SampleRetentionReasonSchemaConflict, group.path, group.method, sample.Fields, sample.Telemetry)
+		s.queueSchemaConflictEvent(group.path, group.method, sample.Fields)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func createBatchTelemetries(count int) []*Telemetry {
+	var telemetries []*Telemetry
+	for i := 0; i < count; i++ {
+		path := fmt.Sprintf("/api/items%d", i%3)
+		respBody := fmt.Sprintf(`{"id": %d, "field%d": "value"}`, i, i%5)
+		telemetries = append(telemetries, createTelemetry("req-id", http.MethodGet, path, "host", "200", "", respBody))
+	}
+	return telemetries
+}
+
+func TestSpec_LearnTelemetries(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.EnumMaxValues = 3
+	telemetries := createBatchTelemetries(20)
+	want := CreateDefaultSpec("host", "80", config)
+	for _, telemetry := range telemetries {
+		assert.NilError(t, want.LearnTelemetry(telemetry))
+	}
+
+	s := CreateDefaultSpec("host", "80", config)
+	errs := s.LearnTelemetries(telemetries)
+	assert.DeepEqual(t, errs, make([]error, len(telemetries)))
+
+	gotB, err := json.Marshal(s.LearningSpec)
+	assert.NilError(t, err)
+	wantB, err := json.Marshal(want.LearningSpec)
+	assert.NilError(t, err)
+	assert.Equal(t, string(gotB), string(wantB))
+	assert.Equal(t, s.LearningStats.TelemetryCount, want.LearningStats.TelemetryCount)
+	for path, methods := range want.LearningStats.Operations {
+		opStats := s.LearningStats.Operations[path][http.MethodGet]
+		assert.Equal(t, opStats.HitCount, methods[http.MethodGet].HitCount)
+		assert.DeepEqual(t, opStats.FieldValues, methods[http.MethodGet].FieldValues)
+		// the telemetries of an operation are merged once
+		assert.Equal(t, len(opStats.SchemaTimeline), 1)
+	}
+}
+
+func TestSpec_LearnTelemetries_Errors(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.RejectPath("/debug/*"))
+	telemetries := []*Telemetry{
+		createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id": 1}`),
+		createTelemetry("req-id", "INVALID", "/api", "host", "200", "", `{"id": 1}`),
+		createTelemetry("req-id", http.MethodGet, "/debug/vars", "host", "200", "", `{"id": 1}`),
+		createTelemetry("req-id", http.MethodGet, "/api", "host", "not a status", "", `{"id": 1}`),
+		createTelemetry("req-id", http.MethodPost, "/api", "host", "200", "", `{"id": 1}`),
+	}
+
+	errs := s.LearnTelemetries(telemetries)
+	assert.Equal(t, len(errs), len(telemetries))
+	for i, err := range errs {
+		assert.Equal(t, err != nil, i == 1 || i == 3, "telemetry %v: %v", i, err)
+	}
+	assert.Equal(t, s.LearningStats.TelemetryCount, 2)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/debug/vars") == nil)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/api").Post != nil)
+}
+
+func TestSpec_LearnTelemetries_OneByOne(t *testing.T) {
+	// established operations record outliers per telemetry
+	config := OperationGeneratorConfig{SchemaMergeMinEstablishedHits: 2}
+	telemetries := []*Telemetry{
+		createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id": 1}`),
+		createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id": 2}`),
+		createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id": "a"}`),
+		createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id": "b"}`),
+	}
+	for i, telemetry := range telemetries {
+		telemetry.Timestamp = time.Unix(int64(i), 0).UTC()
+	}
+	want := CreateDefaultSpec("host", "80", config)
+	for _, telemetry := range telemetries {
+		assert.NilError(t, want.LearnTelemetry(telemetry))
+	}
+
+	s := CreateDefaultSpec("host", "80", config)
+	assert.DeepEqual(t, s.LearnTelemetries(telemetries), make([]error, len(telemetries)))
+	assert.DeepEqual(t, s.GetSchemaOutliers(), want.GetSchemaOutliers())
+	assert.Equal(t, len(s.GetSchemaOutliers()), 1)
+	assert.Equal(t, s.GetSchemaOutliers()[0].Count, 2)
+}
+
+func BenchmarkSpec_LearnTelemetries(b *testing.B) {
+	telemetries := createBatchTelemetries(100)
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+			s.LearnTelemetries(telemetries)
+		}
+	})
+	b.Run("one-by-one", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+			for _, telemetry := range telemetries {
+				_ = s.LearnTelemetry(telemetry)
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	oapi_spec "github.com/go-openapi/spec"
+)
+
+// GenerateLearningOAS generates an OAS (json) of the learned operations that were not approved yet.
+// Paths are parameterized and their path params are typed the same way as when approving a CreateSuggestedReview,
+// so pending operations render like approved ones.
+func (s *Spec) GenerateLearningOAS(opts ...GenerateOASOption) ([]byte, error) {
+	return s.GenerateLearningOASCtx(context.Background(), opts...)
+}
+
+// GenerateLearningOASCtx is GenerateLearningOAS that stops once ctx is done, see GenerateOASJsonCtx.
+func (s *Spec) GenerateLearningOASCtx(ctx context.Context, opts ...GenerateOASOption) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("generation canceled: %w", err)
+	}
+	defer s.recordGenerationDuration(time.Now())
+
+	s.lock.RLock()
+	clonedSpec, err := s.SpecInfoClone()
+	opGenerator := s.OpGenerator
+	s.lock.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone spec. %v", err)
+	}
+	clonedSpec.OpGenerator = opGenerator
+
+	pathItems, parameterizedPathToPaths := clonedSpec.createUnapprovedPathItems()
+
+	getOperationStats := func() map[string]map[string]*OperationStats {
+		return clonedSpec.getLearningOperationStats(parameterizedPathToPaths)
+	}
+	if opGenerator != nil && opGenerator.EnumMaxValues > 0 {
+		clonedSpec.addInferredEnums(pathItems, getOperationStats())
+	}
+	if opGenerator != nil && opGenerator.RequiredPropertyMinRatio > 0 {
+		clonedSpec.addInferredRequired(pathItems, getOperationStats())
+	}
+
+	opts = append(opts[:len(opts):len(opts)], withContext(ctx))
+	return clonedSpec.generateOASJson(pathItems, clonedSpec.LearningSpec.SecurityDefinitions, getOperationStats, opts)
+}
+
+// createUnapprovedPathItems groups the learned paths into parameterized paths and merges their unapproved operations.
+// It returns the merged path items and the learned paths of each parameterized path.
+func (s *Spec) createUnapprovedPathItems() (map[string]*oapi_spec.PathItem, map[string]map[string]bool) {
+	pathItems := make(map[string]*oapi_spec.PathItem)
+	parameterizedPathToPaths := make(map[string]map[string]bool)
+	for parameterizedPath, paths := range s.createLearningParametrizedPaths().Paths {
+		mergedPathItem := &oapi_spec.PathItem{}
+		for path := range paths {
+			pathItem := s.getUnapprovedPathItem(path)
+			if pathItem == nil {
+				continue
+			}
+			mergedPathItem = MergePathItems(mergedPathItem, pathItem)
+		}
+		if isEmptyPathItem(mergedPathItem) {
+			continue
+		}
+		addPathParamsToPathItem(mergedPathItem, parameterizedPath, paths, s.isLearningCompositePathParams())
+		pathItems[parameterizedPath] = mergedPathItem
+		parameterizedPathToPaths[parameterizedPath] = paths
+	}
+
+	return pathItems, parameterizedPathToPaths
+}
+
+// getUnapprovedPathItem returns the learned path item of path without the operations that are already approved,
+// since telemetries of approved operations are still learned, and without the ignored operations.
+func (s *Spec) getUnapprovedPathItem(path string) *oapi_spec.PathItem {
+	pathItem := s.LearningSpec.GetPathItem(path)
+	if pathItem == nil {
+		return nil
+	}
+	pathItem = s.removeIgnoredOperations(path, pathItem)
+	if pathItem == nil {
+		return nil
+	}
+	approvedPath, _, found := s.ApprovedPathTrie.GetPathAndValue(path)
+	if !found {
+		return pathItem
+	}
+
+	for _, method := range supportedMethods {
+		if s.hasApprovedOperation(approvedPath, method) {
+			AddOperationToPathItem(pathItem, method, nil)
+		}
+	}
+	return pathItem
+}
+
+func isEmptyPathItem(pathItem *oapi_spec.PathItem) bool {
+	for _, method := range supportedMethods {
+		if GetOperationFromPathItem(pathItem, method) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// getLearningOperationStats aggregates the learned operation stats by the parameterized path they are grouped into.
+func (s *Spec) getLearningOperationStats(parameterizedPathToPaths map[string]map[string]bool) map[string]map[string]*OperationStats {
+	ret := make(map[string]map[string]*OperationStats)
+	if s.LearningStats == nil {
+		return ret
+	}
+
+	for parameterizedPath, paths := range parameterizedPathToPaths {
+		for path := range paths {
+			for method, opStats := range s.LearningStats.Operations[path] {
+				if _, ok := ret[parameterizedPath]; !ok {
+					ret[parameterizedPath] = make(map[string]*OperationStats)
+				}
+				aggregated, ok := ret[parameterizedPath][method]
+				if !ok {
+					aggregated = &OperationStats{}
+					ret[parameterizedPath][method] = aggregated
+				}
+				aggregated.merge(opStats)
+			}
+		}
+	}
+
+	return ret
+}
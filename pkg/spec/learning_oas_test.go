@@ -0,0 +1,72 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpec_GenerateLearningOAS(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learn := func(method, path string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", method, path, "host", "200", Data.ReqBody, Data.RespBody)))
+	}
+	learn(http.MethodGet, "/api/1")
+	learn(http.MethodGet, "/api/2")
+
+	suggestedReview := s.CreateSuggestedReview()
+	approvedReview := &ApprovedSpecReview{
+		PathToPathItem: suggestedReview.PathToPathItem,
+	}
+	for _, item := range suggestedReview.PathItemsReview {
+		approvedReview.PathItemsReview = append(approvedReview.PathItemsReview, &ApprovedSpecReviewPathItem{
+			ReviewPathItem: item.ReviewPathItem,
+			PathUUID:       "1",
+		})
+	}
+	assert.NilError(t, s.ApplyApprovedReview(approvedReview))
+
+	// GET is approved, POST is pending
+	learn(http.MethodGet, "/api/3")
+	learn(http.MethodPost, "/api/3")
+	learn(http.MethodPost, "/api/4")
+	learn(http.MethodGet, "/users")
+
+	hitsInjector := func(path, method string, operation *oapi_spec.Operation, stats *OperationStats) map[string]interface{} {
+		return map[string]interface{}{"x-hits": stats.HitCount}
+	}
+	oasJSON, err := s.GenerateLearningOAS(WithOperationExtensions(hitsInjector))
+	assert.NilError(t, err)
+	generated := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, generated))
+
+	assert.Equal(t, len(generated.Paths.Paths), 2)
+	pathItem := generated.Paths.Paths["/api/{param1}"]
+	assert.Assert(t, pathItem.Get == nil)
+	assert.Assert(t, pathItem.Post != nil)
+	assert.Equal(t, pathItem.Post.Extensions["x-hits"], float64(2))
+	assert.Equal(t, len(pathItem.Parameters), 1)
+	assert.Equal(t, pathItem.Parameters[0].Type, schemaTypeInteger)
+	assert.Assert(t, generated.Paths.Paths["/users"].Get != nil)
+
+	// the learning spec is not modified
+	assert.Assert(t, s.LearningSpec.GetPathItem("/api/3").Get != nil)
+}
@@ -0,0 +1,92 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// PathMatch is a snapshot of the matched path, its path item and operation are copies that are not changed by
+// telemetries learned after the match.
+type PathMatch struct {
+	// Path is the matched (parameterized) path, e.g. /api/{param1}
+	Path string
+	// PathID of an approved path, empty for learned paths that were not approved yet
+	PathID    string
+	PathItem  *oapi_spec.PathItem
+	Operation *oapi_spec.Operation
+	// PathParams map path param name into its value in the matched path, e.g. param1 -> 1
+	PathParams map[string]string
+	// Approved is true if the path was matched on the approved spec, false if it was matched on the learning spec
+	Approved bool
+}
+
+// MatchPath matches a telemetry method and path (query is ignored) against the approved spec and if not found
+// against the learning spec. Returns false if no operation exists for the path and method.
+// The returned match is a snapshot that is safe to read while telemetries are learned.
+func (s *Spec) MatchPath(method, rawPath string) (*PathMatch, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	path, _ := GetPathAndQuery(rawPath)
+
+	if approvedPath, pathID, found := lookupPathID(&s.ApprovedPathTrie, path); found {
+		if pathItem := s.ApprovedSpec.GetPathItem(approvedPath); pathItem != nil {
+			if op := GetOperationFromPathItem(pathItem, method); op != nil {
+				pathParams, _ := utils.GetPathParamValues(approvedPath, path)
+				pathItem = clonePathItem(pathItem)
+				return &PathMatch{
+					Path:       approvedPath,
+					PathID:     pathID,
+					PathItem:   pathItem,
+					Operation:  GetOperationFromPathItem(pathItem, method),
+					PathParams: pathParams,
+					Approved:   true,
+				}, true
+			}
+		}
+	}
+
+	// learning spec paths are not parameterized
+	if pathItem := s.LearningSpec.GetPathItem(path); pathItem != nil {
+		if op := GetOperationFromPathItem(pathItem, method); op != nil {
+			pathItem = clonePathItem(pathItem)
+			return &PathMatch{
+				Path:       path,
+				PathItem:   pathItem,
+				Operation:  GetOperationFromPathItem(pathItem, method),
+				PathParams: map[string]string{},
+			}, true
+		}
+	}
+
+	return nil, false
+}
+
+// lookupPathID returns the path of trie that matches path and its path ID, ambiguous matches (see pathtrie.PathLookup)
+// are logged.
+func lookupPathID(trie *pathtrie.TypedPathTrie[string], path string) (string, string, bool) {
+	lookup := trie.LookupPath(path)
+	if lookup.Ambiguous {
+		log.Debugf("Path %v matches other paths as accurately as %v", path, lookup.FullPath)
+	}
+
+	return lookup.FullPath, lookup.Value, lookup.Found
+}
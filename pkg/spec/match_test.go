@@ -0,0 +1,135 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpec_MatchPath(t *testing.T) {
+	approvedOp := NewOperation(t, Data).Op
+	approvedPathItem := &NewTestPathItem().WithOperation(http.MethodGet, approvedOp).PathItem
+	learnedOp := NewOperation(t, Data2).Op
+	learnedPathItem := &NewTestPathItem().WithOperation(http.MethodPost, learnedOp).PathItem
+	s := &Spec{
+		SpecInfo: SpecInfo{
+			ApprovedSpec: &ApprovedSpec{
+				PathItems: map[string]*oapi_spec.PathItem{
+					"/api/{param1}/items": approvedPathItem,
+				},
+			},
+			LearningSpec: &LearningSpec{
+				PathItems: map[string]*oapi_spec.PathItem{
+					"/api/learned": learnedPathItem,
+				},
+			},
+			ApprovedPathTrie: createPathTrie(map[string]string{
+				"/api/{param1}/items": "1",
+			}),
+		},
+	}
+	type args struct {
+		method  string
+		rawPath string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		want  *PathMatch
+		want1 bool
+	}{
+		{
+			name: "approved path match",
+			args: args{
+				method:  http.MethodGet,
+				rawPath: "/api/2/items?foo=bar",
+			},
+			want: &PathMatch{
+				Path:       "/api/{param1}/items",
+				PathID:     "1",
+				PathItem:   approvedPathItem,
+				Operation:  approvedOp,
+				PathParams: map[string]string{"param1": "2"},
+				Approved:   true,
+			},
+			want1: true,
+		},
+		{
+			name: "approved path without the method",
+			args: args{
+				method:  http.MethodPost,
+				rawPath: "/api/2/items",
+			},
+			want:  nil,
+			want1: false,
+		},
+		{
+			name: "learned path match",
+			args: args{
+				method:  http.MethodPost,
+				rawPath: "/api/learned",
+			},
+			want: &PathMatch{
+				Path:       "/api/learned",
+				PathItem:   learnedPathItem,
+				Operation:  learnedOp,
+				PathParams: map[string]string{},
+			},
+			want1: true,
+		},
+		{
+			name: "no match",
+			args: args{
+				method:  http.MethodGet,
+				rawPath: "/no/match",
+			},
+			want:  nil,
+			want1: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1 := s.MatchPath(tt.args.method, tt.args.rawPath)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchPath() got = %+v, want %+v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("MatchPath() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestSpec_MatchPath_Snapshot(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api/users", "host", "200", "", `{"id":1}`)))
+
+	match, found := s.MatchPath(http.MethodGet, "/api/users")
+	assert.Assert(t, found)
+	assert.Assert(t, match.Operation == match.PathItem.Get)
+	responseSchema := match.Operation.Responses.StatusCodeResponses[200].Schema
+	assert.Equal(t, len(responseSchema.Properties), 1)
+
+	// learning merges into the learned operation, the match is not changed
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api/users", "host", "200", "", `{"id":1,"name":"a"}`)))
+	assert.Equal(t, len(responseSchema.Properties), 1)
+	assert.Equal(t, len(s.LearningSpec.GetPathItem("/api/users").Get.Responses.StatusCodeResponses[200].Schema.Properties), 2)
+}
@@ -26,7 +26,7 @@ import (
 	"github.com/apiclarity/speculator/pkg/utils/slice"
 )
 
-var supportedParametersInTypes = []string{parametersInBody, parametersInHeader, parametersInQuery, parametersInForm, parametersInPath}
+var supportedParametersInTypes = []string{parametersInBody, parametersInHeader, parametersInQuery, parametersInForm, parametersInPath, parametersInCookie}
 
 func mergeOperation(operation, operation2 *spec.Operation) (*spec.Operation, []conflict) {
 	if op, shouldReturn := shouldReturnIfNil(operation, operation2); shouldReturn {
@@ -47,6 +47,8 @@ func mergeOperation(operation, operation2 *spec.Operation) (*spec.Operation, []c
 
 	ret.Security = mergeOperationSecurity(operation.Security, operation2.Security)
 
+	ret.Extensions = mergeOperationExtensions(operation.Extensions, operation2.Extensions)
+
 	conflicts := append(paramConflicts, resConflicts...)
 
 	if len(conflicts) > 0 {
@@ -56,6 +58,25 @@ func mergeOperation(operation, operation2 *spec.Operation) (*spec.Operation, []c
 	return ret, conflicts
 }
 
+// mergeOperationExtensions returns the extensions of both operations, operation2 extensions take precedence.
+func mergeOperationExtensions(extensions, extensions2 spec.Extensions) spec.Extensions {
+	if len(extensions) == 0 && len(extensions2) == 0 {
+		return nil
+	}
+
+	ret := spec.Extensions{}
+	for key, value := range extensions {
+		ret[key] = value
+	}
+	for key, value := range extensions2 {
+		ret[key] = value
+	}
+	if compression := mergeCompressionExtensions(extensions, extensions2); compression != nil {
+		ret[compressionExtensionName] = compression
+	}
+	return ret
+}
+
 func mergeOperationSecurity(security, security2 []map[string][]string) []map[string][]string {
 	var mergedSecurity []map[string][]string
 
@@ -118,7 +139,7 @@ func getParametersByIn(parameters []spec.Parameter) map[string][]spec.Parameter
 
 	for i, parameter := range parameters {
 		switch parameter.In {
-		case parametersInBody, parametersInHeader, parametersInQuery, parametersInForm, parametersInPath:
+		case parametersInBody, parametersInHeader, parametersInQuery, parametersInForm, parametersInPath, parametersInCookie:
 			ret[parameter.In] = append(ret[parameter.In], parameters[i])
 		default:
 			log.Warnf("in parameter not supported. %v", parameter.In)
@@ -205,6 +226,8 @@ func mergeParameter(parameter, parameter2 spec.Parameter, path *field.Path) (spe
 		items, conflicts := mergeSimpleSchemaItems(parameter.Items, parameter2.Items, path)
 		parameter.Items = items
 		return parameter, conflicts
+	case parameterTypeFile:
+		return parameter, nil
 	case "":
 		// when type is missing it is probably an object - we should try and merge the parameter schema
 		schema, conflicts := mergeSchema(parameter.Schema, parameter2.Schema, path.Child("schema"))
@@ -262,10 +285,20 @@ func mergeSchema(schema, schema2 *spec.Schema, path *field.Path) (*spec.Schema,
 		return s.(*spec.Schema), nil
 	}
 
+	// variants have no type
+	if isVariantsSchema(schema) || isVariantsSchema(schema2) {
+		return mergeSchemaVariants(schema, schema2, path)
+	}
+
 	if s, shouldReturn := shouldReturnIfEmptySchemaType(schema, schema2); shouldReturn {
 		return s, nil
 	}
 
+	// an XML element may be repeated in one body only
+	if isXMLRepeatedElement(schema, schema2) || isXMLRepeatedElement(schema2, schema) {
+		return mergeXMLRepeatedElement(schema, schema2, path)
+	}
+
 	if schema.Type[0] != schema2.Type[0] {
 		return schema, []conflict{
 			{
@@ -284,6 +317,7 @@ func mergeSchema(schema, schema2 *spec.Schema, path *field.Path) (*spec.Schema,
 		if schema.Format != schema2.Format {
 			schema.Format = ""
 		}
+		mergeJWTSchemas(schema, schema2, path)
 		return schema, nil
 	case schemaTypeArray:
 		items, conflicts := mergeSchemaItems(schema.Items, schema2.Items, path)
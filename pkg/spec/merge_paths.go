@@ -0,0 +1,143 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+
+	oapi_spec "github.com/go-openapi/spec"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+// MergePaths groups literalPaths (e.g. /users/alice, /users/bob) into template (e.g. /users/{name}) when suggesting
+// a review, for paths the path param heuristic missed.
+// The approved literal paths are replaced by the approved template, merged with their operations. Once the template
+// is approved, the learned literal paths are approved into it as well.
+func (s *Spec) MergePaths(literalPaths []string, template string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(literalPaths) == 0 {
+		return fmt.Errorf("no paths to merge into %v", template)
+	}
+	for _, path := range literalPaths {
+		if path == template {
+			return fmt.Errorf("path %v is the template", path)
+		}
+		if _, ok := utils.GetPathParamValues(template, path); !ok {
+			return fmt.Errorf("path %v does not match %v", path, template)
+		}
+		_, isLearned := s.LearningSpec.PathItems[path]
+		_, isApproved := s.ApprovedSpec.PathItems[path]
+		if !isLearned && !isApproved {
+			return fmt.Errorf("path %v was not learned", path)
+		}
+	}
+
+	// first update the merge into a copy of the state, in case the validation will fail
+	clonedSpec, err := s.SpecInfoClone()
+	if err != nil {
+		return fmt.Errorf("failed to clone spec. %v", err)
+	}
+	clonedSpec.OpGenerator = s.OpGenerator
+
+	if clonedSpec.mergeApprovedPaths(literalPaths, template) {
+		if _, err := clonedSpec.generateApprovedOASJson(); err != nil {
+			return fmt.Errorf("failed to generate Open API Spec. %w", err)
+		}
+	}
+	for _, path := range literalPaths {
+		clonedSpec.setPathTemplate(path, template)
+	}
+	s.SpecInfo = clonedSpec.SpecInfo
+	s.oasCache.invalidate()
+
+	return nil
+}
+
+// GetPathTemplates returns the template of each path merged by MergePaths.
+func (s *Spec) GetPathTemplates() map[string]string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := make(map[string]string, len(s.PathTemplates))
+	for path, template := range s.PathTemplates {
+		ret[path] = template
+	}
+
+	return ret
+}
+
+func (s *Spec) setPathTemplate(path, template string) {
+	if s.PathTemplates == nil {
+		s.PathTemplates = make(map[string]string)
+	}
+	s.PathTemplates[path] = template
+	delete(s.SplitPaths, path)
+}
+
+// mergeApprovedPaths merges the path items of literalPaths into the approved template path item, if the template
+// or any of literalPaths is approved. Returns true if the approved spec was updated.
+func (s *Spec) mergeApprovedPaths(literalPaths []string, template string) bool {
+	mergedPathItem := &oapi_spec.PathItem{}
+	templatePathItem, isTemplateApproved := s.ApprovedSpec.PathItems[template]
+	if isTemplateApproved {
+		mergedPathItem = MergePathItems(mergedPathItem, templatePathItem)
+		mergedPathItem.Parameters = templatePathItem.Parameters
+	}
+
+	mergedPaths := make(map[string]bool)
+	for _, path := range literalPaths {
+		pathItem, ok := s.ApprovedSpec.PathItems[path]
+		if !ok {
+			continue
+		}
+		mergedPathItem = MergePathItems(mergedPathItem, pathItem)
+		mergedPaths[path] = true
+		delete(s.ApprovedSpec.PathItems, path)
+		s.ApprovedPathTrie.Delete(path)
+	}
+	if !isTemplateApproved && len(mergedPaths) == 0 {
+		return false
+	}
+
+	for _, path := range literalPaths {
+		pathItem, ok := s.LearningSpec.PathItems[path]
+		if !ok {
+			continue
+		}
+		// ignored operations are never approved
+		if pathItem = s.removeIgnoredOperations(path, pathItem); pathItem == nil {
+			continue
+		}
+		mergedPathItem = MergePathItems(mergedPathItem, pathItem)
+		mergedPaths[path] = true
+		delete(s.LearningSpec.PathItems, path)
+	}
+
+	if len(mergedPathItem.Parameters) == 0 {
+		addPathParamsToPathItem(mergedPathItem, template, mergedPaths, s.isLearningCompositePathParams())
+	}
+	s.ApprovedSpec.PathItems[template] = mergedPathItem
+	if !isTemplateApproved {
+		s.ApprovedPathTrie.Insert(template, uuid.New().String())
+	}
+	s.ApprovedSpec.SecurityDefinitions = updateSecurityDefinitionsFromPathItem(s.ApprovedSpec.SecurityDefinitions, mergedPathItem)
+
+	return true
+}
@@ -0,0 +1,148 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func learnMergePathsTestPaths(t *testing.T, s *Spec, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", "", Data.RespBody)))
+	}
+}
+
+func getSuggestedReviewPaths(s *Spec) map[string]map[string]bool {
+	ret := make(map[string]map[string]bool)
+	for _, pathReview := range s.CreateSuggestedReview().PathItemsReview {
+		ret[pathReview.ParameterizedPath] = pathReview.Paths
+	}
+	return ret
+}
+
+func TestSpec_MergePaths_Learning(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMergePathsTestPaths(t, s, "/users/alice", "/users/bob", "/users/carol")
+	assert.Equal(t, len(getSuggestedReviewPaths(s)), 3)
+
+	assert.NilError(t, s.MergePaths([]string{"/users/alice", "/users/bob"}, "/users/{name}"))
+	assert.DeepEqual(t, s.GetPathTemplates(), map[string]string{
+		"/users/alice": "/users/{name}",
+		"/users/bob":   "/users/{name}",
+	})
+	assert.DeepEqual(t, getSuggestedReviewPaths(s), map[string]map[string]bool{
+		"/users/{name}": {"/users/alice": true, "/users/bob": true},
+		"/users/carol":  {"/users/carol": true},
+	})
+	// the template is not approved without a review
+	assert.Equal(t, len(s.ApprovedSpec.PathItems), 0)
+
+	// a merged path can be split again
+	assert.NilError(t, s.SplitPath("/users/{name}", "/users/bob"))
+	assert.DeepEqual(t, s.GetPathTemplates(), map[string]string{"/users/alice": "/users/{name}"})
+	assert.DeepEqual(t, getSuggestedReviewPaths(s), map[string]map[string]bool{
+		"/users/{name}": {"/users/alice": true},
+		"/users/bob":    {"/users/bob": true},
+		"/users/carol":  {"/users/carol": true},
+	})
+}
+
+func TestSpec_MergePaths_Approved(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMergePathsTestPaths(t, s, "/users/alice", "/users/bob")
+	assert.NilError(t, s.ApplyApprovedReview(&ApprovedSpecReview{
+		PathToPathItem: s.LearningSpec.PathItems,
+		PathItemsReview: []*ApprovedSpecReviewPathItem{
+			{
+				ReviewPathItem: ReviewPathItem{ParameterizedPath: "/users/alice", Paths: map[string]bool{"/users/alice": true}},
+				PathUUID:       "1",
+			},
+			{
+				ReviewPathItem: ReviewPathItem{ParameterizedPath: "/users/bob", Paths: map[string]bool{"/users/bob": true}},
+				PathUUID:       "2",
+			},
+		},
+	}))
+	learnMergePathsTestPaths(t, s, "/users/carol")
+
+	assert.NilError(t, s.MergePaths([]string{"/users/alice", "/users/bob", "/users/carol"}, "/users/{name}"))
+
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/users/alice") == nil)
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/users/bob") == nil)
+	pathItem := s.ApprovedSpec.GetPathItem("/users/{name}")
+	assert.Assert(t, pathItem != nil)
+	assert.Assert(t, pathItem.Get != nil)
+	assert.Equal(t, len(pathItem.Parameters), 1)
+	assert.Equal(t, pathItem.Parameters[0].Name, "name")
+	assert.Equal(t, pathItem.Parameters[0].Type, schemaTypeString)
+
+	// the learned path is approved into the template
+	assert.Assert(t, s.LearningSpec.GetPathItem("/users/carol") == nil)
+	assert.Equal(t, len(getSuggestedReviewPaths(s)), 0)
+
+	for _, path := range []string{"/users/alice", "/users/dave"} {
+		approvedPath, _, found := s.ApprovedPathTrie.GetPathAndValue(path)
+		assert.Assert(t, found)
+		assert.Equal(t, approvedPath, "/users/{name}")
+	}
+
+	// merging into the approved template keeps its path params
+	learnMergePathsTestPaths(t, s, "/users/dave")
+	assert.NilError(t, s.MergePaths([]string{"/users/dave"}, "/users/{name}"))
+	assert.Equal(t, len(s.ApprovedSpec.GetPathItem("/users/{name}").Parameters), 1)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/users/dave") == nil)
+}
+
+func TestSpec_MergePaths_Errors(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMergePathsTestPaths(t, s, "/users/alice")
+	tests := []struct {
+		name         string
+		literalPaths []string
+		template     string
+	}{
+		{
+			name:         "no paths",
+			literalPaths: nil,
+			template:     "/users/{name}",
+		},
+		{
+			name:         "path is the template",
+			literalPaths: []string{"/users/alice"},
+			template:     "/users/alice",
+		},
+		{
+			name:         "not matching",
+			literalPaths: []string{"/users/alice"},
+			template:     "/orders/{name}",
+		},
+		{
+			name:         "not learned",
+			literalPaths: []string{"/users/alice", "/users/bob"},
+			template:     "/users/{name}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Assert(t, s.MergePaths(tt.literalPaths, tt.template) != nil)
+		})
+	}
+	assert.Equal(t, len(s.GetPathTemplates()), 0)
+}
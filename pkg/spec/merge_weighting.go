@@ -0,0 +1,188 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/utils/field"
+)
+
+// SchemaOutlier is an operation field whose learned telemetries conflicted with the established operation schema.
+type SchemaOutlier struct {
+	Path   string
+	Method string
+	// Field is the path of the field in the operation, e.g. parameters.body.schema.properties.id
+	Field   string
+	Message string
+	// Count is the amount of telemetries that conflicted on Field
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// mergeLearnedOperation merges an operation learned from a telemetry of path and method into the existing one.
+// Once the existing operation is established (see OperationGeneratorConfig.SchemaMergeMinEstablishedHits), a telemetry
+// that conflicts with its schema is recorded as an outlier and is not learned, unless the outliers of the
+// conflicting fields reach OperationGeneratorConfig.SchemaMergeMinOutlierRatio.
+// The returned conflicts are the merge conflicts, or the outliers of an established operation.
+// With OperationGeneratorConfig.LearnBodyVariants, structurally different bodies are learned as variants instead.
+// The operations frozen by Spec.FreezePath are not merged, see mergeFrozenOperation.
+func (s *Spec) mergeLearnedOperation(path, method string, existingOp, telemetryOp *oapi_spec.Operation, seen time.Time) (*oapi_spec.Operation, []conflict) {
+	if s.isOperationFrozen(path, method) {
+		return s.mergeFrozenOperation(path, method, existingOp, telemetryOp, seen)
+	}
+	if s.OpGenerator.LearnBodyVariants {
+		addBodyVariants(existingOp, telemetryOp)
+	}
+	hitCount := s.getOperationHitCount(path, method)
+	minEstablishedHits := s.OpGenerator.SchemaMergeMinEstablishedHits
+	if minEstablishedHits <= 0 || hitCount < minEstablishedHits {
+		return mergeOperation(existingOp, telemetryOp)
+	}
+
+	// merging may update the existing operation
+	establishedOp, err := CloneOperation(existingOp)
+	if err != nil {
+		log.Errorf("Failed to clone operation, merging without weighting: %v", err)
+		return mergeOperation(existingOp, telemetryOp)
+	}
+	mergedOp, conflicts := mergeOperation(existingOp, telemetryOp)
+	outliers := getWideningOutliers(establishedOp, mergedOp, conflicts)
+	if len(outliers) == 0 {
+		return mergedOp, nil
+	}
+
+	minOutlierRatio := s.OpGenerator.SchemaMergeMinOutlierRatio
+	shouldWiden := minOutlierRatio > 0
+	for _, outlier := range outliers {
+		count := s.recordSchemaOutlier(path, method, outlier, seen)
+		if float64(count) < minOutlierRatio*float64(hitCount) {
+			shouldWiden = false
+		}
+	}
+	if shouldWiden {
+		return mergedOp, outliers
+	}
+
+	return establishedOp, outliers
+}
+
+func (s *Spec) getOperationHitCount(path, method string) int {
+	if s.LearningStats == nil {
+		return 0
+	}
+	opStats, ok := s.LearningStats.Operations[path][method]
+	if !ok {
+		return 0
+	}
+	return opStats.HitCount
+}
+
+// recordSchemaOutlier records the outlier of path and method and returns the amount of times it was seen.
+func (s *Spec) recordSchemaOutlier(path, method string, outlier conflict, seen time.Time) int {
+	fieldPath := outlier.path.String()
+	for _, existing := range s.SchemaOutliers {
+		if existing.Path == path && existing.Method == method && existing.Field == fieldPath {
+			existing.Count++
+			existing.Message = outlier.msg
+			if seen.After(existing.LastSeen) {
+				existing.LastSeen = seen
+			}
+			return existing.Count
+		}
+	}
+
+	s.SchemaOutliers = append(s.SchemaOutliers, &SchemaOutlier{
+		Path:      path,
+		Method:    method,
+		Field:     fieldPath,
+		Message:   outlier.msg,
+		Count:     1,
+		FirstSeen: seen,
+		LastSeen:  seen,
+	})
+	return 1
+}
+
+// GetSchemaOutliers returns the recorded schema outliers, sorted by path, method and field.
+func (s *Spec) GetSchemaOutliers() []SchemaOutlier {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := make([]SchemaOutlier, 0, len(s.SchemaOutliers))
+	for _, outlier := range s.SchemaOutliers {
+		ret = append(ret, *outlier)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Path != ret[j].Path {
+			return ret[i].Path < ret[j].Path
+		}
+		if ret[i].Method != ret[j].Method {
+			return ret[i].Method < ret[j].Method
+		}
+		return ret[i].Field < ret[j].Field
+	})
+
+	return ret
+}
+
+// getWideningOutliers returns the type conflicts of the merge and the formats of the established operation
+// that the merge dropped.
+func getWideningOutliers(establishedOp, mergedOp *oapi_spec.Operation, conflicts []conflict) []conflict {
+	outliers := append([]conflict{}, conflicts...)
+
+	mergedFormats := getOperationFormats(mergedOp)
+	establishedFormats := getOperationFormats(establishedOp)
+	fieldPaths := make([]string, 0, len(establishedFormats))
+	for fieldPath := range establishedFormats {
+		fieldPaths = append(fieldPaths, fieldPath)
+	}
+	sort.Strings(fieldPaths)
+	for _, fieldPath := range fieldPaths {
+		formatField := establishedFormats[fieldPath]
+		if mergedFormats[fieldPath].format == formatField.format {
+			continue
+		}
+		outliers = append(outliers, conflict{
+			path: formatField.path,
+			msg:  fmt.Sprintf("%s: format mismatch: %v", formatField.path, formatField.format),
+		})
+	}
+
+	return outliers
+}
+
+type formatField struct {
+	path   *field.Path
+	format string
+}
+
+// getOperationFormats returns the formats of the operation fields by field path, with the paths used by mergeOperation.
+func getOperationFormats(op *oapi_spec.Operation) map[string]formatField {
+	formats := make(map[string]formatField)
+	forEachOperationField(op, func(path *field.Path, _, format string) {
+		if format != "" {
+			formats[path.String()] = formatField{path: path, format: format}
+		}
+	})
+
+	return formats
+}
@@ -0,0 +1,104 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_LearnTelemetry_SchemaMergeWeighting(t *testing.T) {
+	const (
+		uuidBody  = `{"id":"3f2c7b8e-5a1d-4c9e-8f6b-2d4a1e7c9b30"}`
+		plainBody = `{"id":"not-a-uuid"}`
+	)
+	tests := []struct {
+		name               string
+		config             OperationGeneratorConfig
+		outliers           int
+		wantFormat         string
+		wantOutliersCounts []int
+	}{
+		{
+			name:       "weighting disabled, a single sample widens the schema",
+			config:     OperationGeneratorConfig{},
+			outliers:   1,
+			wantFormat: "",
+		},
+		{
+			name:               "established schema is kept",
+			config:             OperationGeneratorConfig{SchemaMergeMinEstablishedHits: 3},
+			outliers:           2,
+			wantFormat:         "uuid",
+			wantOutliersCounts: []int{2},
+		},
+		{
+			name:               "established schema is widened once outliers reach the ratio",
+			config:             OperationGeneratorConfig{SchemaMergeMinEstablishedHits: 3, SchemaMergeMinOutlierRatio: 0.5},
+			outliers:           2,
+			wantFormat:         "",
+			wantOutliersCounts: []int{2},
+		},
+		{
+			name:       "not established yet",
+			config:     OperationGeneratorConfig{SchemaMergeMinEstablishedHits: 10},
+			outliers:   1,
+			wantFormat: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := CreateDefaultSpec("host", "80", tt.config)
+			for i := 0; i < 3; i++ {
+				assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", uuidBody)))
+			}
+			for i := 0; i < tt.outliers; i++ {
+				assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", plainBody)))
+			}
+
+			schema := s.LearningSpec.GetPathItem("/api").Get.Responses.StatusCodeResponses[200].Schema
+			assert.Equal(t, schema.Properties["id"].Format, tt.wantFormat)
+
+			outliers := s.GetSchemaOutliers()
+			assert.Equal(t, len(outliers), len(tt.wantOutliersCounts))
+			for i, outlier := range outliers {
+				assert.Equal(t, outlier.Path, "/api")
+				assert.Equal(t, outlier.Method, http.MethodGet)
+				assert.Equal(t, outlier.Field, "responses.200.schema.properties.id")
+				assert.Equal(t, outlier.Count, tt.wantOutliersCounts[i])
+			}
+		})
+	}
+}
+
+func TestSpec_LearnTelemetry_SchemaMergeWeighting_TypeConflict(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", OperationGeneratorConfig{SchemaMergeMinEstablishedHits: 1})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id":1}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id":true,"name":"a"}`)))
+
+	schema := s.LearningSpec.GetPathItem("/api").Get.Responses.StatusCodeResponses[200].Schema
+	assert.DeepEqual(t, []string(schema.Properties["id"].Type), []string{schemaTypeInteger})
+	// the outlier telemetry is not learned
+	_, ok := schema.Properties["name"]
+	assert.Assert(t, !ok)
+
+	outliers := s.GetSchemaOutliers()
+	assert.Equal(t, len(outliers), 1)
+	assert.Equal(t, outliers[0].Field, "responses.200.schema.properties.id")
+	assert.Equal(t, outliers[0].Count, 1)
+}
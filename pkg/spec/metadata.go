@@ -0,0 +1,48 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+// Well known telemetry caller metadata keys.
+const (
+	MetadataKeyASN             = "asn"
+	MetadataKeyCountry         = "geo.country"
+	MetadataKeyCity            = "geo.city"
+	MetadataKeyServiceIdentity = "service.identity" // e.g. SPIFFE ID
+)
+
+// maximum distinct values kept per metadata key in the operation stats,
+// values seen after the limit was reached are not counted.
+const maxMetadataValuesPerKey = 100
+
+func (o *OperationStats) addMetadata(metadata map[string]string) {
+	if len(metadata) == 0 {
+		return
+	}
+	if o.Metadata == nil {
+		o.Metadata = make(map[string]map[string]int)
+	}
+	for key, value := range metadata {
+		values, ok := o.Metadata[key]
+		if !ok {
+			values = make(map[string]int)
+			o.Metadata[key] = values
+		}
+		if _, ok := values[value]; !ok && len(values) >= maxMetadataValuesPerKey {
+			continue
+		}
+		values[value]++
+	}
+}
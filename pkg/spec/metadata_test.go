@@ -0,0 +1,39 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"strconv"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestOperationStats_addMetadata(t *testing.T) {
+	opStats := &OperationStats{}
+	opStats.addMetadata(nil)
+	assert.Assert(t, opStats.Metadata == nil)
+
+	for i := 0; i < maxMetadataValuesPerKey+10; i++ {
+		opStats.addMetadata(map[string]string{MetadataKeyASN: strconv.Itoa(i), MetadataKeyCountry: "US"})
+	}
+	// values are not counted once the limit is reached, except for the ones already counted
+	opStats.addMetadata(map[string]string{MetadataKeyASN: "0"})
+
+	assert.Equal(t, len(opStats.Metadata[MetadataKeyASN]), maxMetadataValuesPerKey)
+	assert.Equal(t, opStats.Metadata[MetadataKeyASN]["0"], 2)
+	assert.Equal(t, opStats.Metadata[MetadataKeyCountry]["US"], maxMetadataValuesPerKey+10)
+}
@@ -0,0 +1,43 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+// NormalizeMethod returns the upper case form of method (e.g. "get" -> "GET"),
+// or an ErrUnsupportedMethod error if method is not one a spec path item can hold.
+func NormalizeMethod(method string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(method))
+	if !isSupportedMethod(normalized) {
+		return "", fmt.Errorf("%w: %q", errors.ErrUnsupportedMethod, method)
+	}
+
+	return normalized, nil
+}
+
+func isSupportedMethod(method string) bool {
+	for _, supportedMethod := range supportedMethods {
+		if method == supportedMethod {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+func TestNormalizeMethod(t *testing.T) {
+	tests := []struct {
+		method  string
+		want    string
+		wantErr bool
+	}{
+		{method: http.MethodGet, want: http.MethodGet},
+		{method: "get", want: http.MethodGet},
+		{method: " Patch ", want: http.MethodPatch},
+		{method: "CONNECT", wantErr: true},
+		{method: "G3T", wantErr: true},
+		{method: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			got, err := NormalizeMethod(tt.method)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeMethod() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, _errors.ErrUnsupportedMethod) {
+				t.Errorf("NormalizeMethod() error = %v, want ErrUnsupportedMethod", err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeMethod() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_LearnTelemetry_MethodCase(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "get", "/api", "host", "200", Data.ReqBody, Data.RespBody)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", Data.ReqBody, Data.RespBody)))
+	assert.Assert(t, s.LearnTelemetry(createTelemetry("req-id", "G3T", "/api", "host", "200", Data.ReqBody, Data.RespBody)) != nil)
+
+	assert.Assert(t, s.LearningSpec.GetPathItem("/api").Get != nil)
+	assert.Equal(t, len(s.LearningStats.Operations["/api"]), 1)
+	assert.Equal(t, s.LearningStats.Operations["/api"][http.MethodGet].HitCount, 2)
+}
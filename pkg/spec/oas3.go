@@ -0,0 +1,75 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/internal/apimodel"
+)
+
+// GenerateOAS3Json generates an OpenAPI 3.0 (json) of the approved spec, see ConvertToOAS3.
+func (s *Spec) GenerateOAS3Json(opts ...GenerateOASOption) ([]byte, error) {
+	return s.GenerateOAS3JsonCtx(context.Background(), opts...)
+}
+
+// GenerateOAS3JsonCtx is GenerateOAS3Json that stops once ctx is done, see GenerateOASJsonCtx.
+func (s *Spec) GenerateOAS3JsonCtx(ctx context.Context, opts ...GenerateOASOption) ([]byte, error) {
+	oasJSON, err := s.GenerateOASJsonCtx(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate json spec: %w", err)
+	}
+
+	return ConvertToOAS3(ctx, oasJSON)
+}
+
+func (s *Spec) GenerateOAS3Yaml(opts ...GenerateOASOption) ([]byte, error) {
+	oasJSON, err := s.GenerateOAS3Json(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate json spec: %w", err)
+	}
+
+	oasYaml, err := yaml.JSONToYAML(oasJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert json to yaml: %v", err)
+	}
+
+	return oasYaml, nil
+}
+
+// ConvertToOAS3 converts a swagger 2.0 spec (json), as generated by GenerateOASJson or GenerateLearningOAS,
+// into an OpenAPI 3.0 spec (json). The spec is converted through the version independent apimodel document,
+// and the converted spec is validated by kin-openapi.
+func ConvertToOAS3(ctx context.Context, swaggerJSON []byte) ([]byte, error) {
+	swagger := &oapi_spec.Swagger{}
+	if err := json.Unmarshal(swaggerJSON, swagger); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+
+	ret, err := apimodel.MarshalOAS3(ctx, apimodel.FromSwagger(swagger))
+	if err != nil {
+		log.Errorf("Failed to convert the spec. %v\n\nspec: %s", err, swaggerJSON)
+		return nil, fmt.Errorf("failed to convert the spec. %w", err)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,952 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+const (
+	oas31Version           = "3.1.0"
+	oas31JSONSchemaDialect = "https://spec.openapis.org/oas/3.1/dialect/base"
+	oas31SchemasRefPrefix  = "#/components/schemas/"
+)
+
+// component names must match ^[a-zA-Z0-9.\-_]+$
+var invalidComponentNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// swagger 2.0 simple schema (non body parameters, headers) fields that are converted into JSON schema keywords
+var simpleSchemaKeywords = []string{
+	"type", "format", "items", "default", "enum", "maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum",
+	"maxLength", "minLength", "pattern", "maxItems", "minItems", "uniqueItems", "multipleOf", "x-nullable",
+}
+
+type oas31Document struct {
+	OpenAPI           string                    `json:"openapi"`
+	JSONSchemaDialect string                    `json:"jsonSchemaDialect"`
+	Info              *oapi_spec.Info           `json:"info"`
+	Servers           []*oas31Server            `json:"servers,omitempty"`
+	Paths             map[string]*oas31PathItem `json:"paths"`
+	Components        *oas31Components          `json:"components,omitempty"`
+	Security          []map[string][]string     `json:"security,omitempty"`
+}
+
+type oas31Server struct {
+	URL string `json:"url"`
+}
+
+type oas31Components struct {
+	Schemas         map[string]oas31Schema          `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*oas31SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// oas31Schema is a JSON Schema 2020-12 schema.
+type oas31Schema map[string]interface{}
+
+type oas31PathItem struct {
+	Parameters []*oas31Parameter `json:"parameters,omitempty"`
+	Get        *oas31Operation   `json:"get,omitempty"`
+	Put        *oas31Operation   `json:"put,omitempty"`
+	Post       *oas31Operation   `json:"post,omitempty"`
+	Delete     *oas31Operation   `json:"delete,omitempty"`
+	Options    *oas31Operation   `json:"options,omitempty"`
+	Head       *oas31Operation   `json:"head,omitempty"`
+	Patch      *oas31Operation   `json:"patch,omitempty"`
+}
+
+type oas31Operation struct {
+	Tags        []string                  `json:"tags,omitempty"`
+	Summary     string                    `json:"summary,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	OperationID string                    `json:"operationId,omitempty"`
+	Parameters  []*oas31Parameter         `json:"parameters,omitempty"`
+	RequestBody *oas31RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*oas31Response `json:"responses"`
+	Deprecated  bool                      `json:"deprecated,omitempty"`
+	Security    []map[string][]string     `json:"security,omitempty"`
+	Extensions  map[string]interface{}    `json:"-"`
+}
+
+type oas31Parameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Style       string      `json:"style,omitempty"`
+	Explode     *bool       `json:"explode,omitempty"`
+	Schema      oas31Schema `json:"schema,omitempty"`
+}
+
+type oas31RequestBody struct {
+	Description string                     `json:"description,omitempty"`
+	Content     map[string]*oas31MediaType `json:"content"`
+	Required    bool                       `json:"required,omitempty"`
+}
+
+type oas31MediaType struct {
+	Schema   oas31Schema               `json:"schema,omitempty"`
+	Encoding map[string]*oas31Encoding `json:"encoding,omitempty"`
+}
+
+type oas31Encoding struct {
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type oas31Response struct {
+	Description string                     `json:"description"`
+	Headers     map[string]*oas31Header    `json:"headers,omitempty"`
+	Content     map[string]*oas31MediaType `json:"content,omitempty"`
+}
+
+type oas31Header struct {
+	Description string      `json:"description,omitempty"`
+	Schema      oas31Schema `json:"schema,omitempty"`
+}
+
+type oas31SecurityScheme struct {
+	Type        string           `json:"type"`
+	Description string           `json:"description,omitempty"`
+	Name        string           `json:"name,omitempty"`
+	In          string           `json:"in,omitempty"`
+	Scheme      string           `json:"scheme,omitempty"`
+	Flows       *oas31OAuthFlows `json:"flows,omitempty"`
+}
+
+type oas31OAuthFlows struct {
+	Implicit          *oas31OAuthFlow `json:"implicit,omitempty"`
+	Password          *oas31OAuthFlow `json:"password,omitempty"`
+	ClientCredentials *oas31OAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *oas31OAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+type oas31OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// MarshalJSON inlines the operation vendor extensions, like go-openapi does.
+func (o *oas31Operation) MarshalJSON() ([]byte, error) {
+	type operation oas31Operation
+	b, err := json.Marshal((*operation)(o))
+	if err != nil || len(o.Extensions) == 0 {
+		return b, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range o.Extensions {
+		fields[key] = value
+	}
+	return json.Marshal(fields)
+}
+
+// GenerateOAS31Json generates an OpenAPI 3.1 (json) of the approved spec,
+// with the learned schemas emitted as JSON Schema 2020-12.
+func (s *Spec) GenerateOAS31Json(opts ...GenerateOASOption) ([]byte, error) {
+	oasJSON, err := s.GenerateOASJson(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate json spec: %w", err)
+	}
+
+	return ConvertToOAS31(oasJSON)
+}
+
+func (s *Spec) GenerateOAS31Yaml(opts ...GenerateOASOption) ([]byte, error) {
+	oasJSON, err := s.GenerateOAS31Json(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate json spec: %w", err)
+	}
+
+	oasYaml, err := yaml.JSONToYAML(oasJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert json to yaml: %v", err)
+	}
+
+	return oasYaml, nil
+}
+
+// ConvertToOAS31 converts a swagger 2.0 spec (json), as generated by GenerateOASJson or GenerateLearningOAS,
+// into an OpenAPI 3.1 spec (json).
+// go-openapi doesn't support 3.1, so the converted spec is validated structurally (refs, path params, responses).
+func ConvertToOAS31(swaggerJSON []byte) ([]byte, error) {
+	swagger := &oapi_spec.Swagger{}
+	if err := json.Unmarshal(swaggerJSON, swagger); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+
+	doc, err := convertSwaggerToOAS31(swagger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert spec: %w", err)
+	}
+
+	ret, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the spec. %v", err)
+	}
+	if err := validateOAS31(doc, ret); err != nil {
+		log.Errorf("Failed to validate the spec. %v\n\nspec: %s", err, ret)
+		return nil, fmt.Errorf("failed to validate the spec. %w", err)
+	}
+
+	return ret, nil
+}
+
+func convertSwaggerToOAS31(swagger *oapi_spec.Swagger) (*oas31Document, error) {
+	converter := &oas31Converter{
+		swagger:     swagger,
+		schemaNames: createComponentSchemaNames(swagger.Definitions),
+	}
+
+	doc := &oas31Document{
+		OpenAPI:           oas31Version,
+		JSONSchemaDialect: oas31JSONSchemaDialect,
+		Info:              swagger.Info,
+		Servers:           createOAS31Servers(swagger),
+		Paths:             map[string]*oas31PathItem{},
+		Security:          swagger.Security,
+	}
+
+	components := &oas31Components{}
+	for name, definition := range swagger.Definitions {
+		schema, err := converter.toSchema(definition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert definition %v: %w", name, err)
+		}
+		if components.Schemas == nil {
+			components.Schemas = make(map[string]oas31Schema)
+		}
+		components.Schemas[converter.schemaNames[name]] = schema
+	}
+	for name, securityScheme := range swagger.SecurityDefinitions {
+		if components.SecuritySchemes == nil {
+			components.SecuritySchemes = make(map[string]*oas31SecurityScheme)
+		}
+		components.SecuritySchemes[name] = convertSecurityScheme(securityScheme)
+	}
+	if components.Schemas != nil || components.SecuritySchemes != nil {
+		doc.Components = components
+	}
+
+	if swagger.Paths == nil {
+		return doc, nil
+	}
+	for path, pathItem := range swagger.Paths.Paths {
+		convertedPathItem, err := converter.convertPathItem(pathItem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert path %v: %w", path, err)
+		}
+		doc.Paths[path] = convertedPathItem
+	}
+
+	return doc, nil
+}
+
+// createComponentSchemaNames maps the definition names into valid and unique component names.
+func createComponentSchemaNames(definitions oapi_spec.Definitions) map[string]string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := make(map[string]string)
+	used := make(map[string]bool)
+	for _, name := range names {
+		componentName := invalidComponentNameChars.ReplaceAllString(name, "_")
+		if used[componentName] {
+			counter := 0
+			for used[fmt.Sprintf("%s_%d", componentName, counter)] {
+				counter++
+			}
+			componentName = fmt.Sprintf("%s_%d", componentName, counter)
+		}
+		used[componentName] = true
+		ret[name] = componentName
+	}
+
+	return ret
+}
+
+func createOAS31Servers(swagger *oapi_spec.Swagger) []*oas31Server {
+	if swagger.Host == "" {
+		return nil
+	}
+	if len(swagger.Schemes) == 0 {
+		// the scheme is unknown, use a scheme relative url
+		return []*oas31Server{{URL: "//" + swagger.Host + swagger.BasePath}}
+	}
+
+	var servers []*oas31Server
+	for _, scheme := range swagger.Schemes {
+		servers = append(servers, &oas31Server{URL: scheme + "://" + swagger.Host + swagger.BasePath})
+	}
+	return servers
+}
+
+func convertSecurityScheme(securityScheme *oapi_spec.SecurityScheme) *oas31SecurityScheme {
+	ret := &oas31SecurityScheme{
+		Type:        securityScheme.Type,
+		Description: securityScheme.Description,
+	}
+
+	switch securityScheme.Type {
+	case "basic":
+		ret.Type = "http"
+		ret.Scheme = "basic"
+	case "apiKey":
+		ret.Name = securityScheme.Name
+		ret.In = securityScheme.In
+	case "oauth2":
+		flow := &oas31OAuthFlow{
+			AuthorizationURL: securityScheme.AuthorizationURL,
+			TokenURL:         securityScheme.TokenURL,
+			Scopes:           securityScheme.Scopes,
+		}
+		if flow.Scopes == nil {
+			flow.Scopes = map[string]string{}
+		}
+		ret.Flows = &oas31OAuthFlows{}
+		switch securityScheme.Flow {
+		case "implicit":
+			ret.Flows.Implicit = flow
+		case "password":
+			ret.Flows.Password = flow
+		case "application":
+			ret.Flows.ClientCredentials = flow
+		case "accessCode":
+			ret.Flows.AuthorizationCode = flow
+		}
+	}
+
+	return ret
+}
+
+type oas31Converter struct {
+	swagger *oapi_spec.Swagger
+	// definition name -> component schema name
+	schemaNames map[string]string
+}
+
+func (c *oas31Converter) convertPathItem(pathItem oapi_spec.PathItem) (*oas31PathItem, error) {
+	ret := &oas31PathItem{}
+
+	var err error
+	for i := range pathItem.Parameters {
+		param := &pathItem.Parameters[i]
+		if param.In == parametersInBody || param.In == parametersInForm {
+			// only operations can have a request body in 3.x
+			log.Warnf("Ignoring path item %v parameter %v", param.In, param.Name)
+			continue
+		}
+		convertedParam, err := c.convertParameter(param)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert parameter %v: %w", param.Name, err)
+		}
+		ret.Parameters = append(ret.Parameters, convertedParam)
+	}
+
+	for _, method := range supportedMethods {
+		operation := GetOperationFromPathItem(&pathItem, method)
+		if operation == nil {
+			continue
+		}
+		var convertedOp *oas31Operation
+		if convertedOp, err = c.convertOperation(operation); err != nil {
+			return nil, fmt.Errorf("failed to convert %v operation: %w", method, err)
+		}
+		ret.setOperation(method, convertedOp)
+	}
+
+	return ret, nil
+}
+
+func (p *oas31PathItem) setOperation(method string, operation *oas31Operation) {
+	switch method {
+	case http.MethodGet:
+		p.Get = operation
+	case http.MethodDelete:
+		p.Delete = operation
+	case http.MethodOptions:
+		p.Options = operation
+	case http.MethodPatch:
+		p.Patch = operation
+	case http.MethodHead:
+		p.Head = operation
+	case http.MethodPost:
+		p.Post = operation
+	case http.MethodPut:
+		p.Put = operation
+	}
+}
+
+func (c *oas31Converter) convertOperation(operation *oapi_spec.Operation) (*oas31Operation, error) {
+	ret := &oas31Operation{
+		Tags:        operation.Tags,
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		OperationID: operation.ID,
+		Responses:   map[string]*oas31Response{},
+		Deprecated:  operation.Deprecated,
+		Security:    operation.Security,
+		Extensions:  operation.Extensions,
+	}
+
+	var bodyParam *oapi_spec.Parameter
+	var formParams []*oapi_spec.Parameter
+	for i := range operation.Parameters {
+		param := &operation.Parameters[i]
+		switch param.In {
+		case parametersInBody:
+			bodyParam = param
+		case parametersInForm:
+			formParams = append(formParams, param)
+		default:
+			cookieParams, ok, err := getCookieParams(param)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get cookie parameters: %w", err)
+			}
+			if ok {
+				for i := range cookieParams {
+					convertedParam, err := c.convertParameter(&cookieParams[i])
+					if err != nil {
+						return nil, fmt.Errorf("failed to convert cookie parameter %v: %w", cookieParams[i].Name, err)
+					}
+					ret.Parameters = append(ret.Parameters, convertedParam)
+				}
+				continue
+			}
+			convertedParam, err := c.convertParameter(param)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert parameter %v: %w", param.Name, err)
+			}
+			ret.Parameters = append(ret.Parameters, convertedParam)
+		}
+	}
+
+	requestBody, err := c.createRequestBody(c.getConsumes(operation), bodyParam, formParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request body: %w", err)
+	}
+	ret.RequestBody = requestBody
+
+	if operation.Responses != nil {
+		produces := c.getProduces(operation)
+		if operation.Responses.Default != nil {
+			if ret.Responses["default"], err = c.convertResponse(operation.Responses.Default, produces); err != nil {
+				return nil, fmt.Errorf("failed to convert default response: %w", err)
+			}
+		}
+		for statusCode := range operation.Responses.StatusCodeResponses {
+			response := operation.Responses.StatusCodeResponses[statusCode]
+			if ret.Responses[strconv.Itoa(statusCode)], err = c.convertResponse(&response, produces); err != nil {
+				return nil, fmt.Errorf("failed to convert %v response: %w", statusCode, err)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+func (c *oas31Converter) getConsumes(operation *oapi_spec.Operation) []string {
+	if len(operation.Consumes) > 0 {
+		return operation.Consumes
+	}
+	return c.swagger.Consumes
+}
+
+func (c *oas31Converter) getProduces(operation *oapi_spec.Operation) []string {
+	if len(operation.Produces) > 0 {
+		return operation.Produces
+	}
+	if len(c.swagger.Produces) > 0 {
+		return c.swagger.Produces
+	}
+	return []string{mediaTypeApplicationJSON}
+}
+
+func (c *oas31Converter) createRequestBody(consumes []string, bodyParam *oapi_spec.Parameter, formParams []*oapi_spec.Parameter) (*oas31RequestBody, error) {
+	if bodyParam == nil && len(formParams) == 0 {
+		return nil, nil
+	}
+
+	var bodySchema, formSchema oas31Schema
+	var formEncoding map[string]*oas31Encoding
+	var err error
+	ret := &oas31RequestBody{
+		Content: map[string]*oas31MediaType{},
+	}
+	if bodyParam != nil {
+		ret.Description = bodyParam.Description
+		ret.Required = bodyParam.Required
+		if bodySchema, err = c.toSchema(bodyParam.Schema); err != nil {
+			return nil, fmt.Errorf("failed to convert body schema: %w", err)
+		}
+	}
+	hasFileParam := false
+	if len(formParams) > 0 {
+		if formSchema, formEncoding, hasFileParam, err = c.createFormSchema(formParams); err != nil {
+			return nil, fmt.Errorf("failed to create form schema: %w", err)
+		}
+		for _, param := range formParams {
+			ret.Required = ret.Required || param.Required
+		}
+	}
+
+	if len(consumes) == 0 {
+		if bodySchema != nil {
+			consumes = append(consumes, mediaTypeApplicationJSON)
+		}
+		if formSchema != nil {
+			if hasFileParam {
+				consumes = append(consumes, mediaTypeMultipartFormData)
+			} else {
+				consumes = append(consumes, mediaTypeApplicationForm)
+			}
+		}
+	}
+	for _, mediaType := range consumes {
+		isFormMediaType := mediaType == mediaTypeApplicationForm || mediaType == mediaTypeMultipartFormData
+		if isFormMediaType && formSchema != nil {
+			ret.Content[mediaType] = &oas31MediaType{Schema: formSchema}
+			if mediaType == mediaTypeMultipartFormData {
+				ret.Content[mediaType].Encoding = formEncoding
+			}
+		} else if !isFormMediaType && bodySchema != nil {
+			ret.Content[mediaType] = &oas31MediaType{Schema: bodySchema}
+		}
+	}
+
+	return ret, nil
+}
+
+// createFormSchema creates an object schema with a property per formData param, and the encoding of the params
+// with a multipart part Content-Type (see formDataContentTypeExtensionName).
+func (c *oas31Converter) createFormSchema(formParams []*oapi_spec.Parameter) (oas31Schema, map[string]*oas31Encoding, bool, error) {
+	properties := map[string]interface{}{}
+	var required []interface{}
+	var encoding map[string]*oas31Encoding
+	hasFileParam := false
+	for _, param := range formParams {
+		var schema oas31Schema
+		contentType, _ := param.Extensions.GetString(formDataContentTypeExtensionName)
+		if param.Type == parameterTypeFile {
+			hasFileParam = true
+			fileContentType := contentType
+			if fileContentType == "" {
+				fileContentType = mediaTypeOctetStream
+			}
+			schema = oas31Schema{"type": schemaTypeString, "format": formatBinary, "contentMediaType": fileContentType}
+		} else {
+			var err error
+			if schema, err = c.simpleSchemaToSchema(param); err != nil {
+				return nil, nil, false, fmt.Errorf("failed to convert parameter %v: %w", param.Name, err)
+			}
+		}
+		properties[param.Name] = map[string]interface{}(schema)
+		if param.Required {
+			required = append(required, param.Name)
+		}
+		if contentType != "" {
+			if encoding == nil {
+				encoding = make(map[string]*oas31Encoding)
+			}
+			encoding[param.Name] = &oas31Encoding{ContentType: contentType}
+		}
+	}
+
+	ret := oas31Schema{"type": schemaTypeObject, "properties": properties}
+	if len(required) > 0 {
+		ret["required"] = required
+	}
+	return ret, encoding, hasFileParam, nil
+}
+
+func (c *oas31Converter) convertParameter(param *oapi_spec.Parameter) (*oas31Parameter, error) {
+	ret := &oas31Parameter{
+		Name:        param.Name,
+		In:          param.In,
+		Description: param.Description,
+		Required:    param.Required,
+	}
+
+	var err error
+	if ret.Schema, err = c.simpleSchemaToSchema(param); err != nil {
+		return nil, err
+	}
+	if param.Type == schemaTypeArray {
+		ret.Style, ret.Explode = getParameterStyle(param.In, param.CollectionFormat)
+	}
+
+	return ret, nil
+}
+
+// getParameterStyle returns the 3.x serialization style of an array parameter in its swagger 2.0 collectionFormat.
+func getParameterStyle(in, collectionFormat string) (string, *bool) {
+	explode := false
+	switch collectionFormat {
+	case collectionFormatMulti:
+		// form style with explode is the default of query params
+		return "", nil
+	case collectionFormatSpace:
+		return "spaceDelimited", &explode
+	case collectionFormatPipe:
+		return "pipeDelimited", &explode
+	case collectionFormatTab:
+		log.Warnf("Collection format %v is not supported by OpenAPI 3.x, using %v", collectionFormatTab, collectionFormatComma)
+	}
+
+	// csv (the swagger 2.0 default)
+	if in == parametersInQuery {
+		return "form", &explode
+	}
+	// simple style without explode is the default of path and header params
+	return "", nil
+}
+
+func (c *oas31Converter) convertResponse(response *oapi_spec.Response, produces []string) (*oas31Response, error) {
+	ret := &oas31Response{
+		Description: response.Description,
+	}
+
+	for name := range response.Headers {
+		header := response.Headers[name]
+		schema, err := c.simpleSchemaToSchema(&header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert header %v: %w", name, err)
+		}
+		if ret.Headers == nil {
+			ret.Headers = make(map[string]*oas31Header)
+		}
+		ret.Headers[name] = &oas31Header{
+			Description: header.Description,
+			Schema:      schema,
+		}
+	}
+
+	if response.Schema != nil {
+		schema, err := c.toSchema(response.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert schema: %w", err)
+		}
+		ret.Content = make(map[string]*oas31MediaType)
+		for _, mediaType := range produces {
+			ret.Content[mediaType] = &oas31MediaType{Schema: schema}
+		}
+	}
+
+	return ret, nil
+}
+
+// simpleSchemaToSchema converts the simple schema of a swagger 2.0 non body parameter or header into a JSON schema.
+func (c *oas31Converter) simpleSchemaToSchema(simpleSchema interface{}) (oas31Schema, error) {
+	fields, err := toJSONObject(simpleSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := oas31Schema{}
+	for _, keyword := range simpleSchemaKeywords {
+		if value, ok := fields[keyword]; ok {
+			schema[keyword] = value
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		// items of a simple schema are simple schemas too
+		itemsSchema, err := c.simpleSchemaToSchema(items)
+		if err != nil {
+			return nil, err
+		}
+		schema["items"] = map[string]interface{}(itemsSchema)
+	}
+
+	c.convertSchemaKeywords(schema)
+	return schema, nil
+}
+
+// toSchema converts a swagger 2.0 schema into a JSON schema.
+func (c *oas31Converter) toSchema(swaggerSchema interface{}) (oas31Schema, error) {
+	fields, err := toJSONObject(swaggerSchema)
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, nil
+	}
+
+	schema := oas31Schema(fields)
+	c.convertSchemaKeywords(schema)
+	return schema, nil
+}
+
+// convertSchemaKeywords converts the swagger 2.0 keywords of schema and its sub schemas to JSON Schema 2020-12.
+func (c *oas31Converter) convertSchemaKeywords(schema map[string]interface{}) {
+	if ref, ok := schema["$ref"].(string); ok && strings.HasPrefix(ref, definitionsRefPrefix) {
+		name := strings.TrimPrefix(ref, definitionsRefPrefix)
+		// refs are marshalled escaped
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		if componentName, ok := c.schemaNames[name]; ok {
+			name = componentName
+		}
+		schema["$ref"] = oas31SchemasRefPrefix + name
+	}
+
+	if example, ok := schema["example"]; ok {
+		schema["examples"] = []interface{}{example}
+		delete(schema, "example")
+	}
+
+	if nullable, ok := schema["x-nullable"].(bool); ok {
+		if nullable {
+			addNullType(schema)
+		}
+		delete(schema, "x-nullable")
+	}
+
+	convertExclusiveBound(schema, "exclusiveMaximum", "maximum")
+	convertExclusiveBound(schema, "exclusiveMinimum", "minimum")
+
+	if discriminator, ok := schema["discriminator"].(string); ok {
+		schema["discriminator"] = map[string]interface{}{"propertyName": discriminator}
+	}
+
+	// body variants, see convertBodyVariantsToExtension
+	if variants, ok := schema[schemaVariantsExtensionName]; ok {
+		schema["anyOf"] = variants
+		delete(schema, schemaVariantsExtensionName)
+	}
+
+	// sub schemas
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, property := range properties {
+			c.convertSubSchema(property)
+		}
+	}
+	switch items := schema["items"].(type) {
+	case map[string]interface{}:
+		c.convertSchemaKeywords(items)
+	case []interface{}:
+		// tuple validation
+		for _, item := range items {
+			c.convertSubSchema(item)
+		}
+		schema["prefixItems"] = items
+		delete(schema, "items")
+	}
+	c.convertSubSchema(schema["additionalProperties"])
+	c.convertSubSchema(schema["not"])
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		if subSchemas, ok := schema[keyword].([]interface{}); ok {
+			for _, subSchema := range subSchemas {
+				c.convertSubSchema(subSchema)
+			}
+		}
+	}
+}
+
+func (c *oas31Converter) convertSubSchema(subSchema interface{}) {
+	if schema, ok := subSchema.(map[string]interface{}); ok {
+		c.convertSchemaKeywords(schema)
+	}
+}
+
+// addNullType adds "null" to the schema type, a single type becomes a type array.
+func addNullType(schema map[string]interface{}) {
+	const typeNull = "null"
+
+	switch schemaType := schema["type"].(type) {
+	case string:
+		if schemaType != typeNull {
+			schema["type"] = []interface{}{schemaType, typeNull}
+		}
+	case []interface{}:
+		for _, t := range schemaType {
+			if t == typeNull {
+				return
+			}
+		}
+		schema["type"] = append(schemaType, typeNull)
+	}
+}
+
+// convertExclusiveBound converts a swagger 2.0 boolean exclusive bound into the JSON Schema 2020-12 numeric one.
+func convertExclusiveBound(schema map[string]interface{}, exclusiveKeyword, boundKeyword string) {
+	exclusive, ok := schema[exclusiveKeyword].(bool)
+	if !ok {
+		return
+	}
+	delete(schema, exclusiveKeyword)
+	if bound, ok := schema[boundKeyword]; ok && exclusive {
+		schema[exclusiveKeyword] = bound
+		delete(schema, boundKeyword)
+	}
+}
+
+func toJSONObject(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal: %v", err)
+	}
+
+	var ret map[string]interface{}
+	if err := json.Unmarshal(b, &ret); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal: %v", err)
+	}
+	return ret, nil
+}
+
+var pathTemplateParam = regexp.MustCompile(`{([^}/]+)}`)
+
+// validateOAS31 validates the structure of doc (and its json, docJSON) against OpenAPI 3.1.
+func validateOAS31(doc *oas31Document, docJSON []byte) error {
+	var errs []string
+
+	if doc.Info == nil || doc.Info.Title == "" || doc.Info.Version == "" {
+		errs = append(errs, "info title and version are required")
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Sprintf("path %v must begin with a slash", path))
+		}
+		errs = append(errs, doc.Paths[path].validate(path)...)
+	}
+
+	var schemaNames map[string]oas31Schema
+	if doc.Components != nil {
+		schemaNames = doc.Components.Schemas
+	}
+	for name := range schemaNames {
+		if invalidComponentNameChars.MatchString(name) {
+			errs = append(errs, fmt.Sprintf("invalid component schema name %q", name))
+		}
+	}
+
+	var rawDoc interface{}
+	if err := json.Unmarshal(docJSON, &rawDoc); err != nil {
+		return fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+	forEachRef(rawDoc, func(ref string) {
+		name := strings.TrimPrefix(ref, oas31SchemasRefPrefix)
+		if _, ok := schemaNames[name]; !ok || name == ref {
+			errs = append(errs, fmt.Sprintf("unresolved ref %v", ref))
+		}
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("spec validation failed. %v. %w", strings.Join(errs, ", "), errors.ErrSpecValidation)
+	}
+	return nil
+}
+
+func (p *oas31PathItem) validate(path string) []string {
+	var errs []string
+
+	templateParams := map[string]bool{}
+	for _, match := range pathTemplateParam.FindAllStringSubmatch(path, -1) {
+		templateParams[match[1]] = true
+	}
+
+	for _, method := range supportedMethods {
+		operation := p.getOperation(method)
+		if operation == nil {
+			continue
+		}
+		if len(operation.Responses) == 0 {
+			errs = append(errs, fmt.Sprintf("%v %v has no responses", method, path))
+		}
+
+		pathParams := map[string]bool{}
+		for _, param := range append(append([]*oas31Parameter{}, p.Parameters...), operation.Parameters...) {
+			if param.In != parametersInPath {
+				continue
+			}
+			if !templateParams[param.Name] {
+				errs = append(errs, fmt.Sprintf("%v %v path param %v is not in the path", method, path, param.Name))
+			}
+			if !param.Required {
+				errs = append(errs, fmt.Sprintf("%v %v path param %v must be required", method, path, param.Name))
+			}
+			pathParams[param.Name] = true
+		}
+		for name := range templateParams {
+			if !pathParams[name] {
+				errs = append(errs, fmt.Sprintf("%v %v path param %v is not defined", method, path, name))
+			}
+		}
+	}
+
+	return errs
+}
+
+func (p *oas31PathItem) getOperation(method string) *oas31Operation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodDelete:
+		return p.Delete
+	case http.MethodOptions:
+		return p.Options
+	case http.MethodPatch:
+		return p.Patch
+	case http.MethodHead:
+		return p.Head
+	case http.MethodPost:
+		return p.Post
+	case http.MethodPut:
+		return p.Put
+	}
+	return nil
+}
+
+func forEachRef(v interface{}, f func(ref string)) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if ref, ok := field.(string); ok && key == "$ref" {
+				f(ref)
+				continue
+			}
+			forEachRef(field, f)
+		}
+	case []interface{}:
+		for _, item := range value {
+			forEachRef(item, f)
+		}
+	}
+}
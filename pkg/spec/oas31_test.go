@@ -0,0 +1,201 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+const testSwaggerForOAS31 = `{
+  "swagger": "2.0",
+  "info": {"title": "Swagger", "version": "1.0.0"},
+  "host": "host:80",
+  "paths": {
+    "/api/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "type": "integer", "format": "int64"}],
+      "post": {
+        "consumes": ["application/json"],
+        "produces": ["application/json"],
+        "x-hits": 2,
+        "security": [{"BasicAuth": []}],
+        "parameters": [
+          {"name": "body", "in": "body", "required": true, "schema": {"$ref": "#/definitions/a b"}},
+          {"name": "ids", "in": "query", "type": "array", "items": {"type": "string"}, "collectionFormat": "csv"},
+          {"name": "x-tag", "in": "header", "type": "string", "x-nullable": true}
+        ],
+        "responses": {
+          "200": {
+            "description": "",
+            "schema": {"type": "object", "properties": {"n": {"type": "number", "maximum": 5, "exclusiveMaximum": true, "example": 4}}},
+            "headers": {"x-rate": {"type": "integer"}}
+          },
+          "default": {"description": "Default Response"}
+        }
+      }
+    },
+    "/upload": {
+      "put": {
+        "consumes": ["multipart/form-data"],
+        "parameters": [
+          {"name": "file", "in": "formData", "type": "file", "required": true},
+          {"name": "avatar", "in": "formData", "type": "file", "x-content-type": "image/png"},
+          {"name": "name", "in": "formData", "type": "string"}
+        ],
+        "responses": {"204": {"description": ""}}
+      }
+    }
+  },
+  "definitions": {
+    "a b": {"type": "object", "properties": {"id": {"type": "string", "x-nullable": true}}}
+  },
+  "securityDefinitions": {"BasicAuth": {"type": "basic"}}
+}`
+
+func TestConvertToOAS31(t *testing.T) {
+	oasJSON, err := ConvertToOAS31([]byte(testSwaggerForOAS31))
+	assert.NilError(t, err)
+
+	var doc map[string]interface{}
+	assert.NilError(t, json.Unmarshal(oasJSON, &doc))
+	get := func(path ...interface{}) interface{} {
+		var v interface{} = doc
+		for _, key := range path {
+			switch k := key.(type) {
+			case string:
+				v = v.(map[string]interface{})[k]
+			case int:
+				v = v.([]interface{})[k]
+			}
+		}
+		return v
+	}
+
+	assert.Equal(t, get("openapi"), oas31Version)
+	assert.Equal(t, get("jsonSchemaDialect"), oas31JSONSchemaDialect)
+	assert.Equal(t, get("servers", 0, "url"), "//host:80")
+	assert.DeepEqual(t, get("components", "securitySchemes", "BasicAuth"), map[string]interface{}{"type": "http", "scheme": "basic"})
+	// definitions are renamed into valid component names, and type arrays replace x-nullable
+	assert.DeepEqual(t, get("components", "schemas", "a_b", "properties", "id", "type"), []interface{}{"string", "null"})
+
+	post := []interface{}{"paths", "/api/{id}", "post"}
+	assert.Equal(t, get("paths", "/api/{id}", "parameters", 0, "schema", "type"), "integer")
+	assert.Equal(t, get(append(post, "x-hits")...), float64(2))
+	assert.Equal(t, get(append(post, "requestBody", "required")...), true)
+	assert.Equal(t, get(append(post, "requestBody", "content", "application/json", "schema", "$ref")...), "#/components/schemas/a_b")
+	assert.Equal(t, get(append(post, "parameters", 0, "style")...), "form")
+	assert.Equal(t, get(append(post, "parameters", 0, "explode")...), false)
+	assert.DeepEqual(t, get(append(post, "parameters", 1, "schema", "type")...), []interface{}{"string", "null"})
+
+	responseSchema := append(post, "responses", "200", "content", "application/json", "schema", "properties", "n")
+	assert.DeepEqual(t, get(responseSchema...), map[string]interface{}{"type": "number", "exclusiveMaximum": float64(5), "examples": []interface{}{float64(4)}})
+	assert.Equal(t, get(append(post, "responses", "200", "headers", "x-rate", "schema", "type")...), "integer")
+	assert.Equal(t, get(append(post, "responses", "default", "description")...), "Default Response")
+
+	upload := []interface{}{"paths", "/upload", "put", "requestBody", "content", "multipart/form-data", "schema"}
+	assert.DeepEqual(t, get(append(upload, "required")...), []interface{}{"file"})
+	assert.Equal(t, get(append(upload, "properties", "file", "contentMediaType")...), "application/octet-stream")
+	assert.Equal(t, get(append(upload, "properties", "file", "format")...), "binary")
+	assert.Equal(t, get(append(upload, "properties", "avatar", "contentMediaType")...), "image/png")
+	uploadEncoding := []interface{}{"paths", "/upload", "put", "requestBody", "content", "multipart/form-data", "encoding"}
+	assert.DeepEqual(t, get(uploadEncoding...), map[string]interface{}{"avatar": map[string]interface{}{"contentType": "image/png"}})
+	assert.Equal(t, get(append(upload, "properties", "name", "type")...), "string")
+}
+
+func TestValidateOAS31(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *oas31Document
+	}{
+		{
+			name: "unresolved ref",
+			doc: &oas31Document{
+				Info: createDefaultSwaggerInfo(),
+				Paths: map[string]*oas31PathItem{
+					"/api": {Get: &oas31Operation{Responses: map[string]*oas31Response{
+						"200": {Content: map[string]*oas31MediaType{"application/json": {Schema: oas31Schema{"$ref": "#/components/schemas/missing"}}}},
+					}}},
+				},
+			},
+		},
+		{
+			name: "undefined path param",
+			doc: &oas31Document{
+				Info: createDefaultSwaggerInfo(),
+				Paths: map[string]*oas31PathItem{
+					"/api/{id}": {Get: &oas31Operation{Responses: map[string]*oas31Response{"200": {}}}},
+				},
+			},
+		},
+		{
+			name: "no responses",
+			doc: &oas31Document{
+				Info: createDefaultSwaggerInfo(),
+				Paths: map[string]*oas31PathItem{
+					"/api": {Get: &oas31Operation{Responses: map[string]*oas31Response{}}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docJSON, err := json.Marshal(tt.doc)
+			assert.NilError(t, err)
+			if err := validateOAS31(tt.doc, docJSON); !errors.Is(err, _errors.ErrSpecValidation) {
+				t.Errorf("validateOAS31() error = %v, want ErrSpecValidation", err)
+			}
+		})
+	}
+}
+
+func TestSpec_GenerateOAS31Json(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api/1", "host", "200", Data.ReqBody, Data.RespBody)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api/2", "host", "200", Data.ReqBody, Data.RespBody)))
+
+	suggestedReview := s.CreateSuggestedReview()
+	approvedReview := &ApprovedSpecReview{
+		PathToPathItem: suggestedReview.PathToPathItem,
+	}
+	for _, item := range suggestedReview.PathItemsReview {
+		approvedReview.PathItemsReview = append(approvedReview.PathItemsReview, &ApprovedSpecReviewPathItem{
+			ReviewPathItem: item.ReviewPathItem,
+			PathUUID:       "1",
+		})
+	}
+	assert.NilError(t, s.ApplyApprovedReview(approvedReview))
+
+	oasJSON, err := s.GenerateOAS31Json()
+	assert.NilError(t, err)
+	var doc struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components map[string]map[string]interface{} `json:"components"`
+	}
+	assert.NilError(t, json.Unmarshal(oasJSON, &doc))
+	assert.Equal(t, doc.OpenAPI, oas31Version)
+	assert.Assert(t, doc.Paths["/api/{param1}"]["post"] != nil)
+	assert.Assert(t, len(doc.Components["schemas"]) > 0)
+
+	_, err = s.GenerateOAS31Yaml()
+	assert.NilError(t, err)
+}
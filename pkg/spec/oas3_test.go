@@ -0,0 +1,111 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/internal/apimodel"
+)
+
+func createLearnedSpecForOAS3(t *testing.T) *Spec {
+	t.Helper()
+	s := CreateDefaultSpec("host", "80", OperationGeneratorConfig{})
+	assert.NilError(t, s.LearnTelemetry(&Telemetry{
+		RequestID: "req-id",
+		Scheme:    "http",
+		Request: &Request{
+			Method: http.MethodPost,
+			Path:   "/users/1?limit=1",
+			Host:   "host",
+			Common: &Common{
+				Version: "1",
+				Headers: []*Header{{Key: contentTypeHeaderName, Value: mediaTypeApplicationJSON}, {Key: "X-Request", Value: "a"}},
+				Body:    []byte(`{"name": "alice", "address": {"city": "a"}}`),
+			},
+		},
+		Response: &Response{
+			StatusCode: "200",
+			Common: &Common{
+				Version: "1",
+				Headers: []*Header{{Key: contentTypeHeaderName, Value: mediaTypeApplicationJSON}},
+				Body:    []byte(`{"id": 1, "tags": ["a"]}`),
+			},
+		},
+	}))
+	assert.NilError(t, s.ApprovePaths([]string{"/users/1"}))
+	return s
+}
+
+func TestApimodel_LearnedSpec(t *testing.T) {
+	generated, err := createLearnedSpecForOAS3(t).GenerateOASJson()
+	assert.NilError(t, err)
+	swagger := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(generated, swagger))
+
+	converted, warnings := apimodel.ToSwagger(apimodel.FromSwagger(swagger))
+	assert.Equal(t, len(warnings), 0)
+	assertEqualJSON(t, converted.Paths, swagger.Paths)
+	assertEqualJSON(t, converted.Definitions, swagger.Definitions)
+	assertEqualJSON(t, converted.Info.Extensions, swagger.Info.Extensions)
+}
+
+func TestSpec_GenerateOAS3Json(t *testing.T) {
+	oas3JSON, err := createLearnedSpecForOAS3(t).GenerateOAS3Json()
+	assert.NilError(t, err)
+
+	var doc map[string]interface{}
+	assert.NilError(t, json.Unmarshal(oas3JSON, &doc))
+	assert.Equal(t, doc["openapi"], "3.0.3")
+	assert.DeepEqual(t, doc["servers"], []interface{}{map[string]interface{}{"url": "http://host:80"}})
+	post := doc["paths"].(map[string]interface{})["/users/{param1}"].(map[string]interface{})["post"].(map[string]interface{})
+	content := post["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+	assert.Assert(t, content[mediaTypeApplicationJSON] != nil, "missing json request body in %s", oas3JSON)
+	response := post["responses"].(map[string]interface{})["200"].(map[string]interface{})
+	assert.Assert(t, response["content"].(map[string]interface{})[mediaTypeApplicationJSON] != nil)
+
+	_, err = createLearnedSpecForOAS3(t).GenerateOAS3Yaml()
+	assert.NilError(t, err)
+}
+
+func TestConvertToOAS3_Invalid(t *testing.T) {
+	// a path param that is not declared
+	swaggerJSON := `{
+  "swagger": "2.0",
+  "info": {"title": "Swagger", "version": "1.0.0"},
+  "paths": {"/api/{id}": {"get": {"responses": {"200": {"description": "ok"}}}}}
+}`
+	_, err := ConvertToOAS3(context.Background(), []byte(swaggerJSON))
+	assert.ErrorContains(t, err, "failed to convert the spec")
+
+	_, err = ConvertToOAS3(context.Background(), []byte("{"))
+	assert.ErrorContains(t, err, "failed to unmarshal spec")
+}
+
+func assertEqualJSON(t *testing.T, got, want interface{}) {
+	t.Helper()
+	gotB, err := json.Marshal(got)
+	assert.NilError(t, err)
+	wantB, err := json.Marshal(want)
+	assert.NilError(t, err)
+	assert.Equal(t, string(gotB), string(wantB))
+}
@@ -0,0 +1,112 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import "sync"
+
+// generatedOASCache caches the approved OAS generated by Spec.GenerateOASJson, it must be reset (or set) whenever
+// the approved spec changes.
+type generatedOASCache struct {
+	lock sync.Mutex
+	// oasJSON is the approved OAS generated without options, nil when it is not cached
+	oasJSON []byte
+	// validated is true once an OAS generated from the approved spec was validated, see WithCachedValidation
+	validated bool
+}
+
+// WithCachedValidation skips validating the generated spec if the approved spec was not changed since an OAS
+// generated from it was validated, e.g. when only the stats or operation extensions changed.
+func WithCachedValidation() GenerateOASOption {
+	return func(o *generateOASOptions) {
+		o.cachedValidation = true
+	}
+}
+
+func withoutValidation() GenerateOASOption {
+	return func(o *generateOASOptions) {
+		o.skipValidation = true
+	}
+}
+
+// generateCachedApprovedOASJson generates the OAS of the approved spec like generateApprovedOASJson, with the lock held.
+// The OAS generated without extensions or simplification is cached until the approved spec changes.
+func (s *Spec) generateCachedApprovedOASJson(opts []GenerateOASOption) ([]byte, error) {
+	options := createGenerateOASOptions(opts)
+	cacheable := !options.hasExtensions() && !options.simplifySchemas
+	if cacheable {
+		if oasJSON := s.oasCache.get(); oasJSON != nil {
+			return oasJSON, nil
+		}
+	}
+	skipValidation := options.cachedValidation && s.oasCache.isValidated()
+	if skipValidation {
+		opts = append(opts[:len(opts):len(opts)], withoutValidation())
+	}
+
+	oasJSON, err := s.generateApprovedOASJson(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		s.oasCache.set(oasJSON)
+	} else if !skipValidation {
+		s.oasCache.setValidated()
+	}
+
+	return oasJSON, nil
+}
+
+// get returns a copy of the cached OAS, or nil if it is not cached.
+func (c *generatedOASCache) get() []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.oasJSON == nil {
+		return nil
+	}
+	return append([]byte(nil), c.oasJSON...)
+}
+
+// set caches oasJSON, it must be the validated approved OAS generated without options.
+func (c *generatedOASCache) set(oasJSON []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.oasJSON = append([]byte(nil), oasJSON...)
+	c.validated = true
+}
+
+func (c *generatedOASCache) setValidated() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.validated = true
+}
+
+func (c *generatedOASCache) isValidated() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.validated
+}
+
+func (c *generatedOASCache) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.oasJSON = nil
+	c.validated = false
+}
@@ -0,0 +1,132 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func createOASCacheSpec(t *testing.T) *Spec {
+	t.Helper()
+
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for _, path := range []string{"/api/users", "/api/orders", "/api/pets"} {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", Data.ReqBody, Data.RespBody)))
+	}
+	return s
+}
+
+func getGeneratedPaths(t *testing.T, oasJSON []byte) []string {
+	t.Helper()
+
+	generated := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, generated))
+	paths := make([]string, 0, len(generated.Paths.Paths))
+	for path := range generated.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestSpec_GenerateOASJson_Cache(t *testing.T) {
+	s := createOASCacheSpec(t)
+
+	assert.NilError(t, s.ApprovePaths([]string{"/api/users"}))
+	// approving caches the validated approved spec
+	assert.Assert(t, s.oasCache.get() != nil)
+	oasJSON, err := s.GenerateOASJson()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, getGeneratedPaths(t, oasJSON), []string{"/api/users"})
+	uncachedJSON, err := s.generateApprovedOASJson()
+	assert.NilError(t, err)
+	assert.Equal(t, string(oasJSON), string(uncachedJSON))
+
+	// the returned OAS is a copy of the cached one
+	oasJSON[0] = 'x'
+	cachedJSON, err := s.GenerateOASJson()
+	assert.NilError(t, err)
+	assert.Equal(t, string(cachedJSON), string(uncachedJSON))
+
+	// extensions are not cached
+	withStatsJSON, err := s.GenerateOASJson(WithStatsExtension())
+	assert.NilError(t, err)
+	assert.Assert(t, string(withStatsJSON) != string(uncachedJSON))
+	assert.Equal(t, string(s.oasCache.get()), string(uncachedJSON))
+
+	// changing the approved spec updates the cached OAS
+	assert.NilError(t, s.ApprovePaths([]string{"/api/orders"}))
+	oasJSON, err = s.GenerateOASJson()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, getGeneratedPaths(t, oasJSON), []string{"/api/orders", "/api/users"})
+
+	assert.NilError(t, s.MergePaths([]string{"/api/users", "/api/orders"}, "/api/{id}"))
+	assert.Assert(t, s.oasCache.get() == nil)
+	oasJSON, err = s.GenerateOASJson()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, getGeneratedPaths(t, oasJSON), []string{"/api/{id}"})
+
+	s.UnsetApprovedSpec()
+	assert.Assert(t, s.oasCache.get() == nil)
+	oasJSON, err = s.GenerateOASJson()
+	assert.NilError(t, err)
+	assert.Equal(t, len(getGeneratedPaths(t, oasJSON)), 0)
+}
+
+func TestSpec_GenerateOASJson_WithCachedValidation(t *testing.T) {
+	s := createOASCacheSpec(t)
+	assert.NilError(t, s.ApprovePaths([]string{"/api/users"}))
+
+	// an invalid path item that was added without changing the spec through its methods is only
+	// detected when the spec is validated
+	s.ApprovedSpec.PathItems["/api/{id}"] = &NewTestPathItem().WithOperation(http.MethodGet, NewOperation(t, Data).Op).PathItem
+
+	_, err := s.GenerateOASJson(WithStatsExtension())
+	assert.ErrorContains(t, err, "failed to validate the spec")
+	oasJSON, err := s.GenerateOASJson(WithStatsExtension(), WithCachedValidation())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, getGeneratedPaths(t, oasJSON), []string{"/api/users", "/api/{id}"})
+
+	// the validation is not cached once the approved spec changes
+	s.UnsetApprovedSpec()
+	s.ApprovedSpec.PathItems["/api/{id}"] = &NewTestPathItem().WithOperation(http.MethodGet, NewOperation(t, Data).Op).PathItem
+	_, err = s.GenerateOASJson(WithStatsExtension(), WithCachedValidation())
+	assert.ErrorContains(t, err, "failed to validate the spec")
+}
+
+func BenchmarkSpec_GenerateOASJson(b *testing.B) {
+	s := createCloneSpec(b, 20)
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GenerateOASJson(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.generateApprovedOASJson(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,161 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LearningObserver is notified of the learning lifecycle of a spec, see Spec.AddLearningObserver.
+// The observers are called after the spec lock is released, so they may call the spec, but they are called by the
+// learning goroutine and should return quickly. Embed NopLearningObserver to observe only some events.
+type LearningObserver interface {
+	// OnNewPathLearned is called when a telemetry adds a path to the learning spec
+	OnNewPathLearned(event NewPathEvent)
+	// OnOperationMerged is called when a telemetry is merged into an already learned operation
+	OnOperationMerged(event OperationMergedEvent)
+	// OnSpecApproved is called when paths are approved, see Spec.ApprovePaths, Spec.ApplyApprovedReview and Spec.ImportApprovedSpec
+	OnSpecApproved(event SpecApprovedEvent)
+	// OnSchemaConflict is called when a telemetry conflicts with the learned schema of its operation
+	OnSchemaConflict(event SchemaConflictEvent)
+}
+
+type NewPathEvent struct {
+	Host   string
+	Port   string
+	Path   string
+	Method string
+}
+
+type OperationMergedEvent struct {
+	Host   string
+	Port   string
+	Path   string
+	Method string
+	// SchemaChange is the change of the learned schema, nil if the schema didn't change
+	SchemaChange *SchemaChangeEvent
+}
+
+type SpecApprovedEvent struct {
+	Host string
+	Port string
+	// Paths are the approved (parameterized) paths, sorted
+	Paths []string
+}
+
+type SchemaConflictEvent struct {
+	Host   string
+	Port   string
+	Path   string
+	Method string
+	// Fields are the conflicting operation fields, e.g. responses.200.schema.properties.id
+	Fields []string
+}
+
+// NopLearningObserver ignores all the events.
+type NopLearningObserver struct{}
+
+func (NopLearningObserver) OnNewPathLearned(NewPathEvent)          {}
+func (NopLearningObserver) OnOperationMerged(OperationMergedEvent) {}
+func (NopLearningObserver) OnSpecApproved(SpecApprovedEvent)       {}
+func (NopLearningObserver) OnSchemaConflict(SchemaConflictEvent)   {}
+
+// learningObservers queues the events raised with the spec lock held until they are sent by notify,
+// it has its own lock since notify is called without the spec lock.
+type learningObservers struct {
+	lock      sync.Mutex
+	observers []LearningObserver
+	pending   []func(observer LearningObserver)
+}
+
+// queue queues an event, it is dropped if there are no observers.
+func (o *learningObservers) queue(event func(observer LearningObserver)) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.observers) == 0 {
+		return
+	}
+	o.pending = append(o.pending, event)
+}
+
+// notify sends the queued events to the observers. A panicking observer is logged and doesn't fail the learning.
+func (o *learningObservers) notify() {
+	o.lock.Lock()
+	pending := o.pending
+	observers := o.observers
+	o.pending = nil
+	o.lock.Unlock()
+
+	for _, event := range pending {
+		for _, observer := range observers {
+			notifyObserver(observer, event)
+		}
+	}
+}
+
+func notifyObserver(observer LearningObserver, event func(observer LearningObserver)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Recovered from panic in learning observer: %v", r)
+		}
+	}()
+	event(observer)
+}
+
+// AddLearningObserver registers observer for the learning events of the spec.
+func (s *Spec) AddLearningObserver(observer LearningObserver) {
+	s.observers.lock.Lock()
+	defer s.observers.lock.Unlock()
+
+	s.observers.observers = append(s.observers.observers, observer)
+}
+
+func (s *Spec) queueNewPathEvent(path, method string) {
+	event := NewPathEvent{Host: s.Host, Port: s.Port, Path: path, Method: method}
+	s.observers.queue(func(observer LearningObserver) {
+		observer.OnNewPathLearned(event)
+	})
+}
+
+func (s *Spec) queueOperationMergedEvent(path, method string, schemaChange *SchemaChangeEvent) {
+	event := OperationMergedEvent{Host: s.Host, Port: s.Port, Path: path, Method: method, SchemaChange: schemaChange}
+	s.observers.queue(func(observer LearningObserver) {
+		observer.OnOperationMerged(event)
+	})
+}
+
+func (s *Spec) queueSpecApprovedEvent(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	sortedPaths := append([]string{}, paths...)
+	sort.Strings(sortedPaths)
+	event := SpecApprovedEvent{Host: s.Host, Port: s.Port, Paths: sortedPaths}
+	s.observers.queue(func(observer LearningObserver) {
+		observer.OnSpecApproved(event)
+	})
+}
+
+func (s *Spec) queueSchemaConflictEvent(path, method string, fields []string) {
+	event := SchemaConflictEvent{Host: s.Host, Port: s.Port, Path: path, Method: method, Fields: fields}
+	s.observers.queue(func(observer LearningObserver) {
+		observer.OnSchemaConflict(event)
+	})
+}
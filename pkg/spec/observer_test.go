@@ -0,0 +1,97 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type testLearningObserver struct {
+	spec       *Spec
+	newPaths   []NewPathEvent
+	merged     []OperationMergedEvent
+	approved   []SpecApprovedEvent
+	conflicts  []SchemaConflictEvent
+	panicOnNew bool
+}
+
+func (o *testLearningObserver) OnNewPathLearned(event NewPathEvent) {
+	// the spec lock is released
+	if o.spec != nil {
+		_ = o.spec.HasApprovedSpec()
+	}
+	o.newPaths = append(o.newPaths, event)
+	if o.panicOnNew {
+		panic("observer failed")
+	}
+}
+
+func (o *testLearningObserver) OnOperationMerged(event OperationMergedEvent) {
+	o.merged = append(o.merged, event)
+}
+
+func (o *testLearningObserver) OnSpecApproved(event SpecApprovedEvent) {
+	o.approved = append(o.approved, event)
+}
+
+func (o *testLearningObserver) OnSchemaConflict(event SchemaConflictEvent) {
+	o.conflicts = append(o.conflicts, event)
+}
+
+func TestSpec_AddLearningObserver(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	observer := &testLearningObserver{spec: s}
+	s.AddLearningObserver(observer)
+	panickingObserver := &testLearningObserver{panicOnNew: true}
+	s.AddLearningObserver(panickingObserver)
+
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "GET", "/api/users", "host", "200", "", `{"id": 1}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "GET", "/api/users", "host", "200", "", `{"id": 1, "name": "a"}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "GET", "/api/users", "host", "200", "", `{"id": "a"}`)))
+
+	assert.DeepEqual(t, observer.newPaths, []NewPathEvent{{Host: "host", Port: "80", Path: "/api/users", Method: "GET"}})
+	assert.Equal(t, len(panickingObserver.newPaths), 1)
+	assert.Equal(t, len(observer.merged), 2)
+	assert.Equal(t, observer.merged[0].Path, "/api/users")
+	assert.DeepEqual(t, observer.merged[0].SchemaChange.Added, []string{"responses.200.schema.properties.name"})
+	assert.Equal(t, len(observer.conflicts), 1)
+	assert.DeepEqual(t, observer.conflicts[0].Fields, []string{"responses.200.schema.properties.id"})
+
+	assert.NilError(t, s.ApprovePaths([]string{"/api/users"}))
+	assert.DeepEqual(t, observer.approved, []SpecApprovedEvent{{Host: "host", Port: "80", Paths: []string{"/api/users"}}})
+
+	// a failed approval is not observed
+	assert.ErrorContains(t, s.ApprovePaths([]string{"/api/items"}), "was not learned")
+	assert.Equal(t, len(observer.approved), 1)
+}
+
+func TestSpec_LearnTelemetries_observer(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	observer := &testLearningObserver{}
+	s.AddLearningObserver(observer)
+
+	errs := s.LearnTelemetries([]*Telemetry{
+		createTelemetry("req-id", "GET", "/api/users", "host", "200", "", `{"id": 1}`),
+		createTelemetry("req-id", "GET", "/api/users", "host", "200", "", `{"id": "a"}`),
+		createTelemetry("req-id", "GET", "/api/items", "host", "200", "", ""),
+	})
+	assert.DeepEqual(t, errs, []error{nil, nil, nil})
+	assert.Equal(t, len(observer.newPaths), 2)
+	assert.Equal(t, len(observer.conflicts), 1)
+	assert.Equal(t, observer.conflicts[0].Path, "/api/users")
+}
@@ -16,7 +16,9 @@
 package spec
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"mime"
 	"net/url"
@@ -28,6 +30,7 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 
 	"github.com/apiclarity/speculator/pkg/utils"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
 )
 
 var (
@@ -168,6 +171,8 @@ type HTTPInteractionData struct {
 	ReqHeaders, RespHeaders map[string]string
 	QueryParams             url.Values
 	statusCode              int
+	// path is used to find the messages of gRPC methods, see ProtoDescriptorRegistry
+	path string
 }
 
 func (h *HTTPInteractionData) getReqContentType() string {
@@ -181,66 +186,123 @@ func (h *HTTPInteractionData) getRespContentType() string {
 type OperationGeneratorConfig struct {
 	ResponseHeadersToIgnore []string
 	RequestHeadersToIgnore  []string
+	// MaxBodySizeToLearn is the body size (in bytes) above which the body schema is not inferred,
+	// the operation is flagged with x-large-payload instead. 0 means no limit.
+	MaxBodySizeToLearn int
+	// MaxBodySizeToLearnByMediaType overrides MaxBodySizeToLearn by media type (e.g. application/json)
+	MaxBodySizeToLearnByMediaType map[string]int
+	// SchemaMergeMinEstablishedHits is the amount of telemetries an operation needs to be learned from before
+	// telemetries conflicting with its schema are recorded as outliers instead of widening it. 0 disables weighting.
+	SchemaMergeMinEstablishedHits int
+	// SchemaMergeMinOutlierRatio is the ratio of the operation telemetries the outliers of a field need to reach
+	// to widen an established schema (e.g. 0.05). 0 means established schemas are never widened.
+	SchemaMergeMinOutlierRatio float64
+	// MaxRetainedSamples is the amount of redacted telemetry samples kept for debugging the telemetries that
+	// created new paths or conflicted with the learned schemas, see Spec.GetRetainedSamples. 0 disables retention.
+	MaxRetainedSamples int
+	// CookiesToLearn are the names of the request cookies learned as cookie params, all cookies when empty
+	CookiesToLearn []string
+	// CookiesToIgnore are the names of the request cookies that are not learned
+	CookiesToIgnore []string
+	// EnumMaxValues is the amount of distinct values a plain string or integer field may take to be inferred as an
+	// enum in the learning spec, a field that exceeds it is never inferred as an enum again. 0 disables enum inference.
+	EnumMaxValues int
+	// EnumMinSamples is the amount of telemetries a field needs to be seen in before it is inferred as an enum
+	EnumMinSamples int
+	// RequiredPropertyMinRatio is the ratio of the learned body objects a property needs to be present in to be
+	// required in the learning spec (e.g. 1 for all of them). 0 disables required inference.
+	RequiredPropertyMinRatio float64
+	// LearnBodyVariants learns the structurally different JSON bodies of an operation (e.g. an object and an array,
+	// or objects with disjoint properties) as variants, anyOf in OAS 3.1 and x-variants in OAS 2.0, instead of
+	// conflicting with the learned body schema
+	LearnBodyVariants bool
+	// LearnLocalePathParams learns locale path segments (e.g. /en-US/ or /fr/) as an enum of the learned locales
+	// named locale, instead of literal paths
+	LearnLocalePathParams bool
+	// LearnCompositePathParams learns path segments that mix literal and variable parts (e.g. /123:456/ or
+	// /user-123/) as params with the pattern of their learned values, instead of literal paths
+	LearnCompositePathParams bool
+	// LearnJWTClaims learns the (unverified) header and claims of the JWT body fields (format jwt) as their
+	// x-jwt schema
+	LearnJWTClaims bool
+	// ProtoDescriptors are used to learn the schemas of protobuf (application/x-protobuf, application/grpc) bodies,
+	// which are not learned when nil. The descriptors are not encoded part of the speculator state.
+	ProtoDescriptors *ProtoDescriptorRegistry
 }
 
 type OperationGenerator struct {
-	ResponseHeadersToIgnore map[string]struct{}
-	RequestHeadersToIgnore  map[string]struct{}
+	ResponseHeadersToIgnore       map[string]struct{}
+	RequestHeadersToIgnore        map[string]struct{}
+	MaxBodySizeToLearn            int
+	MaxBodySizeToLearnByMediaType map[string]int
+	SchemaMergeMinEstablishedHits int
+	SchemaMergeMinOutlierRatio    float64
+	MaxRetainedSamples            int
+	CookiesToLearn                map[string]struct{}
+	CookiesToIgnore               map[string]struct{}
+	EnumMaxValues                 int
+	EnumMinSamples                int
+	RequiredPropertyMinRatio      float64
+	LearnBodyVariants             bool
+	LearnLocalePathParams         bool
+	LearnCompositePathParams      bool
+	LearnJWTClaims                bool
+	// protoDescriptors is not exported and is not encoded part of the state
+	protoDescriptors *ProtoDescriptorRegistry
 }
 
 func NewOperationGenerator(config OperationGeneratorConfig) *OperationGenerator {
 	return &OperationGenerator{
-		ResponseHeadersToIgnore: createHeadersToIgnore(config.ResponseHeadersToIgnore),
-		RequestHeadersToIgnore:  createHeadersToIgnore(config.RequestHeadersToIgnore),
+		ResponseHeadersToIgnore:       createHeadersToIgnore(config.ResponseHeadersToIgnore),
+		RequestHeadersToIgnore:        createHeadersToIgnore(config.RequestHeadersToIgnore),
+		MaxBodySizeToLearn:            config.MaxBodySizeToLearn,
+		MaxBodySizeToLearnByMediaType: createMaxBodySizeByMediaType(config.MaxBodySizeToLearnByMediaType),
+		SchemaMergeMinEstablishedHits: config.SchemaMergeMinEstablishedHits,
+		SchemaMergeMinOutlierRatio:    config.SchemaMergeMinOutlierRatio,
+		MaxRetainedSamples:            config.MaxRetainedSamples,
+		CookiesToLearn:                createCookieNames(config.CookiesToLearn),
+		CookiesToIgnore:               createCookieNames(config.CookiesToIgnore),
+		EnumMaxValues:                 config.EnumMaxValues,
+		EnumMinSamples:                config.EnumMinSamples,
+		RequiredPropertyMinRatio:      config.RequiredPropertyMinRatio,
+		LearnBodyVariants:             config.LearnBodyVariants,
+		LearnLocalePathParams:         config.LearnLocalePathParams,
+		LearnCompositePathParams:      config.LearnCompositePathParams,
+		LearnJWTClaims:                config.LearnJWTClaims,
+		protoDescriptors:              config.ProtoDescriptors,
 	}
 }
 
 // Note: securityDefinitions might be updated.
 func (o *OperationGenerator) GenerateSpecOperation(data *HTTPInteractionData, securityDefinitions spec.SecurityDefinitions) (*spec.Operation, error) {
-	operation := spec.NewOperation("")
+	return o.GenerateSpecOperationCtx(context.Background(), data, securityDefinitions)
+}
 
-	if len(data.ReqBody) > 0 {
-		reqContentType := data.getReqContentType()
-		if reqContentType == "" {
-			log.Infof("Missing Content-Type header, ignoring request body. (%v)", data.ReqBody)
-		} else {
-			operation.Consumes = append(operation.Consumes, reqContentType)
-			mediaType, mediaTypeParams, err := mime.ParseMediaType(reqContentType)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse request media type. Content-Type=%v: %w", reqContentType, err)
-			}
-			switch true {
-			case utils.IsApplicationJSONMediaType(mediaType):
-				reqBodyJSON, err := gojsonschema.NewStringLoader(data.ReqBody).LoadJSON()
-				if err != nil {
-					return nil, fmt.Errorf("failed to load json from request body. body=%v: %w", data.ReqBody, err)
-				}
-
-				reqSchema, err := getSchema(reqBodyJSON)
-				if err != nil {
-					return nil, fmt.Errorf("failed to get schema from request body. body=%v: %w", data.ReqBody, err)
-				}
-
-				// all operation have to hold the same in body name parameter (inBodyParameterName)
-				operation.AddParam(spec.BodyParam(inBodyParameterName, reqSchema))
-			case mediaType == mediaTypeApplicationForm:
-				operation, securityDefinitions = addApplicationFormParams(operation, securityDefinitions, data.ReqBody)
-			case mediaType == mediaTypeMultipartFormData:
-				// multipart/form-data (used to upload files or a combination of files and primitive data).
-				// https://swagger.io/docs/specification/2-0/file-upload/
-				operation, err = addMultipartFormDataParams(operation, data.ReqBody, mediaTypeParams)
-				if err != nil {
-					return nil, fmt.Errorf("failed to add multipart formData params from request body. body=%v: %v", data.ReqBody, err)
-				}
-			default:
-				log.Infof("Treating %v as default request content type (no schema)", reqContentType)
-			}
-		}
+// GenerateSpecOperationCtx is GenerateSpecOperation that stops once ctx is done, the returned error then wraps ctx.Err().
+func (o *OperationGenerator) GenerateSpecOperationCtx(ctx context.Context, data *HTTPInteractionData, securityDefinitions spec.SecurityDefinitions) (*spec.Operation, error) {
+	operation, _, err := o.generateSpecOperation(ctx, data, securityDefinitions)
+	return operation, err
+}
+
+// generateSpecOperation generates the operation of data, partial is true if the body of the request or of the
+// response could not be parsed (see _errors.ErrBodyParse) and the operation was learned without it.
+// ctx is checked before each body is parsed, as parsing is the slow part of the generation.
+func (o *OperationGenerator) generateSpecOperation(ctx context.Context, data *HTTPInteractionData, securityDefinitions spec.SecurityDefinitions) (operation *spec.Operation, partial bool, err error) {
+	operation = spec.NewOperation("")
+
+	if err := ctx.Err(); err != nil {
+		return nil, false, fmt.Errorf("operation generation canceled: %w", err)
+	}
+	operation, securityDefinitions, reqBodyErr := o.addRequestBody(operation, data, securityDefinitions)
+	if reqBodyErr != nil && !errors.Is(reqBodyErr, _errors.ErrBodyParse) {
+		return nil, false, reqBodyErr
 	}
 
 	for key, value := range data.ReqHeaders {
 		if strings.ToLower(key) == authorizationTypeHeaderName {
 			operation, securityDefinitions = handleAuthReqHeader(operation, securityDefinitions, value)
+		} else if strings.ToLower(key) == cookieHeaderName {
+			operation = o.addCookieParams(operation, value)
 		} else {
 			operation = o.addHeaderParam(operation, key, value)
 		}
@@ -255,61 +317,185 @@ func (o *OperationGenerator) GenerateSpecOperation(data *HTTPInteractionData, se
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, false, fmt.Errorf("operation generation canceled: %w", err)
+	}
 	response := spec.NewResponse()
-	if len(data.RespBody) > 0 {
-		respContentType := data.getRespContentType()
-		if respContentType == "" {
-			log.Infof("Missing Content-Type header, ignoring response body. (%v)", data.RespBody)
-		} else {
-			operation.Produces = append(operation.Produces, respContentType)
-			mediaType, _, err := mime.ParseMediaType(respContentType)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse response media type. Content-Type=%v: %w", respContentType, err)
-			}
-			switch true {
-			case utils.IsApplicationJSONMediaType(mediaType):
-				respBodyJSON, err := gojsonschema.NewStringLoader(data.RespBody).LoadJSON()
-				if err != nil {
-					return nil, fmt.Errorf("failed to load json from response body. body=%v: %w", data.RespBody, err)
-				}
-
-				respSchema, err := getSchema(respBodyJSON)
-				if err != nil {
-					return nil, fmt.Errorf("failed to get schema from response body. body=%v: %w", respBodyJSON, err)
-				}
-
-				response.WithSchema(respSchema)
-			// WithDescription("some response").
-			// AddExample("application/json", respBody)
-			default:
-				log.Infof("Treating %v as default response content type (no schema)", respContentType)
-			}
-		}
+	respBodyErr := o.addResponseBody(operation, response, data)
+	if respBodyErr != nil && !errors.Is(respBodyErr, _errors.ErrBodyParse) {
+		return nil, false, respBodyErr
+	}
+	// an interaction is still learned when only one of its bodies can not be parsed
+	if reqBodyErr != nil && respBodyErr != nil {
+		return nil, false, fmt.Errorf("failed to parse request and response bodies: %v. %w", reqBodyErr, respBodyErr)
+	}
+	partial = reqBodyErr != nil || respBodyErr != nil
+	if reqBodyErr != nil {
+		log.Warnf("Learning interaction without its request body: %v", reqBodyErr)
+	} else if respBodyErr != nil {
+		log.Warnf("Learning interaction without its response body: %v", respBodyErr)
 	}
 
 	for key, value := range data.RespHeaders {
 		response = o.addResponseHeader(response, key, value)
 	}
+	addCompressionExtension(operation, data.RespHeaders)
 
 	operation.RespondsWith(data.statusCode, response).
 		WithDefaultResponse(defaultResponse)
 
-	return operation, nil
+	return operation, partial, nil
 }
 
-func CloneOperation(op *spec.Operation) (*spec.Operation, error) {
-	var out spec.Operation
-
-	opB, err := json.Marshal(op)
+// addRequestBody adds the request body params of data to operation. If the body can not be parsed, operation
+// is returned without the body and its content type.
+func (o *OperationGenerator) addRequestBody(operation *spec.Operation, data *HTTPInteractionData, securityDefinitions spec.SecurityDefinitions) (*spec.Operation, spec.SecurityDefinitions, error) {
+	if len(data.ReqBody) == 0 {
+		return operation, securityDefinitions, nil
+	}
+	consumesLen := len(operation.Consumes)
+	operation, securityDefinitions, err := o.addRequestBodyParams(operation, data, securityDefinitions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal operation (%+v): %v", op, err)
+		operation.Consumes = operation.Consumes[:consumesLen]
+	}
+	return operation, securityDefinitions, err
+}
+
+func (o *OperationGenerator) addRequestBodyParams(operation *spec.Operation, data *HTTPInteractionData, securityDefinitions spec.SecurityDefinitions) (*spec.Operation, spec.SecurityDefinitions, error) {
+	reqContentType := data.getReqContentType()
+	if reqContentType == "" {
+		log.Infof("Missing Content-Type header, ignoring request body. (%v)", data.ReqBody)
+	} else {
+		operation.Consumes = append(operation.Consumes, reqContentType)
+		mediaType, mediaTypeParams, err := mime.ParseMediaType(reqContentType)
+		if err != nil {
+			return operation, securityDefinitions, fmt.Errorf("failed to parse request media type. Content-Type=%v: %w", reqContentType, err)
+		}
+		switch true {
+		case o.isLargePayload(mediaType, data.ReqBody):
+			addLargePayloadExtension(operation)
+			if schema := getLargePayloadSchema(data.ReqBody); schema != nil {
+				operation.AddParam(spec.BodyParam(inBodyParameterName, schema))
+			}
+		case utils.IsApplicationJSONMediaType(mediaType):
+			reqBodyJSON, err := gojsonschema.NewStringLoader(data.ReqBody).LoadJSON()
+			if err != nil {
+				return operation, securityDefinitions, fmt.Errorf("failed to load json from request body. body=%v: %v. %w", data.ReqBody, err, _errors.ErrBodyParse)
+			}
+
+			reqSchema, err := o.getJSONBodySchema(reqBodyJSON)
+			if err != nil {
+				return operation, securityDefinitions, fmt.Errorf("failed to get schema from request body. body=%v: %w", data.ReqBody, err)
+			}
+
+			// all operation have to hold the same in body name parameter (inBodyParameterName)
+			operation.AddParam(spec.BodyParam(inBodyParameterName, reqSchema))
+		case utils.IsXMLMediaType(mediaType):
+			reqSchema, err := getXMLSchema(data.ReqBody)
+			if err != nil {
+				return operation, securityDefinitions, fmt.Errorf("failed to get schema from request body. body=%v: %v. %w", data.ReqBody, err, _errors.ErrBodyParse)
+			}
+
+			operation.AddParam(spec.BodyParam(inBodyParameterName, reqSchema))
+		case mediaType == mediaTypeApplicationForm:
+			operation, securityDefinitions = addApplicationFormParams(operation, securityDefinitions, data.ReqBody)
+		case mediaType == mediaTypeMultipartFormData:
+			// multipart/form-data (used to upload files or a combination of files and primitive data).
+			// https://swagger.io/docs/specification/2-0/file-upload/
+			operation, err = addMultipartFormDataParams(operation, data.ReqBody, mediaTypeParams)
+			if err != nil {
+				return operation, securityDefinitions, fmt.Errorf("failed to add multipart formData params from request body. body=%v: %v. %w", data.ReqBody, err, _errors.ErrBodyParse)
+			}
+			// the boundary is different in each request
+			operation.Consumes[len(operation.Consumes)-1] = mediaType
+		case o.protoDescriptors != nil && utils.IsProtobufMediaType(mediaType):
+			reqSchema, err := o.getProtobufBodySchema(data.ReqBody, mediaType, mediaTypeParams, data.path, true)
+			if err != nil {
+				return operation, securityDefinitions, fmt.Errorf("failed to get schema from request body: %v. %w", err, _errors.ErrBodyParse)
+			}
+			if reqSchema != nil {
+				operation.AddParam(spec.BodyParam(inBodyParameterName, reqSchema))
+			}
+		default:
+			log.Infof("Treating %v as default request content type (no schema)", reqContentType)
+		}
 	}
+	return operation, securityDefinitions, nil
+}
+
+// addResponseBody adds the response body schema of data to response. If the body can not be parsed, the
+// response has no schema and its content type is not added to operation.
+func (o *OperationGenerator) addResponseBody(operation *spec.Operation, response *spec.Response, data *HTTPInteractionData) error {
+	if len(data.RespBody) == 0 {
+		return nil
+	}
+	producesLen := len(operation.Produces)
+	if err := o.addResponseBodySchema(operation, response, data); err != nil {
+		operation.Produces = operation.Produces[:producesLen]
+		return err
+	}
+	return nil
+}
 
-	if err := json.Unmarshal(opB, &out); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal: %v", err)
+func (o *OperationGenerator) addResponseBodySchema(operation *spec.Operation, response *spec.Response, data *HTTPInteractionData) error {
+	respContentType := data.getRespContentType()
+	if respContentType == "" {
+		log.Infof("Missing Content-Type header, ignoring response body. (%v)", data.RespBody)
+	} else {
+		operation.Produces = append(operation.Produces, respContentType)
+		mediaType, mediaTypeParams, err := mime.ParseMediaType(respContentType)
+		if err != nil {
+			return fmt.Errorf("failed to parse response media type. Content-Type=%v: %w", respContentType, err)
+		}
+		switch true {
+		case o.isLargePayload(mediaType, data.RespBody):
+			addLargePayloadExtension(operation)
+			if schema := getLargePayloadSchema(data.RespBody); schema != nil {
+				response.WithSchema(schema)
+			}
+		case utils.IsApplicationJSONMediaType(mediaType):
+			respBodyJSON, err := gojsonschema.NewStringLoader(data.RespBody).LoadJSON()
+			if err != nil {
+				return fmt.Errorf("failed to load json from response body. body=%v: %v. %w", data.RespBody, err, _errors.ErrBodyParse)
+			}
+
+			respSchema, err := o.getJSONBodySchema(respBodyJSON)
+			if err != nil {
+				return fmt.Errorf("failed to get schema from response body. body=%v: %w", respBodyJSON, err)
+			}
+
+			response.WithSchema(respSchema)
+		// WithDescription("some response").
+		// AddExample("application/json", respBody)
+		case utils.IsXMLMediaType(mediaType):
+			respSchema, err := getXMLSchema(data.RespBody)
+			if err != nil {
+				return fmt.Errorf("failed to get schema from response body. body=%v: %v. %w", data.RespBody, err, _errors.ErrBodyParse)
+			}
+
+			response.WithSchema(respSchema)
+		case o.protoDescriptors != nil && utils.IsProtobufMediaType(mediaType):
+			respSchema, err := o.getProtobufBodySchema(data.RespBody, mediaType, mediaTypeParams, data.path, false)
+			if err != nil {
+				return fmt.Errorf("failed to get schema from response body: %v. %w", err, _errors.ErrBodyParse)
+			}
+			if respSchema != nil {
+				response.WithSchema(respSchema)
+			}
+		default:
+			log.Infof("Treating %v as default response content type (no schema)", respContentType)
+		}
+	}
+	return nil
+}
+
+// CloneOperation returns a deep copy of op, an empty operation if op is nil.
+func CloneOperation(op *spec.Operation) (*spec.Operation, error) {
+	if op == nil {
+		return &spec.Operation{}, nil
 	}
 
-	return &out, nil
+	return cloneOperation(op), nil
 }
 
 func handleAuthReqHeader(operation *spec.Operation, sd spec.SecurityDefinitions, value string) (*spec.Operation, spec.SecurityDefinitions) {
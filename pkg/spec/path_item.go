@@ -21,6 +21,17 @@ import (
 	oapi_spec "github.com/go-openapi/spec"
 )
 
+// supportedMethods are the HTTP methods a swagger 2.0 path item can hold an operation for.
+var supportedMethods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodPatch,
+}
+
 func MergePathItems(dst, src *oapi_spec.PathItem) *oapi_spec.PathItem {
 	dst.Get, _ = mergeOperation(dst.Get, src.Get)
 	dst.Put, _ = mergeOperation(dst.Put, src.Put)
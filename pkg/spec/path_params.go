@@ -18,11 +18,17 @@ package spec
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/go-openapi/spec"
-	uuid "github.com/satori/go.uuid"
+	"github.com/go-openapi/swag"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
 )
 
 type PathParam struct {
@@ -33,16 +39,104 @@ func generateParamName(i int) string {
 	return fmt.Sprintf("param%v", i)
 }
 
-var digitCheck = regexp.MustCompile(`^[0-9]+$`)
+var (
+	digitCheck = regexp.MustCompile(`^[0-9]+$`)
+	hexCheck   = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	// ULIDs are 26 Crockford base32 chars, the first one is at most 7 as the timestamp is 48 bits
+	ulidCheck = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$`)
+	// base64 path segments can't contain a slash, so they are either url safe or standard without a slash
+	base64Check = regexp.MustCompile(`^[A-Za-z0-9+_-]+={0,2}$`)
+	// the words of camel cased names, e.g. get, User and Details of getUserById2Details
+	camelCaseWordCheck = regexp.MustCompile(`[A-Z]?[a-z]{2,}`)
+)
+
+// the lengths of hex encoded sha1, sha224, sha256, sha384 and sha512 hashes. 32 chars hashes (e.g. md5) are
+// detected as uuids without dashes.
+var hexHashLengths = map[int]bool{40: true, 56: true, 64: true, 96: true, 128: true}
+
+// base64 path segments shorter than this are not detected as params
+const minBase64PathParamLength = 32
+
+// base64 path segments without +, _, - or = whose camel cased words cover more than this ratio of their chars are
+// names (e.g. listAllOrdersForCustomer2WithDetails), random tokens are rarely covered by more than half
+const maxBase64WordRatio = 0.6
+
+// the param name of locale path segments, see OperationGeneratorConfig.LearnLocalePathParams
+const localePathParamName = "locale"
+
+// locales are an ISO 639 language, optionally followed by an ISO 15924 script and an ISO 3166 (or UN M.49) region,
+// e.g. en-US, zh-Hant-TW, es-419 or pt_BR.
+var localeCheck = regexp.MustCompile(`^([a-z]{2,3})((?:[-_][A-Z][a-z]{3})?(?:[-_](?:[A-Z]{2}|[0-9]{3}))?)$`)
+
+// the separators between the literal and variable parts of composite path segments, e.g. 123:456 or user-123,
+// see OperationGeneratorConfig.LearnCompositePathParams
+var compositePathPartSeparatorCheck = regexp.MustCompile(`[:_.,~-]`)
+
+// the ISO 639-1 language codes
+var isoLanguageCodes = createISOLanguageCodes("aa ab ae af ak am an ar as av ay az ba be bg bh bi bm bn bo br bs ca ce " +
+	"ch co cr cs cu cv cy da de dv dz ee el en eo es et eu fa ff fi fj fo fr fy ga gd gl gn gu gv ha he hi ho hr ht hu " +
+	"hy hz ia id ie ig ii ik io is it iu ja jv ka kg ki kj kk kl km kn ko kr ks ku kv kw ky la lb lg li ln lo lt lu lv " +
+	"mg mh mi mk ml mn mr ms mt my na nb nd ne ng nl nn no nr nv ny oc oj om or os pa pi pl ps pt qu rm rn ro ru rw sa " +
+	"sc sd se sg si sk sl sm sn so sq sr ss st su sv sw ta te tg th ti tk tl tn to tr ts tt tw ty ug uk ur uz ve vi vo " +
+	"wa wo xh yi yo za zh zu")
+
+func createISOLanguageCodes(codes string) map[string]bool {
+	ret := make(map[string]bool)
+	for _, code := range strings.Fields(codes) {
+		ret[code] = true
+	}
+	return ret
+}
+
+// the param names of YYYY/MM/DD path segments
+var datePathParamNames = []string{"year", "month", "day"}
+
+// YYYY/MM/DD path segments with years out of this range are numeric ids, e.g. /users/1234/12/11
+const (
+	minDatePathYear = 1900
+	maxDatePathYear = 2100
+)
 
-func createParameterizedPath(path string) string {
+// epoch timestamps (in seconds or milliseconds) between these times are detected as unix-time path params
+var (
+	minUnixTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxUnixTime = time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// createParameterizedPath replaces the path parts that are suspect params with params. With learnLocales, locale
+// path parts are replaced with locale params, and with learnComposites, composite path parts are replaced with params.
+func createParameterizedPath(path string, learnLocales, learnComposites bool) string {
 	var ParameterizedPathParts []string
 	paramCount := 0
+	dateCount := 0
+	localeCount := 0
 	pathParts := strings.Split(path, "/")
 
-	for _, part := range pathParts {
+	for i := 0; i < len(pathParts); i++ {
+		part := pathParts[i]
+		if learnLocales && isLocale(part) {
+			localeCount++
+			paramName := localePathParamName
+			if localeCount > 1 {
+				paramName = fmt.Sprintf("%v%v", paramName, localeCount)
+			}
+			ParameterizedPathParts = append(ParameterizedPathParts, "{"+paramName+"}")
+			continue
+		}
+		// YYYY/MM/DD segments are replaced with year, month and day params
+		if i+len(datePathParamNames) <= len(pathParts) && isDatePathSegments(pathParts[i:i+len(datePathParamNames)]) {
+			dateCount++
+			for _, name := range datePathParamNames {
+				if dateCount > 1 {
+					name = fmt.Sprintf("%v%v", name, dateCount)
+				}
+				ParameterizedPathParts = append(ParameterizedPathParts, "{"+name+"}")
+			}
+			i += len(datePathParamNames) - 1
+			continue
+		}
 		// if part is a suspect param, replace it with a param name, otherwise do nothing
-		if isSuspectPathParam(part) {
+		if isSuspectPathParam(part) || (learnComposites && isCompositePathPart(part)) {
 			paramCount++
 			paramName := generateParamName(paramCount)
 			ParameterizedPathParts = append(ParameterizedPathParts, "{"+paramName+"}")
@@ -63,6 +157,13 @@ const (
 	paramFormatNumber paramFormat = "paramFormatNumber"
 	paramFormatUUID   paramFormat = "paramFormatUUID"
 	paramFormatMixed  paramFormat = "paramFormatMixed"
+	// paramFormatUnixTime is also a number (paramFormatNumber)
+	paramFormatUnixTime paramFormat = "paramFormatUnixTime"
+	paramFormatDate     paramFormat = "paramFormatDate"
+	paramFormatDateTime paramFormat = "paramFormatDateTime"
+	paramFormatHexHash  paramFormat = "paramFormatHexHash"
+	paramFormatULID     paramFormat = "paramFormatULID"
+	paramFormatBase64   paramFormat = "paramFormatBase64"
 )
 
 // /api/1/foo, api/2/foo and index 1 will return:
@@ -86,27 +187,21 @@ func getParamTypeAndFormat(paramsList []string) (string, string) {
 	parameterFormat := paramFormatUnset
 
 	for _, pathPart := range paramsList {
-		if isNumber(pathPart) {
-			// in case there is a conflict, we will return string as the type and empty format
-			if parameterFormat != paramFormatNumber && parameterFormat != paramFormatUnset {
-				return schemaTypeString, ""
-			}
-			parameterFormat = paramFormatNumber
+		partFormat := getPathPartFormat(pathPart)
+		if partFormat == paramFormatUnset {
 			continue
 		}
-		if isUUID(pathPart) {
-			if parameterFormat != paramFormatUUID && parameterFormat != paramFormatUnset {
-				return schemaTypeString, ""
-			}
-			parameterFormat = paramFormatUUID
+		if parameterFormat == paramFormatUnset || parameterFormat == partFormat {
+			parameterFormat = partFormat
 			continue
 		}
-		if isMixed(pathPart) {
-			if parameterFormat != paramFormatMixed && parameterFormat != paramFormatUnset {
-				return schemaTypeString, ""
-			}
-			parameterFormat = paramFormatMixed
+		// unix times are numbers as well
+		if isNumberParamFormat(parameterFormat) && isNumberParamFormat(partFormat) {
+			parameterFormat = paramFormatNumber
+			continue
 		}
+		// in case there is a conflict, we will return string as the type and empty format
+		return schemaTypeString, ""
 	}
 
 	switch parameterFormat {
@@ -116,6 +211,18 @@ func getParamTypeAndFormat(paramsList []string) (string, string) {
 		return schemaTypeString, formatUUID
 	case paramFormatNumber:
 		return schemaTypeInteger, ""
+	case paramFormatUnixTime:
+		return schemaTypeInteger, formatUnixTime
+	case paramFormatDate:
+		return schemaTypeString, formatDate
+	case paramFormatDateTime:
+		return schemaTypeString, formatDateTime
+	case paramFormatHexHash:
+		return schemaTypeString, formatHex
+	case paramFormatULID:
+		return schemaTypeString, formatULID
+	case paramFormatBase64:
+		return schemaTypeString, formatByte
 	case paramFormatUnset:
 		return schemaTypeString, ""
 	}
@@ -123,6 +230,34 @@ func getParamTypeAndFormat(paramsList []string) (string, string) {
 	return schemaTypeString, ""
 }
 
+func getPathPartFormat(pathPart string) paramFormat {
+	switch {
+	case isUnixTime(pathPart):
+		return paramFormatUnixTime
+	case isNumber(pathPart):
+		return paramFormatNumber
+	case isUUID(pathPart):
+		return paramFormatUUID
+	case isDate(pathPart):
+		return paramFormatDate
+	case isDateTime(pathPart):
+		return paramFormatDateTime
+	case isHexHash(pathPart):
+		return paramFormatHexHash
+	case isULID(pathPart):
+		return paramFormatULID
+	case isBase64(pathPart):
+		return paramFormatBase64
+	case isMixed(pathPart):
+		return paramFormatMixed
+	}
+	return paramFormatUnset
+}
+
+func isNumberParamFormat(format paramFormat) bool {
+	return format == paramFormatNumber || format == paramFormatUnixTime
+}
+
 func isSuspectPathParam(pathPart string) bool {
 	if isNumber(pathPart) {
 		return true
@@ -130,12 +265,215 @@ func isSuspectPathParam(pathPart string) bool {
 	if isUUID(pathPart) {
 		return true
 	}
+	if isDate(pathPart) || isDateTime(pathPart) {
+		return true
+	}
+	if isHexHash(pathPart) || isULID(pathPart) || isBase64(pathPart) {
+		return true
+	}
 	if isMixed(pathPart) {
 		return true
 	}
 	return false
 }
 
+// isDate returns true for ISO dates, e.g. 2024-01-15.
+func isDate(pathPart string) bool {
+	_, err := time.Parse("2006-01-02", pathPart)
+	return err == nil
+}
+
+// isDateTime returns true for ISO date times, e.g. 2024-01-15T10:00:00Z.
+func isDateTime(pathPart string) bool {
+	_, err := time.Parse(time.RFC3339, pathPart)
+	return err == nil
+}
+
+// isUnixTime returns true for epoch timestamps in seconds (10 digits) or milliseconds (13 digits), between
+// minUnixTime and maxUnixTime.
+func isUnixTime(pathPart string) bool {
+	if !isNumber(pathPart) {
+		return false
+	}
+	value, err := strconv.ParseInt(pathPart, 10, 64)
+	if err != nil {
+		return false
+	}
+	switch len(pathPart) {
+	case 10:
+		return value >= minUnixTime.Unix() && value < maxUnixTime.Unix()
+	case 13:
+		return value >= minUnixTime.UnixNano()/int64(time.Millisecond) && value < maxUnixTime.UnixNano()/int64(time.Millisecond)
+	}
+	return false
+}
+
+// isDatePathSegments returns true for YYYY, MM and DD segments of a valid date between minDatePathYear and
+// maxDatePathYear, e.g. 2024, 01 and 15.
+func isDatePathSegments(segments []string) bool {
+	if len(segments) != len(datePathParamNames) || len(segments[0]) != 4 {
+		return false
+	}
+	date, err := time.Parse("2006/01/02", strings.Join(segments, "/"))
+	return err == nil && date.Year() >= minDatePathYear && date.Year() <= maxDatePathYear
+}
+
+// isHexHash returns true for hex encoded hashes, e.g. a sha256 of a content addressed resource.
+func isHexHash(pathPart string) bool {
+	return hexHashLengths[len(pathPart)] && hexCheck.MatchString(pathPart)
+}
+
+// isULID returns true for ULIDs, e.g. 01ARZ3NDEKTSV4RRFFQ69G5FAV.
+func isULID(pathPart string) bool {
+	return ulidCheck.MatchString(pathPart)
+}
+
+// isBase64 returns true for base64 (standard or url safe) encoded tokens. To avoid matching long words or
+// camel cased names, pathPart must be at least minBase64PathParamLength long, mix upper case, lower case and digits,
+// and either have a +, _, - or = char or not read as camel cased words, see maxBase64WordRatio.
+func isBase64(pathPart string) bool {
+	if len(pathPart) < minBase64PathParamLength || !base64Check.MatchString(pathPart) {
+		return false
+	}
+	// a single char can't be the last base64 quantum
+	if len(strings.TrimRight(pathPart, "="))%4 == 1 {
+		return false
+	}
+
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range pathPart {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasUpper || !hasLower || !hasDigit {
+		return false
+	}
+	if strings.ContainsAny(pathPart, "+_-=") {
+		return true
+	}
+
+	wordsLen := 0
+	for _, word := range camelCaseWordCheck.FindAllString(pathPart, -1) {
+		wordsLen += len(word)
+	}
+	return float64(wordsLen) <= maxBase64WordRatio*float64(len(pathPart))
+}
+
+// isLocale returns true for locales, e.g. en-US, pt_BR or fr.
+func isLocale(pathPart string) bool {
+	match := localeCheck.FindStringSubmatch(pathPart)
+	if match == nil {
+		return false
+	}
+	// a language without a script or a region must be a known language code, not to match short words
+	return match[2] != "" || isoLanguageCodes[match[1]]
+}
+
+// isLocalePathParam returns true if the param named paramName is a locale param (see createParameterizedPath)
+// and all its values are locales.
+func isLocalePathParam(paramName string, values []string) bool {
+	if strings.TrimRight(paramName, "0123456789") != localePathParamName || len(values) == 0 {
+		return false
+	}
+	for _, value := range values {
+		if !isLocale(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// getLocalePathParamEnum returns the sorted distinct locales of values.
+func getLocalePathParamEnum(values []string) []interface{} {
+	locales := make(map[string]bool)
+	for _, value := range values {
+		locales[value] = true
+	}
+	sortedLocales := make([]string, 0, len(locales))
+	for locale := range locales {
+		sortedLocales = append(sortedLocales, locale)
+	}
+	sort.Strings(sortedLocales)
+
+	ret := make([]interface{}, 0, len(sortedLocales))
+	for _, locale := range sortedLocales {
+		ret = append(ret, locale)
+	}
+	return ret
+}
+
+// isCompositePathPart returns true for path parts that mix literal and variable parts, e.g. 123:456 or user-123,
+// and are not suspect params of a specific format (e.g. a date or a uuid) as a whole.
+func isCompositePathPart(pathPart string) bool {
+	return getCompositePathPartPattern(pathPart) != ""
+}
+
+// getCompositePathPartPattern returns the pattern of a composite path part, with its literal parts quoted and its
+// number, hex and mixed parts as char classes (e.g. ^user-[0-9]+$), or an empty string if pathPart is not composite.
+// UUIDs contain dashes, so composite parts with a UUID are not detected.
+func getCompositePathPartPattern(pathPart string) string {
+	if format := getPathPartFormat(pathPart); format != paramFormatUnset && format != paramFormatMixed {
+		return ""
+	}
+	separators := compositePathPartSeparatorCheck.FindAllStringIndex(pathPart, -1)
+	if len(separators) == 0 {
+		return ""
+	}
+
+	var pattern strings.Builder
+	hasVariablePart := false
+	start := 0
+	for i := 0; i <= len(separators); i++ {
+		end := len(pathPart)
+		if i < len(separators) {
+			end = separators[i][0]
+		}
+		part := pathPart[start:end]
+		switch {
+		case isNumber(part):
+			pattern.WriteString("[0-9]+")
+			hasVariablePart = true
+		case isHexHash(part):
+			pattern.WriteString("[0-9a-fA-F]+")
+			hasVariablePart = true
+		case isULID(part) || isMixed(part):
+			pattern.WriteString("[0-9A-Za-z]+")
+			hasVariablePart = true
+		default:
+			pattern.WriteString(regexp.QuoteMeta(part))
+		}
+		if i < len(separators) {
+			pattern.WriteString(regexp.QuoteMeta(pathPart[separators[i][0]:separators[i][1]]))
+			start = separators[i][1]
+		}
+	}
+	if !hasVariablePart {
+		return ""
+	}
+
+	return "^" + pattern.String() + "$"
+}
+
+// getCompositePathParamPattern returns the pattern of values if they are all composite path parts of the same
+// pattern, otherwise an empty string.
+func getCompositePathParamPattern(values []string) string {
+	pattern := ""
+	for _, value := range values {
+		valuePattern := getCompositePathPartPattern(value)
+		if valuePattern == "" || (pattern != "" && valuePattern != pattern) {
+			return ""
+		}
+		pattern = valuePattern
+	}
+	return pattern
+}
+
 func isNumber(pathPart string) bool {
 	return digitCheck.MatchString(pathPart)
 }
@@ -176,3 +514,66 @@ func createPathParam(name, tpe, format string) *PathParam {
 
 	return &pathParam
 }
+
+// ExtractPathParamValues returns a map of path param name into its value in path, coerced to the type and format
+// learned for the param in params (e.g. the PathItem parameters). A param without a learned type remains a string.
+// Returns an error if path does not match parameterizedPath or a value can't be coerced.
+func ExtractPathParamValues(parameterizedPath, path string, params []spec.Parameter) (map[string]interface{}, error) {
+	values, ok := utils.GetPathParamValues(parameterizedPath, path)
+	if !ok {
+		return nil, fmt.Errorf("path %v does not match %v", path, parameterizedPath)
+	}
+
+	pathParams := make(map[string]spec.Parameter)
+	for i := range params {
+		if params[i].In == parametersInPath {
+			pathParams[params[i].Name] = params[i]
+		}
+	}
+
+	ret := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		param, ok := pathParams[name]
+		if !ok {
+			ret[name] = value
+			continue
+		}
+		coerced, err := coercePathParamValue(value, param.Type, param.Format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for path param %v: %w", name, err)
+		}
+		ret[name] = coerced
+	}
+
+	return ret, nil
+}
+
+func coercePathParamValue(value, tpe, format string) (interface{}, error) {
+	switch tpe {
+	case schemaTypeInteger:
+		i, err := swag.ConvertInt64(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not an integer", value)
+		}
+		return i, nil
+	case schemaTypeNumber:
+		f, err := swag.ConvertFloat64(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a number", value)
+		}
+		return f, nil
+	case schemaTypeBoolean:
+		b, err := swag.ConvertBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a boolean", value)
+		}
+		return b, nil
+	case schemaTypeString, "":
+		if format == formatUUID && !isUUID(value) {
+			return nil, fmt.Errorf("%v is not a uuid", value)
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
@@ -18,14 +18,18 @@ package spec
 import (
 	"reflect"
 	"sort"
+	"strconv"
 	"testing"
 
 	"github.com/go-openapi/spec"
+	"gotest.tools/assert"
 )
 
 func Test_createParameterizedPath(t *testing.T) {
 	type args struct {
-		path string
+		path            string
+		learnLocales    bool
+		learnComposites bool
 	}
 	tests := []struct {
 		name string
@@ -53,10 +57,118 @@ func Test_createParameterizedPath(t *testing.T) {
 			},
 			want: "/api/{param1}/hello/{param2}",
 		},
+		{
+			name: "iso date",
+			args: args{
+				path: "/reports/2024-01-15/summary",
+			},
+			want: "/reports/{param1}/summary",
+		},
+		{
+			name: "iso date time",
+			args: args{
+				path: "/events/2024-01-15T10:00:00Z",
+			},
+			want: "/events/{param1}",
+		},
+		{
+			name: "epoch timestamp",
+			args: args{
+				path: "/events/1705276800/summary",
+			},
+			want: "/events/{param1}/summary",
+		},
+		{
+			name: "YYYY/MM/DD segments",
+			args: args{
+				path: "/reports/2024/01/15/summary/123",
+			},
+			want: "/reports/{year}/{month}/{day}/summary/{param1}",
+		},
+		{
+			name: "multiple YYYY/MM/DD segments",
+			args: args{
+				path: "/from/2024/01/15/to/2024/02/29",
+			},
+			want: "/from/{year}/{month}/{day}/to/{year2}/{month2}/{day2}",
+		},
+		{
+			name: "YYYY/MM/DD segments out of the year range",
+			args: args{
+				path: "/users/1234/12/11",
+			},
+			want: "/users/{param1}/{param2}/{param3}",
+		},
+		{
+			name: "invalid YYYY/MM/DD segments",
+			args: args{
+				path: "/reports/2023/02/29",
+			},
+			want: "/reports/{param1}/{param2}/{param3}",
+		},
+		{
+			name: "content addressed path",
+			args: args{
+				path: "/blobs/sha256/e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			},
+			want: "/blobs/sha256/{param1}",
+		},
+		{
+			name: "ulid and base64 token",
+			args: args{
+				path: "/orders/01ARZ3NDEKTSV4RRFFQ69G5FAV/invite/dGhpcyBpcyBhIHRva2Vu_X2-9a",
+			},
+			want: "/orders/{param1}/invite/{param2}",
+		},
+		{
+			name: "camel cased endpoint names are literals",
+			args: args{
+				path: "/getUserById2DetailsX",
+			},
+			want: "/getUserById2DetailsX",
+		},
+		{
+			name: "locales are literals by default",
+			args: args{
+				path: "/en-US/docs/123",
+			},
+			want: "/en-US/docs/{param1}",
+		},
+		{
+			name: "locales",
+			args: args{
+				path:         "/en-US/docs/123/fr",
+				learnLocales: true,
+			},
+			want: "/{locale}/docs/{param1}/{locale2}",
+		},
+		{
+			name: "short words are not locales",
+			args: args{
+				path:         "/api/v1/me",
+				learnLocales: true,
+			},
+			want: "/api/v1/me",
+		},
+		{
+			name: "composites are not learned by default",
+			args: args{
+				path: "/orders/123:456/users/u-12",
+			},
+			want: "/orders/123:456/users/u-12",
+		},
+		{
+			name: "composites",
+			args: args{
+				path:            "/orders/123:456/users/u-12/report.json/999",
+				learnComposites: true,
+			},
+			want: "/orders/{param1}/users/{param2}/report.json/{param3}",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := createParameterizedPath(tt.args.path); got != tt.want {
+			if got := createParameterizedPath(tt.args.path, tt.args.learnLocales, tt.args.learnComposites); got != tt.want {
 				t.Errorf("createParameterizedPath() = %v, want %v", got, tt.want)
 			}
 		})
@@ -107,6 +219,76 @@ func Test_isSuspectPathParam(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "iso date",
+			args: args{
+				pathPart: "2024-01-15",
+			},
+			want: true,
+		},
+		{
+			name: "iso date time",
+			args: args{
+				pathPart: "2024-01-15T10:00:00+02:00",
+			},
+			want: true,
+		},
+		{
+			name: "sha1 hash",
+			args: args{
+				pathPart: "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			},
+			want: true,
+		},
+		{
+			name: "hex hash without enough digits to be mixed",
+			args: args{
+				pathPart: "deadbeefcafebabedeadbeefcafebabe0badf00d",
+			},
+			want: true,
+		},
+		{
+			name: "ulid",
+			args: args{
+				pathPart: "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+			},
+			want: true,
+		},
+		{
+			name: "base64 token without enough digits to be mixed",
+			args: args{
+				pathPart: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
+			},
+			want: true,
+		},
+		{
+			name: "camel case word is not base64",
+			args: args{
+				pathPart: "getAllUsersForAccounts",
+			},
+			want: false,
+		},
+		{
+			name: "camel case name with digits is not base64",
+			args: args{
+				pathPart: "getUserById2DetailsX",
+			},
+			want: false,
+		},
+		{
+			name: "long camel case name with digits is not base64",
+			args: args{
+				pathPart: "listAllOrdersForCustomer2WithDetailsV3",
+			},
+			want: false,
+		},
+		{
+			name: "short base64",
+			args: args{
+				pathPart: "aGVsbG8",
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -117,6 +299,150 @@ func Test_isSuspectPathParam(t *testing.T) {
 	}
 }
 
+func Test_isLocale(t *testing.T) {
+	tests := []struct {
+		pathPart string
+		want     bool
+	}{
+		{pathPart: "en", want: true},
+		{pathPart: "en-US", want: true},
+		{pathPart: "pt_BR", want: true},
+		{pathPart: "es-419", want: true},
+		{pathPart: "zh-Hant-TW", want: true},
+		{pathPart: "zh-Hans", want: true},
+		{pathPart: "api", want: false},
+		{pathPart: "me", want: false},
+		{pathPart: "EN-us", want: false},
+		{pathPart: "en-USA", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pathPart, func(t *testing.T) {
+			if got := isLocale(tt.pathPart); got != tt.want {
+				t.Errorf("isLocale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_LearnLocalePathParams(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.LearnLocalePathParams = true
+	s := CreateDefaultSpec("host", "80", config)
+	learnMergePathsTestPaths(t, s, "/en-US/docs", "/fr/docs", "/de-DE/docs", "/api/docs")
+
+	review := s.CreateSuggestedReview()
+	assert.DeepEqual(t, getSuggestedReviewPaths(s), map[string]map[string]bool{
+		"/{locale}/docs": {"/en-US/docs": true, "/fr/docs": true, "/de-DE/docs": true},
+		"/api/docs":      {"/api/docs": true},
+	})
+
+	approvedReview := &ApprovedSpecReview{PathToPathItem: review.PathToPathItem}
+	for i, pathReview := range review.PathItemsReview {
+		approvedReview.PathItemsReview = append(approvedReview.PathItemsReview, &ApprovedSpecReviewPathItem{
+			ReviewPathItem: pathReview.ReviewPathItem,
+			PathUUID:       strconv.Itoa(i),
+		})
+	}
+	assert.NilError(t, s.ApplyApprovedReview(approvedReview))
+	pathItem := s.ApprovedSpec.GetPathItem("/{locale}/docs")
+	assert.Assert(t, pathItem != nil)
+	assert.Equal(t, len(pathItem.Parameters), 1)
+	assert.Equal(t, pathItem.Parameters[0].Name, localePathParamName)
+	assert.Equal(t, pathItem.Parameters[0].Type, schemaTypeString)
+	assert.DeepEqual(t, pathItem.Parameters[0].Enum, []interface{}{"de-DE", "en-US", "fr"})
+}
+
+func Test_getCompositePathPartPattern(t *testing.T) {
+	tests := []struct {
+		pathPart string
+		want     string
+	}{
+		{pathPart: "123:456", want: "^[0-9]+:[0-9]+$"},
+		{pathPart: "user-123", want: "^user-[0-9]+$"},
+		{pathPart: "v1.2", want: `^v1\.[0-9]+$`},
+		{pathPart: "order_2a1b3c4d5e6f", want: "^order_[0-9A-Za-z]+$"},
+		{pathPart: "blob~da39a3ee5e6b4b0d3255bfef95601890afd80709", want: "^blob~[0-9a-fA-F]+$"},
+		{pathPart: "user-profile", want: ""},
+		{pathPart: "report.json", want: ""},
+		{pathPart: "users", want: ""},
+		{pathPart: "abc12345", want: ""},
+		// suspect params as a whole
+		{pathPart: "123", want: ""},
+		{pathPart: "2024-01-15", want: ""},
+		{pathPart: "3fa85f64-5717-4562-b3fc-2c963f66afa6", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pathPart, func(t *testing.T) {
+			if got := getCompositePathPartPattern(tt.pathPart); got != tt.want {
+				t.Errorf("getCompositePathPartPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getCompositePathParamPattern(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{
+			name:   "same pattern",
+			values: []string{"user-1", "user-234"},
+			want:   "^user-[0-9]+$",
+		},
+		{
+			name:   "different literal parts",
+			values: []string{"user-1", "group-2"},
+			want:   "",
+		},
+		{
+			name:   "not all composite",
+			values: []string{"user-1", "123"},
+			want:   "",
+		},
+		{
+			name:   "no values",
+			values: nil,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getCompositePathParamPattern(tt.values); got != tt.want {
+				t.Errorf("getCompositePathParamPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_LearnCompositePathParams(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.LearnCompositePathParams = true
+	s := CreateDefaultSpec("host", "80", config)
+	learnMergePathsTestPaths(t, s, "/api/users/user-1", "/api/users/user-22", "/api/users/user-12345", "/api/users/me")
+
+	review := s.CreateSuggestedReview()
+	assert.DeepEqual(t, getSuggestedReviewPaths(s), map[string]map[string]bool{
+		"/api/users/{param1}": {"/api/users/user-1": true, "/api/users/user-22": true, "/api/users/user-12345": true},
+		"/api/users/me":       {"/api/users/me": true},
+	})
+
+	approvedReview := &ApprovedSpecReview{PathToPathItem: review.PathToPathItem}
+	for i, pathReview := range review.PathItemsReview {
+		approvedReview.PathItemsReview = append(approvedReview.PathItemsReview, &ApprovedSpecReviewPathItem{
+			ReviewPathItem: pathReview.ReviewPathItem,
+			PathUUID:       strconv.Itoa(i),
+		})
+	}
+	assert.NilError(t, s.ApplyApprovedReview(approvedReview))
+	pathItem := s.ApprovedSpec.GetPathItem("/api/users/{param1}")
+	assert.Assert(t, pathItem != nil)
+	assert.Equal(t, len(pathItem.Parameters), 1)
+	assert.Equal(t, pathItem.Parameters[0].Type, schemaTypeString)
+	assert.Equal(t, pathItem.Parameters[0].Pattern, "^user-[0-9]+$")
+}
+
 func Test_countDigitsInString(t *testing.T) {
 	type args struct {
 		s string
@@ -274,6 +600,86 @@ func Test_getParamTypeAndFormat(t *testing.T) {
 			wantType:   schemaTypeString,
 			wantFormat: "",
 		},
+		{
+			name: "date",
+			args: args{
+				paramsList: []string{"2024-01-15", "2024-02-29"},
+			},
+			wantType:   schemaTypeString,
+			wantFormat: formatDate,
+		},
+		{
+			name: "date time",
+			args: args{
+				paramsList: []string{"2024-01-15T10:00:00Z", "2024-01-15T10:00:00.123+02:00"},
+			},
+			wantType:   schemaTypeString,
+			wantFormat: formatDateTime,
+		},
+		{
+			name: "unix time",
+			args: args{
+				paramsList: []string{"1705276800", "1705276800123"},
+			},
+			wantType:   schemaTypeInteger,
+			wantFormat: formatUnixTime,
+		},
+		{
+			name: "unix time and number",
+			args: args{
+				paramsList: []string{"1705276800", "12"},
+			},
+			wantType:   schemaTypeInteger,
+			wantFormat: "",
+		},
+		{
+			name: "number out of unix time range",
+			args: args{
+				paramsList: []string{"9999999999"},
+			},
+			wantType:   schemaTypeInteger,
+			wantFormat: "",
+		},
+		{
+			name: "date and date time",
+			args: args{
+				paramsList: []string{"2024-01-15", "2024-01-15T10:00:00Z"},
+			},
+			wantType:   schemaTypeString,
+			wantFormat: "",
+		},
+		{
+			name: "hex hash",
+			args: args{
+				paramsList: []string{"da39a3ee5e6b4b0d3255bfef95601890afd80709", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+			},
+			wantType:   schemaTypeString,
+			wantFormat: formatHex,
+		},
+		{
+			name: "ulid",
+			args: args{
+				paramsList: []string{"01ARZ3NDEKTSV4RRFFQ69G5FAV", "01bx5zzkbkactav9wevgemmvrz"},
+			},
+			wantType:   schemaTypeString,
+			wantFormat: formatULID,
+		},
+		{
+			name: "base64",
+			args: args{
+				paramsList: []string{"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", "dGhpcyBpcyBhIGxvbmdlciB0b2tlbg_X2-9a=="},
+			},
+			wantType:   schemaTypeString,
+			wantFormat: formatByte,
+		},
+		{
+			name: "hex hash and base64",
+			args: args{
+				paramsList: []string{"da39a3ee5e6b4b0d3255bfef95601890afd80709", "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"},
+			},
+			wantType:   schemaTypeString,
+			wantFormat: "",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -319,3 +725,90 @@ func Test_createPathParam(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractPathParamValues(t *testing.T) {
+	params := []spec.Parameter{
+		*createPathParam("param1", schemaTypeInteger, "").Parameter,
+		*createPathParam("param2", schemaTypeString, formatUUID).Parameter,
+		*spec.QueryParam("param3").Typed(schemaTypeInteger, ""),
+	}
+	type args struct {
+		parameterizedPath string
+		path              string
+		params            []spec.Parameter
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "typed params",
+			args: args{
+				parameterizedPath: "/api/{param1}/items/{param2}",
+				path:              "/api/1/items/ed1a1a32-3b1f-4a9e-8b66-3c2f6e2b1b0a",
+				params:            params,
+			},
+			want: map[string]interface{}{
+				"param1": int64(1),
+				"param2": "ed1a1a32-3b1f-4a9e-8b66-3c2f6e2b1b0a",
+			},
+			wantErr: false,
+		},
+		{
+			name: "param with no learned type remains a string",
+			args: args{
+				parameterizedPath: "/api/{param3}",
+				path:              "/api/1",
+				params:            params,
+			},
+			want: map[string]interface{}{
+				"param3": "1",
+			},
+			wantErr: false,
+		},
+		{
+			name: "integer param coercion failure",
+			args: args{
+				parameterizedPath: "/api/{param1}",
+				path:              "/api/abc",
+				params:            params,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "uuid param coercion failure",
+			args: args{
+				parameterizedPath: "/api/{param2}",
+				path:              "/api/abc",
+				params:            params,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "path mismatch",
+			args: args{
+				parameterizedPath: "/api/{param1}",
+				path:              "/users/1",
+				params:            params,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractPathParamValues(tt.args.parameterizedPath, tt.args.path, tt.args.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractPathParamValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractPathParamValues() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
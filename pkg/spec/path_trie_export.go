@@ -0,0 +1,107 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+)
+
+type PathTrieKind string
+
+const (
+	// PathTrieKindApproved the approved paths, valued by their path ID
+	PathTrieKindApproved PathTrieKind = "approved"
+	// PathTrieKindProvided the provided spec paths, valued by their path ID
+	PathTrieKindProvided PathTrieKind = "provided"
+	// PathTrieKindLearning the parameterized paths suggested for review, valued by the learned paths they merge
+	PathTrieKindLearning PathTrieKind = "learning"
+)
+
+type PathTrieFormat string
+
+const (
+	// PathTrieFormatDOT a Graphviz DOT digraph
+	PathTrieFormatDOT PathTrieFormat = "dot"
+	// PathTrieFormatJSON a nested JSON tree of pathtrie.JSONTreeNode
+	PathTrieFormatJSON PathTrieFormat = "json"
+)
+
+// ExportPathTrie writes the path trie of kind in format, to inspect how the paths were parameterized.
+func (s *Spec) ExportPathTrie(w io.Writer, kind PathTrieKind, format PathTrieFormat) error {
+	s.lock.RLock()
+	trie, err := s.getPathTrie(kind)
+	s.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case PathTrieFormatDOT:
+		return trie.WriteDOT(w, fmt.Sprintf("%v:%v %v", s.Host, s.Port, kind))
+	case PathTrieFormatJSON:
+		if err := json.NewEncoder(w).Encode(trie.ToJSONTree()); err != nil {
+			return fmt.Errorf("failed to write JSON tree: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown path trie format: %v", format)
+	}
+}
+
+// exportedPathTrie is a path trie of any value type, see ExportPathTrie.
+type exportedPathTrie interface {
+	WriteDOT(w io.Writer, name string) error
+	ToJSONTree() []*pathtrie.JSONTreeNode
+}
+
+func (s *Spec) getPathTrie(kind PathTrieKind) (exportedPathTrie, error) {
+	switch kind {
+	case PathTrieKindApproved:
+		approved := s.ApprovedPathTrie.Clone()
+		return &approved, nil
+	case PathTrieKindProvided:
+		provided := s.ProvidedPathTrie.Clone()
+		return &provided, nil
+	case PathTrieKindLearning:
+		learning := s.createLearningPathTrie()
+		return &learning, nil
+	default:
+		return nil, fmt.Errorf("unknown path trie kind: %v", kind)
+	}
+}
+
+// createLearningPathTrie returns a trie of the learning parameterized paths, valued by the sorted learned paths
+// each of them merges.
+func (s *Spec) createLearningPathTrie() pathtrie.TypedPathTrie[[]string] {
+	trie := pathtrie.NewTyped[[]string]()
+	if s.LearningSpec == nil {
+		return trie
+	}
+	for parameterizedPath, paths := range s.createLearningParametrizedPaths().Paths {
+		learnedPaths := make([]string, 0, len(paths))
+		for path := range paths {
+			learnedPaths = append(learnedPaths, path)
+		}
+		sort.Strings(learnedPaths)
+		trie.Insert(parameterizedPath, learnedPaths)
+	}
+	return trie
+}
@@ -0,0 +1,62 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+)
+
+func TestSpec_ExportPathTrie(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for _, path := range []string{"/api/1", "/api/2", "/api/latest"} {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("", http.MethodGet, path, "host", "200", "", `{"a":1}`)))
+	}
+	s.ApprovedPathTrie.Insert("/api/{id}", "path-id")
+
+	b := &strings.Builder{}
+	assert.NilError(t, s.ExportPathTrie(b, PathTrieKindLearning, PathTrieFormatJSON))
+	var learningTree []*pathtrie.JSONTreeNode
+	assert.NilError(t, json.Unmarshal([]byte(b.String()), &learningTree))
+	assert.DeepEqual(t, learningTree, []*pathtrie.JSONTreeNode{
+		{
+			Name:     "api",
+			FullPath: "/api",
+			Children: []*pathtrie.JSONTreeNode{
+				{Name: "latest", FullPath: "/api/latest", Value: []interface{}{"/api/latest"}},
+				{Name: "{param1}", FullPath: "/api/{param1}", Value: []interface{}{"/api/1", "/api/2"}},
+			},
+		},
+	})
+
+	b = &strings.Builder{}
+	assert.NilError(t, s.ExportPathTrie(b, PathTrieKindApproved, PathTrieFormatDOT))
+	assert.Assert(t, strings.HasPrefix(b.String(), `digraph "host:80 approved" {`), b.String())
+	assert.Assert(t, strings.Contains(b.String(), `[label="{id}\npath-id", tooltip="/api/{id}", shape=box, style=dashed]`), b.String())
+
+	b = &strings.Builder{}
+	assert.NilError(t, s.ExportPathTrie(b, PathTrieKindProvided, PathTrieFormatJSON))
+	assert.Equal(t, b.String(), "null\n")
+
+	assert.ErrorContains(t, s.ExportPathTrie(b, "unknown", PathTrieFormatJSON), "unknown path trie kind")
+	assert.ErrorContains(t, s.ExportPathTrie(b, PathTrieKindLearning, "svg"), "unknown path trie format")
+}
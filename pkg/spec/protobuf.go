@@ -0,0 +1,262 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// the media type params that may hold the full name of the body message, e.g.
+// application/x-protobuf; messageType=example.v1.User
+var protoMessageTypeMediaTypeParams = []string{"messagetype", "proto"}
+
+const (
+	// gRPC messages are prefixed with a compressed flag byte and a 4 bytes message length
+	grpcMessagePrefixLength = 5
+
+	protoTimestampFullName     = "google.protobuf.Timestamp"
+	protoDurationFullName      = "google.protobuf.Duration"
+	protoWrappersPackage       = "google.protobuf"
+	protoWrapperNameSuffix     = "Value"
+	protoWrapperValueFieldName = "value"
+)
+
+// ProtoDescriptorRegistry holds the protobuf descriptors used to decode application/x-protobuf and application/grpc
+// bodies, see OperationGeneratorConfig.ProtoDescriptors.
+type ProtoDescriptorRegistry struct {
+	files *protoregistry.Files
+}
+
+// NewProtoDescriptorRegistry creates a registry from serialized FileDescriptorSets, as written by
+// protoc --include_imports --descriptor_set_out.
+func NewProtoDescriptorRegistry(fileDescriptorSets ...[]byte) (*ProtoDescriptorRegistry, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, data := range fileDescriptorSets {
+		fileDescriptorSet := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(data, fileDescriptorSet); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal file descriptor set: %v", err)
+		}
+		set.File = append(set.File, fileDescriptorSet.File...)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proto files registry: %v", err)
+	}
+
+	return &ProtoDescriptorRegistry{files: files}, nil
+}
+
+// LoadProtoDescriptorRegistry creates a registry from FileDescriptorSet files, see NewProtoDescriptorRegistry.
+func LoadProtoDescriptorRegistry(paths ...string) (*ProtoDescriptorRegistry, error) {
+	fileDescriptorSets := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file descriptor set (%v): %v", path, err)
+		}
+		fileDescriptorSets = append(fileDescriptorSets, data)
+	}
+
+	return NewProtoDescriptorRegistry(fileDescriptorSets...)
+}
+
+// findMessage returns the descriptor of the body message, by the message type media type param, or for gRPC
+// by the input (request) or output (response) of the method of path (/package.Service/Method).
+// Returns nil if the message is unknown.
+func (r *ProtoDescriptorRegistry) findMessage(mediaTypeParams map[string]string, path string, isRequest bool) protoreflect.MessageDescriptor {
+	for _, param := range protoMessageTypeMediaTypeParams {
+		if name, ok := mediaTypeParams[param]; ok {
+			return r.findMessageByName(name)
+		}
+	}
+
+	// /package.Service/Method
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 2 {
+		return nil
+	}
+	descriptor, err := r.files.FindDescriptorByName(protoreflect.FullName(parts[0]))
+	if err != nil {
+		return nil
+	}
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	method := service.Methods().ByName(protoreflect.Name(parts[1]))
+	if method == nil {
+		return nil
+	}
+	if isRequest {
+		return method.Input()
+	}
+	return method.Output()
+}
+
+func (r *ProtoDescriptorRegistry) findMessageByName(name string) protoreflect.MessageDescriptor {
+	descriptor, err := r.files.FindDescriptorByName(protoreflect.FullName(strings.TrimPrefix(name, ".")))
+	if err != nil {
+		return nil
+	}
+	message, _ := descriptor.(protoreflect.MessageDescriptor)
+	return message
+}
+
+// getProtobufBodySchema returns the schema of a protobuf body, or nil if its message is not in the registry.
+func (o *OperationGenerator) getProtobufBodySchema(body, mediaType string, mediaTypeParams map[string]string, path string, isRequest bool) (*spec.Schema, error) {
+	messageDescriptor := o.protoDescriptors.findMessage(mediaTypeParams, path, isRequest)
+	if messageDescriptor == nil {
+		log.Infof("Unknown protobuf message, treating %v as default content type (no schema). path=%v", mediaType, path)
+		return nil, nil
+	}
+
+	return getProtobufSchema([]byte(body), messageDescriptor, utils.IsGRPCMediaType(mediaType))
+}
+
+// getProtobufSchema decodes body as a message of messageDescriptor and returns its schema, in the proto3 JSON mapping.
+// gRPC bodies are length prefixed, the schema of a stream is the schema of its first message.
+func getProtobufSchema(body []byte, messageDescriptor protoreflect.MessageDescriptor, isGRPC bool) (*spec.Schema, error) {
+	if isGRPC {
+		var err error
+		if body, err = getFirstGRPCMessage(body); err != nil {
+			return nil, err
+		}
+	}
+
+	message := dynamicpb.NewMessage(messageDescriptor)
+	if err := proto.Unmarshal(body, message); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %v message: %v", messageDescriptor.FullName(), err)
+	}
+
+	return getProtoMessageSchema(message), nil
+}
+
+func getFirstGRPCMessage(body []byte) ([]byte, error) {
+	if len(body) < grpcMessagePrefixLength {
+		return nil, fmt.Errorf("grpc message is too short")
+	}
+	if body[0] != 0 {
+		return nil, fmt.Errorf("compressed grpc messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(body[1:grpcMessagePrefixLength])
+	if uint64(len(body)-grpcMessagePrefixLength) < uint64(length) {
+		return nil, fmt.Errorf("grpc message is truncated")
+	}
+
+	return body[grpcMessagePrefixLength : grpcMessagePrefixLength+int(length)], nil
+}
+
+// getProtoMessageSchema returns the schema of the populated fields of message, named by their JSON names.
+func getProtoMessageSchema(message protoreflect.Message) *spec.Schema {
+	if schema := getProtoWellKnownTypeSchema(message); schema != nil {
+		return schema
+	}
+
+	schema := &spec.Schema{}
+	schema.AddType(schemaTypeObject, "")
+	message.Range(func(fieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		schema.SetProperty(fieldDescriptor.JSONName(), *getProtoFieldSchema(fieldDescriptor, value))
+		return true
+	})
+
+	return schema
+}
+
+func getProtoFieldSchema(fieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) *spec.Schema {
+	switch {
+	case fieldDescriptor.IsList():
+		// populated lists are not empty
+		return spec.ArrayProperty(getProtoValueSchema(fieldDescriptor, value.List().Get(0)))
+	case fieldDescriptor.IsMap():
+		// maps are JSON objects, same as learned from JSON bodies
+		schema := &spec.Schema{}
+		schema.AddType(schemaTypeObject, "")
+		value.Map().Range(func(key protoreflect.MapKey, mapValue protoreflect.Value) bool {
+			schema.SetProperty(key.String(), *getProtoValueSchema(fieldDescriptor.MapValue(), mapValue))
+			return true
+		})
+		return schema
+	}
+
+	return getProtoValueSchema(fieldDescriptor, value)
+}
+
+func getProtoValueSchema(fieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) *spec.Schema {
+	switch fieldDescriptor.Kind() {
+	case protoreflect.BoolKind:
+		return spec.BooleanProperty()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return spec.Int32Property()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return spec.Int64Property()
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// 64 bit integers are JSON strings
+		return spec.StrFmtProperty("int64")
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return spec.StrFmtProperty("uint64")
+	case protoreflect.FloatKind:
+		return spec.Float32Property()
+	case protoreflect.DoubleKind:
+		return spec.Float64Property()
+	case protoreflect.StringKind:
+		return getStringSchema(value.String())
+	case protoreflect.BytesKind:
+		return spec.StrFmtProperty(formatByte)
+	case protoreflect.EnumKind:
+		// enums are JSON strings of the value names
+		return spec.StringProperty()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return getProtoMessageSchema(value.Message())
+	}
+
+	return spec.StringProperty()
+}
+
+// getProtoWellKnownTypeSchema returns the schema of the well known types with a special JSON mapping, or nil.
+func getProtoWellKnownTypeSchema(message protoreflect.Message) *spec.Schema {
+	descriptor := message.Descriptor()
+	switch descriptor.FullName() {
+	case protoTimestampFullName:
+		return spec.DateTimeProperty()
+	case protoDurationFullName:
+		return spec.StringProperty()
+	}
+
+	// wrappers (e.g. google.protobuf.StringValue) are their wrapped value
+	if descriptor.ParentFile() != nil && string(descriptor.ParentFile().Package()) == protoWrappersPackage &&
+		strings.HasSuffix(string(descriptor.Name()), protoWrapperNameSuffix) && descriptor.Fields().Len() == 1 {
+		if valueField := descriptor.Fields().ByName(protoWrapperValueFieldName); valueField != nil && !valueField.IsList() {
+			return getProtoValueSchema(valueField, message.Get(valueField))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/assert"
+)
+
+// the descriptor set of:
+//
+//	syntax = "proto3";
+//	package example.v1;
+//	message User {
+//	  int32 id = 1;
+//	  string display_name = 2;
+//	  repeated string tags = 3;
+//	  Address address = 4;
+//	  int64 balance = 5;
+//	  google.protobuf.Timestamp created_at = 6;
+//	  google.protobuf.StringValue nickname = 7;
+//	  map<string, int32> counters = 8;
+//	}
+//	message Address { string city = 1; }
+//	message GetUserRequest { int32 id = 1; }
+//	service Users { rpc GetUser(GetUserRequest) returns (User); }
+func createTestProtoDescriptorSet(t *testing.T) []byte {
+	t.Helper()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	field := func(name string, number int32, label *descriptorpb.FieldDescriptorProto_Label, tpe descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  label,
+			Type:   tpe.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("example/v1/user.proto"),
+		Package:    proto.String("example.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto", "google/protobuf/wrappers.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					field("display_name", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					field("tags", 3, repeated, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					field("address", 4, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".example.v1.Address"),
+					field("balance", 5, optional, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+					field("created_at", 6, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+					field("nickname", 7, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.StringValue"),
+					field("counters", 8, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".example.v1.User.CountersEntry"),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("CountersEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+							field("value", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+			{
+				Name:  proto.String("Address"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("city", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")},
+			},
+			{
+				Name:  proto.String("GetUserRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_INT32, "")},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Users"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("GetUser"),
+						InputType:  proto.String(".example.v1.GetUserRequest"),
+						OutputType: proto.String(".example.v1.User"),
+					},
+				},
+			},
+		},
+	}
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+			protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+			file,
+		},
+	}
+	data, err := proto.Marshal(set)
+	assert.NilError(t, err)
+	return data
+}
+
+func createTestProtoDescriptorRegistry(t *testing.T) *ProtoDescriptorRegistry {
+	t.Helper()
+	registry, err := NewProtoDescriptorRegistry(createTestProtoDescriptorSet(t))
+	assert.NilError(t, err)
+	return registry
+}
+
+func createTestProtoUser(t *testing.T, registry *ProtoDescriptorRegistry) []byte {
+	t.Helper()
+	descriptor := registry.findMessageByName("example.v1.User")
+	assert.Assert(t, descriptor != nil)
+	user := dynamicpb.NewMessage(descriptor)
+	fields := descriptor.Fields()
+	user.Set(fields.ByName("id"), protoreflect.ValueOfInt32(1))
+	user.Set(fields.ByName("display_name"), protoreflect.ValueOfString("Jane"))
+	tags := user.Mutable(fields.ByName("tags")).List()
+	tags.Append(protoreflect.ValueOfString("admin"))
+	address := user.Mutable(fields.ByName("address")).Message()
+	address.Set(address.Descriptor().Fields().ByName("city"), protoreflect.ValueOfString("Paris"))
+	user.Set(fields.ByName("balance"), protoreflect.ValueOfInt64(100))
+	user.Set(fields.ByName("created_at"), protoreflect.ValueOfMessage(timestamppb.Now().ProtoReflect()))
+	user.Set(fields.ByName("nickname"), protoreflect.ValueOfMessage(wrapperspb.String("jj").ProtoReflect()))
+	counters := user.Mutable(fields.ByName("counters")).Map()
+	counters.Set(protoreflect.ValueOfString("visits").MapKey(), protoreflect.ValueOfInt32(3))
+
+	data, err := proto.Marshal(user)
+	assert.NilError(t, err)
+	return data
+}
+
+func createGRPCMessage(message []byte) []byte {
+	prefix := make([]byte, grpcMessagePrefixLength)
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(message)))
+	return append(prefix, message...)
+}
+
+var testProtoUserSchemaJSON = `{"type":"object","properties":{"address":{"type":"object","properties":{"city":{"type":"string"}}},"balance":{"type":"string","format":"int64"},"counters":{"type":"object","properties":{"visits":{"type":"integer","format":"int32"}}},"createdAt":{"type":"string","format":"date-time"},"displayName":{"type":"string"},"id":{"type":"integer","format":"int32"},"nickname":{"type":"string"},"tags":{"type":"array","items":{"type":"string"}}}}`
+
+func Test_getProtobufSchema(t *testing.T) {
+	registry := createTestProtoDescriptorRegistry(t)
+	user := createTestProtoUser(t, registry)
+	userDescriptor := registry.findMessageByName("example.v1.User")
+
+	tests := []struct {
+		name     string
+		body     []byte
+		isGRPC   bool
+		wantJSON string
+		wantErr  bool
+	}{
+		{
+			name:     "protobuf",
+			body:     user,
+			wantJSON: testProtoUserSchemaJSON,
+		},
+		{
+			name:     "grpc",
+			body:     createGRPCMessage(user),
+			isGRPC:   true,
+			wantJSON: testProtoUserSchemaJSON,
+		},
+		{
+			name:    "truncated grpc message",
+			body:    createGRPCMessage(user)[:10],
+			isGRPC:  true,
+			wantErr: true,
+		},
+		{
+			name:    "compressed grpc message",
+			body:    append([]byte{1}, createGRPCMessage(user)[1:]...),
+			isGRPC:  true,
+			wantErr: true,
+		},
+		{
+			name:    "invalid message",
+			body:    []byte{0xff, 0xff},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getProtobufSchema(tt.body, userDescriptor, tt.isGRPC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getProtobufSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, marshal(got), tt.wantJSON)
+		})
+	}
+}
+
+func TestProtoDescriptorRegistry_findMessage(t *testing.T) {
+	registry := createTestProtoDescriptorRegistry(t)
+	tests := []struct {
+		name            string
+		mediaTypeParams map[string]string
+		path            string
+		isRequest       bool
+		want            string
+	}{
+		{
+			name:            "message type media type param",
+			mediaTypeParams: map[string]string{"messagetype": "example.v1.User"},
+			path:            "/users/1",
+			want:            "example.v1.User",
+		},
+		{
+			name:            "proto media type param",
+			mediaTypeParams: map[string]string{"proto": ".example.v1.Address"},
+			want:            "example.v1.Address",
+		},
+		{
+			name:      "grpc request",
+			path:      "/example.v1.Users/GetUser",
+			isRequest: true,
+			want:      "example.v1.GetUserRequest",
+		},
+		{
+			name: "grpc response",
+			path: "/example.v1.Users/GetUser",
+			want: "example.v1.User",
+		},
+		{
+			name: "unknown grpc method",
+			path: "/example.v1.Users/DeleteUser",
+		},
+		{
+			name:            "unknown message",
+			mediaTypeParams: map[string]string{"messagetype": "example.v1.Unknown"},
+		},
+		{
+			name: "not a grpc path",
+			path: "/users/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := registry.findMessage(tt.mediaTypeParams, tt.path, tt.isRequest)
+			if tt.want == "" {
+				assert.Assert(t, got == nil)
+				return
+			}
+			assert.Assert(t, got != nil)
+			assert.Equal(t, string(got.FullName()), tt.want)
+		})
+	}
+}
+
+func TestSpec_LearnTelemetry_GRPC(t *testing.T) {
+	registry := createTestProtoDescriptorRegistry(t)
+	config := testOperationGeneratorConfig
+	config.ProtoDescriptors = registry
+	s := CreateDefaultSpec("host", "80", config)
+
+	getUserRequest := dynamicpb.NewMessage(registry.findMessageByName("example.v1.GetUserRequest"))
+	getUserRequest.Set(getUserRequest.Descriptor().Fields().ByName("id"), protoreflect.ValueOfInt32(1))
+	reqBody, err := proto.Marshal(getUserRequest)
+	assert.NilError(t, err)
+
+	telemetry := createTelemetry("req-id", "POST", "/example.v1.Users/GetUser", "host", "200",
+		string(createGRPCMessage(reqBody)), string(createGRPCMessage(createTestProtoUser(t, registry))))
+	telemetry.Request.Common.Headers = []*Header{{Key: contentTypeHeaderName, Value: "application/grpc"}}
+	telemetry.Response.Common.Headers = []*Header{{Key: contentTypeHeaderName, Value: "application/grpc"}}
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+
+	op := s.LearningSpec.GetPathItem("/example.v1.Users/GetUser").Post
+	assert.Assert(t, op != nil)
+	assert.Equal(t, marshal(op.Parameters[0].Schema), `{"type":"object","properties":{"id":{"type":"integer","format":"int32"}}}`)
+	assert.Equal(t, marshal(op.Responses.StatusCodeResponses[200].Schema), testProtoUserSchemaJSON)
+
+	// without descriptors the bodies are not learned
+	s = CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+	op = s.LearningSpec.GetPathItem("/example.v1.Users/GetUser").Post
+	assert.Assert(t, op != nil)
+	assert.Equal(t, len(op.Parameters), 0)
+	assert.Assert(t, op.Responses.StatusCodeResponses[200].Schema == nil)
+}
@@ -38,6 +38,17 @@ func (s *Spec) LoadProvidedSpec(providedSpec []byte, pathToPathID map[string]str
 		return fmt.Errorf("failed to convert provided spec into json: %s. %v", providedSpec, err)
 	}
 
+	// OpenAPI 3.x specs are converted into swagger 2.0, so path matching and diffing work the same
+	isOAS3, err := isOAS3Spec(jsonSpec)
+	if err != nil {
+		return fmt.Errorf("failed to detect provided spec version: %v", err)
+	}
+	if isOAS3 {
+		if jsonSpec, err = convertOAS3ToSwagger(jsonSpec); err != nil {
+			return fmt.Errorf("failed to convert OpenAPI 3.x provided spec: %v", err)
+		}
+	}
+
 	if err := validateRawJSONSpec(jsonSpec); err != nil {
 		log.Errorf("provided spec is not valid: %s. %v", jsonSpec, err)
 		return fmt.Errorf("provided spec is not valid. %w", err)
@@ -57,7 +68,7 @@ func (s *Spec) LoadProvidedSpec(providedSpec []byte, pathToPathID map[string]str
 	}
 
 	// path trie need to be repopulated from start on each new spec
-	s.ProvidedPathTrie = pathtrie.New()
+	s.ProvidedPathTrie = pathtrie.NewTyped[string]()
 	for path := range s.ProvidedSpec.Spec.Paths.Paths {
 		if pathID, ok := pathToPathID[path]; ok {
 			s.ProvidedPathTrie.Insert(path, pathID)
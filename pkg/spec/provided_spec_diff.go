@@ -0,0 +1,79 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+
+	oapi_spec "github.com/go-openapi/spec"
+
+	"github.com/apiclarity/speculator/pkg/specdiff"
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// DiffTelemetryWithProvidedSpec compares the operation of telemetry against the provided spec, reporting the
+// undocumented path, method, parameters and response code, and the changed types.
+// Returns nil if there is no provided spec.
+func (s *Spec) DiffTelemetryWithProvidedSpec(telemetry *Telemetry) (diff *specdiff.OperationDiff, err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	defer utils.RecoverPanic(&err)
+
+	if err := telemetry.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid telemetry: %w", err)
+	}
+	if !s.hasProvidedSpec() {
+		return nil, nil
+	}
+	diffParams, err := s.createDiffParamsFromTelemetry(telemetry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create diff params from telemetry. %w", err)
+	}
+
+	return specdiff.New(s.ProvidedSpec.Spec).DiffOperation(diffParams.path, diffParams.method, diffParams.operation), nil
+}
+
+// DiffLearnedWithProvidedSpec compares the approved and pending learned operations, by their parameterized paths,
+// against the provided spec. Returns nil if there is no provided spec.
+func (s *Spec) DiffLearnedWithProvidedSpec() (*specdiff.SpecDiff, error) {
+	s.lock.RLock()
+	clonedSpec, err := s.SpecInfoClone()
+	opGenerator := s.OpGenerator
+	s.lock.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone spec. %v", err)
+	}
+	clonedSpec.OpGenerator = opGenerator
+	if !clonedSpec.hasProvidedSpec() {
+		return nil, nil
+	}
+
+	pathItems := make(map[string]*oapi_spec.PathItem)
+	if clonedSpec.ApprovedSpec != nil {
+		for path, pathItem := range clonedSpec.ApprovedSpec.PathItems {
+			pathItems[path] = pathItem
+		}
+	}
+	unapprovedPathItems, _ := clonedSpec.createUnapprovedPathItems()
+	for path, pathItem := range unapprovedPathItems {
+		if approvedPathItem, ok := pathItems[path]; ok {
+			pathItem = MergePathItems(approvedPathItem, pathItem)
+		}
+		pathItems[path] = pathItem
+	}
+
+	return specdiff.New(clonedSpec.ProvidedSpec.Spec).DiffSpec(pathItems), nil
+}
@@ -0,0 +1,99 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/specdiff"
+)
+
+const testDiffProvidedSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "test", "version": "1.0.0"},
+  "paths": {
+    "/api/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "type": "integer"}],
+      "get": {
+        "responses": {
+          "200": {"description": "ok", "schema": {"type": "object", "properties": {"message": {"type": "string"}}}}
+        }
+      }
+    }
+  }
+}`
+
+func TestSpec_DiffTelemetryWithProvidedSpec(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	telemetry := createTelemetry("req-id", http.MethodGet, "/api/1", "host", "200", "", Data.RespBody)
+
+	diff, err := s.DiffTelemetryWithProvidedSpec(telemetry)
+	assert.NilError(t, err)
+	assert.Assert(t, diff == nil)
+
+	assert.NilError(t, s.LoadProvidedSpec([]byte(testDiffProvidedSpec), map[string]string{}))
+	diff, err = s.DiffTelemetryWithProvidedSpec(telemetry)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff, &specdiff.OperationDiff{
+		Path:         "/api/1",
+		Method:       http.MethodGet,
+		ProvidedPath: "/api/{id}",
+		Changes: []specdiff.Change{
+			{Kind: specdiff.ChangeKindNewProperty, Location: "/responses/200/schema/properties/cvss", Learned: "array"},
+		},
+	})
+
+	diff, err = s.DiffTelemetryWithProvidedSpec(createTelemetry("req-id", http.MethodPost, "/api/1", "host", "201", "", ""))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff.Changes, []specdiff.Change{{Kind: specdiff.ChangeKindUndocumentedMethod}})
+}
+
+func TestSpec_DiffLearnedWithProvidedSpec(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for i := 1; i <= 2; i++ {
+		path := "/api/" + strconv.Itoa(i)
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", "", `{"message":"hi"}`)))
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "500", "", "")))
+	}
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/health", "host", "200", "", "")))
+
+	diff, err := s.DiffLearnedWithProvidedSpec()
+	assert.NilError(t, err)
+	assert.Assert(t, diff == nil)
+
+	assert.NilError(t, s.LoadProvidedSpec([]byte(testDiffProvidedSpec), map[string]string{}))
+	diff, err = s.DiffLearnedWithProvidedSpec()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, diff, &specdiff.SpecDiff{
+		Operations: []*specdiff.OperationDiff{
+			{
+				Path:         "/api/{param1}",
+				Method:       http.MethodGet,
+				ProvidedPath: "/api/{id}",
+				Changes:      []specdiff.Change{{Kind: specdiff.ChangeKindUndocumentedResponseCode, Location: "/responses/500"}},
+			},
+			{
+				Path:    "/health",
+				Method:  http.MethodGet,
+				Changes: []specdiff.Change{{Kind: specdiff.ChangeKindUndocumentedPath}},
+			},
+		},
+	})
+}
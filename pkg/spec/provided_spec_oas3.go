@@ -0,0 +1,623 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+	"github.com/apiclarity/speculator/pkg/utils/slice"
+)
+
+const (
+	oas3SchemasRefPrefix       = "#/components/schemas/"
+	oas3ComponentsRefPrefix    = "#/components/"
+	maxOAS3ComponentRefDepth   = 10
+	securityTypeHTTPBearerName = "Authorization"
+)
+
+var serverVariable = regexp.MustCompile(`{([^}]+)}`)
+
+// isOAS3Spec returns true if the spec (json) is an OpenAPI 3.x document.
+func isOAS3Spec(jsonSpec []byte) (bool, error) {
+	var probe struct {
+		OpenAPI string `json:"openapi"`
+	}
+	if err := json.Unmarshal(jsonSpec, &probe); err != nil {
+		return false, fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+
+	return strings.HasPrefix(probe.OpenAPI, "3."), nil
+}
+
+// convertOAS3ToSwagger converts an OpenAPI 3.0/3.1 spec (json) into a swagger 2.0 spec (json).
+// OpenAPI 3.x features that have no swagger 2.0 equivalent (cookie params, openIdConnect security,
+// request bodies of several schemas, etc.) are dropped with a warning.
+func convertOAS3ToSwagger(oas3JSON []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(oas3JSON, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %v", err)
+	}
+
+	c := &oas3Converter{doc: doc}
+	swagger := map[string]interface{}{
+		"swagger": "2.0",
+		"info":    doc["info"],
+		"paths":   map[string]interface{}{},
+	}
+	c.addServer(swagger)
+	if security, ok := doc["security"]; ok {
+		swagger["security"] = security
+	}
+
+	components := getObject(doc, "components")
+	if schemas := getObject(components, "schemas"); len(schemas) > 0 {
+		definitions := map[string]interface{}{}
+		for name, schema := range schemas {
+			definitions[name] = c.convertSchema(schema)
+		}
+		swagger["definitions"] = definitions
+	}
+	if securitySchemes := getObject(components, "securitySchemes"); len(securitySchemes) > 0 {
+		securityDefinitions := map[string]interface{}{}
+		for name, securityScheme := range securitySchemes {
+			if definition := c.convertSecurityScheme(name, c.resolve(securityScheme)); definition != nil {
+				securityDefinitions[name] = definition
+			}
+		}
+		swagger["securityDefinitions"] = securityDefinitions
+	}
+
+	paths := swagger["paths"].(map[string]interface{})
+	for path, pathItem := range getObject(doc, "paths") {
+		paths[path] = c.convertPathItem(c.resolve(pathItem))
+	}
+
+	ret, err := json.Marshal(swagger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal spec: %v", err)
+	}
+	return ret, nil
+}
+
+type oas3Converter struct {
+	doc map[string]interface{}
+}
+
+// addServer sets host, basePath and schemes from the first server, swagger 2.0 supports a single server.
+func (c *oas3Converter) addServer(swagger map[string]interface{}) {
+	servers, _ := c.doc["servers"].([]interface{})
+	if len(servers) == 0 {
+		return
+	}
+	if len(servers) > 1 {
+		log.Warnf("Using the first of %v servers", len(servers))
+	}
+	server, _ := servers[0].(map[string]interface{})
+	serverURL, _ := server["url"].(string)
+	variables := getObject(server, "variables")
+	serverURL = serverVariable.ReplaceAllStringFunc(serverURL, func(match string) string {
+		variable := getObject(variables, strings.Trim(match, "{}"))
+		if value, ok := variable["default"].(string); ok {
+			return value
+		}
+		return match
+	})
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		log.Warnf("Ignoring invalid server url %v: %v", serverURL, err)
+		return
+	}
+	if u.Host != "" {
+		swagger["host"] = u.Host
+	}
+	if u.Scheme != "" {
+		swagger["schemes"] = []string{u.Scheme}
+	}
+	if basePath := strings.TrimSuffix(u.Path, "/"); basePath != "" {
+		swagger["basePath"] = basePath
+	}
+}
+
+// resolve returns the component v refers to if it is a ref to a component other than a schema
+// (parameters, responses, request bodies...), since those are inlined in the converted spec.
+func (c *oas3Converter) resolve(v interface{}) map[string]interface{} {
+	obj, _ := v.(map[string]interface{})
+	for depth := 0; depth < maxOAS3ComponentRefDepth; depth++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, oas3ComponentsRefPrefix) || strings.HasPrefix(ref, oas3SchemasRefPrefix) {
+			return obj
+		}
+		// #/components/<kind>/<name>
+		parts := strings.SplitN(strings.TrimPrefix(ref, oas3ComponentsRefPrefix), "/", 2)
+		if len(parts) != 2 {
+			return obj
+		}
+		obj = getObject(getObject(getObject(c.doc, "components"), parts[0]), unescapeJSONPointer(parts[1]))
+	}
+	log.Warnf("Maximum ref depth was reached")
+	return obj
+}
+
+func (c *oas3Converter) convertPathItem(pathItem map[string]interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	if params := c.convertParameters(pathItem["parameters"]); len(params) > 0 {
+		ret["parameters"] = params
+	}
+	for _, method := range supportedMethods {
+		key := strings.ToLower(method)
+		if operation, ok := pathItem[key].(map[string]interface{}); ok {
+			ret[key] = c.convertOperation(operation)
+		}
+	}
+	return ret
+}
+
+func (c *oas3Converter) convertOperation(operation map[string]interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	for key, value := range operation {
+		switch key {
+		case "tags", "summary", "description", "operationId", "deprecated", "security":
+			ret[key] = value
+		default:
+			if strings.HasPrefix(key, "x-") {
+				ret[key] = value
+			}
+		}
+	}
+
+	params := c.convertParameters(operation["parameters"])
+	if requestBody := c.resolve(operation["requestBody"]); requestBody != nil {
+		consumes, bodyParams := c.convertRequestBody(requestBody)
+		if len(consumes) > 0 {
+			ret["consumes"] = consumes
+		}
+		params = append(params, bodyParams...)
+	}
+	if len(params) > 0 {
+		ret["parameters"] = params
+	}
+
+	responses := map[string]interface{}{}
+	var produces []string
+	for statusCode, response := range getObject(operation, "responses") {
+		var responseProduces []string
+		responses[statusCode], responseProduces = c.convertResponse(c.resolve(response))
+		produces = append(produces, responseProduces...)
+	}
+	ret["responses"] = responses
+	if produces = slice.RemoveStringDuplicates(produces); len(produces) > 0 {
+		sort.Strings(produces)
+		ret["produces"] = produces
+	}
+
+	return ret
+}
+
+func (c *oas3Converter) convertParameters(v interface{}) []interface{} {
+	params, _ := v.([]interface{})
+
+	var ret []interface{}
+	for _, param := range params {
+		if converted := c.convertParameter(c.resolve(param)); converted != nil {
+			ret = append(ret, converted)
+		}
+	}
+	return ret
+}
+
+func (c *oas3Converter) convertParameter(param map[string]interface{}) map[string]interface{} {
+	in, _ := param["in"].(string)
+	if in == "cookie" {
+		log.Warnf("Ignoring cookie parameter %v, not supported by swagger 2.0", param["name"])
+		return nil
+	}
+
+	ret := map[string]interface{}{
+		"name": param["name"],
+		"in":   in,
+	}
+	for _, key := range []string{"description", "required"} {
+		if value, ok := param[key]; ok {
+			ret[key] = value
+		}
+	}
+
+	schema, _ := c.convertSchema(param["schema"]).(map[string]interface{})
+	c.addSimpleSchema(ret, schema)
+	if ret["type"] == schemaTypeArray {
+		style, _ := param["style"].(string)
+		explode, hasExplode := param["explode"].(bool)
+		ret["collectionFormat"] = getCollectionFormat(in, style, explode, hasExplode)
+	}
+
+	return ret
+}
+
+// addSimpleSchema sets the swagger 2.0 simple schema fields (of non body params and headers) of target from schema.
+func (c *oas3Converter) addSimpleSchema(target, schema map[string]interface{}) {
+	for _, keyword := range simpleSchemaKeywords {
+		if value, ok := schema[keyword]; ok {
+			target[keyword] = value
+		}
+	}
+	if _, ok := target["type"]; !ok {
+		// simple schemas must have a (primitive) type
+		target["type"] = schemaTypeString
+	}
+	if items, ok := target["items"].(map[string]interface{}); ok {
+		simpleItems := map[string]interface{}{}
+		c.addSimpleSchema(simpleItems, items)
+		target["items"] = simpleItems
+	}
+}
+
+// getCollectionFormat returns the swagger 2.0 collectionFormat of an array parameter serialization style.
+func getCollectionFormat(in, style string, explode, hasExplode bool) string {
+	switch style {
+	case "spaceDelimited":
+		return collectionFormatSpace
+	case "pipeDelimited":
+		return collectionFormatPipe
+	case "", "form":
+		// form is the default style of query params, and explode is true by default for form
+		if in == parametersInQuery && (!hasExplode || explode) {
+			return collectionFormatMulti
+		}
+	}
+	return collectionFormatComma
+}
+
+// convertRequestBody converts a request body into swagger 2.0 consumes and body (or formData) params.
+func (c *oas3Converter) convertRequestBody(requestBody map[string]interface{}) ([]string, []interface{}) {
+	required, _ := requestBody["required"].(bool)
+	content := getObject(requestBody, "content")
+
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	var params []interface{}
+	var bodySchema, formSchema interface{}
+	for _, mediaType := range mediaTypes {
+		schema := getObject(content, mediaType)["schema"]
+		if mediaType == mediaTypeApplicationForm || mediaType == mediaTypeMultipartFormData {
+			if formSchema == nil {
+				formSchema = schema
+			}
+			continue
+		}
+		if bodySchema == nil || utils.IsApplicationJSONMediaType(mediaType) {
+			// a single body schema is supported, prefer the json one
+			bodySchema = schema
+		}
+	}
+
+	if bodySchema != nil {
+		param := map[string]interface{}{
+			"name":   inBodyParameterName,
+			"in":     parametersInBody,
+			"schema": c.convertSchema(bodySchema),
+		}
+		if required {
+			param["required"] = true
+		}
+		if description, ok := requestBody["description"]; ok {
+			param["description"] = description
+		}
+		params = append(params, param)
+	}
+	if formSchema != nil {
+		params = append(params, c.createFormDataParams(formSchema)...)
+	}
+
+	return mediaTypes, params
+}
+
+// createFormDataParams creates a formData param per property of the form object schema.
+func (c *oas3Converter) createFormDataParams(formSchema interface{}) []interface{} {
+	schema := c.resolveSchema(formSchema)
+	required := map[string]bool{}
+	requiredProperties, _ := schema["required"].([]interface{})
+	for _, name := range requiredProperties {
+		if nameStr, ok := name.(string); ok {
+			required[nameStr] = true
+		}
+	}
+
+	properties := getObject(schema, "properties")
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var params []interface{}
+	for _, name := range names {
+		param := map[string]interface{}{
+			"name": name,
+			"in":   parametersInForm,
+		}
+		if required[name] {
+			param["required"] = true
+		}
+		property, _ := c.convertSchema(c.resolveSchema(properties[name])).(map[string]interface{})
+		if isBinarySchema(property) {
+			param["type"] = "file"
+		} else {
+			c.addSimpleSchema(param, property)
+		}
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// resolveSchema returns the component schema v refers to, or v itself.
+func (c *oas3Converter) resolveSchema(v interface{}) map[string]interface{} {
+	schema, _ := v.(map[string]interface{})
+	if ref, ok := schema["$ref"].(string); ok && strings.HasPrefix(ref, oas3SchemasRefPrefix) {
+		name := unescapeJSONPointer(strings.TrimPrefix(ref, oas3SchemasRefPrefix))
+		return getObject(getObject(getObject(c.doc, "components"), "schemas"), name)
+	}
+	return schema
+}
+
+func isBinarySchema(schema map[string]interface{}) bool {
+	if schema["type"] != schemaTypeString {
+		return false
+	}
+	if format := schema["format"]; format == formatBinary || format == "base64" {
+		return true
+	}
+	_, hasContentMediaType := schema["contentMediaType"]
+	return hasContentMediaType
+}
+
+func (c *oas3Converter) convertResponse(response map[string]interface{}) (map[string]interface{}, []string) {
+	ret := map[string]interface{}{
+		"description": "",
+	}
+	if description, ok := response["description"]; ok {
+		ret["description"] = description
+	}
+
+	if headers := getObject(response, "headers"); len(headers) > 0 {
+		convertedHeaders := map[string]interface{}{}
+		for name, header := range headers {
+			header := c.resolve(header)
+			convertedHeader := map[string]interface{}{}
+			if description, ok := header["description"]; ok {
+				convertedHeader["description"] = description
+			}
+			schema, _ := c.convertSchema(header["schema"]).(map[string]interface{})
+			c.addSimpleSchema(convertedHeader, schema)
+			convertedHeaders[name] = convertedHeader
+		}
+		ret["headers"] = convertedHeaders
+	}
+
+	content := getObject(response, "content")
+	produces := make([]string, 0, len(content))
+	for mediaType := range content {
+		produces = append(produces, mediaType)
+	}
+	sort.Strings(produces)
+	for _, mediaType := range produces {
+		schema, ok := getObject(content, mediaType)["schema"]
+		if !ok {
+			continue
+		}
+		// a single response schema is supported, prefer the json one
+		if _, hasSchema := ret["schema"]; !hasSchema || utils.IsApplicationJSONMediaType(mediaType) {
+			ret["schema"] = c.convertSchema(schema)
+		}
+	}
+
+	return ret, produces
+}
+
+func (c *oas3Converter) convertSecurityScheme(name string, securityScheme map[string]interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	if description, ok := securityScheme["description"]; ok {
+		ret["description"] = description
+	}
+
+	switch securityScheme["type"] {
+	case "http":
+		switch strings.ToLower(fmt.Sprint(securityScheme["scheme"])) {
+		case "basic":
+			ret["type"] = "basic"
+		case "bearer":
+			ret["type"] = "apiKey"
+			ret["name"] = securityTypeHTTPBearerName
+			ret["in"] = parametersInHeader
+		default:
+			log.Warnf("Ignoring security scheme %v, http scheme %v is not supported by swagger 2.0", name, securityScheme["scheme"])
+			return nil
+		}
+	case "apiKey":
+		if securityScheme["in"] == "cookie" {
+			log.Warnf("Ignoring security scheme %v, cookie api key is not supported by swagger 2.0", name)
+			return nil
+		}
+		ret["type"] = "apiKey"
+		ret["name"] = securityScheme["name"]
+		ret["in"] = securityScheme["in"]
+	case "oauth2":
+		ret["type"] = "oauth2"
+		flows := getObject(securityScheme, "flows")
+		// swagger 2.0 supports a single flow
+		for _, flow := range []struct{ oas3, swagger string }{
+			{"authorizationCode", "accessCode"},
+			{"implicit", "implicit"},
+			{"password", "password"},
+			{"clientCredentials", "application"},
+		} {
+			oauthFlow := getObject(flows, flow.oas3)
+			if oauthFlow == nil {
+				continue
+			}
+			ret["flow"] = flow.swagger
+			for _, key := range []string{"authorizationUrl", "tokenUrl", "scopes"} {
+				if value, ok := oauthFlow[key]; ok {
+					ret[key] = value
+				}
+			}
+			break
+		}
+	default:
+		log.Warnf("Ignoring security scheme %v, type %v is not supported by swagger 2.0", name, securityScheme["type"])
+		return nil
+	}
+
+	return ret
+}
+
+// convertSchema converts a JSON schema (of OpenAPI 3.0 or 3.1) and its sub schemas into a swagger 2.0 schema.
+func (c *oas3Converter) convertSchema(v interface{}) interface{} {
+	schema, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	ret := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		ret[key] = value
+	}
+
+	if ref, ok := ret["$ref"].(string); ok && strings.HasPrefix(ref, oas3SchemasRefPrefix) {
+		ret["$ref"] = definitionsRefPrefix + strings.TrimPrefix(ref, oas3SchemasRefPrefix)
+	}
+
+	// 3.0 nullable
+	if nullable, ok := ret["nullable"].(bool); ok {
+		delete(ret, "nullable")
+		if nullable {
+			ret["x-nullable"] = true
+		}
+	}
+	// 3.1 type arrays
+	if types, ok := ret["type"].([]interface{}); ok {
+		var nonNullTypes []interface{}
+		for _, t := range types {
+			if t == "null" {
+				ret["x-nullable"] = true
+			} else {
+				nonNullTypes = append(nonNullTypes, t)
+			}
+		}
+		switch len(nonNullTypes) {
+		case 0:
+			delete(ret, "type")
+		case 1:
+			ret["type"] = nonNullTypes[0]
+		default:
+			log.Debugf("Dropping schema type %v, multiple types are not supported by swagger 2.0", types)
+			delete(ret, "type")
+		}
+	}
+	if examples, ok := ret["examples"].([]interface{}); ok {
+		if len(examples) > 0 {
+			ret["example"] = examples[0]
+		}
+		delete(ret, "examples")
+	}
+	if constValue, ok := ret["const"]; ok {
+		ret["enum"] = []interface{}{constValue}
+		delete(ret, "const")
+	}
+	convertNumericExclusiveBound(ret, "exclusiveMaximum", "maximum")
+	convertNumericExclusiveBound(ret, "exclusiveMinimum", "minimum")
+	if discriminator, ok := ret["discriminator"].(map[string]interface{}); ok {
+		ret["discriminator"] = discriminator["propertyName"]
+	}
+	for _, keyword := range []string{"$schema", "$id", "$defs", "contentMediaType", "contentEncoding", "writeOnly"} {
+		delete(ret, keyword)
+	}
+
+	// sub schemas
+	if properties, ok := ret["properties"].(map[string]interface{}); ok {
+		convertedProperties := make(map[string]interface{}, len(properties))
+		for name, property := range properties {
+			convertedProperties[name] = c.convertSchema(property)
+		}
+		ret["properties"] = convertedProperties
+	}
+	if prefixItems, ok := ret["prefixItems"].([]interface{}); ok {
+		ret["items"] = prefixItems
+		delete(ret, "prefixItems")
+	}
+	switch items := ret["items"].(type) {
+	case map[string]interface{}:
+		ret["items"] = c.convertSchema(items)
+	case []interface{}:
+		convertedItems := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			convertedItems = append(convertedItems, c.convertSchema(item))
+		}
+		ret["items"] = convertedItems
+	}
+	for _, keyword := range []string{"additionalProperties", "not"} {
+		if subSchema, ok := ret[keyword]; ok {
+			ret[keyword] = c.convertSchema(subSchema)
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		if subSchemas, ok := ret[keyword].([]interface{}); ok {
+			convertedSubSchemas := make([]interface{}, 0, len(subSchemas))
+			for _, subSchema := range subSchemas {
+				convertedSubSchemas = append(convertedSubSchemas, c.convertSchema(subSchema))
+			}
+			ret[keyword] = convertedSubSchemas
+		}
+	}
+
+	return ret
+}
+
+// convertNumericExclusiveBound converts a JSON Schema 2020-12 numeric exclusive bound into the swagger 2.0 boolean one.
+func convertNumericExclusiveBound(schema map[string]interface{}, exclusiveKeyword, boundKeyword string) {
+	bound, ok := schema[exclusiveKeyword].(float64)
+	if !ok {
+		// swagger 2.0 (and OpenAPI 3.0) boolean bound
+		return
+	}
+	schema[boundKeyword] = bound
+	schema[exclusiveKeyword] = true
+}
+
+func getObject(obj map[string]interface{}, key string) map[string]interface{} {
+	ret, _ := obj[key].(map[string]interface{})
+	return ret
+}
+
+func unescapeJSONPointer(s string) string {
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		s = unescaped
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
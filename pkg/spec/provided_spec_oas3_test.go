@@ -0,0 +1,205 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const testOAS30ProvidedSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+  - url: https://{env}.example.com/api/
+    variables:
+      env:
+        default: prod
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/petId'
+    put:
+      parameters:
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+          explode: false
+        - name: session
+          in: cookie
+          schema:
+            type: string
+      requestBody:
+        $ref: '#/components/requestBodies/pet'
+      responses:
+        "200":
+          description: updated
+          headers:
+            X-Rate-Limit:
+              schema:
+                type: integer
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+      security:
+        - bearer: []
+  /upload:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              required: [file]
+              properties:
+                file:
+                  type: string
+                  format: binary
+                name:
+                  type: string
+      responses:
+        default:
+          description: uploaded
+components:
+  parameters:
+    petId:
+      name: petId
+      in: path
+      required: true
+      schema:
+        type: integer
+        format: int64
+  requestBodies:
+    pet:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Pet'
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          nullable: true
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+    session:
+      type: apiKey
+      in: cookie
+      name: session
+`
+
+func TestSpec_LoadProvidedSpec_OAS30(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LoadProvidedSpec([]byte(testOAS30ProvidedSpec), map[string]string{"/pets/{petId}": "1"}))
+
+	swagger := s.ProvidedSpec.Spec
+	assert.Equal(t, swagger.Host, "prod.example.com")
+	assert.Equal(t, swagger.BasePath, "/api")
+	assert.DeepEqual(t, swagger.Schemes, []string{"https"})
+	assert.Equal(t, swagger.SecurityDefinitions["bearer"].Type, "apiKey")
+	assert.Equal(t, swagger.SecurityDefinitions["bearer"].Name, "Authorization")
+	_, hasCookieScheme := swagger.SecurityDefinitions["session"]
+	assert.Assert(t, !hasCookieScheme)
+	assert.Equal(t, swagger.Definitions["Pet"].Properties["name"].Extensions["x-nullable"], true)
+
+	pets := s.ProvidedSpec.GetPathItem("/pets/{petId}")
+	assert.Equal(t, len(pets.Parameters), 1)
+	assert.Equal(t, pets.Parameters[0].Type, schemaTypeInteger)
+	put := pets.Put
+	assert.DeepEqual(t, put.Consumes, []string{mediaTypeApplicationJSON})
+	assert.DeepEqual(t, put.Produces, []string{mediaTypeApplicationJSON})
+	// the cookie param is dropped
+	assert.Equal(t, len(put.Parameters), 2)
+	assert.Equal(t, put.Parameters[0].Name, "tags")
+	assert.Equal(t, put.Parameters[0].CollectionFormat, collectionFormatComma)
+	assert.Equal(t, put.Parameters[1].In, parametersInBody)
+	assert.Equal(t, put.Parameters[1].Required, true)
+	assert.Equal(t, put.Parameters[1].Schema.Ref.String(), "#/definitions/Pet")
+	response := put.Responses.StatusCodeResponses[200]
+	assert.Equal(t, response.Schema.Ref.String(), "#/definitions/Pet")
+	assert.Equal(t, response.Headers["X-Rate-Limit"].Type, schemaTypeInteger)
+
+	upload := s.ProvidedSpec.GetPathItem("/upload").Post
+	assert.DeepEqual(t, upload.Consumes, []string{mediaTypeMultipartFormData})
+	assert.Equal(t, upload.Parameters[0].Name, "file")
+	assert.Equal(t, upload.Parameters[0].Type, "file")
+	assert.Equal(t, upload.Parameters[0].Required, true)
+	assert.Equal(t, upload.Parameters[1].Type, schemaTypeString)
+	assert.Equal(t, upload.Responses.Default.Description, "uploaded")
+
+	_, pathID, found := s.ProvidedPathTrie.GetPathAndValue("/pets/1")
+	assert.Assert(t, found)
+	assert.Equal(t, pathID, "1")
+}
+
+func TestSpec_LoadProvidedSpec_OAS31(t *testing.T) {
+	// a learned spec round trips through OpenAPI 3.1
+	learned := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, learned.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api", "host", "200", Data.ReqBody, Data.RespBody)))
+	oas2JSON, err := learned.GenerateLearningOAS()
+	assert.NilError(t, err)
+	oas31JSON, err := ConvertToOAS31(oas2JSON)
+	assert.NilError(t, err)
+
+	fromOAS2 := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, fromOAS2.LoadProvidedSpec(oas2JSON, map[string]string{"/api": "1"}))
+	fromOAS31 := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, fromOAS31.LoadProvidedSpec(oas31JSON, map[string]string{"/api": "1"}))
+
+	want, err := json.Marshal(fromOAS2.ProvidedSpec)
+	assert.NilError(t, err)
+	got, err := json.Marshal(fromOAS31.ProvidedSpec)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), string(want))
+}
+
+func TestOAS3Converter_convertSchema(t *testing.T) {
+	c := &oas3Converter{}
+	got := c.convertSchema(map[string]interface{}{
+		"type":             []interface{}{"number", "null"},
+		"exclusiveMaximum": float64(10),
+		"examples":         []interface{}{float64(1), float64(2)},
+		"properties": map[string]interface{}{
+			"kind": map[string]interface{}{"const": "cat"},
+			"ref":  map[string]interface{}{"$ref": "#/components/schemas/Ref"},
+		},
+	})
+	assert.DeepEqual(t, got, map[string]interface{}{
+		"type":             "number",
+		"x-nullable":       true,
+		"maximum":          float64(10),
+		"exclusiveMaximum": true,
+		"example":          float64(1),
+		"properties": map[string]interface{}{
+			"kind": map[string]interface{}{"enum": []interface{}{"cat"}},
+			"ref":  map[string]interface{}{"$ref": "#/definitions/Ref"},
+		},
+	})
+}
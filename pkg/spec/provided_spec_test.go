@@ -60,7 +60,7 @@ func TestSpec_LoadProvidedSpec(t *testing.T) {
 		fields               fields
 		args                 args
 		wantErr              bool
-		wantProvidedPathTrie pathtrie.PathTrie
+		wantProvidedPathTrie pathtrie.TypedPathTrie[string]
 	}{
 		{
 			name: "json spec",
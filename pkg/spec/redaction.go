@@ -0,0 +1,181 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"strings"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+const redactedValue = "[REDACTED]"
+
+// header, query param and body field names containing one of these are redacted by the default redaction policy.
+var sensitiveNameParts = []string{"authorization", "cookie", "password", "passwd", "secret", "token", "apikey", "api-key", "api_key", "session"}
+
+// RedactionPolicy defines how telemetries are redacted before they are kept or shared.
+type RedactionPolicy struct {
+	// SensitiveNames are the (case insensitive) name parts of the headers, query/form params and JSON body fields
+	// whose values are redacted
+	SensitiveNames []string
+	// MaxBodySize bodies are truncated to this size (in bytes) and marked as truncated, not truncated when 0
+	MaxBodySize int
+}
+
+// DefaultRedactionPolicy returns the policy the retained samples are redacted with.
+func DefaultRedactionPolicy() RedactionPolicy {
+	return RedactionPolicy{
+		SensitiveNames: sensitiveNameParts,
+		MaxBodySize:    maxRetainedSampleBodySize,
+	}
+}
+
+// RedactTelemetry copies telemetry with its sensitive values redacted, the caller metadata is not copied.
+func (p RedactionPolicy) RedactTelemetry(telemetry *Telemetry) *Telemetry {
+	ret := &Telemetry{
+		DestinationAddress:   telemetry.DestinationAddress,
+		DestinationNamespace: telemetry.DestinationNamespace,
+		RequestID:            telemetry.RequestID,
+		Scheme:               telemetry.Scheme,
+		SourceAddress:        telemetry.SourceAddress,
+		Source:               telemetry.Source,
+		Timestamp:            telemetry.CaptureTime(),
+		Latency:              telemetry.Latency,
+	}
+	if telemetry.Request != nil {
+		ret.Request = &Request{
+			Common: p.redactCommon(telemetry.Request.Common),
+			Host:   telemetry.Request.Host,
+			Method: telemetry.Request.Method,
+			Path:   p.redactPathQuery(telemetry.Request.Path),
+		}
+	}
+	if telemetry.Response != nil {
+		ret.Response = &Response{
+			Common:     p.redactCommon(telemetry.Response.Common),
+			StatusCode: telemetry.Response.StatusCode,
+		}
+	}
+	return ret
+}
+
+func (p RedactionPolicy) redactCommon(common *Common) *Common {
+	if common == nil {
+		return nil
+	}
+
+	ret := &Common{
+		TruncatedBody: common.TruncatedBody,
+		Version:       common.Version,
+	}
+	contentType := ""
+	for _, header := range common.Headers {
+		value := header.Value
+		if p.isSensitiveName(header.Key) {
+			value = redactedValue
+		}
+		if strings.EqualFold(header.Key, contentTypeHeaderName) {
+			contentType = header.Value
+		}
+		ret.Headers = append(ret.Headers, &Header{Key: header.Key, Value: value})
+	}
+
+	body := p.redactBody(common.Body, contentType)
+	if p.MaxBodySize > 0 && len(body) > p.MaxBodySize {
+		body = body[:p.MaxBodySize]
+		ret.TruncatedBody = true
+	}
+	ret.Body = body
+	return ret
+}
+
+func (p RedactionPolicy) redactBody(body []byte, contentType string) []byte {
+	if len(body) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case utils.IsApplicationJSONMediaType(mediaType):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			// unlearnable body, keep it as is for debugging
+			return append([]byte{}, body...)
+		}
+		redacted, err := json.Marshal(p.redactJSONValue(value))
+		if err != nil {
+			return append([]byte{}, body...)
+		}
+		return redacted
+	case mediaType == mediaTypeApplicationForm:
+		return []byte(p.redactQuery(string(body)))
+	default:
+		return append([]byte{}, body...)
+	}
+}
+
+func (p RedactionPolicy) redactJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if p.isSensitiveName(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = p.redactJSONValue(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = p.redactJSONValue(v[i])
+		}
+	}
+	return value
+}
+
+func (p RedactionPolicy) redactPathQuery(fullPath string) string {
+	path, query := GetPathAndQuery(fullPath)
+	if query == "" {
+		return fullPath
+	}
+	return path + "?" + p.redactQuery(query)
+}
+
+// redactQuery redacts the values of sensitive params, keeping the query as is otherwise.
+func (p RedactionPolicy) redactQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name := strings.SplitN(param, "=", 2)[0]
+		if p.isSensitiveName(name) {
+			params[i] = name + "=" + redactedValue
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func (p RedactionPolicy) isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range p.SensitiveNames {
+		if strings.Contains(name, strings.ToLower(part)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestRedactionPolicy_RedactTelemetry(t *testing.T) {
+	telemetry := &Telemetry{
+		Request: &Request{
+			Common: &Common{
+				Headers: []*Header{
+					{Key: "Authorization", Value: "Bearer abc"},
+					{Key: "Content-Type", Value: "application/json"},
+				},
+				Body: []byte(`{"user":"a","password":"p","nested":[{"accessToken":"t"}]}`),
+			},
+			Method: http.MethodPost,
+			Path:   "/login?user=a&api_key=k",
+		},
+		Response: &Response{
+			Common: &Common{
+				Headers: []*Header{{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+				Body:    []byte("session_id=s&name=b"),
+			},
+			StatusCode: "200",
+		},
+		Metadata: map[string]string{"asn": "1"},
+	}
+
+	got := DefaultRedactionPolicy().RedactTelemetry(telemetry)
+	assert.Equal(t, got.Request.Path, "/login?user=a&api_key=[REDACTED]")
+	assert.Equal(t, got.Request.Common.Headers[0].Value, redactedValue)
+	assert.Equal(t, got.Request.Common.Headers[1].Value, "application/json")
+	assert.Equal(t, string(got.Request.Common.Body), `{"nested":[{"accessToken":"[REDACTED]"}],"password":"[REDACTED]","user":"a"}`)
+	assert.Equal(t, string(got.Response.Common.Body), "session_id=[REDACTED]&name=b")
+	assert.Assert(t, got.Metadata == nil)
+	// the telemetry itself is not changed
+	assert.Equal(t, telemetry.Request.Common.Headers[0].Value, "Bearer abc")
+	assert.Equal(t, telemetry.Request.Path, "/login?user=a&api_key=k")
+}
+
+func TestRedactionPolicy_redactCommon(t *testing.T) {
+	body := []byte(strings.Repeat("a", maxRetainedSampleBodySize+1))
+	policy := DefaultRedactionPolicy()
+	got := policy.redactCommon(&Common{Body: body})
+	assert.Equal(t, len(got.Body), maxRetainedSampleBodySize)
+	assert.Assert(t, got.TruncatedBody)
+
+	if got := policy.redactCommon(nil); got != nil {
+		t.Errorf("redactCommon() = %v, want nil", got)
+	}
+	if got := policy.redactBody([]byte("{"), "application/json"); !reflect.DeepEqual(got, []byte("{")) {
+		t.Errorf("redactBody() = %s, want the body as is", got)
+	}
+}
+
+func TestRedactionPolicy_RedactTelemetry_CustomPolicy(t *testing.T) {
+	telemetry := &Telemetry{
+		Request: &Request{
+			Common: &Common{
+				Headers: []*Header{
+					{Key: "X-Tenant-ID", Value: "acme"},
+					{Key: "Authorization", Value: "Bearer abc"},
+					{Key: "Content-Type", Value: "application/json"},
+				},
+				Body: []byte(`{"email":"a@b.c","description":"` + strings.Repeat("a", maxRetainedSampleBodySize) + `"}`),
+			},
+			Method: http.MethodPost,
+			Path:   "/users?Email=a@b.c",
+		},
+	}
+
+	got := RedactionPolicy{SensitiveNames: []string{"Tenant", "email"}}.RedactTelemetry(telemetry)
+	assert.Equal(t, got.Request.Path, "/users?Email=[REDACTED]")
+	assert.Equal(t, got.Request.Common.Headers[0].Value, redactedValue)
+	assert.Equal(t, got.Request.Common.Headers[1].Value, "Bearer abc")
+	assert.Assert(t, strings.HasPrefix(string(got.Request.Common.Body), `{"description":"aaa`))
+	assert.Assert(t, strings.HasSuffix(string(got.Request.Common.Body), `","email":"[REDACTED]"}`))
+	assert.Assert(t, !got.Request.Common.TruncatedBody)
+	assert.Assert(t, got.Response == nil)
+}
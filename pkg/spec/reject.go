@@ -0,0 +1,129 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// the suffix of path patterns matching one or more path segments, e.g. /static/**
+const rejectedPathAnySegmentsSuffix = "/**"
+
+// RejectPath drops the learned paths matching pattern from the learning spec, with their stats, retained samples
+// and schema outliers, and never learns them again. Unlike IgnoreOperation, rejected paths are not learned at all.
+// pattern is a learned path (/healthz) or a glob (see path.Match) whose * matches a single path segment
+// (/static/*), a pattern ending with /** matches one or more path segments (/static/**).
+func (s *Spec) RejectPath(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("path pattern %v must start with /", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid path pattern %v: %v", pattern, err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.RejectedPaths == nil {
+		s.RejectedPaths = make(map[string]bool)
+	}
+	s.RejectedPaths[pattern] = true
+
+	for learnedPath := range s.LearningSpec.PathItems {
+		if matchPathPattern(pattern, learnedPath) {
+			s.dropLearnedPath(learnedPath)
+		}
+	}
+
+	return nil
+}
+
+// UnrejectPath removes a pattern added by RejectPath, so its paths are learned again.
+func (s *Spec) UnrejectPath(pattern string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.RejectedPaths, pattern)
+}
+
+// GetRejectedPaths returns the sorted rejected path patterns.
+func (s *Spec) GetRejectedPaths() []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := make([]string, 0, len(s.RejectedPaths))
+	for pattern := range s.RejectedPaths {
+		ret = append(ret, pattern)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func (s *Spec) isPathRejected(path string) bool {
+	for pattern := range s.RejectedPaths {
+		if matchPathPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropLearnedPath removes the learned path from the learning spec, and its learning state. The approved paths
+// are left as is.
+func (s *Spec) dropLearnedPath(learnedPath string) {
+	delete(s.LearningSpec.PathItems, learnedPath)
+	if _, isApproved := s.ApprovedSpec.PathItems[learnedPath]; !isApproved && s.LearningStats != nil {
+		delete(s.LearningStats.Operations, learnedPath)
+	}
+
+	retainedSamples := s.RetainedSamples[:0]
+	for _, sample := range s.RetainedSamples {
+		if sample.Path != learnedPath {
+			retainedSamples = append(retainedSamples, sample)
+		}
+	}
+	s.RetainedSamples = retainedSamples
+
+	schemaOutliers := s.SchemaOutliers[:0]
+	for _, outlier := range s.SchemaOutliers {
+		if outlier.Path != learnedPath {
+			schemaOutliers = append(schemaOutliers, outlier)
+		}
+	}
+	s.SchemaOutliers = schemaOutliers
+}
+
+// matchPathPattern returns true if path matches the glob pattern, see Spec.RejectPath.
+func matchPathPattern(pattern, pathToMatch string) bool {
+	prefix := strings.TrimSuffix(pattern, rejectedPathAnySegmentsSuffix)
+	if prefix != pattern {
+		// match the prefix against the same amount of leading path segments, followed by at least one segment
+		prefixSegmentsCount := strings.Count(prefix, "/") + 1
+		segments := strings.SplitN(pathToMatch, "/", prefixSegmentsCount+1)
+		if len(segments) <= prefixSegmentsCount {
+			return false
+		}
+		pathToMatch = strings.Join(segments[:prefixSegmentsCount], "/")
+		pattern = prefix
+	}
+
+	matched, err := path.Match(pattern, pathToMatch)
+	return err == nil && matched
+}
@@ -0,0 +1,81 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_RejectPath(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learn := func(path string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", "", `{"id": 1}`)))
+	}
+	learn("/healthz")
+	learn("/static/css/main.css")
+	learn("/api/users")
+
+	assert.ErrorContains(t, s.RejectPath("healthz"), "must start with /")
+	assert.ErrorContains(t, s.RejectPath("/static/["), "invalid path pattern")
+	assert.NilError(t, s.RejectPath("/healthz"))
+	assert.NilError(t, s.RejectPath("/static/**"))
+	assert.DeepEqual(t, s.GetRejectedPaths(), []string{"/healthz", "/static/**"})
+
+	// the rejected paths are dropped with their stats
+	assert.DeepEqual(t, getSortedPaths(s.LearningSpec.PathItems, nil), []string{"/api/users"})
+	_, ok := s.LearningStats.Operations["/healthz"]
+	assert.Assert(t, !ok)
+
+	// and are not learned again
+	learn("/healthz?verbose=true")
+	learn("/static/js/main.js")
+	assert.DeepEqual(t, getSortedPaths(s.LearningSpec.PathItems, nil), []string{"/api/users"})
+	_, ok = s.LearningStats.Operations["/healthz"]
+	assert.Assert(t, !ok)
+
+	s.UnrejectPath("/healthz")
+	learn("/healthz")
+	assert.DeepEqual(t, getSortedPaths(s.LearningSpec.PathItems, nil), []string{"/api/users", "/healthz"})
+}
+
+func Test_matchPathPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/healthz", path: "/healthz", want: true},
+		{pattern: "/healthz", path: "/healthz/live", want: false},
+		{pattern: "/static/*", path: "/static/main.css", want: true},
+		{pattern: "/static/*", path: "/static/css/main.css", want: false},
+		{pattern: "/static/**", path: "/static/css/main.css", want: true},
+		{pattern: "/static/**", path: "/static", want: false},
+		{pattern: "/static/**", path: "/statics/main.css", want: false},
+		{pattern: "/*/metrics", path: "/v1/metrics", want: true},
+		{pattern: "/*/metrics/**", path: "/v1/metrics/cpu/usage", want: true},
+		{pattern: "/*.php", path: "/index.php", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := matchPathPattern(tt.pattern, tt.path); got != tt.want {
+				t.Errorf("matchPathPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
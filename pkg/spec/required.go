@@ -0,0 +1,221 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/utils/field"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// PropertyPresenceStats are the amount of times an object field of an operation body was seen and the amount of
+// times each of its properties was present, see OperationGeneratorConfig.RequiredPropertyMinRatio.
+type PropertyPresenceStats struct {
+	// SampleCount is the amount of objects seen, an array counts each of its items
+	SampleCount int
+	// PropertyCounts is the amount of objects each property was present in, by property name
+	PropertyCounts map[string]int
+}
+
+func (p *PropertyPresenceStats) addObject(properties []string) {
+	p.SampleCount++
+	if p.PropertyCounts == nil {
+		p.PropertyCounts = make(map[string]int)
+	}
+	for _, property := range properties {
+		p.PropertyCounts[property]++
+	}
+}
+
+func (p *PropertyPresenceStats) merge(other *PropertyPresenceStats) {
+	p.SampleCount += other.SampleCount
+	for property, count := range other.PropertyCounts {
+		if p.PropertyCounts == nil {
+			p.PropertyCounts = make(map[string]int)
+		}
+		p.PropertyCounts[property] += count
+	}
+}
+
+// getRequired returns the sorted properties present in at least minRatio of the objects.
+func (p *PropertyPresenceStats) getRequired(minRatio float64) []string {
+	if p.SampleCount == 0 {
+		return nil
+	}
+	var required []string
+	for property, count := range p.PropertyCounts {
+		if float64(count) >= minRatio*float64(p.SampleCount) {
+			required = append(required, property)
+		}
+	}
+	sort.Strings(required)
+	return required
+}
+
+// recordPropertyPresence records the properties present in the objects of telemetry bodies (see
+// getTelemetryPropertyPresence). Must be called after the telemetry stats were recorded.
+func (s *Spec) recordPropertyPresence(path, method string, objects map[string][][]string) {
+	if len(objects) == 0 {
+		return
+	}
+	opStats, ok := s.LearningStats.Operations[path][method]
+	if !ok {
+		return
+	}
+	if opStats.PropertyPresence == nil {
+		opStats.PropertyPresence = make(map[string]*PropertyPresenceStats)
+	}
+	for fieldPath, fieldObjects := range objects {
+		presence, ok := opStats.PropertyPresence[fieldPath]
+		if !ok {
+			presence = &PropertyPresenceStats{}
+			opStats.PropertyPresence[fieldPath] = presence
+		}
+		for _, properties := range fieldObjects {
+			presence.addObject(properties)
+		}
+	}
+}
+
+// getTelemetryPropertyPresence returns the property names of each object of the JSON request and response bodies of
+// telemetry, by the field paths of forEachOperationField.
+func (o *OperationGenerator) getTelemetryPropertyPresence(telemetry *Telemetry) map[string][][]string {
+	objects := make(map[string][][]string)
+
+	reqHeaders := ConvertHeadersToMap(telemetry.Request.Common.Headers)
+	reqBody := string(telemetry.Request.Common.getLearningBody())
+	mediaType, _, _ := mime.ParseMediaType(reqHeaders[contentTypeHeaderName])
+	if reqBody != "" && !o.isLargePayload(mediaType, reqBody) && utils.IsApplicationJSONMediaType(mediaType) {
+		addJSONObjects(objects, reqBody, field.NewPath("parameters").Child(inBodyParameterName, "schema"))
+	}
+
+	if statusCode, err := strconv.Atoi(telemetry.Response.StatusCode); err == nil {
+		respHeaders := ConvertHeadersToMap(telemetry.Response.Common.Headers)
+		respBody := string(telemetry.Response.Common.getLearningBody())
+		mediaType, _, _ := mime.ParseMediaType(respHeaders[contentTypeHeaderName])
+		if respBody != "" && !o.isLargePayload(mediaType, respBody) && utils.IsApplicationJSONMediaType(mediaType) {
+			addJSONObjects(objects, respBody, field.NewPath("responses").Child(strconv.Itoa(statusCode), "schema"))
+		}
+	}
+
+	return objects
+}
+
+func addJSONObjects(objects map[string][][]string, body string, path *field.Path) {
+	value, err := gojsonschema.NewStringLoader(body).LoadJSON()
+	if err != nil {
+		return
+	}
+	addJSONObjectProperties(objects, value, path, 0)
+}
+
+func addJSONObjectProperties(objects map[string][][]string, value interface{}, path *field.Path, depth int) {
+	if depth >= maxSchemaToRefDepth {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties := make([]string, 0, len(v))
+		for key, propertyValue := range v {
+			// a property that may be null is not required
+			if propertyValue == nil {
+				continue
+			}
+			property := escapeString(key)
+			properties = append(properties, property)
+			addJSONObjectProperties(objects, propertyValue, path.Child("properties", property), depth+1)
+		}
+		objects[path.String()] = append(objects[path.String()], properties)
+	case []interface{}:
+		for _, item := range v {
+			addJSONObjectProperties(objects, item, path.Child("items"), depth+1)
+		}
+	}
+}
+
+// addInferredRequired sets the required properties of the body objects of pathItems, the properties present in at
+// least RequiredPropertyMinRatio of the objects seen. opStats are the operation stats by the paths of pathItems.
+func (s *Spec) addInferredRequired(pathItems map[string]*oapi_spec.PathItem, opStats map[string]map[string]*OperationStats) {
+	minRatio := s.OpGenerator.RequiredPropertyMinRatio
+	if minRatio <= 0 {
+		return
+	}
+
+	for path, pathItem := range pathItems {
+		for _, method := range supportedMethods {
+			operation := GetOperationFromPathItem(pathItem, method)
+			stats, ok := opStats[path][method]
+			if operation == nil || !ok || len(stats.PropertyPresence) == 0 {
+				continue
+			}
+			forEachOperationObject(operation, func(path *field.Path, schema *oapi_spec.Schema) {
+				presence, ok := stats.PropertyPresence[path.String()]
+				if !ok {
+					return
+				}
+				var required []string
+				for _, property := range presence.getRequired(minRatio) {
+					if _, ok := schema.Properties[property]; ok {
+						required = append(required, property)
+					}
+				}
+				schema.Required = required
+			})
+		}
+	}
+}
+
+// forEachOperationObject calls fn with each object schema of the body parameter and the responses of op, with the
+// field paths of forEachOperationField.
+func forEachOperationObject(op *oapi_spec.Operation, fn func(path *field.Path, schema *oapi_spec.Schema)) {
+	parametersPath := field.NewPath("parameters")
+	for _, param := range op.Parameters {
+		if param.In == parametersInBody {
+			forEachSchemaObject(param.Schema, parametersPath.Child(param.Name, "schema"), fn, 0)
+		}
+	}
+
+	if op.Responses == nil {
+		return
+	}
+	responsesPath := field.NewPath("responses")
+	for code, response := range op.Responses.StatusCodeResponses {
+		forEachSchemaObject(response.Schema, responsesPath.Child(strconv.Itoa(code), "schema"), fn, 0)
+	}
+}
+
+func forEachSchemaObject(schema *oapi_spec.Schema, path *field.Path, fn func(path *field.Path, schema *oapi_spec.Schema), depth int) {
+	if schema == nil || depth >= maxSchemaToRefDepth {
+		return
+	}
+	if len(schema.Properties) > 0 {
+		fn(path, schema)
+	}
+	if schema.Items != nil {
+		forEachSchemaObject(schema.Items.Schema, path.Child("items"), fn, depth+1)
+	}
+	for name := range schema.Properties {
+		property := schema.Properties[name]
+		forEachSchemaObject(&property, path.Child("properties", name), fn, depth+1)
+		schema.Properties[name] = property
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpec_GenerateLearningOAS_Required(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.RequiredPropertyMinRatio = 1
+	s := CreateDefaultSpec("host", "80", config)
+
+	telemetries := []*Telemetry{
+		createTelemetry("1", http.MethodPost, "/api", "host", "200",
+			`{"name":"a","note":"x","address":{"city":"c","zip":"1"}}`, `{"id":1,"items":[{"a":1,"b":2},{"a":2}]}`),
+		createTelemetry("2", http.MethodPost, "/api", "host", "200",
+			`{"name":"b","note":null,"address":{"city":"c"}}`, `{"id":2,"items":[{"a":3}]}`),
+	}
+	for _, telemetry := range telemetries {
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	oasJSON, err := s.GenerateLearningOAS()
+	assert.NilError(t, err)
+	swagger := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, swagger))
+	op := swagger.Paths.Paths["/api"].Post
+
+	reqSchema := resolveDefinition(swagger, op.Parameters[0].Schema)
+	assert.DeepEqual(t, reqSchema.Required, []string{"address", "name"})
+	address := reqSchema.Properties["address"]
+	assert.DeepEqual(t, resolveDefinition(swagger, &address).Required, []string{"city"})
+
+	respSchema := resolveDefinition(swagger, op.Responses.StatusCodeResponses[200].Schema)
+	assert.DeepEqual(t, respSchema.Required, []string{"id", "items"})
+	assert.DeepEqual(t, resolveDefinition(swagger, respSchema.Properties["items"].Items.Schema).Required, []string{"a"})
+}
+
+func TestSpec_GenerateLearningOAS_RequiredRatio(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.RequiredPropertyMinRatio = 0.5
+	s := CreateDefaultSpec("host", "80", config)
+	for i, body := range []string{`{"a":1,"b":1}`, `{"a":1,"b":1}`, `{"a":1,"c":1}`, `{"a":1}`} {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry(strconv.Itoa(i), http.MethodGet, "/api", "host", "200", "", body)))
+	}
+
+	oasJSON, err := s.GenerateLearningOAS()
+	assert.NilError(t, err)
+	swagger := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, swagger))
+	respSchema := resolveDefinition(swagger, swagger.Paths.Paths["/api"].Get.Responses.StatusCodeResponses[200].Schema)
+	assert.DeepEqual(t, respSchema.Required, []string{"a", "b"})
+}
+
+func TestSpec_LearnTelemetry_RequiredDisabled(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1", http.MethodGet, "/api", "host", "200", "", `{"a":1}`)))
+
+	assert.Assert(t, s.LearningStats.Operations["/api"][http.MethodGet].PropertyPresence == nil)
+	oasJSON, err := s.GenerateLearningOAS()
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(oasJSON), "required\":[\"a\"]"))
+}
+
+func TestPropertyPresenceStats(t *testing.T) {
+	stats := &PropertyPresenceStats{}
+	stats.addObject([]string{"a", "b"})
+	stats.addObject([]string{"a"})
+	assert.DeepEqual(t, stats.getRequired(1), []string{"a"})
+	assert.DeepEqual(t, stats.getRequired(0.5), []string{"a", "b"})
+
+	other := &PropertyPresenceStats{}
+	other.merge(stats)
+	other.merge(&PropertyPresenceStats{SampleCount: 2, PropertyCounts: map[string]int{"a": 1, "c": 2}})
+	assert.DeepEqual(t, other, &PropertyPresenceStats{SampleCount: 4, PropertyCounts: map[string]int{"a": 3, "b": 1, "c": 2}})
+	assert.DeepEqual(t, other.getRequired(0.75), []string{"a"})
+
+	assert.Assert(t, (&PropertyPresenceStats{}).getRequired(1) == nil)
+}
+
+// resolveDefinition returns the definition schema refers to, or schema if it is not a ref.
+func resolveDefinition(swagger *oapi_spec.Swagger, schema *oapi_spec.Schema) *oapi_spec.Schema {
+	ref := schema.Ref.String()
+	if ref == "" {
+		return schema
+	}
+	definition := swagger.Definitions[strings.TrimPrefix(ref, definitionsRefPrefix)]
+	return &definition
+}
@@ -53,11 +53,11 @@ type ReviewPathItem struct {
 
 // this function should group all paths that have suspect parameter (with a certain template), into one path which is parameterized, and then add this path params to the spec.
 func (s *Spec) CreateSuggestedReview() *SuggestedSpecReview {
-	s.lock.Lock()
-	defer s.lock.Unlock()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 
 	ret := &SuggestedSpecReview{
-		PathToPathItem: s.LearningSpec.PathItems,
+		PathToPathItem: s.getReviewablePathItems(),
 	}
 
 	learningParametrizedPaths := s.createLearningParametrizedPaths()
@@ -66,20 +66,43 @@ func (s *Spec) CreateSuggestedReview() *SuggestedSpecReview {
 		pathReview := &SuggestedSpecReviewPathItem{}
 		pathReview.ParameterizedPath = parametrizedPath
 
-		pathReview.Paths = paths
+		pathReview.Paths = make(map[string]bool)
+		for path := range paths {
+			if _, ok := ret.PathToPathItem[path]; ok {
+				pathReview.Paths[path] = true
+			}
+		}
+		if len(pathReview.Paths) == 0 {
+			continue
+		}
 
 		ret.PathItemsReview = append(ret.PathItemsReview, pathReview)
 	}
 	return ret
 }
 
+// getReviewablePathItems returns the learned path items without the ignored operations.
+func (s *Spec) getReviewablePathItems() map[string]*oapi_spec.PathItem {
+	if len(s.IgnoredOperations) == 0 {
+		return s.LearningSpec.PathItems
+	}
+
+	ret := make(map[string]*oapi_spec.PathItem)
+	for path, pathItem := range s.LearningSpec.PathItems {
+		if reviewablePathItem := s.removeIgnoredOperations(path, pathItem); reviewablePathItem != nil {
+			ret[path] = reviewablePathItem
+		}
+	}
+	return ret
+}
+
 func (s *Spec) createLearningParametrizedPaths() *LearningParametrizedPaths {
 	var learningParametrizedPaths LearningParametrizedPaths
 
 	learningParametrizedPaths.Paths = make(map[string]map[string]bool)
 
 	for path := range s.LearningSpec.PathItems {
-		parameterizedPath := createParameterizedPath(path)
+		parameterizedPath := s.getLearningParameterizedPath(path)
 		if _, ok := learningParametrizedPaths.Paths[parameterizedPath]; !ok {
 			learningParametrizedPaths.Paths[parameterizedPath] = make(map[string]bool)
 		}
@@ -88,31 +111,68 @@ func (s *Spec) createLearningParametrizedPaths() *LearningParametrizedPaths {
 	return &learningParametrizedPaths
 }
 
-func (s *Spec) ApplyApprovedReview(approvedReviews *ApprovedSpecReview) error {
+// getLearningParameterizedPath returns the parameterized path grouping the learned path, see Spec.SplitPath and Spec.MergePaths.
+func (s *Spec) getLearningParameterizedPath(path string) string {
+	if template, ok := s.PathTemplates[path]; ok {
+		return template
+	}
+	if s.SplitPaths[path] {
+		return path
+	}
+	return createParameterizedPath(path, s.OpGenerator != nil && s.OpGenerator.LearnLocalePathParams, s.isLearningCompositePathParams())
+}
+
+func (s *Spec) isLearningCompositePathParams() bool {
+	return s.OpGenerator != nil && s.OpGenerator.LearnCompositePathParams
+}
+
+// ApplyApprovedReview approves the reviewed path items, opts apply only to the reviewed operations.
+func (s *Spec) ApplyApprovedReview(approvedReviews *ApprovedSpecReview, opts ...ApproveOption) error {
+	defer s.observers.notify()
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	options := createApproveOptions(opts)
+
 	// first update the review into a copy of the state, in case the validation will fail
 	clonedSpec, err := s.SpecInfoClone()
 	if err != nil {
 		return fmt.Errorf("failed to clone spec. %v", err)
 	}
 
+	var approvedPaths []string
 	for _, pathItemReview := range approvedReviews.PathItemsReview {
 		mergedPathItem := &oapi_spec.PathItem{}
+		hasSkippedPaths := false
 		for path := range pathItemReview.Paths {
 			pathItem, ok := approvedReviews.PathToPathItem[path]
 			if !ok {
 				log.Errorf("path: %v was not found in learning spec", path)
 				continue
 			}
+			// ignored operations are never approved, even if the review includes them
+			pathItem = clonedSpec.removeIgnoredOperations(path, pathItem)
+			if pathItem == nil {
+				log.Warnf("Ignoring approval of path with only ignored operations. path=%v", path)
+				hasSkippedPaths = true
+				continue
+			}
+			pathItem = clonedSpec.removeRareOperations(path, pathItem, options.minOperationHits)
+			if pathItem == nil {
+				log.Infof("Not approving path with only rare operations. path=%v", path)
+				hasSkippedPaths = true
+				continue
+			}
 			mergedPathItem = MergePathItems(mergedPathItem, pathItem)
 
-			// delete path from learning spec
-			delete(clonedSpec.LearningSpec.PathItems, path)
+			// delete path from learning spec, its rare operations remain in learning
+			clonedSpec.removeApprovedLearnedOperations(path, options.minOperationHits)
+		}
+		if hasSkippedPaths && isEmptyPathItem(mergedPathItem) {
+			continue
 		}
 
-		addPathParamsToPathItem(mergedPathItem, pathItemReview.ParameterizedPath, pathItemReview.Paths)
+		addPathParamsToPathItem(mergedPathItem, pathItemReview.ParameterizedPath, pathItemReview.Paths, s.isLearningCompositePathParams())
 
 		// add modified path and merged path item to ApprovedSpec
 		clonedSpec.ApprovedSpec.PathItems[pathItemReview.ParameterizedPath] = mergedPathItem
@@ -125,12 +185,16 @@ func (s *Spec) ApplyApprovedReview(approvedReviews *ApprovedSpecReview) error {
 
 		// populate SecurityDefinitions from the approved merged path item
 		clonedSpec.ApprovedSpec.SecurityDefinitions = updateSecurityDefinitionsFromPathItem(clonedSpec.ApprovedSpec.SecurityDefinitions, mergedPathItem)
+		approvedPaths = append(approvedPaths, pathItemReview.ParameterizedPath)
 	}
 
-	if _, err := clonedSpec.GenerateOASJson(); err != nil {
+	oasJSON, err := clonedSpec.generateApprovedOASJson()
+	if err != nil {
 		return fmt.Errorf("failed to generate Open API Spec. %w", err)
 	}
 	s.SpecInfo = clonedSpec.SpecInfo
+	s.oasCache.set(oasJSON)
+	s.queueSpecApprovedEvent(approvedPaths)
 
 	return nil
 }
@@ -147,18 +211,26 @@ func updateSecurityDefinitionsFromPathItem(sd oapi_spec.SecurityDefinitions, ite
 	return sd
 }
 
-func addPathParamsToPathItem(pathItem *oapi_spec.PathItem, suggestedPath string, paths map[string]bool) {
+// addPathParamsToPathItem adds the params of suggestedPath to pathItem, typed by their values in paths. With
+// learnCompositePatterns, params whose values are composite path parts of the same pattern are patterned strings.
+func addPathParamsToPathItem(pathItem *oapi_spec.PathItem, suggestedPath string, paths map[string]bool, learnCompositePatterns bool) {
 	// get all parameters names from path
 	suggestedPathTrimed := strings.TrimPrefix(suggestedPath, "/")
 	parts := strings.Split(suggestedPathTrimed, "/")
 
 	for i, part := range parts {
 		if utils.IsPathParam(part) {
-			part = strings.TrimPrefix(part, utils.ParamPrefix)
-			part = strings.TrimSuffix(part, utils.ParamSuffix)
+			part = utils.GetPathParamName(part)
 			paramList := getOnlyIndexedPartFromPaths(paths, i)
 			tpe, format := getParamTypeAndFormat(paramList)
 			paramInfo := createPathParam(part, tpe, format)
+			if isLocalePathParam(part, paramList) {
+				paramInfo.Typed(schemaTypeString, "").WithEnum(getLocalePathParamEnum(paramList)...)
+			} else if learnCompositePatterns {
+				if pattern := getCompositePathParamPattern(paramList); pattern != "" {
+					paramInfo.Typed(schemaTypeString, "").WithPattern(pattern)
+				}
+			}
 			pathItem.Parameters = append(pathItem.Parameters, *paramInfo.Parameter)
 		}
 	}
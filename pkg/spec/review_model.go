@@ -0,0 +1,187 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+
+	oapi_spec "github.com/go-openapi/spec"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+type ReviewOperationStatus string
+
+const (
+	// ReviewOperationStatusNew the operation is not documented by the provided spec (or there is no provided spec)
+	ReviewOperationStatusNew ReviewOperationStatus = "NEW"
+	// ReviewOperationStatusMatched the operation is documented by the provided spec as learned
+	ReviewOperationStatusMatched ReviewOperationStatus = "MATCHED"
+	// ReviewOperationStatusChanged the operation is documented by the provided spec, but differently than learned
+	ReviewOperationStatusChanged ReviewOperationStatus = "CHANGED"
+)
+
+// ReviewModel pairs each pending learned operation with its provided spec counterpart, for rendering reviews side by side.
+type ReviewModel struct {
+	Operations []*ReviewOperation `json:"operations"`
+	// LearnedDefinitions and ProvidedDefinitions resolve the object refs of the learned and provided operations
+	LearnedDefinitions  oapi_spec.Definitions `json:"learnedDefinitions,omitempty"`
+	ProvidedDefinitions oapi_spec.Definitions `json:"providedDefinitions,omitempty"`
+}
+
+type ReviewOperation struct {
+	// ParameterizedPath is the suggested path of the operation, as in CreateSuggestedReview
+	ParameterizedPath string `json:"parameterizedPath"`
+	// Paths are the learned paths grouped into ParameterizedPath
+	Paths    []string             `json:"paths"`
+	Method   string               `json:"method"`
+	Learned  *oapi_spec.Operation `json:"learned"`
+	HitCount int                  `json:"hitCount"`
+	// ProvidedPath and Provided are the matching path and operation of the provided spec, empty for new operations
+	ProvidedPath string                `json:"providedPath,omitempty"`
+	Provided     *oapi_spec.Operation  `json:"provided,omitempty"`
+	Status       ReviewOperationStatus `json:"status"`
+	// Differences are the operation fields (parameters, responses...) that differ between Provided and Learned
+	Differences []string `json:"differences,omitempty"`
+}
+
+// CreateReviewModel creates the side by side review model of the learned operations that were not approved yet.
+// Operations are sorted by parameterized path and method.
+func (s *Spec) CreateReviewModel() (*ReviewModel, error) {
+	s.lock.RLock()
+	clonedSpec, err := s.SpecInfoClone()
+	s.lock.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone spec. %v", err)
+	}
+
+	pathItems, parameterizedPathToPaths := clonedSpec.createUnapprovedPathItems()
+	operationStats := clonedSpec.getLearningOperationStats(parameterizedPathToPaths)
+	// learned operations are shown the same way they will show in the generated spec
+	pathItems, learnedDefinitions := reconstructObjectRefs(pathItems)
+
+	ret := &ReviewModel{
+		Operations:         []*ReviewOperation{},
+		LearnedDefinitions: learnedDefinitions,
+	}
+	if clonedSpec.hasProvidedSpec() {
+		ret.ProvidedDefinitions = clonedSpec.ProvidedSpec.Spec.Definitions
+	}
+	for _, parameterizedPath := range getSortedPaths(pathItems, nil) {
+		pathItem := pathItems[parameterizedPath]
+		paths := make([]string, 0, len(parameterizedPathToPaths[parameterizedPath]))
+		for path := range parameterizedPathToPaths[parameterizedPath] {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		providedPath, providedPathItem := clonedSpec.getProvidedPathItem(paths)
+		for _, method := range supportedMethods {
+			learnedOp := GetOperationFromPathItem(pathItem, method)
+			if learnedOp == nil {
+				continue
+			}
+			reviewOp := &ReviewOperation{
+				ParameterizedPath: parameterizedPath,
+				Paths:             paths,
+				Method:            method,
+				Learned:           learnedOp,
+				Status:            ReviewOperationStatusNew,
+			}
+			if opStats := operationStats[parameterizedPath][method]; opStats != nil {
+				reviewOp.HitCount = opStats.HitCount
+			}
+			if providedPathItem != nil {
+				if err := reviewOp.setProvided(providedPath, GetOperationFromPathItem(providedPathItem, method)); err != nil {
+					return nil, fmt.Errorf("failed to compare %v %v with the provided spec: %w", method, parameterizedPath, err)
+				}
+			}
+			ret.Operations = append(ret.Operations, reviewOp)
+		}
+	}
+
+	return ret, nil
+}
+
+func (r *ReviewOperation) setProvided(providedPath string, providedOp *oapi_spec.Operation) error {
+	if providedOp == nil {
+		return nil
+	}
+	r.ProvidedPath = providedPath
+	r.Provided = providedOp
+
+	clonedProvidedOp, err := CloneOperation(providedOp)
+	if err != nil {
+		return fmt.Errorf("failed to clone provided operation: %w", err)
+	}
+	clonedLearnedOp, err := CloneOperation(r.Learned)
+	if err != nil {
+		return fmt.Errorf("failed to clone learned operation: %w", err)
+	}
+	r.Differences, err = getChangedOperationFields(clonedProvidedOp, clonedLearnedOp)
+	if err != nil {
+		return err
+	}
+
+	r.Status = ReviewOperationStatusMatched
+	if len(r.Differences) > 0 {
+		r.Status = ReviewOperationStatusChanged
+	}
+	return nil
+}
+
+// getProvidedPathItem returns the provided spec path (with the base path) and path item matching the first matched learned path.
+func (s *Spec) getProvidedPathItem(paths []string) (string, *oapi_spec.PathItem) {
+	if !s.hasProvidedSpec() {
+		return "", nil
+	}
+	basePath := s.ProvidedSpec.Spec.BasePath
+
+	for _, path := range paths {
+		pathNoBase := trimBasePathIfNeeded(basePath, path)
+		providedPath, _, found := s.ProvidedPathTrie.GetPathAndValue(pathNoBase)
+		if !found {
+			// paths without a path ID are not in the trie
+			providedPath, found = findParameterizedPath(s.ProvidedSpec.Spec.Paths.Paths, pathNoBase)
+		}
+		if found {
+			return addBasePathIfNeeded(basePath, providedPath), s.ProvidedSpec.GetPathItem(providedPath)
+		}
+	}
+
+	return "", nil
+}
+
+// findParameterizedPath returns the path of pathItems that path matches, preferring an exact match.
+func findParameterizedPath(pathItems map[string]oapi_spec.PathItem, path string) (string, bool) {
+	if _, ok := pathItems[path]; ok {
+		return path, true
+	}
+
+	parameterizedPaths := make([]string, 0, len(pathItems))
+	for parameterizedPath := range pathItems {
+		parameterizedPaths = append(parameterizedPaths, parameterizedPath)
+	}
+	sort.Strings(parameterizedPaths)
+	for _, parameterizedPath := range parameterizedPaths {
+		if _, ok := utils.GetPathParamValues(parameterizedPath, path); ok {
+			return parameterizedPath, true
+		}
+	}
+
+	return "", false
+}
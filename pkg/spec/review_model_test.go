@@ -0,0 +1,91 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_CreateReviewModel(t *testing.T) {
+	providedSource := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, providedSource.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api/1", "host", "200", Data.ReqBody, Data.RespBody)))
+	assert.NilError(t, providedSource.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api/2", "host", "200", Data.ReqBody, Data.RespBody)))
+	assert.NilError(t, providedSource.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/users", "host", "200", Data.ReqBody, Data.RespBody)))
+	providedSpec, err := providedSource.GenerateLearningOAS()
+	assert.NilError(t, err)
+
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LoadProvidedSpec(providedSpec, map[string]string{}))
+	learn := func(method, path, statusCode string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", method, path, "host", statusCode, Data.ReqBody, Data.RespBody)))
+	}
+	learn(http.MethodGet, "/api/2", "200")
+	learn(http.MethodGet, "/api/1", "200")
+	learn(http.MethodPost, "/api/1", "200")
+	learn(http.MethodGet, "/users", "404")
+
+	model, err := s.CreateReviewModel()
+	assert.NilError(t, err)
+	assert.Equal(t, len(model.Operations), 3)
+
+	matched := model.Operations[0]
+	assert.Equal(t, matched.ParameterizedPath, "/api/{param1}")
+	assert.DeepEqual(t, matched.Paths, []string{"/api/1", "/api/2"})
+	assert.Equal(t, matched.Method, http.MethodGet)
+	assert.Equal(t, matched.HitCount, 2)
+	assert.Equal(t, matched.ProvidedPath, "/api/{param1}")
+	assert.Equal(t, matched.Status, ReviewOperationStatusMatched)
+	assert.Assert(t, matched.Provided != nil)
+	assert.Assert(t, len(matched.Differences) == 0)
+	assert.Assert(t, len(model.LearnedDefinitions) > 0)
+	assert.Assert(t, len(model.ProvidedDefinitions) > 0)
+
+	newOp := model.Operations[1]
+	assert.Equal(t, newOp.ParameterizedPath, "/api/{param1}")
+	assert.Equal(t, newOp.Method, http.MethodPost)
+	assert.Equal(t, newOp.Status, ReviewOperationStatusNew)
+	assert.Assert(t, newOp.Provided == nil)
+	assert.Equal(t, newOp.ProvidedPath, "")
+
+	changed := model.Operations[2]
+	assert.Equal(t, changed.ParameterizedPath, "/users")
+	assert.Equal(t, changed.Status, ReviewOperationStatusChanged)
+	assert.DeepEqual(t, changed.Differences, []string{"responses"})
+
+	// new operations omit the provided side
+	newOpJSON, err := json.Marshal(newOp)
+	assert.NilError(t, err)
+	var fields map[string]interface{}
+	assert.NilError(t, json.Unmarshal(newOpJSON, &fields))
+	_, hasProvided := fields["provided"]
+	assert.Assert(t, !hasProvided)
+	assert.Equal(t, fields["status"], string(ReviewOperationStatusNew))
+}
+
+func TestSpec_CreateReviewModel_NoProvidedSpec(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", Data.ReqBody, Data.RespBody)))
+
+	model, err := s.CreateReviewModel()
+	assert.NilError(t, err)
+	assert.Equal(t, len(model.Operations), 1)
+	assert.Equal(t, model.Operations[0].Status, ReviewOperationStatusNew)
+	assert.Equal(t, model.Operations[0].HitCount, 1)
+}
@@ -25,16 +25,16 @@ import (
 	"testing"
 
 	oapi_spec "github.com/go-openapi/spec"
-	uuid "github.com/satori/go.uuid"
 	"gotest.tools/assert"
 
 	"github.com/apiclarity/speculator/pkg/pathtrie"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
 )
 
 func TestSpec_ApplyApprovedReview(t *testing.T) {
 	host := "host"
 	port := "8080"
-	uuidVar := uuid.NewV4()
+	uuidVar := uuid.New()
 
 	type fields struct {
 		Host         string
@@ -494,7 +494,7 @@ func TestSpec_ApplyApprovedReview(t *testing.T) {
 								WithOperation(http.MethodGet, NewOperation(t, Data2).Op).PathItem,
 						},
 					},
-					ApprovedPathTrie: pathtrie.New(),
+					ApprovedPathTrie: pathtrie.NewTyped[string](),
 				},
 			},
 			wantErr: true,
@@ -510,7 +510,7 @@ func TestSpec_ApplyApprovedReview(t *testing.T) {
 					ID:               tt.fields.ID,
 					ApprovedSpec:     tt.fields.ApprovedSpec,
 					LearningSpec:     tt.fields.LearningSpec,
-					ApprovedPathTrie: pathtrie.New(),
+					ApprovedPathTrie: pathtrie.NewTyped[string](),
 				},
 			}
 			err := s.ApplyApprovedReview(tt.args.approvedReviews)
@@ -707,9 +707,10 @@ func TestSpec_createLearningParametrizedPaths(t *testing.T) {
 
 func Test_addPathParamsToPathItem(t *testing.T) {
 	type args struct {
-		pathItem      *oapi_spec.PathItem
-		suggestedPath string
-		paths         map[string]bool
+		pathItem               *oapi_spec.PathItem
+		suggestedPath          string
+		paths                  map[string]bool
+		learnCompositePatterns bool
 	}
 	tests := []struct {
 		name         string
@@ -740,10 +741,39 @@ func Test_addPathParamsToPathItem(t *testing.T) {
 			},
 			wantPathItem: &NewTestPathItem().WithPathParams("param1", schemaTypeInteger, "").WithPathParams("param2", schemaTypeInteger, "").PathItem,
 		},
+		{
+			name: "composite param without patterns",
+			args: args{
+				pathItem:      &NewTestPathItem().PathItem,
+				suggestedPath: "/api/{param1}",
+				paths: map[string]bool{
+					"api/123:1": true,
+					"api/456:2": true,
+				},
+			},
+			wantPathItem: &NewTestPathItem().WithPathParams("param1", schemaTypeString, "").PathItem,
+		},
+		{
+			name: "composite param with patterns",
+			args: args{
+				pathItem:      &NewTestPathItem().PathItem,
+				suggestedPath: "/api/{param1}",
+				paths: map[string]bool{
+					"api/123:1": true,
+					"api/456:2": true,
+				},
+				learnCompositePatterns: true,
+			},
+			wantPathItem: func() *oapi_spec.PathItem {
+				pathItem := &NewTestPathItem().WithPathParams("param1", schemaTypeString, "").PathItem
+				pathItem.Parameters[0].Pattern = "^[0-9]+:[0-9]+$"
+				return pathItem
+			}(),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			addPathParamsToPathItem(tt.args.pathItem, tt.args.suggestedPath, tt.args.paths)
+			addPathParamsToPathItem(tt.args.pathItem, tt.args.suggestedPath, tt.args.paths, tt.args.learnCompositePatterns)
 			assert.Assert(t, reflect.DeepEqual(tt.args.pathItem, tt.wantPathItem))
 		})
 	}
@@ -956,3 +986,30 @@ func Test_updateSecurityDefinitionsFromPathItem(t *testing.T) {
 		})
 	}
 }
+
+func TestSpec_ApplyApprovedReview_WithMinOperationHits(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learnMinOperationHitsTestPaths(t, s)
+
+	suggestedReview := s.CreateSuggestedReview()
+	approvedReview := &ApprovedSpecReview{PathToPathItem: suggestedReview.PathToPathItem}
+	for _, pathItemReview := range suggestedReview.PathItemsReview {
+		approvedReview.PathItemsReview = append(approvedReview.PathItemsReview, &ApprovedSpecReviewPathItem{
+			ReviewPathItem: pathItemReview.ReviewPathItem,
+			PathUUID:       uuid.New().String(),
+		})
+	}
+	assert.NilError(t, s.ApplyApprovedReview(approvedReview, WithMinOperationHits(2)))
+
+	approvedPathItem := s.ApprovedSpec.GetPathItem("/users")
+	assert.Assert(t, approvedPathItem != nil)
+	assert.Assert(t, approvedPathItem.Get != nil)
+	assert.Assert(t, approvedPathItem.Post == nil)
+	assert.Assert(t, s.ApprovedSpec.GetPathItem("/scan") == nil)
+	_, _, found := s.ApprovedPathTrie.GetPathAndValue("/scan")
+	assert.Assert(t, !found)
+
+	assert.Assert(t, s.LearningSpec.GetPathItem("/users").Post != nil)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/users").Get == nil)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/scan") != nil)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+type SampleRetentionReason string
+
+const (
+	// SampleRetentionReasonNewPath the sample is the first telemetry learned for its path
+	SampleRetentionReasonNewPath SampleRetentionReason = "NEW_PATH"
+	// SampleRetentionReasonSchemaConflict the sample conflicted with the learned schema of its operation
+	SampleRetentionReasonSchemaConflict SampleRetentionReason = "SCHEMA_CONFLICT"
+)
+
+// retained sample bodies are truncated to this size (in bytes)
+const maxRetainedSampleBodySize = 4096
+
+// RetainedSample is a redacted copy of a telemetry that changed the learned spec in a surprising way.
+type RetainedSample struct {
+	Reason SampleRetentionReason
+	Path   string
+	Method string
+	// Fields are the conflicting operation fields of SampleRetentionReasonSchemaConflict samples
+	Fields    []string
+	Telemetry *Telemetry
+}
+
+// retainSample keeps a copy of telemetry redacted with DefaultRedactionPolicy, up to OperationGeneratorConfig.MaxRetainedSamples samples
+// are kept, the oldest sample is dropped first.
+func (s *Spec) retainSample(reason SampleRetentionReason, path, method string, fields []string, telemetry *Telemetry) {
+	maxSamples := s.OpGenerator.MaxRetainedSamples
+	if maxSamples <= 0 {
+		return
+	}
+
+	s.RetainedSamples = append(s.RetainedSamples, &RetainedSample{
+		Reason:    reason,
+		Path:      path,
+		Method:    method,
+		Fields:    fields,
+		Telemetry: DefaultRedactionPolicy().RedactTelemetry(telemetry),
+	})
+	if len(s.RetainedSamples) > maxSamples {
+		s.RetainedSamples = s.RetainedSamples[len(s.RetainedSamples)-maxSamples:]
+	}
+}
+
+// GetRetainedSamples returns the retained samples, oldest first.
+func (s *Spec) GetRetainedSamples() []RetainedSample {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := make([]RetainedSample, 0, len(s.RetainedSamples))
+	for _, sample := range s.RetainedSamples {
+		ret = append(ret, *sample)
+	}
+
+	return ret
+}
+
+func getConflictFields(conflicts []conflict) []string {
+	fields := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		fields = append(fields, c.path.String())
+	}
+	return fields
+}
@@ -0,0 +1,55 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_LearnTelemetry_RetainSamples(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", OperationGeneratorConfig{MaxRetainedSamples: 2})
+	learn := func(path, respBody string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", "", respBody)))
+	}
+	learn("/api/1", `{"id":1}`)
+	learn("/api/1", `{"id":2}`)
+	learn("/api/1", `{"id":"a"}`)
+
+	samples := s.GetRetainedSamples()
+	assert.Equal(t, len(samples), 2)
+	assert.Equal(t, samples[0].Reason, SampleRetentionReasonNewPath)
+	assert.Equal(t, samples[0].Path, "/api/1")
+	assert.Equal(t, samples[0].Method, http.MethodGet)
+	assert.Equal(t, samples[1].Reason, SampleRetentionReasonSchemaConflict)
+	assert.DeepEqual(t, samples[1].Fields, []string{"responses.200.schema.properties.id"})
+	assert.Equal(t, string(samples[1].Telemetry.Response.Common.Body), `{"id":"a"}`)
+
+	// bounded, the oldest sample is dropped
+	learn("/api/2", `{"id":1}`)
+	samples = s.GetRetainedSamples()
+	assert.Equal(t, len(samples), 2)
+	assert.Equal(t, samples[0].Reason, SampleRetentionReasonSchemaConflict)
+	assert.Equal(t, samples[1].Path, "/api/2")
+}
+
+func TestSpec_LearnTelemetry_RetainSamplesDisabled(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", OperationGeneratorConfig{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", `{"id":1}`)))
+	assert.Equal(t, len(s.GetRetainedSamples()), 0)
+}
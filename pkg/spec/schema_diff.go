@@ -0,0 +1,244 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+)
+
+type SchemaDiffType string
+
+const (
+	// SchemaDiffTypeAdded the schema was learned but is not in the provided spec
+	SchemaDiffTypeAdded SchemaDiffType = "ADDED"
+	// SchemaDiffTypeRemoved the schema is in the provided spec but was not learned
+	SchemaDiffTypeRemoved SchemaDiffType = "REMOVED"
+	// SchemaDiffTypeChanged the learned schema type differs from the provided one
+	SchemaDiffTypeChanged SchemaDiffType = "CHANGED"
+	// SchemaDiffTypeChildrenChanged the schema type is the same, but some of its properties or items differ
+	SchemaDiffTypeChildrenChanged SchemaDiffType = "CHILDREN_CHANGED"
+)
+
+// OperationSchemaDiff is the structural diff between the learned and provided schemas of a single operation.
+type OperationSchemaDiff struct {
+	ParameterizedPath string `json:"parameterizedPath"`
+	Method            string `json:"method"`
+	ProvidedPath      string `json:"providedPath"`
+	// Diffs are the roots of the drifted schemas, the request body and the responses, sorted by pointer
+	Diffs []*SchemaDiffNode `json:"diffs"`
+}
+
+// SchemaDiffNode is a drifted schema. Children are only set for SchemaDiffTypeChildrenChanged.
+type SchemaDiffNode struct {
+	// Pointer is the JSON pointer of the schema in the operation, with parameters keyed by name
+	// (e.g. /parameters/body/schema/properties/name, /responses/200/schema/items)
+	Pointer string         `json:"pointer"`
+	Type    SchemaDiffType `json:"type"`
+	// Learned and Provided are the types of the schema (e.g. string, string(uuid), object), empty when missing
+	Learned  string            `json:"learned,omitempty"`
+	Provided string            `json:"provided,omitempty"`
+	Children []*SchemaDiffNode `json:"children,omitempty"`
+}
+
+// CreateOperationSchemaDiff creates the schema diff of a pending learned operation, as listed by CreateReviewModel,
+// against its provided spec counterpart.
+func (s *Spec) CreateOperationSchemaDiff(parameterizedPath, method string) (*OperationSchemaDiff, error) {
+	method, err := NormalizeMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	reviewModel, err := s.CreateReviewModel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create review model: %w", err)
+	}
+
+	var reviewOp *ReviewOperation
+	for _, op := range reviewModel.Operations {
+		if op.ParameterizedPath == parameterizedPath && op.Method == method {
+			reviewOp = op
+			break
+		}
+	}
+	if reviewOp == nil {
+		return nil, fmt.Errorf("no pending learned operation for %v %v", method, parameterizedPath)
+	}
+	if reviewOp.Provided == nil {
+		return nil, fmt.Errorf("operation %v %v is not in the provided spec", method, parameterizedPath)
+	}
+
+	differ := &schemaDiffer{
+		learnedDefinitions:  reviewModel.LearnedDefinitions,
+		providedDefinitions: reviewModel.ProvidedDefinitions,
+	}
+	return &OperationSchemaDiff{
+		ParameterizedPath: parameterizedPath,
+		Method:            method,
+		ProvidedPath:      reviewOp.ProvidedPath,
+		Diffs:             differ.diffOperations(reviewOp.Learned, reviewOp.Provided),
+	}, nil
+}
+
+type schemaDiffer struct {
+	learnedDefinitions  oapi_spec.Definitions
+	providedDefinitions oapi_spec.Definitions
+}
+
+func (d *schemaDiffer) diffOperations(learned, provided *oapi_spec.Operation) []*SchemaDiffNode {
+	diffs := []*SchemaDiffNode{}
+
+	if node := d.diffSchemas("/parameters/body/schema", getBodySchema(learned), getBodySchema(provided), 0); node != nil {
+		diffs = append(diffs, node)
+	}
+
+	learnedResponses := getResponseSchemas(learned)
+	providedResponses := getResponseSchemas(provided)
+	for _, code := range getSortedKeys(learnedResponses, providedResponses) {
+		pointer := "/responses/" + escapeJSONPointerToken(code) + "/schema"
+		if node := d.diffSchemas(pointer, getSchemaFromMap(learnedResponses, code),
+			getSchemaFromMap(providedResponses, code), 0); node != nil {
+			diffs = append(diffs, node)
+		}
+	}
+
+	return diffs
+}
+
+// diffSchemas returns the diff node of the learned and provided schemas, or nil if they do not differ.
+func (d *schemaDiffer) diffSchemas(pointer string, learned, provided *oapi_spec.Schema, depth int) *SchemaDiffNode {
+	learned = resolveSchemaRef(learned, d.learnedDefinitions)
+	provided = resolveSchemaRef(provided, d.providedDefinitions)
+
+	switch {
+	case learned == nil && provided == nil:
+		return nil
+	case provided == nil:
+		return &SchemaDiffNode{Pointer: pointer, Type: SchemaDiffTypeAdded, Learned: getSchemaTypeName(learned)}
+	case learned == nil:
+		return &SchemaDiffNode{Pointer: pointer, Type: SchemaDiffTypeRemoved, Provided: getSchemaTypeName(provided)}
+	}
+
+	learnedType := getSchemaTypeName(learned)
+	providedType := getSchemaTypeName(provided)
+	if learnedType != providedType {
+		return &SchemaDiffNode{Pointer: pointer, Type: SchemaDiffTypeChanged, Learned: learnedType, Provided: providedType}
+	}
+	if depth >= maxSchemaToRefDepth {
+		return nil
+	}
+
+	var children []*SchemaDiffNode
+	for _, name := range getSortedKeys(learned.Properties, provided.Properties) {
+		childPointer := pointer + "/properties/" + escapeJSONPointerToken(name)
+		if child := d.diffSchemas(childPointer, getSchemaFromMap(learned.Properties, name),
+			getSchemaFromMap(provided.Properties, name), depth+1); child != nil {
+			children = append(children, child)
+		}
+	}
+	if child := d.diffSchemas(pointer+"/items", getItemsSchema(learned), getItemsSchema(provided), depth+1); child != nil {
+		children = append(children, child)
+	}
+	if len(children) == 0 {
+		return nil
+	}
+
+	return &SchemaDiffNode{
+		Pointer:  pointer,
+		Type:     SchemaDiffTypeChildrenChanged,
+		Learned:  learnedType,
+		Provided: providedType,
+		Children: children,
+	}
+}
+
+func getBodySchema(op *oapi_spec.Operation) *oapi_spec.Schema {
+	for _, param := range op.Parameters {
+		if param.In == parametersInBody {
+			return param.Schema
+		}
+	}
+	return nil
+}
+
+// getResponseSchemas returns the response schemas by status code, responses without a schema are omitted.
+func getResponseSchemas(op *oapi_spec.Operation) map[string]oapi_spec.Schema {
+	ret := map[string]oapi_spec.Schema{}
+	if op.Responses == nil {
+		return ret
+	}
+	for code, response := range op.Responses.StatusCodeResponses {
+		if response.Schema != nil {
+			ret[fmt.Sprintf("%v", code)] = *response.Schema
+		}
+	}
+	if op.Responses.Default != nil && op.Responses.Default.Schema != nil {
+		ret["default"] = *op.Responses.Default.Schema
+	}
+	return ret
+}
+
+func getSchemaFromMap(schemas map[string]oapi_spec.Schema, key string) *oapi_spec.Schema {
+	schema, ok := schemas[key]
+	if !ok {
+		return nil
+	}
+	return &schema
+}
+
+func getItemsSchema(schema *oapi_spec.Schema) *oapi_spec.Schema {
+	if schema.Items == nil {
+		return nil
+	}
+	return schema.Items.Schema
+}
+
+// resolveSchemaRef returns the definition a schema refers to, or the schema itself if it is not a ref.
+func resolveSchemaRef(schema *oapi_spec.Schema, definitions oapi_spec.Definitions) *oapi_spec.Schema {
+	if schema == nil {
+		return nil
+	}
+	ref := schema.Ref.String()
+	if !strings.HasPrefix(ref, definitionsRefPrefix) {
+		return schema
+	}
+	definition, ok := definitions[strings.TrimPrefix(ref, definitionsRefPrefix)]
+	if !ok {
+		return schema
+	}
+	return &definition
+}
+
+func getSchemaTypeName(schema *oapi_spec.Schema) string {
+	if schema.Ref.String() != "" {
+		// unresolved ref
+		return schema.Ref.String()
+	}
+	typeName := strings.Join(schema.Type, ",")
+	if typeName == "" && len(schema.Properties) > 0 {
+		typeName = schemaTypeObject
+	}
+	if schema.Format != "" {
+		typeName += "(" + schema.Format + ")"
+	}
+	return typeName
+}
+
+// escapeJSONPointerToken escapes a JSON pointer reference token as in RFC 6901.
+func escapeJSONPointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
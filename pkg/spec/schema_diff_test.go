@@ -0,0 +1,100 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpec_CreateOperationSchemaDiff(t *testing.T) {
+	providedSource := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, providedSource.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/users", "host", "200",
+		`{"name":"a","age":1,"tags":["a"]}`, `{"id":1}`)))
+	providedSpec, err := providedSource.GenerateLearningOAS()
+	assert.NilError(t, err)
+
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LoadProvidedSpec(providedSpec, map[string]string{}))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/users", "host", "200",
+		`{"name":true,"nick":"b","tags":[1]}`, `{"id":1}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/users", "host", "404",
+		`{"name":true,"nick":"b","tags":[1]}`, `{"error":"not found"}`)))
+
+	diff, err := s.CreateOperationSchemaDiff("/users", "post")
+	assert.NilError(t, err)
+	assert.Equal(t, diff.Method, http.MethodPost)
+	assert.Equal(t, diff.ProvidedPath, "/users")
+	want := []*SchemaDiffNode{
+		{
+			Pointer:  "/parameters/body/schema",
+			Type:     SchemaDiffTypeChildrenChanged,
+			Learned:  "object",
+			Provided: "object",
+			Children: []*SchemaDiffNode{
+				{Pointer: "/parameters/body/schema/properties/age", Type: SchemaDiffTypeRemoved, Provided: "integer(int64)"},
+				{Pointer: "/parameters/body/schema/properties/name", Type: SchemaDiffTypeChanged, Learned: "boolean", Provided: "string"},
+				{Pointer: "/parameters/body/schema/properties/nick", Type: SchemaDiffTypeAdded, Learned: "string"},
+				{
+					Pointer:  "/parameters/body/schema/properties/tags",
+					Type:     SchemaDiffTypeChildrenChanged,
+					Learned:  "array",
+					Provided: "array",
+					Children: []*SchemaDiffNode{
+						{Pointer: "/parameters/body/schema/properties/tags/items", Type: SchemaDiffTypeChanged, Learned: "integer(int64)", Provided: "string"},
+					},
+				},
+			},
+		},
+		{Pointer: "/responses/404/schema", Type: SchemaDiffTypeAdded, Learned: "object"},
+	}
+	assert.DeepEqual(t, diff.Diffs, want)
+
+	_, err = s.CreateOperationSchemaDiff("/users", http.MethodGet)
+	assert.ErrorContains(t, err, "no pending learned operation")
+}
+
+func TestSpec_CreateOperationSchemaDiff_NotProvided(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api", "host", "200", Data.ReqBody, Data.RespBody)))
+
+	_, err := s.CreateOperationSchemaDiff("/api", http.MethodGet)
+	assert.ErrorContains(t, err, "is not in the provided spec")
+}
+
+func Test_schemaDiffer_diffSchemas_Refs(t *testing.T) {
+	differ := &schemaDiffer{
+		learnedDefinitions: oapi_spec.Definitions{
+			"user": *oapi_spec.StrFmtProperty("uuid"),
+		},
+		providedDefinitions: oapi_spec.Definitions{
+			"a/b": *oapi_spec.StringProperty(),
+		},
+	}
+	learned := oapi_spec.RefSchema(definitionsRefPrefix + "user")
+	provided := oapi_spec.RefSchema(definitionsRefPrefix + "a/b")
+	want := &SchemaDiffNode{Pointer: "/x~1y", Type: SchemaDiffTypeChanged, Learned: "string(uuid)", Provided: "string"}
+	if got := differ.diffSchemas("/"+escapeJSONPointerToken("x/y"), learned, provided, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffSchemas() = %+v, want %+v", got, want)
+	}
+	if got := differ.diffSchemas("/", oapi_spec.StringProperty(), provided, 0); got != nil {
+		t.Errorf("diffSchemas() = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+)
+
+// the amount of schema change events kept per operation, older events are dropped first.
+const maxSchemaTimelineEvents = 50
+
+// SchemaChangeEvent is a change of the learned schema of an operation. Fields are the operation field paths
+// (e.g. responses.200.schema.properties.id), the first event of an operation lists all its fields as added.
+type SchemaChangeEvent struct {
+	Time    time.Time
+	Added   []string
+	Removed []string
+	// Changed are the fields whose type or format changed
+	Changed []string
+}
+
+// recordSchemaChange records the schema change of an operation from the fields it had before learning a telemetry
+// (see getOperationFieldTypes) to its learned operation, and returns the change or nil if the schema didn't change.
+// Must be called after the telemetry stats were recorded.
+func (s *Spec) recordSchemaChange(path, method string, fieldsBefore map[string]string, learnedOp *oapi_spec.Operation, seen time.Time) *SchemaChangeEvent {
+	event := createSchemaChangeEvent(fieldsBefore, getOperationFieldTypes(learnedOp), seen)
+	if event == nil {
+		return nil
+	}
+	if opStats, ok := s.LearningStats.Operations[path][method]; ok {
+		opStats.addSchemaChange(event)
+	}
+	return event
+}
+
+func (o *OperationStats) addSchemaChange(event *SchemaChangeEvent) {
+	o.SchemaTimeline = append(o.SchemaTimeline, *event)
+	if len(o.SchemaTimeline) > maxSchemaTimelineEvents {
+		o.SchemaTimeline = o.SchemaTimeline[len(o.SchemaTimeline)-maxSchemaTimelineEvents:]
+	}
+}
+
+// createSchemaChangeEvent returns the change between fields types, or nil if they are the same.
+func createSchemaChangeEvent(before, after map[string]string, seen time.Time) *SchemaChangeEvent {
+	event := &SchemaChangeEvent{Time: seen}
+	for fieldPath, fieldType := range after {
+		typeBefore, ok := before[fieldPath]
+		if !ok {
+			event.Added = append(event.Added, fieldPath)
+		} else if typeBefore != fieldType {
+			event.Changed = append(event.Changed, fieldPath)
+		}
+	}
+	for fieldPath := range before {
+		if _, ok := after[fieldPath]; !ok {
+			event.Removed = append(event.Removed, fieldPath)
+		}
+	}
+	if len(event.Added) == 0 && len(event.Removed) == 0 && len(event.Changed) == 0 {
+		return nil
+	}
+
+	sort.Strings(event.Added)
+	sort.Strings(event.Removed)
+	sort.Strings(event.Changed)
+	return event
+}
+
+// getOperationFieldTypes returns the type (and format) of each operation field by field path.
+func getOperationFieldTypes(op *oapi_spec.Operation) map[string]string {
+	fieldTypes := make(map[string]string)
+	if op == nil {
+		return fieldTypes
+	}
+
+	forEachOperationField(op, func(path *field.Path, fieldType, format string) {
+		if format != "" {
+			fieldType += "(" + format + ")"
+		}
+		fieldTypes[path.String()] = fieldType
+	})
+	return fieldTypes
+}
+
+// forEachOperationField calls fn with the type and format of each parameter, response header and schema field
+// of op, with the field paths used by mergeOperation.
+func forEachOperationField(op *oapi_spec.Operation, fn func(path *field.Path, fieldType, format string)) {
+	parametersPath := field.NewPath("parameters")
+	for _, param := range op.Parameters {
+		if param.In == parametersInBody || param.Type == "" {
+			forEachSchemaField(param.Schema, parametersPath.Child(param.Name, "schema"), fn, 0)
+		} else {
+			forEachSimpleSchemaField(&param.SimpleSchema, parametersPath.Child(param.Name), fn)
+		}
+	}
+
+	if op.Responses == nil {
+		return
+	}
+	responsesPath := field.NewPath("responses")
+	for code, response := range op.Responses.StatusCodeResponses {
+		codePath := responsesPath.Child(strconv.Itoa(code))
+		forEachSchemaField(response.Schema, codePath.Child("schema"), fn, 0)
+		for name := range response.Headers {
+			header := response.Headers[name]
+			forEachSimpleSchemaField(&header.SimpleSchema, codePath.Child("headers", name), fn)
+		}
+	}
+}
+
+func forEachSchemaField(schema *oapi_spec.Schema, path *field.Path, fn func(path *field.Path, fieldType, format string), depth int) {
+	if schema == nil || depth >= maxSchemaToRefDepth {
+		return
+	}
+	fn(path, strings.Join(schema.Type, ","), schema.Format)
+	if schema.Items != nil {
+		forEachSchemaField(schema.Items.Schema, path.Child("items"), fn, depth+1)
+	}
+	for name := range schema.Properties {
+		property := schema.Properties[name]
+		forEachSchemaField(&property, path.Child("properties", name), fn, depth+1)
+	}
+}
+
+func forEachSimpleSchemaField(simpleSchema *oapi_spec.SimpleSchema, path *field.Path, fn func(path *field.Path, fieldType, format string)) {
+	fn(path, simpleSchema.Type, simpleSchema.Format)
+	if simpleSchema.Items != nil {
+		forEachSimpleSchemaField(&simpleSchema.Items.SimpleSchema, path.Child("items"), fn)
+	}
+}
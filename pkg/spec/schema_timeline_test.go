@@ -0,0 +1,94 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_Stats_SchemaTimeline(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learn := func(respBody string, timestamp time.Time) {
+		telemetry := createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", respBody)
+		telemetry.Timestamp = timestamp
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	start := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	learn(`{"id":1}`, start)
+	learn(`{"id":2}`, start.Add(time.Minute))
+	learn(`{"id":3,"name":"a"}`, start.Add(2*time.Minute))
+
+	stats := s.Stats()
+	timeline := stats.Operations["/api"][http.MethodGet].SchemaTimeline
+	assert.Equal(t, len(timeline), 2)
+	assert.Assert(t, timeline[0].Time.Equal(start))
+	assert.DeepEqual(t, timeline[0].Added, []string{"responses.200.schema", "responses.200.schema.properties.id"})
+	assert.Assert(t, timeline[1].Time.Equal(start.Add(2*time.Minute)))
+	assert.DeepEqual(t, timeline[1].Added, []string{"responses.200.schema.properties.name"})
+
+	// stats are a copy
+	stats.Operations["/api"][http.MethodGet].SchemaTimeline = nil
+	stats = s.Stats()
+	assert.Equal(t, len(stats.Operations["/api"][http.MethodGet].SchemaTimeline), 2)
+}
+
+func TestOperationStats_addSchemaChange_Bounded(t *testing.T) {
+	opStats := &OperationStats{}
+	for i := 0; i < maxSchemaTimelineEvents+1; i++ {
+		opStats.addSchemaChange(&SchemaChangeEvent{Time: time.Unix(int64(i), 0)})
+	}
+	assert.Equal(t, len(opStats.SchemaTimeline), maxSchemaTimelineEvents)
+	assert.Assert(t, opStats.SchemaTimeline[0].Time.Equal(time.Unix(1, 0)))
+}
+
+func Test_createSchemaChangeEvent(t *testing.T) {
+	seen := time.Unix(0, 0)
+	tests := []struct {
+		name   string
+		before map[string]string
+		after  map[string]string
+		want   *SchemaChangeEvent
+	}{
+		{
+			name:   "no change",
+			before: map[string]string{"a": "string"},
+			after:  map[string]string{"a": "string"},
+		},
+		{
+			name:   "added, removed and changed",
+			before: map[string]string{"a": "string(uuid)", "b": "integer"},
+			after:  map[string]string{"a": "string", "c": "boolean", "d": "object"},
+			want: &SchemaChangeEvent{
+				Time:    seen,
+				Added:   []string{"c", "d"},
+				Removed: []string{"b"},
+				Changed: []string{"a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createSchemaChangeEvent(tt.before, tt.after, seen); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createSchemaChangeEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+)
+
+// SearchQuery selects operations, an operation matches if it matches all the set criteria, an empty query matches
+// all operations. Criteria are case insensitive.
+type SearchQuery struct {
+	// Path is a substring of the operation path
+	Path string
+	// ParameterName is the name of a query, header, cookie, form or path parameter
+	ParameterName string
+	// PropertyName is the name of a schema property, at any depth of the request or response bodies
+	PropertyName string
+	// ContentType is a media type the operation consumes or produces, without parameters
+	ContentType string
+}
+
+// OperationMatch is an operation that matched a SearchQuery.
+type OperationMatch struct {
+	Path   string
+	Method string
+	// Approved is true for an operation of the approved spec, and false for a learned operation pending approval
+	Approved bool
+	// Fields are the operation fields that matched ParameterName or PropertyName, e.g. responses.200.schema.properties.ssn
+	Fields []string
+}
+
+// Search returns the approved and learned operations that match query, the approved operations first, each sorted
+// by path and method.
+func (s *Spec) Search(query *SearchQuery) []OperationMatch {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var matches []OperationMatch
+	if s.ApprovedSpec != nil {
+		matches = append(matches, searchPathItems(s.ApprovedSpec.PathItems, query, true)...)
+	}
+	if s.LearningSpec != nil {
+		matches = append(matches, searchPathItems(s.LearningSpec.PathItems, query, false)...)
+	}
+	sortOperationMatches(matches)
+
+	return matches
+}
+
+func searchPathItems(pathItems map[string]*oapi_spec.PathItem, query *SearchQuery, approved bool) []OperationMatch {
+	var matches []OperationMatch
+	for path, pathItem := range pathItems {
+		if query.Path != "" && !strings.Contains(strings.ToLower(path), strings.ToLower(query.Path)) {
+			continue
+		}
+		for _, method := range supportedMethods {
+			op := GetOperationFromPathItem(pathItem, method)
+			if op == nil {
+				continue
+			}
+			fields, ok := matchOperation(op, query)
+			if !ok {
+				continue
+			}
+			matches = append(matches, OperationMatch{
+				Path:     path,
+				Method:   method,
+				Approved: approved,
+				Fields:   fields,
+			})
+		}
+	}
+
+	return matches
+}
+
+// matchOperation returns true if op matches the criteria of query other than the path, and the matched fields.
+func matchOperation(op *oapi_spec.Operation, query *SearchQuery) ([]string, bool) {
+	if query.ContentType != "" && !hasContentType(op, query.ContentType) {
+		return nil, false
+	}
+
+	var fields []string
+	if query.ParameterName != "" {
+		paramFields := getParameterFields(op, query.ParameterName)
+		if len(paramFields) == 0 {
+			return nil, false
+		}
+		fields = append(fields, paramFields...)
+	}
+	if query.PropertyName != "" {
+		propertyFields := getPropertyFields(op, query.PropertyName)
+		if len(propertyFields) == 0 {
+			return nil, false
+		}
+		fields = append(fields, propertyFields...)
+	}
+	sort.Strings(fields)
+
+	return fields, true
+}
+
+func hasContentType(op *oapi_spec.Operation, contentType string) bool {
+	for _, mediaType := range append(op.Consumes[:len(op.Consumes):len(op.Consumes)], op.Produces...) {
+		if strings.EqualFold(GetContentTypeWithoutParameter(mediaType), contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// getParameterFields returns the field paths of the non body parameters of op named name.
+func getParameterFields(op *oapi_spec.Operation, name string) []string {
+	var fields []string
+	parametersPath := field.NewPath("parameters")
+	for _, param := range op.Parameters {
+		if param.In == parametersInBody || !strings.EqualFold(param.Name, name) {
+			continue
+		}
+		fields = append(fields, parametersPath.Child(param.Name).String())
+	}
+	return fields
+}
+
+// getPropertyFields returns the field paths of the schema properties of op named name, with the field paths of
+// forEachOperationField.
+func getPropertyFields(op *oapi_spec.Operation, name string) []string {
+	var fields []string
+	addPropertyFields := func(schema *oapi_spec.Schema, path *field.Path) {
+		forEachSchemaProperty(schema, path, func(propertyName string, propertyPath *field.Path) {
+			if strings.EqualFold(propertyName, name) {
+				fields = append(fields, propertyPath.String())
+			}
+		}, 0)
+	}
+
+	parametersPath := field.NewPath("parameters")
+	for _, param := range op.Parameters {
+		if param.In == parametersInBody || param.Type == "" {
+			addPropertyFields(param.Schema, parametersPath.Child(param.Name, "schema"))
+		}
+	}
+	if op.Responses != nil {
+		responsesPath := field.NewPath("responses")
+		for code, response := range op.Responses.StatusCodeResponses {
+			addPropertyFields(response.Schema, responsesPath.Child(strconv.Itoa(code), "schema"))
+		}
+	}
+
+	return fields
+}
+
+func forEachSchemaProperty(schema *oapi_spec.Schema, path *field.Path, fn func(name string, path *field.Path), depth int) {
+	if schema == nil || depth >= maxSchemaToRefDepth {
+		return
+	}
+	if schema.Items != nil {
+		forEachSchemaProperty(schema.Items.Schema, path.Child("items"), fn, depth+1)
+	}
+	for name := range schema.Properties {
+		property := schema.Properties[name]
+		propertyPath := path.Child("properties", name)
+		fn(name, propertyPath)
+		forEachSchemaProperty(&property, propertyPath, fn, depth+1)
+	}
+}
+
+func sortOperationMatches(matches []OperationMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Approved != matches[j].Approved {
+			return matches[i].Approved
+		}
+		if matches[i].Path != matches[j].Path {
+			return matches[i].Path < matches[j].Path
+		}
+		return matches[i].Method < matches[j].Method
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_Search(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	telemetries := []*Telemetry{
+		createTelemetry("1", http.MethodGet, "/api/users?limit=1", "host", "200", "", `{"id": 1, "ssn": "123", "address": {"zip": "1"}}`),
+		createTelemetry("2", http.MethodPost, "/api/orders", "host", "201", `{"items": [{"SSN": "123"}]}`, ""),
+		createTelemetry("3", http.MethodGet, "/health", "host", "200", "", ""),
+	}
+	for _, telemetry := range telemetries {
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	assert.NilError(t, s.ApprovePaths([]string{"/api/users"}))
+
+	tests := []struct {
+		name  string
+		query *SearchQuery
+		want  []OperationMatch
+	}{
+		{
+			name:  "empty query",
+			query: &SearchQuery{},
+			want: []OperationMatch{
+				{Path: "/api/users", Method: http.MethodGet, Approved: true},
+				{Path: "/api/orders", Method: http.MethodPost},
+				{Path: "/health", Method: http.MethodGet},
+			},
+		},
+		{
+			name:  "path substring",
+			query: &SearchQuery{Path: "API/"},
+			want: []OperationMatch{
+				{Path: "/api/users", Method: http.MethodGet, Approved: true},
+				{Path: "/api/orders", Method: http.MethodPost},
+			},
+		},
+		{
+			name:  "nested property in request and response",
+			query: &SearchQuery{PropertyName: "ssn"},
+			want: []OperationMatch{
+				{Path: "/api/users", Method: http.MethodGet, Approved: true, Fields: []string{"responses.200.schema.properties.ssn"}},
+				{Path: "/api/orders", Method: http.MethodPost, Fields: []string{"parameters.body.schema.properties.items.items.properties.SSN"}},
+			},
+		},
+		{
+			name:  "deep property",
+			query: &SearchQuery{PropertyName: "zip"},
+			want: []OperationMatch{
+				{Path: "/api/users", Method: http.MethodGet, Approved: true, Fields: []string{"responses.200.schema.properties.address.properties.zip"}},
+			},
+		},
+		{
+			name:  "parameter name",
+			query: &SearchQuery{ParameterName: "Limit"},
+			want: []OperationMatch{
+				{Path: "/api/users", Method: http.MethodGet, Approved: true, Fields: []string{"parameters.limit"}},
+			},
+		},
+		{
+			name:  "content type",
+			query: &SearchQuery{ContentType: "application/json"},
+			want: []OperationMatch{
+				{Path: "/api/users", Method: http.MethodGet, Approved: true},
+				{Path: "/api/orders", Method: http.MethodPost},
+			},
+		},
+		{
+			name:  "all criteria must match",
+			query: &SearchQuery{Path: "/orders", PropertyName: "ssn"},
+			want: []OperationMatch{
+				{Path: "/api/orders", Method: http.MethodPost, Fields: []string{"parameters.body.schema.properties.items.items.properties.SSN"}},
+			},
+		},
+		{
+			name:  "no match",
+			query: &SearchQuery{Path: "/api/users", PropertyName: "items"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, s.Search(tt.query), tt.want)
+		})
+	}
+}
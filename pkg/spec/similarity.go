@@ -0,0 +1,186 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sort"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// SpecSignature is the host independent shape of a spec, used to compare specs learned for different hosts.
+type SpecSignature struct {
+	// Operations are the typed fields (e.g. responses.200.schema.properties.id=integer) by operation, e.g. "GET /api/{}".
+	// Path params are anonymous so that differently named params match, and are not fields.
+	Operations map[string]map[string]bool
+	// Paths are the paths of each anonymous path, sorted, e.g. /api/{} -> /api/{id}
+	Paths map[string][]string
+}
+
+// Signature returns the signature of the approved and learned operations of the spec, the learned paths are
+// parameterized like the paths suggested for review.
+func (s *Spec) Signature() *SpecSignature {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	signature := &SpecSignature{
+		Operations: make(map[string]map[string]bool),
+		Paths:      make(map[string][]string),
+	}
+	if s.ApprovedSpec != nil {
+		for path, pathItem := range s.ApprovedSpec.PathItems {
+			signature.addPathItem(path, pathItem)
+		}
+	}
+	if s.LearningSpec != nil {
+		for parameterizedPath, paths := range s.createLearningParametrizedPaths().Paths {
+			for path := range paths {
+				signature.addPathItem(parameterizedPath, s.LearningSpec.PathItems[path])
+			}
+		}
+	}
+
+	return signature
+}
+
+func (sig *SpecSignature) addPathItem(path string, pathItem *oapi_spec.PathItem) {
+	if pathItem == nil {
+		return
+	}
+	anonymousPath := getAnonymousParamsPath(path)
+	sig.addPath(anonymousPath, path)
+	for _, method := range supportedMethods {
+		op := GetOperationFromPathItem(pathItem, method)
+		if op == nil {
+			continue
+		}
+		key := method + " " + anonymousPath
+		fields, ok := sig.Operations[key]
+		if !ok {
+			fields = make(map[string]bool)
+			sig.Operations[key] = fields
+		}
+		for _, typedField := range getSignatureFields(op) {
+			fields[typedField] = true
+		}
+	}
+}
+
+func (sig *SpecSignature) addPath(anonymousPath, path string) {
+	paths := sig.Paths[anonymousPath]
+	index := sort.SearchStrings(paths, path)
+	if index < len(paths) && paths[index] == path {
+		return
+	}
+	paths = append(paths, "")
+	copy(paths[index+1:], paths[index:])
+	paths[index] = path
+	sig.Paths[anonymousPath] = paths
+}
+
+// Similarity returns how similar the signatures are, from 0 (no operation in common) to 1 (the same operations with
+// the same fields). Each operation of either signature weighs the same, and scores the Jaccard index of its fields
+// in the two signatures, or 0 if only one signature has it.
+func (sig *SpecSignature) Similarity(other *SpecSignature) float64 {
+	operations := make(map[string]bool, len(sig.Operations))
+	for key := range sig.Operations {
+		operations[key] = true
+	}
+	for key := range other.Operations {
+		operations[key] = true
+	}
+	if len(operations) == 0 {
+		return 0
+	}
+
+	var score float64
+	for key := range operations {
+		fields, ok := sig.Operations[key]
+		otherFields, otherOk := other.Operations[key]
+		if ok && otherOk {
+			score += getJaccardIndex(fields, otherFields)
+		}
+	}
+
+	return score / float64(len(operations))
+}
+
+// SharedOperations returns the amount of operations both signatures have.
+func (sig *SpecSignature) SharedOperations(other *SpecSignature) int {
+	shared := 0
+	for key := range sig.Operations {
+		if _, ok := other.Operations[key]; ok {
+			shared++
+		}
+	}
+	return shared
+}
+
+func getJaccardIndex(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	intersection := 0
+	for key := range a {
+		if b[key] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// getSignatureFields returns the "field=type" of the fields of op (see getOperationFieldTypes), without path params.
+func getSignatureFields(op *oapi_spec.Operation) []string {
+	pathParams := make(map[string]bool)
+	for _, param := range op.Parameters {
+		if param.In == parametersInPath {
+			pathParams[field.NewPath("parameters").Child(param.Name).String()] = true
+		}
+	}
+
+	var fields []string
+	for fieldPath, fieldType := range getOperationFieldTypes(op) {
+		if isPathParamField(fieldPath, pathParams) {
+			continue
+		}
+		fields = append(fields, fieldPath+"="+fieldType)
+	}
+	return fields
+}
+
+func isPathParamField(fieldPath string, pathParams map[string]bool) bool {
+	for pathParam := range pathParams {
+		if fieldPath == pathParam || strings.HasPrefix(fieldPath, pathParam+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// getAnonymousParamsPath replaces the path params of path with {}, e.g. /api/{id} returns /api/{}.
+func getAnonymousParamsPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if utils.IsPathParam(segment) {
+			segments[i] = utils.ParamPrefix + utils.ParamSuffix
+		}
+	}
+	return strings.Join(segments, "/")
+}
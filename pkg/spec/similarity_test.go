@@ -0,0 +1,104 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_Signature(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1", http.MethodGet, "/api/users/1", "host", "200", "", `{"id": 1}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("2", http.MethodGet, "/api/users/2", "host", "200", "", `{"name": "a"}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("3", http.MethodPost, "/api/users", "host", "201", `{"name": "a"}`, "")))
+
+	assert.DeepEqual(t, s.Signature(), &SpecSignature{
+		Operations: map[string]map[string]bool{
+			"GET /api/users/{}": {
+				"responses.200.schema=object":                       true,
+				"responses.200.schema.properties.id=integer(int64)": true,
+				"responses.200.schema.properties.name=string":       true,
+			},
+			"POST /api/users": {
+				"parameters.body.schema=object":                 true,
+				"parameters.body.schema.properties.name=string": true,
+			},
+		},
+		Paths: map[string][]string{
+			"/api/users/{}": {"/api/users/{param1}"},
+			"/api/users":    {"/api/users"},
+		},
+	})
+}
+
+func TestSpecSignature_Similarity(t *testing.T) {
+	signature := &SpecSignature{
+		Operations: map[string]map[string]bool{
+			"GET /api/{}":  {"a=string": true, "b=integer": true},
+			"POST /api":    {},
+			"DELETE /api":  {"c=string": true},
+			"GET /api/foo": {"d=string": true},
+		},
+	}
+	tests := []struct {
+		name  string
+		other *SpecSignature
+		want  float64
+	}{
+		{
+			name:  "identical",
+			other: signature,
+			want:  1,
+		},
+		{
+			name:  "empty",
+			other: &SpecSignature{},
+			want:  0,
+		},
+		{
+			name: "partial",
+			other: &SpecSignature{
+				Operations: map[string]map[string]bool{
+					// 1/3 of the fields
+					"GET /api/{}": {"a=string": true, "b=string": true},
+					"POST /api":   {},
+					"DELETE /api": {"c=string": true},
+					// only in other
+					"PUT /api": {},
+				},
+			},
+			// (1/3 + 1 + 1) / 5
+			want: (1.0/3 + 2) / 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Assert(t, math.Abs(signature.Similarity(tt.other)-tt.want) < 1e-9, "Similarity() = %v, want %v", signature.Similarity(tt.other), tt.want)
+			assert.Assert(t, math.Abs(tt.other.Similarity(signature)-tt.want) < 1e-9, "Similarity() is not symmetric")
+		})
+	}
+	assert.Equal(t, (&SpecSignature{}).Similarity(&SpecSignature{}), float64(0))
+	assert.Equal(t, signature.SharedOperations(signature), 4)
+}
+
+func Test_getAnonymousParamsPath(t *testing.T) {
+	assert.Equal(t, getAnonymousParamsPath("/api/{id}/items/{param2}"), "/api/{}/items/{}")
+	assert.Equal(t, getAnonymousParamsPath("/api/items"), "/api/items")
+}
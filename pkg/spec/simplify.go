@@ -0,0 +1,239 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	oapi_spec "github.com/go-openapi/spec"
+	log "github.com/sirupsen/logrus"
+)
+
+// WithSchemaSimplification simplifies the generated schemas so they read closer to a hand written spec, see simplifySchema.
+// Schemas are simplified before their definitions are created, so objects that only differed by what was simplified
+// away (e.g. an empty object placeholder or the order of an enum) share the same definition.
+func WithSchemaSimplification() GenerateOASOption {
+	return func(o *generateOASOptions) {
+		o.simplifySchemas = true
+	}
+}
+
+// simplifyPathItemsSchemas simplifies the parameter, body and response schemas of pathItems, see simplifySchema.
+func simplifyPathItemsSchemas(pathItems map[string]*oapi_spec.PathItem) {
+	for _, pathItem := range pathItems {
+		for _, method := range supportedMethods {
+			if operation := GetOperationFromPathItem(pathItem, method); operation != nil {
+				simplifyOperationSchemas(operation)
+			}
+		}
+	}
+}
+
+func simplifyOperationSchemas(op *oapi_spec.Operation) {
+	for i := range op.Parameters {
+		param := &op.Parameters[i]
+		param.Schema = simplifySchema(param.Schema, 0)
+		sortEnum(param.Enum)
+		for items := param.Items; items != nil; items = items.Items {
+			sortEnum(items.Enum)
+		}
+	}
+
+	if op.Responses == nil {
+		return
+	}
+	for code, response := range op.Responses.StatusCodeResponses {
+		response.Schema = simplifySchema(response.Schema, 0)
+		for name, header := range response.Headers {
+			sortEnum(header.Enum)
+			response.Headers[name] = header
+		}
+		op.Responses.StatusCodeResponses[code] = response
+	}
+}
+
+// simplifySchema returns schema simplified, schema may be modified:
+//   - identical schemas of allOf, anyOf and oneOf are merged, and a composition of a single schema (e.g. body variants
+//     that turned out to be the same) is collapsed into it
+//   - object properties that are empty object placeholders (an object without properties, e.g. learned from {}) are
+//     removed, including the objects that are left without properties
+//   - enum values are sorted
+func simplifySchema(schema *oapi_spec.Schema, depth int) *oapi_spec.Schema {
+	if schema == nil {
+		return nil
+	}
+	if depth >= maxSchemaToRefDepth {
+		log.Warnf("Maximum depth was reached")
+		return schema
+	}
+
+	schema.AllOf = simplifySchemas(schema.AllOf, depth)
+	schema.AnyOf = simplifySchemas(schema.AnyOf, depth)
+	schema.OneOf = simplifySchemas(schema.OneOf, depth)
+	if single := getSingleComposedSchema(schema); single != nil {
+		return single
+	}
+
+	if schema.Items != nil {
+		schema.Items.Schema = simplifySchema(schema.Items.Schema, depth+1)
+		schema.Items.Schemas = simplifySchemas(schema.Items.Schemas, depth)
+	}
+	if schema.AdditionalProperties != nil {
+		schema.AdditionalProperties.Schema = simplifySchema(schema.AdditionalProperties.Schema, depth+1)
+	}
+	for name := range schema.Properties {
+		property := schema.Properties[name]
+		simplified := simplifySchema(&property, depth+1)
+		if isEmptyObjectPlaceholder(simplified) {
+			delete(schema.Properties, name)
+			schema.Required = removeString(schema.Required, name)
+			continue
+		}
+		schema.Properties[name] = *simplified
+	}
+	if schema.Properties != nil && len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+	sortEnum(schema.Enum)
+
+	return schema
+}
+
+// simplifySchemas simplifies each of schemas, and removes the schemas identical to a previous one.
+func simplifySchemas(schemas []oapi_spec.Schema, depth int) []oapi_spec.Schema {
+	if len(schemas) == 0 {
+		return schemas
+	}
+
+	ret := make([]oapi_spec.Schema, 0, len(schemas))
+	seen := make(map[string]bool, len(schemas))
+	for i := range schemas {
+		simplified := simplifySchema(&schemas[i], depth+1)
+		schemaBytes, err := json.Marshal(simplified)
+		if err != nil {
+			log.Errorf("Failed to marshal schema: %v", err)
+			ret = append(ret, *simplified)
+			continue
+		}
+		if seen[string(schemaBytes)] {
+			continue
+		}
+		seen[string(schemaBytes)] = true
+		ret = append(ret, *simplified)
+	}
+
+	return ret
+}
+
+// getSingleComposedSchema returns the only schema of an allOf, anyOf or oneOf schema that has nothing else, or nil.
+func getSingleComposedSchema(schema *oapi_spec.Schema) *oapi_spec.Schema {
+	var single *oapi_spec.Schema
+	rest := *schema
+	switch {
+	case len(schema.AllOf) == 1 && len(schema.AnyOf) == 0 && len(schema.OneOf) == 0:
+		single = &schema.AllOf[0]
+		rest.AllOf = nil
+	case len(schema.AnyOf) == 1 && len(schema.AllOf) == 0 && len(schema.OneOf) == 0:
+		single = &schema.AnyOf[0]
+		rest.AnyOf = nil
+	case len(schema.OneOf) == 1 && len(schema.AllOf) == 0 && len(schema.AnyOf) == 0:
+		single = &schema.OneOf[0]
+		rest.OneOf = nil
+	default:
+		return nil
+	}
+	if !reflect.DeepEqual(rest, oapi_spec.Schema{}) {
+		return nil
+	}
+
+	return single
+}
+
+// isEmptyObjectPlaceholder returns true if schema is an object with nothing but its type.
+func isEmptyObjectPlaceholder(schema *oapi_spec.Schema) bool {
+	if len(schema.Type) != 1 || schema.Type[0] != schemaTypeObject || len(schema.Properties) > 0 {
+		return false
+	}
+	rest := *schema
+	rest.Type = nil
+	rest.Properties = nil
+
+	return reflect.DeepEqual(rest, oapi_spec.Schema{})
+}
+
+// sortEnum sorts enum numerically if all its values are numbers, and by their JSON encoding otherwise.
+func sortEnum(enum []interface{}) {
+	if len(enum) < 2 {
+		return
+	}
+
+	type enumValue struct {
+		value   interface{}
+		number  float64
+		encoded string
+	}
+	values := make([]enumValue, len(enum))
+	allNumbers := true
+	for i, value := range enum {
+		valueBytes, err := json.Marshal(value)
+		if err != nil {
+			log.Errorf("Failed to marshal enum value %v: %v", value, err)
+			return
+		}
+		number, ok := getEnumNumber(value)
+		allNumbers = allNumbers && ok
+		values[i] = enumValue{value: value, number: number, encoded: string(valueBytes)}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		if allNumbers {
+			return values[i].number < values[j].number
+		}
+		return values[i].encoded < values[j].encoded
+	})
+	for i := range values {
+		enum[i] = values[i].value
+	}
+}
+
+func getEnumNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float64:
+		return number, true
+	case json.Number:
+		f, err := number.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func removeString(values []string, value string) []string {
+	ret := values[:0]
+	for _, v := range values {
+		if v != value {
+			ret = append(ret, v)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
@@ -0,0 +1,149 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func Test_simplifySchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *oapi_spec.Schema
+		want   *oapi_spec.Schema
+	}{
+		{
+			name:   "nil",
+			schema: nil,
+			want:   nil,
+		},
+		{
+			name: "empty object placeholders are removed",
+			schema: new(oapi_spec.Schema).Typed(schemaTypeObject, "").
+				SetProperty("name", *oapi_spec.StringProperty()).
+				SetProperty("meta", *new(oapi_spec.Schema).Typed(schemaTypeObject, "")).
+				SetProperty("labels", *oapi_spec.MapProperty(oapi_spec.StringProperty())).
+				SetProperty("nested", *new(oapi_spec.Schema).Typed(schemaTypeObject, "").
+					SetProperty("empty", *new(oapi_spec.Schema).Typed(schemaTypeObject, ""))).
+				WithRequired("name", "meta", "nested"),
+			want: new(oapi_spec.Schema).Typed(schemaTypeObject, "").
+				SetProperty("name", *oapi_spec.StringProperty()).
+				SetProperty("labels", *oapi_spec.MapProperty(oapi_spec.StringProperty())).
+				WithRequired("name"),
+		},
+		{
+			name:   "object without properties is kept",
+			schema: new(oapi_spec.Schema).Typed(schemaTypeObject, ""),
+			want:   new(oapi_spec.Schema).Typed(schemaTypeObject, ""),
+		},
+		{
+			name:   "identical variants are collapsed",
+			schema: createVariantsSchema(oapi_spec.StringProperty(), oapi_spec.StringProperty()),
+			want:   oapi_spec.StringProperty(),
+		},
+		{
+			name:   "different variants are kept",
+			schema: createVariantsSchema(oapi_spec.StringProperty(), oapi_spec.Int64Property(), oapi_spec.StringProperty()),
+			want:   createVariantsSchema(oapi_spec.StringProperty(), oapi_spec.Int64Property()),
+		},
+		{
+			name:   "single allOf with a description is kept",
+			schema: oapi_spec.ComposedSchema(*oapi_spec.StringProperty()).WithDescription("name"),
+			want:   oapi_spec.ComposedSchema(*oapi_spec.StringProperty()).WithDescription("name"),
+		},
+		{
+			name: "nested array items",
+			schema: oapi_spec.ArrayProperty(oapi_spec.ArrayProperty(
+				oapi_spec.ComposedSchema(*oapi_spec.StringProperty().WithEnum("b", "a")))),
+			want: oapi_spec.ArrayProperty(oapi_spec.ArrayProperty(oapi_spec.StringProperty().WithEnum("a", "b"))),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := simplifySchema(tt.schema, 0); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("simplifySchema() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortEnum(t *testing.T) {
+	tests := []struct {
+		name string
+		enum []interface{}
+		want []interface{}
+	}{
+		{
+			name: "strings",
+			enum: []interface{}{"pending", "active", "closed"},
+			want: []interface{}{"active", "closed", "pending"},
+		},
+		{
+			name: "numbers are sorted numerically",
+			enum: []interface{}{int64(10), float64(9), json.Number("-1")},
+			want: []interface{}{json.Number("-1"), float64(9), int64(10)},
+		},
+		{
+			name: "mixed values are sorted by json",
+			enum: []interface{}{"b", int64(10), true, int64(9)},
+			want: []interface{}{"b", int64(10), int64(9), true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortEnum(tt.enum)
+			assert.DeepEqual(t, tt.enum, tt.want)
+		})
+	}
+}
+
+func TestSpec_GenerateOASJson_WithSchemaSimplification(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api/users", "host", "200",
+		"", `{"user":{"name":"a","meta":{}}}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api/admins", "host", "200",
+		"", `{"user":{"name":"b"}}`)))
+	s.ApprovedSpec.PathItems = s.LearningSpec.PathItems
+	s.ApprovedPathTrie.Insert("/api/users", "1")
+	s.ApprovedPathTrie.Insert("/api/admins", "2")
+
+	oasJSON, err := s.GenerateOASJson()
+	assert.NilError(t, err)
+	generated := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, generated))
+	assert.Equal(t, len(generated.Definitions), 4)
+
+	simplifiedOASJSON, err := s.GenerateOASJson(WithSchemaSimplification())
+	assert.NilError(t, err)
+	simplified := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(simplifiedOASJSON, simplified))
+	// both operations share the same definitions once the meta placeholder is removed
+	assert.Equal(t, len(simplified.Definitions), 2)
+	usersSchema := simplified.Paths.Paths["/api/users"].Get.Responses.StatusCodeResponses[200].Schema
+	adminsSchema := simplified.Paths.Paths["/api/admins"].Get.Responses.StatusCodeResponses[200].Schema
+	assert.Assert(t, reflect.DeepEqual(usersSchema, adminsSchema))
+
+	// the simplified OAS is not cached as the approved OAS
+	cachedOASJSON, err := s.GenerateOASJson()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cachedOASJSON, oasJSON)
+}
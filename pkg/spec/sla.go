@@ -0,0 +1,143 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"math"
+	"time"
+
+	oapi_spec "github.com/go-openapi/spec"
+)
+
+const slaExtensionName = "x-sla"
+
+// the latency histogram bucket bounds grow by latencyBucketGrowth from latencyBucketBase,
+// so the latency percentiles are approximated within 10%.
+const (
+	latencyBucketBase   = 100 * time.Microsecond
+	latencyBucketGrowth = 1.1
+)
+
+// the latency percentile reported by the SLA extension
+const slaPercentile = 0.99
+
+// LatencyStats is a histogram of the latencies of an operation, its size is bounded whatever the amount of telemetries.
+type LatencyStats struct {
+	Count int
+	Max   time.Duration
+	// Buckets counts the latencies by bucket index, see getLatencyBucket
+	Buckets map[int]int
+}
+
+// SLAExtension describes the observed latencies of an operation, e.g. to derive gateway timeouts from.
+type SLAExtension struct {
+	// SampleCount is the amount of telemetries the latencies were observed from
+	SampleCount   int     `json:"sampleCount"`
+	ObservedP99Ms float64 `json:"observedP99Ms"`
+	MaxMs         float64 `json:"maxMs"`
+}
+
+// WithSLAExtension adds an x-sla extension to the operations learned from telemetries with a latency.
+func WithSLAExtension() GenerateOASOption {
+	return WithOperationExtensions(SLAExtensionInjector)
+}
+
+// SLAExtensionInjector is an OperationExtensionInjector that returns the x-sla extension of the operation
+// from its latency stats, if any.
+func SLAExtensionInjector(_, _ string, _ *oapi_spec.Operation, stats *OperationStats) map[string]interface{} {
+	if stats == nil || stats.Latency == nil || stats.Latency.Count == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		slaExtensionName: &SLAExtension{
+			SampleCount:   stats.Latency.Count,
+			ObservedP99Ms: durationToMilliseconds(stats.Latency.Percentile(slaPercentile)),
+			MaxMs:         durationToMilliseconds(stats.Latency.Max),
+		},
+	}
+}
+
+func (o *OperationStats) addLatency(latency time.Duration) {
+	if o.Latency == nil {
+		o.Latency = &LatencyStats{}
+	}
+	o.Latency.add(latency)
+}
+
+func (l *LatencyStats) add(latency time.Duration) {
+	if l.Buckets == nil {
+		l.Buckets = make(map[int]int)
+	}
+	l.Count++
+	l.Buckets[getLatencyBucket(latency)]++
+	if latency > l.Max {
+		l.Max = latency
+	}
+}
+
+func (l *LatencyStats) merge(other *LatencyStats) {
+	if other == nil {
+		return
+	}
+	if l.Buckets == nil {
+		l.Buckets = make(map[int]int)
+	}
+	l.Count += other.Count
+	for bucket, count := range other.Buckets {
+		l.Buckets[bucket] += count
+	}
+	if other.Max > l.Max {
+		l.Max = other.Max
+	}
+}
+
+// Percentile returns the approximate latency under which the p (0-1] part of the latencies fall,
+// i.e. the upper bound of its histogram bucket, capped at the max latency.
+func (l *LatencyStats) Percentile(p float64) time.Duration {
+	if l == nil || l.Count == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p * float64(l.Count)))
+	maxBucket := getLatencyBucket(l.Max)
+	seen := 0
+	for bucket := 0; bucket < maxBucket; bucket++ {
+		seen += l.Buckets[bucket]
+		if seen >= rank {
+			return getLatencyBucketBound(bucket)
+		}
+	}
+
+	return l.Max
+}
+
+// getLatencyBucket returns the index of the histogram bucket of latency, bucket i holds the latencies up to
+// getLatencyBucketBound(i), bucket 0 holds all the latencies up to latencyBucketBase.
+func getLatencyBucket(latency time.Duration) int {
+	if latency <= latencyBucketBase {
+		return 0
+	}
+	return int(math.Ceil(math.Log(float64(latency)/float64(latencyBucketBase)) / math.Log(latencyBucketGrowth)))
+}
+
+func getLatencyBucketBound(bucket int) time.Duration {
+	return time.Duration(float64(latencyBucketBase) * math.Pow(latencyBucketGrowth, float64(bucket)))
+}
+
+func durationToMilliseconds(d time.Duration) float64 {
+	return roundTwoDecimals(float64(d) / float64(time.Millisecond))
+}
@@ -0,0 +1,142 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpec_GenerateOASJson_WithSLAExtension(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	// 99 fast requests and a slow one spread over two learned paths, plus a POST without latency
+	for i := 0; i < 100; i++ {
+		path := "/api/1"
+		if i%2 == 0 {
+			path = "/api/2"
+		}
+		telemetry := createTelemetry("req-id", http.MethodGet, path, "host", "200", Data.ReqBody, Data.RespBody)
+		telemetry.Latency = 10 * time.Millisecond
+		if i == 0 {
+			telemetry.Latency = 2 * time.Second
+		}
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api/1", "host", "200", Data.ReqBody, Data.RespBody)))
+
+	s.ApprovedSpec.PathItems["/api/{param1}"] = &NewTestPathItem().
+		WithPathParams("param1", schemaTypeInteger, "").
+		WithOperation(http.MethodGet, NewOperation(t, Data).Op).
+		WithOperation(http.MethodPost, NewOperation(t, Data).Op).PathItem
+	s.ApprovedPathTrie.Insert("/api/{param1}", "1")
+
+	oasJSON, err := s.GenerateOASJson(WithSLAExtension())
+	assert.NilError(t, err)
+	generated := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, generated))
+
+	pathItem := generated.Paths.Paths["/api/{param1}"]
+	sla := &SLAExtension{}
+	slaJSON, err := json.Marshal(pathItem.Get.Extensions[slaExtensionName])
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(slaJSON, sla))
+	assert.Equal(t, sla.SampleCount, 100)
+	assert.Equal(t, sla.MaxMs, float64(2000))
+	// p99 is approximated by its histogram bucket bound
+	assert.Assert(t, sla.ObservedP99Ms >= 10 && sla.ObservedP99Ms <= 11, "p99 = %v", sla.ObservedP99Ms)
+
+	// no latency was learned for POST
+	_, ok := pathItem.Post.Extensions[slaExtensionName]
+	assert.Assert(t, !ok)
+}
+
+func TestLatencyStats_Percentile(t *testing.T) {
+	createLatencyStats := func(latencies ...time.Duration) *LatencyStats {
+		stats := &LatencyStats{}
+		for _, latency := range latencies {
+			stats.add(latency)
+		}
+		return stats
+	}
+	tests := []struct {
+		name    string
+		stats   *LatencyStats
+		p       float64
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name:  "nil",
+			stats: nil,
+			p:     0.99,
+		},
+		{
+			name:    "single latency is the max",
+			stats:   createLatencyStats(5 * time.Millisecond),
+			p:       0.99,
+			wantMin: 5 * time.Millisecond,
+			wantMax: 5 * time.Millisecond,
+		},
+		{
+			name:    "below the bucket base",
+			stats:   createLatencyStats(time.Microsecond, time.Microsecond, time.Second),
+			p:       0.5,
+			wantMin: latencyBucketBase,
+			wantMax: latencyBucketBase,
+		},
+		{
+			name:    "median",
+			stats:   createLatencyStats(time.Millisecond, 20*time.Millisecond, 20*time.Millisecond, time.Second),
+			p:       0.5,
+			wantMin: 20 * time.Millisecond,
+			wantMax: 22 * time.Millisecond,
+		},
+		{
+			name:    "p100 is the max",
+			stats:   createLatencyStats(time.Millisecond, 1234*time.Millisecond),
+			p:       1,
+			wantMin: 1234 * time.Millisecond,
+			wantMax: 1234 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.stats.Percentile(tt.p)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("Percentile() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestLatencyStats_merge(t *testing.T) {
+	stats := &LatencyStats{}
+	stats.add(time.Millisecond)
+	other := &LatencyStats{}
+	other.add(time.Millisecond)
+	other.add(time.Second)
+
+	stats.merge(other)
+	stats.merge(nil)
+	assert.Equal(t, stats.Count, 3)
+	assert.Equal(t, stats.Max, time.Second)
+	assert.DeepEqual(t, stats.Buckets, map[int]int{getLatencyBucket(time.Millisecond): 2, getLatencyBucket(time.Second): 1})
+}
@@ -0,0 +1,54 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import "sort"
+
+// SourceLabel classifies where a request came from.
+type SourceLabel string
+
+const (
+	SourceInternal SourceLabel = "internal"
+	SourceExternal SourceLabel = "external"
+	SourcePartner  SourceLabel = "partner"
+	// SourceUnknown is used for telemetries that were not classified.
+	SourceUnknown SourceLabel = "unknown"
+)
+
+func (t *Telemetry) getSource() SourceLabel {
+	if t.Source == "" {
+		return SourceUnknown
+	}
+	return t.Source
+}
+
+// GetOperationsBySource returns the learned operations (path to sorted methods) that were hit by requests from source.
+func (s *SpecStats) GetOperationsBySource(source SourceLabel) map[string][]string {
+	ret := make(map[string][]string)
+	for path, methods := range s.Operations {
+		for method, opStats := range methods {
+			if opStats.Sources[source] == 0 {
+				continue
+			}
+			ret[path] = append(ret[path], method)
+		}
+	}
+	for path := range ret {
+		sort.Strings(ret[path])
+	}
+
+	return ret
+}
@@ -0,0 +1,50 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpecStats_GetOperationsBySource(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+
+	learn := func(method, path string, source SourceLabel) {
+		telemetry := createTelemetry("req-id", method, path, "host", "200", Data.ReqBody, Data.RespBody)
+		telemetry.Source = source
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	learn(http.MethodGet, "/api/internal", SourceInternal)
+	learn(http.MethodPost, "/api/both", SourceInternal)
+	learn(http.MethodPost, "/api/both", SourceExternal)
+	learn(http.MethodGet, "/api/both", SourceExternal)
+	learn(http.MethodGet, "/api/unclassified", "")
+
+	assert.DeepEqual(t, s.LearningStats.GetOperationsBySource(SourceExternal), map[string][]string{
+		"/api/both": {http.MethodGet, http.MethodPost},
+	})
+	assert.DeepEqual(t, s.LearningStats.GetOperationsBySource(SourceInternal), map[string][]string{
+		"/api/internal": {http.MethodGet},
+		"/api/both":     {http.MethodPost},
+	})
+	assert.DeepEqual(t, s.LearningStats.GetOperationsBySource(SourceUnknown), map[string][]string{
+		"/api/unclassified": {http.MethodGet},
+	})
+	assert.DeepEqual(t, s.LearningStats.GetOperationsBySource(SourcePartner), map[string][]string{})
+}
@@ -16,20 +16,23 @@
 package spec
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/go-openapi/loads"
 	oapi_spec "github.com/go-openapi/spec"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/validate"
-	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/apiclarity/speculator/pkg/pathtrie"
+	"github.com/apiclarity/speculator/pkg/utils"
 	"github.com/apiclarity/speculator/pkg/utils/errors"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
 )
 
 type Spec struct {
@@ -37,7 +40,14 @@ type Spec struct {
 
 	OpGenerator *OperationGenerator
 
-	lock sync.Mutex
+	// lock is held for reading by the methods that don't change the spec (e.g. GenerateOASJson and DiffTelemetry),
+	// so they don't block each other
+	lock sync.RWMutex
+	// oasCache is the generated approved OAS, see generateCachedApprovedOASJson
+	oasCache        generatedOASCache
+	generationStats generationStats
+	// observers are not part of the spec state, see AddLearningObserver
+	observers learningObservers
 }
 
 type SpecInfo struct {
@@ -45,6 +55,8 @@ type SpecInfo struct {
 	Host string
 
 	Port string
+	// BasePath of a spec of one of the logical APIs of a host (e.g. /billing), the paths of the spec are relative to it
+	BasePath string
 	// Spec ID
 	ID uuid.UUID
 	// Provided Spec
@@ -54,8 +66,33 @@ type SpecInfo struct {
 	// Upon learning, this will be updated (not the ApprovedSpec field)
 	LearningSpec *LearningSpec
 
-	ApprovedPathTrie pathtrie.PathTrie
-	ProvidedPathTrie pathtrie.PathTrie
+	ApprovedPathTrie pathtrie.TypedPathTrie[string]
+	ProvidedPathTrie pathtrie.TypedPathTrie[string]
+
+	// Statistics of the learned telemetries
+	LearningStats *SpecStats
+
+	// Learned operations that are never suggested for review (path -> method)
+	IgnoredOperations map[string]map[string]bool
+
+	// Learned operations whose schema is no longer learned (path -> method), see Spec.FreezePath
+	FrozenOperations map[string]map[string]bool
+
+	// Path patterns that are dropped from the learning spec and never learned again, see Spec.RejectPath
+	RejectedPaths map[string]bool
+
+	// Telemetries that conflicted with established operation schemas, see OperationGeneratorConfig.SchemaMergeMinEstablishedHits,
+	// or drifted from frozen operation schemas, see Spec.FreezePath
+	SchemaOutliers []*SchemaOutlier
+
+	// Redacted copies of the telemetries that created paths or conflicted with schemas, see OperationGeneratorConfig.MaxRetainedSamples
+	RetainedSamples []*RetainedSample
+
+	// Learned paths that are never grouped into a parameterized path, see Spec.SplitPath
+	SplitPaths map[string]bool
+
+	// Learned paths that are always grouped into a user template (path -> template), see Spec.MergePaths
+	PathTemplates map[string]string
 }
 
 type LearningParametrizedPaths struct {
@@ -66,6 +103,8 @@ type LearningParametrizedPaths struct {
 }
 
 type Telemetry struct {
+	// SchemaVersion of the telemetry JSON format, see DecodeTelemetry
+	SchemaVersion        string    `json:"schemaVersion,omitempty"`
 	DestinationAddress   string    `json:"destinationAddress,omitempty"`
 	DestinationNamespace string    `json:"destinationNamespace,omitempty"`
 	Request              *Request  `json:"request,omitempty"`
@@ -73,6 +112,14 @@ type Telemetry struct {
 	Response             *Response `json:"response,omitempty"`
 	Scheme               string    `json:"scheme,omitempty"`
 	SourceAddress        string    `json:"sourceAddress,omitempty"`
+	// Source of the request (internal/external/...), SourceUnknown when not set
+	Source SourceLabel `json:"source,omitempty"`
+	// Metadata about the caller (ASN, geo, service identity...), see MetadataKey* for well known keys
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Timestamp the interaction was captured at, processing time is used when not set
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	// Latency the server took to respond (in nanoseconds in JSON), the latency is not learned when not set
+	Latency time.Duration `json:"latency,omitempty"`
 }
 
 type Request struct {
@@ -100,6 +147,13 @@ type Header struct {
 }
 
 func (s *Spec) HasApprovedSpec() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.hasApprovedSpec()
+}
+
+func (s *Spec) hasApprovedSpec() bool {
 	if s.ApprovedSpec == nil || len(s.ApprovedSpec.PathItems) == 0 {
 		return false
 	}
@@ -108,6 +162,13 @@ func (s *Spec) HasApprovedSpec() bool {
 }
 
 func (s *Spec) HasProvidedSpec() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.hasProvidedSpec()
+}
+
+func (s *Spec) hasProvidedSpec() bool {
 	if s.ProvidedSpec == nil || s.ProvidedSpec.Spec == nil || s.ProvidedSpec.Spec.Paths == nil || s.ProvidedSpec.Spec.Paths.Paths == nil {
 		return false
 	}
@@ -127,7 +188,8 @@ func (s *Spec) UnsetApprovedSpec() {
 		PathItems:           map[string]*oapi_spec.PathItem{},
 		SecurityDefinitions: map[string]*oapi_spec.SecurityScheme{},
 	}
-	s.ApprovedPathTrie = pathtrie.New()
+	s.ApprovedPathTrie = pathtrie.NewTyped[string]()
+	s.oasCache.invalidate()
 }
 
 func (s *Spec) UnsetProvidedSpec() {
@@ -135,32 +197,131 @@ func (s *Spec) UnsetProvidedSpec() {
 	defer s.lock.Unlock()
 
 	s.ProvidedSpec = nil
-	s.ProvidedPathTrie = pathtrie.New()
+	s.ProvidedPathTrie = pathtrie.NewTyped[string]()
 }
 
+// SetOperationGeneratorConfig replaces the operation generator config, it applies to telemetries learned from now on.
+// Operations already learned are kept as is.
+func (s *Spec) SetOperationGeneratorConfig(config OperationGeneratorConfig) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.OpGenerator = NewOperationGenerator(config)
+}
+
+// LearnTelemetry learns telemetry into the learning spec. A telemetry that can't be learned, including one that makes
+// inference panic, fails on its own and leaves the other learned telemetries intact.
 func (s *Spec) LearnTelemetry(telemetry *Telemetry) error {
+	return s.LearnTelemetryCtx(context.Background(), telemetry)
+}
+
+// LearnTelemetryCtx is LearnTelemetry that gives up once ctx is done, e.g. while waiting for the lock or parsing
+// the bodies. A canceled telemetry is not learned at all, the returned error then wraps ctx.Err().
+func (s *Spec) LearnTelemetryCtx(ctx context.Context, telemetry *Telemetry) (err error) {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("learning canceled: %w", err)
+	}
+	// the observers are notified after the lock is released
+	defer s.observers.notify()
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	defer utils.RecoverPanic(&err)
 
-	method := telemetry.Request.Method
+	learning, err := s.prepareTelemetryLearning(ctx, telemetry)
+	if err != nil || learning == nil {
+		return err
+	}
+	// the learning spec is not modified until here
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("learning canceled: %w", err)
+	}
+	s.learnOperation(learning.path, learning.method, learning.operation, []*telemetryLearning{learning})
+
+	return nil
+}
+
+// telemetryLearning is a telemetry prepared to be learned, see Spec.prepareTelemetryLearning.
+type telemetryLearning struct {
+	telemetry *Telemetry
+	path      string
+	method    string
+	// operation learned from the telemetry alone
+	operation *oapi_spec.Operation
+	// partial is true if the operation was learned without the request or the response body
+	partial     bool
+	fieldValues map[string][]string
+	objects     map[string][][]string
+}
+
+// prepareTelemetryLearning validates telemetry and learns its operation, nil is returned if telemetry should be ignored.
+func (s *Spec) prepareTelemetryLearning(ctx context.Context, telemetry *Telemetry) (*telemetryLearning, error) {
+	if err := telemetry.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid telemetry: %w", err)
+	}
+	method, err := NormalizeMethod(telemetry.Request.Method)
+	if err != nil {
+		return nil, fmt.Errorf("invalid telemetry: %w", err)
+	}
 	// remove query params if exists
 	path, _ := GetPathAndQuery(telemetry.Request.Path)
-	telemetryOp, err := s.telemetryToOperation(telemetry, s.LearningSpec.SecurityDefinitions)
+	if s.isPathRejected(path) {
+		log.Debugf("Ignoring telemetry of rejected path. path=%v", path)
+		return nil, nil
+	}
+	telemetryOp, partial, err := s.telemetryToOperation(ctx, telemetry, s.LearningSpec.SecurityDefinitions)
 	if err != nil {
-		return fmt.Errorf("failed to convert telemetry to operation. %v", err)
+		return nil, fmt.Errorf("failed to convert telemetry to operation. %w", err)
+	}
+	learning := &telemetryLearning{
+		telemetry: telemetry,
+		path:      path,
+		method:    method,
+		operation: telemetryOp,
+		partial:   partial,
+	}
+	if s.OpGenerator.EnumMaxValues > 0 {
+		learning.fieldValues = s.OpGenerator.getTelemetryFieldValues(telemetry, telemetryOp)
+	}
+	if s.OpGenerator.RequiredPropertyMinRatio > 0 {
+		learning.objects = s.OpGenerator.getTelemetryPropertyPresence(telemetry)
+	}
+
+	return learning, nil
+}
+
+// learnOperation merges telemetryOp, the operation learned from the telemetries of learnings (of path and method),
+// into the learning spec and records the telemetries stats. The first telemetry is the one retained for a new path
+// or a merge conflict.
+func (s *Spec) learnOperation(path, method string, telemetryOp *oapi_spec.Operation, learnings []*telemetryLearning) {
+	telemetry := learnings[0].telemetry
+	seen := telemetry.CaptureTime()
+	for _, learning := range learnings[1:] {
+		if captureTime := learning.telemetry.CaptureTime(); captureTime.After(seen) {
+			seen = captureTime
+		}
 	}
 	var existingOp *oapi_spec.Operation
 
 	// Get existing path item or create a new one
 	pathItem := s.LearningSpec.GetPathItem(path)
-	if pathItem == nil {
+	isNewPath := pathItem == nil
+	if isNewPath {
 		pathItem = &oapi_spec.PathItem{}
+		s.retainSample(SampleRetentionReasonNewPath, path, method, nil, telemetry)
 	}
 
 	// Get existing operation of path item, and if exists, merge it with the operation learned from this interaction
 	existingOp = GetOperationFromPathItem(pathItem, method)
+	// merging may update the existing operation
+	fieldsBefore := getOperationFieldTypes(existingOp)
 	if existingOp != nil {
-		telemetryOp, _ = mergeOperation(existingOp, telemetryOp)
+		var conflicts []conflict
+		telemetryOp, conflicts = s.mergeLearnedOperation(path, method, existingOp, telemetryOp, seen)
+		if len(conflicts) > 0 {
+			fields := getConflictFields(conflicts)
+			s.retainSample(SampleRetentionReasonSchemaConflict, path, method, fields, telemetry)
+			s.queueSchemaConflictEvent(path, method, fields)
+		}
 	}
 
 	// save Operation on the path item
@@ -169,11 +330,64 @@ func (s *Spec) LearnTelemetry(telemetry *Telemetry) error {
 	// add/update this path item in the spec
 	s.LearningSpec.AddPathItem(path, pathItem)
 
-	return nil
+	for _, learning := range learnings {
+		s.recordTelemetryStats(path, method, learning.telemetry, learning.partial)
+		s.recordFieldValues(path, method, learning.fieldValues)
+		s.recordPropertyPresence(path, method, learning.objects)
+	}
+	schemaChange := s.recordSchemaChange(path, method, fieldsBefore, telemetryOp, seen)
+	if isNewPath {
+		s.queueNewPathEvent(path, method)
+	} else if existingOp != nil {
+		s.queueOperationMergedEvent(path, method, schemaChange)
+	}
 }
 
-func (s *Spec) GenerateOASYaml() ([]byte, error) {
-	oasJSON, err := s.GenerateOASJson()
+type GenerateOASOption func(*generateOASOptions)
+
+type generateOASOptions struct {
+	withStatsExtension          bool
+	operationExtensionInjectors []OperationExtensionInjector
+	cachedValidation            bool
+	skipValidation              bool
+	simplifySchemas             bool
+	ctx                         context.Context
+}
+
+func createGenerateOASOptions(opts []GenerateOASOption) *generateOASOptions {
+	options := &generateOASOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// hasExtensions returns true if the options add extensions that depend on the learning stats.
+func (o *generateOASOptions) hasExtensions() bool {
+	return o.withStatsExtension || len(o.operationExtensionInjectors) > 0
+}
+
+// withContext stops the generation once ctx is done, see Spec.GenerateOASJsonCtx.
+func withContext(ctx context.Context) GenerateOASOption {
+	return func(o *generateOASOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithStatsExtension embeds an x-speculator block into the spec info, describing how the spec was learned.
+func WithStatsExtension() GenerateOASOption {
+	return func(o *generateOASOptions) {
+		o.withStatsExtension = true
+	}
+}
+
+func (s *Spec) GenerateOASYaml(opts ...GenerateOASOption) ([]byte, error) {
+	return s.GenerateOASYamlCtx(context.Background(), opts...)
+}
+
+// GenerateOASYamlCtx is GenerateOASYaml that stops once ctx is done, see GenerateOASJsonCtx.
+func (s *Spec) GenerateOASYamlCtx(ctx context.Context, opts ...GenerateOASOption) ([]byte, error) {
+	oasJSON, err := s.GenerateOASJsonCtx(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate json spec: %w", err)
 	}
@@ -186,39 +400,88 @@ func (s *Spec) GenerateOASYaml() ([]byte, error) {
 	return oasYaml, nil
 }
 
-func (s *Spec) GenerateOASJson() ([]byte, error) {
-	// yaml.Marshal does not omit empty fields
-	var definitions oapi_spec.Definitions
+func (s *Spec) GenerateOASJson(opts ...GenerateOASOption) ([]byte, error) {
+	return s.GenerateOASJsonCtx(context.Background(), opts...)
+}
+
+// GenerateOASJsonCtx is GenerateOASJson that stops once ctx is done, which also bounds the validation of the
+// generated spec by the deadline of ctx. The returned error then wraps ctx.Err(), and nothing is cached.
+func (s *Spec) GenerateOASJsonCtx(ctx context.Context, opts ...GenerateOASOption) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("generation canceled: %w", err)
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	defer s.recordGenerationDuration(time.Now())
+
+	return s.generateCachedApprovedOASJson(append(opts[:len(opts):len(opts)], withContext(ctx)))
+}
 
+// generateApprovedOASJson generates the OAS of the approved spec, with the lock held.
+func (s *Spec) generateApprovedOASJson(opts ...GenerateOASOption) ([]byte, error) {
 	clonedApprovedSpec, err := s.ApprovedSpec.Clone()
 	if err != nil {
 		return nil, fmt.Errorf("failed to clone approved spec. %v", err)
 	}
 
-	clonedApprovedSpec.PathItems, definitions = reconstructObjectRefs(clonedApprovedSpec.PathItems)
+	return s.generateOASJson(clonedApprovedSpec.PathItems, clonedApprovedSpec.SecurityDefinitions, s.getApprovedOperationStats, opts)
+}
+
+// generateOASJson generates an OAS from pathItems, which may be modified.
+// getOperationStats returns the operation stats by the paths of pathItems, it is called only if needed.
+func (s *Spec) generateOASJson(pathItems map[string]*oapi_spec.PathItem, securityDefinitions oapi_spec.SecurityDefinitions,
+	getOperationStats func() map[string]map[string]*OperationStats, opts []GenerateOASOption) ([]byte, error) {
+	// yaml.Marshal does not omit empty fields
+	var definitions oapi_spec.Definitions
+
+	options := createGenerateOASOptions(opts)
+
+	if options.simplifySchemas {
+		simplifyPathItemsSchemas(pathItems)
+	}
+	pathItems, definitions = reconstructObjectRefs(pathItems)
+	convertCookieParamsToHeader(pathItems)
+	convertBodyVariantsToExtension(pathItems)
+	if len(options.operationExtensionInjectors) > 0 {
+		injectOperationExtensions(pathItems, getOperationStats(), options.operationExtensionInjectors)
+	}
 
 	generatedSpec := &oapi_spec.Swagger{
 		SwaggerProps: oapi_spec.SwaggerProps{
-			Host:    s.Host + ":" + s.Port,
-			Swagger: "2.0",
-			Info:    createDefaultSwaggerInfo(),
+			Host:     s.Host + ":" + s.Port,
+			BasePath: s.BasePath,
+			Swagger:  "2.0",
+			Info:     createDefaultSwaggerInfo(),
 			Paths: &oapi_spec.Paths{
 				Paths: map[string]oapi_spec.PathItem{},
 			},
 			Definitions:         definitions,
-			SecurityDefinitions: clonedApprovedSpec.SecurityDefinitions,
+			SecurityDefinitions: securityDefinitions,
 		},
 	}
 
-	for path, approvedPathItem := range clonedApprovedSpec.PathItems {
-		generatedSpec.Paths.Paths[path] = *approvedPathItem
+	for path, pathItem := range pathItems {
+		generatedSpec.Paths.Paths[path] = *pathItem
 	}
 
+	if options.withStatsExtension {
+		generatedSpec.Info.AddExtension(specStatsExtensionName, s.createSpecStatsExtension())
+	}
+
+	if err := options.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("generation canceled: %w", err)
+	}
 	ret, err := json.Marshal(generatedSpec)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal the spec. %v", err)
 	}
-	if err := validateRawJSONSpec(ret); err != nil {
+	if options.skipValidation {
+		return ret, nil
+	}
+	if err := validateRawJSONSpecCtx(options.ctx, ret); err != nil {
+		if options.ctx.Err() != nil {
+			return nil, err
+		}
 		log.Errorf("Failed to validate the spec. %v\n\nspec: %s", err, ret)
 		return nil, fmt.Errorf("failed to validate the spec. %w", err)
 	}
@@ -226,22 +489,41 @@ func (s *Spec) GenerateOASJson() ([]byte, error) {
 	return ret, nil
 }
 
+// WithReadLock calls fn with the spec locked for reading, e.g. to encode the spec consistently while it is learned
+// concurrently. fn must not call the methods of the spec, they lock it as well.
+func (s *Spec) WithReadLock(fn func() error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return fn()
+}
+
+// SpecInfoClone returns a spec with a deep copy of the spec info, without the operation generator.
 func (s *Spec) SpecInfoClone() (*Spec, error) {
-	var clonedSpecInfo SpecInfo
+	return &Spec{
+		SpecInfo: cloneSpecInfo(&s.SpecInfo),
+		lock:     sync.RWMutex{},
+	}, nil
+}
 
-	specB, err := json.Marshal(s.SpecInfo)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal spec info: %w", err)
+// validateRawJSONSpecCtx validates spec like validateRawJSONSpec, but returns once ctx is done. The validation can't
+// be interrupted, so it is left to finish in the background on its own copy of spec.
+func validateRawJSONSpecCtx(ctx context.Context, spec []byte) error {
+	if ctx.Done() == nil {
+		return validateRawJSONSpec(spec)
 	}
 
-	if err := json.Unmarshal(specB, &clonedSpecInfo); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal spec info: %w", err)
+	spec = append([]byte{}, spec...)
+	result := make(chan error, 1)
+	go func() {
+		result <- validateRawJSONSpec(spec)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("spec validation canceled: %w", ctx.Err())
 	}
-
-	return &Spec{
-		SpecInfo: clonedSpecInfo,
-		lock:     sync.Mutex{},
-	}, nil
 }
 
 func validateRawJSONSpec(spec []byte) error {
@@ -256,8 +538,11 @@ func validateRawJSONSpec(spec []byte) error {
 	return nil
 }
 
+// reconstructObjectRefs replaces the object schemas of pathItems with definition refs. The paths are iterated in order
+// so that the definition names are the same between exports.
 func reconstructObjectRefs(pathItems map[string]*oapi_spec.PathItem) (retPathItems map[string]*oapi_spec.PathItem, definitions map[string]oapi_spec.Schema) {
-	for _, item := range pathItems {
+	for _, path := range getSortedPaths(pathItems, nil) {
+		item := pathItems[path]
 		definitions, item.Get = updateDefinitions(definitions, item.Get)
 		definitions, item.Put = updateDefinitions(definitions, item.Put)
 		definitions, item.Post = updateDefinitions(definitions, item.Post)
@@ -17,14 +17,19 @@ package spec
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	oapi_spec "github.com/go-openapi/spec"
-	uuid "github.com/satori/go.uuid"
 
 	"github.com/apiclarity/speculator/pkg/pathtrie"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
 )
 
 func TestSpec_LearnTelemetry(t *testing.T) {
@@ -191,9 +196,9 @@ func TestSpec_LearnTelemetry(t *testing.T) {
 }
 
 func TestSpec_SpecInfoClone(t *testing.T) {
-	uuidVar := uuid.NewV4()
-	pathTrie := pathtrie.New()
-	pathTrie.Insert("/api", 1)
+	uuidVar := uuid.New()
+	pathTrie := pathtrie.NewTyped[string]()
+	pathTrie.Insert("/api", "1")
 
 	type fields struct {
 		Host             string
@@ -202,8 +207,8 @@ func TestSpec_SpecInfoClone(t *testing.T) {
 		ProvidedSpec     *ProvidedSpec
 		ApprovedSpec     *ApprovedSpec
 		LearningSpec     *LearningSpec
-		ApprovedPathTrie pathtrie.PathTrie
-		ProvidedPathTrie pathtrie.PathTrie
+		ApprovedPathTrie pathtrie.TypedPathTrie[string]
+		ProvidedPathTrie pathtrie.TypedPathTrie[string]
 	}
 	tests := []struct {
 		name    string
@@ -304,3 +309,201 @@ func TestSpec_SpecInfoClone(t *testing.T) {
 		})
 	}
 }
+
+func TestSpec_LearnTelemetry_Invalid(t *testing.T) {
+	telemetry := createTelemetry("req-id", http.MethodGet, "/api", "host", "200", "", "")
+	tests := []struct {
+		name      string
+		spec      *Spec
+		telemetry *Telemetry
+		wantPanic bool
+	}{
+		{
+			name:      "nil telemetry",
+			spec:      CreateDefaultSpec("host", "80", testOperationGeneratorConfig),
+			telemetry: nil,
+		},
+		{
+			name:      "missing response",
+			spec:      CreateDefaultSpec("host", "80", testOperationGeneratorConfig),
+			telemetry: &Telemetry{Request: telemetry.Request},
+		},
+		{
+			name:      "panic is recovered",
+			spec:      &Spec{OpGenerator: NewOperationGenerator(testOperationGeneratorConfig)},
+			telemetry: telemetry,
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.LearnTelemetry(tt.telemetry)
+			if err == nil {
+				t.Fatalf("LearnTelemetry() expected error")
+			}
+			if got := errors.Is(err, _errors.ErrRecoveredPanic); got != tt.wantPanic {
+				t.Errorf("LearnTelemetry() error = %v, wantPanic %v", err, tt.wantPanic)
+			}
+		})
+	}
+}
+
+func TestSpec_LearnTelemetryCtx(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expiredCtx, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			name: "learned",
+			ctx:  context.Background(),
+		},
+		{
+			name:    "canceled",
+			ctx:     canceledCtx,
+			wantErr: context.Canceled,
+		},
+		{
+			name:    "deadline exceeded",
+			ctx:     expiredCtx,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+			err := s.LearnTelemetryCtx(tt.ctx, createTelemetry("1", http.MethodGet, "/api", "host", "200", "", `{"id": 1}`))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LearnTelemetryCtx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			stats := s.Stats()
+			if len(s.LearningSpec.PathItems) != 0 || stats.TelemetryCount != 0 {
+				t.Errorf("LearnTelemetryCtx() learned a canceled telemetry")
+			}
+		})
+	}
+}
+
+func TestOperationGenerator_GenerateSpecOperationCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	data := &HTTPInteractionData{
+		RespBody:    `{"id": 1}`,
+		RespHeaders: map[string]string{contentTypeHeaderName: mediaTypeApplicationJSON},
+		statusCode:  200,
+	}
+
+	_, err := CreateTestNewOperationGenerator().GenerateSpecOperationCtx(ctx, data, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateSpecOperationCtx() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := CreateTestNewOperationGenerator().GenerateSpecOperationCtx(context.Background(), data, nil); err != nil {
+		t.Errorf("GenerateSpecOperationCtx() error = %v", err)
+	}
+}
+
+func TestSpec_GenerateOASJsonCtx(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	if err := s.LearnTelemetry(createTelemetry("1", http.MethodGet, "/api", "host", "200", "", `{"id": 1}`)); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.GenerateLearningOASCtx(canceledCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateLearningOASCtx() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := s.GenerateLearningOASCtx(context.Background()); err != nil {
+		t.Errorf("GenerateLearningOASCtx() error = %v", err)
+	}
+	if err := s.ApprovePaths([]string{"/api"}); err != nil {
+		t.Fatalf("ApprovePaths() error = %v", err)
+	}
+	if _, err := s.GenerateOASJsonCtx(canceledCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateOASJsonCtx() error = %v, want %v", err, context.Canceled)
+	}
+	ctx, cancelCtx := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelCtx()
+	if _, err := s.GenerateOASYamlCtx(ctx); err != nil {
+		t.Errorf("GenerateOASYamlCtx() error = %v", err)
+	}
+}
+
+func Test_validateRawJSONSpecCtx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	validSpec := []byte(`{"swagger": "2.0", "info": {"title": "test", "version": "1.0"}, "paths": {}}`)
+	if err := validateRawJSONSpecCtx(ctx, validSpec); err != nil {
+		t.Errorf("validateRawJSONSpecCtx() error = %v", err)
+	}
+	invalidSpec := []byte(`{"swagger": "2.0", "paths": {"/api": {"get": {}}}}`)
+	if err := validateRawJSONSpecCtx(ctx, invalidSpec); !errors.Is(err, _errors.ErrSpecValidation) {
+		t.Errorf("validateRawJSONSpecCtx() error = %v, want %v", err, _errors.ErrSpecValidation)
+	}
+}
+
+func TestSpec_ConcurrentReads(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	if err := s.LearnTelemetry(createTelemetry("1", http.MethodGet, "/api/1", "host", "200", "", `{"id": 1}`)); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+	if err := s.ApprovePaths([]string{"/api/1"}); err != nil {
+		t.Fatalf("ApprovePaths() error = %v", err)
+	}
+
+	// readers don't wait for each other, they only wait for learning
+	s.lock.RLock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if !s.HasApprovedSpec() {
+			t.Errorf("HasApprovedSpec() = false, want true")
+		}
+		if _, err := s.GenerateOASJson(); err != nil {
+			t.Errorf("GenerateOASJson() error = %v", err)
+		}
+		if _, err := s.DiffTelemetry(createTelemetry("2", http.MethodGet, "/api/1", "host", "200", "", `{"id": 2}`), DiffSourceReconstructed); err != nil {
+			t.Errorf("DiffTelemetry() error = %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("readers were blocked by a reader")
+	}
+	s.lock.RUnlock()
+	<-done
+
+	// learning concurrently with reading
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := s.LearnTelemetry(createTelemetry("3", http.MethodGet, "/api/2", "host", "200", "", `{"id": 3}`)); err != nil {
+				t.Errorf("LearnTelemetry() error = %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if _, err := s.GenerateOASJson(); err != nil {
+				t.Errorf("GenerateOASJson() error = %v", err)
+			}
+			if _, err := s.GenerateLearningOAS(); err != nil {
+				t.Errorf("GenerateLearningOAS() error = %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+}
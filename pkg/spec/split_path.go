@@ -0,0 +1,151 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	oapi_spec "github.com/go-openapi/spec"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+// SplitPath splits literalPath (e.g. /orders/latest) out of parameterizedPath (e.g. /orders/{orderId}), so it is
+// never grouped into it again when suggesting a review.
+// If parameterizedPath is approved, literalPath is approved as a path of its own, with the operations reconstructed
+// from its learned operations and retained samples. Operations that were only counted in the stats are copied
+// from parameterizedPath. The approved parameterizedPath is left as is.
+func (s *Spec) SplitPath(parameterizedPath, literalPath string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if parameterizedPath == literalPath {
+		return fmt.Errorf("path %v is not parameterized", parameterizedPath)
+	}
+	if _, ok := utils.GetPathParamValues(parameterizedPath, literalPath); !ok {
+		return fmt.Errorf("path %v does not match %v", literalPath, parameterizedPath)
+	}
+
+	approvedPathItem, isApproved := s.ApprovedSpec.PathItems[parameterizedPath]
+	if !isApproved {
+		learningPaths := s.createLearningParametrizedPaths().Paths[parameterizedPath]
+		if !learningPaths[literalPath] {
+			return fmt.Errorf("path %v was not learned as %v", literalPath, parameterizedPath)
+		}
+		s.addSplitPath(literalPath)
+		return nil
+	}
+	if _, ok := s.ApprovedSpec.PathItems[literalPath]; ok {
+		return fmt.Errorf("path %v is already approved", literalPath)
+	}
+
+	// first update the split into a copy of the state, in case the validation will fail
+	clonedSpec, err := s.SpecInfoClone()
+	if err != nil {
+		return fmt.Errorf("failed to clone spec. %v", err)
+	}
+	clonedSpec.OpGenerator = s.OpGenerator
+
+	pathItem, err := clonedSpec.createSplitPathItem(literalPath, approvedPathItem)
+	if err != nil {
+		return err
+	}
+	addPathParamsToPathItem(pathItem, literalPath, map[string]bool{literalPath: true}, s.isLearningCompositePathParams())
+	clonedSpec.ApprovedSpec.PathItems[literalPath] = pathItem
+	clonedSpec.ApprovedPathTrie.Insert(literalPath, uuid.New().String())
+	clonedSpec.ApprovedSpec.SecurityDefinitions = updateSecurityDefinitionsFromPathItem(clonedSpec.ApprovedSpec.SecurityDefinitions, pathItem)
+	delete(clonedSpec.LearningSpec.PathItems, literalPath)
+	clonedSpec.addSplitPath(literalPath)
+
+	oasJSON, err := clonedSpec.generateApprovedOASJson()
+	if err != nil {
+		return fmt.Errorf("failed to generate Open API Spec. %w", err)
+	}
+	s.SpecInfo = clonedSpec.SpecInfo
+	s.oasCache.set(oasJSON)
+
+	return nil
+}
+
+// GetSplitPaths returns the sorted paths split by SplitPath.
+func (s *Spec) GetSplitPaths() []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := make([]string, 0, len(s.SplitPaths))
+	for path := range s.SplitPaths {
+		ret = append(ret, path)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func (s *Spec) addSplitPath(path string) {
+	if s.SplitPaths == nil {
+		s.SplitPaths = make(map[string]bool)
+	}
+	s.SplitPaths[path] = true
+	delete(s.PathTemplates, path)
+}
+
+// createSplitPathItem reconstructs the path item of literalPath from its learned path item and its retained samples.
+// The operations of literalPath that are only found in the stats are copied from parameterizedPathItem.
+func (s *Spec) createSplitPathItem(literalPath string, parameterizedPathItem *oapi_spec.PathItem) (*oapi_spec.PathItem, error) {
+	pathItem := &oapi_spec.PathItem{}
+	if learnedPathItem, ok := s.LearningSpec.PathItems[literalPath]; ok {
+		pathItem = MergePathItems(pathItem, learnedPathItem)
+	}
+
+	for _, sample := range s.RetainedSamples {
+		if sample.Path != literalPath {
+			continue
+		}
+		sampleOp, _, err := s.telemetryToOperation(context.Background(), sample.Telemetry, s.LearningSpec.SecurityDefinitions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert retained sample to operation. %v", err)
+		}
+		mergedOp, _ := mergeOperation(GetOperationFromPathItem(pathItem, sample.Method), sampleOp)
+		AddOperationToPathItem(pathItem, sample.Method, mergedOp)
+	}
+
+	if s.LearningStats != nil {
+		for method := range s.LearningStats.Operations[literalPath] {
+			if GetOperationFromPathItem(pathItem, method) != nil {
+				continue
+			}
+			parameterizedOp := GetOperationFromPathItem(parameterizedPathItem, method)
+			if parameterizedOp == nil {
+				continue
+			}
+			op, err := CloneOperation(parameterizedOp)
+			if err != nil {
+				return nil, fmt.Errorf("failed to clone operation. %v", err)
+			}
+			AddOperationToPathItem(pathItem, method, op)
+		}
+	}
+
+	pathItem = s.removeIgnoredOperations(literalPath, pathItem)
+	if pathItem == nil || isEmptyPathItem(pathItem) {
+		return nil, fmt.Errorf("no operations were learned for path %v", literalPath)
+	}
+
+	return pathItem, nil
+}
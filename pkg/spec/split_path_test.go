@@ -0,0 +1,155 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func createSplitPathTestSpec(t *testing.T, maxRetainedSamples int) *Spec {
+	t.Helper()
+	config := testOperationGeneratorConfig
+	config.MaxRetainedSamples = maxRetainedSamples
+	s := CreateDefaultSpec("host", "80", config)
+	learn := func(path, respBody string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", "", respBody)))
+	}
+	learn("/orders/1", `{"id":1,"total":3.5}`)
+	learn("/orders/latest", `{"id":2,"latest":true}`)
+
+	assert.NilError(t, s.ApplyApprovedReview(&ApprovedSpecReview{
+		PathToPathItem: s.LearningSpec.PathItems,
+		PathItemsReview: []*ApprovedSpecReviewPathItem{
+			{
+				ReviewPathItem: ReviewPathItem{
+					ParameterizedPath: "/orders/{orderId}",
+					Paths:             map[string]bool{"/orders/1": true, "/orders/latest": true},
+				},
+				PathUUID: "1",
+			},
+		},
+	}))
+	return s
+}
+
+func assertEqualOperationJSON(t *testing.T, op, want *oapi_spec.Operation) {
+	t.Helper()
+	opB, err := json.Marshal(op)
+	assert.NilError(t, err)
+	wantB, err := json.Marshal(want)
+	assert.NilError(t, err)
+	assert.Equal(t, string(opB), string(wantB))
+}
+
+func TestSpec_SplitPath_Approved(t *testing.T) {
+	s := createSplitPathTestSpec(t, 10)
+	templateOp := s.ApprovedSpec.GetPathItem("/orders/{orderId}").Get
+
+	assert.NilError(t, s.SplitPath("/orders/{orderId}", "/orders/latest"))
+	assert.DeepEqual(t, s.GetSplitPaths(), []string{"/orders/latest"})
+
+	// the split operation is reconstructed from the retained sample, without the properties of /orders/1
+	splitOp := s.ApprovedSpec.GetPathItem("/orders/latest").Get
+	assert.Assert(t, splitOp != nil)
+	splitSchema := splitOp.Responses.StatusCodeResponses[200].Schema
+	assert.Assert(t, splitSchema.Properties["latest"].Type.Contains(schemaTypeBoolean))
+	_, hasTotal := splitSchema.Properties["total"]
+	assert.Assert(t, !hasTotal)
+	assert.Assert(t, len(s.ApprovedSpec.GetPathItem("/orders/latest").Parameters) == 0)
+
+	// the parameterized path is left as is
+	assertEqualOperationJSON(t, s.ApprovedSpec.GetPathItem("/orders/{orderId}").Get, templateOp)
+
+	path, _, found := s.ApprovedPathTrie.GetPathAndValue("/orders/latest")
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/orders/latest")
+	path, value, found := s.ApprovedPathTrie.GetPathAndValue("/orders/2")
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/orders/{orderId}")
+	assert.Equal(t, value, "1")
+
+	// already approved
+	assert.Assert(t, s.SplitPath("/orders/{orderId}", "/orders/latest") != nil)
+}
+
+func TestSpec_SplitPath_FromStats(t *testing.T) {
+	s := createSplitPathTestSpec(t, 0)
+	templateOp := s.ApprovedSpec.GetPathItem("/orders/{orderId}").Get
+
+	assert.NilError(t, s.SplitPath("/orders/{orderId}", "/orders/latest"))
+	// without retained samples, the operations counted in the stats are copied from the parameterized path
+	assertEqualOperationJSON(t, s.ApprovedSpec.GetPathItem("/orders/latest").Get, templateOp)
+}
+
+func TestSpec_SplitPath_Learning(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for _, path := range []string{"/orders/1", "/orders/2", "/orders/3"} {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, path, "host", "200", "", Data.RespBody)))
+	}
+	assert.Equal(t, len(s.CreateSuggestedReview().PathItemsReview), 1)
+
+	assert.NilError(t, s.SplitPath("/orders/{param1}", "/orders/3"))
+	review := s.CreateSuggestedReview()
+	reviewPaths := make(map[string]map[string]bool)
+	for _, pathReview := range review.PathItemsReview {
+		reviewPaths[pathReview.ParameterizedPath] = pathReview.Paths
+	}
+	assert.DeepEqual(t, reviewPaths, map[string]map[string]bool{
+		"/orders/{param1}": {"/orders/1": true, "/orders/2": true},
+		"/orders/3":        {"/orders/3": true},
+	})
+}
+
+func TestSpec_SplitPath_Errors(t *testing.T) {
+	s := createSplitPathTestSpec(t, 10)
+	tests := []struct {
+		name              string
+		parameterizedPath string
+		literalPath       string
+	}{
+		{
+			name:              "not parameterized",
+			parameterizedPath: "/orders/latest",
+			literalPath:       "/orders/latest",
+		},
+		{
+			name:              "not matching",
+			parameterizedPath: "/orders/{orderId}",
+			literalPath:       "/users/latest",
+		},
+		{
+			name:              "not approved nor learned",
+			parameterizedPath: "/users/{userId}",
+			literalPath:       "/users/latest",
+		},
+		{
+			name:              "no learned operations",
+			parameterizedPath: "/orders/{orderId}",
+			literalPath:       "/orders/first",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Assert(t, s.SplitPath(tt.parameterizedPath, tt.literalPath) != nil)
+		})
+	}
+	assert.Equal(t, len(s.GetSplitPaths()), 0)
+}
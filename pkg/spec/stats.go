@@ -0,0 +1,256 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// minimum telemetries an approved operation needs to be learned from to be considered as high/medium confidence.
+	highConfidenceMinHits   = 100
+	mediumConfidenceMinHits = 10
+)
+
+type OperationStats struct {
+	HitCount  int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// hit count per request source
+	Sources map[SourceLabel]int
+	// hit count per caller metadata key and value
+	Metadata map[string]map[string]int
+	// SchemaTimeline are the latest changes of the operation learned schema, oldest first
+	SchemaTimeline []SchemaChangeEvent
+	// FieldValues are the distinct values of the enum candidate fields by field path, recorded when
+	// OperationGeneratorConfig.EnumMaxValues is set
+	FieldValues map[string]*FieldValueStats
+	// PropertyPresence are the property presence counts of the body objects by field path, recorded when
+	// OperationGeneratorConfig.RequiredPropertyMinRatio is set
+	PropertyPresence map[string]*PropertyPresenceStats
+	// Latency is the latency histogram of the telemetries that had a latency, nil if none had
+	Latency *LatencyStats
+	// PartialLearnings is the amount of telemetries learned without their request or response body,
+	// that could not be parsed
+	PartialLearnings int
+}
+
+type SpecStats struct {
+	TelemetryCount int
+	FirstSeen      time.Time
+	LastSeen       time.Time
+	// map learned path (not parameterized) into method and its stats
+	Operations map[string]map[string]*OperationStats
+	// telemetry count per request source
+	Sources map[SourceLabel]int
+	// PartialLearnings is the amount of telemetries learned without their request or response body
+	PartialLearnings int
+}
+
+func NewSpecStats() *SpecStats {
+	return &SpecStats{
+		Operations: make(map[string]map[string]*OperationStats),
+		Sources:    make(map[SourceLabel]int),
+	}
+}
+
+func (s *SpecStats) addHit(path, method string, source SourceLabel, metadata map[string]string, seen time.Time) {
+	s.TelemetryCount++
+	// stats decoded from an older state may not have sources
+	if s.Sources == nil {
+		s.Sources = make(map[SourceLabel]int)
+	}
+	s.Sources[source]++
+	if s.FirstSeen.IsZero() || seen.Before(s.FirstSeen) {
+		s.FirstSeen = seen
+	}
+	if seen.After(s.LastSeen) {
+		s.LastSeen = seen
+	}
+
+	if _, ok := s.Operations[path]; !ok {
+		s.Operations[path] = make(map[string]*OperationStats)
+	}
+	opStats, ok := s.Operations[path][method]
+	if !ok {
+		opStats = &OperationStats{FirstSeen: seen}
+		s.Operations[path][method] = opStats
+	}
+	opStats.HitCount++
+	if opStats.Sources == nil {
+		opStats.Sources = make(map[SourceLabel]int)
+	}
+	opStats.Sources[source]++
+	opStats.addMetadata(metadata)
+	if seen.Before(opStats.FirstSeen) {
+		opStats.FirstSeen = seen
+	}
+	if seen.After(opStats.LastSeen) {
+		opStats.LastSeen = seen
+	}
+}
+
+func (s *Spec) recordTelemetryStats(path, method string, telemetry *Telemetry, partial bool) {
+	if s.LearningStats == nil {
+		s.LearningStats = NewSpecStats()
+	}
+	s.LearningStats.addHit(path, method, telemetry.getSource(), telemetry.Metadata, telemetry.CaptureTime())
+	if telemetry.Latency > 0 {
+		s.LearningStats.Operations[path][method].addLatency(telemetry.Latency)
+	}
+	if partial {
+		s.LearningStats.PartialLearnings++
+		s.LearningStats.Operations[path][method].PartialLearnings++
+	}
+}
+
+// Stats returns a copy of the learning stats, including the schema timeline of each learned operation.
+func (s *Spec) Stats() *SpecStats {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.LearningStats == nil {
+		return NewSpecStats()
+	}
+
+	return cloneSpecStats(s.LearningStats)
+}
+
+// GetPartialLearnings returns the amount of telemetries learned without their request or response body,
+// see SpecStats.PartialLearnings.
+func (s *Spec) GetPartialLearnings() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.LearningStats == nil {
+		return 0
+	}
+	return s.LearningStats.PartialLearnings
+}
+
+// GetLastLearned returns the capture time of the last learned telemetry and of the last learned schema change
+// (see OperationStats.SchemaTimeline), zero when nothing was learned.
+func (s *Spec) GetLastLearned() (lastTelemetry, lastChange time.Time) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.LearningStats == nil {
+		return time.Time{}, time.Time{}
+	}
+	for _, methods := range s.LearningStats.Operations {
+		for _, opStats := range methods {
+			timeline := opStats.SchemaTimeline
+			if len(timeline) > 0 && timeline[len(timeline)-1].Time.After(lastChange) {
+				lastChange = timeline[len(timeline)-1].Time
+			}
+		}
+	}
+	return s.LearningStats.LastSeen, lastChange
+}
+
+const specStatsExtensionName = "x-speculator"
+
+type SpecStatsExtension struct {
+	LearningWindow LearningWindow `json:"learningWindow"`
+	TelemetryCount int            `json:"telemetryCount"`
+	// CoveragePercentage is the percentage of the learned operations that are documented by the approved spec
+	CoveragePercentage float64           `json:"coveragePercentage"`
+	Confidence         ConfidenceSummary `json:"confidence"`
+}
+
+type LearningWindow struct {
+	FirstSeen time.Time `json:"firstSeen,omitempty"`
+	LastSeen  time.Time `json:"lastSeen,omitempty"`
+}
+
+// ConfidenceSummary counts the approved operations by the amount of telemetries they were learned from.
+type ConfidenceSummary struct {
+	High   int `json:"high"`
+	Medium int `json:"medium"`
+	Low    int `json:"low"`
+}
+
+func (s *Spec) createSpecStatsExtension() *SpecStatsExtension {
+	stats := s.LearningStats
+	if stats == nil {
+		stats = NewSpecStats()
+	}
+
+	ret := &SpecStatsExtension{
+		LearningWindow: LearningWindow{
+			FirstSeen: stats.FirstSeen,
+			LastSeen:  stats.LastSeen,
+		},
+		TelemetryCount: stats.TelemetryCount,
+	}
+
+	// map approved path into method and the amount of telemetries learned for it
+	approvedHits := make(map[string]map[string]int)
+	learnedOperationsCount := 0
+	coveredOperationsCount := 0
+	for path, methods := range stats.Operations {
+		approvedPath, _, found := s.ApprovedPathTrie.GetPathAndValue(path)
+		for method, opStats := range methods {
+			learnedOperationsCount++
+			if !found || !s.hasApprovedOperation(approvedPath, method) {
+				continue
+			}
+			coveredOperationsCount++
+			if _, ok := approvedHits[approvedPath]; !ok {
+				approvedHits[approvedPath] = make(map[string]int)
+			}
+			approvedHits[approvedPath][method] += opStats.HitCount
+		}
+	}
+
+	if learnedOperationsCount > 0 {
+		const percent = 100
+		ret.CoveragePercentage = roundTwoDecimals(float64(coveredOperationsCount) / float64(learnedOperationsCount) * percent)
+	}
+
+	for path, pathItem := range s.ApprovedSpec.PathItems {
+		for _, method := range supportedMethods {
+			if GetOperationFromPathItem(pathItem, method) == nil {
+				continue
+			}
+			switch hits := approvedHits[path][method]; {
+			case hits >= highConfidenceMinHits:
+				ret.Confidence.High++
+			case hits >= mediumConfidenceMinHits:
+				ret.Confidence.Medium++
+			default:
+				ret.Confidence.Low++
+			}
+		}
+	}
+
+	return ret
+}
+
+func (s *Spec) hasApprovedOperation(path, method string) bool {
+	pathItem := s.ApprovedSpec.GetPathItem(path)
+	if pathItem == nil {
+		return false
+	}
+
+	return GetOperationFromPathItem(pathItem, method) != nil
+}
+
+func roundTwoDecimals(f float64) float64 {
+	const hundred = 100
+	return math.Round(f*hundred) / hundred
+}
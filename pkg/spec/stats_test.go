@@ -0,0 +1,145 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+func TestSpecStats_addHit(t *testing.T) {
+	first := time.Unix(1000, 0)
+	second := time.Unix(2000, 0)
+
+	stats := NewSpecStats()
+	stats.addHit("/api/1", http.MethodGet, SourceExternal, map[string]string{MetadataKeyCountry: "US"}, second)
+	stats.addHit("/api/1", http.MethodGet, SourceInternal, map[string]string{MetadataKeyCountry: "US"}, first)
+	stats.addHit("/api/2", http.MethodPost, SourceInternal, nil, second)
+
+	assert.Equal(t, stats.TelemetryCount, 3)
+	assert.Equal(t, stats.FirstSeen, first)
+	assert.Equal(t, stats.LastSeen, second)
+	assert.DeepEqual(t, stats.Sources, map[SourceLabel]int{SourceExternal: 1, SourceInternal: 2})
+	assert.DeepEqual(t, stats.Operations["/api/1"][http.MethodGet], &OperationStats{
+		HitCount:  2,
+		FirstSeen: first,
+		LastSeen:  second,
+		Sources:   map[SourceLabel]int{SourceExternal: 1, SourceInternal: 1},
+		Metadata:  map[string]map[string]int{MetadataKeyCountry: {"US": 2}},
+	})
+	assert.DeepEqual(t, stats.Operations["/api/2"][http.MethodPost], &OperationStats{
+		HitCount:  1,
+		FirstSeen: second,
+		LastSeen:  second,
+		Sources:   map[SourceLabel]int{SourceInternal: 1},
+	})
+}
+
+func TestSpec_LearnTelemetry_UsesCaptureTime(t *testing.T) {
+	captured := time.Unix(1000, 0).UTC()
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+
+	telemetry := createTelemetry("req-id", http.MethodGet, "/api/1", "host", "200", Data.ReqBody, Data.RespBody)
+	telemetry.Timestamp = captured
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+
+	assert.Equal(t, s.LearningStats.FirstSeen, captured)
+	assert.Equal(t, s.LearningStats.LastSeen, captured)
+	assert.Equal(t, s.LearningStats.Operations["/api/1"][http.MethodGet].FirstSeen, captured)
+}
+
+func TestSpec_GenerateOASJson_WithStatsExtension(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for i := 0; i < mediumConfidenceMinHits; i++ {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodGet, "/api/1", "host", "200", Data.ReqBody, Data.RespBody)))
+	}
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", http.MethodPost, "/api/1", "host", "200", Data.ReqBody, Data.RespBody)))
+
+	s.ApprovedSpec.PathItems["/api/{param1}"] = &NewTestPathItem().
+		WithPathParams("param1", schemaTypeInteger, "").
+		WithOperation(http.MethodGet, NewOperation(t, Data).Op).PathItem
+	s.ApprovedPathTrie.Insert("/api/{param1}", "1")
+
+	oasJSON, err := s.GenerateOASJson(WithStatsExtension())
+	assert.NilError(t, err)
+
+	generated := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, generated))
+	extB, err := json.Marshal(generated.Info.Extensions[specStatsExtensionName])
+	assert.NilError(t, err)
+	ext := &SpecStatsExtension{}
+	assert.NilError(t, json.Unmarshal(extB, ext))
+
+	assert.Equal(t, ext.TelemetryCount, mediumConfidenceMinHits+1)
+	assert.Equal(t, ext.CoveragePercentage, float64(50))
+	assert.DeepEqual(t, ext.Confidence, ConfidenceSummary{Medium: 1})
+	assert.Assert(t, !ext.LearningWindow.FirstSeen.IsZero())
+
+	// extension is added only when requested
+	oasJSON, err = s.GenerateOASJson()
+	assert.NilError(t, err)
+	generated = &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, generated))
+	_, ok := generated.Info.Extensions[specStatsExtensionName]
+	assert.Assert(t, !ok)
+}
+
+func TestSpec_LearnTelemetry_PartialLearning(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+
+	// the response is learned when the request body can not be parsed, and vice versa
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "POST", "/api/users", "host", "200", `{"name": `, `{"id": 1}`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "POST", "/api/users", "host", "200", `{"name": "a"}`, `{"id"`)))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", "POST", "/api/users", "host", "200", `{"name": "a"}`, `{"id": 1}`)))
+	assert.ErrorContains(t, s.LearnTelemetry(createTelemetry("req-id", "POST", "/api/users", "host", "200", `{`, `{`)), "failed to parse request and response bodies")
+
+	assert.Equal(t, s.GetPartialLearnings(), 2)
+	opStats := s.LearningStats.Operations["/api/users"]["POST"]
+	assert.Equal(t, opStats.HitCount, 3)
+	assert.Equal(t, opStats.PartialLearnings, 2)
+
+	op := s.LearningSpec.GetPathItem("/api/users").Post
+	assert.Equal(t, len(op.Parameters), 1)
+	assert.Assert(t, op.Parameters[0].Schema.Properties["name"].Type.Contains("string"))
+	assert.Assert(t, op.Responses.StatusCodeResponses[200].Schema.Properties["id"].Type.Contains("integer"))
+}
+
+func TestSpec_GetLastLearned(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	lastTelemetry, lastChange := s.GetLastLearned()
+	assert.Assert(t, lastTelemetry.IsZero())
+	assert.Assert(t, lastChange.IsZero())
+
+	learn := func(path, respBody string, timestamp time.Time) {
+		telemetry := createTelemetry("req-id", http.MethodGet, path, "host", "200", "", respBody)
+		telemetry.Timestamp = timestamp
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	start := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	learn("/api/1", `{"id":1}`, start)
+	learn("/api/2", `{"id":1}`, start.Add(time.Minute))
+	// doesn't change the schema
+	learn("/api/1", `{"id":2}`, start.Add(2*time.Minute))
+
+	lastTelemetry, lastChange = s.GetLastLearned()
+	assert.Assert(t, lastTelemetry.Equal(start.Add(2*time.Minute)))
+	assert.Assert(t, lastChange.Equal(start.Add(time.Minute)))
+}
@@ -0,0 +1,155 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"sort"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// SuggestedReview is the suggested review (see CreateSuggestedReview) together with the evidence of each suggested
+// parameterized path, for presenting merge and approve screens.
+type SuggestedReview struct {
+	// Paths are sorted by parameterized path
+	Paths []*SuggestedReviewPath `json:"paths"`
+	// PathToPathItem are the reviewable learned path items by the observed paths, as in SuggestedSpecReview
+	PathToPathItem map[string]*oapi_spec.PathItem `json:"pathToPathItem"`
+}
+
+type SuggestedReviewPath struct {
+	// ParameterizedPath is the suggested path grouping ObservedPaths
+	ParameterizedPath string `json:"parameterizedPath"`
+	// ObservedPaths are the learned paths grouped into ParameterizedPath, sorted by path
+	ObservedPaths []*ObservedPath `json:"observedPaths"`
+	// HitCount is the amount of telemetries learned for all ObservedPaths
+	HitCount int `json:"hitCount"`
+	// Params are the path params of ParameterizedPath, as they will be approved
+	Params []*SuggestedPathParam `json:"params,omitempty"`
+}
+
+type ObservedPath struct {
+	Path     string   `json:"path"`
+	Methods  []string `json:"methods"`
+	HitCount int      `json:"hitCount"`
+}
+
+type SuggestedPathParam struct {
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	Format  string        `json:"format,omitempty"`
+	Pattern string        `json:"pattern,omitempty"`
+	Enum    []interface{} `json:"enum,omitempty"`
+	// Values are the distinct sorted values of the param in the observed paths
+	Values []string `json:"values"`
+}
+
+// GetSuggestedReview returns the suggested parameterized paths of the reviewable learned paths, with their observed
+// paths, hit counts and inferred path param types as evidence.
+func (s *Spec) GetSuggestedReview() *SuggestedReview {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ret := &SuggestedReview{
+		Paths:          []*SuggestedReviewPath{},
+		PathToPathItem: s.getReviewablePathItems(),
+	}
+
+	learningParametrizedPaths := s.createLearningParametrizedPaths()
+	parameterizedPaths := make([]string, 0, len(learningParametrizedPaths.Paths))
+	for parameterizedPath := range learningParametrizedPaths.Paths {
+		parameterizedPaths = append(parameterizedPaths, parameterizedPath)
+	}
+	sort.Strings(parameterizedPaths)
+
+	for _, parameterizedPath := range parameterizedPaths {
+		paths := make(map[string]bool)
+		for path := range learningParametrizedPaths.Paths[parameterizedPath] {
+			if _, ok := ret.PathToPathItem[path]; ok {
+				paths[path] = true
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		ret.Paths = append(ret.Paths, s.createSuggestedReviewPath(parameterizedPath, paths, ret.PathToPathItem))
+	}
+
+	return ret
+}
+
+func (s *Spec) createSuggestedReviewPath(parameterizedPath string, paths map[string]bool, pathItems map[string]*oapi_spec.PathItem) *SuggestedReviewPath {
+	reviewPath := &SuggestedReviewPath{
+		ParameterizedPath: parameterizedPath,
+	}
+
+	sortedPaths := make([]string, 0, len(paths))
+	for path := range paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+	for _, path := range sortedPaths {
+		observedPath := &ObservedPath{Path: path, Methods: []string{}}
+		for _, method := range supportedMethods {
+			if GetOperationFromPathItem(pathItems[path], method) == nil {
+				continue
+			}
+			observedPath.Methods = append(observedPath.Methods, method)
+			observedPath.HitCount += s.getOperationHitCount(path, method)
+		}
+		reviewPath.HitCount += observedPath.HitCount
+		reviewPath.ObservedPaths = append(reviewPath.ObservedPaths, observedPath)
+	}
+
+	// the params are typed the same way as when the paths are approved
+	pathItem := &oapi_spec.PathItem{}
+	addPathParamsToPathItem(pathItem, parameterizedPath, paths, s.isLearningCompositePathParams())
+	params := make(map[string]oapi_spec.Parameter, len(pathItem.Parameters))
+	for _, param := range pathItem.Parameters {
+		params[param.Name] = param
+	}
+
+	parts := strings.Split(strings.TrimPrefix(parameterizedPath, "/"), "/")
+	for i, part := range parts {
+		if !utils.IsPathParam(part) {
+			continue
+		}
+		name := utils.GetPathParamName(part)
+		param := params[name]
+		reviewPath.Params = append(reviewPath.Params, &SuggestedPathParam{
+			Name:    name,
+			Type:    param.Type,
+			Format:  param.Format,
+			Pattern: param.Pattern,
+			Enum:    param.Enum,
+			Values:  getSortedDistinctStrings(getOnlyIndexedPartFromPaths(paths, i)),
+		})
+	}
+
+	return reviewPath
+}
+
+func getSortedDistinctStrings(values []string) []string {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return getSortedStrings(set)
+}
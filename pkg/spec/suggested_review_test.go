@@ -0,0 +1,59 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSpec_GetSuggestedReview(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	learn := func(method, path string) {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id", method, path, "host", "200", "", `{"id": 1}`)))
+	}
+	learn(http.MethodGet, "/users/1/orders")
+	learn(http.MethodGet, "/users/1/orders")
+	learn(http.MethodDelete, "/users/1/orders")
+	learn(http.MethodGet, "/users/20/orders")
+	learn(http.MethodGet, "/health")
+	learn(http.MethodPost, "/ignored")
+	assert.NilError(t, s.IgnoreOperation("/ignored", http.MethodPost))
+
+	review := s.GetSuggestedReview()
+
+	assert.Equal(t, len(review.PathToPathItem), 3)
+	assert.DeepEqual(t, review.Paths, []*SuggestedReviewPath{
+		{
+			ParameterizedPath: "/health",
+			ObservedPaths:     []*ObservedPath{{Path: "/health", Methods: []string{http.MethodGet}, HitCount: 1}},
+			HitCount:          1,
+		},
+		{
+			ParameterizedPath: "/users/{param1}/orders",
+			ObservedPaths: []*ObservedPath{
+				{Path: "/users/1/orders", Methods: []string{http.MethodGet, http.MethodDelete}, HitCount: 3},
+				{Path: "/users/20/orders", Methods: []string{http.MethodGet}, HitCount: 1},
+			},
+			HitCount: 4,
+			Params: []*SuggestedPathParam{
+				{Name: "param1", Type: schemaTypeInteger, Values: []string{"1", "20"}},
+			},
+		},
+	})
+}
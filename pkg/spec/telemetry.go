@@ -0,0 +1,271 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// TelemetrySchemaVersionLegacy is the snake_case format (scnt_request/scnt_response) of older taps.
+	// It has no schemaVersion field.
+	TelemetrySchemaVersionLegacy = "0"
+	// TelemetrySchemaVersion1 is the Telemetry struct format.
+	// Telemetries without a schemaVersion that are not in the legacy format are considered version 1.
+	TelemetrySchemaVersion1 = "1"
+
+	CurrentTelemetrySchemaVersion = TelemetrySchemaVersion1
+)
+
+// DefaultMaxClockSkew is the default amount of time a telemetry timestamp may be ahead of the local clock.
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// DecodeTelemetry decodes a JSON telemetry of any known schema version into the current Telemetry.
+// A telemetry with a newer (unknown) version is decoded as the current version, ignoring unknown fields,
+// so out-of-process taps can be upgraded before the library.
+func DecodeTelemetry(data []byte) (*Telemetry, error) {
+	var probe struct {
+		SchemaVersion string          `json:"schemaVersion"`
+		LegacyRequest json.RawMessage `json:"scnt_request"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal telemetry: %v", err)
+	}
+
+	version := probe.SchemaVersion
+	if version == "" {
+		version = TelemetrySchemaVersion1
+		if len(probe.LegacyRequest) > 0 {
+			version = TelemetrySchemaVersionLegacy
+		}
+	}
+
+	var telemetry *Telemetry
+	switch version {
+	case TelemetrySchemaVersionLegacy:
+		legacy := &legacyTelemetry{}
+		if err := json.Unmarshal(data, legacy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal legacy telemetry: %v", err)
+		}
+		telemetry = legacy.toTelemetry()
+	case TelemetrySchemaVersion1:
+		telemetry = &Telemetry{}
+		if err := json.Unmarshal(data, telemetry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal telemetry: %v", err)
+		}
+	default:
+		log.Warnf("Unknown telemetry schema version %q, decoding as version %v", version, CurrentTelemetrySchemaVersion)
+		telemetry = &Telemetry{}
+		if err := json.Unmarshal(data, telemetry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal telemetry: %v", err)
+		}
+	}
+
+	if err := telemetry.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid telemetry: %w", err)
+	}
+	telemetry.SchemaVersion = CurrentTelemetrySchemaVersion
+
+	return telemetry, nil
+}
+
+// Validate checks that the telemetry has a request and a response, setting their missing common parts.
+func (t *Telemetry) Validate() error {
+	if t == nil {
+		return fmt.Errorf("missing telemetry")
+	}
+	if t.Request == nil {
+		return fmt.Errorf("missing request")
+	}
+	if t.Response == nil {
+		return fmt.Errorf("missing response")
+	}
+	if t.Latency < 0 {
+		return fmt.Errorf("negative latency")
+	}
+	if t.Request.Common == nil {
+		t.Request.Common = &Common{}
+	}
+	if t.Response.Common == nil {
+		t.Response.Common = &Common{}
+	}
+	return nil
+}
+
+// CaptureTime returns the time the interaction was captured at, or the current time if the telemetry has no timestamp.
+func (t *Telemetry) CaptureTime() time.Time {
+	if t.Timestamp.IsZero() {
+		return time.Now().UTC()
+	}
+	return t.Timestamp.UTC()
+}
+
+// NormalizeTimestamp fixes the telemetry timestamp against the local clock (now):
+// a missing timestamp is set to now, and a timestamp more than maxSkew ahead of now is clamped to now,
+// since the capturing clock is ahead of ours. Past timestamps are kept as is, as ingestion may be delayed.
+func (t *Telemetry) NormalizeTimestamp(now time.Time, maxSkew time.Duration) {
+	if t.Timestamp.IsZero() {
+		t.Timestamp = now.UTC()
+		return
+	}
+	if t.Timestamp.After(now.Add(maxSkew)) {
+		log.Debugf("Telemetry timestamp %v is ahead of local clock %v by more than %v, using local clock", t.Timestamp, now, maxSkew)
+		t.Timestamp = now.UTC()
+	}
+}
+
+// isBodyTruncated returns true if the captured body is partial: either the tap flagged it as truncated,
+// or it is shorter than the Content-Length header, since some taps truncate without setting the flag.
+// The Content-Length header is ignored when the body has a Transfer-Encoding (RFC 7230 section 3.3.3).
+func (c *Common) isBodyTruncated() bool {
+	if c.TruncatedBody {
+		return true
+	}
+	headers := ConvertHeadersToMap(c.Headers)
+	if _, ok := headers[transferEncodingHeaderName]; ok {
+		return false
+	}
+	contentLength, ok := headers[contentLengthHeaderName]
+	if !ok {
+		return false
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(contentLength))
+	if err != nil {
+		log.Debugf("Ignoring invalid content-length header: %v", contentLength)
+		return false
+	}
+
+	return len(c.Body) < length
+}
+
+// getLearningBody returns the body to learn the schema from, decoded by its Transfer-Encoding and Content-Encoding headers.
+// A truncated body can't be parsed into a schema, so it is not learned, while the rest of the interaction is.
+// The same goes for a body that can't be decoded, e.g. a chunked body missing chunks.
+func (c *Common) getLearningBody() []byte {
+	if c.isBodyTruncated() {
+		if len(c.Body) > 0 {
+			log.Debugf("Ignoring truncated body. captured length=%v", len(c.Body))
+		}
+		return nil
+	}
+	if len(c.Body) == 0 {
+		return c.Body
+	}
+	headers := ConvertHeadersToMap(c.Headers)
+	body := c.Body
+	if transferEncoding := headers[transferEncodingHeaderName]; transferEncoding != "" {
+		var err error
+		if body, err = decodeTransferEncoding(body, transferEncoding); err != nil {
+			log.Debugf("Ignoring body that can't be decoded. Transfer-Encoding=%v: %v", transferEncoding, err)
+			return nil
+		}
+	}
+	contentEncoding := headers[contentEncodingHeaderName]
+	if contentEncoding == "" {
+		return body
+	}
+	body, err := decodeBody(body, contentEncoding)
+	if err != nil {
+		log.Debugf("Ignoring body that can't be decoded. Content-Encoding=%v: %v", contentEncoding, err)
+		return nil
+	}
+	return body
+}
+
+type legacyTelemetry struct {
+	DestinationAddress   string          `json:"destination_address,omitempty"`
+	DestinationNamespace string          `json:"destination_namespace,omitempty"`
+	Request              *legacyRequest  `json:"scnt_request,omitempty"`
+	RequestID            string          `json:"request_id,omitempty"`
+	Response             *legacyResponse `json:"scnt_response,omitempty"`
+	Scheme               string          `json:"scheme,omitempty"`
+	SourceAddress        string          `json:"source_address,omitempty"`
+}
+
+type legacyCommon struct {
+	Body          []byte `json:"body,omitempty"`
+	TruncatedBody bool   `json:"truncated_body,omitempty"`
+	// list of [key, value] pairs
+	Headers [][]string `json:"headers,omitempty"`
+	Version string     `json:"version,omitempty"`
+}
+
+type legacyRequest struct {
+	legacyCommon
+	Host   string `json:"host,omitempty"`
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+type legacyResponse struct {
+	legacyCommon
+	StatusCode string `json:"status_code,omitempty"`
+}
+
+func (l *legacyTelemetry) toTelemetry() *Telemetry {
+	telemetry := &Telemetry{
+		DestinationAddress:   l.DestinationAddress,
+		DestinationNamespace: l.DestinationNamespace,
+		RequestID:            l.RequestID,
+		Scheme:               l.Scheme,
+		SourceAddress:        l.SourceAddress,
+	}
+	if l.Request != nil {
+		telemetry.Request = &Request{
+			Common: l.Request.legacyCommon.toCommon(),
+			Host:   l.Request.Host,
+			Method: l.Request.Method,
+			Path:   l.Request.Path,
+		}
+	}
+	if l.Response != nil {
+		telemetry.Response = &Response{
+			Common:     l.Response.legacyCommon.toCommon(),
+			StatusCode: l.Response.StatusCode,
+		}
+	}
+
+	return telemetry
+}
+
+func (l *legacyCommon) toCommon() *Common {
+	const keyValueLen = 2
+
+	common := &Common{
+		TruncatedBody: l.TruncatedBody,
+		Body:          l.Body,
+		Headers:       []*Header{},
+		Version:       l.Version,
+	}
+	for _, header := range l.Headers {
+		if len(header) != keyValueLen {
+			log.Warnf("Ignoring invalid legacy header: %v", header)
+			continue
+		}
+		common.Headers = append(common.Headers, &Header{
+			Key:   header[0],
+			Value: header[1],
+		})
+	}
+
+	return common
+}
@@ -0,0 +1,242 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDecodeTelemetry(t *testing.T) {
+	wantTelemetry := &Telemetry{
+		SchemaVersion:      CurrentTelemetrySchemaVersion,
+		DestinationAddress: "10.0.0.1:80",
+		RequestID:          "req-id",
+		Scheme:             "http",
+		Request: &Request{
+			Common: &Common{
+				Body:    []byte("{\"a\":1}"),
+				Headers: []*Header{{Key: "content-type", Value: "application/json"}},
+				Version: "1.1",
+			},
+			Host:   "host",
+			Method: "POST",
+			Path:   "/api",
+		},
+		Response: &Response{
+			Common: &Common{
+				TruncatedBody: true,
+				Headers:       []*Header{},
+			},
+			StatusCode: "200",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    *Telemetry
+		wantErr bool
+	}{
+		{
+			name: "legacy",
+			data: `{"request_id":"req-id","scheme":"http","destination_address":"10.0.0.1:80",
+"scnt_request":{"method":"POST","path":"/api","host":"host","version":"1.1","headers":[["content-type","application/json"],["invalid"]],"body":"eyJhIjoxfQ=="},
+"scnt_response":{"status_code":"200","headers":null,"truncated_body":true}}`,
+			want: wantTelemetry,
+		},
+		{
+			name: "version 1 without schemaVersion",
+			data: `{"requestID":"req-id","scheme":"http","destinationAddress":"10.0.0.1:80",
+"request":{"method":"POST","path":"/api","host":"host","common":{"version":"1.1","headers":[{"key":"content-type","value":"application/json"}],"body":"eyJhIjoxfQ=="}},
+"response":{"statusCode":"200","common":{"headers":[],"TruncatedBody":true}}}`,
+			want: wantTelemetry,
+		},
+		{
+			name: "unknown version is decoded as current",
+			data: `{"schemaVersion":"99","newField":"value","requestID":"req-id","scheme":"http","destinationAddress":"10.0.0.1:80",
+"request":{"method":"POST","path":"/api","host":"host","common":{"version":"1.1","headers":[{"key":"content-type","value":"application/json"}],"body":"eyJhIjoxfQ=="}},
+"response":{"statusCode":"200","common":{"headers":[],"TruncatedBody":true}}}`,
+			want: wantTelemetry,
+		},
+		{
+			name:    "missing response",
+			data:    `{"schemaVersion":"1","request":{"method":"GET","path":"/api","host":"host"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			data:    `{"schemaVersion":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeTelemetry([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeTelemetry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeTelemetry() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTelemetry_LegacySamples(t *testing.T) {
+	files := []string{"httpbin0.json", "httpbin1.json", "test0.json", "test1.json"}
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			data, err := ioutil.ReadFile("../../test/" + file)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+			telemetry, err := DecodeTelemetry(data)
+			if err != nil {
+				t.Fatalf("DecodeTelemetry() error = %v", err)
+			}
+			if telemetry.Request.Method == "" || telemetry.Request.Path == "" || telemetry.Response.StatusCode == "" {
+				t.Errorf("DecodeTelemetry() missing fields: %+v", telemetry)
+			}
+			if len(telemetry.Response.Common.Body) == 0 {
+				t.Errorf("DecodeTelemetry() missing response body")
+			}
+		})
+	}
+}
+
+func TestTelemetry_NormalizeTimestamp(t *testing.T) {
+	now := time.Unix(10000, 0).UTC()
+	maxSkew := time.Minute
+
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		want      time.Time
+	}{
+		{
+			name:      "missing timestamp",
+			timestamp: time.Time{},
+			want:      now,
+		},
+		{
+			name:      "delayed ingestion keeps the capture time",
+			timestamp: now.Add(-time.Hour),
+			want:      now.Add(-time.Hour),
+		},
+		{
+			name:      "ahead within skew",
+			timestamp: now.Add(maxSkew),
+			want:      now.Add(maxSkew),
+		},
+		{
+			name:      "ahead more than skew",
+			timestamp: now.Add(maxSkew + time.Second),
+			want:      now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telemetry := &Telemetry{Timestamp: tt.timestamp}
+			telemetry.NormalizeTimestamp(now, maxSkew)
+			if !telemetry.Timestamp.Equal(tt.want) {
+				t.Errorf("NormalizeTimestamp() got = %v, want %v", telemetry.Timestamp, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommon_getLearningBody(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	contentLength := func(value string) []*Header {
+		return []*Header{{Key: "Content-Length", Value: value}}
+	}
+	tests := []struct {
+		name   string
+		common *Common
+		want   []byte
+	}{
+		{
+			name:   "no content-length",
+			common: &Common{Body: body},
+			want:   body,
+		},
+		{
+			name:   "flagged as truncated",
+			common: &Common{Body: body, TruncatedBody: true},
+			want:   nil,
+		},
+		{
+			name:   "content-length matches",
+			common: &Common{Body: body, Headers: contentLength("7")},
+			want:   body,
+		},
+		{
+			name:   "silently truncated",
+			common: &Common{Body: body, Headers: contentLength("1024")},
+			want:   nil,
+		},
+		{
+			name:   "invalid content-length",
+			common: &Common{Body: body, Headers: contentLength("a lot")},
+			want:   body,
+		},
+		{
+			name: "chunked body",
+			common: &Common{Body: []byte("3\r\n{\"a\r\n4\r\n\":1}\r\n0\r\n\r\n"), Headers: []*Header{
+				{Key: "Transfer-Encoding", Value: "chunked"},
+				// the content-length is ignored with a transfer-encoding
+				{Key: "Content-Length", Value: "1024"},
+			}},
+			want: body,
+		},
+		{
+			name:   "missing chunks",
+			common: &Common{Body: []byte("3\r\n{\"a\r\n4\r\n\":"), Headers: []*Header{{Key: "Transfer-Encoding", Value: "chunked"}}},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.common.getLearningBody(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getLearningBody() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_LearnTelemetry_TruncatedBody(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	telemetry := createTelemetry("req-id", "POST", "/api", "host", "200", Data.ReqBody, Data.RespBody)
+	// the tap cut the response body without flagging it
+	telemetry.Response.Common.Body = telemetry.Response.Common.Body[:len(telemetry.Response.Common.Body)/2]
+	telemetry.Response.Common.Headers = append(telemetry.Response.Common.Headers, &Header{Key: "content-length", Value: strconv.Itoa(len(Data.RespBody))})
+
+	if err := s.LearnTelemetry(telemetry); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+	op := s.LearningSpec.GetPathItem("/api").Post
+	if op == nil || op.Responses.StatusCodeResponses[200].Schema != nil {
+		t.Errorf("LearnTelemetry() expected operation without response schema, got %+v", op)
+	}
+	if len(op.Parameters) == 0 {
+		t.Errorf("LearnTelemetry() expected request body to be learned")
+	}
+}
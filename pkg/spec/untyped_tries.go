@@ -0,0 +1,87 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"fmt"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+// UntypedTriesSpec is a Spec as it was gob encoded before its path tries were typed, its fields match the encoded
+// fields of Spec. It is used to decode the states and specs encoded with the untyped tries, see ToSpec.
+type UntypedTriesSpec struct {
+	SpecInfo    UntypedTriesSpecInfo
+	OpGenerator *OperationGenerator
+}
+
+// UntypedTriesSpecInfo is SpecInfo with the untyped path tries it was encoded with, the other fields are the same.
+type UntypedTriesSpecInfo struct {
+	Host              string
+	Port              string
+	BasePath          string
+	ID                uuid.UUID
+	ProvidedSpec      *ProvidedSpec
+	ApprovedSpec      *ApprovedSpec
+	LearningSpec      *LearningSpec
+	ApprovedPathTrie  pathtrie.PathTrie
+	ProvidedPathTrie  pathtrie.PathTrie
+	LearningStats     *SpecStats
+	IgnoredOperations map[string]map[string]bool
+	FrozenOperations  map[string]map[string]bool
+	RejectedPaths     map[string]bool
+	SchemaOutliers    []*SchemaOutlier
+	RetainedSamples   []*RetainedSample
+	SplitPaths        map[string]bool
+	PathTemplates     map[string]string
+}
+
+// ToSpec returns the spec with its path tries typed, an error is returned if one of their path IDs is not a string.
+func (u *UntypedTriesSpec) ToSpec() (*Spec, error) {
+	approvedPathTrie, err := pathtrie.ToTyped[string](&u.SpecInfo.ApprovedPathTrie)
+	if err != nil {
+		return nil, fmt.Errorf("invalid approved path trie: %v", err)
+	}
+	providedPathTrie, err := pathtrie.ToTyped[string](&u.SpecInfo.ProvidedPathTrie)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provided path trie: %v", err)
+	}
+	info := u.SpecInfo
+
+	return &Spec{
+		SpecInfo: SpecInfo{
+			Host:              info.Host,
+			Port:              info.Port,
+			BasePath:          info.BasePath,
+			ID:                info.ID,
+			ProvidedSpec:      info.ProvidedSpec,
+			ApprovedSpec:      info.ApprovedSpec,
+			LearningSpec:      info.LearningSpec,
+			ApprovedPathTrie:  approvedPathTrie,
+			ProvidedPathTrie:  providedPathTrie,
+			LearningStats:     info.LearningStats,
+			IgnoredOperations: info.IgnoredOperations,
+			FrozenOperations:  info.FrozenOperations,
+			RejectedPaths:     info.RejectedPaths,
+			SchemaOutliers:    info.SchemaOutliers,
+			RetainedSamples:   info.RetainedSamples,
+			SplitPaths:        info.SplitPaths,
+			PathTemplates:     info.PathTemplates,
+		},
+		OpGenerator: u.OpGenerator,
+	}, nil
+}
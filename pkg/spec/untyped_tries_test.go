@@ -0,0 +1,78 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+)
+
+func TestUntypedTriesSpecInfo_Fields(t *testing.T) {
+	// the untyped spec info must keep decoding every field of the encoded spec info
+	fieldNames := func(v interface{}) []string {
+		var names []string
+		valueType := reflect.TypeOf(v)
+		for i := 0; i < valueType.NumField(); i++ {
+			names = append(names, valueType.Field(i).Name)
+		}
+		return names
+	}
+	assert.DeepEqual(t, fieldNames(UntypedTriesSpecInfo{}), fieldNames(SpecInfo{}))
+}
+
+func TestUntypedTriesSpec_ToSpec(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", OperationGeneratorConfig{})
+	approvedPathTrie := pathtrie.New()
+	approvedPathTrie.Insert("/api/{param1}", "approved-id")
+	untyped := &UntypedTriesSpec{
+		SpecInfo: UntypedTriesSpecInfo{
+			Host:             s.Host,
+			Port:             s.Port,
+			ID:               s.ID,
+			LearningSpec:     s.LearningSpec,
+			ApprovedSpec:     s.ApprovedSpec,
+			ApprovedPathTrie: approvedPathTrie,
+			ProvidedPathTrie: pathtrie.New(),
+			RejectedPaths:    map[string]bool{"/healthz": true},
+		},
+		OpGenerator: s.OpGenerator,
+	}
+	buf := &bytes.Buffer{}
+	assert.NilError(t, gob.NewEncoder(buf).Encode(untyped))
+	decoded := &UntypedTriesSpec{}
+	assert.NilError(t, gob.NewDecoder(buf).Decode(decoded))
+
+	got, err := decoded.ToSpec()
+	assert.NilError(t, err)
+	assert.Equal(t, got.Host, "host")
+	assert.Equal(t, got.ID, s.ID)
+	assert.DeepEqual(t, got.RejectedPaths, map[string]bool{"/healthz": true})
+	path, pathID, found := got.ApprovedPathTrie.GetPathAndValue("/api/1")
+	assert.Assert(t, found)
+	assert.Equal(t, path, "/api/{param1}")
+	assert.Equal(t, pathID, "approved-id")
+	assert.Equal(t, len(got.ProvidedPathTrie.Paths()), 0)
+
+	untyped.SpecInfo.ProvidedPathTrie.Insert("/api", 1)
+	_, err = untyped.ToSpec()
+	assert.ErrorContains(t, err, "invalid provided path trie")
+}
@@ -16,6 +16,7 @@
 package spec
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -24,34 +25,39 @@ import (
 )
 
 // Note: securityDefinitions might be updated.
-func (s *Spec) telemetryToOperation(telemetry *Telemetry, securityDefinitions oapi_spec.SecurityDefinitions) (*oapi_spec.Operation, error) {
+// telemetryToOperation returns the operation of telemetry, partial is true if it was learned without the body of
+// its request or response, see OperationGenerator.generateSpecOperation.
+func (s *Spec) telemetryToOperation(ctx context.Context, telemetry *Telemetry, securityDefinitions oapi_spec.SecurityDefinitions) (op *oapi_spec.Operation, partial bool, err error) {
 	statusCode, err := strconv.Atoi(telemetry.Response.StatusCode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert status code: %v. %v", statusCode, err)
+		return nil, false, fmt.Errorf("failed to convert status code: %v. %v", statusCode, err)
 	}
 
 	queryParams, err := extractQueryParams(telemetry.Request.Path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert query params: %v", err)
+		return nil, false, fmt.Errorf("failed to convert query params: %v", err)
 	}
 
 	if s.OpGenerator == nil {
-		return nil, fmt.Errorf("operation generator was not set")
+		return nil, false, fmt.Errorf("operation generator was not set")
 	}
 
+	path, _ := GetPathAndQuery(telemetry.Request.Path)
+
 	// Generate operation from telemetry
-	telemetryOp, err := s.OpGenerator.GenerateSpecOperation(&HTTPInteractionData{
-		ReqBody:     string(telemetry.Request.Common.Body),
-		RespBody:    string(telemetry.Response.Common.Body),
+	telemetryOp, partial, err := s.OpGenerator.generateSpecOperation(ctx, &HTTPInteractionData{
+		ReqBody:     string(telemetry.Request.Common.getLearningBody()),
+		RespBody:    string(telemetry.Response.Common.getLearningBody()),
 		ReqHeaders:  ConvertHeadersToMap(telemetry.Request.Common.Headers),
 		RespHeaders: ConvertHeadersToMap(telemetry.Response.Common.Headers),
 		QueryParams: queryParams,
 		statusCode:  statusCode,
+		path:        path,
 	}, securityDefinitions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate spec operation. %v", err)
+		return nil, false, fmt.Errorf("failed to generate spec operation. %w", err)
 	}
-	return telemetryOp, nil
+	return telemetryOp, partial, nil
 }
 
 // example: for "/example-path?param=value" returns "/example-path", "param=value"
@@ -0,0 +1,169 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+)
+
+const (
+	// schemaVariantsExtensionName holds the variants of a body schema in swagger 2.0, that does not support anyOf
+	schemaVariantsExtensionName = "x-variants"
+	// the amount of variants kept per body, further conflicting bodies are merged into the last variant
+	maxSchemaVariants = 5
+)
+
+// addBodyVariants turns the body schemas of operation into variants, when the matching body of operation2 has a
+// structurally different shape (see hasShapeConflict), so merging them keeps both shapes instead of conflicting.
+// The variants are kept in the schema anyOf, see mergeSchemaVariants.
+func addBodyVariants(operation, operation2 *spec.Operation) {
+	for i, param := range operation.Parameters {
+		if param.In != parametersInBody {
+			continue
+		}
+		for _, param2 := range operation2.Parameters {
+			if param2.In == parametersInBody && hasShapeConflict(param.Schema, param2.Schema) {
+				operation.Parameters[i].Schema = createVariantsSchema(param.Schema)
+			}
+		}
+	}
+
+	if operation.Responses == nil || operation2.Responses == nil {
+		return
+	}
+	for code, response := range operation.Responses.StatusCodeResponses {
+		response2, ok := operation2.Responses.StatusCodeResponses[code]
+		if ok && hasShapeConflict(response.Schema, response2.Schema) {
+			response.Schema = createVariantsSchema(response.Schema)
+			operation.Responses.StatusCodeResponses[code] = response
+		}
+	}
+}
+
+// hasShapeConflict returns true if the schemas are structurally different: different types, arrays of items with
+// a shape conflict, or objects with disjoint properties.
+func hasShapeConflict(schema, schema2 *spec.Schema) bool {
+	if schema == nil || schema2 == nil || isVariantsSchema(schema) || isVariantsSchema(schema2) ||
+		len(schema.Type) == 0 || len(schema2.Type) == 0 {
+		return false
+	}
+	if schema.Type[0] != schema2.Type[0] {
+		return true
+	}
+
+	switch schema.Type[0] {
+	case schemaTypeArray:
+		if schema.Items == nil || schema2.Items == nil {
+			return false
+		}
+		return hasShapeConflict(schema.Items.Schema, schema2.Items.Schema)
+	case schemaTypeObject:
+		if len(schema.Properties) == 0 || len(schema2.Properties) == 0 {
+			return false
+		}
+		for name := range schema.Properties {
+			if _, ok := schema2.Properties[name]; ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func isVariantsSchema(schema *spec.Schema) bool {
+	return schema != nil && len(schema.Type) == 0 && len(schema.AnyOf) > 0
+}
+
+func createVariantsSchema(variants ...*spec.Schema) *spec.Schema {
+	ret := &spec.Schema{}
+	for _, variant := range variants {
+		ret.AnyOf = append(ret.AnyOf, *variant)
+	}
+	return ret
+}
+
+// getSchemaVariants returns the variants of schema, a schema without variants is a single variant.
+func getSchemaVariants(schema *spec.Schema) []spec.Schema {
+	if isVariantsSchema(schema) {
+		return append([]spec.Schema{}, schema.AnyOf...)
+	}
+	return []spec.Schema{*schema}
+}
+
+// mergeSchemaVariants merges each variant of schema2 into the first variant of schema it has no shape conflict with,
+// or adds it as a new variant. Once there are maxSchemaVariants variants, it is merged into the last one.
+func mergeSchemaVariants(schema, schema2 *spec.Schema, path *field.Path) (*spec.Schema, []conflict) {
+	variants := getSchemaVariants(schema)
+	var retConflicts []conflict
+
+	for _, variant2 := range getSchemaVariants(schema2) {
+		variant2 := variant2
+		i := 0
+		for ; i < len(variants); i++ {
+			if !hasShapeConflict(&variants[i], &variant2) {
+				break
+			}
+		}
+		if i == len(variants) && len(variants) < maxSchemaVariants {
+			variants = append(variants, variant2)
+			continue
+		}
+		if i == len(variants) {
+			i = len(variants) - 1
+		}
+		mergedSchema, conflicts := mergeSchema(&variants[i], &variant2, path.Child("anyOf").Index(i))
+		variants[i] = *mergedSchema
+		retConflicts = append(retConflicts, conflicts...)
+	}
+
+	return &spec.Schema{SchemaProps: spec.SchemaProps{AnyOf: variants}}, retConflicts
+}
+
+// convertBodyVariantsToExtension replaces the anyOf of the variant body schemas of each operation, that is not
+// supported by swagger 2.0, with the x-variants extension.
+func convertBodyVariantsToExtension(pathItems map[string]*spec.PathItem) {
+	for _, pathItem := range pathItems {
+		for _, method := range supportedMethods {
+			operation := GetOperationFromPathItem(pathItem, method)
+			if operation == nil {
+				continue
+			}
+			for i, param := range operation.Parameters {
+				if param.In == parametersInBody && isVariantsSchema(param.Schema) {
+					operation.Parameters[i].Schema = createVariantsExtensionSchema(param.Schema)
+				}
+			}
+			if operation.Responses == nil {
+				continue
+			}
+			for code, response := range operation.Responses.StatusCodeResponses {
+				if isVariantsSchema(response.Schema) {
+					response.Schema = createVariantsExtensionSchema(response.Schema)
+					operation.Responses.StatusCodeResponses[code] = response
+				}
+			}
+		}
+	}
+}
+
+func createVariantsExtensionSchema(schema *spec.Schema) *spec.Schema {
+	ret := &spec.Schema{}
+	ret.AddExtension(schemaVariantsExtensionName, schema.AnyOf)
+	return ret
+}
@@ -0,0 +1,163 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"gotest.tools/assert"
+	"k8s.io/utils/field"
+)
+
+func TestSpec_GenerateLearningOAS_BodyVariants(t *testing.T) {
+	config := testOperationGeneratorConfig
+	config.LearnBodyVariants = true
+	s := CreateDefaultSpec("host", "80", config)
+	for i, body := range []string{`{"id":1}`, `[{"id":1}]`, `{"id":2,"name":"x"}`, `{"error":"x"}`} {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry(strconv.Itoa(i), http.MethodPost, "/api", "host", "200", body, body)))
+	}
+
+	oasJSON, err := s.GenerateLearningOAS()
+	assert.NilError(t, err)
+	swagger := &spec.Swagger{}
+	assert.NilError(t, json.Unmarshal(oasJSON, swagger))
+	op := swagger.Paths.Paths["/api"].Post
+	wantVariants := []interface{}{
+		map[string]interface{}{"$ref": definitionsRefPrefix + "id_name"},
+		map[string]interface{}{"type": schemaTypeArray, "items": map[string]interface{}{"$ref": definitionsRefPrefix + "id"}},
+		map[string]interface{}{"$ref": definitionsRefPrefix + "error"},
+	}
+	assert.DeepEqual(t, op.Parameters[0].Schema.Extensions[schemaVariantsExtensionName], wantVariants)
+	assert.DeepEqual(t, op.Responses.StatusCodeResponses[200].Schema.Extensions[schemaVariantsExtensionName], wantVariants)
+
+	oas31JSON, err := ConvertToOAS31(oasJSON)
+	assert.NilError(t, err)
+	oas31 := map[string]interface{}{}
+	assert.NilError(t, json.Unmarshal(oas31JSON, &oas31))
+	responseSchema := oas31["paths"].(map[string]interface{})["/api"].(map[string]interface{})["post"].(map[string]interface{})["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})[mediaTypeApplicationJSON].(map[string]interface{})["schema"].(map[string]interface{})
+	assert.DeepEqual(t, responseSchema, map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"$ref": oas31SchemasRefPrefix + "id_name"},
+			map[string]interface{}{"type": schemaTypeArray, "items": map[string]interface{}{"$ref": oas31SchemasRefPrefix + "id"}},
+			map[string]interface{}{"$ref": oas31SchemasRefPrefix + "error"},
+		},
+	})
+}
+
+func TestSpec_LearnTelemetry_BodyVariantsDisabled(t *testing.T) {
+	s := CreateDefaultSpec("host", "80", testOperationGeneratorConfig)
+	for i, body := range []string{`{"id":1}`, `[{"id":1}]`} {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry(strconv.Itoa(i), http.MethodPost, "/api", "host", "200", body, body)))
+	}
+
+	// the conflicting body is not learned
+	schema := s.LearningSpec.GetPathItem("/api").Post.Responses.StatusCodeResponses[200].Schema
+	assert.DeepEqual(t, []string(schema.Type), []string{schemaTypeObject})
+	assert.Assert(t, schema.AnyOf == nil)
+}
+
+func Test_hasShapeConflict(t *testing.T) {
+	objectSchema := func(names ...string) *spec.Schema {
+		schema := &spec.Schema{}
+		schema.AddType(schemaTypeObject, "")
+		for _, name := range names {
+			schema.SetProperty(name, *spec.StringProperty())
+		}
+		return schema
+	}
+	tests := []struct {
+		name    string
+		schema  *spec.Schema
+		schema2 *spec.Schema
+		want    bool
+	}{
+		{
+			name:    "same type",
+			schema:  spec.StringProperty(),
+			schema2: spec.StrFmtProperty(formatUUID),
+			want:    false,
+		},
+		{
+			name:    "object and array",
+			schema:  objectSchema("id"),
+			schema2: spec.ArrayProperty(objectSchema("id")),
+			want:    true,
+		},
+		{
+			name:    "objects with a shared property",
+			schema:  objectSchema("id", "name"),
+			schema2: objectSchema("id", "error"),
+			want:    false,
+		},
+		{
+			name:    "objects with disjoint properties",
+			schema:  objectSchema("id", "name"),
+			schema2: objectSchema("error"),
+			want:    true,
+		},
+		{
+			name:    "empty object",
+			schema:  objectSchema("id"),
+			schema2: objectSchema(),
+			want:    false,
+		},
+		{
+			name:    "arrays of different items",
+			schema:  spec.ArrayProperty(spec.StringProperty()),
+			schema2: spec.ArrayProperty(objectSchema("id")),
+			want:    true,
+		},
+		{
+			name:    "variants",
+			schema:  createVariantsSchema(objectSchema("id")),
+			schema2: spec.StringProperty(),
+			want:    false,
+		},
+		{
+			name:    "nil",
+			schema:  nil,
+			schema2: spec.StringProperty(),
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasShapeConflict(tt.schema, tt.schema2); got != tt.want {
+				t.Errorf("hasShapeConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mergeSchemaVariants_MaxVariants(t *testing.T) {
+	schema := createVariantsSchema(spec.StringProperty())
+	var conflicts []conflict
+	for _, variant := range []*spec.Schema{
+		spec.Int64Property(), spec.BooleanProperty(), spec.Float64Property(), spec.ArrayProperty(spec.StringProperty()),
+		// merged into the last variant
+		spec.ArrayProperty(spec.Int64Property()),
+	} {
+		schema, conflicts = mergeSchemaVariants(schema, variant, field.NewPath("schema"))
+	}
+
+	assert.Equal(t, len(schema.AnyOf), maxSchemaVariants)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].path.String(), "schema.anyOf[4].items")
+}
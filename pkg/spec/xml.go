@@ -0,0 +1,194 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"k8s.io/utils/field"
+)
+
+const xmlNamespaceAttributeName = "xmlns"
+
+type xmlElement struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     strings.Builder
+}
+
+// getXMLSchema returns the schema of an XML body, with the xml object of each element (element names, namespaces
+// and attributes). Elements with attributes or child elements are objects, the attributes and child elements are
+// their properties. A child element repeated in an element is an array. Other elements are typed by their text.
+func getXMLSchema(body string) (*spec.Schema, error) {
+	root, err := parseXMLElement(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return getXMLElementSchema(root), nil
+}
+
+// parseXMLElement returns the root element of an XML document.
+func parseXMLElement(body string) (*xmlElement, error) {
+	var root *xmlElement
+	var stack []*xmlElement
+
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode xml: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{name: t.Name, attrs: t.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, element)
+			} else if root == nil {
+				root = element
+			} else {
+				return nil, fmt.Errorf("failed to decode xml: multiple root elements")
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("failed to decode xml: no root element")
+	}
+
+	return root, nil
+}
+
+func getXMLElementSchema(element *xmlElement) *spec.Schema {
+	var schema *spec.Schema
+	attrs := getXMLElementAttrs(element)
+	if len(attrs) == 0 && len(element.children) == 0 {
+		schema = getXMLTextSchema(element.text.String())
+	} else {
+		schema = &spec.Schema{}
+		schema.AddType(schemaTypeObject, "")
+		for _, attr := range attrs {
+			attrSchema := getXMLTextSchema(attr.Value)
+			attrSchema.XML = createXMLObject(attr.Name).AsAttribute()
+			schema.SetProperty(attr.Name.Local, *attrSchema)
+		}
+		for _, name := range getXMLChildNames(element) {
+			schema.SetProperty(name, *getXMLChildrenSchema(element, name))
+		}
+	}
+	schema.XML = createXMLObject(element.name)
+
+	return schema
+}
+
+// getXMLChildrenSchema returns the schema of the child elements of element named name, an array if there are many.
+func getXMLChildrenSchema(element *xmlElement, name string) *spec.Schema {
+	var schema *spec.Schema
+	count := 0
+	for _, child := range element.children {
+		if child.name.Local != name {
+			continue
+		}
+		count++
+		// merging may update the schema
+		schema, _ = mergeSchema(schema, getXMLElementSchema(child), field.NewPath(name))
+	}
+	if count == 1 {
+		return schema
+	}
+
+	return spec.ArrayProperty(schema)
+}
+
+// getXMLChildNames returns the distinct names of the child elements of element, in the order they first appear.
+func getXMLChildNames(element *xmlElement) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, child := range element.children {
+		if seen[child.name.Local] {
+			continue
+		}
+		seen[child.name.Local] = true
+		names = append(names, child.name.Local)
+	}
+	return names
+}
+
+// getXMLElementAttrs returns the attributes of element, without the namespace declarations.
+func getXMLElementAttrs(element *xmlElement) []xml.Attr {
+	var attrs []xml.Attr
+	for _, attr := range element.attrs {
+		if attr.Name.Space == xmlNamespaceAttributeName || (attr.Name.Space == "" && attr.Name.Local == xmlNamespaceAttributeName) {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+func getXMLTextSchema(text string) *spec.Schema {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return spec.StringProperty()
+	}
+	tpe, format := getTypeAndFormat(text)
+	return new(spec.Schema).Typed(tpe, format)
+}
+
+func createXMLObject(name xml.Name) *spec.XMLObject {
+	xmlObject := spec.XMLObject{}
+	xmlObject.WithName(name.Local)
+	if name.Space != "" {
+		xmlObject.WithNamespace(name.Space)
+	}
+	return &xmlObject
+}
+
+// isXMLRepeatedElement returns true if schema is an array of the XML elements of schema2, i.e. an element that
+// was repeated in one body and not in another.
+func isXMLRepeatedElement(schema, schema2 *spec.Schema) bool {
+	if schema2.XML == nil || !schema.Type.Contains(schemaTypeArray) || schema2.Type.Contains(schemaTypeArray) {
+		return false
+	}
+	if schema.Items == nil || schema.Items.Schema == nil || schema.Items.Schema.XML == nil {
+		return false
+	}
+	return schema.Items.Schema.XML.Name == schema2.XML.Name
+}
+
+// mergeXMLRepeatedElement merges an array of XML elements with a single element of the same name.
+func mergeXMLRepeatedElement(schema, schema2 *spec.Schema, path *field.Path) (*spec.Schema, []conflict) {
+	if isXMLRepeatedElement(schema2, schema) {
+		schema, schema2 = schema2, schema
+	}
+	return mergeSchema(schema, spec.ArrayProperty(schema2), path)
+}
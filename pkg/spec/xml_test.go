@@ -0,0 +1,156 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/utils/field"
+)
+
+func Test_getXMLSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "text element",
+			body: `<count>3</count>`,
+			want: `{"type":"integer","xml":{"name":"count"}}`,
+		},
+		{
+			name: "empty element",
+			body: `<note/>`,
+			want: `{"type":"string","xml":{"name":"note"}}`,
+		},
+		{
+			name: "attributes, repeated elements and namespace",
+			body: `<?xml version="1.0"?>
+<order id="12" xmlns="urn:orders">
+	<total>3.5</total>
+	<item sku="a"/>
+	<item sku="b"/>
+	<note/>
+</order>`,
+			want: `{"type":"object","properties":{"id":{"type":"integer","xml":{"name":"id","attribute":true}},` +
+				`"item":{"type":"array","items":{"type":"object","properties":{"sku":{"type":"string","xml":{"name":"sku","attribute":true}}},"xml":{"name":"item","namespace":"urn:orders"}}},` +
+				`"note":{"type":"string","xml":{"name":"note","namespace":"urn:orders"}},` +
+				`"total":{"type":"number","xml":{"name":"total","namespace":"urn:orders"}}},"xml":{"name":"order","namespace":"urn:orders"}}`,
+		},
+		{
+			name: "namespaced attribute",
+			body: `<user xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"/>`,
+			want: `{"type":"object","properties":{"nil":{"type":"boolean","xml":{"name":"nil","namespace":"http://www.w3.org/2001/XMLSchema-instance","attribute":true}}},"xml":{"name":"user"}}`,
+		},
+		{
+			name:    "invalid xml",
+			body:    `<order><id>1</id>`,
+			wantErr: true,
+		},
+		{
+			name:    "no root element",
+			body:    `<?xml version="1.0"?>`,
+			wantErr: true,
+		},
+		{
+			name:    "multiple root elements",
+			body:    `<a/><b/>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getXMLSchema(tt.body)
+			if tt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			gotB, err := json.Marshal(got)
+			assert.NilError(t, err)
+			assert.Equal(t, string(gotB), tt.want)
+		})
+	}
+}
+
+func Test_mergeSchema_XMLRepeatedElement(t *testing.T) {
+	single, err := getXMLSchema(`<order><item>1</item></order>`)
+	assert.NilError(t, err)
+	repeated, err := getXMLSchema(`<order><item>1</item><item>2</item></order>`)
+	assert.NilError(t, err)
+	want := `{"type":"object","properties":{"item":{"type":"array","items":{"type":"integer","xml":{"name":"item"}}}},"xml":{"name":"order"}}`
+
+	merged, conflicts := mergeSchema(single, repeated, field.NewPath("schema"))
+	assert.Equal(t, len(conflicts), 0)
+	mergedB, err := json.Marshal(merged)
+	assert.NilError(t, err)
+	assert.Equal(t, string(mergedB), want)
+
+	single, err = getXMLSchema(`<order><item>1</item></order>`)
+	assert.NilError(t, err)
+	merged, conflicts = mergeSchema(repeated, single, field.NewPath("schema"))
+	assert.Equal(t, len(conflicts), 0)
+	mergedB, err = json.Marshal(merged)
+	assert.NilError(t, err)
+	assert.Equal(t, string(mergedB), want)
+}
+
+func TestGenerateSpecOperation_XML(t *testing.T) {
+	data := &HTTPInteractionData{
+		ReqBody:  `<order><id>1</id></order>`,
+		RespBody: `<status code="ok"/>`,
+		ReqHeaders: map[string]string{
+			contentTypeHeaderName: "application/xml; charset=utf-8",
+		},
+		RespHeaders: map[string]string{
+			contentTypeHeaderName: "text/xml",
+		},
+		statusCode: 200,
+	}
+	got, err := CreateTestNewOperationGenerator().GenerateSpecOperation(data, nil)
+	assert.NilError(t, err)
+
+	want := `{"consumes":["application/xml; charset=utf-8"],"produces":["text/xml"],` +
+		`"parameters":[{"name":"body","in":"body","schema":{"type":"object","properties":{"id":{"type":"integer","xml":{"name":"id"}}},"xml":{"name":"order"}}}],` +
+		`"responses":{"200":{"description":"","schema":{"type":"object","properties":{"code":{"type":"string","xml":{"name":"code","attribute":true}}},"xml":{"name":"status"}}},` +
+		`"default":{"description":"Default Response","schema":{"type":"object","properties":{"message":{"type":"string"}}}}}}`
+	assert.Assert(t, validateOperation(t, got, want), marshal(got))
+
+	// the operation is learned without the request body that can not be parsed
+	got, partial, err := CreateTestNewOperationGenerator().generateSpecOperation(context.Background(), &HTTPInteractionData{
+		ReqBody:    `<order>`,
+		ReqHeaders: map[string]string{contentTypeHeaderName: "application/xml"},
+		statusCode: 200,
+	}, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, partial)
+	assert.Equal(t, len(got.Parameters), 0)
+	assert.Equal(t, len(got.Consumes), 0)
+
+	_, err = CreateTestNewOperationGenerator().GenerateSpecOperation(&HTTPInteractionData{
+		ReqBody:     `<order>`,
+		ReqHeaders:  map[string]string{contentTypeHeaderName: "application/xml"},
+		RespBody:    `<status`,
+		RespHeaders: map[string]string{contentTypeHeaderName: "text/xml"},
+		statusCode:  200,
+	}, nil)
+	assert.Assert(t, err != nil)
+}
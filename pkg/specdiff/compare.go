@@ -0,0 +1,294 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package specdiff
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+)
+
+const (
+	parametersInBody   = "body"
+	parametersInPath   = "path"
+	parametersInHeader = "header"
+
+	schemaTypeInteger = "integer"
+	schemaTypeNumber  = "number"
+	schemaTypeObject  = "object"
+
+	definitionsRefPrefix = "#/definitions/"
+	parametersRefPrefix  = "#/parameters/"
+	responsesRefPrefix   = "#/responses/"
+
+	// schemas are compared up to this depth, so recursive provided definitions end
+	maxSchemaDepth = 20
+)
+
+// comparer compares a learned operation against a provided one, resolving the refs of the provided spec.
+type comparer struct {
+	definitions oapi_spec.Definitions
+	parameters  map[string]oapi_spec.Parameter
+	responses   map[string]oapi_spec.Response
+}
+
+// compareParameters compares the learned parameters with the provided operation and path item parameters.
+// Path params are matched by the path, so only their paths are compared.
+func (c *comparer) compareParameters(learned, provided *oapi_spec.Operation, providedPathItemParams []oapi_spec.Parameter) []Change {
+	providedParams := make(map[string]oapi_spec.Parameter)
+	var providedBody *oapi_spec.Parameter
+	// operation params override the path item ones
+	for _, params := range [][]oapi_spec.Parameter{providedPathItemParams, provided.Parameters} {
+		for _, param := range params {
+			param := c.resolveParameter(param)
+			if param.In == parametersInBody {
+				providedBody = &param
+				continue
+			}
+			providedParams[getParameterKey(param)] = param
+		}
+	}
+
+	var changes []Change
+	for _, param := range getSortedParameters(learned.Parameters) {
+		switch param.In {
+		case parametersInPath:
+			continue
+		case parametersInBody:
+			if providedBody == nil {
+				changes = append(changes, Change{
+					Kind:     ChangeKindNewParameter,
+					Location: "/parameters/" + parametersInBody,
+					Learned:  getSchemaTypeName(param.Schema),
+				})
+				continue
+			}
+			changes = append(changes, c.compareSchemas("/parameters/body/schema", param.Schema, providedBody.Schema, 0)...)
+			continue
+		}
+
+		location := "/parameters/" + param.In + "/" + escapeJSONPointerToken(param.Name)
+		providedParam, ok := providedParams[getParameterKey(param)]
+		if !ok {
+			changes = append(changes, Change{
+				Kind:     ChangeKindNewParameter,
+				Location: location,
+				Learned:  getTypeName(param.Type, param.Format),
+			})
+			continue
+		}
+		if isTypeChanged(param.Type, param.Format, providedParam.Type, providedParam.Format) {
+			changes = append(changes, Change{
+				Kind:     ChangeKindChangedType,
+				Location: location,
+				Learned:  getTypeName(param.Type, param.Format),
+				Provided: getTypeName(providedParam.Type, providedParam.Format),
+			})
+		}
+	}
+
+	return changes
+}
+
+// compareResponses compares the learned response codes and schemas with the provided ones. The learned default
+// response is not compared, a provided default response documents all the response codes.
+func (c *comparer) compareResponses(learned, provided *oapi_spec.Operation) []Change {
+	if learned.Responses == nil {
+		return nil
+	}
+	codes := make([]int, 0, len(learned.Responses.StatusCodeResponses))
+	for code := range learned.Responses.StatusCodeResponses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var changes []Change
+	for _, code := range codes {
+		learnedResponse := learned.Responses.StatusCodeResponses[code]
+		location := "/responses/" + strconv.Itoa(code)
+		providedResponse := c.getProvidedResponse(provided, code)
+		if providedResponse == nil {
+			changes = append(changes, Change{Kind: ChangeKindUndocumentedResponseCode, Location: location})
+			continue
+		}
+		changes = append(changes, c.compareSchemas(location+"/schema", learnedResponse.Schema, providedResponse.Schema, 0)...)
+	}
+
+	return changes
+}
+
+func (c *comparer) getProvidedResponse(provided *oapi_spec.Operation, code int) *oapi_spec.Response {
+	if provided.Responses == nil {
+		return nil
+	}
+	if response, ok := provided.Responses.StatusCodeResponses[code]; ok {
+		return c.resolveResponse(&response)
+	}
+	if provided.Responses.Default != nil {
+		return c.resolveResponse(provided.Responses.Default)
+	}
+	return nil
+}
+
+// compareSchemas compares the learned schema with the provided one. A provided schema without a type accepts
+// any learned schema, and a learned property is only new if the provided object has no additional properties.
+func (c *comparer) compareSchemas(location string, learned, provided *oapi_spec.Schema, depth int) []Change {
+	// learned operations are not generated into an OAS, so their schemas have no refs
+	provided = resolveSchemaRef(provided, c.definitions)
+	if learned == nil || provided == nil || depth >= maxSchemaDepth {
+		return nil
+	}
+
+	learnedType, providedType := getSchemaType(learned), getSchemaType(provided)
+	if learnedType == "" || providedType == "" {
+		return nil
+	}
+	if isTypeChanged(learnedType, learned.Format, providedType, provided.Format) {
+		return []Change{{
+			Kind:     ChangeKindChangedType,
+			Location: location,
+			Learned:  getTypeName(learnedType, learned.Format),
+			Provided: getTypeName(providedType, provided.Format),
+		}}
+	}
+
+	var changes []Change
+	if learned.Items != nil && provided.Items != nil {
+		changes = append(changes, c.compareSchemas(location+"/items", learned.Items.Schema, provided.Items.Schema, depth+1)...)
+	}
+
+	names := make([]string, 0, len(learned.Properties))
+	for name := range learned.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		learnedProperty := learned.Properties[name]
+		propertyLocation := location + "/properties/" + escapeJSONPointerToken(name)
+		providedProperty, ok := provided.Properties[name]
+		if !ok {
+			if provided.AdditionalProperties == nil || !provided.AdditionalProperties.Allows {
+				changes = append(changes, Change{
+					Kind:     ChangeKindNewProperty,
+					Location: propertyLocation,
+					Learned:  getSchemaTypeName(&learnedProperty),
+				})
+			}
+			continue
+		}
+		changes = append(changes, c.compareSchemas(propertyLocation, &learnedProperty, &providedProperty, depth+1)...)
+	}
+
+	return changes
+}
+
+func (c *comparer) resolveParameter(param oapi_spec.Parameter) oapi_spec.Parameter {
+	ref := param.Ref.String()
+	if !strings.HasPrefix(ref, parametersRefPrefix) {
+		return param
+	}
+	if resolved, ok := c.parameters[strings.TrimPrefix(ref, parametersRefPrefix)]; ok {
+		return resolved
+	}
+	return param
+}
+
+func (c *comparer) resolveResponse(response *oapi_spec.Response) *oapi_spec.Response {
+	ref := response.Ref.String()
+	if !strings.HasPrefix(ref, responsesRefPrefix) {
+		return response
+	}
+	if resolved, ok := c.responses[strings.TrimPrefix(ref, responsesRefPrefix)]; ok {
+		return &resolved
+	}
+	return response
+}
+
+// resolveSchemaRef returns the definition a schema refers to, or the schema itself if it is not a ref.
+func resolveSchemaRef(schema *oapi_spec.Schema, definitions oapi_spec.Definitions) *oapi_spec.Schema {
+	if schema == nil {
+		return nil
+	}
+	ref := schema.Ref.String()
+	if !strings.HasPrefix(ref, definitionsRefPrefix) {
+		return schema
+	}
+	definition, ok := definitions[strings.TrimPrefix(ref, definitionsRefPrefix)]
+	if !ok {
+		return schema
+	}
+	return &definition
+}
+
+// isTypeChanged returns true if the learned type is not accepted by the provided type. Integers are numbers, and
+// formats are only compared when both are set.
+func isTypeChanged(learnedType, learnedFormat, providedType, providedFormat string) bool {
+	if providedType == "" {
+		return false
+	}
+	if learnedType != providedType && !(learnedType == schemaTypeInteger && providedType == schemaTypeNumber) {
+		return true
+	}
+	return learnedType == providedType && learnedFormat != "" && providedFormat != "" && learnedFormat != providedFormat
+}
+
+func getSchemaType(schema *oapi_spec.Schema) string {
+	if len(schema.Type) > 0 {
+		return schema.Type[0]
+	}
+	if len(schema.Properties) > 0 {
+		return schemaTypeObject
+	}
+	return ""
+}
+
+func getSchemaTypeName(schema *oapi_spec.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	return getTypeName(getSchemaType(schema), schema.Format)
+}
+
+func getTypeName(tpe, format string) string {
+	if format != "" {
+		return tpe + "(" + format + ")"
+	}
+	return tpe
+}
+
+// getParameterKey returns the key of a parameter by its location and name, header names are case insensitive.
+func getParameterKey(param oapi_spec.Parameter) string {
+	name := param.Name
+	if param.In == parametersInHeader {
+		name = strings.ToLower(name)
+	}
+	return param.In + "/" + name
+}
+
+func getSortedParameters(params []oapi_spec.Parameter) []oapi_spec.Parameter {
+	ret := append([]oapi_spec.Parameter{}, params...)
+	sort.Slice(ret, func(i, j int) bool {
+		return getParameterKey(ret[i]) < getParameterKey(ret[j])
+	})
+	return ret
+}
+
+// escapeJSONPointerToken escapes a JSON pointer reference token as in RFC 6901.
+func escapeJSONPointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,206 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package specdiff compares learned operations and specs against a provided spec, reporting the undocumented paths,
+// methods, parameters, response codes and schema type changes.
+package specdiff
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	oapi_spec "github.com/go-openapi/spec"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+)
+
+type ChangeKind string
+
+const (
+	// ChangeKindUndocumentedPath the path is not in the provided spec (shadow API)
+	ChangeKindUndocumentedPath ChangeKind = "UNDOCUMENTED_PATH"
+	// ChangeKindUndocumentedMethod the path is in the provided spec, but not with this method
+	ChangeKindUndocumentedMethod ChangeKind = "UNDOCUMENTED_METHOD"
+	// ChangeKindNewParameter the query, header, cookie or form data parameter is not in the provided operation
+	ChangeKindNewParameter ChangeKind = "NEW_PARAMETER"
+	// ChangeKindNewProperty the body property is not in the provided schema
+	ChangeKindNewProperty ChangeKind = "NEW_PROPERTY"
+	// ChangeKindChangedType the type or format of the parameter or schema differs from the provided one
+	ChangeKindChangedType ChangeKind = "CHANGED_TYPE"
+	// ChangeKindUndocumentedResponseCode the response status code is not in the provided operation, which has no
+	// default response
+	ChangeKindUndocumentedResponseCode ChangeKind = "UNDOCUMENTED_RESPONSE_CODE"
+)
+
+// Change is a difference between a learned operation and its provided counterpart.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	// Location is the JSON pointer of the changed field in the learned operation, with parameters keyed by location
+	// and name (e.g. /parameters/query/limit, /parameters/body/schema/properties/id, /responses/200/schema/items).
+	// Empty for ChangeKindUndocumentedPath and ChangeKindUndocumentedMethod.
+	Location string `json:"location,omitempty"`
+	// Learned and Provided are the types of the field (e.g. string, string(uuid), object), empty when missing
+	Learned  string `json:"learned,omitempty"`
+	Provided string `json:"provided,omitempty"`
+}
+
+// OperationDiff is the diff of a learned operation against the provided spec.
+type OperationDiff struct {
+	Path   string `json:"path"`
+	Method string `json:"method"`
+	// ProvidedPath is the provided spec path matching Path (without the provided base path), empty if undocumented
+	ProvidedPath string   `json:"providedPath,omitempty"`
+	Changes      []Change `json:"changes"`
+}
+
+// HasChanges returns true if the operation differs from the provided spec.
+func (o *OperationDiff) HasChanges() bool {
+	return len(o.Changes) > 0
+}
+
+// SpecDiff is the diff of the learned operations against the provided spec.
+type SpecDiff struct {
+	// Operations are the operations with changes, sorted by path and method
+	Operations []*OperationDiff `json:"operations"`
+}
+
+// Differ compares learned operations against a provided spec.
+type Differ struct {
+	provided *oapi_spec.Swagger
+	// pathTrie holds the provided paths, valued by themselves
+	pathTrie pathtrie.TypedPathTrie[string]
+}
+
+// New creates a Differ of the provided spec.
+func New(provided *oapi_spec.Swagger) *Differ {
+	d := &Differ{
+		provided: provided,
+		pathTrie: pathtrie.NewTyped[string](),
+	}
+	if provided.Paths != nil {
+		for path := range provided.Paths.Paths {
+			d.pathTrie.Insert(path, path)
+		}
+	}
+
+	return d
+}
+
+// DiffOperation compares the learned operation of method and path (e.g. from a single telemetry, or merged from
+// many) against the provided spec. path may be literal or parameterized.
+func (d *Differ) DiffOperation(path, method string, learned *oapi_spec.Operation) *OperationDiff {
+	diff := &OperationDiff{
+		Path:    path,
+		Method:  method,
+		Changes: []Change{},
+	}
+
+	providedPath, ok := d.findProvidedPath(path)
+	if !ok {
+		diff.Changes = append(diff.Changes, Change{Kind: ChangeKindUndocumentedPath})
+		return diff
+	}
+	diff.ProvidedPath = providedPath
+
+	providedPathItem := d.provided.Paths.Paths[providedPath]
+	providedOp := getOperation(&providedPathItem, method)
+	if providedOp == nil {
+		diff.Changes = append(diff.Changes, Change{Kind: ChangeKindUndocumentedMethod})
+		return diff
+	}
+
+	c := &comparer{
+		definitions: d.provided.Definitions,
+		parameters:  d.provided.Parameters,
+		responses:   d.provided.Responses,
+	}
+	diff.Changes = append(diff.Changes, c.compareParameters(learned, providedOp, providedPathItem.Parameters)...)
+	diff.Changes = append(diff.Changes, c.compareResponses(learned, providedOp)...)
+
+	return diff
+}
+
+// DiffSpec compares the operations of the learned path items (by path) against the provided spec.
+func (d *Differ) DiffSpec(learnedPathItems map[string]*oapi_spec.PathItem) *SpecDiff {
+	paths := make([]string, 0, len(learnedPathItems))
+	for path := range learnedPathItems {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	specDiff := &SpecDiff{Operations: []*OperationDiff{}}
+	for _, path := range paths {
+		pathItem := learnedPathItems[path]
+		for _, method := range methods {
+			op := getOperation(pathItem, method)
+			if op == nil {
+				continue
+			}
+			if diff := d.DiffOperation(path, method, op); diff.HasChanges() {
+				specDiff.Operations = append(specDiff.Operations, diff)
+			}
+		}
+	}
+
+	return specDiff
+}
+
+// findProvidedPath returns the provided path matching path. The provided base path is trimmed from path.
+func (d *Differ) findProvidedPath(path string) (string, bool) {
+	if basePath := d.provided.BasePath; basePath != "" && basePath != "/" {
+		if !strings.HasPrefix(path, basePath) {
+			return "", false
+		}
+		path = strings.TrimPrefix(path, basePath)
+	}
+
+	_, providedPath, found := d.pathTrie.GetPathAndValue(path)
+	return providedPath, found
+}
+
+// methods are sorted the same way they are listed in the diff
+var methods = []string{
+	http.MethodDelete,
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPatch,
+	http.MethodPost,
+	http.MethodPut,
+}
+
+func getOperation(pathItem *oapi_spec.PathItem, method string) *oapi_spec.Operation {
+	if pathItem == nil {
+		return nil
+	}
+	switch method {
+	case http.MethodGet:
+		return pathItem.Get
+	case http.MethodPut:
+		return pathItem.Put
+	case http.MethodPost:
+		return pathItem.Post
+	case http.MethodDelete:
+		return pathItem.Delete
+	case http.MethodOptions:
+		return pathItem.Options
+	case http.MethodHead:
+		return pathItem.Head
+	case http.MethodPatch:
+		return pathItem.Patch
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package specdiff
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	oapi_spec "github.com/go-openapi/spec"
+	"gotest.tools/assert"
+)
+
+const testProvidedSpec = `{
+  "swagger": "2.0",
+  "basePath": "/api",
+  "paths": {
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "type": "integer"}],
+      "get": {
+        "parameters": [
+          {"$ref": "#/parameters/Limit"},
+          {"name": "X-Request-Id", "in": "header", "type": "string", "format": "uuid"}
+        ],
+        "responses": {
+          "200": {"description": "user", "schema": {"$ref": "#/definitions/User"}},
+          "404": {"$ref": "#/responses/NotFound"}
+        }
+      },
+      "put": {
+        "parameters": [{"name": "user", "in": "body", "schema": {"$ref": "#/definitions/User"}}],
+        "responses": {"default": {"description": "any"}}
+      }
+    },
+    "/tags": {
+      "get": {"responses": {"200": {"description": "tags", "schema": {"type": "object", "additionalProperties": true}}}}
+    }
+  },
+  "parameters": {"Limit": {"name": "limit", "in": "query", "type": "integer"}},
+  "responses": {"NotFound": {"description": "not found", "schema": {"type": "object", "properties": {"message": {"type": "string"}}}}},
+  "definitions": {
+    "User": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "integer", "format": "int64"},
+        "name": {"type": "string"},
+        "score": {"type": "number"},
+        "tags": {"type": "array", "items": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func loadTestProvidedSpec(t *testing.T) *oapi_spec.Swagger {
+	t.Helper()
+	provided := &oapi_spec.Swagger{}
+	assert.NilError(t, json.Unmarshal([]byte(testProvidedSpec), provided))
+	return provided
+}
+
+func unmarshalOperation(t *testing.T, data string) *oapi_spec.Operation {
+	t.Helper()
+	op := &oapi_spec.Operation{}
+	assert.NilError(t, json.Unmarshal([]byte(data), op))
+	return op
+}
+
+func TestDiffer_DiffOperation(t *testing.T) {
+	differ := New(loadTestProvidedSpec(t))
+	tests := []struct {
+		name             string
+		path             string
+		method           string
+		learned          string
+		wantProvidedPath string
+		want             []Change
+	}{
+		{
+			name:             "no changes",
+			path:             "/api/users/1",
+			method:           http.MethodGet,
+			learned:          `{"parameters":[{"name":"limit","in":"query","type":"integer"},{"name":"x-request-id","in":"header","type":"string","format":"uuid"}],"responses":{"200":{"description":"","schema":{"type":"object","properties":{"id":{"type":"integer","format":"int64"},"score":{"type":"integer","format":"int64"}}}},"default":{"description":"Default Response"}}}`,
+			wantProvidedPath: "/users/{id}",
+			want:             []Change{},
+		},
+		{
+			name:    "undocumented path",
+			path:    "/api/admin",
+			method:  http.MethodGet,
+			learned: `{"responses":{"200":{"description":""}}}`,
+			want:    []Change{{Kind: ChangeKindUndocumentedPath}},
+		},
+		{
+			name:    "path without base path",
+			path:    "/users/1",
+			method:  http.MethodGet,
+			learned: `{"responses":{"200":{"description":""}}}`,
+			want:    []Change{{Kind: ChangeKindUndocumentedPath}},
+		},
+		{
+			name:             "undocumented method",
+			path:             "/api/users/{param1}",
+			method:           http.MethodDelete,
+			learned:          `{"responses":{"204":{"description":""}}}`,
+			wantProvidedPath: "/users/{id}",
+			want:             []Change{{Kind: ChangeKindUndocumentedMethod}},
+		},
+		{
+			name:             "new parameters, changed types and undocumented response code",
+			path:             "/api/users/1",
+			method:           http.MethodGet,
+			learned:          `{"parameters":[{"name":"limit","in":"query","type":"string"},{"name":"X-Request-Id","in":"header","type":"string","format":"date-time"},{"name":"verbose","in":"query","type":"boolean"}],"responses":{"200":{"description":"","schema":{"type":"object","properties":{"id":{"type":"string"},"email":{"type":"string","format":"email"},"tags":{"type":"array","items":{"type":"integer"}}}}},"404":{"description":"","schema":{"type":"object","properties":{"message":{"type":"string"}}}},"500":{"description":""}}}`,
+			wantProvidedPath: "/users/{id}",
+			want: []Change{
+				{Kind: ChangeKindChangedType, Location: "/parameters/header/X-Request-Id", Learned: "string(date-time)", Provided: "string(uuid)"},
+				{Kind: ChangeKindChangedType, Location: "/parameters/query/limit", Learned: "string", Provided: "integer"},
+				{Kind: ChangeKindNewParameter, Location: "/parameters/query/verbose", Learned: "boolean"},
+				{Kind: ChangeKindNewProperty, Location: "/responses/200/schema/properties/email", Learned: "string(email)"},
+				{Kind: ChangeKindChangedType, Location: "/responses/200/schema/properties/id", Learned: "string", Provided: "integer(int64)"},
+				{Kind: ChangeKindChangedType, Location: "/responses/200/schema/properties/tags/items", Learned: "integer", Provided: "string"},
+				{Kind: ChangeKindUndocumentedResponseCode, Location: "/responses/500"},
+			},
+		},
+		{
+			name:             "body and default response",
+			path:             "/api/users/1",
+			method:           http.MethodPut,
+			learned:          `{"parameters":[{"name":"body","in":"body","schema":{"type":"object","properties":{"name":{"type":"boolean"}}}}],"responses":{"500":{"description":""}}}`,
+			wantProvidedPath: "/users/{id}",
+			want: []Change{
+				{Kind: ChangeKindChangedType, Location: "/parameters/body/schema/properties/name", Learned: "boolean", Provided: "string"},
+			},
+		},
+		{
+			name:             "additional properties",
+			path:             "/api/tags",
+			method:           http.MethodGet,
+			learned:          `{"responses":{"200":{"description":"","schema":{"type":"object","properties":{"go":{"type":"integer"}}}}}}`,
+			wantProvidedPath: "/tags",
+			want:             []Change{},
+		},
+		{
+			name:             "undocumented body",
+			path:             "/api/tags",
+			method:           http.MethodGet,
+			learned:          `{"parameters":[{"name":"body","in":"body","schema":{"type":"array","items":{"type":"string"}}}],"responses":{"200":{"description":""}}}`,
+			wantProvidedPath: "/tags",
+			want:             []Change{{Kind: ChangeKindNewParameter, Location: "/parameters/body", Learned: "array"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := differ.DiffOperation(tt.path, tt.method, unmarshalOperation(t, tt.learned))
+			assert.Equal(t, got.Path, tt.path)
+			assert.Equal(t, got.Method, tt.method)
+			assert.Equal(t, got.ProvidedPath, tt.wantProvidedPath)
+			assert.DeepEqual(t, got.Changes, tt.want)
+		})
+	}
+}
+
+func TestDiffer_DiffSpec(t *testing.T) {
+	differ := New(loadTestProvidedSpec(t))
+	learned := map[string]*oapi_spec.PathItem{
+		"/api/users/{param1}": {
+			PathItemProps: oapi_spec.PathItemProps{
+				Get:    unmarshalOperation(t, `{"parameters":[{"name":"limit","in":"query","type":"integer"}],"responses":{"200":{"description":""}}}`),
+				Delete: unmarshalOperation(t, `{"responses":{"204":{"description":""}}}`),
+			},
+		},
+		"/api/admin": {
+			PathItemProps: oapi_spec.PathItemProps{
+				Post: unmarshalOperation(t, `{"responses":{"200":{"description":""}}}`),
+			},
+		},
+	}
+
+	got := differ.DiffSpec(learned)
+	assert.DeepEqual(t, got, &SpecDiff{
+		Operations: []*OperationDiff{
+			{
+				Path:    "/api/admin",
+				Method:  http.MethodPost,
+				Changes: []Change{{Kind: ChangeKindUndocumentedPath}},
+			},
+			{
+				Path:         "/api/users/{param1}",
+				Method:       http.MethodDelete,
+				ProvidedPath: "/users/{id}",
+				Changes:      []Change{{Kind: ChangeKindUndocumentedMethod}},
+			},
+		},
+	})
+}
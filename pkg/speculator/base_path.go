@@ -0,0 +1,116 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"strings"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// GetBasePathSpecKey returns the key of the spec of the logical API of host under basePath, see HostConfig.BasePaths.
+func GetBasePathSpecKey(host, port, basePath string) SpecKey {
+	return SpecKey(string(GetSpecKey(host, port)) + basePath)
+}
+
+// GetBasePathFromSpecKey returns the base path of a key of GetBasePathSpecKey, or "" for a key of GetSpecKey.
+func GetBasePathFromSpecKey(key SpecKey) string {
+	if i := strings.Index(string(key), "/"); i >= 0 {
+		return string(key)[i:]
+	}
+	return ""
+}
+
+// getTelemetrySpecKey returns the key of the spec that learns telemetry and the base path of the spec,
+// "" if the path of telemetry is not under one of the base paths of its host.
+func (s *Speculator) getTelemetrySpecKey(telemetry *_spec.Telemetry, port string) (SpecKey, string) {
+	host := telemetry.Request.Host
+	basePath := matchBasePath(s.getBasePaths(host, port), telemetry.Request.Path)
+	if basePath == "" {
+		return GetSpecKey(host, port), ""
+	}
+	return GetBasePathSpecKey(host, port, basePath), basePath
+}
+
+func (s *Speculator) getBasePaths(host, port string) []string {
+	hostConfig, _ := s.getHostConfig(host, port)
+	return hostConfig.BasePaths
+}
+
+// matchBasePath returns the longest base path (without a trailing slash) that path is under, e.g. /billing for
+// /billing/invoices?id=1, or "" if there is none.
+func matchBasePath(basePaths []string, path string) string {
+	var matched string
+	for _, basePath := range basePaths {
+		basePath = strings.TrimSuffix(basePath, "/")
+		if basePath != "" && len(basePath) > len(matched) && isUnderBasePath(basePath, path) {
+			matched = basePath
+		}
+	}
+	return matched
+}
+
+func isUnderBasePath(basePath, path string) bool {
+	if !strings.HasPrefix(path, basePath) {
+		return false
+	}
+	rest := path[len(basePath):]
+	return rest == "" || rest[0] == '/' || rest[0] == '?' || rest[0] == '#'
+}
+
+// trimBasePath returns a copy of telemetry whose request path is relative to basePath, the caller's telemetry is not
+// modified.
+func trimBasePath(telemetry *_spec.Telemetry, basePath string) *_spec.Telemetry {
+	if basePath == "" {
+		return telemetry
+	}
+	trimmed := *telemetry
+	request := *telemetry.Request
+	request.Path = strings.TrimPrefix(request.Path, basePath)
+	if request.Path == "" || request.Path[0] != '/' {
+		request.Path = "/" + request.Path
+	}
+	trimmed.Request = &request
+
+	return &trimmed
+}
+
+func (s *Speculator) createSpec(host, port, basePath string) *_spec.Spec {
+	spec := _spec.CreateDefaultSpec(host, port, s.getOperationGeneratorConfig(host, port))
+	spec.BasePath = basePath
+	return spec
+}
+
+// validateBasePaths returns the base paths without a trailing slash, a base path must start with a slash
+// and have no path params.
+func validateBasePaths(basePaths []string) ([]string, error) {
+	var ret []string
+	for _, basePath := range basePaths {
+		trimmed := strings.TrimSuffix(basePath, "/")
+		if !strings.HasPrefix(trimmed, "/") || strings.ContainsAny(trimmed, "?#") {
+			return nil, fmt.Errorf("invalid base path %q: must be a path other than /", basePath)
+		}
+		for _, segment := range strings.Split(trimmed, "/") {
+			if utils.IsPathParam(segment) {
+				return nil, fmt.Errorf("invalid base path %q: must not have path params", basePath)
+			}
+		}
+		ret = append(ret, trimmed)
+	}
+	return ret, nil
+}
@@ -0,0 +1,133 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestSpeculator_LearnTelemetry_BasePaths(t *testing.T) {
+	s := CreateSpeculator(Config{
+		HostConfigs: map[string]HostConfig{
+			"host": {BasePaths: []string{"/billing", "/billing/v2", "/auth/"}},
+		},
+	})
+	telemetries := []*_spec.Telemetry{
+		createHostTelemetry("host", "10.0.0.1:80", "GET", "/billing/invoices?id=1"),
+		createHostTelemetry("host", "10.0.0.1:80", "GET", "/billing/v2/invoices"),
+		createHostTelemetry("host", "10.0.0.1:80", "POST", "/auth"),
+		createHostTelemetry("host", "10.0.0.1:80", "GET", "/billingx"),
+		createHostTelemetry("other", "10.0.0.1:80", "GET", "/billing/invoices"),
+	}
+	for _, telemetry := range telemetries {
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	// the caller's telemetry is not modified
+	assert.Equal(t, telemetries[0].Request.Path, "/billing/invoices?id=1")
+
+	wantPaths := map[SpecKey]string{
+		GetBasePathSpecKey("host", "80", "/billing"):    "/invoices",
+		GetBasePathSpecKey("host", "80", "/billing/v2"): "/invoices",
+		GetBasePathSpecKey("host", "80", "/auth"):       "/",
+		GetSpecKey("host", "80"):                        "/billingx",
+		GetSpecKey("other", "80"):                       "/billing/invoices",
+	}
+	specs := s.getSpecs()
+	assert.Equal(t, len(specs), len(wantPaths))
+	for specKey, wantPath := range wantPaths {
+		spec, ok := specs[specKey]
+		assert.Assert(t, ok, specKey)
+		assert.Equal(t, spec.BasePath, GetBasePathFromSpecKey(specKey))
+		assert.Assert(t, spec.LearningSpec.GetPathItem(wantPath) != nil, specKey)
+	}
+
+	specKey := GetBasePathSpecKey("host", "80", "/billing")
+	assert.NilError(t, s.ApprovePaths(specKey, []string{"/invoices"}))
+	spec, _ := s.getSpec(specKey)
+	oasJSON, err := spec.GenerateOASJson()
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(oasJSON), `"basePath":"/billing"`))
+
+	// the approved spec of the base path is diffed
+	diff, err := s.DiffTelemetry(createHostTelemetry("host", "10.0.0.1:80", "GET", "/billing/invoices"), _spec.DiffSourceReconstructed)
+	assert.NilError(t, err)
+	assert.Equal(t, diff.Path, "/invoices")
+}
+
+func TestGetHostAndPortFromSpecKey_BasePath(t *testing.T) {
+	host, port, err := GetHostAndPortFromSpecKey(GetBasePathSpecKey("host", "8080", "/billing/v2"))
+	assert.NilError(t, err)
+	assert.Equal(t, host, "host")
+	assert.Equal(t, port, "8080")
+	assert.Equal(t, GetBasePathFromSpecKey(GetBasePathSpecKey("host", "8080", "/billing/v2")), "/billing/v2")
+	assert.Equal(t, GetBasePathFromSpecKey(GetSpecKey("host", "8080")), "")
+}
+
+func Test_matchBasePath(t *testing.T) {
+	basePaths := []string{"/billing", "/billing/v2/"}
+	assert.Equal(t, matchBasePath(basePaths, "/billing"), "/billing")
+	assert.Equal(t, matchBasePath(basePaths, "/billing?id=1"), "/billing")
+	assert.Equal(t, matchBasePath(basePaths, "/billing/v2/a"), "/billing/v2")
+	assert.Equal(t, matchBasePath(basePaths, "/billing2"), "")
+	assert.Equal(t, matchBasePath(nil, "/billing"), "")
+}
+
+func Test_validateBasePaths(t *testing.T) {
+	basePaths, err := validateBasePaths([]string{"/a/", "/b"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, basePaths, []string{"/a", "/b"})
+	_, err = validateBasePaths([]string{"/"})
+	assert.ErrorContains(t, err, "must be a path other than /")
+	_, err = validateBasePaths([]string{"a"})
+	assert.ErrorContains(t, err, "must be a path other than /")
+}
+
+func TestSpeculator_LearnTelemetry_BasePathsFileConfig(t *testing.T) {
+	fileConfig, err := ParseFileConfig([]byte(`
+requestHeadersToIgnore: [x-trace-id]
+hosts:
+  host:
+    basePaths: [/billing]
+`))
+	assert.NilError(t, err)
+	config, err := fileConfig.ToConfig()
+	assert.NilError(t, err)
+	s := CreateSpeculator(config)
+
+	for _, path := range []string{"/billing/invoices", "/users"} {
+		telemetry := createHostTelemetry("host", "10.0.0.1:80", "GET", path)
+		telemetry.Request.Common.Headers = append(telemetry.Request.Common.Headers, &_spec.Header{Key: "x-trace-id", Value: "1"})
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	// the global ignored headers apply to the specs of the host and of its base paths
+	for specKey, path := range map[SpecKey]string{
+		GetBasePathSpecKey("host", "80", "/billing"): "/invoices",
+		GetSpecKey("host", "80"):                     "/users",
+	} {
+		spec, ok := s.getSpec(specKey)
+		assert.Assert(t, ok, specKey)
+		op := spec.LearningSpec.GetPathItem(path).Get
+		for _, param := range op.Parameters {
+			assert.Assert(t, param.Name != "x-trace-id", specKey)
+		}
+	}
+}
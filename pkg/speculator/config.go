@@ -0,0 +1,487 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// HostConfig holds the options of a single host, overriding the global options.
+type HostConfig struct {
+	OperationGeneratorConfig _spec.OperationGeneratorConfig
+	// BasePaths are the prefixes of the logical APIs of the host (e.g. /billing), the telemetries under a base path
+	// are learned into their own spec whose paths are relative to it, see GetBasePathSpecKey. The longest matching
+	// base path is used, and the telemetries under none are learned into the spec of the host.
+	BasePaths []string
+	// Filter and Redaction replace the global Config.Filter and Config.Redaction for the telemetries of the host
+	Filter    FilterConfig
+	Redaction *_spec.RedactionPolicy
+}
+
+// FileConfig is the YAML/JSON representation of Config, see LoadConfig.
+type FileConfig struct {
+	ResponseHeadersToIgnore []string `json:"responseHeadersToIgnore,omitempty"`
+	RequestHeadersToIgnore  []string `json:"requestHeadersToIgnore,omitempty"`
+	// body sizes in bytes, see OperationGeneratorConfig.MaxBodySizeToLearn
+	MaxBodySizeToLearn            int            `json:"maxBodySizeToLearn,omitempty"`
+	MaxBodySizeToLearnByMediaType map[string]int `json:"maxBodySizeToLearnByMediaType,omitempty"`
+	// see OperationGeneratorConfig.SchemaMergeMinEstablishedHits
+	SchemaMergeMinEstablishedHits int     `json:"schemaMergeMinEstablishedHits,omitempty"`
+	SchemaMergeMinOutlierRatio    float64 `json:"schemaMergeMinOutlierRatio,omitempty"`
+	MaxRetainedSamples            int     `json:"maxRetainedSamples,omitempty"`
+	// cookie names allowlist/denylist, see OperationGeneratorConfig.CookiesToLearn
+	CookiesToLearn  []string `json:"cookiesToLearn,omitempty"`
+	CookiesToIgnore []string `json:"cookiesToIgnore,omitempty"`
+	// enum inference, see OperationGeneratorConfig.EnumMaxValues
+	EnumMaxValues  int `json:"enumMaxValues,omitempty"`
+	EnumMinSamples int `json:"enumMinSamples,omitempty"`
+	// see OperationGeneratorConfig.RequiredPropertyMinRatio
+	RequiredPropertyMinRatio float64 `json:"requiredPropertyMinRatio,omitempty"`
+	// see OperationGeneratorConfig.LearnBodyVariants
+	LearnBodyVariants bool `json:"learnBodyVariants,omitempty"`
+	// see OperationGeneratorConfig.LearnLocalePathParams
+	LearnLocalePathParams bool `json:"learnLocalePathParams,omitempty"`
+	// see OperationGeneratorConfig.LearnCompositePathParams
+	LearnCompositePathParams bool `json:"learnCompositePathParams,omitempty"`
+	// see OperationGeneratorConfig.LearnJWTClaims
+	LearnJWTClaims bool `json:"learnJWTClaims,omitempty"`
+	// ProtoDescriptorSets are FileDescriptorSet files (protoc --descriptor_set_out) used for all hosts,
+	// see OperationGeneratorConfig.ProtoDescriptors
+	ProtoDescriptorSets []string `json:"protoDescriptorSets,omitempty"`
+	// durations are in time.ParseDuration format, e.g. "5m"
+	MaxClockSkew        string   `json:"maxClockSkew,omitempty"`
+	DeduplicationWindow string   `json:"deduplicationWindow,omitempty"`
+	InternalCIDRs       []string `json:"internalCIDRs,omitempty"`
+	PartnerCIDRs        []string `json:"partnerCIDRs,omitempty"`
+	SplitSpecsBySource  bool     `json:"splitSpecsBySource,omitempty"`
+	TrackDependencies   bool     `json:"trackDependencies,omitempty"`
+	// ingestion queue, see IngestionConfig
+	QueueSize          int    `json:"queueSize,omitempty"`
+	PerSpecQueueSize   int    `json:"perSpecQueueSize,omitempty"`
+	BackpressurePolicy string `json:"backpressurePolicy,omitempty"`
+	// scanner detection, see ScannerDetectionConfig
+	ScannerMinUniquePaths  int    `json:"scannerMinUniquePaths,omitempty"`
+	ScannerDetectionWindow string `json:"scannerDetectionWindow,omitempty"`
+	ScannerCooldown        string `json:"scannerCooldown,omitempty"`
+	// staleness, see StalenessConfig
+	StaleAfter           string `json:"staleAfter,omitempty"`
+	SilentAfter          string `json:"silentAfter,omitempty"`
+	SilenceCheckInterval string `json:"silenceCheckInterval,omitempty"`
+	// telemetry filter, see FilterConfig
+	PathsToIgnore   []string `json:"pathsToIgnore,omitempty"`
+	MethodsToIgnore []string `json:"methodsToIgnore,omitempty"`
+	// redaction of the telemetries before they are learned, see Config.Redaction. Redaction is enabled by redact or
+	// by any of the redact options, the default sensitive names are used when redactSensitiveNames is not set.
+	Redact               bool     `json:"redact,omitempty"`
+	RedactSensitiveNames []string `json:"redactSensitiveNames,omitempty"`
+	RedactMaxBodySize    int      `json:"redactMaxBodySize,omitempty"`
+	// see Config.MaxPoisonedTelemetries
+	MaxPoisonedTelemetries int `json:"maxPoisonedTelemetries,omitempty"`
+	// see Config.SpecCheckpointInterval, the spec store itself can only be set in code
+	SpecCheckpointInterval string `json:"specCheckpointInterval,omitempty"`
+	// Hosts maps "host" or "host:port" into its options
+	Hosts map[string]HostFileConfig `json:"hosts,omitempty"`
+}
+
+// HostFileConfig overrides the global options of FileConfig for a host, the options that are not set (nil) are
+// inherited from the global options. Lists and maps that are set replace the global ones, e.g. [] learns all headers.
+type HostFileConfig struct {
+	ResponseHeadersToIgnore       []string       `json:"responseHeadersToIgnore,omitempty"`
+	RequestHeadersToIgnore        []string       `json:"requestHeadersToIgnore,omitempty"`
+	MaxBodySizeToLearn            *int           `json:"maxBodySizeToLearn,omitempty"`
+	MaxBodySizeToLearnByMediaType map[string]int `json:"maxBodySizeToLearnByMediaType,omitempty"`
+	SchemaMergeMinEstablishedHits *int           `json:"schemaMergeMinEstablishedHits,omitempty"`
+	SchemaMergeMinOutlierRatio    *float64       `json:"schemaMergeMinOutlierRatio,omitempty"`
+	MaxRetainedSamples            *int           `json:"maxRetainedSamples,omitempty"`
+	CookiesToLearn                []string       `json:"cookiesToLearn,omitempty"`
+	CookiesToIgnore               []string       `json:"cookiesToIgnore,omitempty"`
+	EnumMaxValues                 *int           `json:"enumMaxValues,omitempty"`
+	EnumMinSamples                *int           `json:"enumMinSamples,omitempty"`
+	RequiredPropertyMinRatio      *float64       `json:"requiredPropertyMinRatio,omitempty"`
+	LearnBodyVariants             *bool          `json:"learnBodyVariants,omitempty"`
+	LearnLocalePathParams         *bool          `json:"learnLocalePathParams,omitempty"`
+	LearnCompositePathParams      *bool          `json:"learnCompositePathParams,omitempty"`
+	LearnJWTClaims                *bool          `json:"learnJWTClaims,omitempty"`
+	BasePaths                     []string       `json:"basePaths,omitempty"`
+	PathsToIgnore                 []string       `json:"pathsToIgnore,omitempty"`
+	MethodsToIgnore               []string       `json:"methodsToIgnore,omitempty"`
+	Redact                        *bool          `json:"redact,omitempty"`
+	RedactSensitiveNames          []string       `json:"redactSensitiveNames,omitempty"`
+	RedactMaxBodySize             *int           `json:"redactMaxBodySize,omitempty"`
+}
+
+// LoadConfig loads a YAML or JSON config file. Unknown fields are rejected, missing fields get their defaults.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file (%v): %v", path, err)
+	}
+
+	fileConfig, err := ParseFileConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file (%v): %w", path, err)
+	}
+
+	config, err := fileConfig.ToConfig()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid config file (%v): %w", path, err)
+	}
+
+	return config, nil
+}
+
+// ParseFileConfig parses a YAML or JSON (which is valid YAML) config.
+func ParseFileConfig(data []byte) (*FileConfig, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert yaml to json: %v", err)
+	}
+
+	fileConfig := &FileConfig{}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(fileConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %v", err)
+	}
+
+	return fileConfig, nil
+}
+
+// ToConfig validates the file config and converts it into a Config.
+func (f *FileConfig) ToConfig() (Config, error) {
+	config := Config{
+		OperationGeneratorConfig: _spec.OperationGeneratorConfig{
+			ResponseHeadersToIgnore:       f.ResponseHeadersToIgnore,
+			RequestHeadersToIgnore:        f.RequestHeadersToIgnore,
+			MaxBodySizeToLearn:            f.MaxBodySizeToLearn,
+			MaxBodySizeToLearnByMediaType: f.MaxBodySizeToLearnByMediaType,
+			SchemaMergeMinEstablishedHits: f.SchemaMergeMinEstablishedHits,
+			SchemaMergeMinOutlierRatio:    f.SchemaMergeMinOutlierRatio,
+			MaxRetainedSamples:            f.MaxRetainedSamples,
+			CookiesToLearn:                f.CookiesToLearn,
+			CookiesToIgnore:               f.CookiesToIgnore,
+			EnumMaxValues:                 f.EnumMaxValues,
+			EnumMinSamples:                f.EnumMinSamples,
+			RequiredPropertyMinRatio:      f.RequiredPropertyMinRatio,
+			LearnBodyVariants:             f.LearnBodyVariants,
+			LearnLocalePathParams:         f.LearnLocalePathParams,
+			LearnCompositePathParams:      f.LearnCompositePathParams,
+			LearnJWTClaims:                f.LearnJWTClaims,
+		},
+		MaxClockSkew:       _spec.DefaultMaxClockSkew,
+		SplitSpecsBySource: f.SplitSpecsBySource,
+		TrackDependencies:  f.TrackDependencies,
+		Ingestion: IngestionConfig{
+			QueueSize:        f.QueueSize,
+			PerSpecQueueSize: f.PerSpecQueueSize,
+			Policy:           BackpressurePolicy(f.BackpressurePolicy),
+		},
+		Filter: FilterConfig{
+			PathsToIgnore:   f.PathsToIgnore,
+			MethodsToIgnore: f.MethodsToIgnore,
+		},
+		MaxPoisonedTelemetries: f.MaxPoisonedTelemetries,
+	}
+	if err := config.Ingestion.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid ingestion config: %v", err)
+	}
+	if err := validateOperationGeneratorConfig(config.OperationGeneratorConfig); err != nil {
+		return Config{}, err
+	}
+	if err := config.Filter.validate(); err != nil {
+		return Config{}, err
+	}
+	var err error
+	if config.Redaction, err = createRedactionPolicy(f.Redact, f.RedactSensitiveNames, f.RedactMaxBodySize); err != nil {
+		return Config{}, err
+	}
+	if f.MaxPoisonedTelemetries < 0 {
+		return Config{}, fmt.Errorf("invalid maxPoisonedTelemetries: must not be negative: %v", f.MaxPoisonedTelemetries)
+	}
+	if f.SpecCheckpointInterval != "" {
+		if config.SpecCheckpointInterval, err = parsePositiveDuration(f.SpecCheckpointInterval); err != nil {
+			return Config{}, fmt.Errorf("invalid specCheckpointInterval: %v", err)
+		}
+	}
+
+	var protoDescriptors *_spec.ProtoDescriptorRegistry
+	if len(f.ProtoDescriptorSets) > 0 {
+		if protoDescriptors, err = _spec.LoadProtoDescriptorRegistry(f.ProtoDescriptorSets...); err != nil {
+			return Config{}, fmt.Errorf("invalid protoDescriptorSets: %v", err)
+		}
+		config.OperationGeneratorConfig.ProtoDescriptors = protoDescriptors
+	}
+	if f.MaxClockSkew != "" {
+		if config.MaxClockSkew, err = parsePositiveDuration(f.MaxClockSkew); err != nil {
+			return Config{}, fmt.Errorf("invalid maxClockSkew: %v", err)
+		}
+	}
+	if f.DeduplicationWindow != "" {
+		if config.DeduplicationWindow, err = parsePositiveDuration(f.DeduplicationWindow); err != nil {
+			return Config{}, fmt.Errorf("invalid deduplicationWindow: %v", err)
+		}
+	}
+	if f.ScannerMinUniquePaths < 0 {
+		return Config{}, fmt.Errorf("invalid scannerMinUniquePaths: must not be negative: %v", f.ScannerMinUniquePaths)
+	}
+	config.ScannerDetection.MinUniquePaths = f.ScannerMinUniquePaths
+	if f.ScannerDetectionWindow != "" {
+		if config.ScannerDetection.Window, err = parsePositiveDuration(f.ScannerDetectionWindow); err != nil {
+			return Config{}, fmt.Errorf("invalid scannerDetectionWindow: %v", err)
+		}
+	}
+	if f.ScannerCooldown != "" {
+		if config.ScannerDetection.Cooldown, err = parsePositiveDuration(f.ScannerCooldown); err != nil {
+			return Config{}, fmt.Errorf("invalid scannerCooldown: %v", err)
+		}
+	}
+	if f.StaleAfter != "" {
+		if config.Staleness.StaleAfter, err = parsePositiveDuration(f.StaleAfter); err != nil {
+			return Config{}, fmt.Errorf("invalid staleAfter: %v", err)
+		}
+	}
+	if f.SilentAfter != "" {
+		if config.Staleness.SilentAfter, err = parsePositiveDuration(f.SilentAfter); err != nil {
+			return Config{}, fmt.Errorf("invalid silentAfter: %v", err)
+		}
+	}
+	if f.SilenceCheckInterval != "" {
+		if config.Staleness.CheckInterval, err = parsePositiveDuration(f.SilenceCheckInterval); err != nil {
+			return Config{}, fmt.Errorf("invalid silenceCheckInterval: %v", err)
+		}
+	}
+	if len(f.InternalCIDRs) > 0 || len(f.PartnerCIDRs) > 0 {
+		if config.SourceClassifier, err = NewCIDRSourceClassifier(f.InternalCIDRs, f.PartnerCIDRs); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if len(f.Hosts) > 0 {
+		config.HostConfigs = make(map[string]HostConfig, len(f.Hosts))
+	}
+	for host, hostFileConfig := range f.Hosts {
+		if host == "" {
+			return Config{}, fmt.Errorf("empty host in hosts")
+		}
+		hostConfig, err := hostFileConfig.toHostConfig(f, config)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid host %v: %v", host, err)
+		}
+		config.HostConfigs[host] = hostConfig
+	}
+
+	return config, nil
+}
+
+// toHostConfig validates the host options and returns them with the options that are not set inherited from the
+// global ones, f and config are the global file config and its Config.
+func (h *HostFileConfig) toHostConfig(f *FileConfig, config Config) (HostConfig, error) {
+	hostConfig := HostConfig{
+		OperationGeneratorConfig: h.overrideOperationGeneratorConfig(config.OperationGeneratorConfig),
+		Filter:                   config.Filter,
+	}
+	if err := validateOperationGeneratorConfig(hostConfig.OperationGeneratorConfig); err != nil {
+		return HostConfig{}, err
+	}
+	var err error
+	if hostConfig.BasePaths, err = validateBasePaths(h.BasePaths); err != nil {
+		return HostConfig{}, err
+	}
+
+	if h.PathsToIgnore != nil {
+		hostConfig.Filter.PathsToIgnore = h.PathsToIgnore
+	}
+	if h.MethodsToIgnore != nil {
+		hostConfig.Filter.MethodsToIgnore = h.MethodsToIgnore
+	}
+	if err := hostConfig.Filter.validate(); err != nil {
+		return HostConfig{}, err
+	}
+
+	redact := config.Redaction != nil
+	redactSensitiveNames := f.RedactSensitiveNames
+	redactMaxBodySize := f.RedactMaxBodySize
+	if h.Redact != nil {
+		redact = *h.Redact
+	}
+	if h.RedactSensitiveNames != nil {
+		redactSensitiveNames = h.RedactSensitiveNames
+		redact = true
+	}
+	if h.RedactMaxBodySize != nil {
+		redactMaxBodySize = *h.RedactMaxBodySize
+		redact = true
+	}
+	if redact {
+		if hostConfig.Redaction, err = createRedactionPolicy(redact, redactSensitiveNames, redactMaxBodySize); err != nil {
+			return HostConfig{}, err
+		}
+	}
+
+	return hostConfig, nil
+}
+
+// createRedactionPolicy returns nil when redaction is not enabled, by redact or by any of the redact options.
+func createRedactionPolicy(redact bool, sensitiveNames []string, maxBodySize int) (*_spec.RedactionPolicy, error) {
+	if maxBodySize < 0 {
+		return nil, fmt.Errorf("invalid redactMaxBodySize: must not be negative: %v", maxBodySize)
+	}
+	if !redact && len(sensitiveNames) == 0 && maxBodySize == 0 {
+		return nil, nil
+	}
+	if len(sensitiveNames) == 0 {
+		sensitiveNames = _spec.DefaultRedactionPolicy().SensitiveNames
+	}
+	return &_spec.RedactionPolicy{
+		SensitiveNames: sensitiveNames,
+		MaxBodySize:    maxBodySize,
+	}, nil
+}
+
+// overrideOperationGeneratorConfig returns the global config with the options set for the host.
+func (h *HostFileConfig) overrideOperationGeneratorConfig(config _spec.OperationGeneratorConfig) _spec.OperationGeneratorConfig {
+	if h.ResponseHeadersToIgnore != nil {
+		config.ResponseHeadersToIgnore = h.ResponseHeadersToIgnore
+	}
+	if h.RequestHeadersToIgnore != nil {
+		config.RequestHeadersToIgnore = h.RequestHeadersToIgnore
+	}
+	if h.MaxBodySizeToLearn != nil {
+		config.MaxBodySizeToLearn = *h.MaxBodySizeToLearn
+	}
+	if h.MaxBodySizeToLearnByMediaType != nil {
+		config.MaxBodySizeToLearnByMediaType = h.MaxBodySizeToLearnByMediaType
+	}
+	if h.SchemaMergeMinEstablishedHits != nil {
+		config.SchemaMergeMinEstablishedHits = *h.SchemaMergeMinEstablishedHits
+	}
+	if h.SchemaMergeMinOutlierRatio != nil {
+		config.SchemaMergeMinOutlierRatio = *h.SchemaMergeMinOutlierRatio
+	}
+	if h.MaxRetainedSamples != nil {
+		config.MaxRetainedSamples = *h.MaxRetainedSamples
+	}
+	if h.CookiesToLearn != nil {
+		config.CookiesToLearn = h.CookiesToLearn
+	}
+	if h.CookiesToIgnore != nil {
+		config.CookiesToIgnore = h.CookiesToIgnore
+	}
+	if h.EnumMaxValues != nil {
+		config.EnumMaxValues = *h.EnumMaxValues
+	}
+	if h.EnumMinSamples != nil {
+		config.EnumMinSamples = *h.EnumMinSamples
+	}
+	if h.RequiredPropertyMinRatio != nil {
+		config.RequiredPropertyMinRatio = *h.RequiredPropertyMinRatio
+	}
+	if h.LearnBodyVariants != nil {
+		config.LearnBodyVariants = *h.LearnBodyVariants
+	}
+	if h.LearnLocalePathParams != nil {
+		config.LearnLocalePathParams = *h.LearnLocalePathParams
+	}
+	if h.LearnCompositePathParams != nil {
+		config.LearnCompositePathParams = *h.LearnCompositePathParams
+	}
+	if h.LearnJWTClaims != nil {
+		config.LearnJWTClaims = *h.LearnJWTClaims
+	}
+	return config
+}
+
+// validateOperationGeneratorConfig returns an error naming the first invalid option of config.
+func validateOperationGeneratorConfig(config _spec.OperationGeneratorConfig) error {
+	if err := validateMaxBodySizes(config.MaxBodySizeToLearn, config.MaxBodySizeToLearnByMediaType); err != nil {
+		return err
+	}
+	if err := validateSchemaMergeWeighting(config.SchemaMergeMinEstablishedHits, config.SchemaMergeMinOutlierRatio); err != nil {
+		return err
+	}
+	if config.MaxRetainedSamples < 0 {
+		return fmt.Errorf("invalid maxRetainedSamples: must not be negative: %v", config.MaxRetainedSamples)
+	}
+	if config.EnumMaxValues < 0 {
+		return fmt.Errorf("invalid enumMaxValues: must not be negative: %v", config.EnumMaxValues)
+	}
+	if config.EnumMinSamples < 0 {
+		return fmt.Errorf("invalid enumMinSamples: must not be negative: %v", config.EnumMinSamples)
+	}
+	if config.RequiredPropertyMinRatio < 0 || config.RequiredPropertyMinRatio > 1 {
+		return fmt.Errorf("invalid requiredPropertyMinRatio: must be between 0 and 1: %v", config.RequiredPropertyMinRatio)
+	}
+	return nil
+}
+
+func validateMaxBodySizes(maxBodySize int, maxBodySizeByMediaType map[string]int) error {
+	if maxBodySize < 0 {
+		return fmt.Errorf("invalid maxBodySizeToLearn: must not be negative: %v", maxBodySize)
+	}
+	for mediaType, size := range maxBodySizeByMediaType {
+		if size < 0 {
+			return fmt.Errorf("invalid maxBodySizeToLearnByMediaType: must not be negative: %v=%v", mediaType, size)
+		}
+	}
+	return nil
+}
+
+func parsePositiveDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive: %v", s)
+	}
+	return d, nil
+}
+
+// getOperationGeneratorConfig returns the operation generator config of the host config matching "host:port",
+// or "host", falling back to the global config.
+func (s *Speculator) getOperationGeneratorConfig(host, port string) _spec.OperationGeneratorConfig {
+	if hostConfig, ok := s.getHostConfig(host, port); ok {
+		return hostConfig.OperationGeneratorConfig
+	}
+	return s.config.OperationGeneratorConfig
+}
+
+// getHostConfig returns the host config matching "host:port", or "host".
+func (s *Speculator) getHostConfig(host, port string) (HostConfig, bool) {
+	if hostConfig, ok := s.config.HostConfigs[string(GetSpecKey(host, port))]; ok {
+		return hostConfig, true
+	}
+	hostConfig, ok := s.config.HostConfigs[host]
+	return hostConfig, ok
+}
+
+func validateSchemaMergeWeighting(minEstablishedHits int, minOutlierRatio float64) error {
+	if minEstablishedHits < 0 {
+		return fmt.Errorf("invalid schemaMergeMinEstablishedHits: must not be negative: %v", minEstablishedHits)
+	}
+	if minOutlierRatio < 0 || minOutlierRatio > 1 {
+		return fmt.Errorf("invalid schemaMergeMinOutlierRatio: must be between 0 and 1: %v", minOutlierRatio)
+	}
+	return nil
+}
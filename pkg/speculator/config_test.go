@@ -0,0 +1,440 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		check   func(t *testing.T, config Config)
+		wantErr string
+	}{
+		{
+			name: "yaml",
+			data: `
+responseHeadersToIgnore: [date]
+maxClockSkew: 1m
+deduplicationWindow: 10m
+internalCIDRs: [10.0.0.0/8]
+splitSpecsBySource: true
+trackDependencies: true
+hosts:
+  "api.example.com:443":
+    requestHeadersToIgnore: [x-trace-id]
+`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.OperationGeneratorConfig.ResponseHeadersToIgnore, []string{"date"})
+				assert.Equal(t, config.MaxClockSkew, time.Minute)
+				assert.Equal(t, config.DeduplicationWindow, 10*time.Minute)
+				assert.Assert(t, config.SourceClassifier != nil)
+				assert.Equal(t, config.SplitSpecsBySource, true)
+				assert.Equal(t, config.TrackDependencies, true)
+				assert.DeepEqual(t, config.HostConfigs["api.example.com:443"].OperationGeneratorConfig.RequestHeadersToIgnore, []string{"x-trace-id"})
+			},
+		},
+		{
+			name: "json with defaults",
+			data: `{"requestHeadersToIgnore": ["cookie"]}`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.OperationGeneratorConfig.RequestHeadersToIgnore, []string{"cookie"})
+				assert.Equal(t, config.MaxClockSkew, spec.DefaultMaxClockSkew)
+				assert.Equal(t, config.DeduplicationWindow, time.Duration(0))
+				assert.Assert(t, config.SourceClassifier == nil)
+				assert.Assert(t, config.HostConfigs == nil)
+			},
+		},
+		{
+			name: "cookies",
+			data: `
+cookiesToLearn: [session_id, theme]
+hosts:
+  api.example.com:
+    cookiesToIgnore: [tracking]
+`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.OperationGeneratorConfig.CookiesToLearn, []string{"session_id", "theme"})
+				assert.DeepEqual(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig.CookiesToIgnore, []string{"tracking"})
+			},
+		},
+		{
+			name: "enums",
+			data: `
+enumMaxValues: 10
+enumMinSamples: 5
+hosts:
+  api.example.com:
+    enumMaxValues: 3
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.EnumMaxValues, 10)
+				assert.Equal(t, config.OperationGeneratorConfig.EnumMinSamples, 5)
+				assert.Equal(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig.EnumMaxValues, 3)
+			},
+		},
+		{
+			name: "required properties",
+			data: `
+requiredPropertyMinRatio: 0.95
+hosts:
+  api.example.com:
+    requiredPropertyMinRatio: 1
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.RequiredPropertyMinRatio, 0.95)
+				assert.Equal(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig.RequiredPropertyMinRatio, 1.0)
+			},
+		},
+		{
+			name: "body variants",
+			data: `
+learnBodyVariants: true
+hosts:
+  api.example.com:
+    learnBodyVariants: false
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.LearnBodyVariants, true)
+				assert.Equal(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig.LearnBodyVariants, false)
+			},
+		},
+		{
+			name: "base paths",
+			data: `
+requestHeadersToIgnore: [x-trace-id]
+enumMaxValues: 10
+learnBodyVariants: true
+hosts:
+  api.example.com:
+    basePaths: [/billing/, /auth]
+`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.HostConfigs["api.example.com"].BasePaths, []string{"/billing", "/auth"})
+				// a host that only declares base paths keeps the global options
+				assert.DeepEqual(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig, config.OperationGeneratorConfig)
+			},
+		},
+		{
+			name: "invalid base path",
+			data: `
+hosts:
+  api.example.com:
+    basePaths: ["/api/{id}"]
+`,
+			wantErr: "must not have path params",
+		},
+		{
+			name:    "unknown field",
+			data:    `unknownField: 1`,
+			wantErr: "unknown field",
+		},
+		{
+			name: "locale path params",
+			data: `
+learnLocalePathParams: true
+hosts:
+  api.example.com:
+    learnLocalePathParams: false
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.LearnLocalePathParams, true)
+				assert.Equal(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig.LearnLocalePathParams, false)
+			},
+		},
+		{
+			name: "composite path params",
+			data: `
+learnCompositePathParams: true
+hosts:
+  api.example.com:
+    learnCompositePathParams: true
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.LearnCompositePathParams, true)
+				assert.Equal(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig.LearnCompositePathParams, true)
+			},
+		},
+		{
+			name: "jwt claims",
+			data: `
+learnJWTClaims: true
+hosts:
+  api.example.com:
+    learnJWTClaims: false
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.LearnJWTClaims, true)
+				assert.Equal(t, config.HostConfigs["api.example.com"].OperationGeneratorConfig.LearnJWTClaims, false)
+			},
+		},
+		{
+			name:    "missing proto descriptor set",
+			data:    `protoDescriptorSets: [/does/not/exist.pb]`,
+			wantErr: "invalid protoDescriptorSets",
+		},
+		{
+			name:    "invalid duration",
+			data:    `maxClockSkew: 5 minutes`,
+			wantErr: "invalid maxClockSkew",
+		},
+		{
+			name:    "negative duration",
+			data:    `deduplicationWindow: -1m`,
+			wantErr: "invalid deduplicationWindow",
+		},
+		{
+			name: "ingestion",
+			data: `{"queueSize": 100, "perSpecQueueSize": 10, "backpressurePolicy": "drop-oldest"}`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.Ingestion, IngestionConfig{
+					QueueSize:        100,
+					PerSpecQueueSize: 10,
+					Policy:           BackpressureDropOldest,
+				})
+			},
+		},
+		{
+			name: "scanner detection",
+			data: `{"scannerMinUniquePaths": 20, "scannerCooldown": "1h"}`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.ScannerDetection, ScannerDetectionConfig{
+					MinUniquePaths: 20,
+					Cooldown:       time.Hour,
+				})
+			},
+		},
+		{
+			name: "staleness",
+			data: `{"staleAfter": "24h", "silentAfter": "10m", "silenceCheckInterval": "1m"}`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.Staleness, StalenessConfig{
+					StaleAfter:    24 * time.Hour,
+					SilentAfter:   10 * time.Minute,
+					CheckInterval: time.Minute,
+				})
+			},
+		},
+		{
+			name:    "invalid silent after",
+			data:    `silentAfter: -1m`,
+			wantErr: "invalid silentAfter",
+		},
+		{
+			name:    "invalid scanner detection window",
+			data:    `scannerDetectionWindow: 0s`,
+			wantErr: "invalid scannerDetectionWindow",
+		},
+		{
+			name:    "invalid backpressure policy",
+			data:    `backpressurePolicy: drop-all`,
+			wantErr: "unknown backpressure policy",
+		},
+		{
+			name: "max body sizes",
+			data: `
+maxBodySizeToLearn: 1048576
+maxBodySizeToLearnByMediaType:
+  application/json: 4194304
+hosts:
+  export.example.com:
+    maxBodySizeToLearn: 1024
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.MaxBodySizeToLearn, 1048576)
+				assert.DeepEqual(t, config.OperationGeneratorConfig.MaxBodySizeToLearnByMediaType, map[string]int{"application/json": 4194304})
+				assert.Equal(t, config.HostConfigs["export.example.com"].OperationGeneratorConfig.MaxBodySizeToLearn, 1024)
+			},
+		},
+		{
+			name: "host inherits the global options",
+			data: `
+requestHeadersToIgnore: [x-trace-id]
+responseHeadersToIgnore: [date]
+maxBodySizeToLearn: 1048576
+enumMaxValues: 10
+requiredPropertyMinRatio: 0.95
+cookiesToIgnore: [tracking]
+learnBodyVariants: true
+learnJWTClaims: true
+hosts:
+  api.example.com:
+    requestHeadersToIgnore: [x-request-id]
+    enumMaxValues: 0
+    learnJWTClaims: false
+`,
+			check: func(t *testing.T, config Config) {
+				hostConfig := config.HostConfigs["api.example.com"].OperationGeneratorConfig
+				assert.DeepEqual(t, hostConfig.RequestHeadersToIgnore, []string{"x-request-id"})
+				// explicit zero values override the global options
+				assert.Equal(t, hostConfig.EnumMaxValues, 0)
+				assert.Equal(t, hostConfig.LearnJWTClaims, false)
+				// the rest is inherited
+				assert.DeepEqual(t, hostConfig.ResponseHeadersToIgnore, []string{"date"})
+				assert.Equal(t, hostConfig.MaxBodySizeToLearn, 1048576)
+				assert.Equal(t, hostConfig.RequiredPropertyMinRatio, 0.95)
+				assert.DeepEqual(t, hostConfig.CookiesToIgnore, []string{"tracking"})
+				assert.Equal(t, hostConfig.LearnBodyVariants, true)
+			},
+		},
+		{
+			name:    "invalid inherited host options",
+			data:    `{schemaMergeMinEstablishedHits: 10, hosts: {api.example.com: {schemaMergeMinOutlierRatio: 2}}}`,
+			wantErr: "invalid host api.example.com",
+		},
+		{
+			name:    "negative max body size",
+			data:    `maxBodySizeToLearnByMediaType: {application/json: -1}`,
+			wantErr: "invalid maxBodySizeToLearnByMediaType",
+		},
+		{
+			name: "schema merge weighting",
+			data: `
+schemaMergeMinEstablishedHits: 1000
+schemaMergeMinOutlierRatio: 0.05
+`,
+			check: func(t *testing.T, config Config) {
+				assert.Equal(t, config.OperationGeneratorConfig.SchemaMergeMinEstablishedHits, 1000)
+				assert.Equal(t, config.OperationGeneratorConfig.SchemaMergeMinOutlierRatio, 0.05)
+			},
+		},
+		{
+			name:    "invalid schema merge outlier ratio",
+			data:    `schemaMergeMinOutlierRatio: 2`,
+			wantErr: "invalid schemaMergeMinOutlierRatio",
+		},
+		{
+			name:    "negative max retained samples",
+			data:    `hosts: {api.example.com: {maxRetainedSamples: -1}}`,
+			wantErr: "invalid maxRetainedSamples",
+		},
+		{
+			name:    "invalid cidr",
+			data:    `partnerCIDRs: [10.0.0.1]`,
+			wantErr: "invalid partner CIDRs",
+		},
+		{
+			name:    "negative enum max values",
+			data:    `enumMaxValues: -1`,
+			wantErr: "invalid enumMaxValues",
+		},
+		{
+			name:    "negative host enum min samples",
+			data:    `hosts: {api.example.com: {enumMinSamples: -1}}`,
+			wantErr: "invalid host api.example.com: invalid enumMinSamples",
+		},
+		{
+			name:    "invalid required property min ratio",
+			data:    `requiredPropertyMinRatio: 1.5`,
+			wantErr: "invalid requiredPropertyMinRatio",
+		},
+		{
+			name: "filter and redaction",
+			data: `
+pathsToIgnore: [/health]
+methodsToIgnore: [options]
+redact: true
+maxPoisonedTelemetries: 5
+specCheckpointInterval: 1m
+hosts:
+  api.example.com:
+    pathsToIgnore: [/metrics]
+    redactSensitiveNames: [ssn]
+  internal.example.com:
+    redact: false
+`,
+			check: func(t *testing.T, config Config) {
+				assert.DeepEqual(t, config.Filter, FilterConfig{PathsToIgnore: []string{"/health"}, MethodsToIgnore: []string{"options"}})
+				assert.DeepEqual(t, config.Redaction, &spec.RedactionPolicy{SensitiveNames: spec.DefaultRedactionPolicy().SensitiveNames})
+				assert.Equal(t, config.MaxPoisonedTelemetries, 5)
+				assert.Equal(t, config.SpecCheckpointInterval, time.Minute)
+				hostConfig := config.HostConfigs["api.example.com"]
+				assert.DeepEqual(t, hostConfig.Filter, FilterConfig{PathsToIgnore: []string{"/metrics"}, MethodsToIgnore: []string{"options"}})
+				assert.DeepEqual(t, hostConfig.Redaction, &spec.RedactionPolicy{SensitiveNames: []string{"ssn"}})
+				assert.Assert(t, config.HostConfigs["internal.example.com"].Redaction == nil)
+			},
+		},
+		{
+			name:    "invalid paths to ignore",
+			data:    `hosts: {api.example.com: {pathsToIgnore: [health]}}`,
+			wantErr: "invalid host api.example.com: invalid pathsToIgnore",
+		},
+		{
+			name:    "invalid methods to ignore",
+			data:    `methodsToIgnore: [FETCH]`,
+			wantErr: "invalid methodsToIgnore",
+		},
+		{
+			name:    "negative redact max body size",
+			data:    `redactMaxBodySize: -1`,
+			wantErr: "invalid redactMaxBodySize",
+		},
+		{
+			name:    "negative max poisoned telemetries",
+			data:    `maxPoisonedTelemetries: -1`,
+			wantErr: "invalid maxPoisonedTelemetries",
+		},
+		{
+			name:    "invalid spec checkpoint interval",
+			data:    `specCheckpointInterval: 0s`,
+			wantErr: "invalid specCheckpointInterval",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/tmp/" + uuid.New().String() + "config.yaml"
+			assert.NilError(t, ioutil.WriteFile(path, []byte(tt.data), 0600))
+			defer func() {
+				_ = os.Remove(path)
+			}()
+
+			config, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			tt.check(t, config)
+		})
+	}
+}
+
+func TestSpeculator_getOperationGeneratorConfig(t *testing.T) {
+	global := spec.OperationGeneratorConfig{ResponseHeadersToIgnore: []string{"global"}}
+	hostPort := spec.OperationGeneratorConfig{ResponseHeadersToIgnore: []string{"host-port"}}
+	host := spec.OperationGeneratorConfig{ResponseHeadersToIgnore: []string{"host"}}
+	s := CreateSpeculator(Config{
+		OperationGeneratorConfig: global,
+		HostConfigs: map[string]HostConfig{
+			"a:80": {OperationGeneratorConfig: hostPort},
+			"a":    {OperationGeneratorConfig: host},
+		},
+	})
+
+	assert.DeepEqual(t, s.getOperationGeneratorConfig("a", "80"), hostPort)
+	assert.DeepEqual(t, s.getOperationGeneratorConfig("a", "8080"), host)
+	assert.DeepEqual(t, s.getOperationGeneratorConfig("b", "80"), global)
+}
@@ -0,0 +1,80 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import "time"
+
+type seenRequestID struct {
+	id     string
+	seenAt time.Time
+}
+
+// requestIDCache remembers the request IDs seen in the last window.
+type requestIDCache struct {
+	window time.Duration
+	seen   map[string]time.Time
+	// request IDs ordered by the time they were first seen, used to expire old IDs
+	order []seenRequestID
+}
+
+func newRequestIDCache(window time.Duration) *requestIDCache {
+	return &requestIDCache{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// has returns true if id was added in the window preceding now.
+func (c *requestIDCache) has(id string, now time.Time) bool {
+	c.expire(now)
+
+	_, ok := c.seen[id]
+	return ok
+}
+
+func (c *requestIDCache) add(id string, now time.Time) {
+	if _, ok := c.seen[id]; ok {
+		return
+	}
+	c.seen[id] = now
+	c.order = append(c.order, seenRequestID{id: id, seenAt: now})
+}
+
+func (c *requestIDCache) expire(now time.Time) {
+	expired := 0
+	for _, entry := range c.order {
+		if now.Sub(entry.seenAt) < c.window {
+			break
+		}
+		delete(c.seen, entry.id)
+		expired++
+	}
+	c.order = c.order[expired:]
+}
+
+func (s *Speculator) hasRequestID(id string) bool {
+	s.specsMapLock.Lock()
+	defer s.specsMapLock.Unlock()
+
+	return s.requestIDs.has(id, time.Now())
+}
+
+func (s *Speculator) addRequestID(id string) {
+	s.specsMapLock.Lock()
+	defer s.specsMapLock.Unlock()
+
+	s.requestIDs.add(id, time.Now())
+}
@@ -0,0 +1,97 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestRequestIDCache(t *testing.T) {
+	start := time.Unix(1000, 0)
+	c := newRequestIDCache(time.Minute)
+
+	assert.Assert(t, !c.has("1", start))
+	c.add("1", start)
+	c.add("2", start.Add(30*time.Second))
+
+	assert.Assert(t, c.has("1", start.Add(59*time.Second)))
+	assert.Assert(t, c.has("2", start.Add(59*time.Second)))
+
+	// "1" expired, "2" is still in the window
+	assert.Assert(t, !c.has("1", start.Add(time.Minute)))
+	assert.Assert(t, c.has("2", start.Add(time.Minute)))
+	assert.Equal(t, len(c.order), 1)
+	assert.Equal(t, len(c.seen), 1)
+}
+
+func createTelemetry(reqID string) *spec.Telemetry {
+	return &spec.Telemetry{
+		DestinationAddress: "10.0.0.1:80",
+		RequestID:          reqID,
+		Request: &spec.Request{
+			Method: "GET",
+			Path:   "/api",
+			Host:   "host",
+			Common: &spec.Common{},
+		},
+		Response: &spec.Response{
+			StatusCode: "200",
+			Common:     &spec.Common{},
+		},
+	}
+}
+
+func TestSpeculator_LearnTelemetry_Deduplication(t *testing.T) {
+	tests := []struct {
+		name                string
+		deduplicationWindow time.Duration
+		requestIDs          []string
+		wantTelemetryCount  int
+	}{
+		{
+			name:                "duplicates are ignored",
+			deduplicationWindow: time.Minute,
+			requestIDs:          []string{"1", "2", "1", "1"},
+			wantTelemetryCount:  2,
+		},
+		{
+			name:                "telemetries without request id are not deduplicated",
+			deduplicationWindow: time.Minute,
+			requestIDs:          []string{"", ""},
+			wantTelemetryCount:  2,
+		},
+		{
+			name:               "deduplication disabled",
+			requestIDs:         []string{"1", "1"},
+			wantTelemetryCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := CreateSpeculator(Config{DeduplicationWindow: tt.deduplicationWindow})
+			for _, id := range tt.requestIDs {
+				assert.NilError(t, s.LearnTelemetry(createTelemetry(id)))
+			}
+			stats := s.Specs[GetSpecKey("host", "80")].LearningStats
+			assert.Equal(t, stats.TelemetryCount, tt.wantTelemetryCount)
+		})
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	// the amount of caller and service pairs tracked, the calls of new pairs are not recorded once reached
+	maxDependencyEdges = 10000
+	// the amount of operations tracked per caller and service pair, calls of other operations are only counted
+	maxDependencyEdgeOperations = 100
+	// the amount of service destination IPs tracked to resolve callers into services
+	maxDependencyServiceIPs = 10000
+)
+
+type DependencyGraphFormat string
+
+const (
+	// DependencyGraphFormatDOT a Graphviz DOT digraph
+	DependencyGraphFormatDOT DependencyGraphFormat = "dot"
+	// DependencyGraphFormatJSON a DependencyGraph in JSON
+	DependencyGraphFormatJSON DependencyGraphFormat = "json"
+)
+
+// DependencyGraph is the graph of the calls between callers and the learned services, see Config.TrackDependencies.
+type DependencyGraph struct {
+	// Nodes are sorted by ID
+	Nodes []DependencyNode `json:"nodes"`
+	// Edges are sorted by source and destination
+	Edges []DependencyEdge `json:"edges"`
+}
+
+// DependencyNode is a caller or a service of the dependency graph.
+type DependencyNode struct {
+	// ID is the spec key of a service, or the source IP of a caller that is not a learned service
+	ID string `json:"id"`
+	// Service is true if the node is a learned service, i.e. a spec
+	Service bool `json:"service"`
+}
+
+// DependencyEdge is the calls of a caller to a service.
+type DependencyEdge struct {
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Calls       int       `json:"calls"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+	// Operations are the operations invoked, sorted by path and method
+	Operations []DependencyOperation `json:"operations"`
+}
+
+// DependencyOperation is an operation of a service invoked by a caller.
+type DependencyOperation struct {
+	Method string `json:"method"`
+	// Path is the approved (parameterized) path of the operation, or its learned path if it was not approved yet
+	Path  string `json:"path"`
+	Calls int    `json:"calls"`
+}
+
+// dependencyGraph records the calls of learned telemetries by their source IP and the spec key of their service.
+type dependencyGraph struct {
+	lock  sync.Mutex
+	edges map[dependencyEdgeKey]*dependencyEdgeStats
+	// serviceIPs are the spec keys by the destination IPs of the learned telemetries, a caller whose source IP
+	// is the IP of a single service is resolved into the service
+	serviceIPs map[string]map[SpecKey]bool
+}
+
+type dependencyEdgeKey struct {
+	sourceIP string
+	specKey  SpecKey
+}
+
+type dependencyOperationKey struct {
+	method string
+	path   string
+}
+
+type dependencyEdgeStats struct {
+	calls      int
+	firstSeen  time.Time
+	lastSeen   time.Time
+	operations map[dependencyOperationKey]int
+}
+
+// record records a learned call of telemetry to the operation of method and path of the service of specKey.
+// Telemetries without a source IP are not recorded.
+func (g *dependencyGraph) record(telemetry *_spec.Telemetry, specKey SpecKey, method, path string) {
+	sourceIP := getSourceIP(telemetry.SourceAddress)
+	if sourceIP == nil {
+		return
+	}
+	destinationIP := getSourceIP(telemetry.DestinationAddress)
+	seen := telemetry.Timestamp
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if destinationIP != nil {
+		g.addServiceIP(destinationIP.String(), specKey)
+	}
+	key := dependencyEdgeKey{sourceIP: sourceIP.String(), specKey: specKey}
+	edge, ok := g.edges[key]
+	if !ok {
+		if len(g.edges) >= maxDependencyEdges {
+			return
+		}
+		if g.edges == nil {
+			g.edges = make(map[dependencyEdgeKey]*dependencyEdgeStats)
+		}
+		edge = &dependencyEdgeStats{
+			firstSeen:  seen,
+			lastSeen:   seen,
+			operations: make(map[dependencyOperationKey]int),
+		}
+		g.edges[key] = edge
+	}
+	edge.calls++
+	if seen.Before(edge.firstSeen) {
+		edge.firstSeen = seen
+	}
+	if seen.After(edge.lastSeen) {
+		edge.lastSeen = seen
+	}
+	operationKey := dependencyOperationKey{method: method, path: path}
+	if _, ok := edge.operations[operationKey]; ok || len(edge.operations) < maxDependencyEdgeOperations {
+		edge.operations[operationKey]++
+	}
+}
+
+func (g *dependencyGraph) addServiceIP(ip string, specKey SpecKey) {
+	specKeys, ok := g.serviceIPs[ip]
+	if !ok {
+		if len(g.serviceIPs) >= maxDependencyServiceIPs {
+			return
+		}
+		if g.serviceIPs == nil {
+			g.serviceIPs = make(map[string]map[SpecKey]bool)
+		}
+		specKeys = make(map[SpecKey]bool)
+		g.serviceIPs[ip] = specKeys
+	}
+	specKeys[specKey] = true
+}
+
+// getNodeID returns the spec key of the single service of ip, or ip if it is not the IP of a single service.
+func (g *dependencyGraph) getNodeID(ip string) (id string, service bool) {
+	if specKeys := g.serviceIPs[ip]; len(specKeys) == 1 {
+		for specKey := range specKeys {
+			return string(specKey), true
+		}
+	}
+	return ip, false
+}
+
+// snapshot returns the graph, merging the edges of the callers that resolve into the same node.
+func (g *dependencyGraph) snapshot() *DependencyGraph {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	nodes := make(map[string]bool)
+	type edgeKey struct{ source, destination string }
+	edges := make(map[edgeKey]*DependencyEdge)
+	operations := make(map[edgeKey]map[dependencyOperationKey]int)
+	for key, stats := range g.edges {
+		sourceID, sourceService := g.getNodeID(key.sourceIP)
+		nodes[sourceID] = nodes[sourceID] || sourceService
+		nodes[string(key.specKey)] = true
+
+		k := edgeKey{source: sourceID, destination: string(key.specKey)}
+		edge, ok := edges[k]
+		if !ok {
+			edge = &DependencyEdge{
+				Source:      k.source,
+				Destination: k.destination,
+				FirstSeen:   stats.firstSeen,
+				LastSeen:    stats.lastSeen,
+			}
+			edges[k] = edge
+			operations[k] = make(map[dependencyOperationKey]int)
+		}
+		edge.Calls += stats.calls
+		if stats.firstSeen.Before(edge.FirstSeen) {
+			edge.FirstSeen = stats.firstSeen
+		}
+		if stats.lastSeen.After(edge.LastSeen) {
+			edge.LastSeen = stats.lastSeen
+		}
+		for operationKey, calls := range stats.operations {
+			operations[k][operationKey] += calls
+		}
+	}
+
+	ret := &DependencyGraph{
+		Nodes: make([]DependencyNode, 0, len(nodes)),
+		Edges: make([]DependencyEdge, 0, len(edges)),
+	}
+	for id, service := range nodes {
+		ret.Nodes = append(ret.Nodes, DependencyNode{ID: id, Service: service})
+	}
+	sort.Slice(ret.Nodes, func(i, j int) bool {
+		return ret.Nodes[i].ID < ret.Nodes[j].ID
+	})
+	for k, edge := range edges {
+		for operationKey, calls := range operations[k] {
+			edge.Operations = append(edge.Operations, DependencyOperation{
+				Method: operationKey.method,
+				Path:   operationKey.path,
+				Calls:  calls,
+			})
+		}
+		sort.Slice(edge.Operations, func(i, j int) bool {
+			if edge.Operations[i].Path != edge.Operations[j].Path {
+				return edge.Operations[i].Path < edge.Operations[j].Path
+			}
+			return edge.Operations[i].Method < edge.Operations[j].Method
+		})
+		ret.Edges = append(ret.Edges, *edge)
+	}
+	sort.Slice(ret.Edges, func(i, j int) bool {
+		if ret.Edges[i].Source != ret.Edges[j].Source {
+			return ret.Edges[i].Source < ret.Edges[j].Source
+		}
+		return ret.Edges[i].Destination < ret.Edges[j].Destination
+	})
+
+	return ret
+}
+
+// recordDependency records the learned call of telemetry to the spec of specKey when Config.TrackDependencies is set.
+func (s *Speculator) recordDependency(telemetry *_spec.Telemetry, specKey SpecKey, spec *_spec.Spec) {
+	if !s.config.TrackDependencies {
+		return
+	}
+	method, err := _spec.NormalizeMethod(telemetry.Request.Method)
+	if err != nil {
+		return
+	}
+	path, _ := _spec.GetPathAndQuery(telemetry.Request.Path)
+	if match, ok := spec.MatchPath(method, path); ok {
+		path = match.Path
+	}
+	s.dependencies.record(telemetry, specKey, method, path)
+}
+
+// GetDependencyGraph returns the graph of the calls of the learned telemetries between their callers and services,
+// recorded when Config.TrackDependencies is set. Callers are identified by the IP of the telemetry SourceAddress,
+// and are resolved into a service when it is the destination IP of the telemetries of a single spec.
+// The graph is not encoded part of the state.
+func (s *Speculator) GetDependencyGraph() *DependencyGraph {
+	return s.dependencies.snapshot()
+}
+
+// ExportDependencyGraph writes the dependency graph of GetDependencyGraph in format.
+func (s *Speculator) ExportDependencyGraph(w io.Writer, format DependencyGraphFormat) error {
+	graph := s.GetDependencyGraph()
+	switch format {
+	case DependencyGraphFormatDOT:
+		return graph.WriteDOT(w)
+	case DependencyGraphFormatJSON:
+		if err := json.NewEncoder(w).Encode(graph); err != nil {
+			return fmt.Errorf("failed to write JSON graph: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown dependency graph format: %v", format)
+	}
+}
+
+// WriteDOT writes the graph as a Graphviz DOT digraph, the services are boxes and each edge is labeled with the
+// operations it invoked.
+func (g *DependencyGraph) WriteDOT(w io.Writer) error {
+	b := &strings.Builder{}
+	b.WriteString("digraph \"dependencies\" {\n")
+	b.WriteString("\trankdir=LR;\n")
+	nodeIDs := make(map[string]string, len(g.Nodes))
+	for i, node := range g.Nodes {
+		id := "n" + strconv.Itoa(i)
+		nodeIDs[node.ID] = id
+		attributes := []string{"label=" + strconv.Quote(node.ID)}
+		if node.Service {
+			attributes = append(attributes, "shape=box")
+		}
+		fmt.Fprintf(b, "\t%v [%v];\n", id, strings.Join(attributes, ", "))
+	}
+	for _, edge := range g.Edges {
+		labels := make([]string, 0, len(edge.Operations))
+		for _, operation := range edge.Operations {
+			labels = append(labels, fmt.Sprintf("%v %v (%v)", operation.Method, operation.Path, operation.Calls))
+		}
+		fmt.Fprintf(b, "\t%v -> %v [label=%v, tooltip=%v];\n", nodeIDs[edge.Source], nodeIDs[edge.Destination],
+			strconv.Quote(strings.Join(labels, "\n")), strconv.Quote(fmt.Sprintf("%v calls", edge.Calls)))
+	}
+	b.WriteString("}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write DOT: %v", err)
+	}
+	return nil
+}
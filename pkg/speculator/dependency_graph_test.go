@@ -0,0 +1,132 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func createDependencyTelemetry(source, destination, host, method, path string, timestamp time.Time) *spec.Telemetry {
+	telemetry := createTelemetry("")
+	telemetry.SourceAddress = source
+	telemetry.DestinationAddress = destination
+	telemetry.Request.Host = host
+	telemetry.Request.Method = method
+	telemetry.Request.Path = path
+	telemetry.Timestamp = timestamp
+	return telemetry
+}
+
+func TestSpeculator_GetDependencyGraph(t *testing.T) {
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	s := CreateSpeculator(Config{TrackDependencies: true})
+	telemetries := []*spec.Telemetry{
+		createDependencyTelemetry("1.2.3.4:5000", "10.0.0.1:80", "users", http.MethodGet, "/api?id=1", start),
+		createDependencyTelemetry("1.2.3.4:5001", "10.0.0.1:80", "users", http.MethodGet, "/api?id=2", start.Add(time.Minute)),
+		createDependencyTelemetry("1.2.3.4:5002", "10.0.0.2:80", "orders", http.MethodPost, "/orders", start.Add(2*time.Minute)),
+		// the caller is the orders service
+		createDependencyTelemetry("10.0.0.2:6000", "10.0.0.1:80", "users", http.MethodPost, "/api", start.Add(3*time.Minute)),
+		// no source to record
+		createDependencyTelemetry("", "10.0.0.1:80", "users", http.MethodGet, "/api", start),
+	}
+	for _, telemetry := range telemetries {
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	assert.DeepEqual(t, s.GetDependencyGraph(), &DependencyGraph{
+		Nodes: []DependencyNode{
+			{ID: "1.2.3.4"},
+			{ID: "orders:80", Service: true},
+			{ID: "users:80", Service: true},
+		},
+		Edges: []DependencyEdge{
+			{
+				Source:      "1.2.3.4",
+				Destination: "orders:80",
+				Calls:       1,
+				FirstSeen:   start.Add(2 * time.Minute),
+				LastSeen:    start.Add(2 * time.Minute),
+				Operations:  []DependencyOperation{{Method: http.MethodPost, Path: "/orders", Calls: 1}},
+			},
+			{
+				Source:      "1.2.3.4",
+				Destination: "users:80",
+				Calls:       2,
+				FirstSeen:   start,
+				LastSeen:    start.Add(time.Minute),
+				Operations:  []DependencyOperation{{Method: http.MethodGet, Path: "/api", Calls: 2}},
+			},
+			{
+				Source:      "orders:80",
+				Destination: "users:80",
+				Calls:       1,
+				FirstSeen:   start.Add(3 * time.Minute),
+				LastSeen:    start.Add(3 * time.Minute),
+				Operations:  []DependencyOperation{{Method: http.MethodPost, Path: "/api", Calls: 1}},
+			},
+		},
+	})
+
+	b := &strings.Builder{}
+	assert.NilError(t, s.ExportDependencyGraph(b, DependencyGraphFormatDOT))
+	assert.Equal(t, b.String(), `digraph "dependencies" {
+	rankdir=LR;
+	n0 [label="1.2.3.4"];
+	n1 [label="orders:80", shape=box];
+	n2 [label="users:80", shape=box];
+	n0 -> n1 [label="POST /orders (1)", tooltip="1 calls"];
+	n0 -> n2 [label="GET /api (2)", tooltip="2 calls"];
+	n1 -> n2 [label="POST /api (1)", tooltip="1 calls"];
+}
+`)
+
+	jsonB := &bytes.Buffer{}
+	assert.NilError(t, s.ExportDependencyGraph(jsonB, DependencyGraphFormatJSON))
+	graph := &DependencyGraph{}
+	assert.NilError(t, json.Unmarshal(jsonB.Bytes(), graph))
+	assert.DeepEqual(t, graph, s.GetDependencyGraph())
+
+	assert.ErrorContains(t, s.ExportDependencyGraph(b, "svg"), "unknown dependency graph format")
+}
+
+func TestSpeculator_GetDependencyGraph_Disabled(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createDependencyTelemetry("1.2.3.4:5000", "10.0.0.1:80", "users", http.MethodGet, "/api", time.Now())))
+
+	assert.DeepEqual(t, s.GetDependencyGraph(), &DependencyGraph{Nodes: []DependencyNode{}, Edges: []DependencyEdge{}})
+}
+
+func TestDependencyGraph_record_Limits(t *testing.T) {
+	g := &dependencyGraph{}
+	for i := 0; i < maxDependencyEdgeOperations+10; i++ {
+		telemetry := createDependencyTelemetry("1.2.3.4:5000", "10.0.0.1:80", "users", http.MethodGet, "/api", time.Now())
+		g.record(telemetry, "users:80", http.MethodGet, "/api/"+strings.Repeat("a", i))
+	}
+
+	graph := g.snapshot()
+	assert.Equal(t, len(graph.Edges), 1)
+	assert.Equal(t, graph.Edges[0].Calls, maxDependencyEdgeOperations+10)
+	assert.Equal(t, len(graph.Edges[0].Operations), maxDependencyEdgeOperations)
+}
@@ -0,0 +1,122 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"sort"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// DefaultDuplicateSpecMinSimilarity is the similarity from which specs are considered duplicates, see FindDuplicateSpecs.
+const DefaultDuplicateSpecMinSimilarity = 0.9
+
+// DuplicateSpecs are two specs that are likely the same service behind different host names, and are suggested
+// to be consolidated into SpecKey.
+type DuplicateSpecs struct {
+	// SpecKey is the spec to keep, the one learned from more telemetries
+	SpecKey SpecKey
+	// DuplicateSpecKey is the spec suggested to be consolidated into SpecKey
+	DuplicateSpecKey SpecKey
+	// Similarity is from 0 to 1, see _spec.SpecSignature.Similarity
+	Similarity float64
+	// SharedOperations is the amount of operations both specs have
+	SharedOperations int
+}
+
+// GetSpecSimilarity returns the similarity of the specs, from 0 (no operation in common) to 1 (the same operations
+// with the same fields), see _spec.SpecSignature.Similarity. It is safe to call concurrently with ingestion.
+func (s *Speculator) GetSpecSimilarity(specKey, otherSpecKey SpecKey) (float64, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return 0, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	otherSpec, ok := s.getSpec(otherSpecKey)
+	if !ok {
+		return 0, fmt.Errorf("spec doesn't exist for key %v", otherSpecKey)
+	}
+
+	return spec.Signature().Similarity(otherSpec.Signature()), nil
+}
+
+// CompareSpecs compares the specs operation by operation, e.g. to verify that the spec learned for the new
+// implementation of a service matches the spec of the old one, see _spec.CompareLearnedSpecs.
+// It is safe to call concurrently with ingestion.
+func (s *Speculator) CompareSpecs(specKey, otherSpecKey SpecKey) (*_spec.SpecComparison, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	otherSpec, ok := s.getSpec(otherSpecKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", otherSpecKey)
+	}
+
+	return _spec.CompareLearnedSpecs(spec, otherSpec), nil
+}
+
+// FindDuplicateSpecs compares each pair of in-memory specs and returns the pairs whose similarity is at least
+// minSimilarity (DefaultDuplicateSpecMinSimilarity when zero), the most similar first.
+// It is safe to call concurrently with ingestion.
+func (s *Speculator) FindDuplicateSpecs(minSimilarity float64) []DuplicateSpecs {
+	if minSimilarity == 0 {
+		minSimilarity = DefaultDuplicateSpecMinSimilarity
+	}
+
+	type specSignature struct {
+		specKey        SpecKey
+		signature      *_spec.SpecSignature
+		telemetryCount int
+	}
+	var signatures []specSignature
+	for specKey, spec := range s.getSpecs() {
+		signature := spec.Signature()
+		if len(signature.Operations) == 0 {
+			continue
+		}
+		stats := spec.Stats()
+		signatures = append(signatures, specSignature{specKey: specKey, signature: signature, telemetryCount: stats.TelemetryCount})
+	}
+	// the spec to keep of specs learned from as many telemetries is the first by key
+	sort.Slice(signatures, func(i, j int) bool {
+		if signatures[i].telemetryCount != signatures[j].telemetryCount {
+			return signatures[i].telemetryCount > signatures[j].telemetryCount
+		}
+		return signatures[i].specKey < signatures[j].specKey
+	})
+
+	var duplicates []DuplicateSpecs
+	for i := range signatures {
+		for j := i + 1; j < len(signatures); j++ {
+			similarity := signatures[i].signature.Similarity(signatures[j].signature)
+			if similarity < minSimilarity {
+				continue
+			}
+			duplicates = append(duplicates, DuplicateSpecs{
+				SpecKey:          signatures[i].specKey,
+				DuplicateSpecKey: signatures[j].specKey,
+				Similarity:       similarity,
+				SharedOperations: signatures[i].signature.SharedOperations(signatures[j].signature),
+			})
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Similarity > duplicates[j].Similarity
+	})
+
+	return duplicates
+}
@@ -0,0 +1,79 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func createJSONResponseTelemetry(host, path, respBody string) *spec.Telemetry {
+	telemetry := createHostTelemetry(host, "10.0.0.1:80", "GET", path)
+	telemetry.Response.Common = &spec.Common{
+		Headers: []*spec.Header{{Key: "Content-Type", Value: "application/json"}},
+		Body:    []byte(respBody),
+	}
+	return telemetry
+}
+
+func TestSpeculator_FindDuplicateSpecs(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	telemetries := []*spec.Telemetry{
+		createJSONResponseTelemetry("users", "/api/users/1", `{"id": 1, "name": "a"}`),
+		createJSONResponseTelemetry("users", "/api/users/2", `{"id": 2, "name": "b"}`),
+		// the same service behind another name
+		createJSONResponseTelemetry("users.internal", "/api/users/3", `{"id": 3, "name": "c"}`),
+		createJSONResponseTelemetry("orders", "/api/orders", `{"total": 1}`),
+	}
+	for _, telemetry := range telemetries {
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	assert.DeepEqual(t, s.FindDuplicateSpecs(0), []DuplicateSpecs{
+		{
+			SpecKey:          GetSpecKey("users", "80"),
+			DuplicateSpecKey: GetSpecKey("users.internal", "80"),
+			Similarity:       1,
+			SharedOperations: 1,
+		},
+	})
+	assert.Equal(t, len(s.FindDuplicateSpecs(0.01)), 1)
+
+	similarity, err := s.GetSpecSimilarity(GetSpecKey("users", "80"), GetSpecKey("orders", "80"))
+	assert.NilError(t, err)
+	assert.Equal(t, similarity, float64(0))
+	_, err = s.GetSpecSimilarity(GetSpecKey("users", "80"), GetSpecKey("missing", "80"))
+	assert.ErrorContains(t, err, "spec doesn't exist")
+}
+
+func TestSpeculator_CompareSpecs(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createJSONResponseTelemetry("users", "/api/users/1", `{"id": 1}`)))
+	assert.NilError(t, s.LearnTelemetry(createJSONResponseTelemetry("users-v2", "/api/users/2", `{"id": 2}`)))
+
+	comparison, err := s.CompareSpecs(GetSpecKey("users", "80"), GetSpecKey("users-v2", "80"))
+	assert.NilError(t, err)
+	assert.Equal(t, comparison.Similarity, float64(1))
+	assert.Equal(t, len(comparison.Operations), 1)
+	assert.Equal(t, comparison.Operations[0].Path, "/api/users/{param1}")
+	assert.Equal(t, comparison.Operations[0].OtherPath, "/api/users/{param1}")
+
+	_, err = s.CompareSpecs(GetSpecKey("missing", "80"), GetSpecKey("users", "80"))
+	assert.ErrorContains(t, err, "spec doesn't exist")
+}
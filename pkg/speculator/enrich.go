@@ -0,0 +1,50 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// Enricher returns caller metadata (ASN, geo, service identity...) for a telemetry, e.g. by looking up its SourceAddress.
+// The returned metadata is added to the telemetry metadata, see _spec.MetadataKey* for well known keys.
+type Enricher func(telemetry *_spec.Telemetry) (map[string]string, error)
+
+// enrich runs the enrichers in order, so a later enricher can override the metadata of an earlier one.
+// telemetry.Metadata is replaced with a new map so the caller's map is not modified.
+func enrich(telemetry *_spec.Telemetry, enrichers []Enricher) {
+	if len(enrichers) == 0 {
+		return
+	}
+
+	metadata := make(map[string]string, len(telemetry.Metadata))
+	for key, value := range telemetry.Metadata {
+		metadata[key] = value
+	}
+	for _, enricher := range enrichers {
+		enriched, err := enricher(telemetry)
+		if err != nil {
+			log.Warnf("Failed to enrich telemetry. RequestID=%v: %v", telemetry.RequestID, err)
+			continue
+		}
+		for key, value := range enriched {
+			metadata[key] = value
+		}
+	}
+	telemetry.Metadata = metadata
+}
@@ -0,0 +1,67 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestSpeculator_LearnTelemetry_Enrichers(t *testing.T) {
+	asnEnricher := func(telemetry *spec.Telemetry) (map[string]string, error) {
+		return map[string]string{spec.MetadataKeyASN: "AS1", spec.MetadataKeyCountry: "US"}, nil
+	}
+	geoEnricher := func(telemetry *spec.Telemetry) (map[string]string, error) {
+		return map[string]string{spec.MetadataKeyCountry: "IL"}, nil
+	}
+	failingEnricher := func(telemetry *spec.Telemetry) (map[string]string, error) {
+		return nil, fmt.Errorf("lookup failed")
+	}
+	var classified map[string]string
+	classifier := func(telemetry *spec.Telemetry) spec.SourceLabel {
+		classified = telemetry.Metadata
+		return spec.SourcePartner
+	}
+	s := CreateSpeculator(Config{
+		Enrichers:        []Enricher{asnEnricher, failingEnricher, geoEnricher},
+		SourceClassifier: classifier,
+	})
+
+	telemetry := createTelemetry("1")
+	telemetry.Metadata = map[string]string{spec.MetadataKeyServiceIdentity: "spiffe://cluster/ns/default/sa/client"}
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+
+	wantMetadata := map[string]string{
+		spec.MetadataKeyServiceIdentity: "spiffe://cluster/ns/default/sa/client",
+		spec.MetadataKeyASN:             "AS1",
+		spec.MetadataKeyCountry:         "IL",
+	}
+	// the classifier sees the enriched metadata
+	assert.DeepEqual(t, classified, wantMetadata)
+	// the caller's metadata is not modified
+	assert.DeepEqual(t, telemetry.Metadata, map[string]string{spec.MetadataKeyServiceIdentity: "spiffe://cluster/ns/default/sa/client"})
+
+	opStats := s.Specs[GetSpecKey("host", "80")].LearningStats.Operations["/api"]["GET"]
+	assert.DeepEqual(t, opStats.Metadata, map[string]map[string]int{
+		spec.MetadataKeyServiceIdentity: {"spiffe://cluster/ns/default/sa/client": 1},
+		spec.MetadataKeyASN:             {"AS1": 1},
+		spec.MetadataKeyCountry:         {"IL": 1},
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// EventSink receives the API diffs found by DiffTelemetry.
+type EventSink interface {
+	HandleDiff(diff *_spec.APIDiff) error
+	// Close is called once on Shutdown, no diffs are sent after it.
+	Close() error
+}
+
+func (s *Speculator) sendDiff(diff *_spec.APIDiff) {
+	if diff == nil || diff.Type == _spec.DiffTypeNoDiff {
+		return
+	}
+	for _, sink := range s.config.EventSinks {
+		if err := sink.HandleDiff(diff); err != nil {
+			log.Errorf("Failed to send diff to event sink: %v", err)
+		}
+	}
+}
+
+func (s *Speculator) closeEventSinks() error {
+	var firstErr error
+	for _, sink := range s.config.EventSinks {
+		if err := sink.Close(); err != nil {
+			log.Errorf("Failed to close event sink: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,91 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"strings"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// FilterConfig drops the telemetries that are not part of the API (e.g. health checks) before they are learned or diffed.
+type FilterConfig struct {
+	// PathsToIgnore are the path prefixes (e.g. /health) of the telemetries that are dropped, the prefix of a path
+	// is made of whole segments
+	PathsToIgnore []string
+	// MethodsToIgnore are the (case insensitive) methods of the telemetries that are dropped, e.g. OPTIONS
+	MethodsToIgnore []string
+}
+
+func (c FilterConfig) keep(telemetry *_spec.Telemetry) bool {
+	for _, method := range c.MethodsToIgnore {
+		if strings.EqualFold(method, telemetry.Request.Method) {
+			return false
+		}
+	}
+	for _, path := range c.PathsToIgnore {
+		if isUnderBasePath(strings.TrimSuffix(path, "/"), telemetry.Request.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c FilterConfig) validate() error {
+	for _, path := range c.PathsToIgnore {
+		if trimmed := strings.TrimSuffix(path, "/"); !strings.HasPrefix(trimmed, "/") || strings.ContainsAny(trimmed, "?#") {
+			return fmt.Errorf("invalid pathsToIgnore: %q must be a path other than /", path)
+		}
+	}
+	for _, method := range c.MethodsToIgnore {
+		if _, err := _spec.NormalizeMethod(method); err != nil {
+			return fmt.Errorf("invalid methodsToIgnore: %v", err)
+		}
+	}
+	return nil
+}
+
+// filterTelemetry drops telemetry if it is ignored by the filter of its host.
+func (s *Speculator) filterTelemetry(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+	filter := s.config.Filter
+	if hostConfig, ok := s.getTelemetryHostConfig(telemetry); ok {
+		filter = hostConfig.Filter
+	}
+	return FilterStage(filter.keep)(telemetry)
+}
+
+// redactTelemetry redacts telemetry with the redaction policy of its host, if any.
+func (s *Speculator) redactTelemetry(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+	policy := s.config.Redaction
+	if hostConfig, ok := s.getTelemetryHostConfig(telemetry); ok {
+		policy = hostConfig.Redaction
+	}
+	if policy == nil {
+		return telemetry, nil
+	}
+	return RedactStage(*policy)(telemetry)
+}
+
+// getTelemetryHostConfig returns the host config of the destination of telemetry, see getHostConfig.
+func (s *Speculator) getTelemetryHostConfig(telemetry *_spec.Telemetry) (HostConfig, bool) {
+	destInfo, err := GetAddressInfoFromAddress(telemetry.DestinationAddress)
+	if err != nil {
+		// the telemetry is rejected when it is learned or diffed
+		return HostConfig{}, false
+	}
+	return s.getHostConfig(telemetry.Request.Host, destInfo.Port)
+}
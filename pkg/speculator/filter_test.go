@@ -0,0 +1,62 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestSpeculator_FilterAndRedaction(t *testing.T) {
+	policy := _spec.DefaultRedactionPolicy()
+	s := CreateSpeculator(Config{
+		Filter: FilterConfig{
+			PathsToIgnore:   []string{"/health/"},
+			MethodsToIgnore: []string{"options"},
+		},
+		Redaction: &policy,
+		HostConfigs: map[string]HostConfig{
+			"other": {},
+		},
+	})
+	var learned []string
+	pipeline := s.NewPipeline(s.DefaultStages()...).With(func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+		learned = append(learned, telemetry.Request.Host+telemetry.Request.Path)
+		return telemetry, nil
+	})
+
+	for _, telemetry := range []*_spec.Telemetry{
+		createHostTelemetry("host", "10.0.0.1:80", "GET", "/health/live"),
+		createHostTelemetry("host", "10.0.0.1:80", "GET", "/healthz"),
+		createHostTelemetry("host", "10.0.0.1:80", "OPTIONS", "/api"),
+		createHostTelemetry("host", "10.0.0.1:80", "GET", "/api?token=secret"),
+		// the host config replaces the global filter and redaction
+		createHostTelemetry("other", "10.0.0.2:80", "GET", "/health?token=secret"),
+	} {
+		assert.NilError(t, pipeline.LearnTelemetry(context.Background(), telemetry))
+	}
+
+	assert.Equal(t, len(learned), 3)
+	assert.Equal(t, learned[0], "host/healthz")
+	assert.Assert(t, strings.HasPrefix(learned[1], "host/api?token="))
+	assert.Assert(t, !strings.Contains(learned[1], "secret"))
+	assert.Equal(t, learned[2], "other/health?token=secret")
+}
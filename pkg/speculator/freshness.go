@@ -0,0 +1,246 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultStaleAfter  = 7 * 24 * time.Hour
+	defaultSilentAfter = time.Hour
+)
+
+// StalenessConfig configures the freshness of the specs, see GetSpecFreshness.
+type StalenessConfig struct {
+	// StaleAfter is the amount of time without a learned schema change after which a spec is stale,
+	// defaults to defaultStaleAfter.
+	StaleAfter time.Duration
+	// SilentAfter is the amount of time without telemetries after which a spec is silent,
+	// defaults to defaultSilentAfter.
+	SilentAfter time.Duration
+	// CheckInterval is the interval silent specs are checked at, see CheckSilentSpecs.
+	// Silent specs are only checked by CheckSilentSpecs calls when zero.
+	CheckInterval time.Duration
+}
+
+func (c StalenessConfig) getStaleAfter() time.Duration {
+	if c.StaleAfter <= 0 {
+		return defaultStaleAfter
+	}
+	return c.StaleAfter
+}
+
+func (c StalenessConfig) getSilentAfter() time.Duration {
+	if c.SilentAfter <= 0 {
+		return defaultSilentAfter
+	}
+	return c.SilentAfter
+}
+
+// SpecFreshness is how recently a spec was learned. Times are capture times of the learned telemetries,
+// and are zero if the spec learned nothing.
+type SpecFreshness struct {
+	SpecKey       SpecKey   `json:"specKey"`
+	LastTelemetry time.Time `json:"lastTelemetry,omitempty"`
+	// LastChange is the last time a learned telemetry changed the learned schema
+	LastChange                time.Time `json:"lastChange,omitempty"`
+	SecondsSinceLastTelemetry float64   `json:"secondsSinceLastTelemetry"`
+	SecondsSinceLastChange    float64   `json:"secondsSinceLastChange"`
+	// Stale is true if the learned schema didn't change for StalenessConfig.StaleAfter
+	Stale bool `json:"stale"`
+	// Silent is true if no telemetry was learned for StalenessConfig.SilentAfter
+	Silent bool `json:"silent"`
+}
+
+// SilenceEventHandler may be implemented by an EventSink to be notified of specs that went silent.
+type SilenceEventHandler interface {
+	HandleSilenceEvent(event *SilenceEvent) error
+}
+
+// SilenceEvent is a spec that got telemetries and stopped getting them for StalenessConfig.SilentAfter.
+type SilenceEvent struct {
+	SpecKey       SpecKey
+	LastTelemetry time.Time
+	DetectedAt    time.Time
+}
+
+// silenceTracker is checked concurrently by CheckSilentSpecs calls, so it has its own lock.
+type silenceTracker struct {
+	lock sync.Mutex
+	// silent are the specs whose silence was reported, a spec is forgotten once it gets telemetries again
+	silent map[SpecKey]bool
+}
+
+// GetSpecFreshness returns the freshness of the spec, it is safe to call concurrently with ingestion.
+func (s *Speculator) GetSpecFreshness(specKey SpecKey) (*SpecFreshness, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+
+	lastTelemetry, lastChange := spec.GetLastLearned()
+	freshness := createSpecFreshness(specKey, lastTelemetry, lastChange, s.getStalenessConfig(), time.Now())
+	return &freshness, nil
+}
+
+// GetFreshness returns the freshness of the in-memory specs sorted by key, it is safe to call concurrently with ingestion.
+func (s *Speculator) GetFreshness() []SpecFreshness {
+	config := s.getStalenessConfig()
+	now := time.Now()
+
+	var ret []SpecFreshness
+	for specKey, spec := range s.getSpecs() {
+		lastTelemetry, lastChange := spec.GetLastLearned()
+		ret = append(ret, createSpecFreshness(specKey, lastTelemetry, lastChange, config, now))
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].SpecKey < ret[j].SpecKey
+	})
+
+	return ret
+}
+
+func createSpecFreshness(specKey SpecKey, lastTelemetry, lastChange time.Time, config StalenessConfig, now time.Time) SpecFreshness {
+	freshness := SpecFreshness{
+		SpecKey:       specKey,
+		LastTelemetry: lastTelemetry,
+		LastChange:    lastChange,
+	}
+	if !lastTelemetry.IsZero() {
+		freshness.SecondsSinceLastTelemetry = now.Sub(lastTelemetry).Seconds()
+		freshness.Silent = now.Sub(lastTelemetry) >= config.getSilentAfter()
+	}
+	if !lastChange.IsZero() {
+		freshness.SecondsSinceLastChange = now.Sub(lastChange).Seconds()
+		freshness.Stale = now.Sub(lastChange) >= config.getStaleAfter()
+	}
+	return freshness
+}
+
+// CheckSilentSpecs returns the specs that went silent since the last check, the specs that never got telemetries
+// are not reported. The events are reported to the event sinks that implement SilenceEventHandler.
+func (s *Speculator) CheckSilentSpecs() []SilenceEvent {
+	return s.checkSilentSpecs(time.Now())
+}
+
+func (s *Speculator) checkSilentSpecs(now time.Time) []SilenceEvent {
+	s.specsLock.RLock()
+	defer s.specsLock.RUnlock()
+
+	config := s.config.Staleness
+
+	var events []SilenceEvent
+	for specKey, spec := range s.getSpecs() {
+		lastTelemetry, lastChange := spec.GetLastLearned()
+		freshness := createSpecFreshness(specKey, lastTelemetry, lastChange, config, now)
+		if !s.silence.update(specKey, freshness.Silent) || !freshness.Silent {
+			continue
+		}
+		events = append(events, SilenceEvent{
+			SpecKey:       specKey,
+			LastTelemetry: lastTelemetry,
+			DetectedAt:    now,
+		})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].SpecKey < events[j].SpecKey
+	})
+
+	for i := range events {
+		log.Warnf("Spec %v went silent, its last telemetry was at %v", events[i].SpecKey, events[i].LastTelemetry.Format(time.RFC3339))
+		s.sendSilenceEvent(&events[i])
+	}
+
+	return events
+}
+
+// update records whether the spec is silent and returns true if it changed.
+func (t *silenceTracker) update(specKey SpecKey, silent bool) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.silent[specKey] == silent {
+		return false
+	}
+	if !silent {
+		delete(t.silent, specKey)
+		return true
+	}
+	if t.silent == nil {
+		t.silent = make(map[SpecKey]bool)
+	}
+	t.silent[specKey] = true
+	return true
+}
+
+func (s *Speculator) sendSilenceEvent(event *SilenceEvent) {
+	for _, sink := range s.config.EventSinks {
+		handler, ok := sink.(SilenceEventHandler)
+		if !ok {
+			continue
+		}
+		if err := handler.HandleSilenceEvent(event); err != nil {
+			log.Errorf("Failed to send silence event to event sink: %v", err)
+		}
+	}
+}
+
+func (s *Speculator) getStalenessConfig() StalenessConfig {
+	s.specsLock.RLock()
+	defer s.specsLock.RUnlock()
+
+	return s.config.Staleness
+}
+
+// startSilenceChecks checks the silent specs every StalenessConfig.CheckInterval until Shutdown.
+func (s *Speculator) startSilenceChecks() {
+	if s.config.Staleness.CheckInterval <= 0 {
+		return
+	}
+	s.silenceCheckStop = make(chan struct{})
+	s.silenceCheckDone = make(chan struct{})
+	go s.runSilenceChecks(s.config.Staleness.CheckInterval)
+}
+
+func (s *Speculator) runSilenceChecks(interval time.Duration) {
+	defer close(s.silenceCheckDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.silenceCheckStop:
+			return
+		case <-ticker.C:
+			s.CheckSilentSpecs()
+		}
+	}
+}
+
+func (s *Speculator) stopSilenceChecks() {
+	if s.silenceCheckStop == nil {
+		return
+	}
+	close(s.silenceCheckStop)
+	<-s.silenceCheckDone
+	s.silenceCheckStop = nil
+}
@@ -0,0 +1,103 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+type silenceEventSink struct {
+	events []*SilenceEvent
+}
+
+func (s *silenceEventSink) HandleDiff(*_spec.APIDiff) error { return nil }
+func (s *silenceEventSink) Close() error                    { return nil }
+func (s *silenceEventSink) HandleSilenceEvent(event *SilenceEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func createTimedTelemetry(host, respBody string, timestamp time.Time) *_spec.Telemetry {
+	telemetry := createJSONResponseTelemetry(host, "/api", respBody)
+	telemetry.Timestamp = timestamp
+	return telemetry
+}
+
+func TestSpeculator_GetSpecFreshness(t *testing.T) {
+	s := CreateSpeculator(Config{Staleness: StalenessConfig{StaleAfter: time.Hour, SilentAfter: 10 * time.Minute}})
+	now := time.Now()
+	assert.NilError(t, s.LearnTelemetry(createTimedTelemetry("users", `{"id": 1}`, now.Add(-2*time.Hour))))
+	// doesn't change the schema
+	assert.NilError(t, s.LearnTelemetry(createTimedTelemetry("users", `{"id": 2}`, now.Add(-time.Minute))))
+	assert.NilError(t, s.LearnTelemetry(createTimedTelemetry("orders", `{"id": 1}`, now.Add(-time.Hour))))
+
+	freshness, err := s.GetSpecFreshness(GetSpecKey("users", "80"))
+	assert.NilError(t, err)
+	assert.Assert(t, freshness.LastTelemetry.Equal(now.Add(-time.Minute)))
+	assert.Assert(t, freshness.LastChange.Equal(now.Add(-2*time.Hour)))
+	assert.Assert(t, freshness.SecondsSinceLastChange >= 2*time.Hour.Seconds())
+	assert.Assert(t, freshness.Stale)
+	assert.Assert(t, !freshness.Silent)
+
+	all := s.GetFreshness()
+	assert.Equal(t, len(all), 2)
+	assert.Equal(t, all[0].SpecKey, GetSpecKey("orders", "80"))
+	assert.Assert(t, all[0].Silent)
+
+	_, err = s.GetSpecFreshness(GetSpecKey("missing", "80"))
+	assert.ErrorContains(t, err, "spec doesn't exist")
+}
+
+func TestSpeculator_CheckSilentSpecs(t *testing.T) {
+	sink := &silenceEventSink{}
+	s := CreateSpeculator(Config{EventSinks: []EventSink{sink}})
+	now := time.Now()
+	assert.NilError(t, s.LearnTelemetry(createTimedTelemetry("users", `{"id": 1}`, now.Add(-2*time.Hour))))
+	assert.NilError(t, s.LearnTelemetry(createTimedTelemetry("orders", `{"id": 1}`, now)))
+
+	events := s.checkSilentSpecs(now)
+	assert.DeepEqual(t, events, []SilenceEvent{
+		{SpecKey: GetSpecKey("users", "80"), LastTelemetry: now.Add(-2 * time.Hour), DetectedAt: now},
+	})
+	assert.Equal(t, len(sink.events), 1)
+	// reported once
+	assert.Equal(t, len(s.checkSilentSpecs(now)), 0)
+
+	// active again, then silent again
+	assert.NilError(t, s.LearnTelemetry(createTimedTelemetry("users", `{"id": 1}`, now)))
+	assert.Equal(t, len(s.checkSilentSpecs(now)), 0)
+	events = s.checkSilentSpecs(now.Add(2 * defaultSilentAfter))
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, len(sink.events), 3)
+}
+
+func TestSpeculator_startSilenceChecks(t *testing.T) {
+	sink := &silenceEventSink{}
+	s := CreateSpeculator(Config{
+		EventSinks: []EventSink{sink},
+		Staleness:  StalenessConfig{SilentAfter: time.Millisecond, CheckInterval: time.Millisecond},
+	})
+	assert.NilError(t, s.LearnTelemetry(createTimedTelemetry("users", `{"id": 1}`, time.Now().Add(-time.Second))))
+	time.Sleep(50 * time.Millisecond)
+	s.stopSilenceChecks()
+
+	assert.Equal(t, len(sink.events), 1)
+}
@@ -0,0 +1,123 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"sync"
+	"time"
+)
+
+type Health struct {
+	// Ready is false once Shutdown was called
+	Ready bool `json:"ready"`
+	// IngestionLagSeconds is the time between the capture and the learning of the last learned telemetry
+	IngestionLagSeconds float64   `json:"ingestionLagSeconds"`
+	LastIngestion       time.Time `json:"lastIngestion,omitempty"`
+	// QueueDepth is the number of telemetries accepted and not yet learned/diffed
+	QueueDepth      int       `json:"queueDepth"`
+	LastPersistence time.Time `json:"lastPersistence,omitempty"`
+	// SpecErrors is the number of telemetries that failed to be learned/diffed per spec
+	SpecErrors map[SpecKey]int `json:"specErrors,omitempty"`
+	// DroppedTelemetries is the number of telemetries dropped by the ingestion backpressure policy per spec
+	DroppedTelemetries map[SpecKey]int `json:"droppedTelemetries,omitempty"`
+	// SpecQueues are the ingestion queues of the specs that have queued telemetries
+	SpecQueues map[SpecKey]*SpecQueueHealth `json:"specQueues,omitempty"`
+	// RecoveredPanics is the number of telemetries that panicked while learned/diffed, see GetPoisonedTelemetries
+	RecoveredPanics int `json:"recoveredPanics"`
+}
+
+type SpecQueueHealth struct {
+	// Depth is the number of queued telemetries of the spec
+	Depth int `json:"depth"`
+	// LagSeconds is the time the oldest queued telemetry of the spec has been waiting to be learned
+	LagSeconds float64 `json:"lagSeconds"`
+}
+
+// healthStats is updated concurrently with Health calls, so it has its own lock.
+type healthStats struct {
+	lock            sync.Mutex
+	lastIngestion   time.Time
+	ingestionLag    time.Duration
+	lastPersistence time.Time
+	specErrors      map[SpecKey]int
+}
+
+func (h *healthStats) recordIngestion(capturedAt, now time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.lastIngestion = now
+	h.ingestionLag = now.Sub(capturedAt)
+	// capture clock may be a bit ahead
+	if h.ingestionLag < 0 {
+		h.ingestionLag = 0
+	}
+}
+
+func (h *healthStats) recordError(specKey SpecKey) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.specErrors == nil {
+		h.specErrors = make(map[SpecKey]int)
+	}
+	h.specErrors[specKey]++
+}
+
+func (h *healthStats) recordPersistence(now time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.lastPersistence = now
+}
+
+// Health returns a snapshot of the speculator health, it is safe to call concurrently with ingestion.
+func (s *Speculator) Health() *Health {
+	s.lifecycleLock.Lock()
+	ready := !s.isShutdown
+	queueDepth := s.inFlightCount
+	s.lifecycleLock.Unlock()
+
+	recoveredPanics := s.poisoned.getCount()
+
+	var dropped map[SpecKey]int
+	var specQueues map[SpecKey]*SpecQueueHealth
+	if s.queue != nil {
+		queueDepth += s.queue.len()
+		dropped = s.queue.getDropped()
+		specQueues = s.queue.getSpecQueueHealth(time.Now())
+	}
+
+	s.health.lock.Lock()
+	defer s.health.lock.Unlock()
+
+	specErrors := make(map[SpecKey]int, len(s.health.specErrors))
+	for specKey, count := range s.health.specErrors {
+		specErrors[specKey] = count
+	}
+
+	return &Health{
+		Ready:               ready,
+		IngestionLagSeconds: s.health.ingestionLag.Seconds(),
+		LastIngestion:       s.health.lastIngestion,
+		QueueDepth:          queueDepth,
+		LastPersistence:     s.health.lastPersistence,
+		SpecErrors:          specErrors,
+		DroppedTelemetries:  dropped,
+		SpecQueues:          specQueues,
+		RecoveredPanics:     recoveredPanics,
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+func TestSpeculator_Health(t *testing.T) {
+	statePath := "/tmp/" + uuid.New().String() + "state.gob"
+	defer func() {
+		_ = os.Remove(statePath)
+	}()
+	s := CreateSpeculator(Config{StateStore: NewFileStateStore(statePath)})
+
+	health := s.Health()
+	assert.Equal(t, health.Ready, true)
+	assert.Assert(t, health.LastIngestion.IsZero())
+	assert.Assert(t, health.LastPersistence.IsZero())
+
+	telemetry := createTelemetry("1")
+	telemetry.Timestamp = time.Now().Add(-time.Minute)
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+
+	// diff fails with an unknown diff source
+	_, err := s.DiffTelemetry(createTelemetry("2"), spec.DiffSource("unknown"))
+	assert.ErrorContains(t, err, "failed to run DiffTelemetry")
+
+	assert.NilError(t, s.SaveState())
+
+	health = s.Health()
+	assert.Assert(t, health.IngestionLagSeconds >= time.Minute.Seconds())
+	assert.Assert(t, !health.LastIngestion.IsZero())
+	assert.Assert(t, !health.LastPersistence.IsZero())
+	assert.DeepEqual(t, health.SpecErrors, map[SpecKey]int{GetSpecKey("host", "80"): 1})
+
+	// in-flight telemetries are counted in the queue depth
+	assert.NilError(t, s.beginIngestion())
+	assert.Equal(t, s.Health().QueueDepth, 1)
+	s.endIngestion()
+	assert.Equal(t, s.Health().QueueDepth, 0)
+}
@@ -0,0 +1,73 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+// Ingest queues the telemetry to be learned asynchronously, by the worker of its spec.
+// When the queue is full the Config.Ingestion policy is applied: errors.ErrTelemetryDropped is returned if
+// the telemetry was dropped (BackpressureDropNewest), or Ingest blocks until there is room (BackpressureBlock).
+// Without a configured queue the telemetry is learned synchronously.
+func (s *Speculator) Ingest(telemetry *_spec.Telemetry) error {
+	if s.queue == nil {
+		return s.LearnTelemetry(telemetry)
+	}
+
+	s.lifecycleLock.Lock()
+	isShutdown := s.isShutdown
+	s.lifecycleLock.Unlock()
+	if isShutdown {
+		return errors.ErrShutdown
+	}
+
+	if err := telemetry.Validate(); err != nil {
+		return fmt.Errorf("invalid telemetry: %w", err)
+	}
+	destInfo, err := GetAddressInfoFromAddress(telemetry.DestinationAddress)
+	if err != nil {
+		return fmt.Errorf("failed get destination info: %v", err)
+	}
+
+	return s.queue.push(&queuedTelemetry{
+		specKey:   GetSpecKey(telemetry.Request.Host, destInfo.Port),
+		telemetry: telemetry,
+	})
+}
+
+func (s *Speculator) startIngestionWorkers() {
+	if s.config.Ingestion.QueueSize <= 0 {
+		return
+	}
+	s.queue = newIngestQueue(s.config.Ingestion, s.learnQueuedTelemetry)
+}
+
+// learnQueuedTelemetry is called by the spec workers of the queue.
+func (s *Speculator) learnQueuedTelemetry(item *queuedTelemetry) {
+	s.specsLock.RLock()
+	err := s.learnTelemetry(context.Background(), item.telemetry)
+	s.specsLock.RUnlock()
+	if err != nil {
+		log.Errorf("Failed to learn queued telemetry of %v: %v", item.specKey, err)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// IngestionReport summarizes the learning of a capture (e.g. a HAR file) that may contain many hosts.
+type IngestionReport struct {
+	// Hosts are the summaries of each spec the capture was learned into, sorted by spec key
+	Hosts []*HostIngestionSummary
+	// Unroutable is the amount of telemetries that couldn't be mapped to a spec (e.g. invalid destination address)
+	Unroutable int
+}
+
+type HostIngestionSummary struct {
+	SpecKey SpecKey
+	// NewSpec is set if the spec was created by the ingestion
+	NewSpec bool
+	// Learned telemetries, including ignored duplicates
+	Learned int
+	Failed  int
+	// NewPaths and NewOperations that were learned by the ingestion
+	NewPaths      int
+	NewOperations int
+}
+
+type hostIngestion struct {
+	summary          *HostIngestionSummary
+	pathsBefore      int
+	operationsBefore int
+}
+
+// LearnTelemetries learns the telemetries of a capture, demultiplexing them into the spec of each host,
+// and returns a summary of each host. Telemetries that fail to be learned are logged and skipped.
+func (s *Speculator) LearnTelemetries(telemetries []*_spec.Telemetry) (*IngestionReport, error) {
+	if err := s.beginIngestion(); err != nil {
+		return nil, err
+	}
+	defer s.endIngestion()
+
+	s.specsLock.RLock()
+	defer s.specsLock.RUnlock()
+
+	report := &IngestionReport{}
+	hosts := make(map[SpecKey]*hostIngestion)
+	for _, telemetry := range telemetries {
+		if err := telemetry.Validate(); err != nil {
+			log.Warnf("Skipping telemetry: %v", err)
+			report.Unroutable++
+			continue
+		}
+		destInfo, err := GetAddressInfoFromAddress(telemetry.DestinationAddress)
+		if err != nil {
+			log.Warnf("Skipping telemetry of %v %v: failed get destination info: %v", telemetry.Request.Method, telemetry.Request.Path, err)
+			report.Unroutable++
+			continue
+		}
+		specKey, _ := s.getTelemetrySpecKey(telemetry, destInfo.Port)
+		host, ok := hosts[specKey]
+		if !ok {
+			host = s.createHostIngestion(specKey)
+			hosts[specKey] = host
+		}
+		if err := s.learnTelemetry(context.Background(), telemetry); err != nil {
+			log.Warnf("Failed to learn telemetry of %v %v%v: %v", telemetry.Request.Method, telemetry.Request.Host, telemetry.Request.Path, err)
+			host.summary.Failed++
+			continue
+		}
+		host.summary.Learned++
+	}
+
+	for specKey, host := range hosts {
+		paths, operations, err := s.getSpecPathAndOperationCount(specKey)
+		if err != nil {
+			// the telemetries of the host all failed before a spec was created
+			log.Debugf("No spec was learned for %v: %v", specKey, err)
+		} else {
+			host.summary.NewPaths = paths - host.pathsBefore
+			host.summary.NewOperations = operations - host.operationsBefore
+		}
+		report.Hosts = append(report.Hosts, host.summary)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		return report.Hosts[i].SpecKey < report.Hosts[j].SpecKey
+	})
+
+	return report, nil
+}
+
+func (s *Speculator) createHostIngestion(specKey SpecKey) *hostIngestion {
+	host := &hostIngestion{
+		summary: &HostIngestionSummary{
+			SpecKey: specKey,
+		},
+	}
+	paths, operations, err := s.getSpecPathAndOperationCount(specKey)
+	if err != nil {
+		host.summary.NewSpec = true
+		return host
+	}
+	host.pathsBefore = paths
+	host.operationsBefore = operations
+	return host
+}
+
+// getSpecPathAndOperationCount returns the amount of learned paths and operations of the spec of specKey.
+func (s *Speculator) getSpecPathAndOperationCount(specKey SpecKey) (paths, operations int, err error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return 0, 0, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	stats := spec.Stats()
+	for _, methods := range stats.Operations {
+		operations += len(methods)
+	}
+	return len(stats.Operations), operations, nil
+}
+
+// Log logs the summary of each host of the report.
+func (r *IngestionReport) Log(source string) {
+	for _, host := range r.Hosts {
+		log.Infof("%v: %v learned %v interactions (%v failed), new spec=%v, new paths=%v, new operations=%v",
+			source, host.SpecKey, host.Learned, host.Failed, host.NewSpec, host.NewPaths, host.NewOperations)
+	}
+	if r.Unroutable > 0 {
+		log.Warnf("%v: %v interactions couldn't be mapped to a host", source, r.Unroutable)
+	}
+}
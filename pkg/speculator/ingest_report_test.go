@@ -0,0 +1,78 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+func createHostTelemetry(host, destinationAddress, method, path string) *spec.Telemetry {
+	telemetry := createTelemetry("")
+	telemetry.Request.Host = host
+	telemetry.DestinationAddress = destinationAddress
+	telemetry.Request.Method = method
+	telemetry.Request.Path = path
+	return telemetry
+}
+
+func TestSpeculator_LearnTelemetries(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createHostTelemetry("existing", "10.0.0.1:80", "GET", "/api")))
+
+	report, err := s.LearnTelemetries([]*spec.Telemetry{
+		createHostTelemetry("existing", "10.0.0.1:80", "GET", "/api"),
+		createHostTelemetry("existing", "10.0.0.1:80", "POST", "/api"),
+		createHostTelemetry("new", "10.0.0.2:8080", "GET", "/users"),
+		createHostTelemetry("new", "10.0.0.2:8080", "GET", "/items"),
+		createHostTelemetry("new", "10.0.0.2:8080", "FOO", "/items"),
+		createHostTelemetry("new", "invalid", "GET", "/items"),
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, report, &IngestionReport{
+		Hosts: []*HostIngestionSummary{
+			{
+				SpecKey:       GetSpecKey("existing", "80"),
+				Learned:       2,
+				NewPaths:      0,
+				NewOperations: 1,
+			},
+			{
+				SpecKey:       GetSpecKey("new", "8080"),
+				NewSpec:       true,
+				Learned:       2,
+				Failed:        1,
+				NewPaths:      2,
+				NewOperations: 2,
+			},
+		},
+		Unroutable: 1,
+	})
+	assert.Equal(t, s.Specs[GetSpecKey("new", "8080")].LearningStats.TelemetryCount, 2)
+}
+
+func TestSpeculator_LearnTelemetries_Shutdown(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.Shutdown(context.Background()))
+	_, err := s.LearnTelemetries([]*spec.Telemetry{createTelemetry("")})
+	assert.Assert(t, errors.Is(err, _errors.ErrShutdown))
+}
@@ -0,0 +1,47 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+func TestSpeculator_Ingest(t *testing.T) {
+	s := CreateSpeculator(Config{
+		Ingestion: IngestionConfig{QueueSize: 10},
+	})
+	for i := 0; i < 5; i++ {
+		assert.NilError(t, s.Ingest(createTelemetry("")))
+	}
+	// Shutdown drains the queue
+	assert.NilError(t, s.Shutdown(context.Background()))
+	assert.Equal(t, s.Specs[GetSpecKey("host", "80")].LearningStats.TelemetryCount, 5)
+	assert.Equal(t, s.Health().QueueDepth, 0)
+
+	assert.Assert(t, errors.Is(s.Ingest(createTelemetry("")), _errors.ErrShutdown))
+}
+
+func TestSpeculator_Ingest_Synchronous(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.Ingest(createTelemetry("")))
+	assert.Equal(t, s.Specs[GetSpecKey("host", "80")].LearningStats.TelemetryCount, 1)
+}
@@ -0,0 +1,199 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+const metricsNamespace = "speculator"
+
+// Metrics is a snapshot of the learning pipeline metrics, see MetricsCollector for their Prometheus metrics.
+type Metrics struct {
+	Specs map[SpecKey]*SpecMetrics
+}
+
+type SpecMetrics struct {
+	TelemetriesLearned int
+	LearnErrors        int
+	// BodyParseFailures are the learn errors of bodies that could not be parsed, see errors.ErrBodyParse
+	BodyParseFailures int
+	// PartialLearnings are the telemetries learned without the request or response body that failed to be parsed
+	PartialLearnings int
+	LearningPaths    int
+	ApprovedPaths    int
+	// LearnDuration is the histogram of the durations of the learned telemetries, by _spec.GenerationDurationBuckets
+	LearnDuration _spec.GenerationStats
+	// GenerationDuration is the histogram of the spec OAS generations, see _spec.Spec.GetGenerationStats
+	GenerationDuration _spec.GenerationStats
+}
+
+// pipelineStats is updated concurrently with GetMetrics calls, so it has its own lock.
+type pipelineStats struct {
+	lock              sync.Mutex
+	learned           map[SpecKey]int
+	learnDurations    map[SpecKey]*_spec.GenerationStats
+	learnErrors       map[SpecKey]int
+	bodyParseFailures map[SpecKey]int
+}
+
+func (p *pipelineStats) recordLearned(specKey SpecKey, duration time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.learned == nil {
+		p.learned = make(map[SpecKey]int)
+		p.learnDurations = make(map[SpecKey]*_spec.GenerationStats)
+	}
+	p.learned[specKey]++
+	learnDuration, ok := p.learnDurations[specKey]
+	if !ok {
+		learnDuration = &_spec.GenerationStats{}
+		p.learnDurations[specKey] = learnDuration
+	}
+	learnDuration.Observe(duration)
+}
+
+func (p *pipelineStats) recordLearnError(specKey SpecKey, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.learnErrors == nil {
+		p.learnErrors = make(map[SpecKey]int)
+		p.bodyParseFailures = make(map[SpecKey]int)
+	}
+	p.learnErrors[specKey]++
+	if errors.Is(err, _errors.ErrBodyParse) {
+		p.bodyParseFailures[specKey]++
+	}
+}
+
+// GetMetrics returns a snapshot of the learning pipeline metrics of the in-memory specs, and of the specs that
+// telemetries were learned for.
+func (s *Speculator) GetMetrics() *Metrics {
+	ret := &Metrics{Specs: make(map[SpecKey]*SpecMetrics)}
+	getSpecMetrics := func(specKey SpecKey) *SpecMetrics {
+		specMetrics, ok := ret.Specs[specKey]
+		if !ok {
+			specMetrics = &SpecMetrics{}
+			ret.Specs[specKey] = specMetrics
+		}
+		return specMetrics
+	}
+
+	s.pipeline.lock.Lock()
+	for specKey, count := range s.pipeline.learned {
+		getSpecMetrics(specKey).TelemetriesLearned = count
+	}
+	for specKey, learnDuration := range s.pipeline.learnDurations {
+		getSpecMetrics(specKey).LearnDuration.Merge(*learnDuration)
+	}
+	for specKey, count := range s.pipeline.learnErrors {
+		getSpecMetrics(specKey).LearnErrors = count
+	}
+	for specKey, count := range s.pipeline.bodyParseFailures {
+		getSpecMetrics(specKey).BodyParseFailures = count
+	}
+	s.pipeline.lock.Unlock()
+
+	for specKey, spec := range s.getSpecs() {
+		specMetrics := getSpecMetrics(specKey)
+		specMetrics.LearningPaths, specMetrics.ApprovedPaths = spec.GetPathCounts()
+		specMetrics.PartialLearnings = spec.GetPartialLearnings()
+		specMetrics.GenerationDuration = spec.GetGenerationStats()
+	}
+
+	return ret
+}
+
+// MetricsCollector is a prometheus.Collector of the metrics of Speculator.GetMetrics, labeled by spec key.
+// It can be registered with the prometheus registry of an embedding application, see NewMetricsCollector.
+type MetricsCollector struct {
+	speculator         *Speculator
+	telemetriesLearned *prometheus.Desc
+	learnErrors        *prometheus.Desc
+	bodyParseFailures  *prometheus.Desc
+	partialLearnings   *prometheus.Desc
+	paths              *prometheus.Desc
+	learnDuration      *prometheus.Desc
+	generationDuration *prometheus.Desc
+}
+
+// NewMetricsCollector returns a collector of the metrics of s, that are read from GetMetrics on each collection.
+func NewMetricsCollector(s *Speculator) *MetricsCollector {
+	specLabels := []string{"spec"}
+	return &MetricsCollector{
+		speculator: s,
+		telemetriesLearned: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "telemetries_learned_total"),
+			"Telemetries learned per spec.", specLabels, nil),
+		learnErrors: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "learn_errors_total"),
+			"Telemetries that failed to be learned per spec.", specLabels, nil),
+		bodyParseFailures: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "body_parse_failures_total"),
+			"Telemetries whose body failed to be parsed per spec.", specLabels, nil),
+		partialLearnings: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "partial_learnings_total"),
+			"Telemetries learned without their request or response body per spec.", specLabels, nil),
+		paths: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "paths"),
+			"Paths per spec and state (learning or approved).", []string{"spec", "state"}, nil),
+		learnDuration: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "learn_duration_seconds"),
+			"Durations of the learned telemetries.", specLabels, nil),
+		generationDuration: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "generation_duration_seconds"),
+			"Durations of the spec OAS generations.", specLabels, nil),
+	}
+}
+
+func (c *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.telemetriesLearned
+	ch <- c.learnErrors
+	ch <- c.bodyParseFailures
+	ch <- c.partialLearnings
+	ch <- c.paths
+	ch <- c.learnDuration
+	ch <- c.generationDuration
+}
+
+func (c *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for specKey, specMetrics := range c.speculator.GetMetrics().Specs {
+		spec := string(specKey)
+		ch <- prometheus.MustNewConstMetric(c.telemetriesLearned, prometheus.CounterValue, float64(specMetrics.TelemetriesLearned), spec)
+		ch <- prometheus.MustNewConstMetric(c.learnErrors, prometheus.CounterValue, float64(specMetrics.LearnErrors), spec)
+		ch <- prometheus.MustNewConstMetric(c.bodyParseFailures, prometheus.CounterValue, float64(specMetrics.BodyParseFailures), spec)
+		ch <- prometheus.MustNewConstMetric(c.partialLearnings, prometheus.CounterValue, float64(specMetrics.PartialLearnings), spec)
+		ch <- prometheus.MustNewConstMetric(c.paths, prometheus.GaugeValue, float64(specMetrics.LearningPaths), spec, "learning")
+		ch <- prometheus.MustNewConstMetric(c.paths, prometheus.GaugeValue, float64(specMetrics.ApprovedPaths), spec, "approved")
+		ch <- newDurationHistogram(c.learnDuration, specMetrics.LearnDuration, spec)
+		ch <- newDurationHistogram(c.generationDuration, specMetrics.GenerationDuration, spec)
+	}
+}
+
+// newDurationHistogram converts a histogram of _spec.GenerationDurationBuckets into a prometheus histogram in seconds.
+func newDurationHistogram(desc *prometheus.Desc, stats _spec.GenerationStats, labelValues ...string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(_spec.GenerationDurationBuckets))
+	for i, bound := range _spec.GenerationDurationBuckets {
+		var count uint64
+		if i < len(stats.BucketCounts) {
+			count = uint64(stats.BucketCounts[i])
+		}
+		buckets[bound.Seconds()] = count
+	}
+	return prometheus.MustNewConstHistogram(desc, uint64(stats.Count), stats.Sum.Seconds(), buckets, labelValues...)
+}
@@ -0,0 +1,114 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+func TestSpeculator_GetMetrics(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, s.LearnTelemetry(createTelemetry("")))
+	}
+	invalidBody := &spec.Common{
+		Headers: []*spec.Header{{Key: "Content-Type", Value: "application/json"}},
+		Body:    []byte("{invalid"),
+	}
+	// learned without the response body
+	telemetry := createTelemetry("")
+	telemetry.Response.Common = invalidBody
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+	telemetry = createTelemetry("")
+	telemetry.Request.Common = invalidBody
+	telemetry.Response.Common = invalidBody
+	err := s.LearnTelemetry(telemetry)
+	assert.Assert(t, errors.Is(err, _errors.ErrBodyParse), err)
+	specKey := GetSpecKey("host", "80")
+	generatedSpec, ok := s.getSpec(specKey)
+	assert.Assert(t, ok)
+	_, err = generatedSpec.GenerateOASJson()
+	assert.NilError(t, err)
+
+	metrics := s.GetMetrics()
+	assert.Equal(t, len(metrics.Specs), 1)
+	specMetrics := metrics.Specs[specKey]
+	assert.Equal(t, specMetrics.TelemetriesLearned, 4)
+	assert.Equal(t, specMetrics.LearnErrors, 1)
+	assert.Equal(t, specMetrics.BodyParseFailures, 1)
+	assert.Equal(t, specMetrics.PartialLearnings, 1)
+	assert.Equal(t, specMetrics.LearningPaths, 1)
+	assert.Equal(t, specMetrics.ApprovedPaths, 0)
+	assert.Equal(t, specMetrics.LearnDuration.Count, 4)
+	assert.Equal(t, specMetrics.GenerationDuration.Count, 1)
+}
+
+func TestMetricsCollector(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("")))
+	s.pipeline.recordLearnError(GetSpecKey("other", "80"), fmt.Errorf("failed"))
+
+	collector := NewMetricsCollector(s)
+	expected := `
+# HELP speculator_telemetries_learned_total Telemetries learned per spec.
+# TYPE speculator_telemetries_learned_total counter
+speculator_telemetries_learned_total{spec="host:80"} 1
+speculator_telemetries_learned_total{spec="other:80"} 0
+# HELP speculator_learn_errors_total Telemetries that failed to be learned per spec.
+# TYPE speculator_learn_errors_total counter
+speculator_learn_errors_total{spec="host:80"} 0
+speculator_learn_errors_total{spec="other:80"} 1
+# HELP speculator_paths Paths per spec and state (learning or approved).
+# TYPE speculator_paths gauge
+speculator_paths{spec="host:80",state="approved"} 0
+speculator_paths{spec="host:80",state="learning"} 1
+speculator_paths{spec="other:80",state="approved"} 0
+speculator_paths{spec="other:80",state="learning"} 0
+`
+	assert.NilError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"speculator_telemetries_learned_total", "speculator_learn_errors_total", "speculator_paths"))
+
+	registry := prometheus.NewPedanticRegistry()
+	assert.NilError(t, registry.Register(collector))
+	families, err := registry.Gather()
+	assert.NilError(t, err)
+	histograms := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetType() != dto.MetricType_HISTOGRAM {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			histograms[family.GetName()+"/"+metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+	assert.DeepEqual(t, histograms, map[string]uint64{
+		"speculator_learn_duration_seconds/host:80":       1,
+		"speculator_learn_duration_seconds/other:80":      0,
+		"speculator_generation_duration_seconds/host:80":  0,
+		"speculator_generation_duration_seconds/other:80": 0,
+	})
+}
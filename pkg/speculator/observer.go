@@ -0,0 +1,44 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// AddLearningObserver registers observer for the learning events of all the specs, including the per source specs
+// and the specs created or loaded later, see _spec.LearningObserver. The events carry the host and port of their spec.
+func (s *Speculator) AddLearningObserver(observer _spec.LearningObserver) {
+	s.specsMapLock.Lock()
+	defer s.specsMapLock.Unlock()
+
+	s.learningObservers = append(s.learningObservers, observer)
+	for _, spec := range s.Specs {
+		spec.AddLearningObserver(observer)
+	}
+	for _, specs := range s.SourceSpecs {
+		for _, spec := range specs {
+			spec.AddLearningObserver(observer)
+		}
+	}
+}
+
+// addLearningObservers registers the observers of the speculator on a new spec, with specsMapLock held.
+func (s *Speculator) addLearningObservers(spec *_spec.Spec) {
+	for _, observer := range s.learningObservers {
+		spec.AddLearningObserver(observer)
+	}
+}
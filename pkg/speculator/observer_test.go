@@ -0,0 +1,61 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+type newPathObserver struct {
+	_spec.NopLearningObserver
+	lock     sync.Mutex
+	newPaths []_spec.NewPathEvent
+}
+
+func (o *newPathObserver) OnNewPathLearned(event _spec.NewPathEvent) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.newPaths = append(o.newPaths, event)
+}
+
+func TestSpeculator_AddLearningObserver(t *testing.T) {
+	s := CreateSpeculator(Config{SplitSpecsBySource: true})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("")))
+	observer := &newPathObserver{}
+	s.AddLearningObserver(observer)
+
+	// registered on the existing specs and the new ones
+	existingTelemetry := createTelemetry("")
+	existingTelemetry.Request.Path = "/api/users"
+	assert.NilError(t, s.LearnTelemetry(existingTelemetry))
+	newTelemetry := createTelemetry("")
+	newTelemetry.Request.Host = "other"
+	assert.NilError(t, s.LearnTelemetry(newTelemetry))
+
+	// once by the spec and once by its source spec
+	assert.DeepEqual(t, observer.newPaths, []_spec.NewPathEvent{
+		{Host: "host", Port: "80", Path: "/api/users", Method: "GET"},
+		{Host: "host", Port: "80", Path: "/api/users", Method: "GET"},
+		{Host: "other", Port: "80", Path: "/api", Method: "GET"},
+		{Host: "other", Port: "80", Path: "/api", Method: "GET"},
+	})
+}
@@ -0,0 +1,195 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/utils"
+)
+
+// TelemetryStage processes a telemetry before it is learned or diffed, e.g. filters, redacts, enriches or classifies it.
+// It returns the telemetry passed to the next stage, or nil to drop the telemetry. A stage may modify the fields of
+// the telemetry it gets, but must copy its Request, Response and Metadata before modifying them, they are the caller's.
+type TelemetryStage func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error)
+
+// Pipeline processes telemetries with its stages, in order, and learns or diffs the processed telemetries
+// with its Speculator. It is safe to use concurrently.
+type Pipeline struct {
+	speculator *Speculator
+	stages     []TelemetryStage
+}
+
+// NewPipeline returns a pipeline of stages that learns and diffs with s. The stages of the Config (enrichers and
+// source classifier) only run if included, see DefaultStages.
+func (s *Speculator) NewPipeline(stages ...TelemetryStage) *Pipeline {
+	return &Pipeline{
+		speculator: s,
+		stages:     append([]TelemetryStage{}, stages...),
+	}
+}
+
+// DefaultStages returns the stages of LearnTelemetry and DiffTelemetry: the Config.Filter, the Config.Enrichers,
+// the Config.SourceClassifier, then the Config.Redaction, with the filter and redaction of the host config of each
+// telemetry. The stages use the config at the time they run, so they follow ReloadConfig.
+func (s *Speculator) DefaultStages() []TelemetryStage {
+	return []TelemetryStage{
+		s.filterTelemetry,
+		func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+			return EnrichStage(s.config.Enrichers...)(telemetry)
+		},
+		func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+			return ClassifyStage(s.config.SourceClassifier)(telemetry)
+		},
+		s.redactTelemetry,
+	}
+}
+
+func (s *Speculator) defaultPipeline() *Pipeline {
+	return &Pipeline{
+		speculator: s,
+		stages:     s.DefaultStages(),
+	}
+}
+
+// With returns a pipeline of the stages of p followed by stages.
+func (p *Pipeline) With(stages ...TelemetryStage) *Pipeline {
+	return p.speculator.NewPipeline(append(append([]TelemetryStage{}, p.stages...), stages...)...)
+}
+
+// LearnTelemetry processes telemetry with the stages and learns it, see Speculator.LearnTelemetryCtx.
+// A dropped telemetry is not learned and is not an error.
+func (p *Pipeline) LearnTelemetry(ctx context.Context, telemetry *_spec.Telemetry) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("learning canceled: %w", err)
+	}
+	s := p.speculator
+	if err := s.beginIngestion(); err != nil {
+		return err
+	}
+	defer s.endIngestion()
+
+	s.specsLock.RLock()
+	defer s.specsLock.RUnlock()
+
+	return p.learnTelemetry(ctx, telemetry)
+}
+
+// learnTelemetry learns telemetry with specsLock held for reading. A panic in a stage or while learning fails the
+// telemetry on its own, the telemetry is kept for analysis, see GetPoisonedTelemetries.
+func (p *Pipeline) learnTelemetry(ctx context.Context, telemetry *_spec.Telemetry) (err error) {
+	s := p.speculator
+	defer func() {
+		s.poisoned.recordPanic(telemetry, err, s.config.MaxPoisonedTelemetries, time.Now())
+	}()
+	defer utils.RecoverPanic(&err)
+
+	processed, err := p.process(telemetry)
+	if err != nil || processed == nil {
+		return err
+	}
+
+	return s.learnProcessedTelemetry(ctx, processed)
+}
+
+// DiffTelemetry processes telemetry with the stages and diffs it, see Speculator.DiffTelemetry.
+// A dropped telemetry is not diffed, and nil is returned.
+func (p *Pipeline) DiffTelemetry(telemetry *_spec.Telemetry, diffSource _spec.DiffSource) (*_spec.APIDiff, error) {
+	s := p.speculator
+	if err := s.beginIngestion(); err != nil {
+		return nil, err
+	}
+	defer s.endIngestion()
+
+	s.specsLock.RLock()
+	defer s.specsLock.RUnlock()
+
+	processed, err := p.process(telemetry)
+	if err != nil || processed == nil {
+		return nil, err
+	}
+
+	return s.diffProcessedTelemetry(processed, diffSource)
+}
+
+// process returns a copy of telemetry with a normalized timestamp processed by the stages, or nil if a stage
+// dropped it. The processed telemetry must remain valid.
+func (p *Pipeline) process(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+	if err := telemetry.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid telemetry: %w", err)
+	}
+
+	processed := p.speculator.prepareTelemetry(telemetry)
+	for i, stage := range p.stages {
+		var err error
+		if processed, err = stage(processed); err != nil {
+			return nil, fmt.Errorf("telemetry stage %v failed: %w", i, err)
+		}
+		if processed == nil {
+			log.Debugf("Telemetry was dropped by stage %v. RequestID=%v", i, telemetry.RequestID)
+			return nil, nil
+		}
+	}
+	if err := processed.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid processed telemetry: %w", err)
+	}
+
+	return processed, nil
+}
+
+// FilterStage drops the telemetries keep returns false for.
+func FilterStage(keep func(telemetry *_spec.Telemetry) bool) TelemetryStage {
+	return func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+		if !keep(telemetry) {
+			return nil, nil
+		}
+		return telemetry, nil
+	}
+}
+
+// RedactStage redacts the sensitive values of the telemetries with policy, e.g. so that they are never part of
+// the learned examples. The caller metadata is kept.
+func RedactStage(policy _spec.RedactionPolicy) TelemetryStage {
+	return func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+		redacted := policy.RedactTelemetry(telemetry)
+		redacted.Metadata = telemetry.Metadata
+		return redacted, nil
+	}
+}
+
+// EnrichStage adds the caller metadata returned by enrichers to the telemetries, see Enricher.
+func EnrichStage(enrichers ...Enricher) TelemetryStage {
+	return func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+		enrich(telemetry, enrichers)
+		return telemetry, nil
+	}
+}
+
+// ClassifyStage labels the source of the telemetries that were not labeled by the sender, with classifier
+// when not nil.
+func ClassifyStage(classifier SourceClassifier) TelemetryStage {
+	return func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+		if telemetry.Source == "" && classifier != nil {
+			telemetry.Source = classifier(telemetry)
+		}
+		return telemetry, nil
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestPipeline_LearnTelemetry(t *testing.T) {
+	s := CreateSpeculator(Config{
+		Enrichers: []Enricher{func(*_spec.Telemetry) (map[string]string, error) {
+			return map[string]string{"config": "enricher"}, nil
+		}},
+	})
+	var learned []*_spec.Telemetry
+	pipeline := s.NewPipeline(
+		FilterStage(func(telemetry *_spec.Telemetry) bool {
+			return !strings.HasPrefix(telemetry.Request.Path, "/health")
+		}),
+		RedactStage(_spec.DefaultRedactionPolicy()),
+		EnrichStage(func(*_spec.Telemetry) (map[string]string, error) {
+			return map[string]string{"team": "a"}, nil
+		}),
+		func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+			learned = append(learned, telemetry)
+			return telemetry, nil
+		},
+	)
+
+	telemetry := createHostTelemetry("host", "10.0.0.1:80", "GET", "/api?token=secret")
+	assert.NilError(t, pipeline.LearnTelemetry(context.Background(), telemetry))
+	assert.NilError(t, pipeline.LearnTelemetry(context.Background(), createHostTelemetry("host", "10.0.0.1:80", "GET", "/healthz")))
+
+	assert.Equal(t, len(learned), 1)
+	assert.Assert(t, !strings.Contains(learned[0].Request.Path, "secret"))
+	// the config stages are not part of the pipeline
+	assert.DeepEqual(t, learned[0].Metadata, map[string]string{"team": "a"})
+	// the caller's telemetry is not modified
+	assert.Equal(t, telemetry.Request.Path, "/api?token=secret")
+	assert.Assert(t, telemetry.Metadata == nil)
+
+	spec, ok := s.getSpec(GetSpecKey("host", "80"))
+	assert.Assert(t, ok)
+	learningPaths, _ := spec.GetPathCounts()
+	assert.Equal(t, learningPaths, 1)
+}
+
+func TestPipeline_DefaultStages(t *testing.T) {
+	s := CreateSpeculator(Config{
+		Enrichers: []Enricher{func(*_spec.Telemetry) (map[string]string, error) {
+			return map[string]string{"config": "enricher"}, nil
+		}},
+		SourceClassifier: func(*_spec.Telemetry) _spec.SourceLabel {
+			return _spec.SourceInternal
+		},
+	})
+	var learned *_spec.Telemetry
+	pipeline := s.NewPipeline(s.DefaultStages()...).With(func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+		learned = telemetry
+		return telemetry, nil
+	})
+
+	assert.NilError(t, pipeline.LearnTelemetry(context.Background(), createTelemetry("1")))
+	assert.DeepEqual(t, learned.Metadata, map[string]string{"config": "enricher"})
+	assert.Equal(t, learned.Source, _spec.SourceInternal)
+}
+
+func TestPipeline_With(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	var calls []string
+	stage := func(name string) TelemetryStage {
+		return func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+			calls = append(calls, name)
+			return telemetry, nil
+		}
+	}
+	pipeline := s.NewPipeline(stage("a"))
+	extended := pipeline.With(stage("b"))
+
+	assert.NilError(t, extended.LearnTelemetry(context.Background(), createTelemetry("1")))
+	assert.DeepEqual(t, calls, []string{"a", "b"})
+	calls = nil
+	assert.NilError(t, pipeline.LearnTelemetry(context.Background(), createTelemetry("2")))
+	assert.DeepEqual(t, calls, []string{"a"})
+}
+
+func TestPipeline_Errors(t *testing.T) {
+	s := CreateSpeculator(Config{})
+
+	failing := s.NewPipeline(func(*_spec.Telemetry) (*_spec.Telemetry, error) {
+		return nil, fmt.Errorf("stage error")
+	})
+	assert.ErrorContains(t, failing.LearnTelemetry(context.Background(), createTelemetry("1")), "telemetry stage 0 failed: stage error")
+
+	invalid := s.NewPipeline(func(telemetry *_spec.Telemetry) (*_spec.Telemetry, error) {
+		telemetry.Request = nil
+		return telemetry, nil
+	})
+	assert.ErrorContains(t, invalid.LearnTelemetry(context.Background(), createTelemetry("2")), "invalid processed telemetry")
+
+	panicking := s.NewPipeline(func(*_spec.Telemetry) (*_spec.Telemetry, error) {
+		panic("stage panic")
+	})
+	assert.Assert(t, panicking.LearnTelemetry(context.Background(), createTelemetry("3")) != nil)
+	assert.Equal(t, len(s.GetPoisonedTelemetries()), 1)
+	assert.Equal(t, len(s.getSpecs()), 0)
+}
+
+func TestPipeline_DiffTelemetry(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1")))
+	assert.NilError(t, s.ApprovePaths(GetSpecKey("host", "80"), []string{"/api"}))
+
+	dropping := s.NewPipeline(FilterStage(func(*_spec.Telemetry) bool { return false }))
+	diff, err := dropping.DiffTelemetry(createTelemetry("2"), _spec.DiffSourceReconstructed)
+	assert.NilError(t, err)
+	assert.Assert(t, diff == nil)
+
+	diff, err = s.NewPipeline().DiffTelemetry(createTelemetry("3"), _spec.DiffSourceReconstructed)
+	assert.NilError(t, err)
+	assert.Assert(t, diff != nil)
+}
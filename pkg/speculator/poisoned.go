@@ -0,0 +1,90 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+// the amount of poisoned telemetries kept when Config.MaxPoisonedTelemetries is not set
+const defaultMaxPoisonedTelemetries = 20
+
+// PoisonedTelemetry is a redacted copy of a telemetry that panicked while it was learned or diffed.
+type PoisonedTelemetry struct {
+	Time time.Time
+	// Error is the recovered panic and Stack its stack trace
+	Error     string
+	Stack     string
+	Telemetry *_spec.Telemetry
+}
+
+// poisonedTelemetries is updated concurrently with GetPoisonedTelemetries calls, so it has its own lock.
+type poisonedTelemetries struct {
+	lock        sync.Mutex
+	telemetries []PoisonedTelemetry
+	// count is the amount of recovered panics, including the dropped telemetries
+	count int
+}
+
+// recordPanic keeps telemetry redacted with _spec.DefaultRedactionPolicy when err is a recovered panic, up to
+// maxTelemetries telemetries are kept, the oldest telemetry is dropped first.
+func (p *poisonedTelemetries) recordPanic(telemetry *_spec.Telemetry, err error, maxTelemetries int, now time.Time) {
+	var panicErr *_errors.PanicError
+	if !errors.As(err, &panicErr) {
+		return
+	}
+	poisoned := PoisonedTelemetry{
+		Time:  now,
+		Error: panicErr.Error(),
+		Stack: string(panicErr.Stack),
+	}
+	if telemetry != nil {
+		poisoned.Telemetry = _spec.DefaultRedactionPolicy().RedactTelemetry(telemetry)
+	}
+	if maxTelemetries <= 0 {
+		maxTelemetries = defaultMaxPoisonedTelemetries
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.count++
+	p.telemetries = append(p.telemetries, poisoned)
+	if len(p.telemetries) > maxTelemetries {
+		p.telemetries = p.telemetries[len(p.telemetries)-maxTelemetries:]
+	}
+}
+
+func (p *poisonedTelemetries) getCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.count
+}
+
+// GetPoisonedTelemetries returns the telemetries that panicked while they were learned or diffed, oldest first.
+// It is safe to call concurrently with ingestion.
+func (s *Speculator) GetPoisonedTelemetries() []PoisonedTelemetry {
+	s.poisoned.lock.Lock()
+	defer s.poisoned.lock.Unlock()
+
+	return append([]PoisonedTelemetry{}, s.poisoned.telemetries...)
+}
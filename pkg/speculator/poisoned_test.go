@@ -0,0 +1,75 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestSpeculator_GetPoisonedTelemetries(t *testing.T) {
+	panickingEnricher := func(telemetry *spec.Telemetry) (map[string]string, error) {
+		if strings.HasPrefix(telemetry.RequestID, "panic") {
+			panic("bad telemetry " + telemetry.RequestID)
+		}
+		return nil, nil
+	}
+	s := CreateSpeculator(Config{Enrichers: []Enricher{panickingEnricher}, MaxPoisonedTelemetries: 2})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1")))
+	assert.Equal(t, len(s.GetPoisonedTelemetries()), 0)
+
+	for _, reqID := range []string{"panic1", "panic2", "panic3"} {
+		telemetry := createTelemetry(reqID)
+		telemetry.Request.Common.Headers = []*spec.Header{{Key: "Authorization", Value: "Bearer secret"}}
+		assert.ErrorContains(t, s.LearnTelemetry(telemetry), "bad telemetry "+reqID)
+	}
+	// errors that are not panics are not kept
+	assert.ErrorContains(t, s.LearnTelemetry(&spec.Telemetry{}), "missing request")
+
+	poisoned := s.GetPoisonedTelemetries()
+	assert.Equal(t, len(poisoned), 2)
+	for i, reqID := range []string{"panic2", "panic3"} {
+		assert.Equal(t, poisoned[i].Telemetry.RequestID, reqID)
+		assert.Assert(t, strings.Contains(poisoned[i].Error, "bad telemetry "+reqID), poisoned[i].Error)
+		assert.Assert(t, poisoned[i].Stack != "")
+		assert.Assert(t, !poisoned[i].Time.IsZero())
+		assert.Assert(t, poisoned[i].Telemetry.Request.Common.Headers[0].Value != "Bearer secret")
+	}
+	assert.Equal(t, s.Health().RecoveredPanics, 3)
+
+	// the caller telemetry is not redacted
+	telemetry := createTelemetry("panic4")
+	telemetry.Request.Common.Headers = []*spec.Header{{Key: "Authorization", Value: "Bearer secret"}}
+	_ = s.LearnTelemetry(telemetry)
+	assert.Equal(t, telemetry.Request.Common.Headers[0].Value, "Bearer secret")
+	assert.Equal(t, s.Health().RecoveredPanics, 4)
+}
+
+func TestSpeculator_GetPoisonedTelemetries_DefaultMax(t *testing.T) {
+	panickingEnricher := func(telemetry *spec.Telemetry) (map[string]string, error) {
+		panic("bad telemetry")
+	}
+	s := CreateSpeculator(Config{Enrichers: []Enricher{panickingEnricher}})
+	for i := 0; i < defaultMaxPoisonedTelemetries+5; i++ {
+		_ = s.LearnTelemetry(createTelemetry("1"))
+	}
+	assert.Equal(t, len(s.GetPoisonedTelemetries()), defaultMaxPoisonedTelemetries)
+	assert.Equal(t, s.Health().RecoveredPanics, defaultMaxPoisonedTelemetries+5)
+}
@@ -0,0 +1,286 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+// BackpressurePolicy decides what happens to a telemetry ingested into a full queue.
+type BackpressurePolicy string
+
+const (
+	// BackpressureBlock blocks Ingest until there is room in the queue.
+	BackpressureBlock BackpressurePolicy = "block"
+	// BackpressureDropNewest drops the ingested telemetry.
+	BackpressureDropNewest BackpressurePolicy = "drop-newest"
+	// BackpressureDropOldest drops the oldest queued telemetry (of the same spec when the spec queue is full).
+	BackpressureDropOldest BackpressurePolicy = "drop-oldest"
+)
+
+type IngestionConfig struct {
+	// QueueSize is the maximum number of queued telemetries, Ingest learns synchronously when zero.
+	QueueSize int
+	// PerSpecQueueSize is the maximum number of queued telemetries per spec, unlimited (up to QueueSize) when zero.
+	// Each spec is learned by its own worker, but without a per spec limit a single chatty spec can still fill
+	// the whole queue and apply backpressure to the others.
+	PerSpecQueueSize int
+	// Policy applied when the queue is full, defaults to BackpressureBlock.
+	Policy BackpressurePolicy
+}
+
+func (c IngestionConfig) validate() error {
+	if c.QueueSize < 0 || c.PerSpecQueueSize < 0 {
+		return fmt.Errorf("queue sizes must not be negative")
+	}
+	switch c.Policy {
+	case "", BackpressureBlock, BackpressureDropNewest, BackpressureDropOldest:
+		return nil
+	default:
+		return fmt.Errorf("unknown backpressure policy: %v", c.Policy)
+	}
+}
+
+type queuedTelemetry struct {
+	specKey   SpecKey
+	telemetry *_spec.Telemetry
+	// set by push
+	seq        uint64
+	enqueuedAt time.Time
+}
+
+// specQueue is the FIFO queue of the telemetries of a spec.
+type specQueue struct {
+	items []*queuedTelemetry
+	// hasWorker is true while a worker learns the spec telemetries, see runSpecWorker
+	hasWorker bool
+}
+
+// ingestQueue is a bounded queue of telemetries with global and per spec limits. Each spec has its own FIFO queue,
+// learned by its own worker, so a spec with a large backlog doesn't delay the learning of the others.
+type ingestQueue struct {
+	lock    sync.Mutex
+	notFull *sync.Cond
+
+	config IngestionConfig
+	// learn is called by the spec workers, no worker is started when it is nil
+	learn   func(item *queuedTelemetry)
+	workers sync.WaitGroup
+	specs   map[SpecKey]*specQueue
+	size    int
+	seq     uint64
+	dropped map[SpecKey]int
+	closed  bool
+}
+
+func newIngestQueue(config IngestionConfig, learn func(item *queuedTelemetry)) *ingestQueue {
+	if config.Policy == "" {
+		config.Policy = BackpressureBlock
+	}
+	q := &ingestQueue{
+		config:  config,
+		learn:   learn,
+		specs:   make(map[SpecKey]*specQueue),
+		dropped: make(map[SpecKey]int),
+	}
+	q.notFull = sync.NewCond(&q.lock)
+
+	return q
+}
+
+// push adds item to the queue of its spec, applying the backpressure policy if the queue is full, and starts
+// the spec worker if it is not running.
+// errors.ErrTelemetryDropped is returned when item was dropped, and errors.ErrShutdown if the queue is closed.
+func (q *ingestQueue) push(item *queuedTelemetry) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for {
+		if q.closed {
+			return errors.ErrShutdown
+		}
+		globalFull := q.size >= q.config.QueueSize
+		specFull := q.config.PerSpecQueueSize > 0 && q.getSpecLen(item.specKey) >= q.config.PerSpecQueueSize
+		if !globalFull && !specFull {
+			break
+		}
+
+		switch q.config.Policy {
+		case BackpressureDropNewest:
+			q.dropped[item.specKey]++
+			return errors.ErrTelemetryDropped
+		case BackpressureDropOldest:
+			if specFull {
+				q.dropOldest(item.specKey)
+			} else {
+				q.dropOldest(q.getOldestSpec())
+			}
+		default:
+			q.notFull.Wait()
+		}
+	}
+
+	sq, ok := q.specs[item.specKey]
+	if !ok {
+		sq = &specQueue{}
+		q.specs[item.specKey] = sq
+	}
+	q.seq++
+	item.seq = q.seq
+	item.enqueuedAt = time.Now()
+	sq.items = append(sq.items, item)
+	q.size++
+	if !sq.hasWorker && q.learn != nil {
+		sq.hasWorker = true
+		q.workers.Add(1)
+		go q.runSpecWorker(item.specKey)
+	}
+
+	return nil
+}
+
+func (q *ingestQueue) getSpecLen(specKey SpecKey) int {
+	sq, ok := q.specs[specKey]
+	if !ok {
+		return 0
+	}
+	return len(sq.items)
+}
+
+// getOldestSpec returns the spec of the oldest queued item.
+func (q *ingestQueue) getOldestSpec() SpecKey {
+	var oldest *queuedTelemetry
+	for _, sq := range q.specs {
+		if len(sq.items) > 0 && (oldest == nil || sq.items[0].seq < oldest.seq) {
+			oldest = sq.items[0]
+		}
+	}
+	if oldest == nil {
+		return ""
+	}
+	return oldest.specKey
+}
+
+// dropOldest removes the oldest item of specKey.
+func (q *ingestQueue) dropOldest(specKey SpecKey) {
+	if _, ok := q.popSpecItem(specKey); ok {
+		q.dropped[specKey]++
+	}
+}
+
+// popSpecItem removes the oldest item of specKey, the spec queue is removed once empty unless its worker is running.
+func (q *ingestQueue) popSpecItem(specKey SpecKey) (*queuedTelemetry, bool) {
+	sq, ok := q.specs[specKey]
+	if !ok || len(sq.items) == 0 {
+		return nil, false
+	}
+
+	item := sq.items[0]
+	sq.items[0] = nil
+	sq.items = sq.items[1:]
+	if len(sq.items) == 0 && !sq.hasWorker {
+		delete(q.specs, specKey)
+	}
+	q.size--
+	q.notFull.Broadcast()
+
+	return item, true
+}
+
+// popSpec returns the oldest item of specKey, false is returned if the spec queue is empty.
+// The spec worker, which is the only caller, stops when the spec queue is empty.
+func (q *ingestQueue) popSpec(specKey SpecKey) (*queuedTelemetry, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	item, ok := q.popSpecItem(specKey)
+	if !ok {
+		delete(q.specs, specKey)
+	}
+	return item, ok
+}
+
+// runSpecWorker learns the queued telemetries of specKey until its queue is empty.
+func (q *ingestQueue) runSpecWorker(specKey SpecKey) {
+	defer q.workers.Done()
+
+	for {
+		item, ok := q.popSpec(specKey)
+		if !ok {
+			return
+		}
+		q.learn(item)
+	}
+}
+
+// close stops accepting items, the queued items are still learned.
+func (q *ingestQueue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.closed = true
+	q.notFull.Broadcast()
+}
+
+// wait blocks until the spec workers learned all the queued items, it must be called after close.
+func (q *ingestQueue) wait() {
+	q.workers.Wait()
+}
+
+func (q *ingestQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.size
+}
+
+func (q *ingestQueue) getDropped() map[SpecKey]int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	ret := make(map[SpecKey]int, len(q.dropped))
+	for specKey, count := range q.dropped {
+		ret[specKey] = count
+	}
+	return ret
+}
+
+// getSpecQueueHealth returns the depth and lag of the non-empty spec queues.
+func (q *ingestQueue) getSpecQueueHealth(now time.Time) map[SpecKey]*SpecQueueHealth {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	ret := make(map[SpecKey]*SpecQueueHealth)
+	for specKey, sq := range q.specs {
+		if len(sq.items) == 0 {
+			continue
+		}
+		lag := now.Sub(sq.items[0].enqueuedAt)
+		if lag < 0 {
+			lag = 0
+		}
+		ret[specKey] = &SpecQueueHealth{
+			Depth:      len(sq.items),
+			LagSeconds: lag.Seconds(),
+		}
+	}
+	return ret
+}
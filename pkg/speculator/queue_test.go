@@ -0,0 +1,198 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+func queueItem(specKey SpecKey, reqID string) *queuedTelemetry {
+	return &queuedTelemetry{
+		specKey:   specKey,
+		telemetry: createTelemetry(reqID),
+	}
+}
+
+// popRequestIDs pops the queued items of each spec, by spec key order.
+func popRequestIDs(q *ingestQueue) []string {
+	q.close()
+	q.lock.Lock()
+	specKeys := make([]string, 0, len(q.specs))
+	for specKey := range q.specs {
+		specKeys = append(specKeys, string(specKey))
+	}
+	q.lock.Unlock()
+	sort.Strings(specKeys)
+
+	var ret []string
+	for _, specKey := range specKeys {
+		for {
+			item, ok := q.popSpec(SpecKey(specKey))
+			if !ok {
+				break
+			}
+			ret = append(ret, item.telemetry.RequestID)
+		}
+	}
+	return ret
+}
+
+func TestIngestQueue_push(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      IngestionConfig
+		items       []*queuedTelemetry
+		want        []string
+		wantDropped map[SpecKey]int
+	}{
+		{
+			name:   "drop newest",
+			config: IngestionConfig{QueueSize: 2, Policy: BackpressureDropNewest},
+			items: []*queuedTelemetry{
+				queueItem("a:80", "1"), queueItem("a:80", "2"), queueItem("b:80", "3"),
+			},
+			want:        []string{"1", "2"},
+			wantDropped: map[SpecKey]int{"b:80": 1},
+		},
+		{
+			name:   "drop oldest",
+			config: IngestionConfig{QueueSize: 2, Policy: BackpressureDropOldest},
+			items: []*queuedTelemetry{
+				queueItem("a:80", "1"), queueItem("a:80", "2"), queueItem("b:80", "3"),
+			},
+			want:        []string{"2", "3"},
+			wantDropped: map[SpecKey]int{"a:80": 1},
+		},
+		{
+			name:   "per spec drop oldest drops the oldest of the same spec",
+			config: IngestionConfig{QueueSize: 10, PerSpecQueueSize: 2, Policy: BackpressureDropOldest},
+			items: []*queuedTelemetry{
+				queueItem("a:80", "1"), queueItem("b:80", "2"), queueItem("b:80", "3"), queueItem("b:80", "4"),
+			},
+			want:        []string{"1", "3", "4"},
+			wantDropped: map[SpecKey]int{"b:80": 1},
+		},
+		{
+			name:   "per spec drop newest",
+			config: IngestionConfig{QueueSize: 10, PerSpecQueueSize: 1, Policy: BackpressureDropNewest},
+			items: []*queuedTelemetry{
+				queueItem("a:80", "1"), queueItem("a:80", "2"), queueItem("b:80", "3"),
+			},
+			want:        []string{"1", "3"},
+			wantDropped: map[SpecKey]int{"a:80": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newIngestQueue(tt.config, nil)
+			for _, item := range tt.items {
+				err := q.push(item)
+				if err != nil {
+					assert.Assert(t, errors.Is(err, _errors.ErrTelemetryDropped))
+				}
+			}
+			assert.DeepEqual(t, q.getDropped(), tt.wantDropped)
+			assert.DeepEqual(t, popRequestIDs(q), tt.want)
+		})
+	}
+}
+
+func TestIngestQueue_pushBlock(t *testing.T) {
+	q := newIngestQueue(IngestionConfig{QueueSize: 1}, nil)
+	assert.NilError(t, q.push(queueItem("a:80", "1")))
+
+	pushed := make(chan error)
+	go func() {
+		pushed <- q.push(queueItem("a:80", "2"))
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("push should block while the queue is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	item, ok := q.popSpec("a:80")
+	assert.Assert(t, ok)
+	assert.Equal(t, item.telemetry.RequestID, "1")
+	assert.NilError(t, <-pushed)
+
+	// closing the queue releases blocked pushes
+	go func() {
+		pushed <- q.push(queueItem("a:80", "3"))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.close()
+	assert.Assert(t, errors.Is(<-pushed, _errors.ErrShutdown))
+	assert.DeepEqual(t, popRequestIDs(q), []string{"2"})
+}
+
+func TestIngestQueue_specWorkers(t *testing.T) {
+	release := make(chan struct{})
+	learningA := make(chan struct{}, 10)
+	learned := make(chan string, 10)
+	var lock sync.Mutex
+	var learnedA []string
+	q := newIngestQueue(IngestionConfig{QueueSize: 10}, func(item *queuedTelemetry) {
+		if item.specKey == "a:80" {
+			learningA <- struct{}{}
+			<-release
+			lock.Lock()
+			learnedA = append(learnedA, item.telemetry.RequestID)
+			lock.Unlock()
+			return
+		}
+		learned <- item.telemetry.RequestID
+	})
+
+	// the backlog of a:80 doesn't delay b:80
+	for _, reqID := range []string{"1", "2", "3"} {
+		assert.NilError(t, q.push(queueItem("a:80", reqID)))
+	}
+	assert.NilError(t, q.push(queueItem("b:80", "4")))
+	select {
+	case reqID := <-learned:
+		assert.Equal(t, reqID, "4")
+	case <-time.After(time.Second):
+		t.Fatal("b:80 telemetry should be learned while a:80 is blocked")
+	}
+
+	// the first a:80 telemetry is being learned, the others are queued
+	<-learningA
+	health := q.getSpecQueueHealth(time.Now().Add(time.Minute))
+	assert.Equal(t, len(health), 1)
+	assert.Equal(t, health["a:80"].Depth, 2)
+	assert.Assert(t, health["a:80"].LagSeconds >= time.Minute.Seconds())
+
+	close(release)
+	q.close()
+	q.wait()
+	assert.DeepEqual(t, learnedA, []string{"1", "2", "3"})
+	assert.Equal(t, q.len(), 0)
+	assert.Equal(t, len(q.getSpecQueueHealth(time.Now())), 0)
+
+	// the spec queues are removed with their workers
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	assert.Equal(t, len(q.specs), 0)
+}
@@ -0,0 +1,84 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// ReloadConfig replaces the config of a running speculator.
+// Changes are not retroactive: the new options apply to telemetries learned from now on,
+// and everything learned so far (specs, stats, per-source specs) is kept.
+// Existing specs switch to the new (per-host) operation generator config, and the request IDs
+// already seen are kept for deduplication, expired by the new window. Detected scanners stay excluded until the end
+// of their cooldown.
+// The StateStore, SpecStore, EventSinks and Ingestion queue are lifecycle resources and are not reloaded, the current ones are kept.
+// The staleness thresholds are reloaded, the silence check interval is kept.
+func (s *Speculator) ReloadConfig(config Config) {
+	log.Info("Reloading Speculator config")
+	log.Debugf("Speculator Config %+v", config)
+
+	s.specsLock.Lock()
+	defer s.specsLock.Unlock()
+
+	config.StateStore = s.config.StateStore
+	config.SpecStore = s.config.SpecStore
+	config.SpecCheckpointInterval = s.config.SpecCheckpointInterval
+	config.EventSinks = s.config.EventSinks
+	config.Ingestion = s.config.Ingestion
+	config.Staleness.CheckInterval = s.config.Staleness.CheckInterval
+	s.config = config
+	s.requestIDs = reloadRequestIDCache(s.requestIDs, config)
+	s.scanners = reloadScannerDetector(s.scanners, config.ScannerDetection)
+
+	for _, spec := range s.Specs {
+		s.reloadSpecConfig(spec)
+	}
+	for _, specs := range s.SourceSpecs {
+		for _, spec := range specs {
+			s.reloadSpecConfig(spec)
+		}
+	}
+}
+
+func (s *Speculator) reloadSpecConfig(spec *_spec.Spec) {
+	spec.SetOperationGeneratorConfig(s.getOperationGeneratorConfig(spec.Host, spec.Port))
+}
+
+func reloadRequestIDCache(current *requestIDCache, config Config) *requestIDCache {
+	if config.DeduplicationWindow <= 0 {
+		return nil
+	}
+	if current == nil {
+		return newRequestIDCache(config.DeduplicationWindow)
+	}
+	current.window = config.DeduplicationWindow
+	return current
+}
+
+func reloadScannerDetector(current *scannerDetector, config ScannerDetectionConfig) *scannerDetector {
+	reloaded := newScannerDetector(config)
+	if reloaded == nil || current == nil {
+		return reloaded
+	}
+	current.lock.Lock()
+	defer current.lock.Unlock()
+
+	current.config = reloaded.config
+	return current
+}
@@ -0,0 +1,65 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestSpeculator_ReloadConfig(t *testing.T) {
+	s := CreateSpeculator(Config{
+		DeduplicationWindow: time.Hour,
+		SplitSpecsBySource:  true,
+	})
+	telemetry := createTelemetry("1")
+	telemetry.Request.Common.Headers = []*spec.Header{{Key: "X-Trace-Id", Value: "abc"}}
+	assert.NilError(t, s.LearnTelemetry(telemetry))
+
+	s.ReloadConfig(Config{
+		DeduplicationWindow: time.Hour,
+		SplitSpecsBySource:  true,
+		HostConfigs: map[string]HostConfig{
+			"host": {OperationGeneratorConfig: spec.OperationGeneratorConfig{RequestHeadersToIgnore: []string{"X-Trace-Id"}}},
+		},
+	})
+
+	specKey := GetSpecKey("host", "80")
+	learnedSpec := s.Specs[specKey]
+	// learning is not lost
+	assert.Equal(t, learnedSpec.LearningStats.TelemetryCount, 1)
+	assert.Assert(t, learnedSpec.LearningSpec.GetPathItem("/api") != nil)
+	// request IDs seen before the reload are still deduplicated
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1")))
+	assert.Equal(t, learnedSpec.LearningStats.TelemetryCount, 1)
+
+	// existing specs (including per-source specs) use the new host config
+	_, ok := learnedSpec.OpGenerator.RequestHeadersToIgnore["x-trace-id"]
+	assert.Assert(t, ok)
+	sourceSpec, err := s.GetSourceSpec(spec.SourceUnknown, specKey)
+	assert.NilError(t, err)
+	_, ok = sourceSpec.OpGenerator.RequestHeadersToIgnore["x-trace-id"]
+	assert.Assert(t, ok)
+
+	// disabling deduplication
+	s.ReloadConfig(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1")))
+	assert.Equal(t, learnedSpec.LearningStats.TelemetryCount, 2)
+}
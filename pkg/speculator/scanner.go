@@ -0,0 +1,241 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	// the amount of sources tracked before the sources without recent error responses are forgotten
+	maxScannerTrackedSources = 10000
+	// the amount of scanner events kept, the oldest event is dropped first
+	maxScannerEvents = 100
+)
+
+// ScannerDetectionConfig configures the detection of scanners and fuzzers, sources that get 400 and 404 responses
+// on many unique paths. The telemetries of a detected source are not learned for a cooldown.
+type ScannerDetectionConfig struct {
+	// MinUniquePaths is the amount of unique paths with a 400 or 404 response from a source in Window
+	// that detects it as a scanner, detection is disabled when zero.
+	MinUniquePaths int
+	// Window defaults to defaultScannerDetectionWindow.
+	Window time.Duration
+	// Cooldown is the amount of time the telemetries of a detected source are not learned,
+	// defaults to defaultScannerCooldown.
+	Cooldown time.Duration
+}
+
+const (
+	defaultScannerDetectionWindow = time.Minute
+	defaultScannerCooldown        = 10 * time.Minute
+)
+
+// ScannerEventHandler may be implemented by an EventSink to be notified of detected scanners.
+type ScannerEventHandler interface {
+	HandleScannerEvent(event *ScannerEvent) error
+}
+
+// ScannerEvent is the detection of a source as a scanner.
+type ScannerEvent struct {
+	// Source is the IP of the source address of the telemetries
+	Source     string
+	DetectedAt time.Time
+	// ExcludedUntil is the end of the cooldown, a repeated detection extends it
+	ExcludedUntil time.Time
+	// Paths are the unique paths with error responses that detected the source
+	Paths []string
+	// ExcludedTelemetries is the amount of telemetries of the source that were not learned in the cooldown
+	ExcludedTelemetries int
+}
+
+type scannerSource struct {
+	// errorPaths are the paths with error responses in the window by the time they were last seen
+	errorPaths map[string]time.Time
+	// event is the last detection of the source, nil if not detected
+	event *ScannerEvent
+}
+
+// scannerDetector is updated concurrently by the spec workers, so it has its own lock.
+type scannerDetector struct {
+	lock    sync.Mutex
+	config  ScannerDetectionConfig
+	sources map[string]*scannerSource
+	events  []*ScannerEvent
+}
+
+func newScannerDetector(config ScannerDetectionConfig) *scannerDetector {
+	if config.MinUniquePaths <= 0 {
+		return nil
+	}
+	if config.Window <= 0 {
+		config.Window = defaultScannerDetectionWindow
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaultScannerCooldown
+	}
+	return &scannerDetector{
+		config:  config,
+		sources: make(map[string]*scannerSource),
+	}
+}
+
+// observe records the response of telemetry and returns whether its source is excluded from learning,
+// and the event of the source if it was just detected as a scanner.
+func (d *scannerDetector) observe(telemetry *_spec.Telemetry, now time.Time) (excluded bool, detected *ScannerEvent) {
+	ip := getSourceIP(telemetry.SourceAddress)
+	if ip == nil {
+		return false, nil
+	}
+	sourceIP := ip.String()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	source, ok := d.sources[sourceIP]
+	if ok && source.event != nil && now.Before(source.event.ExcludedUntil) {
+		source.event.ExcludedTelemetries++
+		return true, nil
+	}
+	if !isScannerResponse(telemetry.Response) {
+		return false, nil
+	}
+	if !ok {
+		if len(d.sources) >= maxScannerTrackedSources {
+			d.expireSources(now)
+		}
+		source = &scannerSource{errorPaths: make(map[string]time.Time)}
+		d.sources[sourceIP] = source
+	}
+
+	source.errorPaths[getPathWithoutQuery(telemetry.Request.Path)] = now
+	d.expireErrorPaths(source, now)
+	if len(source.errorPaths) < d.config.MinUniquePaths {
+		return false, nil
+	}
+
+	event := &ScannerEvent{
+		Source:        sourceIP,
+		DetectedAt:    now,
+		ExcludedUntil: now.Add(d.config.Cooldown),
+	}
+	for path := range source.errorPaths {
+		event.Paths = append(event.Paths, path)
+	}
+	sort.Strings(event.Paths)
+	source.event = event
+	source.errorPaths = make(map[string]time.Time)
+	d.events = append(d.events, event)
+	if len(d.events) > maxScannerEvents {
+		d.events = d.events[len(d.events)-maxScannerEvents:]
+	}
+	// the detecting telemetry is an error response of the scanner as well
+	return true, copyScannerEvent(event)
+}
+
+func (d *scannerDetector) expireErrorPaths(source *scannerSource, now time.Time) {
+	for path, seenAt := range source.errorPaths {
+		if now.Sub(seenAt) >= d.config.Window {
+			delete(source.errorPaths, path)
+		}
+	}
+}
+
+// expireSources forgets the sources that are neither in their cooldown nor have error responses in the window.
+func (d *scannerDetector) expireSources(now time.Time) {
+	for sourceIP, source := range d.sources {
+		d.expireErrorPaths(source, now)
+		inCooldown := source.event != nil && now.Before(source.event.ExcludedUntil)
+		if !inCooldown && len(source.errorPaths) == 0 {
+			delete(d.sources, sourceIP)
+		}
+	}
+}
+
+func (d *scannerDetector) getEvents() []ScannerEvent {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	ret := make([]ScannerEvent, 0, len(d.events))
+	for _, event := range d.events {
+		ret = append(ret, *copyScannerEvent(event))
+	}
+	return ret
+}
+
+func copyScannerEvent(event *ScannerEvent) *ScannerEvent {
+	ret := *event
+	ret.Paths = append([]string{}, event.Paths...)
+	return &ret
+}
+
+func isScannerResponse(response *_spec.Response) bool {
+	if response == nil {
+		return false
+	}
+	return response.StatusCode == strconv.Itoa(http.StatusBadRequest) || response.StatusCode == strconv.Itoa(http.StatusNotFound)
+}
+
+func getPathWithoutQuery(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// isExcludedScanner returns true if the source of telemetry is a detected scanner in its cooldown,
+// the detection is reported to the event sinks that implement ScannerEventHandler.
+func (s *Speculator) isExcludedScanner(telemetry *_spec.Telemetry) bool {
+	if s.scanners == nil {
+		return false
+	}
+	excluded, detected := s.scanners.observe(telemetry, time.Now())
+	if detected != nil {
+		log.Warnf("Detected scanner %v (error responses on %v unique paths), its telemetries are not learned until %v",
+			detected.Source, len(detected.Paths), detected.ExcludedUntil.Format(time.RFC3339))
+		s.sendScannerEvent(detected)
+	}
+	return excluded
+}
+
+func (s *Speculator) sendScannerEvent(event *ScannerEvent) {
+	for _, sink := range s.config.EventSinks {
+		handler, ok := sink.(ScannerEventHandler)
+		if !ok {
+			continue
+		}
+		if err := handler.HandleScannerEvent(event); err != nil {
+			log.Errorf("Failed to send scanner event to event sink: %v", err)
+		}
+	}
+}
+
+// GetScannerEvents returns the detected scanners, oldest first, see Config.ScannerDetection.
+func (s *Speculator) GetScannerEvents() []ScannerEvent {
+	if s.scanners == nil {
+		return nil
+	}
+	return s.scanners.getEvents()
+}
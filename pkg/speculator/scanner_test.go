@@ -0,0 +1,133 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func createScannerTelemetry(sourceAddress, path, statusCode string) *_spec.Telemetry {
+	telemetry := createTelemetry("")
+	telemetry.SourceAddress = sourceAddress
+	telemetry.Request.Path = path
+	telemetry.Response.StatusCode = statusCode
+	return telemetry
+}
+
+func Test_scannerDetector_observe(t *testing.T) {
+	now := time.Now()
+	d := newScannerDetector(ScannerDetectionConfig{MinUniquePaths: 3, Window: time.Minute, Cooldown: time.Hour})
+
+	// repeated paths, other status codes and expired paths do not count
+	for _, telemetry := range []*_spec.Telemetry{
+		createScannerTelemetry("1.1.1.1:1234", "/a", "404"),
+		createScannerTelemetry("1.1.1.1:1234", "/a?x=1", "404"),
+		createScannerTelemetry("1.1.1.1:1234", "/b", "500"),
+		createScannerTelemetry("2.2.2.2:1234", "/b", "404"),
+		createScannerTelemetry("", "/c", "404"),
+	} {
+		excluded, detected := d.observe(telemetry, now)
+		assert.Assert(t, !excluded && detected == nil)
+	}
+	excluded, detected := d.observe(createScannerTelemetry("1.1.1.1:1234", "/b", "400"), now.Add(2*time.Minute))
+	assert.Assert(t, !excluded && detected == nil)
+	excluded, detected = d.observe(createScannerTelemetry("1.1.1.1:4321", "/c", "404"), now.Add(2*time.Minute))
+	assert.Assert(t, !excluded && detected == nil)
+
+	excluded, detected = d.observe(createScannerTelemetry("1.1.1.1:1234", "/d", "404"), now.Add(2*time.Minute))
+	assert.Assert(t, excluded)
+	assert.DeepEqual(t, detected, &ScannerEvent{
+		Source:        "1.1.1.1",
+		DetectedAt:    now.Add(2 * time.Minute),
+		ExcludedUntil: now.Add(time.Hour + 2*time.Minute),
+		Paths:         []string{"/b", "/c", "/d"},
+	})
+
+	// excluded in the cooldown, including successful responses
+	excluded, detected = d.observe(createScannerTelemetry("1.1.1.1:1234", "/a", "200"), now.Add(time.Hour))
+	assert.Assert(t, excluded && detected == nil)
+	excluded, _ = d.observe(createScannerTelemetry("2.2.2.2:1234", "/a", "200"), now.Add(time.Hour))
+	assert.Assert(t, !excluded)
+	excluded, _ = d.observe(createScannerTelemetry("1.1.1.1:1234", "/a", "200"), now.Add(2*time.Hour))
+	assert.Assert(t, !excluded)
+
+	events := d.getEvents()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ExcludedTelemetries, 1)
+}
+
+func Test_newScannerDetector(t *testing.T) {
+	assert.Assert(t, newScannerDetector(ScannerDetectionConfig{}) == nil)
+
+	d := newScannerDetector(ScannerDetectionConfig{MinUniquePaths: 1})
+	assert.Equal(t, d.config.Window, defaultScannerDetectionWindow)
+	assert.Equal(t, d.config.Cooldown, defaultScannerCooldown)
+}
+
+type scannerEventSink struct {
+	events []*ScannerEvent
+}
+
+func (s *scannerEventSink) HandleDiff(*_spec.APIDiff) error { return nil }
+func (s *scannerEventSink) Close() error                    { return nil }
+func (s *scannerEventSink) HandleScannerEvent(event *ScannerEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestSpeculator_LearnTelemetry_scanner(t *testing.T) {
+	sink := &scannerEventSink{}
+	s := CreateSpeculator(Config{
+		ScannerDetection: ScannerDetectionConfig{MinUniquePaths: 5},
+		EventSinks:       []EventSink{sink},
+	})
+	assert.NilError(t, s.LearnTelemetry(createScannerTelemetry("1.1.1.1:1234", "/api", "200")))
+	for i := 0; i < 10; i++ {
+		assert.NilError(t, s.LearnTelemetry(createScannerTelemetry("1.1.1.1:1234", fmt.Sprintf("/admin%v.php", i), "404")))
+	}
+	assert.NilError(t, s.LearnTelemetry(createScannerTelemetry("2.2.2.2:1234", "/users", "200")))
+
+	assert.Equal(t, len(sink.events), 1)
+	assert.Equal(t, sink.events[0].Source, "1.1.1.1")
+	events := s.GetScannerEvents()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ExcludedTelemetries, 5)
+
+	// the first 4 error responses were learned before the detection
+	spec, ok := s.getSpec(GetSpecKey("host", "80"))
+	assert.Assert(t, ok)
+	learningPaths, _ := spec.GetPathCounts()
+	assert.Equal(t, learningPaths, 6)
+}
+
+func Test_reloadScannerDetector(t *testing.T) {
+	assert.Assert(t, reloadScannerDetector(nil, ScannerDetectionConfig{}) == nil)
+
+	current := newScannerDetector(ScannerDetectionConfig{MinUniquePaths: 1})
+	current.observe(createScannerTelemetry("1.1.1.1:1234", "/a", "404"), time.Now())
+	assert.Assert(t, reloadScannerDetector(current, ScannerDetectionConfig{}) == nil)
+
+	reloaded := reloadScannerDetector(current, ScannerDetectionConfig{MinUniquePaths: 2})
+	assert.Equal(t, reloaded, current)
+	assert.Equal(t, reloaded.config.MinUniquePaths, 2)
+	assert.Equal(t, len(reloaded.getEvents()), 1)
+}
@@ -0,0 +1,55 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"sort"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// SearchMatch is an operation of the spec of SpecKey that matched a search, see Speculator.Search.
+type SearchMatch struct {
+	SpecKey SpecKey
+	_spec.OperationMatch
+}
+
+// Search returns the operations of all the in-memory specs that match query, e.g. the operations that return a
+// property called ssn, sorted by spec key (see _spec.Spec.Search for the order of each spec).
+// The specs of the configured SpecStore that were not loaded yet are not searched.
+// It is safe to call concurrently with ingestion.
+func (s *Speculator) Search(query *_spec.SearchQuery) []SearchMatch {
+	specs := s.getSpecs()
+	specKeys := make([]SpecKey, 0, len(specs))
+	for specKey := range specs {
+		specKeys = append(specKeys, specKey)
+	}
+	sort.Slice(specKeys, func(i, j int) bool {
+		return specKeys[i] < specKeys[j]
+	})
+
+	var matches []SearchMatch
+	for _, specKey := range specKeys {
+		for _, match := range specs[specKey].Search(query) {
+			matches = append(matches, SearchMatch{
+				SpecKey:        specKey,
+				OperationMatch: match,
+			})
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,53 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestSpeculator_Search(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	for i, host := range []string{"users", "billing", "catalog"} {
+		telemetry := createTelemetry("")
+		telemetry.Request.Host = host
+		if i < 2 {
+			telemetry.Response.Common = &spec.Common{
+				Headers: []*spec.Header{{Key: "Content-Type", Value: "application/json"}},
+				Body:    []byte(`{"ssn": "123"}`),
+			}
+		}
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	matches := s.Search(&spec.SearchQuery{PropertyName: "ssn"})
+	assert.DeepEqual(t, matches, []SearchMatch{
+		{
+			SpecKey:        GetSpecKey("billing", "80"),
+			OperationMatch: spec.OperationMatch{Path: "/api", Method: "GET", Fields: []string{"responses.200.schema.properties.ssn"}},
+		},
+		{
+			SpecKey:        GetSpecKey("users", "80"),
+			OperationMatch: spec.OperationMatch{Path: "/api", Method: "GET", Fields: []string{"responses.200.schema.properties.ssn"}},
+		},
+	})
+	assert.Equal(t, len(s.Search(&spec.SearchQuery{Path: "/api"})), 3)
+	assert.Equal(t, len(s.Search(&spec.SearchQuery{Path: "/missing"})), 0)
+}
@@ -0,0 +1,99 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+// beginIngestion registers an in-flight learn/diff, it fails once Shutdown was called.
+// Each successful call must be followed by endIngestion.
+func (s *Speculator) beginIngestion() error {
+	s.lifecycleLock.Lock()
+	defer s.lifecycleLock.Unlock()
+
+	if s.isShutdown {
+		return errors.ErrShutdown
+	}
+	s.inFlight.Add(1)
+	s.inFlightCount++
+	return nil
+}
+
+func (s *Speculator) endIngestion() {
+	s.lifecycleLock.Lock()
+	s.inFlightCount--
+	s.lifecycleLock.Unlock()
+
+	s.inFlight.Done()
+}
+
+// Shutdown stops accepting telemetries (Ingest/LearnTelemetry/DiffTelemetry return errors.ErrShutdown),
+// waits for queued and in-flight telemetries to be learned, saves the state to the StateStore and the specs to the
+// SpecStore, and closes the SpecStore and the event sinks.
+// If ctx is done before in-flight telemetries are drained, the state is not saved and ctx error is returned.
+func (s *Speculator) Shutdown(ctx context.Context) error {
+	s.lifecycleLock.Lock()
+	if s.isShutdown {
+		s.lifecycleLock.Unlock()
+		return errors.ErrShutdown
+	}
+	s.isShutdown = true
+	s.lifecycleLock.Unlock()
+
+	log.Info("Shutting down Speculator")
+
+	drained := make(chan struct{})
+	go func() {
+		if s.queue != nil {
+			s.queue.close()
+			s.queue.wait()
+		}
+		s.inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain in-flight telemetries: %w", ctx.Err())
+	}
+
+	var saveErr error
+	if s.config.StateStore != nil {
+		saveErr = s.SaveState()
+	}
+	s.stopSpecCheckpoints()
+	s.stopSilenceChecks()
+	if s.config.SpecStore != nil {
+		if err := s.CheckpointSpecs(); err != nil && saveErr == nil {
+			saveErr = err
+		}
+		if err := s.config.SpecStore.Close(); err != nil && saveErr == nil {
+			saveErr = fmt.Errorf("failed to close spec store: %w", err)
+		}
+	}
+
+	if err := s.closeEventSinks(); err != nil && saveErr == nil {
+		return fmt.Errorf("failed to close event sinks: %w", err)
+	}
+
+	return saveErr
+}
@@ -0,0 +1,100 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
+)
+
+type fakeEventSink struct {
+	diffs  []*spec.APIDiff
+	closed bool
+}
+
+func (f *fakeEventSink) HandleDiff(diff *spec.APIDiff) error {
+	f.diffs = append(f.diffs, diff)
+	return nil
+}
+
+func (f *fakeEventSink) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestSpeculator_Shutdown(t *testing.T) {
+	statePath := "/tmp/" + uuid.New().String() + "state.gob"
+	defer func() {
+		_ = os.Remove(statePath)
+	}()
+	sink := &fakeEventSink{}
+	s := CreateSpeculator(Config{
+		StateStore: NewFileStateStore(statePath),
+		EventSinks: []EventSink{sink},
+	})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1")))
+
+	assert.NilError(t, s.Shutdown(context.Background()))
+	assert.Assert(t, sink.closed)
+
+	// ingestion is stopped
+	assert.Assert(t, errors.Is(s.LearnTelemetry(createTelemetry("2")), _errors.ErrShutdown))
+	_, err := s.DiffTelemetry(createTelemetry("2"), spec.DiffSourceReconstructed)
+	assert.Assert(t, errors.Is(err, _errors.ErrShutdown))
+	assert.Assert(t, errors.Is(s.Shutdown(context.Background()), _errors.ErrShutdown))
+
+	// state was saved
+	loaded, err := NewFileStateStore(statePath).Load(Config{})
+	assert.NilError(t, err)
+	assert.Equal(t, loaded.Specs[GetSpecKey("host", "80")].LearningStats.TelemetryCount, 1)
+}
+
+func TestSpeculator_Shutdown_DrainTimeout(t *testing.T) {
+	sink := &fakeEventSink{}
+	s := CreateSpeculator(Config{
+		EventSinks: []EventSink{sink},
+	})
+	// simulate an in-flight telemetry that is never done
+	assert.NilError(t, s.beginIngestion())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	assert.Assert(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Assert(t, !sink.closed)
+}
+
+func TestSpeculator_sendDiff(t *testing.T) {
+	sink := &fakeEventSink{}
+	s := CreateSpeculator(Config{
+		EventSinks: []EventSink{sink},
+	})
+
+	s.sendDiff(nil)
+	s.sendDiff(&spec.APIDiff{Type: spec.DiffTypeNoDiff})
+	s.sendDiff(&spec.APIDiff{Type: spec.DiffTypeShadowDiff, Path: "/api"})
+
+	assert.DeepEqual(t, sink.diffs, []*spec.APIDiff{{Type: spec.DiffTypeShadowDiff, Path: "/api"}})
+}
@@ -0,0 +1,83 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"net"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// SourceClassifier labels the source of a telemetry request, using its SourceAddress, headers etc.
+type SourceClassifier func(telemetry *_spec.Telemetry) _spec.SourceLabel
+
+// NewCIDRSourceClassifier creates a SourceClassifier that labels requests by their source address:
+// addresses in internalCIDRs (or loopback) are internal, addresses in partnerCIDRs are partner, and any other address is external.
+func NewCIDRSourceClassifier(internalCIDRs, partnerCIDRs []string) (SourceClassifier, error) {
+	internal, err := parseCIDRs(internalCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal CIDRs: %v", err)
+	}
+	partner, err := parseCIDRs(partnerCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid partner CIDRs: %v", err)
+	}
+
+	return func(telemetry *_spec.Telemetry) _spec.SourceLabel {
+		ip := getSourceIP(telemetry.SourceAddress)
+		if ip == nil {
+			return _spec.SourceUnknown
+		}
+		switch {
+		case ip.IsLoopback() || containsIP(internal, ip):
+			return _spec.SourceInternal
+		case containsIP(partner, ip):
+			return _spec.SourcePartner
+		default:
+			return _spec.SourceExternal
+		}
+	}, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	ret := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CIDR %q: %v", cidr, err)
+		}
+		ret = append(ret, ipNet)
+	}
+	return ret, nil
+}
+
+// getSourceIP returns the IP of an "ip:port" or "ip" address, or nil if address is not valid.
+func getSourceIP(address string) net.IP {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	return net.ParseIP(address)
+}
+
+func containsIP(ipNets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range ipNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
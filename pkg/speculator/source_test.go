@@ -0,0 +1,106 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestNewCIDRSourceClassifier(t *testing.T) {
+	classifier, err := NewCIDRSourceClassifier([]string{"10.0.0.0/8"}, []string{"192.168.1.0/24"})
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name          string
+		sourceAddress string
+		want          spec.SourceLabel
+	}{
+		{
+			name:          "internal with port",
+			sourceAddress: "10.1.2.3:5000",
+			want:          spec.SourceInternal,
+		},
+		{
+			name:          "loopback",
+			sourceAddress: "127.0.0.1",
+			want:          spec.SourceInternal,
+		},
+		{
+			name:          "partner",
+			sourceAddress: "192.168.1.10:80",
+			want:          spec.SourcePartner,
+		},
+		{
+			name:          "external",
+			sourceAddress: "8.8.8.8:443",
+			want:          spec.SourceExternal,
+		},
+		{
+			name:          "invalid address",
+			sourceAddress: "not-an-ip",
+			want:          spec.SourceUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifier(&spec.Telemetry{SourceAddress: tt.sourceAddress})
+			assert.Equal(t, got, tt.want)
+		})
+	}
+
+	_, err = NewCIDRSourceClassifier([]string{"10.0.0.0"}, nil)
+	assert.ErrorContains(t, err, "invalid internal CIDRs")
+}
+
+func TestSpeculator_LearnTelemetry_SplitSpecsBySource(t *testing.T) {
+	classifier, err := NewCIDRSourceClassifier([]string{"10.0.0.0/8"}, nil)
+	assert.NilError(t, err)
+	s := CreateSpeculator(Config{
+		SourceClassifier:   classifier,
+		SplitSpecsBySource: true,
+	})
+
+	internalTelemetry := createTelemetry("1")
+	internalTelemetry.SourceAddress = "10.0.0.2:1234"
+	externalTelemetry := createTelemetry("2")
+	externalTelemetry.SourceAddress = "8.8.8.8:1234"
+	labeledTelemetry := createTelemetry("3")
+	labeledTelemetry.SourceAddress = "8.8.8.8:1234"
+	labeledTelemetry.Source = spec.SourcePartner
+	for _, telemetry := range []*spec.Telemetry{internalTelemetry, externalTelemetry, labeledTelemetry} {
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	// the caller's telemetry is not modified
+	assert.Equal(t, internalTelemetry.Source, spec.SourceLabel(""))
+
+	specKey := GetSpecKey("host", "80")
+	assert.DeepEqual(t, s.Specs[specKey].LearningStats.Sources, map[spec.SourceLabel]int{
+		spec.SourceInternal: 1,
+		spec.SourceExternal: 1,
+		spec.SourcePartner:  1,
+	})
+	for _, source := range []spec.SourceLabel{spec.SourceInternal, spec.SourceExternal, spec.SourcePartner} {
+		sourceSpec, err := s.GetSourceSpec(source, specKey)
+		assert.NilError(t, err)
+		assert.Equal(t, sourceSpec.LearningStats.TelemetryCount, 1)
+	}
+	_, err = s.GetSourceSpec(spec.SourceUnknown, specKey)
+	assert.ErrorContains(t, err, "no unknown spec")
+}
@@ -0,0 +1,299 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// the version of an encoded spec, see encodeSpec. Version 1 specs were encoded with untyped path tries.
+const currentSpecVersion = 2
+
+// the extension of the spec files of DirSpecStore
+const dirSpecStoreFileExt = ".spec"
+
+// SpecStore persists each spec on its own, so specs are checkpointed and loaded one by one instead of
+// with the whole state, see Config.SpecStore.
+// The specs are encoded by the Speculator, a store only keeps their bytes by spec key, so a store can be
+// backed by a file system (DirSpecStore), a key/value store (BoltSpecStore) or a database table (SQLiteSpecStore).
+type SpecStore interface {
+	SaveSpec(key SpecKey, specB []byte) error
+	// LoadSpec returns nil when no spec was saved for key
+	LoadSpec(key SpecKey) ([]byte, error)
+	ListSpecs() ([]SpecKey, error)
+	// DeleteSpec does nothing when no spec was saved for key
+	DeleteSpec(key SpecKey) error
+	Close() error
+}
+
+// specEnvelope is an encoded spec of SpecStore.
+type specEnvelope struct {
+	SpecVersion int
+	Spec        *_spec.Spec
+}
+
+// untypedTriesSpecEnvelope is a specEnvelope encoded with the untyped spec path tries, see decodeSpec.
+type untypedTriesSpecEnvelope struct {
+	SpecVersion int
+	Spec        *_spec.UntypedTriesSpec
+}
+
+// encodeSpec encodes spec with its lock held for reading.
+func encodeSpec(spec *_spec.Spec) ([]byte, error) {
+	var buf bytes.Buffer
+	envelope := &specEnvelope{
+		SpecVersion: currentSpecVersion,
+		Spec:        spec,
+	}
+	if err := spec.WithReadLock(func() error {
+		return gob.NewEncoder(&buf).Encode(envelope)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode spec: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeSpec(specB []byte) (*_spec.Spec, error) {
+	envelope := &specEnvelope{}
+	if err := gob.NewDecoder(bytes.NewReader(specB)).Decode(envelope); err != nil {
+		untypedEnvelope := &untypedTriesSpecEnvelope{}
+		if untypedErr := gob.NewDecoder(bytes.NewReader(specB)).Decode(untypedEnvelope); untypedErr != nil || untypedEnvelope.Spec == nil {
+			return nil, fmt.Errorf("failed to decode spec: %v", err)
+		}
+		if envelope.Spec, err = untypedEnvelope.Spec.ToSpec(); err != nil {
+			return nil, fmt.Errorf("failed to decode spec: %v", err)
+		}
+		envelope.SpecVersion = untypedEnvelope.SpecVersion
+	}
+	if envelope.SpecVersion > currentSpecVersion {
+		return nil, fmt.Errorf("spec version %v is newer than the supported version %v", envelope.SpecVersion, currentSpecVersion)
+	}
+	if envelope.Spec == nil {
+		return nil, fmt.Errorf("spec is missing")
+	}
+
+	return envelope.Spec, nil
+}
+
+// CheckpointSpecs saves every spec to the configured SpecStore. The specs are encoded one at a time, so the telemetries
+// of the other specs are learned meanwhile, and are saved without holding their locks, see checkpointSpec.
+// A spec that fails to save does not stop the others from being saved, the first error is returned.
+func (s *Speculator) CheckpointSpecs() error {
+	if s.config.SpecStore == nil {
+		return fmt.Errorf("no spec store is configured")
+	}
+
+	specs := s.getSpecs()
+	keys := make([]string, 0, len(specs))
+	for key := range specs {
+		keys = append(keys, string(key))
+	}
+	sort.Strings(keys)
+
+	var retErr error
+	for _, key := range keys {
+		specKey := SpecKey(key)
+		if err := s.checkpointSpec(specKey, specs[specKey]); err != nil {
+			log.Errorf("Failed to checkpoint spec %v: %v", specKey, err)
+			if retErr == nil {
+				retErr = fmt.Errorf("failed to checkpoint spec %v: %w", specKey, err)
+			}
+		}
+	}
+	if retErr == nil {
+		s.health.recordPersistence(time.Now())
+	}
+
+	return retErr
+}
+
+// checkpointSpec encodes spec with the lock of specKey (see lockSpec) and its own lock held, and saves it once they
+// are released.
+func (s *Speculator) checkpointSpec(specKey SpecKey, spec *_spec.Spec) error {
+	unlock := s.lockSpec(specKey)
+	specB, err := encodeSpec(spec)
+	unlock()
+	if err != nil {
+		return err
+	}
+	if err := s.config.SpecStore.SaveSpec(specKey, specB); err != nil {
+		return fmt.Errorf("failed to save spec: %v", err)
+	}
+
+	return nil
+}
+
+// getOrLoadSpec returns the spec of specKey with the lock of specKey held, see lockSpec. A spec that is not in memory is loaded from
+// the configured SpecStore, with the operation generator config of its host. It returns nil when there is no
+// spec for specKey.
+func (s *Speculator) getOrLoadSpec(specKey SpecKey) (*_spec.Spec, error) {
+	if spec, ok := s.getSpec(specKey); ok {
+		return spec, nil
+	}
+	if s.config.SpecStore == nil {
+		return nil, nil
+	}
+
+	specB, err := s.config.SpecStore.LoadSpec(specKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spec %v: %v", specKey, err)
+	}
+	if specB == nil {
+		return nil, nil
+	}
+	spec, err := decodeSpec(specB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spec %v: %w", specKey, err)
+	}
+	spec.SetOperationGeneratorConfig(s.getOperationGeneratorConfig(spec.Host, spec.Port))
+	s.setSpec(specKey, spec)
+	log.Infof("Spec %v was loaded from the spec store", specKey)
+
+	return spec, nil
+}
+
+// startSpecCheckpoints checkpoints the specs every Config.SpecCheckpointInterval until Shutdown.
+func (s *Speculator) startSpecCheckpoints() {
+	if s.config.SpecStore == nil || s.config.SpecCheckpointInterval <= 0 {
+		return
+	}
+	s.checkpointStop = make(chan struct{})
+	s.checkpointDone = make(chan struct{})
+	go s.runSpecCheckpoints(s.config.SpecCheckpointInterval)
+}
+
+func (s *Speculator) runSpecCheckpoints(interval time.Duration) {
+	defer close(s.checkpointDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.checkpointStop:
+			return
+		case <-ticker.C:
+			if err := s.CheckpointSpecs(); err != nil {
+				log.Errorf("Failed to checkpoint specs: %v", err)
+			}
+		}
+	}
+}
+
+func (s *Speculator) stopSpecCheckpoints() {
+	if s.checkpointStop == nil {
+		return
+	}
+	close(s.checkpointStop)
+	<-s.checkpointDone
+	s.checkpointStop = nil
+}
+
+// DirSpecStore stores each spec in its own file of a directory.
+type DirSpecStore struct {
+	Dir string
+}
+
+func NewDirSpecStore(dir string) (*DirSpecStore, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create spec store directory: %v", err)
+	}
+	return &DirSpecStore{
+		Dir: dir,
+	}, nil
+}
+
+func (d *DirSpecStore) specPath(key SpecKey) string {
+	return filepath.Join(d.Dir, url.PathEscape(string(key))+dirSpecStoreFileExt)
+}
+
+// SaveSpec replaces the spec file atomically, so an aborted save keeps the previous spec.
+func (d *DirSpecStore) SaveSpec(key SpecKey, specB []byte) error {
+	path := d.specPath(key)
+	tmpFile, err := ioutil.TempFile(d.Dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create spec file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(specB); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write spec file: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write spec file: %v", err)
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace spec file: %v", err)
+	}
+	return nil
+}
+
+func (d *DirSpecStore) LoadSpec(key SpecKey) ([]byte, error) {
+	specB, err := ioutil.ReadFile(d.specPath(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %v", err)
+	}
+	return specB, nil
+}
+
+// ListSpecs returns the keys of the saved specs, sorted.
+func (d *DirSpecStore) ListSpecs() ([]SpecKey, error) {
+	entries, err := ioutil.ReadDir(d.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec store directory: %v", err)
+	}
+	var keys []SpecKey
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, dirSpecStoreFileExt) {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSuffix(name, dirSpecStoreFileExt))
+		if err != nil {
+			log.Warnf("Skipping spec file %v: %v", name, err)
+			continue
+		}
+		keys = append(keys, SpecKey(key))
+	}
+	return keys, nil
+}
+
+func (d *DirSpecStore) DeleteSpec(key SpecKey) error {
+	if err := os.Remove(d.specPath(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spec file: %v", err)
+	}
+	return nil
+}
+
+func (d *DirSpecStore) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// the bucket of the specs of BoltSpecStore
+var boltSpecsBucket = []byte("specs")
+
+// BoltSpecStore stores each spec under its key in a bucket of a BoltDB file.
+type BoltSpecStore struct {
+	db *bolt.DB
+}
+
+// NewBoltSpecStore opens, or creates, the BoltDB file of path. BoltDB locks the file, so a file can only be opened by one store.
+func NewBoltSpecStore(path string) (*BoltSpecStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spec store database: %v", err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltSpecsBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create spec store bucket: %v", err)
+	}
+	return &BoltSpecStore{
+		db: db,
+	}, nil
+}
+
+func (b *BoltSpecStore) SaveSpec(key SpecKey, specB []byte) error {
+	if err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSpecsBucket).Put([]byte(key), specB)
+	}); err != nil {
+		return fmt.Errorf("failed to save spec: %v", err)
+	}
+	return nil
+}
+
+func (b *BoltSpecStore) LoadSpec(key SpecKey) ([]byte, error) {
+	var specB []byte
+	if err := b.db.View(func(tx *bolt.Tx) error {
+		// the value is only valid during the transaction
+		if value := tx.Bucket(boltSpecsBucket).Get([]byte(key)); value != nil {
+			specB = append([]byte{}, value...)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load spec: %v", err)
+	}
+	return specB, nil
+}
+
+// ListSpecs returns the keys of the saved specs, sorted.
+func (b *BoltSpecStore) ListSpecs() ([]SpecKey, error) {
+	var keys []SpecKey
+	if err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSpecsBucket).ForEach(func(key, _ []byte) error {
+			keys = append(keys, SpecKey(key))
+			return nil
+		})
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list specs: %v", err)
+	}
+	return keys, nil
+}
+
+func (b *BoltSpecStore) DeleteSpec(key SpecKey) error {
+	if err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSpecsBucket).Delete([]byte(key))
+	}); err != nil {
+		return fmt.Errorf("failed to delete spec: %v", err)
+	}
+	return nil
+}
+
+func (b *BoltSpecStore) Close() error {
+	if err := b.db.Close(); err != nil {
+		return fmt.Errorf("failed to close spec store database: %v", err)
+	}
+	return nil
+}
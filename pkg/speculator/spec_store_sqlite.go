@@ -0,0 +1,103 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	// registers the sqlite3 database/sql driver, it requires cgo
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteSpecStore stores each spec in a row of the specs table of a SQLite database.
+type SQLiteSpecStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteSpecStore opens, or creates, the SQLite database file of path.
+func NewSQLiteSpecStore(path string) (*SQLiteSpecStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spec store database: %v", err)
+	}
+	// SQLite allows a single writer, concurrent connections would fail with "database is locked"
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS specs (key TEXT PRIMARY KEY, spec BLOB NOT NULL)"); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create spec store table: %v", err)
+	}
+	return &SQLiteSpecStore{
+		db: db,
+	}, nil
+}
+
+func (s *SQLiteSpecStore) SaveSpec(key SpecKey, specB []byte) error {
+	if _, err := s.db.Exec("INSERT INTO specs (key, spec) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET spec = excluded.spec",
+		string(key), specB); err != nil {
+		return fmt.Errorf("failed to save spec: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLiteSpecStore) LoadSpec(key SpecKey) ([]byte, error) {
+	var specB []byte
+	err := s.db.QueryRow("SELECT spec FROM specs WHERE key = ?", string(key)).Scan(&specB)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spec: %v", err)
+	}
+	return specB, nil
+}
+
+// ListSpecs returns the keys of the saved specs, sorted.
+func (s *SQLiteSpecStore) ListSpecs() ([]SpecKey, error) {
+	rows, err := s.db.Query("SELECT key FROM specs ORDER BY key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list specs: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []SpecKey
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to list specs: %v", err)
+		}
+		keys = append(keys, SpecKey(key))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list specs: %v", err)
+	}
+	return keys, nil
+}
+
+func (s *SQLiteSpecStore) DeleteSpec(key SpecKey) error {
+	if _, err := s.db.Exec("DELETE FROM specs WHERE key = ?", string(key)); err != nil {
+		return fmt.Errorf("failed to delete spec: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLiteSpecStore) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("failed to close spec store database: %v", err)
+	}
+	return nil
+}
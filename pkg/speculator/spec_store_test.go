@@ -0,0 +1,291 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestDirSpecStore(t *testing.T) {
+	store, err := NewDirSpecStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirSpecStore() error = %v", err)
+	}
+	testSpecStore(t, store)
+}
+
+func TestBoltSpecStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "specs.db")
+	store, err := NewBoltSpecStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltSpecStore() error = %v", err)
+	}
+	testSpecStore(t, store)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	store, err = NewBoltSpecStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltSpecStore() of an existing database error = %v", err)
+	}
+	defer store.Close()
+	testReopenedSpecStore(t, store)
+}
+
+func TestSQLiteSpecStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "specs.sqlite")
+	store, err := NewSQLiteSpecStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteSpecStore() error = %v", err)
+	}
+	testSpecStore(t, store)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	store, err = NewSQLiteSpecStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteSpecStore() of an existing database error = %v", err)
+	}
+	defer store.Close()
+	testReopenedSpecStore(t, store)
+}
+
+// testSpecStore saves, loads, lists and deletes specs of an empty store, it keeps the spec of "host:80".
+func testSpecStore(t *testing.T, store SpecStore) {
+	t.Helper()
+	specKey := GetSpecKey("host", "80")
+	otherKey := GetSpecKey("other/host", "8080")
+
+	specB, err := store.LoadSpec(specKey)
+	if err != nil || specB != nil {
+		t.Fatalf("LoadSpec() of a missing spec = %v, %v, want nil, nil", specB, err)
+	}
+	if err := store.SaveSpec(specKey, []byte("first")); err != nil {
+		t.Fatalf("SaveSpec() error = %v", err)
+	}
+	if err := store.SaveSpec(specKey, []byte("second")); err != nil {
+		t.Fatalf("SaveSpec() error = %v", err)
+	}
+	if err := store.SaveSpec(otherKey, []byte("other")); err != nil {
+		t.Fatalf("SaveSpec() error = %v", err)
+	}
+
+	specB, err = store.LoadSpec(specKey)
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if string(specB) != "second" {
+		t.Errorf("LoadSpec() = %s, want second", specB)
+	}
+	keys, err := store.ListSpecs()
+	if err != nil {
+		t.Fatalf("ListSpecs() error = %v", err)
+	}
+	wantKeys := []SpecKey{"host:80", "other/host:8080"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("ListSpecs() = %v, want %v", keys, wantKeys)
+	}
+
+	if err := store.DeleteSpec(otherKey); err != nil {
+		t.Fatalf("DeleteSpec() error = %v", err)
+	}
+	if err := store.DeleteSpec(otherKey); err != nil {
+		t.Fatalf("DeleteSpec() of a missing spec error = %v", err)
+	}
+	specB, err = store.LoadSpec(otherKey)
+	if err != nil || specB != nil {
+		t.Fatalf("LoadSpec() of a deleted spec = %v, %v, want nil, nil", specB, err)
+	}
+	keys, err = store.ListSpecs()
+	if err != nil {
+		t.Fatalf("ListSpecs() error = %v", err)
+	}
+	wantKeys = []SpecKey{"host:80"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("ListSpecs() after DeleteSpec() = %v, want %v", keys, wantKeys)
+	}
+}
+
+// testReopenedSpecStore checks the spec kept by testSpecStore is loaded by a store reopened on the same database.
+func testReopenedSpecStore(t *testing.T, store SpecStore) {
+	t.Helper()
+	specB, err := store.LoadSpec(GetSpecKey("host", "80"))
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if string(specB) != "second" {
+		t.Errorf("LoadSpec() of a reopened store = %s, want second", specB)
+	}
+}
+
+func TestSpeculator_SpecStore_LazyLoad(t *testing.T) {
+	dir := t.TempDir()
+	specKey := GetSpecKey("host", "80")
+
+	store, err := NewDirSpecStore(dir)
+	if err != nil {
+		t.Fatalf("NewDirSpecStore() error = %v", err)
+	}
+	s := CreateSpeculator(Config{SpecStore: store})
+	if err := s.LearnTelemetry(createTelemetry("1")); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	store, err = NewDirSpecStore(dir)
+	if err != nil {
+		t.Fatalf("NewDirSpecStore() error = %v", err)
+	}
+	s = CreateSpeculator(Config{SpecStore: store})
+	if len(s.Specs) != 0 {
+		t.Fatalf("specs should not be loaded before a telemetry of their key, got %v", len(s.Specs))
+	}
+	if _, err := s.DiffTelemetry(createTelemetry("2"), _spec.DiffSourceReconstructed); err != nil {
+		t.Fatalf("DiffTelemetry() of a stored spec error = %v", err)
+	}
+	if err := s.LearnTelemetry(createTelemetry("2")); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+	stats := s.Specs[specKey].Stats()
+	if got := stats.Operations["/api"]["GET"].HitCount; got != 2 {
+		t.Errorf("HitCount = %v, want 2 (the stored telemetry and the new one)", got)
+	}
+}
+
+func TestSpeculator_SpecStore_Checkpoints(t *testing.T) {
+	store, err := NewDirSpecStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirSpecStore() error = %v", err)
+	}
+	s := CreateSpeculator(Config{SpecStore: store, SpecCheckpointInterval: 10 * time.Millisecond})
+	defer func() { _ = s.Shutdown(context.Background()) }()
+	if err := s.LearnTelemetry(createTelemetry("1")); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		specB, err := store.LoadSpec(GetSpecKey("host", "80"))
+		if err != nil {
+			t.Fatalf("LoadSpec() error = %v", err)
+		}
+		if specB != nil {
+			spec, err := decodeSpec(specB)
+			if err != nil {
+				t.Fatalf("decodeSpec() error = %v", err)
+			}
+			if _, ok := spec.LearningSpec.PathItems["/api"]; !ok {
+				t.Errorf("checkpointed spec is missing the learned path")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("spec was not checkpointed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSpeculator_CheckpointSpecs_WhileLearning(t *testing.T) {
+	store, err := NewDirSpecStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirSpecStore() error = %v", err)
+	}
+	s := CreateSpeculator(Config{SpecStore: store})
+	defer func() { _ = s.Shutdown(context.Background()) }()
+	if err := s.LearnTelemetry(createTelemetry("0")); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i < 50; i++ {
+			if err := s.LearnTelemetry(createTelemetry(strconv.Itoa(i))); err != nil {
+				t.Errorf("LearnTelemetry() error = %v", err)
+			}
+		}
+	}()
+	// A reader of specsLock, e.g. a concurrent learner, must not block the checkpoints.
+	s.specsLock.RLock()
+	for i := 0; i < 10; i++ {
+		if err := s.CheckpointSpecs(); err != nil {
+			t.Errorf("CheckpointSpecs() error = %v", err)
+		}
+	}
+	s.specsLock.RUnlock()
+	wg.Wait()
+
+	specB, err := store.LoadSpec(GetSpecKey("host", "80"))
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if _, err := decodeSpec(specB); err != nil {
+		t.Fatalf("decodeSpec() error = %v", err)
+	}
+}
+
+func TestDecodeSpec_NewerVersion(t *testing.T) {
+	specB, err := encodeSpec(_spec.CreateDefaultSpec("host", "80", _spec.OperationGeneratorConfig{}))
+	if err != nil {
+		t.Fatalf("encodeSpec() error = %v", err)
+	}
+	if _, err := decodeSpec(specB); err != nil {
+		t.Fatalf("decodeSpec() error = %v", err)
+	}
+
+	newerEnvelope := &specEnvelope{SpecVersion: currentSpecVersion + 1, Spec: _spec.CreateDefaultSpec("host", "80", _spec.OperationGeneratorConfig{})}
+	newerB := &bytes.Buffer{}
+	if err := gob.NewEncoder(newerB).Encode(newerEnvelope); err != nil {
+		t.Fatalf("failed to encode spec envelope: %v", err)
+	}
+	if _, err := decodeSpec(newerB.Bytes()); err == nil {
+		t.Errorf("decodeSpec() of a newer spec version should fail")
+	}
+}
+
+func TestDecodeSpec_UntypedTries(t *testing.T) {
+	spec := _spec.CreateDefaultSpec("host", "80", _spec.OperationGeneratorConfig{})
+	spec.ApprovedPathTrie.Insert("/api/{param1}", "path-id")
+	untypedEnvelope := &untypedTriesSpecEnvelope{SpecVersion: 1, Spec: createUntypedTriesSpec(spec)}
+	untypedB := &bytes.Buffer{}
+	if err := gob.NewEncoder(untypedB).Encode(untypedEnvelope); err != nil {
+		t.Fatalf("failed to encode spec envelope: %v", err)
+	}
+
+	got, err := decodeSpec(untypedB.Bytes())
+	if err != nil {
+		t.Fatalf("decodeSpec() error = %v", err)
+	}
+	if _, pathID, _ := got.ApprovedPathTrie.GetPathAndValue("/api/1"); pathID != "path-id" {
+		t.Errorf("decodeSpec() path ID = %v, want path-id", pathID)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// the amount of locks the specs are sharded into, telemetries of specs of different shards are learned concurrently.
+const specLockShards = 32
+
+// lockSpec locks the shard of specKey and returns its unlock function.
+func (s *Speculator) lockSpec(specKey SpecKey) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(specKey))
+	lock := &s.specLocks[h.Sum32()%specLockShards]
+	lock.Lock()
+	return lock.Unlock
+}
+
+// getSpec returns the in-memory spec of specKey, it is safe to call concurrently with ingestion.
+func (s *Speculator) getSpec(specKey SpecKey) (*_spec.Spec, bool) {
+	s.specsMapLock.Lock()
+	defer s.specsMapLock.Unlock()
+
+	spec, ok := s.Specs[specKey]
+	return spec, ok
+}
+
+func (s *Speculator) setSpec(specKey SpecKey, spec *_spec.Spec) {
+	s.specsMapLock.Lock()
+	defer s.specsMapLock.Unlock()
+
+	s.Specs[specKey] = spec
+	s.addLearningObservers(spec)
+}
+
+// getSpecs returns the in-memory specs by key.
+func (s *Speculator) getSpecs() map[SpecKey]*_spec.Spec {
+	s.specsMapLock.Lock()
+	defer s.specsMapLock.Unlock()
+
+	specs := make(map[SpecKey]*_spec.Spec, len(s.Specs))
+	for specKey, spec := range s.Specs {
+		specs[specKey] = spec
+	}
+	return specs
+}
+
+// ListSpecKeys returns the keys of the specs, in memory or in the configured SpecStore, sorted.
+func (s *Speculator) ListSpecKeys() ([]SpecKey, error) {
+	keys := make(map[SpecKey]bool)
+	for specKey := range s.getSpecs() {
+		keys[specKey] = true
+	}
+	if s.config.SpecStore != nil {
+		storedKeys, err := s.config.SpecStore.ListSpecs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stored specs: %v", err)
+		}
+		for _, specKey := range storedKeys {
+			keys[specKey] = true
+		}
+	}
+
+	ret := make([]SpecKey, 0, len(keys))
+	for specKey := range keys {
+		ret = append(ret, specKey)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i] < ret[j]
+	})
+	return ret, nil
+}
+
+// DeleteSpec deletes the spec of specKey, with its per source specs, from memory and from the configured SpecStore.
+// A telemetry learned afterwards creates a new spec.
+func (s *Speculator) DeleteSpec(specKey SpecKey) error {
+	s.specsLock.RLock()
+	defer s.specsLock.RUnlock()
+	unlock := s.lockSpec(specKey)
+	defer unlock()
+
+	s.specsMapLock.Lock()
+	_, found := s.Specs[specKey]
+	delete(s.Specs, specKey)
+	for _, specs := range s.SourceSpecs {
+		delete(specs, specKey)
+	}
+	s.specsMapLock.Unlock()
+
+	if s.config.SpecStore != nil {
+		specB, err := s.config.SpecStore.LoadSpec(specKey)
+		if err != nil {
+			return fmt.Errorf("failed to load stored spec %v: %v", specKey, err)
+		}
+		if specB != nil {
+			found = true
+			if err := s.config.SpecStore.DeleteSpec(specKey); err != nil {
+				return fmt.Errorf("failed to delete stored spec %v: %v", specKey, err)
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	log.Infof("Spec %v was deleted", specKey)
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestSpeculator_LearnTelemetry_Concurrent(t *testing.T) {
+	const hosts = 8
+	const telemetriesPerHost = 20
+	s := CreateSpeculator(Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < hosts; i++ {
+		host := fmt.Sprintf("host%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < telemetriesPerHost; j++ {
+				if err := s.LearnTelemetry(createHostTelemetry(host, "10.0.0.1:80", "GET", "/api")); err != nil {
+					t.Errorf("LearnTelemetry() error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	keys, err := s.ListSpecKeys()
+	if err != nil {
+		t.Fatalf("ListSpecKeys() error = %v", err)
+	}
+	if len(keys) != hosts {
+		t.Fatalf("ListSpecKeys() = %v, want %v keys", keys, hosts)
+	}
+	for _, specKey := range keys {
+		stats := s.Specs[specKey].Stats()
+		if stats.TelemetryCount != telemetriesPerHost {
+			t.Errorf("TelemetryCount of %v = %v, want %v", specKey, stats.TelemetryCount, telemetriesPerHost)
+		}
+	}
+}
+
+func TestSpeculator_DeleteSpec(t *testing.T) {
+	store, err := NewDirSpecStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirSpecStore() error = %v", err)
+	}
+	s := CreateSpeculator(Config{SpecStore: store, SplitSpecsBySource: true})
+	for _, host := range []string{"b", "a", "c"} {
+		if err := s.LearnTelemetry(createHostTelemetry(host, "10.0.0.1:80", "GET", "/api")); err != nil {
+			t.Fatalf("LearnTelemetry() error = %v", err)
+		}
+	}
+	if err := s.CheckpointSpecs(); err != nil {
+		t.Fatalf("CheckpointSpecs() error = %v", err)
+	}
+	// only in the store
+	delete(s.Specs, GetSpecKey("c", "80"))
+
+	keys, err := s.ListSpecKeys()
+	if err != nil {
+		t.Fatalf("ListSpecKeys() error = %v", err)
+	}
+	if want := []SpecKey{"a:80", "b:80", "c:80"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ListSpecKeys() = %v, want %v", keys, want)
+	}
+
+	for _, specKey := range []SpecKey{"a:80", "c:80"} {
+		if err := s.DeleteSpec(specKey); err != nil {
+			t.Fatalf("DeleteSpec(%v) error = %v", specKey, err)
+		}
+	}
+	if err := s.DeleteSpec("a:80"); err == nil {
+		t.Errorf("DeleteSpec() of a deleted spec should fail")
+	}
+	keys, err = s.ListSpecKeys()
+	if err != nil {
+		t.Fatalf("ListSpecKeys() error = %v", err)
+	}
+	if want := []SpecKey{"b:80"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ListSpecKeys() after delete = %v, want %v", keys, want)
+	}
+	if _, err := s.GetSourceSpec(spec.SourceUnknown, "a:80"); err == nil {
+		t.Errorf("source spec of a deleted spec should be deleted")
+	}
+
+	// a deleted spec is learned from scratch
+	if err := s.LearnTelemetry(createHostTelemetry("a", "10.0.0.1:80", "GET", "/api")); err != nil {
+		t.Fatalf("LearnTelemetry() error = %v", err)
+	}
+	stats := s.Specs["a:80"].Stats()
+	if stats.TelemetryCount != 1 {
+		t.Errorf("TelemetryCount = %v, want 1", stats.TelemetryCount)
+	}
+}
@@ -16,45 +16,140 @@
 package speculator
 
 import (
-	"encoding/gob"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	_spec "github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/specdiff"
 )
 
 type SpecKey string
 
 type Config struct {
 	OperationGeneratorConfig _spec.OperationGeneratorConfig
+	// MaxClockSkew is the amount of time a telemetry timestamp may be ahead of the local clock,
+	// later timestamps are replaced with the local time. Defaults to _spec.DefaultMaxClockSkew.
+	MaxClockSkew time.Duration
+	// DeduplicationWindow is the amount of time a telemetry RequestID is remembered,
+	// a telemetry with a RequestID that was already learned in the window is ignored. Disabled when zero.
+	DeduplicationWindow time.Duration
+	// Enrichers annotate each telemetry with caller metadata before it is classified, learned or diffed, optional
+	Enrichers []Enricher
+	// SourceClassifier labels the source of telemetries that were not labeled by the sender, optional
+	SourceClassifier SourceClassifier
+	// SplitSpecsBySource learns an additional spec per source label, see GetSourceSpec
+	SplitSpecsBySource bool
+	// HostConfigs maps "host:port" or "host" into options that override the global ones for specs of that host
+	HostConfigs map[string]HostConfig
+	// StateStore the state is saved to on Shutdown, optional
+	StateStore StateStore
+	// SpecStore each spec is saved to on Shutdown and every SpecCheckpointInterval, a spec that is not in memory
+	// is loaded from it on the first telemetry of its key, optional
+	SpecStore SpecStore
+	// SpecCheckpointInterval is the interval specs are saved to SpecStore at, specs are only saved on Shutdown when zero
+	SpecCheckpointInterval time.Duration
+	// EventSinks receive the diffs found by DiffTelemetry and are closed on Shutdown, optional
+	EventSinks []EventSink
+	// Ingestion configures the queue of Ingest
+	Ingestion IngestionConfig
+	// MaxPoisonedTelemetries is the amount of telemetries that panicked kept for analysis, see GetPoisonedTelemetries.
+	// Defaults to defaultMaxPoisonedTelemetries.
+	MaxPoisonedTelemetries int
+	// ScannerDetection excludes the telemetries of scanners and fuzzers from learning, disabled by default
+	ScannerDetection ScannerDetectionConfig
+	// TrackDependencies records the calls between the sources and the services of the learned telemetries,
+	// see GetDependencyGraph
+	TrackDependencies bool
+	// Staleness configures when specs are stale or silent, see GetSpecFreshness
+	Staleness StalenessConfig
+	// Filter drops the telemetries that are not part of the API before they are learned or diffed
+	Filter FilterConfig
+	// Redaction redacts the sensitive values of the telemetries before they are learned or diffed, so that they
+	// are never part of the learned examples. Not redacted when nil.
+	Redaction *_spec.RedactionPolicy
 }
 
 type Speculator struct {
 	Specs map[SpecKey]*_spec.Spec `json:"specs,omitempty"`
+	// SourceSpecs holds the specs learned per source label when Config.SplitSpecsBySource is set
+	SourceSpecs map[_spec.SourceLabel]map[SpecKey]*_spec.Spec `json:"sourceSpecs,omitempty"`
 
 	// config is not exported and is not encoded part of the state
 	config Config
+	// requestIDs is nil when deduplication is disabled, not encoded part of the state
+	requestIDs *requestIDCache
+	// scanners is nil when scanner detection is disabled, not encoded part of the state
+	scanners *scannerDetector
+
+	// lifecycle of in-flight telemetries, see Shutdown
+	lifecycleLock sync.Mutex
+	isShutdown    bool
+	inFlight      sync.WaitGroup
+	inFlightCount int
+
+	// specsLock is held for reading while telemetries are learned or diffed, and for writing by the
+	// operations on all the specs (e.g. ReloadConfig)
+	specsLock sync.RWMutex
+	// specsMapLock guards the Specs and SourceSpecs maps, the request IDs and the learning observers, see getSpec
+	specsMapLock      sync.Mutex
+	learningObservers []_spec.LearningObserver
+	// specLocks serialize learning and diffing the telemetries of a spec, see lockSpec
+	specLocks [specLockShards]sync.Mutex
+	// queue is nil when ingestion is synchronous, see Ingest
+	queue *ingestQueue
+	// checkpointStop is nil when specs are not checkpointed periodically, see Config.SpecCheckpointInterval
+	checkpointStop chan struct{}
+	checkpointDone chan struct{}
+	// silenceCheckStop is nil when silent specs are not checked periodically, see StalenessConfig.CheckInterval
+	silenceCheckStop chan struct{}
+	silenceCheckDone chan struct{}
+
+	health       healthStats
+	pipeline     pipelineStats
+	poisoned     poisonedTelemetries
+	dependencies dependencyGraph
+	silence      silenceTracker
 }
 
 func CreateSpeculator(config Config) *Speculator {
 	log.Info("Creating Speculator")
 	log.Debugf("Speculator Config %+v", config)
-	return &Speculator{
-		Specs:  make(map[SpecKey]*_spec.Spec),
-		config: config,
+	s := &Speculator{
+		Specs:       make(map[SpecKey]*_spec.Spec),
+		SourceSpecs: make(map[_spec.SourceLabel]map[SpecKey]*_spec.Spec),
+		config:      config,
+		requestIDs:  createRequestIDCache(config),
+		scanners:    newScannerDetector(config.ScannerDetection),
 	}
+	s.startIngestionWorkers()
+	s.startSpecCheckpoints()
+	s.startSilenceChecks()
+
+	return s
+}
+
+func createRequestIDCache(config Config) *requestIDCache {
+	if config.DeduplicationWindow <= 0 {
+		return nil
+	}
+	return newRequestIDCache(config.DeduplicationWindow)
 }
 
 func GetSpecKey(host, port string) SpecKey {
 	return SpecKey(host + ":" + port)
 }
 
+// GetHostAndPortFromSpecKey returns the host and port of a key of GetSpecKey or GetBasePathSpecKey.
 func GetHostAndPortFromSpecKey(key SpecKey) (host, port string, err error) {
 	const hostAndPortLen = 2
-	hostAndPort := strings.Split(string(key), ":")
+	hostAndPort := strings.Split(strings.TrimSuffix(string(key), GetBasePathFromSpecKey(key)), ":")
 	if len(hostAndPort) != hostAndPortLen {
 		return "", "", fmt.Errorf("invalid key: %v", key)
 	}
@@ -70,7 +165,7 @@ func GetHostAndPortFromSpecKey(key SpecKey) (host, port string, err error) {
 }
 
 func (s *Speculator) SuggestedReview(specKey SpecKey) (*_spec.SuggestedSpecReview, error) {
-	spec, ok := s.Specs[specKey]
+	spec, ok := s.getSpec(specKey)
 	if !ok {
 		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
 	}
@@ -78,6 +173,16 @@ func (s *Speculator) SuggestedReview(specKey SpecKey) (*_spec.SuggestedSpecRevie
 	return spec.CreateSuggestedReview(), nil
 }
 
+// GetSuggestedReview returns the suggested review of the spec with its evidence, see _spec.Spec.GetSuggestedReview.
+func (s *Speculator) GetSuggestedReview(specKey SpecKey) (*_spec.SuggestedReview, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+
+	return spec.GetSuggestedReview(), nil
+}
+
 type AddressInfo struct {
 	IP   string
 	Port string
@@ -97,43 +202,167 @@ func GetAddressInfoFromAddress(address string) (*AddressInfo, error) {
 }
 
 func (s *Speculator) LearnTelemetry(telemetry *_spec.Telemetry) error {
+	return s.LearnTelemetryCtx(context.Background(), telemetry)
+}
+
+// LearnTelemetryCtx is LearnTelemetry that gives up once ctx is done, see Spec.LearnTelemetryCtx. A canceled
+// telemetry is not learned and is not counted as a learning error of its spec.
+// The telemetry is learned through the default pipeline, see DefaultStages and NewPipeline to compose other stages.
+func (s *Speculator) LearnTelemetryCtx(ctx context.Context, telemetry *_spec.Telemetry) error {
+	return s.defaultPipeline().LearnTelemetry(ctx, telemetry)
+}
+
+// learnTelemetry learns telemetry through the default pipeline with specsLock held for reading, see DefaultStages.
+func (s *Speculator) learnTelemetry(ctx context.Context, telemetry *_spec.Telemetry) error {
+	return s.defaultPipeline().learnTelemetry(ctx, telemetry)
+}
+
+// learnProcessedTelemetry learns a valid telemetry processed by the stages of a pipeline, with specsLock held for reading.
+func (s *Speculator) learnProcessedTelemetry(ctx context.Context, telemetry *_spec.Telemetry) error {
 	destInfo, err := GetAddressInfoFromAddress(telemetry.DestinationAddress)
 	if err != nil {
 		return fmt.Errorf("failed get destination info: %v", err)
 	}
-	specKey := GetSpecKey(telemetry.Request.Host, destInfo.Port)
-	if _, ok := s.Specs[specKey]; !ok {
-		s.Specs[specKey] = _spec.CreateDefaultSpec(telemetry.Request.Host, destInfo.Port, s.config.OperationGeneratorConfig)
+	// reject before a spec is created for the telemetry
+	if _, err := _spec.NormalizeMethod(telemetry.Request.Method); err != nil {
+		return fmt.Errorf("invalid telemetry: %w", err)
+	}
+	// the error responses a scanner gets are not learned either
+	if s.isExcludedScanner(telemetry) {
+		log.Debugf("Ignoring telemetry of a detected scanner. Source=%v", telemetry.SourceAddress)
+		return nil
 	}
-	spec := s.Specs[specKey]
-	if err := spec.LearnTelemetry(telemetry); err != nil {
-		return fmt.Errorf("failed to insert telemetry: %v. %v", telemetry, err)
+	specKey, basePath := s.getTelemetrySpecKey(telemetry, destInfo.Port)
+	unlock := s.lockSpec(specKey)
+	defer unlock()
+
+	dedup := s.requestIDs != nil && telemetry.RequestID != ""
+	if dedup && s.hasRequestID(telemetry.RequestID) {
+		log.Debugf("Ignoring duplicate telemetry. RequestID=%v", telemetry.RequestID)
+		return nil
+	}
+	spec, err := s.getOrLoadSpec(specKey)
+	if err != nil {
+		return err
+	}
+	if spec == nil {
+		spec = s.createSpec(telemetry.Request.Host, destInfo.Port, basePath)
+		s.setSpec(specKey, spec)
+	}
+	preparedTelemetry := trimBasePath(telemetry, basePath)
+	learnStart := time.Now()
+	if err := spec.LearnTelemetryCtx(ctx, preparedTelemetry); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to insert telemetry: %w", err)
+		}
+		s.health.recordError(specKey)
+		s.pipeline.recordLearnError(specKey, err)
+		return fmt.Errorf("failed to insert telemetry: %v. %w", telemetry, err)
+	}
+	// the source spec learns the telemetry regardless of ctx, so that it stays consistent with the learned spec
+	if s.config.SplitSpecsBySource {
+		if err := s.learnSourceTelemetry(specKey, destInfo.Port, basePath, preparedTelemetry); err != nil {
+			s.health.recordError(specKey)
+			s.pipeline.recordLearnError(specKey, err)
+			return fmt.Errorf("failed to insert telemetry to source spec: %w", err)
+		}
+	}
+	s.health.recordIngestion(preparedTelemetry.Timestamp, time.Now())
+	s.pipeline.recordLearned(specKey, time.Since(learnStart))
+	s.recordDependency(preparedTelemetry, specKey, spec)
+	// only a learned telemetry is remembered, so a failed one can be re-delivered
+	if dedup {
+		s.addRequestID(telemetry.RequestID)
 	}
 
 	return nil
 }
 
+// prepareTelemetry returns a copy of telemetry with a normalized timestamp, the caller's telemetry is not modified.
+func (s *Speculator) prepareTelemetry(telemetry *_spec.Telemetry) *_spec.Telemetry {
+	prepared := *telemetry
+	prepared.NormalizeTimestamp(time.Now(), s.getMaxClockSkew())
+
+	return &prepared
+}
+
+func (s *Speculator) learnSourceTelemetry(specKey SpecKey, port, basePath string, telemetry *_spec.Telemetry) error {
+	source := telemetry.Source
+	if source == "" {
+		source = _spec.SourceUnknown
+	}
+	s.specsMapLock.Lock()
+	// state decoded from an older version may not have source specs
+	if s.SourceSpecs == nil {
+		s.SourceSpecs = make(map[_spec.SourceLabel]map[SpecKey]*_spec.Spec)
+	}
+	if _, ok := s.SourceSpecs[source]; !ok {
+		s.SourceSpecs[source] = make(map[SpecKey]*_spec.Spec)
+	}
+	spec, ok := s.SourceSpecs[source][specKey]
+	if !ok {
+		spec = s.createSpec(telemetry.Request.Host, port, basePath)
+		s.SourceSpecs[source][specKey] = spec
+		s.addLearningObservers(spec)
+	}
+	s.specsMapLock.Unlock()
+
+	return spec.LearnTelemetry(telemetry)
+}
+
+// GetSourceSpec returns the spec learned only from requests of source, available when Config.SplitSpecsBySource is set.
+func (s *Speculator) GetSourceSpec(source _spec.SourceLabel, specKey SpecKey) (*_spec.Spec, error) {
+	s.specsMapLock.Lock()
+	spec, ok := s.SourceSpecs[source][specKey]
+	s.specsMapLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no %v spec for key %v", source, specKey)
+	}
+	return spec, nil
+}
+
+func (s *Speculator) getMaxClockSkew() time.Duration {
+	if s.config.MaxClockSkew <= 0 {
+		return _spec.DefaultMaxClockSkew
+	}
+	return s.config.MaxClockSkew
+}
+
+// DiffTelemetry diffs telemetry through the default pipeline, see DefaultStages.
 func (s *Speculator) DiffTelemetry(telemetry *_spec.Telemetry, diffSource _spec.DiffSource) (*_spec.APIDiff, error) {
+	return s.defaultPipeline().DiffTelemetry(telemetry, diffSource)
+}
+
+// diffProcessedTelemetry diffs a valid telemetry processed by the stages of a pipeline, with specsLock held for reading.
+func (s *Speculator) diffProcessedTelemetry(telemetry *_spec.Telemetry, diffSource _spec.DiffSource) (*_spec.APIDiff, error) {
 	destInfo, err := GetAddressInfoFromAddress(telemetry.DestinationAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed get destination info: %v", err)
 	}
-	specKey := GetSpecKey(telemetry.Request.Host, destInfo.Port)
-	spec, ok := s.Specs[specKey]
-	if !ok {
+	specKey, basePath := s.getTelemetrySpecKey(telemetry, destInfo.Port)
+	unlock := s.lockSpec(specKey)
+	defer unlock()
+	spec, err := s.getOrLoadSpec(specKey)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
 		return nil, fmt.Errorf("no spec for key %v", specKey)
 	}
 
-	apiDiff, err := spec.DiffTelemetry(telemetry, diffSource)
+	apiDiff, err := spec.DiffTelemetry(trimBasePath(telemetry, basePath), diffSource)
 	if err != nil {
-		return nil, fmt.Errorf("failed to run DiffTelemetry: %v", err)
+		s.health.recordError(specKey)
+		s.poisoned.recordPanic(telemetry, err, s.config.MaxPoisonedTelemetries, time.Now())
+		return nil, fmt.Errorf("failed to run DiffTelemetry: %w", err)
 	}
+	s.sendDiff(apiDiff)
 
 	return apiDiff, nil
 }
 
 func (s *Speculator) HasApprovedSpec(key SpecKey) bool {
-	spec, ok := s.Specs[key]
+	spec, ok := s.getSpec(key)
 	if !ok {
 		return false
 	}
@@ -142,7 +371,7 @@ func (s *Speculator) HasApprovedSpec(key SpecKey) bool {
 }
 
 func (s *Speculator) LoadProvidedSpec(key SpecKey, providedSpec []byte, pathToPathID map[string]string) error {
-	spec, ok := s.Specs[key]
+	spec, ok := s.getSpec(key)
 	if !ok {
 		return fmt.Errorf("no spec found with key: %v", key)
 	}
@@ -155,7 +384,7 @@ func (s *Speculator) LoadProvidedSpec(key SpecKey, providedSpec []byte, pathToPa
 }
 
 func (s *Speculator) UnsetProvidedSpec(key SpecKey) error {
-	spec, ok := s.Specs[key]
+	spec, ok := s.getSpec(key)
 	if !ok {
 		return fmt.Errorf("no spec found with key: %v", key)
 	}
@@ -164,7 +393,7 @@ func (s *Speculator) UnsetProvidedSpec(key SpecKey) error {
 }
 
 func (s *Speculator) UnsetApprovedSpec(key SpecKey) error {
-	spec, ok := s.Specs[key]
+	spec, ok := s.getSpec(key)
 	if !ok {
 		return fmt.Errorf("no spec found with key: %v", key)
 	}
@@ -173,7 +402,7 @@ func (s *Speculator) UnsetApprovedSpec(key SpecKey) error {
 }
 
 func (s *Speculator) HasProvidedSpec(key SpecKey) bool {
-	spec, ok := s.Specs[key]
+	spec, ok := s.getSpec(key)
 	if !ok {
 		return false
 	}
@@ -183,7 +412,7 @@ func (s *Speculator) HasProvidedSpec(key SpecKey) bool {
 
 func (s *Speculator) DumpSpecs() {
 	log.Infof("Generating Open API Specs...\n")
-	for specKey, spec := range s.Specs {
+	for specKey, spec := range s.getSpecs() {
 		approvedYaml, err := spec.GenerateOASYaml()
 		if err != nil {
 			log.Errorf("failed to generate OAS yaml for %v.: %v", specKey, err)
@@ -193,48 +422,197 @@ func (s *Speculator) DumpSpecs() {
 	}
 }
 
-func (s *Speculator) ApplyApprovedReview(specKey SpecKey, approvedReview *_spec.ApprovedSpecReview) error {
-	if err := s.Specs[specKey].ApplyApprovedReview(approvedReview); err != nil {
+func (s *Speculator) ApplyApprovedReview(specKey SpecKey, approvedReview *_spec.ApprovedSpecReview, opts ..._spec.ApproveOption) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	if err := spec.ApplyApprovedReview(approvedReview, opts...); err != nil {
 		return fmt.Errorf("failed to apply approved review for spec: %v. %w", specKey, err)
 	}
 	return nil
 }
 
-func (s *Speculator) EncodeState(filePath string) error {
-	file, err := openFile(filePath)
+func (s *Speculator) IgnoreOperation(specKey SpecKey, path, method string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	if err := spec.IgnoreOperation(path, method); err != nil {
+		return fmt.Errorf("failed to ignore operation for spec: %v. %w", specKey, err)
+	}
+	return nil
+}
+
+func (s *Speculator) UnignoreOperation(specKey SpecKey, path, method string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	spec.UnignoreOperation(path, method)
+	return nil
+}
+
+// FreezePath stops the schema learning of the operation of the spec, see _spec.Spec.FreezePath.
+func (s *Speculator) FreezePath(specKey SpecKey, path, method string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	if err := spec.FreezePath(path, method); err != nil {
+		return fmt.Errorf("failed to freeze path for spec: %v. %w", specKey, err)
+	}
+	return nil
+}
+
+// UnfreezePath removes an operation of the spec frozen by FreezePath.
+func (s *Speculator) UnfreezePath(specKey SpecKey, path, method string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	spec.UnfreezePath(path, method)
+	return nil
+}
+
+// VerifyApprovedSpecRoundTrip reports the information of the approved spec lost by exporting and importing it,
+// see _spec.Spec.VerifyApprovedSpecRoundTrip.
+func (s *Speculator) VerifyApprovedSpecRoundTrip(specKey SpecKey) (*_spec.ApprovedSpecRoundTripReport, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	report, err := spec.VerifyApprovedSpecRoundTrip()
 	if err != nil {
-		return fmt.Errorf("failed to open state file: %v", err)
+		return nil, fmt.Errorf("failed to verify approved spec round trip for spec: %v. %w", specKey, err)
+	}
+	return report, nil
+}
+
+// RejectPath drops the learned paths of the spec matching pattern and never learns them again, see _spec.Spec.RejectPath.
+func (s *Speculator) RejectPath(specKey SpecKey, pattern string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	if err := spec.RejectPath(pattern); err != nil {
+		return fmt.Errorf("failed to reject path for spec: %v. %w", specKey, err)
+	}
+	return nil
+}
+
+// UnrejectPath removes a path pattern of the spec rejected by RejectPath.
+func (s *Speculator) UnrejectPath(specKey SpecKey, pattern string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
 	}
-	encoder := gob.NewEncoder(file)
-	err = encoder.Encode(s)
+	spec.UnrejectPath(pattern)
+	return nil
+}
+
+// SplitPath splits literalPath out of parameterizedPath of the spec, see _spec.Spec.SplitPath.
+func (s *Speculator) SplitPath(specKey SpecKey, parameterizedPath, literalPath string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	if err := spec.SplitPath(parameterizedPath, literalPath); err != nil {
+		return fmt.Errorf("failed to split path for spec: %v. %w", specKey, err)
+	}
+	return nil
+}
+
+// MergePaths groups literalPaths of the spec into template, see _spec.Spec.MergePaths.
+func (s *Speculator) MergePaths(specKey SpecKey, literalPaths []string, template string) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	if err := spec.MergePaths(literalPaths, template); err != nil {
+		return fmt.Errorf("failed to merge paths for spec: %v. %w", specKey, err)
+	}
+	return nil
+}
+
+// ImportApprovedSpec replaces the approved spec of the spec with an edited one, see _spec.Spec.ImportApprovedSpec.
+func (s *Speculator) ImportApprovedSpec(specKey SpecKey, rawSpec []byte) (*_spec.ApprovedSpecImportReport, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	report, err := spec.ImportApprovedSpec(rawSpec)
 	if err != nil {
-		return fmt.Errorf("failed to encode state: %v", err)
+		return nil, fmt.Errorf("failed to import approved spec for spec: %v. %w", specKey, err)
 	}
-	closeFile(file)
+	return report, nil
+}
 
+// ApprovePaths approves the learned paths of the spec only, see _spec.Spec.ApprovePaths.
+func (s *Speculator) ApprovePaths(specKey SpecKey, paths []string, opts ..._spec.ApproveOption) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	if err := spec.ApprovePaths(paths, opts...); err != nil {
+		return fmt.Errorf("failed to approve paths for spec: %v. %w", specKey, err)
+	}
 	return nil
 }
 
-func DecodeState(filePath string, config Config) (*Speculator, error) {
-	r := &Speculator{}
-	file, err := openFile(filePath)
+// DiffLearnedWithProvidedSpec compares the learned operations of the spec against its provided spec,
+// see _spec.Spec.DiffLearnedWithProvidedSpec.
+func (s *Speculator) DiffLearnedWithProvidedSpec(specKey SpecKey) (*specdiff.SpecDiff, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	diff, err := spec.DiffLearnedWithProvidedSpec()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file (%v): %v", filePath, err)
+		return nil, fmt.Errorf("failed to diff learned spec: %v. %w", specKey, err)
 	}
-	defer closeFile(file)
+	return diff, nil
+}
 
-	decoder := gob.NewDecoder(file)
-	err = decoder.Decode(r)
+// ExportPathTrie writes the path trie of kind of the spec in format, see _spec.Spec.ExportPathTrie.
+func (s *Speculator) ExportPathTrie(specKey SpecKey, w io.Writer, kind _spec.PathTrieKind, format _spec.PathTrieFormat) error {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	return spec.ExportPathTrie(w, kind, format)
+}
+
+// GetRetainedSamples returns the retained telemetry samples of the spec, see _spec.OperationGeneratorConfig.MaxRetainedSamples.
+// It is safe to call concurrently with ingestion.
+func (s *Speculator) GetRetainedSamples(specKey SpecKey) ([]_spec.RetainedSample, error) {
+	spec, ok := s.getSpec(specKey)
+	if !ok {
+		return nil, fmt.Errorf("spec doesn't exist for key %v", specKey)
+	}
+	return spec.GetRetainedSamples(), nil
+}
+
+// EncodeStateFile writes the state to filePath, see EncodeState.
+func (s *Speculator) EncodeStateFile(filePath string) error {
+	file, err := openFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode state: %v", err)
+		return fmt.Errorf("failed to open state file: %v", err)
 	}
+	defer closeFile(file)
 
-	r.config = config
+	return s.EncodeState(file)
+}
 
-	log.Info("Speculator state was decoded")
-	log.Debugf("Speculator Config %+v", config)
+// DecodeStateFile reads the state from filePath, see DecodeState.
+func DecodeStateFile(filePath string, config Config) (*Speculator, error) {
+	file, err := openFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file (%v): %v", filePath, err)
+	}
+	defer closeFile(file)
 
-	return r, nil
+	return DecodeState(file, config)
 }
 
 func openFile(filePath string) (*os.File, error) {
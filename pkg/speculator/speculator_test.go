@@ -16,12 +16,18 @@
 package speculator
 
 import (
+	"context"
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
-	uuid "github.com/satori/go.uuid"
+	"gotest.tools/assert"
 
 	"github.com/apiclarity/speculator/pkg/spec"
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+	"github.com/apiclarity/speculator/pkg/utils/uuid"
 )
 
 func TestGetHostAndPortFromSpecKey(t *testing.T) {
@@ -100,7 +106,7 @@ func TestGetHostAndPortFromSpecKey(t *testing.T) {
 
 func TestDecodeState(t *testing.T) {
 	testSpec := GetSpecKey("host", "port")
-	testStatePath := "/tmp/" + uuid.NewV4().String() + "state.gob"
+	testStatePath := "/tmp/" + uuid.New().String() + "state.gob"
 	defer func() {
 		_ = os.Remove(testStatePath)
 	}()
@@ -113,8 +119,8 @@ func TestDecodeState(t *testing.T) {
 	speculator := CreateSpeculator(speculatorConfig)
 	speculator.Specs[testSpec] = spec.CreateDefaultSpec("host", "port", speculator.config.OperationGeneratorConfig)
 
-	if err := speculator.EncodeState(testStatePath); err != nil {
-		t.Errorf("EncodeState() error = %v", err)
+	if err := speculator.EncodeStateFile(testStatePath); err != nil {
+		t.Errorf("EncodeStateFile() error = %v", err)
 		return
 	}
 
@@ -123,9 +129,9 @@ func TestDecodeState(t *testing.T) {
 			ResponseHeadersToIgnore: []string{"after"},
 		},
 	}
-	got, err := DecodeState(testStatePath, newSpeculatorConfig)
+	got, err := DecodeStateFile(testStatePath, newSpeculatorConfig)
 	if err != nil {
-		t.Errorf("DecodeState() error = %v", err)
+		t.Errorf("DecodeStateFile() error = %v", err)
 		return
 	}
 
@@ -136,3 +142,245 @@ func TestDecodeState(t *testing.T) {
 		return
 	}
 }
+
+func TestDecodeState_IgnoredOperations(t *testing.T) {
+	testSpec := GetSpecKey("host", "port")
+	testStatePath := "/tmp/" + uuid.New().String() + "state.gob"
+	defer func() {
+		_ = os.Remove(testStatePath)
+	}()
+
+	speculator := CreateSpeculator(Config{})
+	speculator.Specs[testSpec] = spec.CreateDefaultSpec("host", "port", speculator.config.OperationGeneratorConfig)
+	if err := speculator.IgnoreOperation(testSpec, "/debug/vars", "GET"); err != nil {
+		t.Errorf("IgnoreOperation() error = %v", err)
+		return
+	}
+	if err := speculator.IgnoreOperation("missing:80", "/debug/vars", "GET"); err == nil {
+		t.Errorf("IgnoreOperation() expected error for missing spec")
+		return
+	}
+
+	if err := speculator.EncodeStateFile(testStatePath); err != nil {
+		t.Errorf("EncodeStateFile() error = %v", err)
+		return
+	}
+	got, err := DecodeStateFile(testStatePath, Config{})
+	if err != nil {
+		t.Errorf("DecodeStateFile() error = %v", err)
+		return
+	}
+
+	want := map[string][]string{"/debug/vars": {"GET"}}
+	if ignored := got.Specs[testSpec].GetIgnoredOperations(); !reflect.DeepEqual(ignored, want) {
+		t.Errorf("GetIgnoredOperations() got = %v, want %v", ignored, want)
+	}
+}
+
+func TestSpeculator_LearnTelemetry_UnsupportedMethod(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	telemetry := createTelemetry("1")
+	telemetry.Request.Method = "G3T"
+	if err := s.LearnTelemetry(telemetry); err == nil {
+		t.Errorf("LearnTelemetry() expected error for unsupported method")
+	}
+	if len(s.Specs) != 0 {
+		t.Errorf("LearnTelemetry() created specs for unsupported method: %v", s.Specs)
+	}
+}
+
+func TestSpeculator_LearnTelemetry_Isolation(t *testing.T) {
+	panickingEnricher := func(telemetry *spec.Telemetry) (map[string]string, error) {
+		if telemetry.RequestID == "panic" {
+			var metadata map[string]string
+			metadata["a"] = "b"
+		}
+		return nil, nil
+	}
+	s := CreateSpeculator(Config{Enrichers: []Enricher{panickingEnricher}})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1")))
+
+	err := s.LearnTelemetry(createTelemetry("panic"))
+	assert.Assert(t, errors.Is(err, _errors.ErrRecoveredPanic), "unexpected error: %v", err)
+	err = s.LearnTelemetry(&spec.Telemetry{})
+	assert.ErrorContains(t, err, "missing request")
+	err = s.LearnTelemetry(nil)
+	assert.ErrorContains(t, err, "missing telemetry")
+
+	// the speculator keeps learning after a failed telemetry
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("2")))
+	report, err := s.LearnTelemetries([]*spec.Telemetry{{Request: &spec.Request{}}, createTelemetry("3")})
+	assert.NilError(t, err)
+	assert.Equal(t, report.Unroutable, 1)
+	assert.Equal(t, report.Hosts[0].Learned, 1)
+	assert.Equal(t, s.Specs[GetSpecKey("host", "80")].LearningStats.TelemetryCount, 3)
+}
+
+func TestSpeculator_LearnTelemetryCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// cancels while the telemetry is being learned
+	cancelingEnricher := func(telemetry *spec.Telemetry) (map[string]string, error) {
+		if telemetry.RequestID == "cancel" {
+			cancel()
+		}
+		return nil, nil
+	}
+	s := CreateSpeculator(Config{Enrichers: []Enricher{cancelingEnricher}})
+	assert.NilError(t, s.LearnTelemetryCtx(ctx, createTelemetry("1")))
+
+	err := s.LearnTelemetryCtx(ctx, createTelemetry("cancel"))
+	assert.Assert(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+	err = s.LearnTelemetryCtx(ctx, createTelemetry("2"))
+	assert.Assert(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+
+	specKey := GetSpecKey("host", "80")
+	learnedSpec, ok := s.getSpec(specKey)
+	assert.Assert(t, ok)
+	assert.Equal(t, learnedSpec.LearningStats.TelemetryCount, 1)
+	assert.Equal(t, s.Health().SpecErrors[specKey], 0)
+}
+
+func TestSpeculator_ExportPathTrie(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("1")))
+
+	b := &strings.Builder{}
+	assert.NilError(t, s.ExportPathTrie(GetSpecKey("host", "80"), b, spec.PathTrieKindLearning, spec.PathTrieFormatDOT))
+	assert.Assert(t, strings.Contains(b.String(), `tooltip="/api"`), b.String())
+	assert.ErrorContains(t, s.ExportPathTrie(GetSpecKey("other", "80"), b, spec.PathTrieKindLearning, spec.PathTrieFormatDOT), "spec doesn't exist")
+}
+
+func TestSpeculator_SplitPath(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	for _, path := range []string{"/api/1", "/api/2"} {
+		telemetry := createTelemetry(path)
+		telemetry.Request.Path = path
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.SplitPath(specKey, "/api/{param1}", "/api/2"))
+	assert.DeepEqual(t, s.Specs[specKey].GetSplitPaths(), []string{"/api/2"})
+	assert.ErrorContains(t, s.SplitPath(specKey, "/api/{param1}", "/users/2"), "does not match")
+	assert.ErrorContains(t, s.SplitPath(GetSpecKey("other", "80"), "/api/{param1}", "/api/2"), "spec doesn't exist")
+}
+
+func TestSpeculator_MergePaths(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	for _, path := range []string{"/users/alice", "/users/bob"} {
+		telemetry := createTelemetry(path)
+		telemetry.Request.Path = path
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.MergePaths(specKey, []string{"/users/alice", "/users/bob"}, "/users/{name}"))
+	assert.DeepEqual(t, s.Specs[specKey].GetPathTemplates(), map[string]string{
+		"/users/alice": "/users/{name}",
+		"/users/bob":   "/users/{name}",
+	})
+	assert.ErrorContains(t, s.MergePaths(specKey, []string{"/users/carol"}, "/users/{name}"), "was not learned")
+	assert.ErrorContains(t, s.MergePaths(GetSpecKey("other", "80"), []string{"/users/alice"}, "/users/{name}"), "spec doesn't exist")
+}
+
+func TestSpeculator_FreezePath(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.FreezePath(specKey, "/api", "GET"))
+	assert.DeepEqual(t, s.Specs[specKey].GetFrozenOperations(), map[string][]string{"/api": {"GET"}})
+	assert.NilError(t, s.UnfreezePath(specKey, "/api", "GET"))
+	assert.DeepEqual(t, s.Specs[specKey].GetFrozenOperations(), map[string][]string{})
+	assert.ErrorContains(t, s.FreezePath(specKey, "/api", "POST"), "was not learned")
+	assert.ErrorContains(t, s.FreezePath(GetSpecKey("other", "80"), "/api", "GET"), "spec doesn't exist")
+}
+
+func TestSpeculator_GetSuggestedReview(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+
+	review, err := s.GetSuggestedReview(GetSpecKey("host", "80"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(review.Paths), 1)
+	assert.Equal(t, review.Paths[0].ParameterizedPath, "/api")
+	assert.Equal(t, review.Paths[0].HitCount, 2)
+
+	_, err = s.GetSuggestedReview(GetSpecKey("other", "80"))
+	assert.ErrorContains(t, err, "spec doesn't exist")
+}
+
+func TestSpeculator_VerifyApprovedSpecRoundTrip(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.ApprovePaths(specKey, []string{"/api"}))
+	report, err := s.VerifyApprovedSpecRoundTrip(specKey)
+	assert.NilError(t, err)
+	assert.Assert(t, report.IsLossless())
+
+	_, err = s.VerifyApprovedSpecRoundTrip(GetSpecKey("other", "80"))
+	assert.ErrorContains(t, err, "spec doesn't exist")
+}
+
+func TestSpeculator_RejectPath(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.RejectPath(specKey, "/api"))
+	assert.DeepEqual(t, s.Specs[specKey].GetRejectedPaths(), []string{"/api"})
+	assert.Equal(t, len(s.Specs[specKey].LearningSpec.PathItems), 0)
+	assert.NilError(t, s.UnrejectPath(specKey, "/api"))
+	assert.DeepEqual(t, s.Specs[specKey].GetRejectedPaths(), []string{})
+	assert.ErrorContains(t, s.RejectPath(specKey, "api"), "must start with /")
+	assert.ErrorContains(t, s.RejectPath(GetSpecKey("other", "80"), "/api"), "spec doesn't exist")
+}
+
+func TestSpeculator_ImportApprovedSpec(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.ApprovePaths(specKey, []string{"/api"}))
+	exported, err := s.Specs[specKey].GenerateOASYaml()
+	assert.NilError(t, err)
+	report, err := s.ImportApprovedSpec(specKey, exported)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, report, &spec.ApprovedSpecImportReport{})
+
+	_, err = s.ImportApprovedSpec(GetSpecKey("other", "80"), exported)
+	assert.ErrorContains(t, err, "spec doesn't exist")
+}
+
+func TestSpeculator_ApprovePaths(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	for _, path := range []string{"/api/1", "/health"} {
+		telemetry := createTelemetry(path)
+		telemetry.Request.Path = path
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.ApprovePaths(specKey, []string{"/health"}))
+	assert.Assert(t, s.Specs[specKey].ApprovedSpec.GetPathItem("/health") != nil)
+	assert.Assert(t, s.Specs[specKey].LearningSpec.GetPathItem("/api/1") != nil)
+	assert.ErrorContains(t, s.ApprovePaths(specKey, []string{"/health"}), "was not learned")
+	assert.ErrorContains(t, s.ApprovePaths(GetSpecKey("other", "80"), []string{"/api/1"}), "spec doesn't exist")
+}
+
+func TestSpeculator_DiffLearnedWithProvidedSpec(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+
+	specKey := GetSpecKey("host", "80")
+	diff, err := s.DiffLearnedWithProvidedSpec(specKey)
+	assert.NilError(t, err)
+	assert.Assert(t, diff == nil)
+
+	_, err = s.DiffLearnedWithProvidedSpec(GetSpecKey("other", "80"))
+	assert.ErrorContains(t, err, "spec doesn't exist")
+}
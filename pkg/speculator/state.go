@@ -0,0 +1,226 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// the version of the encoded state, increase it and add a stateMigration when decoding older states requires
+// more than gob's handling of added and removed fields.
+const currentStateVersion = 2
+
+// legacyStateVersion is the version of the states encoded before the state envelope was added.
+const legacyStateVersion = 0
+
+// untypedTriesStateVersion is the last version of the states encoded with the untyped spec path tries.
+const untypedTriesStateVersion = 1
+
+// stateEnvelope is the encoded state. Its fields don't match the fields of Speculator, so a legacy state fails to
+// decode into it and is decoded as a Speculator instead.
+type stateEnvelope struct {
+	StateVersion int
+	State        *Speculator
+}
+
+// untypedTriesStateEnvelope is a stateEnvelope encoded with the untyped spec path tries, the spec path tries are
+// typed when it is decoded, see decodeStateEnvelope.
+type untypedTriesStateEnvelope struct {
+	StateVersion int
+	State        *untypedTriesState
+}
+
+// untypedTriesState holds the encoded fields of a Speculator encoded with the untyped spec path tries.
+type untypedTriesState struct {
+	Specs       map[SpecKey]*_spec.UntypedTriesSpec
+	SourceSpecs map[_spec.SourceLabel]map[SpecKey]*_spec.UntypedTriesSpec
+}
+
+// stateMigration updates a decoded state of version fromVersion to version fromVersion+1.
+type stateMigration struct {
+	fromVersion int
+	migrate     func(s *Speculator) error
+}
+
+var stateMigrations = []stateMigration{
+	{fromVersion: legacyStateVersion, migrate: migrateLegacyState},
+	{fromVersion: untypedTriesStateVersion, migrate: migrateUntypedTriesState},
+}
+
+// EncodeState writes the state (the specs, with their spec info, path tries, learning and approved specs) to w,
+// in a versioned gob envelope, see DecodeState.
+func (s *Speculator) EncodeState(w io.Writer) error {
+	envelope := &stateEnvelope{
+		StateVersion: currentStateVersion,
+		State:        s,
+	}
+	if err := gob.NewEncoder(w).Encode(envelope); err != nil {
+		return fmt.Errorf("failed to encode state: %v", err)
+	}
+
+	return nil
+}
+
+// DecodeState reads a state written by EncodeState from r, and migrates it from the version it was encoded with.
+// States encoded before states were versioned are supported. States of a newer version are rejected.
+func DecodeState(r io.Reader, config Config) (*Speculator, error) {
+	stateB, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+
+	s, version, err := decodeStateEnvelope(stateB)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateState(s, version); err != nil {
+		return nil, err
+	}
+
+	s.config = config
+	s.requestIDs = createRequestIDCache(config)
+	s.scanners = newScannerDetector(config.ScannerDetection)
+	s.startIngestionWorkers()
+	s.startSpecCheckpoints()
+	s.startSilenceChecks()
+
+	log.Info("Speculator state was decoded")
+	log.Debugf("Speculator Config %+v", config)
+
+	return s, nil
+}
+
+// decodeStateEnvelope returns the decoded state and the version it was encoded with. The states encoded with the
+// untyped spec path tries, with or without an envelope, fail to decode into a Speculator and are decoded as an
+// untypedTriesState instead.
+func decodeStateEnvelope(stateB []byte) (*Speculator, int, error) {
+	envelope := &stateEnvelope{}
+	envelopeErr := gob.NewDecoder(bytes.NewReader(stateB)).Decode(envelope)
+	if envelopeErr == nil && envelope.State != nil {
+		return envelope.State, envelope.StateVersion, nil
+	}
+
+	untypedEnvelope := &untypedTriesStateEnvelope{}
+	if err := gob.NewDecoder(bytes.NewReader(stateB)).Decode(untypedEnvelope); err == nil && untypedEnvelope.State != nil {
+		s, err := untypedEnvelope.State.toSpeculator()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode state: %v", err)
+		}
+		return s, untypedEnvelope.StateVersion, nil
+	}
+
+	untypedState := &untypedTriesState{}
+	if err := gob.NewDecoder(bytes.NewReader(stateB)).Decode(untypedState); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode state: %v", envelopeErr)
+	}
+	s, err := untypedState.toSpeculator()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode state: %v", err)
+	}
+	log.Infof("Decoded a legacy state, migrating it to version %v", currentStateVersion)
+
+	return s, legacyStateVersion, nil
+}
+
+// toSpeculator returns a Speculator of the specs with their path tries typed.
+func (u *untypedTriesState) toSpeculator() (*Speculator, error) {
+	s := &Speculator{}
+	if u.Specs != nil {
+		s.Specs = make(map[SpecKey]*_spec.Spec, len(u.Specs))
+	}
+	for specKey, untypedSpec := range u.Specs {
+		spec, err := toTypedTriesSpec(untypedSpec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec %v: %v", specKey, err)
+		}
+		s.Specs[specKey] = spec
+	}
+	if u.SourceSpecs != nil {
+		s.SourceSpecs = make(map[_spec.SourceLabel]map[SpecKey]*_spec.Spec, len(u.SourceSpecs))
+	}
+	for source, untypedSpecs := range u.SourceSpecs {
+		s.SourceSpecs[source] = make(map[SpecKey]*_spec.Spec, len(untypedSpecs))
+		for specKey, untypedSpec := range untypedSpecs {
+			spec, err := toTypedTriesSpec(untypedSpec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid spec %v of source %v: %v", specKey, source, err)
+			}
+			s.SourceSpecs[source][specKey] = spec
+		}
+	}
+
+	return s, nil
+}
+
+// toTypedTriesSpec keeps the missing specs missing, see migrateLegacyState.
+func toTypedTriesSpec(untypedSpec *_spec.UntypedTriesSpec) (*_spec.Spec, error) {
+	if untypedSpec == nil {
+		return nil, nil
+	}
+	return untypedSpec.ToSpec()
+}
+
+// migrateState updates s from version to currentStateVersion.
+func migrateState(s *Speculator, version int) error {
+	if version > currentStateVersion {
+		return fmt.Errorf("state version %v is newer than the supported version %v", version, currentStateVersion)
+	}
+
+	for _, migration := range stateMigrations {
+		if migration.fromVersion < version {
+			continue
+		}
+		if err := migration.migrate(s); err != nil {
+			return fmt.Errorf("failed to migrate state from version %v: %v", migration.fromVersion, err)
+		}
+		version = migration.fromVersion + 1
+	}
+	if version != currentStateVersion {
+		return fmt.Errorf("no migration of state version %v", version)
+	}
+
+	return nil
+}
+
+// migrateLegacyState initializes the maps that gob decodes as nil when they were empty or missing.
+func migrateLegacyState(s *Speculator) error {
+	if s.Specs == nil {
+		s.Specs = make(map[SpecKey]*_spec.Spec)
+	}
+	if s.SourceSpecs == nil {
+		s.SourceSpecs = make(map[_spec.SourceLabel]map[SpecKey]*_spec.Spec)
+	}
+	for specKey, spec := range s.Specs {
+		if spec == nil {
+			return fmt.Errorf("spec %v is missing", specKey)
+		}
+	}
+
+	return nil
+}
+
+// migrateUntypedTriesState does nothing, the spec path tries were typed when the state was decoded.
+func migrateUntypedTriesState(*Speculator) error {
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/pathtrie"
+	_spec "github.com/apiclarity/speculator/pkg/spec"
+)
+
+// createUntypedTriesSpec returns spec as it was encoded before its path tries were typed.
+func createUntypedTriesSpec(spec *_spec.Spec) *_spec.UntypedTriesSpec {
+	toUntyped := func(typed pathtrie.TypedPathTrie[string]) pathtrie.PathTrie {
+		untyped := pathtrie.New()
+		typed.Walk(func(path, pathID string) bool {
+			untyped.Insert(path, pathID)
+			return true
+		})
+		return untyped
+	}
+	return &_spec.UntypedTriesSpec{
+		SpecInfo: _spec.UntypedTriesSpecInfo{
+			Host:             spec.Host,
+			Port:             spec.Port,
+			ID:               spec.ID,
+			ApprovedSpec:     spec.ApprovedSpec,
+			LearningSpec:     spec.LearningSpec,
+			ApprovedPathTrie: toUntyped(spec.ApprovedPathTrie),
+			ProvidedPathTrie: toUntyped(spec.ProvidedPathTrie),
+			LearningStats:    spec.LearningStats,
+		},
+		OpGenerator: spec.OpGenerator,
+	}
+}
+
+func TestEncodeState_DecodeState(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.ApprovePaths(specKey, []string{"/api"}))
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+	assert.NilError(t, s.RejectPath(specKey, "/healthz"))
+	providedSpec := `{"swagger": "2.0", "info": {"title": "t", "version": "1"}, "paths": {"/api": {"get": {"responses": {"200": {"description": "ok"}}}}}}`
+	assert.NilError(t, s.LoadProvidedSpec(specKey, []byte(providedSpec), map[string]string{"/api": "provided-id"}))
+
+	buf := &bytes.Buffer{}
+	assert.NilError(t, s.EncodeState(buf))
+	got, err := DecodeState(buf, Config{})
+	assert.NilError(t, err)
+
+	gotSpec := got.Specs[specKey]
+	assert.Assert(t, gotSpec.ApprovedSpec.GetPathItem("/api") != nil)
+	_, pathID, found := gotSpec.ApprovedPathTrie.GetPathAndValue("/api")
+	assert.Assert(t, found)
+	_, wantPathID, _ := s.Specs[specKey].ApprovedPathTrie.GetPathAndValue("/api")
+	assert.Equal(t, pathID, wantPathID)
+	_, providedPathID, found := gotSpec.ProvidedPathTrie.GetPathAndValue("/api")
+	assert.Assert(t, found)
+	assert.Equal(t, providedPathID, "provided-id")
+	assert.DeepEqual(t, gotSpec.GetRejectedPaths(), []string{"/healthz"})
+	assert.Equal(t, gotSpec.LearningStats.TelemetryCount, 2)
+
+	// an empty state
+	buf.Reset()
+	assert.NilError(t, CreateSpeculator(Config{}).EncodeState(buf))
+	got, err = DecodeState(buf, Config{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(got.Specs), 0)
+}
+
+func TestDecodeState_ScannerDetection(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NilError(t, CreateSpeculator(Config{}).EncodeState(buf))
+	s, err := DecodeState(buf, Config{ScannerDetection: ScannerDetectionConfig{MinUniquePaths: 5}})
+	assert.NilError(t, err)
+
+	for i := 0; i < 10; i++ {
+		assert.NilError(t, s.LearnTelemetry(createScannerTelemetry("1.1.1.1:1234", fmt.Sprintf("/admin%v.php", i), "404")))
+	}
+	events := s.GetScannerEvents()
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ExcludedTelemetries, 5)
+	spec, ok := s.getSpec(GetSpecKey("host", "80"))
+	assert.Assert(t, ok)
+	learningPaths, _ := spec.GetPathCounts()
+	assert.Equal(t, learningPaths, 4)
+}
+
+func TestDecodeState_Legacy(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+
+	specKey := GetSpecKey("host", "80")
+	legacyState := &untypedTriesState{
+		Specs: map[SpecKey]*_spec.UntypedTriesSpec{specKey: createUntypedTriesSpec(s.Specs[specKey])},
+	}
+
+	// states were encoded without an envelope
+	buf := &bytes.Buffer{}
+	assert.NilError(t, gob.NewEncoder(buf).Encode(legacyState))
+	got, err := DecodeState(buf, Config{})
+	assert.NilError(t, err)
+	assert.Assert(t, got.Specs[specKey].LearningSpec.GetPathItem("/api") != nil)
+	assert.Assert(t, got.SourceSpecs != nil)
+}
+
+func TestDecodeState_UntypedTries(t *testing.T) {
+	s := CreateSpeculator(Config{})
+	assert.NilError(t, s.LearnTelemetry(createTelemetry("req-id")))
+	specKey := GetSpecKey("host", "80")
+	assert.NilError(t, s.ApprovePaths(specKey, []string{"/api"}))
+	_, wantPathID, _ := s.Specs[specKey].ApprovedPathTrie.GetPathAndValue("/api")
+
+	buf := &bytes.Buffer{}
+	assert.NilError(t, gob.NewEncoder(buf).Encode(&untypedTriesStateEnvelope{
+		StateVersion: untypedTriesStateVersion,
+		State: &untypedTriesState{
+			Specs: map[SpecKey]*_spec.UntypedTriesSpec{specKey: createUntypedTriesSpec(s.Specs[specKey])},
+			SourceSpecs: map[_spec.SourceLabel]map[SpecKey]*_spec.UntypedTriesSpec{
+				_spec.SourceInternal: {specKey: createUntypedTriesSpec(s.Specs[specKey])},
+			},
+		},
+	}))
+	got, err := DecodeState(buf, Config{})
+	assert.NilError(t, err)
+
+	_, pathID, found := got.Specs[specKey].ApprovedPathTrie.GetPathAndValue("/api")
+	assert.Assert(t, found)
+	assert.Equal(t, pathID, wantPathID)
+	_, pathID, found = got.SourceSpecs[_spec.SourceInternal][specKey].ApprovedPathTrie.GetPathAndValue("/api")
+	assert.Assert(t, found)
+	assert.Equal(t, pathID, wantPathID)
+}
+
+func TestDecodeState_Invalid(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NilError(t, gob.NewEncoder(buf).Encode(&stateEnvelope{StateVersion: currentStateVersion + 1, State: CreateSpeculator(Config{})}))
+	_, err := DecodeState(buf, Config{})
+	assert.ErrorContains(t, err, "is newer than the supported version")
+
+	_, err = DecodeState(bytes.NewBufferString("not a state"), Config{})
+	assert.ErrorContains(t, err, "failed to decode state")
+}
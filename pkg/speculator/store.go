@@ -0,0 +1,60 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package speculator
+
+import (
+	"fmt"
+	"time"
+)
+
+// StateStore persists the speculator state.
+type StateStore interface {
+	Save(s *Speculator) error
+	Load(config Config) (*Speculator, error)
+}
+
+// SaveState saves the state to the configured StateStore.
+// It must not be called concurrently with ingestion.
+func (s *Speculator) SaveState() error {
+	if s.config.StateStore == nil {
+		return fmt.Errorf("no state store is configured")
+	}
+	if err := s.config.StateStore.Save(s); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	s.health.recordPersistence(time.Now())
+
+	return nil
+}
+
+// FileStateStore stores the encoded state in a file, see EncodeState.
+type FileStateStore struct {
+	Path string
+}
+
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{
+		Path: path,
+	}
+}
+
+func (f *FileStateStore) Save(s *Speculator) error {
+	return s.EncodeStateFile(f.Path)
+}
+
+func (f *FileStateStore) Load(config Config) (*Speculator, error) {
+	return DecodeStateFile(f.Path, config)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is the position of a batch processing in its input, see Config.OnCheckpoint and Config.Resume.
+// A checkpoint is only consistent with the learner state persisted along it: records learned after the state
+// was persisted are learned again when resuming.
+type Checkpoint struct {
+	// File the checkpoint is of, set by ProcessFile
+	File string `json:"file,omitempty"`
+	// Offset is the amount of (uncompressed) bytes processed
+	Offset     int64 `json:"offset"`
+	LineNumber int   `json:"lineNumber"`
+	Records    int   `json:"records"`
+	Learned    int   `json:"learned"`
+	Failed     int   `json:"failed"`
+}
+
+func (c *Checkpoint) progress() Progress {
+	return Progress{
+		Records:   c.Records,
+		Learned:   c.Learned,
+		Failed:    c.Failed,
+		BytesRead: c.Offset,
+	}
+}
+
+func (c *Checkpoint) setProgress(progress Progress) {
+	c.Offset = progress.BytesRead
+	c.Records = progress.Records
+	c.Learned = progress.Learned
+	c.Failed = progress.Failed
+}
+
+// SaveCheckpoint writes checkpoint to path as JSON. The file is replaced atomically, so an aborted save
+// keeps the previous checkpoint.
+func SaveCheckpoint(path string, checkpoint Checkpoint) error {
+	checkpointB, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %v", err)
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(checkpointB); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write checkpoint file: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %v", err)
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint file: %v", err)
+	}
+	return nil
+}
+
+// LoadCheckpoint reads a checkpoint written by SaveCheckpoint.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	checkpointB, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %v", err)
+	}
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(checkpointB, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %v", err)
+	}
+	return checkpoint, nil
+}
+
+// skipBytes skips the first offset bytes of r, seeking if r supports it (e.g. an uncompressed file).
+func skipBytes(r io.Reader, offset int64) error {
+	if offset <= 0 {
+		return nil
+	}
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek to offset %v: %v", offset, err)
+		}
+		return nil
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, offset); err != nil {
+		return fmt.Errorf("failed to skip to offset %v: %v", offset, err)
+	}
+	return nil
+}
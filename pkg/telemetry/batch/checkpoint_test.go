@@ -0,0 +1,74 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSaveCheckpoint_LoadCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.checkpoint")
+
+	_, err = LoadCheckpoint(path)
+	assert.Assert(t, err != nil)
+
+	checkpoint := Checkpoint{File: "telemetries.jsonl", Offset: 100, LineNumber: 3, Records: 2, Learned: 1, Failed: 1}
+	assert.NilError(t, SaveCheckpoint(path, checkpoint))
+	checkpoint.Offset = 200
+	assert.NilError(t, SaveCheckpoint(path, checkpoint))
+	loaded, err := LoadCheckpoint(path)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *loaded, checkpoint)
+
+	// no temporary files are left
+	files, err := ioutil.ReadDir(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 1)
+}
+
+// reader hides the io.Seeker of a reader
+type reader struct {
+	r *strings.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func Test_skipBytes(t *testing.T) {
+	seekable := strings.NewReader("abcdef")
+	assert.NilError(t, skipBytes(seekable, 2))
+	rest, err := ioutil.ReadAll(seekable)
+	assert.NilError(t, err)
+	assert.Equal(t, string(rest), "cdef")
+
+	notSeekable := &reader{r: strings.NewReader("abcdef")}
+	assert.NilError(t, skipBytes(notSeekable, 4))
+	rest, err = ioutil.ReadAll(notSeekable)
+	assert.NilError(t, err)
+	assert.Equal(t, string(rest), "ef")
+
+	assert.Assert(t, skipBytes(&reader{r: strings.NewReader("ab")}, 4) != nil)
+}
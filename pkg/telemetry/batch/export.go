@@ -0,0 +1,90 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+// Exporter writes telemetries redacted with a spec.RedactionPolicy into a bulk telemetry file, e.g. to share a
+// dataset reproducing a learning issue.
+type Exporter struct {
+	file       *os.File
+	gzipWriter *gzip.Writer
+	writer     *Writer
+	policy     spec.RedactionPolicy
+	count      int
+}
+
+// CreateExporter creates (or truncates) the bulk telemetry file path, gzip compressed if it has a .gz extension.
+func CreateExporter(path string, policy spec.RedactionPolicy) (*Exporter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %v. %v", path, err)
+	}
+
+	e := &Exporter{
+		file:   file,
+		policy: policy,
+	}
+	var w io.Writer = file
+	if strings.HasSuffix(path, gzipExtension) {
+		e.gzipWriter = gzip.NewWriter(file)
+		w = e.gzipWriter
+	}
+	e.writer = NewWriter(w)
+	return e, nil
+}
+
+// Export writes a redacted copy of telemetry.
+func (e *Exporter) Export(telemetry *spec.Telemetry) error {
+	redacted := e.policy.RedactTelemetry(telemetry)
+	redacted.SchemaVersion = telemetry.SchemaVersion
+	if err := e.writer.Write(redacted); err != nil {
+		return err
+	}
+	e.count++
+	return nil
+}
+
+// Count returns the amount of telemetries exported so far.
+func (e *Exporter) Count() int {
+	return e.count
+}
+
+// Close flushes the exported telemetries and closes the file.
+func (e *Exporter) Close() error {
+	if err := e.writer.Flush(); err != nil {
+		_ = e.file.Close()
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	if e.gzipWriter != nil {
+		if err := e.gzipWriter.Close(); err != nil {
+			_ = e.file.Close()
+			return fmt.Errorf("failed to write gzip file: %v", err)
+		}
+	}
+	if err := e.file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	policy := spec.RedactionPolicy{SensitiveNames: []string{"authorization", "email"}}
+	for _, path := range []string{filepath.Join(dir, "export.jsonl"), filepath.Join(dir, "export.jsonl.gz")} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			exporter, err := CreateExporter(path, policy)
+			assert.NilError(t, err)
+			telemetry := createTelemetry("/users?email=a@b.c")
+			telemetry.Request.Common.Headers = []*spec.Header{
+				{Key: "Authorization", Value: "Bearer abc"},
+				{Key: "Accept", Value: "application/json"},
+			}
+			telemetry.Metadata = map[string]string{"tenant": "acme"}
+			assert.NilError(t, exporter.Export(telemetry))
+			assert.NilError(t, exporter.Export(createTelemetry("/b")))
+			assert.Equal(t, exporter.Count(), 2)
+			assert.NilError(t, exporter.Close())
+
+			r, closer, err := OpenFile(path)
+			assert.NilError(t, err)
+			defer closer.Close()
+			reader := NewReader(r)
+			var telemetries []*spec.Telemetry
+			for {
+				record, _, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NilError(t, err)
+				telemetry, err := spec.DecodeTelemetry(record)
+				assert.NilError(t, err)
+				telemetries = append(telemetries, telemetry)
+			}
+			assert.Equal(t, len(telemetries), 2)
+			assert.Equal(t, telemetries[0].Request.Path, "/users?email=[REDACTED]")
+			assert.Equal(t, telemetries[0].Request.Common.Headers[0].Value, "[REDACTED]")
+			assert.Equal(t, telemetries[0].Request.Common.Headers[1].Value, "application/json")
+			assert.Assert(t, telemetries[0].Metadata == nil)
+			assert.Equal(t, telemetries[1].Request.Path, "/b")
+		})
+	}
+
+	_, err = CreateExporter(filepath.Join(dir, "missing", "export.jsonl"), policy)
+	assert.Assert(t, err != nil)
+}
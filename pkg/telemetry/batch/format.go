@@ -0,0 +1,129 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package batch defines a bulk telemetry file format and processes such files for offline spec generation.
+//
+// A bulk telemetry file is newline delimited JSON (NDJSON): each line is a JSON telemetry of any schema version
+// supported by spec.DecodeTelemetry, empty lines are ignored. Files with a .gz extension are gzip compressed.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	gzipExtension = ".gz"
+	// records larger than this are rejected by the Reader
+	maxRecordSize = 10 * 1024 * 1024
+)
+
+// Writer writes telemetries in the bulk telemetry file format.
+type Writer struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func NewWriter(w io.Writer) *Writer {
+	bw := bufio.NewWriter(w)
+	return &Writer{
+		w:   bw,
+		enc: json.NewEncoder(bw),
+	}
+}
+
+// Write writes telemetry as a single record, the current schema version is set if telemetry has none.
+func (w *Writer) Write(telemetry *spec.Telemetry) error {
+	if telemetry.SchemaVersion == "" {
+		copied := *telemetry
+		copied.SchemaVersion = spec.CurrentTelemetrySchemaVersion
+		telemetry = &copied
+	}
+	if err := w.enc.Encode(telemetry); err != nil {
+		return fmt.Errorf("failed to encode telemetry: %v", err)
+	}
+	return nil
+}
+
+// Flush writes the buffered records to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader reads raw records of the bulk telemetry file format, see spec.DecodeTelemetry to decode them.
+type Reader struct {
+	scanner    *bufio.Scanner
+	lineNumber int
+	bytesRead  int64
+}
+
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxRecordSize)
+	return &Reader{
+		scanner: scanner,
+	}
+}
+
+// Next returns the next non-empty record and its line number, or io.EOF when there are no more records.
+// The returned record is only valid until the next call.
+func (r *Reader) Next() ([]byte, int, error) {
+	for r.scanner.Scan() {
+		r.lineNumber++
+		line := r.scanner.Bytes()
+		// account for the newline
+		r.bytesRead += int64(len(line)) + 1
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		return line, r.lineNumber, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, r.lineNumber, fmt.Errorf("failed to read line %v: %v", r.lineNumber+1, err)
+	}
+	return nil, r.lineNumber, io.EOF
+}
+
+// BytesRead returns the amount of (uncompressed) bytes read so far.
+func (r *Reader) BytesRead() int64 {
+	return r.bytesRead
+}
+
+// OpenFile opens a bulk telemetry file for reading, decompressing it if it has a .gz extension.
+// The returned closer closes the file.
+func OpenFile(path string) (io.Reader, io.Closer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %v. %v", path, err)
+	}
+	if !strings.HasSuffix(path, gzipExtension) {
+		return file, file, nil
+	}
+
+	gzipReader, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("failed to open gzip file: %v. %v", path, err)
+	}
+	return gzipReader, file, nil
+}
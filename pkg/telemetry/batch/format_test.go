@@ -0,0 +1,110 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func createTelemetry(path string) *spec.Telemetry {
+	return &spec.Telemetry{
+		DestinationAddress: "1.1.1.1:80",
+		Request: &spec.Request{
+			Common: &spec.Common{},
+			Host:   "example.com",
+			Method: "GET",
+			Path:   path,
+		},
+		RequestID: "req-id",
+		Response: &spec.Response{
+			Common:     &spec.Common{},
+			StatusCode: "200",
+		},
+	}
+}
+
+func TestWriter_Reader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	assert.NilError(t, w.Write(createTelemetry("/a")))
+	assert.NilError(t, w.Write(createTelemetry("/b")))
+	assert.NilError(t, w.Flush())
+	assert.Equal(t, strings.Count(buf.String(), "\n"), 2)
+
+	// empty lines are ignored
+	r := NewReader(strings.NewReader(strings.Replace(buf.String(), "\n", "\n\n", 1)))
+	var paths []string
+	var lineNumbers []int
+	for {
+		record, lineNumber, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		telemetry, err := spec.DecodeTelemetry(record)
+		assert.NilError(t, err)
+		assert.Equal(t, telemetry.SchemaVersion, spec.CurrentTelemetrySchemaVersion)
+		paths = append(paths, telemetry.Request.Path)
+		lineNumbers = append(lineNumbers, lineNumber)
+	}
+	assert.DeepEqual(t, paths, []string{"/a", "/b"})
+	assert.DeepEqual(t, lineNumbers, []int{1, 3})
+	assert.Equal(t, r.BytesRead(), int64(buf.Len()+1))
+}
+
+func TestOpenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	records := "{\"a\":1}\n{\"b\":2}\n"
+	plainPath := filepath.Join(dir, "telemetries.jsonl")
+	assert.NilError(t, ioutil.WriteFile(plainPath, []byte(records), 0o600))
+	gzipBuf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(gzipBuf)
+	_, err = gzipWriter.Write([]byte(records))
+	assert.NilError(t, err)
+	assert.NilError(t, gzipWriter.Close())
+	gzipPath := filepath.Join(dir, "telemetries.jsonl.gz")
+	assert.NilError(t, ioutil.WriteFile(gzipPath, gzipBuf.Bytes(), 0o600))
+
+	for _, path := range []string{plainPath, gzipPath} {
+		r, closer, err := OpenFile(path)
+		assert.NilError(t, err)
+		data, err := ioutil.ReadAll(r)
+		assert.NilError(t, err)
+		assert.Equal(t, string(data), records)
+		assert.NilError(t, closer.Close())
+	}
+
+	_, _, err = OpenFile(filepath.Join(dir, "missing.jsonl"))
+	assert.Assert(t, err != nil)
+	// not gzip compressed
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "invalid.gz"), []byte(records), 0o600))
+	_, _, err = OpenFile(filepath.Join(dir, "invalid.gz"))
+	assert.Assert(t, err != nil)
+}
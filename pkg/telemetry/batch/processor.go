@@ -0,0 +1,256 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	defaultProgressInterval   = 10 * time.Second
+	defaultCheckpointInterval = time.Minute
+)
+
+// Learner learns telemetries, e.g. spec.Spec or speculator.Speculator.
+type Learner interface {
+	LearnTelemetry(telemetry *spec.Telemetry) error
+}
+
+type Config struct {
+	// Workers is the amount of records decoded in parallel, defaults to the number of CPUs.
+	// Records are learned one at a time in file order, so the learned specs don't depend on Workers.
+	Workers int
+	// OnProgress is called every ProgressInterval (defaults to 10s) and once processing is done
+	OnProgress       func(progress Progress)
+	ProgressInterval time.Duration
+	// OnCheckpoint is called every CheckpointInterval (defaults to 1m), when processing is stopped and once
+	// processing is done, between learning records so the learner state can be persisted along the checkpoint.
+	// Processing fails if OnCheckpoint fails.
+	OnCheckpoint       func(checkpoint Checkpoint) error
+	CheckpointInterval time.Duration
+	// Resume processing from a checkpoint of the same input, the records before it are skipped
+	Resume *Checkpoint
+}
+
+// Progress of processing a bulk telemetry file.
+type Progress struct {
+	// Records read so far
+	Records int
+	// Learned records, the others failed to be decoded or learned
+	Learned int
+	Failed  int
+	// BytesRead so far (uncompressed)
+	BytesRead int64
+	Elapsed   time.Duration
+}
+
+type decodeResult struct {
+	lineNumber int
+	telemetry  *spec.Telemetry
+	err        error
+}
+
+type decodeJob struct {
+	record     []byte
+	lineNumber int
+	// bytesRead once the record was read
+	bytesRead int64
+	result    chan decodeResult
+}
+
+// Process reads the bulk telemetry records of r and learns them with learner. Records are decoded in parallel
+// and learned in order, records that fail to be decoded or learned are logged and skipped.
+// Processing stops when ctx is done, returning the progress so far with the context error.
+func Process(ctx context.Context, r io.Reader, learner Learner, config Config) (Progress, error) {
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+	if config.ProgressInterval <= 0 {
+		config.ProgressInterval = defaultProgressInterval
+	}
+	if config.CheckpointInterval <= 0 {
+		config.CheckpointInterval = defaultCheckpointInterval
+	}
+
+	reader := NewReader(r)
+	progress := Progress{}
+	checkpoint := Checkpoint{}
+	if config.Resume != nil {
+		if err := skipBytes(r, config.Resume.Offset); err != nil {
+			return progress, fmt.Errorf("failed to resume from checkpoint: %v", err)
+		}
+		checkpoint = *config.Resume
+		reader.bytesRead = checkpoint.Offset
+		reader.lineNumber = checkpoint.LineNumber
+		progress = checkpoint.progress()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan *decodeJob, config.Workers)
+	// the jobs in file order, bounded so the reader doesn't run too far ahead of learning
+	ordered := make(chan *decodeJob, 4*config.Workers)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(jobs)
+		defer close(ordered)
+		for {
+			record, lineNumber, err := reader.Next()
+			if err == io.EOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+			job := &decodeJob{
+				// the reader reuses its buffer
+				record:     append([]byte{}, record...),
+				lineNumber: lineNumber,
+				bytesRead:  reader.BytesRead(),
+				result:     make(chan decodeResult, 1),
+			}
+			select {
+			case ordered <- job:
+			case <-ctx.Done():
+				readErr <- nil
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				readErr <- nil
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < config.Workers; i++ {
+		go func() {
+			for job := range jobs {
+				telemetry, err := spec.DecodeTelemetry(job.record)
+				job.result <- decodeResult{lineNumber: job.lineNumber, telemetry: telemetry, err: err}
+			}
+		}()
+	}
+
+	start := time.Now()
+	lastReport := start
+	lastCheckpoint := start
+	report := func() {
+		progress.Elapsed = time.Since(start)
+		if config.OnProgress != nil {
+			config.OnProgress(progress)
+		}
+	}
+	saveCheckpoint := func() error {
+		lastCheckpoint = time.Now()
+		if config.OnCheckpoint == nil {
+			return nil
+		}
+		checkpoint.setProgress(progress)
+		if err := config.OnCheckpoint(checkpoint); err != nil {
+			return fmt.Errorf("failed to checkpoint: %w", err)
+		}
+		return nil
+	}
+	stop := func(err error) (Progress, error) {
+		report()
+		if checkpointErr := saveCheckpoint(); checkpointErr != nil {
+			log.Errorf("Failed to checkpoint on stop: %v", checkpointErr)
+		}
+		return progress, err
+	}
+
+	for job := range ordered {
+		var result decodeResult
+		select {
+		case result = <-job.result:
+		case <-ctx.Done():
+			return stop(ctx.Err())
+		}
+		progress.Records++
+		progress.BytesRead = job.bytesRead
+		checkpoint.LineNumber = job.lineNumber
+		if result.err != nil {
+			log.Warnf("Skipping record at line %v: %v", result.lineNumber, result.err)
+			progress.Failed++
+		} else if err := learner.LearnTelemetry(result.telemetry); err != nil {
+			log.Warnf("Failed to learn record at line %v: %v", result.lineNumber, err)
+			progress.Failed++
+		} else {
+			progress.Learned++
+		}
+		if time.Since(lastReport) >= config.ProgressInterval {
+			lastReport = time.Now()
+			report()
+		}
+		if time.Since(lastCheckpoint) >= config.CheckpointInterval {
+			if err := saveCheckpoint(); err != nil {
+				report()
+				return progress, err
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return stop(err)
+	}
+
+	if err := <-readErr; err != nil {
+		return stop(fmt.Errorf("failed to read records: %w", err))
+	}
+	// include the trailing empty lines
+	progress.BytesRead = reader.BytesRead()
+	checkpoint.LineNumber = reader.lineNumber
+	report()
+	if err := saveCheckpoint(); err != nil {
+		return progress, err
+	}
+	return progress, nil
+}
+
+// ProcessFile processes the bulk telemetry file at path, see Process and OpenFile.
+// The checkpoints are of path, resuming from a checkpoint of another file fails.
+func ProcessFile(ctx context.Context, path string, learner Learner, config Config) (Progress, error) {
+	if config.Resume != nil && config.Resume.File != "" && config.Resume.File != path {
+		return Progress{}, fmt.Errorf("checkpoint is of file %v", config.Resume.File)
+	}
+	if onCheckpoint := config.OnCheckpoint; onCheckpoint != nil {
+		config.OnCheckpoint = func(checkpoint Checkpoint) error {
+			checkpoint.File = path
+			return onCheckpoint(checkpoint)
+		}
+	}
+
+	r, closer, err := OpenFile(path)
+	if err != nil {
+		return Progress{}, err
+	}
+	defer closer.Close()
+
+	return Process(ctx, r, learner, config)
+}
@@ -0,0 +1,175 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+type recordingLearner struct {
+	paths []string
+	// learning fails for failPath
+	failPath string
+	onLearn  func()
+}
+
+func (l *recordingLearner) LearnTelemetry(telemetry *spec.Telemetry) error {
+	if l.onLearn != nil {
+		l.onLearn()
+	}
+	if telemetry.Request.Path == l.failPath {
+		return fmt.Errorf("failed")
+	}
+	l.paths = append(l.paths, telemetry.Request.Path)
+	return nil
+}
+
+func createRecords(t *testing.T, count int) (string, []string) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	paths := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		path := fmt.Sprintf("/api/%v", i)
+		paths = append(paths, path)
+		assert.NilError(t, w.Write(createTelemetry(path)))
+	}
+	assert.NilError(t, w.Flush())
+	return buf.String(), paths
+}
+
+func TestProcess(t *testing.T) {
+	records, paths := createRecords(t, 1000)
+	var progresses []Progress
+	learner := &recordingLearner{}
+	progress, err := Process(context.Background(), strings.NewReader(records), learner, Config{
+		Workers: 8,
+		OnProgress: func(progress Progress) {
+			progresses = append(progresses, progress)
+		},
+	})
+	assert.NilError(t, err)
+
+	// learned in file order
+	assert.DeepEqual(t, learner.paths, paths)
+	assert.Equal(t, progress.Records, 1000)
+	assert.Equal(t, progress.Learned, 1000)
+	assert.Equal(t, progress.Failed, 0)
+	assert.Equal(t, progress.BytesRead, int64(len(records)))
+	// reported once done
+	assert.Equal(t, len(progresses), 1)
+	assert.DeepEqual(t, progresses[0], progress)
+}
+
+func TestProcess_Failures(t *testing.T) {
+	records, _ := createRecords(t, 3)
+	records = "not a telemetry\n" + records + "{\"request\":{}}\n"
+	learner := &recordingLearner{failPath: "/api/1"}
+	progress, err := Process(context.Background(), strings.NewReader(records), learner, Config{})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, learner.paths, []string{"/api/0", "/api/2"})
+	assert.Equal(t, progress.Records, 5)
+	assert.Equal(t, progress.Learned, 2)
+	assert.Equal(t, progress.Failed, 3)
+}
+
+func TestProcess_Canceled(t *testing.T) {
+	records, _ := createRecords(t, 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	learned := 0
+	learner := &recordingLearner{onLearn: func() {
+		learned++
+		if learned == 10 {
+			cancel()
+		}
+	}}
+	progress, err := Process(ctx, strings.NewReader(records), learner, Config{Workers: 2})
+	assert.Equal(t, err, context.Canceled)
+	assert.Assert(t, progress.Learned >= 10)
+	assert.Assert(t, progress.Learned < 1000)
+}
+
+func TestProcess_Resume(t *testing.T) {
+	records, paths := createRecords(t, 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var checkpoints []Checkpoint
+	learner := &recordingLearner{}
+	learner.onLearn = func() {
+		if len(learner.paths) == 100 {
+			cancel()
+		}
+	}
+	_, err := Process(ctx, strings.NewReader(records), learner, Config{
+		Workers: 4,
+		OnCheckpoint: func(checkpoint Checkpoint) error {
+			checkpoints = append(checkpoints, checkpoint)
+			return nil
+		},
+	})
+	assert.Equal(t, err, context.Canceled)
+	// checkpointed on stop
+	assert.Equal(t, len(checkpoints), 1)
+	checkpoint := checkpoints[0]
+	assert.Equal(t, checkpoint.Records, len(learner.paths))
+	assert.Equal(t, checkpoint.LineNumber, len(learner.paths))
+
+	resumedLearner := &recordingLearner{}
+	progress, err := Process(context.Background(), strings.NewReader(records), resumedLearner, Config{
+		Resume: &checkpoint,
+		OnCheckpoint: func(checkpoint Checkpoint) error {
+			checkpoints = append(checkpoints, checkpoint)
+			return nil
+		},
+	})
+	assert.NilError(t, err)
+
+	// every record is learned once
+	assert.DeepEqual(t, append(learner.paths, resumedLearner.paths...), paths)
+	assert.Equal(t, progress.Records, 1000)
+	assert.Equal(t, progress.Learned, 1000)
+	assert.Equal(t, progress.BytesRead, int64(len(records)))
+	// checkpointed once done
+	assert.Equal(t, len(checkpoints), 2)
+	assert.DeepEqual(t, checkpoints[1], Checkpoint{Offset: int64(len(records)), LineNumber: 1000, Records: 1000, Learned: 1000})
+}
+
+func TestProcess_CheckpointFailure(t *testing.T) {
+	records, _ := createRecords(t, 3)
+	_, err := Process(context.Background(), strings.NewReader(records), &recordingLearner{}, Config{
+		OnCheckpoint: func(checkpoint Checkpoint) error {
+			return fmt.Errorf("failed")
+		},
+	})
+	assert.ErrorContains(t, err, "failed to checkpoint")
+}
+
+func TestProcessFile_ResumeOtherFile(t *testing.T) {
+	_, err := ProcessFile(context.Background(), "telemetries.jsonl", &recordingLearner{}, Config{
+		Resume: &Checkpoint{File: "other.jsonl"},
+	})
+	assert.ErrorContains(t, err, "checkpoint is of file other.jsonl")
+}
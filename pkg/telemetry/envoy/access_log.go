@@ -0,0 +1,177 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/internal/httpcapture"
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	contentTypeHeaderName = "Content-Type"
+	// envoy access logs render missing values as "-"
+	accessLogMissingValue = "-"
+	maxAccessLogLineSize  = 10 * 1024 * 1024
+)
+
+// AccessLogEntry is a line of an Envoy JSON access log, expected to be configured with the following json_format
+// (the bodies are typically set into the dynamic metadata by a Lua or Wasm filter):
+//
+//	start_time: "%START_TIME%"
+//	method: "%REQ(:METHOD)%"
+//	path: "%REQ(X-ENVOY-ORIGINAL-PATH?:PATH)%"
+//	authority: "%REQ(:AUTHORITY)%"
+//	protocol: "%PROTOCOL%"
+//	response_code: "%RESPONSE_CODE%"
+//	upstream_host: "%UPSTREAM_HOST%"
+//	downstream_remote_address: "%DOWNSTREAM_REMOTE_ADDRESS%"
+//	x_request_id: "%REQ(X-REQUEST-ID)%"
+//	request_content_type: "%REQ(CONTENT-TYPE)%"
+//	response_content_type: "%RESP(CONTENT-TYPE)%"
+//	request_body: "%DYNAMIC_METADATA(speculator:request_body)%"
+//	response_body: "%DYNAMIC_METADATA(speculator:response_body)%"
+//	duration: "%DURATION%"
+type AccessLogEntry struct {
+	StartTime string `json:"start_time"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Authority string `json:"authority"`
+	Protocol  string `json:"protocol"`
+	// ResponseCode is a number, or a string when the access log is not typed
+	ResponseCode            json.RawMessage `json:"response_code"`
+	UpstreamHost            string          `json:"upstream_host"`
+	DownstreamRemoteAddress string          `json:"downstream_remote_address"`
+	RequestID               string          `json:"x_request_id"`
+	RequestContentType      string          `json:"request_content_type"`
+	ResponseContentType     string          `json:"response_content_type"`
+	RequestBody             string          `json:"request_body"`
+	ResponseBody            string          `json:"response_body"`
+	// Duration is the total duration of the request in milliseconds, a number or a string like ResponseCode
+	Duration json.RawMessage `json:"duration,omitempty"`
+}
+
+// DecodeAccessLog reads a newline delimited JSON access log and converts its entries into telemetries.
+// Lines that can not be converted (e.g. connections closed before a response was sent) are skipped.
+func DecodeAccessLog(r io.Reader) ([]*spec.Telemetry, error) {
+	var telemetries []*spec.Telemetry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxAccessLogLineSize)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entry := &AccessLogEntry{}
+		if err := json.Unmarshal([]byte(line), entry); err != nil {
+			log.Warnf("Skipping access log line %v: failed to decode: %v", lineNumber, err)
+			continue
+		}
+		telemetry, err := entry.ToTelemetry()
+		if err != nil {
+			log.Warnf("Skipping access log line %v: %v", lineNumber, err)
+			continue
+		}
+		telemetries = append(telemetries, telemetry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read access log: %v", err)
+	}
+
+	return telemetries, nil
+}
+
+// ToTelemetry converts the access log entry into a telemetry.
+func (e *AccessLogEntry) ToTelemetry() (*spec.Telemetry, error) {
+	method := getAccessLogValue(e.Method)
+	path := getAccessLogValue(e.Path)
+	if method == "" || path == "" {
+		return nil, fmt.Errorf("entry is missing method or path")
+	}
+	statusCode := getAccessLogValue(strings.Trim(string(e.ResponseCode), `"`))
+	// envoy logs 0 when no response was sent
+	if statusCode == "" || statusCode == "0" || statusCode == "null" {
+		return nil, fmt.Errorf("entry has no response")
+	}
+	authority := getAccessLogValue(e.Authority)
+
+	telemetry := &spec.Telemetry{
+		DestinationAddress: httpcapture.AddressWithPort(authority, ""),
+		Request: &spec.Request{
+			Common: createAccessLogCommon(e.RequestContentType, e.RequestBody, e.Protocol),
+			Host:   httpcapture.HostWithoutPort(authority),
+			Method: method,
+			Path:   path,
+		},
+		RequestID: getAccessLogValue(e.RequestID),
+		Response: &spec.Response{
+			Common:     createAccessLogCommon(e.ResponseContentType, e.ResponseBody, e.Protocol),
+			StatusCode: statusCode,
+		},
+		SourceAddress: getAccessLogValue(e.DownstreamRemoteAddress),
+	}
+	if upstreamHost := getAccessLogValue(e.UpstreamHost); upstreamHost != "" {
+		telemetry.DestinationAddress = upstreamHost
+	}
+	if startTime := getAccessLogValue(e.StartTime); startTime != "" {
+		timestamp, err := time.Parse(time.RFC3339Nano, startTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_time %v: %v", startTime, err)
+		}
+		telemetry.Timestamp = timestamp
+	}
+	if duration := getAccessLogValue(strings.Trim(string(e.Duration), `"`)); duration != "" && duration != "null" {
+		durationMs, err := strconv.ParseInt(duration, 10, 64)
+		if err != nil || durationMs < 0 {
+			return nil, fmt.Errorf("invalid duration %v", duration)
+		}
+		telemetry.Latency = time.Duration(durationMs) * time.Millisecond
+	}
+
+	return telemetry, nil
+}
+
+func createAccessLogCommon(contentType, body, protocol string) *spec.Common {
+	common := &spec.Common{
+		Version: getAccessLogValue(protocol),
+	}
+	if body = getAccessLogValue(body); body != "" {
+		common.Body = []byte(body)
+	}
+	if contentType = getAccessLogValue(contentType); contentType != "" {
+		common.Headers = []*spec.Header{{Key: contentTypeHeaderName, Value: contentType}}
+	}
+	return common
+}
+
+func getAccessLogValue(value string) string {
+	if value == accessLogMissingValue {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,118 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestDecodeAccessLog(t *testing.T) {
+	file, err := os.Open("../../../test/envoy_access_log.jsonl")
+	assert.NilError(t, err)
+	defer file.Close()
+
+	// invalid lines and entries without a response are skipped
+	got, err := DecodeAccessLog(file)
+	assert.NilError(t, err)
+	want := []*spec.Telemetry{
+		{
+			DestinationAddress: "10.0.0.3:8080",
+			Request: &spec.Request{
+				Common: &spec.Common{Version: "HTTP/1.1"},
+				Host:   "users.default",
+				Method: "GET",
+				Path:   "/users/1",
+			},
+			RequestID: "req-1",
+			Response: &spec.Response{
+				Common: &spec.Common{
+					Body:    []byte(`{"id":1}`),
+					Headers: []*spec.Header{{Key: contentTypeHeaderName, Value: "application/json"}},
+					Version: "HTTP/1.1",
+				},
+				StatusCode: "200",
+			},
+			SourceAddress: "10.0.0.4:40000",
+			Timestamp:     time.Date(2021, 9, 1, 10, 0, 0, 123000000, time.UTC),
+			Latency:       12 * time.Millisecond,
+		},
+		{
+			DestinationAddress: "users.default:80",
+			Request: &spec.Request{
+				Common: &spec.Common{
+					Body:    []byte(`{"name":"a"}`),
+					Headers: []*spec.Header{{Key: contentTypeHeaderName, Value: "application/json"}},
+					Version: "HTTP/2",
+				},
+				Host:   "users.default",
+				Method: "POST",
+				Path:   "/users",
+			},
+			Response: &spec.Response{
+				Common:     &spec.Common{Version: "HTTP/2"},
+				StatusCode: "201",
+			},
+		},
+	}
+	assert.Equal(t, len(got), len(want))
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("DecodeAccessLog() telemetry %v = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAccessLogEntry_ToTelemetry_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   AccessLogEntry
+		wantErr string
+	}{
+		{
+			name:    "missing path",
+			entry:   AccessLogEntry{Method: "GET", Path: "-", ResponseCode: []byte("200")},
+			wantErr: "missing method or path",
+		},
+		{
+			name:    "no response",
+			entry:   AccessLogEntry{Method: "GET", Path: "/", ResponseCode: []byte("null")},
+			wantErr: "no response",
+		},
+		{
+			name:    "invalid start time",
+			entry:   AccessLogEntry{Method: "GET", Path: "/", ResponseCode: []byte("200"), StartTime: "yesterday"},
+			wantErr: "invalid start_time",
+		},
+		{
+			name:    "invalid duration",
+			entry:   AccessLogEntry{Method: "GET", Path: "/", ResponseCode: []byte("200"), Duration: []byte(`"-12"`)},
+			wantErr: "invalid duration",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.entry.ToTelemetry()
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
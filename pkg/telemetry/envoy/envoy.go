@@ -0,0 +1,58 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package envoy converts Envoy tap traces and JSON access logs into telemetries.
+package envoy
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	methodHeaderName    = ":method"
+	pathHeaderName      = ":path"
+	authorityHeaderName = ":authority"
+	schemeHeaderName    = ":scheme"
+	statusHeaderName    = ":status"
+	hostHeaderName      = "host"
+	requestIDHeaderName = "x-request-id"
+)
+
+// Learner learns telemetries, e.g. spec.Spec or speculator.Speculator.
+type Learner interface {
+	LearnTelemetry(telemetry *spec.Telemetry) error
+}
+
+// Learn feeds the telemetries to learner, and returns the amount of telemetries learned.
+// Telemetries that fail to be learned are logged and skipped.
+func Learn(learner Learner, telemetries []*spec.Telemetry) int {
+	learned := 0
+	for _, telemetry := range telemetries {
+		if err := learner.LearnTelemetry(telemetry); err != nil {
+			log.Errorf("Failed to learn telemetry of %v %v: %v", telemetry.Request.Method, telemetry.Request.Path, err)
+			continue
+		}
+		learned++
+	}
+	return learned
+}
+
+func isPseudoHeader(name string) bool {
+	return strings.HasPrefix(name, ":")
+}
@@ -0,0 +1,40 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestLearn(t *testing.T) {
+	data, err := ioutil.ReadFile("../../../test/envoy_tap.json")
+	assert.NilError(t, err)
+	telemetry, err := DecodeTapTrace(data)
+	assert.NilError(t, err)
+	invalid := &spec.Telemetry{
+		Request:  &spec.Request{Method: "BREW", Path: "/coffee"},
+		Response: &spec.Response{StatusCode: "418"},
+	}
+
+	s := spec.CreateDefaultSpec("httpbin.org", "8080", spec.OperationGeneratorConfig{})
+	assert.Equal(t, Learn(s, []*spec.Telemetry{telemetry, invalid}), 1)
+	assert.Assert(t, s.LearningSpec.GetPathItem("/anything/1") != nil)
+}
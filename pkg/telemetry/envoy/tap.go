@@ -0,0 +1,183 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/apiclarity/speculator/pkg/internal/httpcapture"
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+// TapTrace is a trace written by the Envoy tap filter in JSON (JSON_BODY_AS_BYTES or JSON_BODY_AS_STRING format).
+// Only buffered HTTP traces are supported.
+type TapTrace struct {
+	HTTPBufferedTrace *HTTPBufferedTrace `json:"http_buffered_trace"`
+}
+
+type HTTPBufferedTrace struct {
+	Request              TapMessage     `json:"request"`
+	Response             TapMessage     `json:"response"`
+	DownstreamConnection *TapConnection `json:"downstream_connection,omitempty"`
+}
+
+type TapMessage struct {
+	Headers []TapHeader `json:"headers"`
+	Body    *TapBody    `json:"body,omitempty"`
+}
+
+type TapHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type TapBody struct {
+	// AsBytes is base64 encoded, AsString is set instead with the JSON_BODY_AS_STRING format
+	AsBytes   string `json:"as_bytes,omitempty"`
+	AsString  string `json:"as_string,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+type TapConnection struct {
+	LocalAddress  *TapAddress `json:"local_address,omitempty"`
+	RemoteAddress *TapAddress `json:"remote_address,omitempty"`
+}
+
+type TapAddress struct {
+	SocketAddress *TapSocketAddress `json:"socket_address,omitempty"`
+}
+
+type TapSocketAddress struct {
+	Address   string `json:"address"`
+	PortValue int    `json:"port_value"`
+}
+
+// DecodeTapTrace decodes a JSON tap trace, as written per file by the tap filter file sink, into a telemetry.
+func DecodeTapTrace(data []byte) (*spec.Telemetry, error) {
+	trace := &TapTrace{}
+	if err := json.Unmarshal(data, trace); err != nil {
+		return nil, fmt.Errorf("failed to decode tap trace: %v", err)
+	}
+	if trace.HTTPBufferedTrace == nil {
+		return nil, fmt.Errorf("tap trace is not a buffered http trace")
+	}
+
+	return trace.HTTPBufferedTrace.toTelemetry()
+}
+
+func (t *HTTPBufferedTrace) toTelemetry() (*spec.Telemetry, error) {
+	reqHeaders := getTapHeaders(t.Request.Headers)
+	method := reqHeaders[methodHeaderName]
+	path := reqHeaders[pathHeaderName]
+	if method == "" || path == "" {
+		return nil, fmt.Errorf("request is missing %v or %v headers", methodHeaderName, pathHeaderName)
+	}
+	statusCode := getTapHeaders(t.Response.Headers)[statusHeaderName]
+	if statusCode == "" {
+		return nil, fmt.Errorf("response is missing %v header", statusHeaderName)
+	}
+	authority := reqHeaders[authorityHeaderName]
+	if authority == "" {
+		authority = reqHeaders[hostHeaderName]
+	}
+	scheme := reqHeaders[schemeHeaderName]
+
+	reqCommon, err := t.Request.toCommon()
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+	respCommon, err := t.Response.toCommon()
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+
+	telemetry := &spec.Telemetry{
+		DestinationAddress: httpcapture.AddressWithPort(authority, scheme),
+		Request: &spec.Request{
+			Common: reqCommon,
+			Host:   httpcapture.HostWithoutPort(authority),
+			Method: method,
+			Path:   path,
+		},
+		RequestID: reqHeaders[requestIDHeaderName],
+		Response: &spec.Response{
+			Common:     respCommon,
+			StatusCode: statusCode,
+		},
+		Scheme: scheme,
+	}
+	// the local address of the downstream connection is the tapped listener, i.e. the destination
+	if t.DownstreamConnection != nil {
+		if address := t.DownstreamConnection.LocalAddress.String(); address != "" {
+			telemetry.DestinationAddress = address
+		}
+		telemetry.SourceAddress = t.DownstreamConnection.RemoteAddress.String()
+	}
+
+	return telemetry, nil
+}
+
+func (m *TapMessage) toCommon() (*spec.Common, error) {
+	common := &spec.Common{}
+	for _, header := range m.Headers {
+		if isPseudoHeader(header.Key) {
+			continue
+		}
+		common.Headers = append(common.Headers, &spec.Header{Key: header.Key, Value: header.Value})
+	}
+	if m.Body == nil {
+		return common, nil
+	}
+
+	common.TruncatedBody = m.Body.Truncated
+	if m.Body.AsString != "" {
+		common.Body = []byte(m.Body.AsString)
+		return common, nil
+	}
+	if m.Body.AsBytes == "" {
+		return common, nil
+	}
+	body, err := base64.StdEncoding.DecodeString(m.Body.AsBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode body bytes: %v", err)
+	}
+	common.Body = body
+	return common, nil
+}
+
+// getTapHeaders returns the headers by lower case name, using the first value of repeated headers.
+func getTapHeaders(headers []TapHeader) map[string]string {
+	ret := make(map[string]string, len(headers))
+	for _, header := range headers {
+		key := strings.ToLower(header.Key)
+		if _, ok := ret[key]; !ok {
+			ret[key] = header.Value
+		}
+	}
+	return ret
+}
+
+func (a *TapAddress) String() string {
+	if a == nil || a.SocketAddress == nil || a.SocketAddress.Address == "" {
+		return ""
+	}
+	return net.JoinHostPort(a.SocketAddress.Address, strconv.Itoa(a.SocketAddress.PortValue))
+}
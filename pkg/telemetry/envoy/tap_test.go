@@ -0,0 +1,107 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func TestDecodeTapTrace(t *testing.T) {
+	data, err := ioutil.ReadFile("../../../test/envoy_tap.json")
+	assert.NilError(t, err)
+
+	got, err := DecodeTapTrace(data)
+	assert.NilError(t, err)
+	want := &spec.Telemetry{
+		DestinationAddress: "10.0.0.1:8080",
+		Request: &spec.Request{
+			Common: &spec.Common{
+				Body: []byte(`{"name":"a"}`),
+				Headers: []*spec.Header{
+					{Key: "content-type", Value: "application/json"},
+					{Key: "x-request-id", Value: "0f6ba2e4-1d6c-4c30-9d5e-0a4b7f3f1d2a"},
+				},
+			},
+			Host:   "httpbin.org",
+			Method: "POST",
+			Path:   "/anything/1?limit=10",
+		},
+		RequestID: "0f6ba2e4-1d6c-4c30-9d5e-0a4b7f3f1d2a",
+		Response: &spec.Response{
+			Common: &spec.Common{
+				Body:    []byte(`{"id":1}`),
+				Headers: []*spec.Header{{Key: "content-type", Value: "application/json"}},
+			},
+			StatusCode: "201",
+		},
+		Scheme:        "http",
+		SourceAddress: "10.0.0.2:51234",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeTapTrace() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeTapTrace_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "not json",
+			data:    `{`,
+			wantErr: "failed to decode tap trace",
+		},
+		{
+			name:    "streamed trace",
+			data:    `{"http_streamed_trace_segment": {}}`,
+			wantErr: "not a buffered http trace",
+		},
+		{
+			name:    "missing method",
+			data:    `{"http_buffered_trace": {"request": {"headers": [{"key": ":path", "value": "/"}]}}}`,
+			wantErr: "request is missing",
+		},
+		{
+			name:    "missing status",
+			data:    `{"http_buffered_trace": {"request": {"headers": [{"key": ":path", "value": "/"}, {"key": ":method", "value": "GET"}]}}}`,
+			wantErr: "response is missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeTapTrace([]byte(tt.data))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTapMessage_toCommon(t *testing.T) {
+	common, err := (&TapMessage{Body: &TapBody{AsString: "text", Truncated: true}}).toCommon()
+	assert.NilError(t, err)
+	assert.Equal(t, string(common.Body), "text")
+	assert.Assert(t, common.TruncatedBody)
+
+	_, err = (&TapMessage{Body: &TapBody{AsBytes: "%%"}}).toCommon()
+	assert.ErrorContains(t, err, "failed to decode body bytes")
+}
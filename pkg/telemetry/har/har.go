@@ -0,0 +1,263 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package har converts HAR (HTTP Archive) files, as exported by browser devtools and proxies, into telemetries.
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	contentTypeHeaderName = "Content-Type"
+	base64Encoding        = "base64"
+)
+
+// HAR is the root of a HAR file, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+type Log struct {
+	Entries []Entry `json:"entries"`
+}
+
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	// Time is the total elapsed time of the request in milliseconds
+	Time float64 `json:"time,omitempty"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Params are the posted form params, when Text is not set
+	Params []NameValue `json:"params,omitempty"`
+}
+
+type Content struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	// Encoding of Text, e.g. base64 for binary contents
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// LoadFile reads a HAR file and converts its entries into telemetries, see Decode.
+func LoadFile(path string) ([]*spec.Telemetry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HAR file (%v): %v", path, err)
+	}
+	defer file.Close()
+
+	return Decode(file)
+}
+
+// Decode reads a HAR archive and converts its entries into telemetries, in the archive order.
+// Entries that can not be converted (e.g. requests that got no response) are skipped.
+func Decode(r io.Reader) ([]*spec.Telemetry, error) {
+	har := &HAR{}
+	if err := json.NewDecoder(r).Decode(har); err != nil {
+		return nil, fmt.Errorf("failed to decode HAR: %v", err)
+	}
+
+	telemetries := make([]*spec.Telemetry, 0, len(har.Log.Entries))
+	for i := range har.Log.Entries {
+		telemetry, err := EntryToTelemetry(&har.Log.Entries[i])
+		if err != nil {
+			log.Warnf("Skipping HAR entry %v: %v", i, err)
+			continue
+		}
+		telemetries = append(telemetries, telemetry)
+	}
+
+	return telemetries, nil
+}
+
+// EntryToTelemetry converts a single HAR entry into a telemetry.
+func EntryToTelemetry(entry *Entry) (*spec.Telemetry, error) {
+	if entry.Response.Status == 0 {
+		return nil, fmt.Errorf("entry has no response")
+	}
+	reqURL, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %v: %v", entry.Request.URL, err)
+	}
+	if reqURL.Host == "" {
+		return nil, fmt.Errorf("url %v has no host", entry.Request.URL)
+	}
+
+	reqBody := getRequestBody(entry.Request.PostData)
+	reqHeaders := convertHeaders(entry.Request.Headers)
+	if entry.Request.PostData != nil && entry.Request.PostData.MimeType != "" && !hasHeader(reqHeaders, contentTypeHeaderName) {
+		reqHeaders = append(reqHeaders, &spec.Header{Key: contentTypeHeaderName, Value: entry.Request.PostData.MimeType})
+	}
+
+	respBody, err := getResponseBody(entry.Response.Content)
+	if err != nil {
+		return nil, err
+	}
+	respHeaders := convertHeaders(entry.Response.Headers)
+	if entry.Response.Content.MimeType != "" && len(respBody) > 0 && !hasHeader(respHeaders, contentTypeHeaderName) {
+		respHeaders = append(respHeaders, &spec.Header{Key: contentTypeHeaderName, Value: entry.Response.Content.MimeType})
+	}
+
+	return &spec.Telemetry{
+		DestinationAddress: getDestinationAddress(entry, reqURL),
+		Request: &spec.Request{
+			Common: &spec.Common{
+				Body:    reqBody,
+				Headers: reqHeaders,
+				Version: entry.Request.HTTPVersion,
+			},
+			Host:   reqURL.Hostname(),
+			Method: entry.Request.Method,
+			Path:   getRequestPath(reqURL, entry.Request.QueryString),
+		},
+		Response: &spec.Response{
+			Common: &spec.Common{
+				Body:    respBody,
+				Headers: respHeaders,
+				Version: entry.Response.HTTPVersion,
+			},
+			StatusCode: strconv.Itoa(entry.Response.Status),
+		},
+		Scheme:    reqURL.Scheme,
+		Timestamp: entry.StartedDateTime,
+		Latency:   time.Duration(entry.Time * float64(time.Millisecond)),
+	}, nil
+}
+
+// getDestinationAddress returns the server address of the entry, falling back to the url host when the
+// server IP was not recorded. The port defaults to the url scheme port.
+func getDestinationAddress(entry *Entry, reqURL *url.URL) string {
+	port := reqURL.Port()
+	if port == "" {
+		port = "80"
+		if reqURL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	host := strings.Trim(entry.ServerIPAddress, "[]")
+	if host == "" {
+		host = reqURL.Hostname()
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// getRequestPath returns the path and query of the url, the query is taken from queryString if the url has none.
+func getRequestPath(reqURL *url.URL, queryString []NameValue) string {
+	if reqURL.RawQuery != "" || len(queryString) == 0 {
+		return reqURL.RequestURI()
+	}
+
+	query := url.Values{}
+	for _, param := range queryString {
+		query.Add(param.Name, param.Value)
+	}
+	reqURL.RawQuery = query.Encode()
+	return reqURL.RequestURI()
+}
+
+func getRequestBody(postData *PostData) []byte {
+	if postData == nil {
+		return nil
+	}
+	if postData.Text != "" || len(postData.Params) == 0 {
+		return []byte(postData.Text)
+	}
+
+	// exporters may only record the params of url encoded forms
+	form := url.Values{}
+	for _, param := range postData.Params {
+		form.Add(param.Name, param.Value)
+	}
+	return []byte(form.Encode())
+}
+
+func getResponseBody(content Content) ([]byte, error) {
+	if content.Encoding != base64Encoding {
+		return []byte(content.Text), nil
+	}
+	body, err := base64.StdEncoding.DecodeString(content.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 response content: %v", err)
+	}
+	return body, nil
+}
+
+func convertHeaders(headers []NameValue) []*spec.Header {
+	var ret []*spec.Header
+
+	for _, header := range headers {
+		// HTTP/2 pseudo headers (:authority, :path...) are not part of the API
+		if strings.HasPrefix(header.Name, ":") {
+			continue
+		}
+		ret = append(ret, &spec.Header{
+			Key:   header.Name,
+			Value: header.Value,
+		})
+	}
+
+	return ret
+}
+
+func hasHeader(headers []*spec.Header, name string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package har
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+	"github.com/apiclarity/speculator/pkg/speculator"
+)
+
+func TestLoadFile(t *testing.T) {
+	telemetries, err := LoadFile("../../../test/httpbin.har")
+	assert.NilError(t, err)
+	// the entry without a response is skipped
+	want := []*spec.Telemetry{
+		{
+			DestinationAddress: "34.227.213.82:443",
+			Request: &spec.Request{
+				Common: &spec.Common{
+					Headers: []*spec.Header{{Key: "accept", Value: "application/json"}},
+					Version: "http/2.0",
+				},
+				Host:   "httpbin.org",
+				Method: "GET",
+				Path:   "/anything/1?limit=10",
+			},
+			Response: &spec.Response{
+				Common: &spec.Common{
+					Body:    []byte(`{"id":1}`),
+					Headers: []*spec.Header{{Key: "content-type", Value: "application/json"}},
+					Version: "http/2.0",
+				},
+				StatusCode: "200",
+			},
+			Scheme:    "https",
+			Timestamp: time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC),
+			Latency:   150500 * time.Microsecond,
+		},
+		{
+			DestinationAddress: "httpbin.org:8080",
+			Request: &spec.Request{
+				Common: &spec.Common{
+					Body:    []byte(`{"name":"a"}`),
+					Headers: []*spec.Header{{Key: "Content-Type", Value: "application/json"}},
+					Version: "HTTP/1.1",
+				},
+				Host:   "httpbin.org",
+				Method: "POST",
+				Path:   "/anything?dry=true",
+			},
+			Response: &spec.Response{
+				Common: &spec.Common{
+					Body:    []byte(`{"id":2}`),
+					Headers: []*spec.Header{{Key: "Content-Type", Value: "application/json"}},
+					Version: "HTTP/1.1",
+				},
+				StatusCode: "201",
+			},
+			Scheme:    "http",
+			Timestamp: time.Date(2021, 9, 1, 10, 0, 1, 0, time.UTC),
+		},
+	}
+	assert.Equal(t, len(telemetries), len(want))
+	for i := range want {
+		if !reflect.DeepEqual(telemetries[i], want[i]) {
+			t.Errorf("LoadFile() telemetry %v = %+v, want %+v", i, telemetries[i], want[i])
+		}
+	}
+
+	// telemetries are learned as is
+	s := speculator.CreateSpeculator(speculator.Config{})
+	for _, telemetry := range telemetries {
+		assert.NilError(t, s.LearnTelemetry(telemetry))
+	}
+	assert.Equal(t, len(s.Specs), 2)
+}
+
+func Test_getRequestBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		postData *PostData
+		want     []byte
+	}{
+		{
+			name: "no post data",
+		},
+		{
+			name:     "text",
+			postData: &PostData{Text: "a=1", Params: []NameValue{{Name: "b", Value: "2"}}},
+			want:     []byte("a=1"),
+		},
+		{
+			name:     "form params",
+			postData: &PostData{Params: []NameValue{{Name: "b", Value: "2"}, {Name: "a", Value: "1 2"}}},
+			want:     []byte("a=1+2&b=2"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getRequestBody(tt.postData); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getRequestBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryToTelemetry_Errors(t *testing.T) {
+	_, err := EntryToTelemetry(&Entry{Request: Request{URL: "https://host/api"}})
+	assert.ErrorContains(t, err, "no response")
+	_, err = EntryToTelemetry(&Entry{Request: Request{URL: "/api"}, Response: Response{Status: 200}})
+	assert.ErrorContains(t, err, "has no host")
+	_, err = EntryToTelemetry(&Entry{
+		Request:  Request{URL: "https://host/api"},
+		Response: Response{Status: 200, Content: Content{Text: "%%", Encoding: base64Encoding}},
+	})
+	assert.ErrorContains(t, err, "base64")
+}
@@ -0,0 +1,238 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry converts net/http requests and responses into telemetries, see FromHTTP.
+// The sub packages decode the telemetries of other sources (HAR files, packet captures and Envoy).
+package telemetry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apiclarity/speculator/pkg/internal/httpcapture"
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+const (
+	// DefaultMaxBodySize is the maximum request/response body bytes captured by FromHTTP, see WithMaxBodySize.
+	DefaultMaxBodySize = 1 << 20 // 1 MB
+
+	requestIDHeaderName = "X-Request-Id"
+)
+
+type Option func(*options)
+
+type options struct {
+	maxBodySize        int64
+	destinationAddress string
+	source             spec.SourceLabel
+	requestID          string
+	timestamp          time.Time
+	latency            time.Duration
+	// the request/response bodies are partial captures
+	truncatedRequestBody  bool
+	truncatedResponseBody bool
+}
+
+// WithMaxBodySize sets the maximum body bytes captured per request/response, larger bodies are marked as truncated
+// and are not learned. Defaults to DefaultMaxBodySize.
+func WithMaxBodySize(maxBodySize int64) Option {
+	return func(o *options) {
+		o.maxBodySize = maxBodySize
+	}
+}
+
+// WithDestinationAddress sets the "ip:port" destination address, which defaults to the request host with the
+// default port of the scheme when it has no port.
+func WithDestinationAddress(address string) Option {
+	return func(o *options) {
+		o.destinationAddress = address
+	}
+}
+
+// WithSource labels the source of the request, see spec.Telemetry.Source.
+func WithSource(source spec.SourceLabel) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+// WithRequestID sets the request ID, which defaults to the X-Request-Id request header.
+func WithRequestID(requestID string) Option {
+	return func(o *options) {
+		o.requestID = requestID
+	}
+}
+
+// WithTimestamp sets the time the interaction was captured at, the processing time is used when not set.
+func WithTimestamp(timestamp time.Time) Option {
+	return func(o *options) {
+		o.timestamp = timestamp
+	}
+}
+
+// WithLatency sets the time the server took to respond.
+func WithLatency(latency time.Duration) Option {
+	return func(o *options) {
+		o.latency = latency
+	}
+}
+
+// WithTruncatedBodies marks the request and/or response body as truncated, for callers that captured the bodies
+// themselves and pass only the captured part. Truncated bodies are not learned.
+func WithTruncatedBodies(request, response bool) Option {
+	return func(o *options) {
+		o.truncatedRequestBody = request
+		o.truncatedResponseBody = response
+	}
+}
+
+// FromHTTP converts a request and its response into a telemetry, on the client side (e.g. in an
+// http.RoundTripper) or on the server side (e.g. in a middleware with a recorded response).
+// The bodies are captured up to the max body size without consuming them: the response body is replaced with a
+// reader of the full body, and the request body is read from req.GetBody when it is set (as by http.NewRequest),
+// otherwise it is replaced like the response body.
+func FromHTTP(req *http.Request, resp *http.Response, opts ...Option) (*spec.Telemetry, error) {
+	if req == nil || resp == nil {
+		return nil, fmt.Errorf("request and response are required")
+	}
+	if req.URL == nil {
+		return nil, fmt.Errorf("request has no URL")
+	}
+	o := &options{maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	reqBody, reqTruncated, err := captureRequestBody(req, o.maxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture request body: %v", err)
+	}
+	respBody, respTruncated, err := captureBody(&resp.Body, o.maxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture response body: %v", err)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	scheme := getScheme(req)
+	telemetry := &spec.Telemetry{
+		DestinationAddress: o.destinationAddress,
+		Request: &spec.Request{
+			Common: createCommon(req.Header, req.Proto, reqBody, reqTruncated || o.truncatedRequestBody),
+			Host:   httpcapture.HostWithoutPort(host),
+			Method: req.Method,
+			Path:   req.URL.RequestURI(),
+		},
+		RequestID: o.requestID,
+		Response: &spec.Response{
+			Common:     createCommon(resp.Header, resp.Proto, respBody, respTruncated || o.truncatedResponseBody),
+			StatusCode: strconv.Itoa(resp.StatusCode),
+		},
+		Scheme:        scheme,
+		SourceAddress: req.RemoteAddr,
+		Source:        o.source,
+		Timestamp:     o.timestamp,
+		Latency:       o.latency,
+	}
+	if telemetry.DestinationAddress == "" {
+		telemetry.DestinationAddress = httpcapture.AddressWithPort(host, scheme)
+	}
+	if telemetry.RequestID == "" {
+		telemetry.RequestID = req.Header.Get(requestIDHeaderName)
+	}
+
+	return telemetry, nil
+}
+
+func captureRequestBody(req *http.Request, maxBodySize int64) (body []byte, truncated bool, err error) {
+	if req.GetBody != nil {
+		bodyCopy, err := req.GetBody()
+		if err != nil {
+			return nil, false, err
+		}
+		defer bodyCopy.Close()
+		captured, err := readBody(bodyCopy, maxBodySize)
+		if err != nil {
+			return nil, false, err
+		}
+		body, truncated = truncateBody(captured, maxBodySize)
+		return body, truncated, nil
+	}
+	return captureBody(&req.Body, maxBodySize)
+}
+
+// captureBody reads up to maxBodySize bytes of body and replaces it with a reader of the full body.
+func captureBody(body *io.ReadCloser, maxBodySize int64) (captured []byte, truncated bool, err error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, false, nil
+	}
+	read, err := readBody(*body, maxBodySize)
+	if err != nil {
+		return nil, false, err
+	}
+	*body = &replayedBody{
+		Reader: io.MultiReader(bytes.NewReader(read), *body),
+		Closer: *body,
+	}
+	captured, truncated = truncateBody(read, maxBodySize)
+	return captured, truncated, nil
+}
+
+// readBody reads up to maxBodySize+1 bytes of body, one more byte tells whether the body is larger than maxBodySize.
+func readBody(body io.Reader, maxBodySize int64) ([]byte, error) {
+	return ioutil.ReadAll(io.LimitReader(body, maxBodySize+1))
+}
+
+func truncateBody(body []byte, maxBodySize int64) ([]byte, bool) {
+	if int64(len(body)) > maxBodySize {
+		return body[:maxBodySize], true
+	}
+	return body, false
+}
+
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+func createCommon(header http.Header, proto string, body []byte, truncated bool) *spec.Common {
+	common := &spec.Common{
+		Headers:       httpcapture.ConvertHeaders(header),
+		Version:       proto,
+		TruncatedBody: truncated,
+	}
+	if len(body) > 0 {
+		common.Body = body
+	}
+	return common
+}
+
+func getScheme(req *http.Request) string {
+	if req.URL.Scheme != "" {
+		return req.URL.Scheme
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,133 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+func createResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Proto:      "HTTP/1.1",
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestFromHTTP_client(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/users?limit=1", strings.NewReader(`{"name":"a"}`))
+	assert.NilError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "req-id")
+	resp := createResponse(http.StatusCreated, `{"id":1}`)
+	timestamp := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	telemetry, err := FromHTTP(req, resp, WithSource(spec.SourceInternal), WithTimestamp(timestamp), WithLatency(time.Second))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, telemetry, &spec.Telemetry{
+		DestinationAddress: "api.example.com:443",
+		Request: &spec.Request{
+			Common: &spec.Common{
+				Body: []byte(`{"name":"a"}`),
+				Headers: []*spec.Header{
+					{Key: "Content-Type", Value: "application/json"},
+					{Key: "X-Request-Id", Value: "req-id"},
+				},
+				Version: "HTTP/1.1",
+			},
+			Host:   "api.example.com",
+			Method: http.MethodPost,
+			Path:   "/users?limit=1",
+		},
+		RequestID: "req-id",
+		Response: &spec.Response{
+			Common: &spec.Common{
+				Body:    []byte(`{"id":1}`),
+				Headers: []*spec.Header{{Key: "Content-Type", Value: "application/json"}},
+				Version: "HTTP/1.1",
+			},
+			StatusCode: "201",
+		},
+		Scheme:    "https",
+		Source:    spec.SourceInternal,
+		Timestamp: timestamp,
+		Latency:   time.Second,
+	})
+	assert.NilError(t, telemetry.Validate())
+
+	// the bodies can still be read
+	reqBody, err := ioutil.ReadAll(req.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, string(reqBody), `{"name":"a"}`)
+	respBody, err := ioutil.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, string(respBody), `{"id":1}`)
+}
+
+func TestFromHTTP_server(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/users/1", bytes.NewBufferString("0123456789"))
+	req.Host = "api.example.com:8443"
+	req.TLS = &tls.ConnectionState{}
+	req.RemoteAddr = "10.0.0.2:1234"
+	resp := createResponse(http.StatusOK, "")
+	resp.Body = http.NoBody
+
+	telemetry, err := FromHTTP(req, resp, WithMaxBodySize(4), WithRequestID("id"), WithDestinationAddress("10.0.0.1:8443"))
+	assert.NilError(t, err)
+	assert.Equal(t, telemetry.DestinationAddress, "10.0.0.1:8443")
+	assert.Equal(t, telemetry.SourceAddress, "10.0.0.2:1234")
+	assert.Equal(t, telemetry.Scheme, "https")
+	assert.Equal(t, telemetry.Request.Host, "api.example.com")
+	assert.Equal(t, telemetry.RequestID, "id")
+	assert.Equal(t, string(telemetry.Request.Common.Body), "0123")
+	assert.Equal(t, telemetry.Request.Common.TruncatedBody, true)
+	assert.Assert(t, telemetry.Response.Common.Body == nil)
+
+	// the full body is replayed
+	reqBody, err := ioutil.ReadAll(req.Body)
+	assert.NilError(t, err)
+	assert.Equal(t, string(reqBody), "0123456789")
+}
+
+func TestFromHTTP_truncatedBodies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"na`))
+	resp := createResponse(http.StatusOK, `{"id":1}`)
+
+	telemetry, err := FromHTTP(req, resp, WithTruncatedBodies(true, false))
+	assert.NilError(t, err)
+	assert.Equal(t, telemetry.Request.Common.TruncatedBody, true)
+	assert.Equal(t, telemetry.Response.Common.TruncatedBody, false)
+	assert.Equal(t, string(telemetry.Response.Common.Body), `{"id":1}`)
+}
+
+func TestFromHTTP_invalid(t *testing.T) {
+	_, err := FromHTTP(nil, createResponse(http.StatusOK, ""))
+	assert.ErrorContains(t, err, "required")
+	_, err = FromHTTP(&http.Request{}, createResponse(http.StatusOK, ""))
+	assert.ErrorContains(t, err, "no URL")
+}
@@ -0,0 +1,111 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pcap reassembles the HTTP/1.x streams of pcap/pcapng packet captures and converts them into telemetries.
+package pcap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/google/gopacket/tcpassembly"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+var pcapngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// packetDataSource is implemented by both the pcap and pcapng readers.
+type packetDataSource interface {
+	gopacket.PacketDataSource
+	LinkType() layers.LinkType
+}
+
+// LoadFile reads a pcap or pcapng file and converts its HTTP/1.x interactions into telemetries, see Decode.
+func LoadFile(path string) ([]*spec.Telemetry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v. %v", path, err)
+	}
+	defer file.Close()
+
+	return Decode(file)
+}
+
+// Decode reads a pcap or pcapng capture and converts its HTTP/1.x interactions into telemetries, sorted by capture time.
+// Requests are paired with the responses of their TCP connection in order, so connections whose start was not
+// captured may be paired incorrectly. Encrypted (TLS) traffic and other protocols are ignored.
+func Decode(r io.Reader) ([]*spec.Telemetry, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(pcapngMagic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %v", err)
+	}
+	var source packetDataSource
+	if bytes.Equal(magic, pcapngMagic) {
+		source, err = pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions)
+	} else {
+		source, err = pcapgo.NewReader(br)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture: %v", err)
+	}
+
+	factory := newHTTPStreamFactory()
+	assembler := tcpassembly.NewAssembler(tcpassembly.NewStreamPool(factory))
+	packets := gopacket.NewPacketSource(source, source.LinkType())
+	packets.DecodeOptions.Lazy = true
+	packets.DecodeOptions.NoCopy = true
+	for {
+		packet, err := packets.NextPacket()
+		// a truncated capture is read up to the truncated packet
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			assembler.FlushAll()
+			factory.wait()
+			return nil, fmt.Errorf("failed to read packet: %v", err)
+		}
+		if packet.NetworkLayer() == nil {
+			continue
+		}
+		tcp, ok := packet.TransportLayer().(*layers.TCP)
+		if !ok {
+			continue
+		}
+		assembler.AssembleWithTimestamp(packet.NetworkLayer().NetworkFlow(), tcp, packet.Metadata().Timestamp)
+	}
+	assembler.FlushAll()
+	factory.wait()
+
+	telemetries := factory.getTelemetries()
+	sort.SliceStable(telemetries, func(i, j int) bool {
+		return telemetries[i].Timestamp.Before(telemetries[j].Timestamp)
+	})
+	return telemetries, nil
+}
+
+func getAddress(endpoint, port gopacket.Endpoint) string {
+	return net.JoinHostPort(endpoint.String(), port.String())
+}
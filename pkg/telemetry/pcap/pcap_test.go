@@ -0,0 +1,210 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcap
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"gotest.tools/assert"
+
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+var (
+	clientIP   = net.IP{10, 0, 0, 1}
+	serverIP   = net.IP{10, 0, 0, 2}
+	clientPort = layers.TCPPort(40000)
+	serverPort = layers.TCPPort(8080)
+	startTime  = time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+)
+
+type testPacket struct {
+	fromClient bool
+	syn        bool
+	ack        bool
+	payload    string
+	// offset from startTime
+	offset time.Duration
+}
+
+// createTCPPackets serializes the packets of a single client/server connection.
+func createTCPPackets(t *testing.T, packets []testPacket) ([][]byte, []time.Time) {
+	t.Helper()
+	clientSeq, serverSeq := uint32(1000), uint32(5000)
+	var ret [][]byte
+	var timestamps []time.Time
+	for _, p := range packets {
+		ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: serverIP, DstIP: clientIP}
+		tcp := &layers.TCP{SrcPort: serverPort, DstPort: clientPort, SYN: p.syn, ACK: p.ack, Window: 65535}
+		seq := &serverSeq
+		if p.fromClient {
+			ip.SrcIP, ip.DstIP = clientIP, serverIP
+			tcp.SrcPort, tcp.DstPort = clientPort, serverPort
+			seq = &clientSeq
+		}
+		tcp.Seq = *seq
+		*seq += uint32(len(p.payload))
+		if p.syn {
+			*seq++
+		}
+		assert.NilError(t, tcp.SetNetworkLayerForChecksum(ip))
+
+		buf := gopacket.NewSerializeBuffer()
+		assert.NilError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+			&layers.Ethernet{
+				SrcMAC:       net.HardwareAddr{1, 2, 3, 4, 5, 6},
+				DstMAC:       net.HardwareAddr{6, 5, 4, 3, 2, 1},
+				EthernetType: layers.EthernetTypeIPv4,
+			},
+			ip, tcp, gopacket.Payload(p.payload)))
+		ret = append(ret, buf.Bytes())
+		timestamps = append(timestamps, startTime.Add(p.offset))
+	}
+	return ret, timestamps
+}
+
+var testConnectionPackets = []testPacket{
+	{fromClient: true, syn: true},
+	{syn: true, ack: true},
+	{fromClient: true, ack: true},
+	// request split in two segments
+	{fromClient: true, ack: true, offset: time.Second, payload: "POST /api/users?foo=bar HTTP/1.1\r\nHost: api.example.com\r\n" +
+		"Content-Type: application/json\r\nContent-Length: 14\r\n\r\n"},
+	{fromClient: true, ack: true, offset: time.Second, payload: `{"name":"foo"}`},
+	{ack: true, offset: 2 * time.Second, payload: "HTTP/1.1 100 Continue\r\n\r\n"},
+	{ack: true, offset: 2 * time.Second, payload: "HTTP/1.1 201 Created\r\nContent-Type: application/json\r\nContent-Length: 8\r\n\r\n{\"id\":1}"},
+	// keep alive
+	{fromClient: true, ack: true, offset: 3 * time.Second, payload: "GET /api/users/1 HTTP/1.1\r\nHost: api.example.com:8080\r\n\r\n"},
+	{ack: true, offset: 4 * time.Second, payload: "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"},
+	// request without a response
+	{fromClient: true, ack: true, offset: 5 * time.Second, payload: "GET /api/users/2 HTTP/1.1\r\nHost: api.example.com\r\n\r\n"},
+}
+
+var wantTelemetries = []*spec.Telemetry{
+	{
+		DestinationAddress: "10.0.0.2:8080",
+		Request: &spec.Request{
+			Common: &spec.Common{
+				Body: []byte(`{"name":"foo"}`),
+				Headers: []*spec.Header{
+					{Key: "Content-Length", Value: "14"},
+					{Key: "Content-Type", Value: "application/json"},
+				},
+				Version: "HTTP/1.1",
+			},
+			Host:   "api.example.com",
+			Method: "POST",
+			Path:   "/api/users?foo=bar",
+		},
+		Response: &spec.Response{
+			Common: &spec.Common{
+				Body: []byte(`{"id":1}`),
+				Headers: []*spec.Header{
+					{Key: "Content-Length", Value: "8"},
+					{Key: "Content-Type", Value: "application/json"},
+				},
+				Version: "HTTP/1.1",
+			},
+			StatusCode: "201",
+		},
+		Scheme:        "http",
+		SourceAddress: "10.0.0.1:40000",
+		Timestamp:     startTime.Add(time.Second),
+		Latency:       time.Second,
+	},
+	{
+		DestinationAddress: "10.0.0.2:8080",
+		Request: &spec.Request{
+			Common: &spec.Common{
+				Version: "HTTP/1.1",
+			},
+			Host:   "api.example.com",
+			Method: "GET",
+			Path:   "/api/users/1",
+		},
+		Response: &spec.Response{
+			Common: &spec.Common{
+				Headers: []*spec.Header{
+					{Key: "Content-Length", Value: "0"},
+				},
+				Version: "HTTP/1.1",
+			},
+			StatusCode: "404",
+		},
+		Scheme:        "http",
+		SourceAddress: "10.0.0.1:40000",
+		Timestamp:     startTime.Add(3 * time.Second),
+		Latency:       time.Second,
+	},
+}
+
+func TestDecode_Pcap(t *testing.T) {
+	packets, timestamps := createTCPPackets(t, testConnectionPackets)
+	buf := &bytes.Buffer{}
+	w := pcapgo.NewWriter(buf)
+	assert.NilError(t, w.WriteFileHeader(65536, layers.LinkTypeEthernet))
+	for i, packet := range packets {
+		assert.NilError(t, w.WritePacket(gopacket.CaptureInfo{Timestamp: timestamps[i], CaptureLength: len(packet), Length: len(packet)}, packet))
+	}
+
+	telemetries, err := Decode(buf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, telemetries, wantTelemetries)
+}
+
+func TestDecode_Pcapng(t *testing.T) {
+	packets, timestamps := createTCPPackets(t, testConnectionPackets)
+	buf := &bytes.Buffer{}
+	w, err := pcapgo.NewNgWriter(buf, layers.LinkTypeEthernet)
+	assert.NilError(t, err)
+	for i, packet := range packets {
+		assert.NilError(t, w.WritePacket(gopacket.CaptureInfo{Timestamp: timestamps[i], CaptureLength: len(packet), Length: len(packet)}, packet))
+	}
+	assert.NilError(t, w.Flush())
+
+	telemetries, err := Decode(buf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, telemetries, wantTelemetries)
+}
+
+func TestDecode_NotHTTP(t *testing.T) {
+	packets, timestamps := createTCPPackets(t, []testPacket{
+		{fromClient: true, syn: true},
+		{syn: true, ack: true},
+		{fromClient: true, ack: true, payload: "\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03"},
+	})
+	buf := &bytes.Buffer{}
+	w := pcapgo.NewWriter(buf)
+	assert.NilError(t, w.WriteFileHeader(65536, layers.LinkTypeEthernet))
+	for i, packet := range packets {
+		assert.NilError(t, w.WritePacket(gopacket.CaptureInfo{Timestamp: timestamps[i], CaptureLength: len(packet), Length: len(packet)}, packet))
+	}
+
+	telemetries, err := Decode(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, len(telemetries), 0)
+}
+
+func TestDecode_InvalidCapture(t *testing.T) {
+	_, err := Decode(bytes.NewReader([]byte("not a capture file")))
+	assert.Assert(t, err != nil)
+}
@@ -0,0 +1,329 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcap
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/tcpassembly"
+	"github.com/google/gopacket/tcpassembly/tcpreader"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/internal/httpcapture"
+	"github.com/apiclarity/speculator/pkg/spec"
+)
+
+// the maximum body bytes captured per message, larger bodies are marked as truncated
+const maxBodySize = 1 << 20 // 1 MB
+
+var responsePrefix = []byte("HTTP/")
+
+type capturedMessage struct {
+	common *spec.Common
+	seen   time.Time
+	// request only
+	method string
+	host   string
+	path   string
+	// response only
+	statusCode int
+}
+
+// connection holds the messages of a TCP connection, keyed by its client -> server flows.
+type connection struct {
+	clientAddress string
+	serverAddress string
+	requests      []*capturedMessage
+	responses     []*capturedMessage
+}
+
+type httpStreamFactory struct {
+	wg          sync.WaitGroup
+	lock        sync.Mutex
+	connections map[string]*connection
+}
+
+func newHTTPStreamFactory() *httpStreamFactory {
+	return &httpStreamFactory{
+		connections: make(map[string]*connection),
+	}
+}
+
+func (f *httpStreamFactory) New(netFlow, tcpFlow gopacket.Flow) tcpassembly.Stream {
+	stream := &httpStream{
+		ReaderStream: tcpreader.NewReaderStream(),
+		netFlow:      netFlow,
+		tcpFlow:      tcpFlow,
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.readStream(stream)
+	}()
+	return stream
+}
+
+func (f *httpStreamFactory) wait() {
+	f.wg.Wait()
+}
+
+// readStream reads the HTTP messages of a stream, a stream is a request stream unless it starts with a response.
+func (f *httpStreamFactory) readStream(stream *httpStream) {
+	// the stream must be fully read so the assembler doesn't block
+	defer tcpreader.DiscardBytesToEOF(stream)
+
+	counter := &countingReader{r: stream}
+	br := bufio.NewReader(counter)
+	prefix, err := br.Peek(len(responsePrefix))
+	if err != nil {
+		return
+	}
+	isResponse := bytes.Equal(prefix, responsePrefix)
+
+	var messages []*capturedMessage
+	for {
+		// wait for the next message so its capture time is known
+		if _, err := br.Peek(1); err != nil {
+			break
+		}
+		seen := stream.getSeen(counter.n - int64(br.Buffered()))
+		var message *capturedMessage
+		if isResponse {
+			message, err = readResponse(br)
+		} else {
+			message, err = readRequest(br)
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Debugf("Stopped reading HTTP stream %v %v: %v", stream.netFlow, stream.tcpFlow, err)
+			}
+			break
+		}
+		if message == nil {
+			continue
+		}
+		message.seen = seen
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	netFlow, tcpFlow := stream.netFlow, stream.tcpFlow
+	if isResponse {
+		netFlow, tcpFlow = netFlow.Reverse(), tcpFlow.Reverse()
+	}
+	f.addMessages(netFlow, tcpFlow, isResponse, messages)
+}
+
+func (f *httpStreamFactory) addMessages(netFlow, tcpFlow gopacket.Flow, isResponse bool, messages []*capturedMessage) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	key := netFlow.String() + " " + tcpFlow.String()
+	conn, ok := f.connections[key]
+	if !ok {
+		src, dst := netFlow.Endpoints()
+		srcPort, dstPort := tcpFlow.Endpoints()
+		conn = &connection{
+			clientAddress: getAddress(src, srcPort),
+			serverAddress: getAddress(dst, dstPort),
+		}
+		f.connections[key] = conn
+	}
+	if isResponse {
+		conn.responses = append(conn.responses, messages...)
+	} else {
+		conn.requests = append(conn.requests, messages...)
+	}
+}
+
+// getTelemetries pairs the requests and responses of each connection, requests without a response are dropped.
+func (f *httpStreamFactory) getTelemetries() []*spec.Telemetry {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	// sorted for deterministic results
+	keys := make([]string, 0, len(f.connections))
+	for key := range f.connections {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var telemetries []*spec.Telemetry
+	for _, key := range keys {
+		conn := f.connections[key]
+		for i, req := range conn.requests {
+			if i >= len(conn.responses) {
+				log.Debugf("Dropping %v requests without a response to %v", len(conn.requests)-i, conn.serverAddress)
+				break
+			}
+			resp := conn.responses[i]
+			telemetry := &spec.Telemetry{
+				DestinationAddress: conn.serverAddress,
+				Request: &spec.Request{
+					Common: req.common,
+					Host:   req.host,
+					Method: req.method,
+					Path:   req.path,
+				},
+				Response: &spec.Response{
+					Common:     resp.common,
+					StatusCode: strconv.Itoa(resp.statusCode),
+				},
+				Scheme:        "http",
+				SourceAddress: conn.clientAddress,
+				Timestamp:     req.seen,
+			}
+			// the time to the first response packet
+			if latency := resp.seen.Sub(req.seen); latency > 0 {
+				telemetry.Latency = latency
+			}
+			telemetries = append(telemetries, telemetry)
+		}
+	}
+	return telemetries
+}
+
+func readRequest(br *bufio.Reader) (*capturedMessage, error) {
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return nil, err
+	}
+	common, err := readCommon(req.Body, req.Header, req.Proto)
+	if err != nil {
+		return nil, err
+	}
+	return &capturedMessage{
+		common: common,
+		method: req.Method,
+		host:   httpcapture.HostWithoutPort(req.Host),
+		path:   req.RequestURI,
+	}, nil
+}
+
+// readResponse reads the next response, informational (1xx) responses are skipped (returned as nil).
+// Responses are read without their request, so bodiless responses to HEAD requests can't be told apart.
+func readResponse(br *bufio.Reader) (*capturedMessage, error) {
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return nil, err
+	}
+	common, err := readCommon(resp.Body, resp.Header, resp.Proto)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusContinue && resp.StatusCode < http.StatusOK {
+		return nil, nil
+	}
+	return &capturedMessage{
+		common:     common,
+		statusCode: resp.StatusCode,
+	}, nil
+}
+
+func readCommon(body io.ReadCloser, header http.Header, proto string) (*spec.Common, error) {
+	defer body.Close()
+
+	common := &spec.Common{
+		Headers: httpcapture.ConvertHeaders(header),
+		Version: proto,
+	}
+
+	bodyB, err := ioutil.ReadAll(io.LimitReader(body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(bodyB) > maxBodySize {
+		// the rest of the body is read so the next message can be read
+		if _, err := io.Copy(ioutil.Discard, body); err != nil {
+			return nil, err
+		}
+		bodyB = bodyB[:maxBodySize]
+		common.TruncatedBody = true
+	}
+	if len(bodyB) > 0 {
+		common.Body = bodyB
+	}
+	return common, nil
+}
+
+// httpStream is a reassembled TCP stream, that keeps the capture time of the stream offsets.
+type httpStream struct {
+	tcpreader.ReaderStream
+	netFlow gopacket.Flow
+	tcpFlow gopacket.Flow
+
+	lock     sync.Mutex
+	length   int64
+	segments []segment
+}
+
+type segment struct {
+	offset int64
+	seen   time.Time
+}
+
+func (s *httpStream) Reassembled(reassemblies []tcpassembly.Reassembly) {
+	s.lock.Lock()
+	for _, reassembly := range reassemblies {
+		if len(reassembly.Bytes) == 0 {
+			continue
+		}
+		s.segments = append(s.segments, segment{offset: s.length, seen: reassembly.Seen})
+		s.length += int64(len(reassembly.Bytes))
+	}
+	s.lock.Unlock()
+
+	// blocks until the bytes are read
+	s.ReaderStream.Reassembled(reassemblies)
+}
+
+// getSeen returns the capture time of the byte at offset.
+func (s *httpStream) getSeen(offset int64) time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	i := sort.Search(len(s.segments), func(i int) bool {
+		return s.segments[i].offset > offset
+	})
+	if i == 0 {
+		if len(s.segments) == 0 {
+			return time.Time{}
+		}
+		i = 1
+	}
+	return s.segments[i-1].seen.UTC()
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -15,6 +15,34 @@
 
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var ErrSpecValidation = errors.New("spec validation failed")
+
+var ErrShutdown = errors.New("speculator is shut down")
+
+var ErrTelemetryDropped = errors.New("telemetry was dropped")
+
+var ErrUnsupportedMethod = errors.New("unsupported method")
+
+var ErrBodyParse = errors.New("failed to parse body")
+
+var ErrRecoveredPanic = errors.New("recovered from panic")
+
+// PanicError is a panic recovered into an error, it wraps ErrRecoveredPanic.
+type PanicError struct {
+	// Value is the value the code panicked with
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrRecoveredPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrRecoveredPanic
+}
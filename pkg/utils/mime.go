@@ -22,3 +22,20 @@ func IsApplicationJSONMediaType(mediaType string) bool {
 	return strings.HasPrefix(mediaType, "application/") &&
 		strings.HasSuffix(mediaType, "json")
 }
+
+// IsXMLMediaType will return true if mediaType is an XML media type (application/xml, text/xml, application/soap+xml...)
+func IsXMLMediaType(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+xml"))
+}
+
+// IsProtobufMediaType will return true if mediaType is a protobuf media type (application/x-protobuf, application/protobuf...)
+func IsProtobufMediaType(mediaType string) bool {
+	return mediaType == "application/x-protobuf" || mediaType == "application/protobuf" ||
+		mediaType == "application/vnd.google.protobuf" || IsGRPCMediaType(mediaType)
+}
+
+// IsGRPCMediaType will return true if mediaType is a gRPC media type with protobuf messages (application/grpc, application/grpc+proto)
+func IsGRPCMediaType(mediaType string) bool {
+	return mediaType == "application/grpc" || mediaType == "application/grpc+proto"
+}
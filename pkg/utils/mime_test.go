@@ -63,3 +63,95 @@ func TestIsApplicationJsonMediaType(t *testing.T) {
 		})
 	}
 }
+
+func TestIsXMLMediaType(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		want      bool
+	}{
+		{
+			name:      "application/xml",
+			mediaType: "application/xml",
+			want:      true,
+		},
+		{
+			name:      "text/xml",
+			mediaType: "text/xml",
+			want:      true,
+		},
+		{
+			name:      "application/soap+xml",
+			mediaType: "application/soap+xml",
+			want:      true,
+		},
+		{
+			name:      "application json",
+			mediaType: "application/json",
+			want:      false,
+		},
+		{
+			name:      "text xml suffix",
+			mediaType: "text/html+xml",
+			want:      false,
+		},
+		{
+			name:      "empty mediaType",
+			mediaType: "",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsXMLMediaType(tt.mediaType); got != tt.want {
+				t.Errorf("IsXMLMediaType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsProtobufMediaType(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		want      bool
+	}{
+		{
+			name:      "application/x-protobuf",
+			mediaType: "application/x-protobuf",
+			want:      true,
+		},
+		{
+			name:      "application/protobuf",
+			mediaType: "application/protobuf",
+			want:      true,
+		},
+		{
+			name:      "application/grpc",
+			mediaType: "application/grpc",
+			want:      true,
+		},
+		{
+			name:      "application/grpc+proto",
+			mediaType: "application/grpc+proto",
+			want:      true,
+		},
+		{
+			name:      "grpc with json messages",
+			mediaType: "application/grpc+json",
+			want:      false,
+		},
+		{
+			name:      "empty mediaType",
+			mediaType: "",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsProtobufMediaType(tt.mediaType); got != tt.want {
+				t.Errorf("IsProtobufMediaType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
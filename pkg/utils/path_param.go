@@ -26,3 +26,31 @@ func IsPathParam(segment string) bool {
 	return strings.HasPrefix(segment, ParamPrefix) &&
 		strings.HasSuffix(segment, ParamSuffix)
 }
+
+// GetPathParamName returns the param name of a path param segment, e.g. {param1} will return param1.
+func GetPathParamName(segment string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(segment, ParamPrefix), ParamSuffix)
+}
+
+// GetPathParamValues returns the values of parameterizedPath params in path,
+// e.g. /api/{param1}/foo and /api/1/foo will return param1 -> 1.
+// Returns false if path does not match parameterizedPath.
+func GetPathParamValues(parameterizedPath, path string) (map[string]string, bool) {
+	ret := make(map[string]string)
+
+	parameterizedSegments := strings.Split(parameterizedPath, "/")
+	segments := strings.Split(path, "/")
+	if len(parameterizedSegments) != len(segments) {
+		return nil, false
+	}
+
+	for i, segment := range parameterizedSegments {
+		if IsPathParam(segment) {
+			ret[GetPathParamName(segment)] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return ret, true
+}
@@ -0,0 +1,82 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPathParamValues(t *testing.T) {
+	type args struct {
+		parameterizedPath string
+		path              string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		want  map[string]string
+		want1 bool
+	}{
+		{
+			name: "multiple params",
+			args: args{
+				parameterizedPath: "/api/{param1}/items/{param2}",
+				path:              "/api/1/items/2",
+			},
+			want:  map[string]string{"param1": "1", "param2": "2"},
+			want1: true,
+		},
+		{
+			name: "no params",
+			args: args{
+				parameterizedPath: "/api/items",
+				path:              "/api/items",
+			},
+			want:  map[string]string{},
+			want1: true,
+		},
+		{
+			name: "segments count mismatch",
+			args: args{
+				parameterizedPath: "/api/{param1}",
+				path:              "/api/1/items",
+			},
+			want:  nil,
+			want1: false,
+		},
+		{
+			name: "literal segment mismatch",
+			args: args{
+				parameterizedPath: "/api/{param1}/items",
+				path:              "/api/1/users",
+			},
+			want:  nil,
+			want1: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1 := GetPathParamValues(tt.args.parameterizedPath, tt.args.path)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPathParamValues() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("GetPathParamValues() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
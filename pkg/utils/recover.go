@@ -0,0 +1,34 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+// RecoverPanic recovers a panic into *err (an *errors.PanicError) and logs its stack trace,
+// so a single malformed input fails on its own instead of taking down the process. Must be deferred directly.
+func RecoverPanic(err *error) {
+	if r := recover(); r != nil {
+		stack := debug.Stack()
+		log.Errorf("Recovered from panic: %v\n%s", r, stack)
+		*err = &errors.PanicError{Value: r, Stack: stack}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	_errors "github.com/apiclarity/speculator/pkg/utils/errors"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func()
+		wantPanic bool
+	}{
+		{
+			name: "no panic",
+			fn:   func() {},
+		},
+		{
+			name: "nil pointer",
+			fn: func() {
+				var m *struct{ a int }
+				m.a = 1
+			},
+			wantPanic: true,
+		},
+		{
+			name: "index out of range",
+			fn: func() {
+				var s []int
+				_ = s[len(s)]
+			},
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := func() (err error) {
+				defer RecoverPanic(&err)
+				tt.fn()
+				return nil
+			}()
+			if got := errors.Is(err, _errors.ErrRecoveredPanic); got != tt.wantPanic {
+				t.Errorf("RecoverPanic() error = %v, wantPanic %v", err, tt.wantPanic)
+			}
+			var panicErr *_errors.PanicError
+			if got := errors.As(err, &panicErr); got != tt.wantPanic {
+				t.Errorf("RecoverPanic() error = %T, want *PanicError %v", err, tt.wantPanic)
+			}
+			if tt.wantPanic && (panicErr.Value == nil || len(panicErr.Stack) == 0) {
+				t.Errorf("RecoverPanic() PanicError = %+v, want value and stack", panicErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package uuid is the UUID type of the specs, diffs and approved paths. IDs are generated by a Generator, which
+// defaults to github.com/google/uuid and can be replaced to supply IDs from an external source, see SetGenerator.
+// A UUID is encoded (as text and as binary) like github.com/satori/go.uuid UUIDs, so states and diffs encoded
+// before are decoded unchanged.
+package uuid
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	google_uuid "github.com/google/uuid"
+)
+
+// Size of a UUID in bytes.
+const Size = 16
+
+const urnPrefix = "urn:uuid:"
+
+// UUID is a RFC 4122 UUID.
+type UUID [Size]byte
+
+// Nil is the zero UUID.
+var Nil = UUID{}
+
+// Generator generates UUIDs.
+type Generator interface {
+	// NewRandom returns a new random UUID
+	NewRandom() UUID
+	// NewFromName returns the UUID of name, it returns the same UUID for the same name
+	NewFromName(name string) UUID
+}
+
+type googleGenerator struct{}
+
+func (googleGenerator) NewRandom() UUID {
+	return UUID(google_uuid.New())
+}
+
+// NewFromName returns a version 5 UUID of name in the Nil namespace.
+func (googleGenerator) NewFromName(name string) UUID {
+	return UUID(google_uuid.NewSHA1(google_uuid.Nil, []byte(name)))
+}
+
+var (
+	generatorLock sync.RWMutex
+	generator     Generator = googleGenerator{}
+)
+
+// SetGenerator replaces the generator of New and NewFromName, nil restores the default generator.
+func SetGenerator(g Generator) {
+	generatorLock.Lock()
+	defer generatorLock.Unlock()
+
+	if g == nil {
+		g = googleGenerator{}
+	}
+	generator = g
+}
+
+func getGenerator() Generator {
+	generatorLock.RLock()
+	defer generatorLock.RUnlock()
+
+	return generator
+}
+
+// New returns a new random UUID.
+func New() UUID {
+	return getGenerator().NewRandom()
+}
+
+// NewFromName returns the UUID of name, see Generator.NewFromName.
+func NewFromName(name string) UUID {
+	return getGenerator().NewFromName(name)
+}
+
+// FromString parses a UUID in the canonical format "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+// the hash like format "6ba7b8109dad11d180b400c04fd430c8", the braced format "{6ba7b810-9dad-11d1-80b4-00c04fd430c8}"
+// or the URN format "urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8" (canonical or hash like).
+func FromString(s string) (UUID, error) {
+	u := UUID{}
+	if err := u.UnmarshalText([]byte(s)); err != nil {
+		return Nil, err
+	}
+	return u, nil
+}
+
+// String returns the canonical format of the UUID.
+func (u UUID) String() string {
+	buf := make([]byte, 36)
+
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf)
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText parses the formats of FromString.
+func (u *UUID) UnmarshalText(text []byte) error {
+	s := string(text)
+	switch len(s) {
+	case 32, 36:
+		return u.decodePlain(s)
+	case 38:
+		if s[0] != '{' || s[37] != '}' {
+			return fmt.Errorf("uuid: incorrect UUID format %s", s)
+		}
+		return u.decodePlain(s[1:37])
+	case 41, 45:
+		if !strings.HasPrefix(s, urnPrefix) {
+			return fmt.Errorf("uuid: incorrect UUID format %s", s)
+		}
+		return u.decodePlain(s[len(urnPrefix):])
+	default:
+		return fmt.Errorf("uuid: incorrect UUID length: %s", s)
+	}
+}
+
+// decodePlain decodes the canonical or the hash like format.
+func (u *UUID) decodePlain(s string) error {
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return fmt.Errorf("uuid: incorrect UUID format %s", s)
+		}
+		s = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return fmt.Errorf("uuid: incorrect UUID length: %s", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return fmt.Errorf("uuid: incorrect UUID format %s: %v", s, err)
+	}
+	return nil
+}
+
+func (u UUID) MarshalBinary() ([]byte, error) {
+	return u[:], nil
+}
+
+func (u *UUID) UnmarshalBinary(data []byte) error {
+	if len(data) != Size {
+		return fmt.Errorf("uuid: UUID must be exactly %d bytes long, got %d bytes", Size, len(data))
+	}
+	copy(u[:], data)
+	return nil
+}
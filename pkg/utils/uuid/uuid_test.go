@@ -0,0 +1,179 @@
+// Copyright © 2021 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uuid
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+const canonical = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+func TestFromString(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		wantErr bool
+	}{
+		{name: "canonical", s: canonical},
+		{name: "hash like", s: "6ba7b8109dad11d180b400c04fd430c8"},
+		{name: "upper case", s: "6BA7B810-9DAD-11D1-80B4-00C04FD430C8"},
+		{name: "braced", s: "{" + canonical + "}"},
+		{name: "urn", s: "urn:uuid:" + canonical},
+		{name: "urn hash like", s: "urn:uuid:6ba7b8109dad11d180b400c04fd430c8"},
+		{name: "braced hash like", s: "{6ba7b8109dad11d180b400c04fd430c8}", wantErr: true},
+		{name: "misplaced dashes", s: "6ba7b810d-9ad-11d1-80b4-00c04fd430c8", wantErr: true},
+		{name: "not hex", s: "6ba7b810-9dad-11d1-80b4-00c04fd430cg", wantErr: true},
+		{name: "bad braces", s: "(" + canonical + ")", wantErr: true},
+		{name: "bad urn prefix", s: "urn:uid::" + canonical, wantErr: true},
+		{name: "short", s: "6ba7b810", wantErr: true},
+		{name: "empty", s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromString(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != canonical {
+				t.Errorf("FromString() = %v, want %v", got, canonical)
+			}
+		})
+	}
+}
+
+func TestNewFromName(t *testing.T) {
+	// version 5 UUIDs in the Nil namespace, as generated by github.com/satori/go.uuid NewV5
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "spec-id", want: "a3c20367-255d-5f45-ace9-9025c17c9df1"},
+		{name: "req-id", want: "6c6e5f95-c357-5b2c-b2dd-770c87303ef7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewFromName(tt.name).String(); got != tt.want {
+				t.Errorf("NewFromName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	first := New()
+	second := New()
+	if first == Nil || first == second {
+		t.Errorf("New() should return distinct random UUIDs, got %v and %v", first, second)
+	}
+	if version := first[6] >> 4; version != 4 {
+		t.Errorf("New() version = %v, want 4", version)
+	}
+}
+
+type fixedGenerator struct {
+	id UUID
+}
+
+func (g fixedGenerator) NewRandom() UUID {
+	return g.id
+}
+
+func (g fixedGenerator) NewFromName(string) UUID {
+	return g.id
+}
+
+func TestSetGenerator(t *testing.T) {
+	id, err := FromString(canonical)
+	if err != nil {
+		t.Fatalf("FromString() error = %v", err)
+	}
+	SetGenerator(fixedGenerator{id: id})
+	defer SetGenerator(nil)
+
+	if got := New(); got != id {
+		t.Errorf("New() = %v, want %v", got, id)
+	}
+	if got := NewFromName("spec-id"); got != id {
+		t.Errorf("NewFromName() = %v, want %v", got, id)
+	}
+
+	SetGenerator(nil)
+	if got := NewFromName("spec-id").String(); got != "a3c20367-255d-5f45-ace9-9025c17c9df1" {
+		t.Errorf("NewFromName() of the default generator = %v", got)
+	}
+}
+
+func TestUUID_JSON(t *testing.T) {
+	type object struct {
+		ID UUID
+	}
+	id, err := FromString(canonical)
+	if err != nil {
+		t.Fatalf("FromString() error = %v", err)
+	}
+
+	objectB, err := json.Marshal(&object{ID: id})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"ID":"` + canonical + `"}`; string(objectB) != want {
+		t.Errorf("json.Marshal() = %s, want %s", objectB, want)
+	}
+	got := &object{}
+	if err := json.Unmarshal(objectB, got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got.ID != id {
+		t.Errorf("json.Unmarshal() = %v, want %v", got.ID, id)
+	}
+}
+
+// TestUUID_GobLegacy decodes a gob stream of struct{ ID uuid.UUID; Name string } encoded with a
+// github.com/satori/go.uuid UUID, as stored in states encoded before this package was added.
+func TestUUID_GobLegacy(t *testing.T) {
+	type object struct {
+		ID   UUID
+		Name string
+	}
+	legacyB, err := hex.DecodeString("1f7f030101015301ff800001020102494401ff820001044e616d65010c00000010ff81060101045555494401ff8200000018ff8001106ba7b8109dad11d180b400c04fd430c801016e00")
+	if err != nil {
+		t.Fatalf("failed to decode hex: %v", err)
+	}
+
+	got := &object{}
+	if err := gob.NewDecoder(bytes.NewReader(legacyB)).Decode(got); err != nil {
+		t.Fatalf("failed to decode legacy gob: %v", err)
+	}
+	if got.ID.String() != canonical || got.Name != "n" {
+		t.Errorf("decoded %+v, want ID %v and Name n", got, canonical)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(got); err != nil {
+		t.Fatalf("failed to encode gob: %v", err)
+	}
+	decoded := &object{}
+	if err := gob.NewDecoder(&buf).Decode(decoded); err != nil {
+		t.Fatalf("failed to decode gob: %v", err)
+	}
+	if *decoded != *got {
+		t.Errorf("decoded %+v, want %+v", decoded, got)
+	}
+}